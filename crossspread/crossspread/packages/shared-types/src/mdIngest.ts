@@ -0,0 +1,571 @@
+// Code generated by md-ingest cmd/mdschema (schema v1). DO NOT EDIT.
+
+export const MD_SCHEMA_VERSION = 1;
+
+export interface Action {
+  exchange: string;
+  symbol: string;
+  canonical: string;
+  kind: string;
+  fraction?: number;
+  paying_side: string;
+  position?: number;
+  predicted_rate: number;
+  settles_at: string;
+  at: string;
+}
+
+export interface Alert {
+  exchange: string;
+  channel: string;
+  kind: string;
+  rate: number;
+  baseline: number;
+  symbols?: number;
+  since: string;
+  at: string;
+}
+
+export interface BBO {
+  exchange: string;
+  symbol: string;
+  canonical: string;
+  bid_price: number;
+  bid_size: number;
+  ask_price: number;
+  ask_size: number;
+  timestamp: string;
+  received_at: string;
+  published_at: string;
+}
+
+export interface Bar {
+  exchange?: string;
+  symbol?: string;
+  canonical: string;
+  interval: string;
+  open: number;
+  high: number;
+  low: number;
+  close: number;
+  volume: number;
+  quote_volume: number;
+  buy_volume: number;
+  vwap: number;
+  trades: number;
+  start: string;
+  end: string;
+}
+
+export interface Leg {
+  exchange: string;
+  symbol: string;
+  market: string;
+  side: string;
+  price: number;
+  size: number;
+  depth_usd: number;
+  funding?: number;
+  multiplier?: number;
+  quote?: string;
+}
+
+export interface BasisOpportunity {
+  id: string;
+  canonical: string;
+  exchange: string;
+  direction: string;
+  kind: string;
+  legs: Leg[];
+  spot_symbol: string;
+  perp_symbol: string;
+  spot_price: number;
+  perp_price: number;
+  basis_bps: number;
+  funding: number;
+  spot_depth_usd: number;
+  perp_depth_usd: number;
+  min_depth_usd: number;
+  breakeven_bps: number;
+  net_edge_bps: number;
+  profitable: boolean;
+  updated_at: string;
+  event_time: string;
+}
+
+export interface BasisSummary {
+  timestamp: string;
+  count: number;
+  basis: BasisOpportunity[];
+}
+
+export interface DeRiskState {
+  active: boolean;
+  action?: string;
+  size_factor: number;
+  drawdown_usd?: number;
+  peak_usd?: number;
+  pnl_usd?: number;
+  strategies?: Record<string, unknown>;
+  tripped_at?: string;
+  acknowledged_by?: string;
+  acknowledged_at?: string;
+}
+
+export interface Filter {
+  events?: string[];
+  canonicals?: string[];
+  exchanges?: string[];
+  min_spread_bps?: number;
+  min_net_edge_bps?: number;
+  profitable_only?: boolean;
+}
+
+export interface Endpoint {
+  id: string;
+  url: string;
+  secret?: string;
+  filter: Filter;
+  created_by?: string;
+  created_at: string;
+}
+
+export interface ThresholdTime {
+  bps: number;
+  ms: number;
+}
+
+export interface Episode {
+  id: string;
+  canonical: string;
+  long_exchange: string;
+  short_exchange: string;
+  start: string;
+  duration_ms: number;
+  peak_bps: number;
+  min_after_peak_bps: number;
+  above_ms: ThresholdTime[];
+}
+
+export interface Event {
+  exchange: string;
+  symbol: string;
+  canonical: string;
+  kind: string;
+  open_interest: number;
+  previous: number;
+  change_pct: number;
+  window: string;
+  at: string;
+}
+
+export interface FundingRate {
+  exchange_id: string;
+  symbol: string;
+  canonical: string;
+  funding_rate: number;
+  mark_price?: number;
+  next_funding_time: string;
+  funding_interval_hours: number;
+  timestamp: string;
+  interval_source?: string;
+  funding_rate_8h: number;
+  funding_apr: number;
+}
+
+export interface Health {
+  exchange: string;
+  state: string;
+  reason?: string;
+  samples: number;
+  error_rate: number;
+  p90_latency_ms: number;
+  rate_factor: number;
+  quoting_allowed: boolean;
+  since: string;
+  updated_at: string;
+}
+
+export interface Heartbeat {
+  channel: string;
+  producer?: string;
+  seq: number;
+  beat: number;
+  interval_ms: number;
+  last_published_at?: string;
+  timestamp: string;
+}
+
+export interface IndexConstituent {
+  exchange: string;
+  price: number;
+  volume_24h: number;
+  weight: number;
+  deviation_bps: number;
+  included: boolean;
+  stale: boolean;
+  outlier: boolean;
+  updated_at: string;
+}
+
+export interface IndexPrice {
+  canonical: string;
+  price: number;
+  included: number;
+  constituents: IndexConstituent[];
+  timestamp: string;
+}
+
+export interface MigrationFlag {
+  id: string;
+  canonical: string;
+  side: string;
+  intended: string;
+  actual: string;
+  symbol: string;
+  quantity: number;
+  avg_price: number;
+  at: string;
+}
+
+export interface PriceLevel {
+  price: number;
+  quantity: number;
+  notional_usd?: number;
+}
+
+export interface Orderbook {
+  exchange_id: string;
+  symbol: string;
+  canonical: string;
+  market?: string;
+  bids: PriceLevel[];
+  asks: PriceLevel[];
+  best_bid: number;
+  best_ask: number;
+  spread_bps: number;
+  timestamp: string;
+  sequence_id?: number;
+  is_snapshot: boolean;
+  approximate?: boolean;
+  aggregation?: number;
+  received_at: string;
+  normalized_at: string;
+  published_at: string;
+}
+
+export interface PairCorrelation {
+  a: string;
+  b: string;
+  correlation: number;
+}
+
+export interface PairMute {
+  id: string;
+  long?: string;
+  short?: string;
+  reason?: string;
+  by: string;
+  at: string;
+  until?: string;
+}
+
+export interface Settlement {
+  exchange: string;
+  symbol: string;
+  canonical: string;
+  stage: string;
+  predicted_rate: number;
+  settles_at: string;
+  interval_hours?: number;
+  at: string;
+}
+
+export interface SpreadCluster {
+  pairs: string[];
+  common?: string[];
+  avg_correlation: number;
+  min_correlation: number;
+  episodes: number;
+  avg_peak_bps: number;
+  symbols?: string[];
+}
+
+export interface SpreadCorrelation {
+  start: string;
+  end: string;
+  bucket: string;
+  pairs: string[];
+  correlations: PairCorrelation[];
+  clusters: SpreadCluster[];
+  generated_at: string;
+}
+
+export interface SpreadOpportunity {
+  id: string;
+  canonical: string;
+  kind: string;
+  legs: Leg[];
+  long_exchange: string;
+  short_exchange: string;
+  long_symbol: string;
+  short_symbol: string;
+  long_price: number;
+  short_price: number;
+  spread_percent: number;
+  spread_bps: number;
+  sized_spread_bps?: number;
+  long_funding: number;
+  short_funding: number;
+  net_funding: number;
+  long_depth_usd: number;
+  short_depth_usd: number;
+  min_depth_usd: number;
+  volume_24h: number;
+  score: number;
+  quality: number;
+  quote_only: boolean;
+  latency_ms: number;
+  breakeven_bps: number;
+  net_edge_bps: number;
+  profitable: boolean;
+  tags?: string[];
+  skew_usd?: number;
+  updated_at: string;
+  event_time: string;
+  long_quote_age_ms: number;
+  short_quote_age_ms: number;
+  effective_edge_bps: number;
+  rebalance_seconds?: number;
+}
+
+export interface SpreadSummary {
+  timestamp: string;
+  count: number;
+  top_10: SpreadOpportunity[];
+  spreads: SpreadOpportunity[];
+}
+
+export interface Tag {
+  opportunity_id: string;
+  canonical: string;
+  exchanges: string[];
+  reason: string;
+  source: string;
+  note?: string;
+  at: string;
+}
+
+export interface TenantSpreadSummary {
+  tenant: string;
+  timestamp: string;
+  count: number;
+  top_10: SpreadOpportunity[];
+  spreads: SpreadOpportunity[];
+}
+
+export interface Trade {
+  exchange_id: string;
+  symbol: string;
+  canonical: string;
+  trade_id: string;
+  price: number;
+  quantity: number;
+  side: string;
+  timestamp: string;
+  notional_usd?: number;
+  side_inferred?: boolean;
+  received_at: string;
+  normalized_at: string;
+  published_at: string;
+}
+
+export interface TransferSample {
+  id: string;
+  seconds: number;
+  at: string;
+}
+
+export interface TransferLatency {
+  asset: string;
+  chain: string;
+  from?: string;
+  to?: string;
+  samples: TransferSample[];
+  p50_seconds: number;
+  p90_seconds: number;
+  max_seconds: number;
+  updated_at: string;
+}
+
+export interface Transition {
+  exchange: string;
+  symbol: string;
+  canonical?: string;
+  from: string;
+  to: string;
+  source: string;
+  reason?: string;
+  at: string;
+}
+
+export interface VenueEquity {
+  balance_usd: number;
+  unrealized_pnl_usd: number;
+  updated_at: string;
+}
+
+export interface VenueSubscriptions {
+  exchange: string;
+  symbols: string[];
+  channels: string[];
+  updated_at: string;
+}
+
+// Key and channel names, within the namespace set by setMdKeyPrefix
+let mdKeyPrefix = '';
+
+/** Namespace every key and channel like md-ingest's REDIS_KEY_PREFIX: {instance} expands to the instance ID (INSTANCE_ID) and a missing trailing colon is added */
+export function setMdKeyPrefix(prefix: string, instance = 'default'): void {
+  prefix = prefix.replaceAll('{instance}', instance);
+  if (prefix !== '' && !prefix.endsWith(':')) {
+    prefix += ':';
+  }
+  mdKeyPrefix = prefix;
+}
+
+/** Returns a key or channel name within the namespace */
+export function mdKey(name: string): string {
+  return mdKeyPrefix + name;
+}
+
+export const MdKeys = {
+  /** Orderbook snapshots/updates per exchange-native symbol (approximate trim); snapshots carry at most the configured publish depth (1/5/20/full) (stream, payload Orderbook) */
+  orderbookStream: (exchange: string, symbol: string): string => mdKey(`orderbook:${exchange}:${symbol}`),
+  /** Real-time orderbook updates, same payload as the stream (pubsub, payload Orderbook) */
+  orderbookChannel: (exchange: string, symbol: string): string => mdKey(`orderbook:${exchange}:${symbol}`),
+  /** Best bid and offer on every touch change, published only in partial book mode; books then follow at most every depth interval (pubsub, payload BBO) */
+  bboChannel: (exchange: string, symbol: string): string => mdKey(`bbo:${exchange}:${symbol}`),
+  /** Every second per published channel ({channel} unprefixed, e.g. heartbeat:orderbook:binance:BTCUSDT): messages published on it so far and the producer's clock, so a quiet market is told apart from a dead or partitioned producer (pubsub, payload Heartbeat) */
+  heartbeatChannel: (channel: string): string => mdKey(`heartbeat:${channel}`),
+  /** Public trades per exchange-native symbol (stream, payload Trade) */
+  tradesStream: (exchange: string, symbol: string): string => mdKey(`trades:${exchange}:${symbol}`),
+  /** Funding rate, next funding time and mark price (where the venue returns it) per exchange-native symbol, from WebSocket and REST polling (stream, payload FundingRate) */
+  fundingStream: (exchange: string, symbol: string): string => mdKey(`funding:${exchange}:${symbol}`),
+  /** Real-time funding rates, same payload as the stream (pubsub, payload FundingRate) */
+  fundingChannel: (exchange: string, symbol: string): string => mdKey(`funding:${exchange}:${symbol}`),
+  /** Historical spread opportunities (stream, payload SpreadOpportunity) */
+  spreadsStream: (): string => mdKey(`spreads`),
+  /** Latest state of a spread, keyed by canonical:long:short; the TTL follows the quote-age decay (SPREAD_TTL) less the older leg's quote age, this is the fallback (string, payload SpreadOpportunity) */
+  spreadData: (spreadId: string): string => mdKey(`spread:data:${spreadId}`),
+  /** Real-time updates for a single spread ID (pubsub, payload SpreadOpportunity) */
+  spreadChannel: (spreadId: string): string => mdKey(`spread:${spreadId}`),
+  /** Real-time updates for every spread of a canonical symbol (pubsub, payload SpreadOpportunity) */
+  spreadCanonicalChannel: (canonical: string): string => mdKey(`spread:${canonical}`),
+  /** Set of spread IDs whose data key is live; IDs are removed as their data expires and the set expires once nothing is published (set, payload SpreadID) */
+  spreadsActive: (): string => mdKey(`spreads:active`),
+  /** Summary of the current top spreads (string, payload SpreadSummary) */
+  spreadsList: (): string => mdKey(`spreads:list`),
+  /** Real-time summary of the current top spreads (pubsub, payload SpreadSummary) */
+  spreadsSummaryChannel: (): string => mdKey(`spreads:summary`),
+  /** Summary of the current top spreads whose legs are both on venues the tenant has credentials for (string, payload TenantSpreadSummary) */
+  tenantSpreads: (tenant: string): string => mdKey(`tenant:${tenant}:spreads`),
+  /** Real-time per-tenant spread summary, same payload as the key (pubsub, payload TenantSpreadSummary) */
+  tenantSpreadsChannel: (tenant: string): string => mdKey(`tenant:${tenant}:spreads`),
+  /** Current spot-vs-perp basis opportunities within single venues, by net edge; written once spot books are ingested (string, payload BasisSummary) */
+  basis: (): string => mdKey(`spreads:basis`),
+  /** Real-time basis summary, same payload as the key (pubsub, payload BasisSummary) */
+  basisChannel: (): string => mdKey(`spreads:basis`),
+  /** Volume-weighted median reference price with per-venue deviation (string, payload IndexPrice) */
+  indexPrice: (canonical: string): string => mdKey(`index:${canonical}`),
+  /** Real-time index price updates, same payload as the key (pubsub, payload IndexPrice) */
+  indexChannel: (canonical: string): string => mdKey(`index:${canonical}`),
+  /** OHLCV bars per exchange-native symbol built from the trade stream, one entry per closed bar (stream, payload Bar) */
+  barsStream: (exchange: string, symbol: string, interval: string): string => mdKey(`bars:${exchange}:${symbol}:${interval}`),
+  /** Real-time closed bars, same payload as the stream (pubsub, payload Bar) */
+  barsChannel: (exchange: string, symbol: string, interval: string): string => mdKey(`bars:${exchange}:${symbol}:${interval}`),
+  /** OHLCV bars per canonical symbol across every venue's trades; volume in base units (stream, payload Bar) */
+  consolidatedBarsStream: (canonical: string, interval: string): string => mdKey(`bars:consolidated:${canonical}:${interval}`),
+  /** Real-time closed consolidated bars, same payload as the stream (pubsub, payload Bar) */
+  consolidatedBarsChannel: (canonical: string, interval: string): string => mdKey(`bars:consolidated:${canonical}:${interval}`),
+  /** Abnormal open interest builds and drops per exchange-native symbol (stream, payload OpenInterestEvent) */
+  oiEventsStream: (): string => mdKey(`oi:events`),
+  /** Real-time open interest events, same payload as the stream (pubsub, payload OpenInterestEvent) */
+  oiEventsChannel: (): string => mdKey(`oi:events`),
+  /** Funding settlements per exchange-native symbol as they become upcoming, imminent and settled (stream, payload FundingSettlement) */
+  fundingSettlementsStream: (): string => mdKey(`funding:settlements`),
+  /** Real-time funding settlement events, same payload as the stream (pubsub, payload FundingSettlement) */
+  fundingSettlementsChannel: (): string => mdKey(`funding:settlements`),
+  /** Pre-settlement reduce or flip actions for legs paying an imminent funding settlement (stream, payload FundingAction) */
+  fundingActionsStream: (): string => mdKey(`funding:actions`),
+  /** Real-time pre-settlement actions, same payload as the stream (pubsub, payload FundingAction) */
+  fundingActionsChannel: (): string => mdKey(`funding:actions`),
+  /** Symbol status transitions (trading, limit_open, delisting, settling, maintenance) from venue instrument lists and scheduled windows; an empty symbol covers the whole exchange (stream, payload SymbolStatusTransition) */
+  symbolStatusStream: (): string => mdKey(`symbols:status`),
+  /** Real-time symbol status transitions, same payload as the stream (pubsub, payload SymbolStatusTransition) */
+  symbolStatusChannel: (): string => mdKey(`symbols:status`),
+  /** Feeds (exchange and channel) whose message rate collapsed against their learned baseline while still connected, and their recoveries (stream, payload FeedAlert) */
+  feedAlertsStream: (): string => mdKey(`feeds:alerts`),
+  /** Real-time feed alerts, same payload as the stream (pubsub, payload FeedAlert) */
+  feedAlertsChannel: (): string => mdKey(`feeds:alerts`),
+  /** Published opportunities tagged as false positives by executors or operators; the tags within a week lower the data-quality score of the venue symbols involved (stream, payload FalsePositiveTag) */
+  falsePositivesStream: (): string => mdKey(`feedback:false_positives`),
+  /** Spread IDs scored by peak spread bps for a UTC date (zset, payload SpreadID) */
+  historyTop: (date: string): string => mdKey(`history:top:${date}`),
+  /** Spread snapshot at its daily peak, field per spread ID (hash, payload SpreadOpportunity) */
+  historyPeak: (date: string): string => mdKey(`history:peak:${date}`),
+  /** Sampled spread bps histogram per exchange pair, field per bucket (hash, payload Counter) */
+  historyDistribution: (date: string, long: string, short: string): string => mdKey(`history:dist:${date}:${long}:${short}`),
+  /** Exchange pairs (long:short) with distribution data for a date (set, payload ExchangePair) */
+  historyPairs: (date: string): string => mdKey(`history:pairs:${date}`),
+  /** Opportunity lifetime counters (episodes, total_ms, max_ms) per symbol (hash, payload Counter) */
+  historyPersistence: (date: string, canonical: string): string => mdKey(`history:persist:${date}:${canonical}`),
+  /** Canonical symbols with persistence data for a date (set, payload Canonical) */
+  historySymbols: (date: string): string => mdKey(`history:symbols:${date}`),
+  /** Opportunity episodes (lifetime, peak, time above levels) that ended on a date (stream, payload Episode) */
+  historyEpisodes: (date: string): string => mdKey(`history:episodes:${date}`),
+  /** OHLCV bars scored by start time in ms, backfilled from venue klines at startup and extended by live bars (zset, payload Bar) */
+  historyBars: (exchange: string, symbol: string, interval: string): string => mdKey(`history:bars:${exchange}:${symbol}:${interval}`),
+  /** Signal vs captured spread counters of executed opportunities, fields {pair:long:short|symbol:canonical}|{count,signal_bps,captured_bps} (hash, payload Counter) */
+  historyCapture: (date: string): string => mdKey(`history:capture:${date}`),
+  /** Correlation matrix of exchange pairs' spread activity and the clusters of pairs that move together, recomputed periodically (string, payload SpreadCorrelation) */
+  historyClusters: (): string => mdKey(`history:clusters`),
+  /** Spread clusters as they are recomputed, same payload as the key (pubsub, payload SpreadCorrelation) */
+  historyClustersChannel: (): string => mdKey(`history:clusters`),
+  /** Order rate token bucket (tokens, ts_ms) shared by every strategy trading the account; factor, set while the venue is degraded, scales its capacity and rate (hash, payload RateBudget) */
+  rateBudget: (exchange: string): string => mdKey(`ratebudget:${exchange}`),
+  /** REST health per venue ({exchange} -> JSON); executors stop resting passive quotes on venues with quoting_allowed false (hash, payload VenueHealth) */
+  venueHealth: (): string => mdKey(`venues:health`),
+  /** WebSocket subscription set per venue ({exchange} -> JSON), saved periodically; a restarted or failed-over instance resubscribes from it before the REST load (hash, payload VenueSubscriptions) */
+  subscriptionState: (): string => mdKey(`subscriptions:state`),
+  /** Registered spread event webhooks ({id} -> JSON), including the secret each delivery is signed with (hash, payload WebhookEndpoint) */
+  webhooks: (): string => mdKey(`webhooks`),
+  /** Feature flag overrides (flag name -> true/false) for an environment; unset flags use each service's default (hash, payload Flags) */
+  flags: (env: string): string => mdKey(`flags:${env}`),
+  /** Runtime parameter overrides (name -> number), e.g. spread.min_spread_bps or tier.major.min_depth_usd; unset parameters use the service default (hash, payload Settings) */
+  settings: (env: string): string => mdKey(`settings:${env}`),
+  /** Exchange pair mutes applied by every instance (mute ID -> JSON); expired mutes are removed by the watchers (hash, payload PairMute) */
+  settingsMutes: (env: string): string => mdKey(`settings:${env}:mutes`),
+  /** Tier of each canonical symbol (canonical -> tier name); a tier's thresholds are the tier.{name}.* settings (hash, payload SymbolTiers) */
+  settingsTiers: (env: string): string => mdKey(`settings:${env}:tiers`),
+  /** Filter expression every opportunity must match to be published, e.g. spread_bps > 8 && long_exchange != "lbank"; unset publishes all (string, payload FilterExpression) */
+  settingsFilter: (env: string): string => mdKey(`settings:${env}:filter`),
+  /** Name of the settings section just written (params, mutes, tiers, filter); watchers reload within seconds without it (pubsub, payload SettingsSection) */
+  settingsChangedChannel: (env: string): string => mdKey(`settings:${env}:changed`),
+  /** Executor lease on a spread opportunity (token, owner, state, claimed_ms); expires unless renewed, held briefly once done (hash, payload Claim) */
+  claim: (opportunityId: string): string => mdKey(`claim:${opportunityId}`),
+  /** Executor lease on a spread opportunity for one tenant's account; same fields as claim (hash, payload Claim) */
+  tenantClaim: (tenant: string, opportunityId: string): string => mdKey(`claim:tenant:${tenant}:${opportunityId}`),
+  /** Positions hedged on a fallback venue (flag ID -> JSON), pending migration to the intended venue pair (hash, payload MigrationFlag) */
+  migrations: (): string => mdKey(`execution:migrations`),
+  /** Net perp position per venue ({exchange}:{canonical} -> signed base quantity), adjusted by executors on fills (hash, payload Positions) */
+  positions: (): string => mdKey(`positions`),
+  /** Cumulative realized plus unrealized PnL per strategy ({strategy} -> USD), written by executors; drives drawdown de-risking (hash, payload StrategyPnL) */
+  strategyPnl: (): string => mdKey(`risk:pnl`),
+  /** De-risking in force after a drawdown (halve sizes or halt entries); executors scale entries by size_factor until it is acknowledged (string, payload DeRiskState) */
+  derisk: (): string => mdKey(`risk:derisk`),
+  /** Balance and unrealized PnL per venue ({exchange} -> JSON), written by executors; drives per-venue exposure caps (hash, payload VenueEquity) */
+  venueEquity: (): string => mdKey(`risk:equity`),
+  /** Observed deposit and withdrawal completion times per route ({asset}:{chain}:{from}:{to} -> JSON, empty from/to for untracked venues); the expected latency for rebalancing transfers (hash, payload TransferLatency) */
+  transferLatency: (): string => mdKey(`transfers:latency`),
+} as const;
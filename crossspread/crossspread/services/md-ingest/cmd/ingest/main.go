@@ -2,30 +2,75 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"crossspread-md-ingest/internal/admin"
+	"crossspread-md-ingest/internal/apiversion"
+	"crossspread-md-ingest/internal/archive"
+	"crossspread-md-ingest/internal/bars"
+	"crossspread-md-ingest/internal/blacklist"
+	"crossspread-md-ingest/internal/books"
+	"crossspread-md-ingest/internal/bus"
+	"crossspread-md-ingest/internal/claim"
 	"crossspread-md-ingest/internal/connector"
 	"crossspread-md-ingest/internal/connector/binance"
 	"crossspread-md-ingest/internal/connector/bingx"
 	"crossspread-md-ingest/internal/connector/bitget"
+	"crossspread-md-ingest/internal/connector/bitmart"
+	"crossspread-md-ingest/internal/connector/bitrue"
 	"crossspread-md-ingest/internal/connector/bybit"
 	"crossspread-md-ingest/internal/connector/coinex"
+	"crossspread-md-ingest/internal/connector/deribit"
 	gateio "crossspread-md-ingest/internal/connector/gate"
 	"crossspread-md-ingest/internal/connector/htx"
 	"crossspread-md-ingest/internal/connector/kucoin"
 	"crossspread-md-ingest/internal/connector/lbank"
 	"crossspread-md-ingest/internal/connector/mexc"
 	"crossspread-md-ingest/internal/connector/okx"
+	"crossspread-md-ingest/internal/connector/whitebit"
+	"crossspread-md-ingest/internal/connector/xt"
 	"crossspread-md-ingest/internal/credentials"
+	"crossspread-md-ingest/internal/endpoints"
+	"crossspread-md-ingest/internal/execution"
+	"crossspread-md-ingest/internal/export"
+	"crossspread-md-ingest/internal/feedback"
+	"crossspread-md-ingest/internal/feedwatch"
+	"crossspread-md-ingest/internal/flags"
+	"crossspread-md-ingest/internal/funding"
+	"crossspread-md-ingest/internal/history"
+	"crossspread-md-ingest/internal/index"
+	"crossspread-md-ingest/internal/inventory"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/listings"
 	"crossspread-md-ingest/internal/loader"
+	"crossspread-md-ingest/internal/maintenance"
+	"crossspread-md-ingest/internal/memory"
 	"crossspread-md-ingest/internal/metrics"
 	"crossspread-md-ingest/internal/normalizer"
+	"crossspread-md-ingest/internal/openinterest"
+	"crossspread-md-ingest/internal/orderbook"
 	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/internal/ratebudget"
+	"crossspread-md-ingest/internal/replay"
+	"crossspread-md-ingest/internal/restcache"
+	"crossspread-md-ingest/internal/settings"
+	"crossspread-md-ingest/internal/soak"
 	"crossspread-md-ingest/internal/spread"
+	"crossspread-md-ingest/internal/strategy"
+	"crossspread-md-ingest/internal/symbolmap"
+	"crossspread-md-ingest/internal/symbolstatus"
+	"crossspread-md-ingest/internal/universe"
+	"crossspread-md-ingest/internal/venuehealth"
+	"crossspread-md-ingest/internal/webhook"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -34,30 +79,74 @@ import (
 // Global credentials fetcher
 var credsFetcher *credentials.CredentialsFetcher
 
+// mdCredentialsTenant is the tenant whose keys authenticate market data
+// connections; empty uses the first credentials of any user
+var mdCredentialsTenant string
+
 func main() {
 	// Setup logging
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
+	// Soak mode runs against live venues for a fixed time while checking
+	// invariants, then writes a report and exits non-zero on any violation.
+	// Used to qualify a release before production.
+	soakMode := flag.Bool("soak", false, "run a soak test with self-checks and exit with a report")
+	soakDuration := flag.Duration("soak-duration", 4*time.Hour, "how long the soak test runs")
+	soakReportPath := flag.String("soak-report", "soak-report.json", "where the soak report is written")
+	recordPath := flag.String("record", "", "record the frames fed to spread discovery to this file for replay")
+	recordWindow := flag.Duration("record-window", 10*time.Minute, "how long frames are recorded")
+	recordRotate := flag.Duration("record-rotate", 0, "start a new recording file this often; -record is then a directory and -record-window 0 records until shutdown")
+	recordSnapshotEvery := flag.Int("record-snapshot-every", 100, "book updates stored as deltas between full snapshots of a symbol (0 stores every book whole)")
+	flag.Parse()
+
 	// Load config from environment
 	redisHost := getEnv("REDIS_HOST", "localhost")
 	redisPort := getEnv("REDIS_PORT", "6379")
+	// Instances sharing one Redis each get a namespace and an ACL user
+	// limited to it, see docs/REDIS_ACL.md
+	redisPrefix := strings.ReplaceAll(getEnv("REDIS_KEY_PREFIX", ""), "{instance}", getEnv("INSTANCE_ID", "default"))
+	keyspace.SetPrefix(redisPrefix)
 	metricsPort := getEnv("METRICS_PORT", "9090")
+	adminPort := getEnv("ADMIN_PORT", "9091")
 	enabledExchanges := getEnv("ENABLED_EXCHANGES", "binance,bybit,okx,kucoin,mexc,bitget,gateio,bingx,coinex,lbank,htx")
 	useTwoPhase := getEnv("USE_TWO_PHASE", "true") == "true"
 	backendAPIURL := getEnv("BACKEND_API_URL", "http://localhost:8000")
-	serviceSecret := getEnv("SERVICE_SECRET", "default-dev-secret")
+	environment := getEnv("ENVIRONMENT", "dev")
 	minSpreadBps := 5.0 // Minimum spread in basis points
 
+	// Calls to the credentials API carry short-lived signed tokens. Keys are
+	// "kid:secret,..." with the signing key first; SERVICE_SECRET alone is
+	// used as key "default" for setups that predate rotation.
+	tokenConfig := credentials.DefaultTokenConfig()
+	tokenConfig.Audience = getEnv("SERVICE_TOKEN_AUDIENCE", tokenConfig.Audience)
+	if v, err := time.ParseDuration(getEnv("SERVICE_TOKEN_TTL", "1m")); err == nil && v > 0 {
+		tokenConfig.TTL = v
+	}
+	signingKeys := getEnv("SERVICE_TOKEN_KEYS", "default:"+getEnv("SERVICE_SECRET", "default-dev-secret"))
+	keys, err := credentials.ParseSigningKeys(signingKeys)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid SERVICE_TOKEN_KEYS")
+	}
+	tokenConfig.Keys = keys
+	tokenSigner, err := credentials.NewTokenSigner(tokenConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create service token signer")
+	}
+
 	// Initialize credentials fetcher
-	credsFetcher = credentials.NewCredentialsFetcher(backendAPIURL, serviceSecret)
+	credsFetcher = credentials.NewCredentialsFetcher(backendAPIURL, tokenSigner)
+	mdCredentialsTenant = getEnv("MD_CREDENTIALS_TENANT", "")
 
 	log.Info().
 		Str("redis", redisHost+":"+redisPort).
+		Str("redis_prefix", keyspace.Prefix()).
 		Str("metrics", ":"+metricsPort).
 		Str("exchanges", enabledExchanges).
 		Bool("two_phase", useTwoPhase).
 		Str("backend_api", backendAPIURL).
+		Str("service_key", tokenSigner.KeyID()).
+		Str("env", environment).
 		Msg("Starting market data ingestion service")
 
 	// Log credential status (after a short delay to let backend start)
@@ -66,6 +155,20 @@ func main() {
 		logCredentialStatus()
 	}()
 
+	// Apply memory budget before anything large is allocated
+	memConfig := memory.DefaultConfig()
+	if v, err := strconv.ParseInt(getEnv("MEMORY_LIMIT_MB", "400"), 10, 64); err == nil {
+		memConfig.LimitBytes = v << 20
+	}
+	if v, err := strconv.ParseInt(getEnv("MEMORY_BALLAST_MB", "0"), 10, 64); err == nil {
+		memConfig.BallastBytes = v << 20
+	}
+	if v, err := strconv.ParseFloat(getEnv("MEMORY_SHED_RATIO", "0.85"), 64); err == nil {
+		memConfig.ShedRatio = v
+	}
+	memManager := memory.NewManager(memConfig)
+	memManager.Apply()
+
 	// Start metrics server
 	metricsServer := metrics.NewServer(":" + metricsPort)
 	go func() {
@@ -74,21 +177,226 @@ func main() {
 		}
 	}()
 
+	// Symbols that keep failing are blacklisted for a cooldown instead of
+	// being retried forever; the admin API lists and clears them
+	symbolBlacklist := blacklist.New(blacklist.DefaultConfig())
+
+	// Start admin server
+	adminServer := admin.NewServer(":" + adminPort)
+	adminServer.RegisterBlacklist(symbolBlacklist)
+	adminServer.RegisterDashboard()
+
+	// Venue API versions are tracked on every REST call and WebSocket
+	// handshake; deprecation headers, response fields and announced sunsets
+	// (API_SUNSETS=kucoin:/api/v1=2026-12-01,...) raise warnings and metrics
+	apiVersionConfig := apiversion.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("API_SUNSET_WARN_BEFORE", "720h")); err == nil {
+		apiVersionConfig.WarnBefore = v
+	}
+	apiVersions := apiversion.NewTracker(apiVersionConfig)
+	http.DefaultTransport = apiVersions.Transport(http.DefaultTransport)
+	connector.SetHandshakeObserver(apiVersions.ObserveHandshake)
+	sunsets, err := apiversion.ParseSunsets(getEnv("API_SUNSETS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid API_SUNSETS")
+	}
+	for _, notice := range sunsets {
+		apiVersions.Report(notice)
+	}
+	adminServer.RegisterAPIVersions(apiVersions)
+	go func() {
+		if err := adminServer.Start(); err != nil {
+			log.Error().Err(err).Msg("Admin server error")
+		}
+	}()
+
 	// Create Redis publisher
-	pub, err := publisher.NewRedisPublisher(redisHost + ":" + redisPort)
+	pub, err := publisher.NewRedisPublisher(redisHost+":"+redisPort, getEnv("REDIS_USERNAME", ""), getEnv("REDIS_PASSWORD", ""))
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create Redis publisher")
 	}
 	defer pub.Close()
 
-	// Create normalizer
-	norm := normalizer.NewInstrumentNormalizer()
+	// Publishes share PUBLISH_SLOTS Redis slots (default the pool size) by
+	// class, spreads > trades > orderbooks > analytics, so a backlog of books
+	// never delays spreads. PUBLISH_QUEUE_LIMITS=orderbook=500 bounds how
+	// many of a class may wait before new ones are dropped; PUBLISH_PRIORITY=false
+	// publishes in arrival order.
+	if getEnv("PUBLISH_PRIORITY", "true") != "false" {
+		priorityConfig, err := publisher.ParseQueueLimits(getEnv("PUBLISH_QUEUE_LIMITS", ""))
+		if err != nil {
+			log.Warn().Err(err).Msg("Ignoring invalid PUBLISH_QUEUE_LIMITS")
+			priorityConfig = publisher.DefaultPriorityConfig()
+		}
+		if v, err := strconv.Atoi(getEnv("PUBLISH_SLOTS", "0")); err == nil && v > 0 {
+			priorityConfig.Slots = v
+		}
+		pub.SetPriority(priorityConfig)
+	}
+	adminServer.RegisterPublishClasses(pub)
+
+	// BOOK_PUBLISH_MODE=partial publishes the BBO on bbo:{exchange}:{symbol}
+	// on every touch change and full books at most every BOOK_DEPTH_INTERVAL;
+	// the p99 BBO latency from receipt is held to BBO_LATENCY_SLO
+	bookMode, err := publisher.ParseBookMode(getEnv("BOOK_PUBLISH_MODE", publisher.BookModeFull))
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring invalid BOOK_PUBLISH_MODE")
+		bookMode = publisher.BookModeFull
+	}
+	if bookMode == publisher.BookModePartial {
+		partialConfig := publisher.DefaultPartialBookConfig()
+		if v, err := time.ParseDuration(getEnv("BOOK_DEPTH_INTERVAL", "250ms")); err == nil && v > 0 {
+			partialConfig.DepthInterval = v
+		}
+		if v, err := time.ParseDuration(getEnv("BBO_LATENCY_SLO", "5ms")); err == nil && v > 0 {
+			partialConfig.SLO = v
+		}
+		pub.SetPartialBook(partialConfig)
+		log.Info().Dur("depth_interval", partialConfig.DepthInterval).Dur("slo", partialConfig.SLO).
+			Msg("Partial book publication: BBO on every touch change")
+	}
+	adminServer.RegisterBBO(pub)
+
+	// Every published channel gets a heartbeat on heartbeat:{channel} each
+	// HEARTBEAT_INTERVAL (0 disables) carrying its message count and the
+	// producer clock, so consumers can tell a quiet market from a dead
+	// producer and measure their lag
+	if v, err := time.ParseDuration(getEnv("HEARTBEAT_INTERVAL", "1s")); err == nil && v > 0 {
+		heartbeatConfig := publisher.DefaultHeartbeatConfig()
+		heartbeatConfig.Interval = v
+		heartbeatConfig.Producer = getEnv("INSTANCE_ID", "default")
+		pub.SetHeartbeat(heartbeatConfig)
+	}
+
+	// PUBLISHER_BACKEND=jetstream sends books, trades, funding and spreads to
+	// persistent NATS JetStream streams on md.<exchange>.<symbol>.<type>
+	// instead of Redis streams, so consumers that restart resume where they
+	// left off. State keys (spread data, lists, indices) stay in Redis.
+	backend, err := publisher.ParseBackend(getEnv("PUBLISHER_BACKEND", publisher.BackendRedis))
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring invalid PUBLISHER_BACKEND")
+		backend = publisher.BackendRedis
+	}
+	var marketPub publisher.Publisher = pub
+	if backend == publisher.BackendJetStream {
+		jsConfig := publisher.DefaultJetStreamConfig()
+		jsConfig.URL = getEnv("NATS_URL", jsConfig.URL)
+		jsConfig.Name = "md-ingest-" + getEnv("INSTANCE_ID", "default")
+		jsConfig.User = getEnv("NATS_USER", "")
+		jsConfig.Password = getEnv("NATS_PASSWORD", "")
+		jsConfig.Token = getEnv("NATS_TOKEN", "")
+		if v, err := strconv.Atoi(getEnv("NATS_REPLICAS", "1")); err == nil && v > 0 {
+			jsConfig.Replicas = v
+		}
+		if v, err := time.ParseDuration(getEnv("NATS_MAX_AGE", "24h")); err == nil && v > 0 {
+			jsConfig.MaxAge = v
+		}
+		if v, err := strconv.Atoi(getEnv("NATS_SPREADS_PER_SUBJECT", "100")); err == nil && v > 0 {
+			jsConfig.SpreadsPerSubject = v
+		}
+		if v, err := time.ParseDuration(getEnv("NATS_RETRY_QUEUE_WAIT", "1s")); err == nil && v > 0 {
+			jsConfig.RetryQueueWait = v
+		}
+		jsPub, err := publisher.NewJetStreamPublisher(jsConfig, pub)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create JetStream publisher")
+		}
+		defer jsPub.Close()
+		marketPub = jsPub
+		log.Info().Str("url", jsConfig.URL).Msg("Publishing market data to NATS JetStream")
+	}
+
+	// Every venue REST call is timed; a venue whose p90 latency passes
+	// VENUE_MAX_LATENCY or whose error rate passes VENUE_MAX_ERROR_RATE over
+	// a minute is degraded: its order rate budget is scaled by
+	// VENUE_DEGRADED_RATE_FACTOR and passive quoting on it disabled until
+	// it recovers
+	venueHealthConfig := venuehealth.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("VENUE_MAX_LATENCY", "2s")); err == nil && v > 0 {
+		venueHealthConfig.MaxLatency = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("VENUE_MAX_ERROR_RATE", "0.2"), 64); err == nil && v > 0 && v <= 1 {
+		venueHealthConfig.MaxErrorRate = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("VENUE_DEGRADED_RATE_FACTOR", "0.25"), 64); err == nil && v > 0 && v <= 1 {
+		venueHealthConfig.RateFactor = v
+	}
+	if v, err := time.ParseDuration(getEnv("VENUE_RECOVER_AFTER", "2m")); err == nil && v > 0 {
+		venueHealthConfig.RecoverAfter = v
+	}
+	venueHealth := venuehealth.NewBreaker(pub.Client(), venueHealthConfig)
+	http.DefaultTransport = venueHealth.Transport(http.DefaultTransport)
+	adminServer.RegisterVenueHealth(venueHealth)
+
+	// Venue instrument and asset lists are cached on disk (REST_CACHE_DIR)
+	// so restarts, crash loops above all, don't refetch every venue's
+	// metadata and get the IP rate limited. REST_CACHE_TTLS=/fapi/v1/exchangeInfo=30m
+	// changes an endpoint's TTL; an expired copy up to REST_CACHE_MAX_STALE
+	// old is served while a venue fails or rate limits the fetch.
+	if getEnv("REST_CACHE", "true") == "true" {
+		cacheConfig, err := restcache.ParseTTLs(restcache.DefaultConfig(), getEnv("REST_CACHE_TTLS", ""))
+		if err != nil {
+			log.Warn().Err(err).Msg("Ignoring invalid REST_CACHE_TTLS")
+			cacheConfig = restcache.DefaultConfig()
+		}
+		if v := getEnv("REST_CACHE_DIR", ""); v != "" {
+			cacheConfig.Dir = v
+		}
+		if v, err := time.ParseDuration(getEnv("REST_CACHE_MAX_STALE", "24h")); err == nil && v >= 0 {
+			cacheConfig.MaxStale = v
+		}
+		restCache, err := restcache.New(cacheConfig)
+		if err != nil {
+			log.Warn().Err(err).Str("dir", cacheConfig.Dir).Msg("REST cache unavailable, fetching metadata uncached")
+		} else {
+			http.DefaultTransport = restCache.Transport(http.DefaultTransport)
+			adminServer.RegisterRESTCache(restCache)
+		}
+	}
 
-	// Default symbols to subscribe (perpetual futures) - used for legacy mode
-	defaultSymbols := []string{
-		"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT", "XRPUSDT",
-		"DOGEUSDT", "ADAUSDT", "MATICUSDT", "AVAXUSDT", "DOTUSDT",
-		"LTCUSDT", "LINKUSDT", "UNIUSDT", "ATOMUSDT", "ETCUSDT",
+	// Risky features are toggled per environment through Redis without a
+	// redeploy; the first read happens before connectors are created
+	flagConfig := flags.DefaultConfig()
+	flagConfig.Env = environment
+	if v, err := time.ParseDuration(getEnv("FLAGS_REFRESH_INTERVAL", "5s")); err == nil {
+		flagConfig.RefreshInterval = v
+	}
+	flagStore := flags.New(pub.Client(), flagConfig)
+	if err := flagStore.Refresh(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load feature flags, using defaults")
+	}
+	adminServer.RegisterFlags(flagStore)
+
+	// Create normalizer. Renamed assets (MATIC to POL) map to their new
+	// canonical from the effective date; SYMBOL_RENAMES=FTM=S@2025-01-13,
+	// bybit:RNDR=RENDER@2024-07-29 adds to the built-in renames
+	norm := normalizer.NewInstrumentNormalizer()
+	renames := normalizer.DefaultRenames()
+	if v := getEnv("SYMBOL_RENAMES", ""); v != "" {
+		extra, err := normalizer.ParseRenames(v)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid SYMBOL_RENAMES")
+		}
+		renames = append(renames, extra...)
+	}
+	norm.SetRenames(renames)
+	adminServer.RegisterRenames(norm)
+
+	// The symbol universe, book depths and default spread thresholds come
+	// from CONFIG_PATH (YAML, or JSON if it ends in .json), with
+	// per-exchange overrides; without it the built-in 15 perpetuals. SIGHUP
+	// reloads the file. In two-phase mode a file limits the symbols
+	// discovery pairs; without one every listed contract is scanned.
+	configPath := getEnv("CONFIG_PATH", "")
+	uni := universe.Default()
+	if configPath != "" {
+		if uni, err = universe.Load(configPath); err != nil {
+			log.Fatal().Err(err).Str("path", configPath).Msg("Invalid symbol universe config")
+		}
+		log.Info().Str("path", configPath).Int("symbols", len(uni.Symbols)).Msg("Loaded symbol universe")
+	}
+	if uni.MinSpreadBps > 0 {
+		minSpreadBps = uni.MinSpreadBps
 	}
 
 	// Create exchange connectors based on enabled exchanges
@@ -97,34 +405,27 @@ func main() {
 
 	for _, ex := range exchanges {
 		ex = strings.TrimSpace(strings.ToLower(ex))
+		id := connector.ExchangeID(ex)
+		symbols := exchangeSymbols(id, uni.SymbolsFor(id))
+		depth := uni.DepthFor(id)
 		switch ex {
 		case "binance":
-			conn := binance.NewBinanceConnector(defaultSymbols, 20)
+			conn := binance.NewBinanceConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added Binance connector")
 
 		case "bybit":
-			conn := bybit.NewBybitConnector(defaultSymbols, 50)
+			conn := bybit.NewBybitConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added Bybit connector")
 
 		case "okx":
-			// Convert to OKX format: BTCUSDT -> BTC-USDT-SWAP
-			okxSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				okxSymbols[i] = convertToOKXSymbol(s)
-			}
-			conn := okx.NewOKXConnector(okxSymbols, 5)
+			conn := okx.NewOKXConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added OKX connector")
 
 		case "kucoin":
-			// Convert to KuCoin format: BTCUSDT -> XBTUSDTM
-			kucoinSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				kucoinSymbols[i] = convertToKuCoinSymbol(s)
-			}
-			conn := kucoin.NewKuCoinConnector(kucoinSymbols, 20)
+			conn := kucoin.NewKuCoinConnector(symbols, depth)
 			connectors = append(connectors, conn)
 
 			// Check if credentials are available (for future authenticated endpoint support)
@@ -135,112 +436,1078 @@ func main() {
 			}
 
 		case "mexc":
-			// Convert to MEXC format: BTCUSDT -> BTC_USDT
-			mexcSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				mexcSymbols[i] = convertToMEXCSymbol(s)
-			}
-
 			// Try to use credentials if available
 			var conn connector.Connector
 			if creds := getCredentialsForExchange("mexc"); creds != nil {
-				conn = mexc.NewMEXCConnectorWithCredentials(mexcSymbols, 20, creds.APIKey, creds.APISecret)
+				conn = mexc.NewMEXCConnectorWithCredentials(symbols, depth, creds.APIKey, creds.APISecret)
 				log.Info().Msg("Added MEXC connector with API credentials")
 			} else {
-				conn = mexc.NewMEXCConnector(mexcSymbols, 20)
+				conn = mexc.NewMEXCConnector(symbols, depth)
 				log.Info().Msg("Added MEXC connector (public endpoints only)")
 			}
 			connectors = append(connectors, conn)
 
 		case "bitget":
 			// Bitget uses BTCUSDT format
-			conn := bitget.NewBitgetConnector(defaultSymbols, 20)
+			conn := bitget.NewBitgetConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added Bitget connector")
 
 		case "gateio":
-			// Convert to Gate.io format: BTCUSDT -> BTC_USDT
-			gateSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				gateSymbols[i] = convertToGateSymbol(s)
-			}
-
 			// Try to use credentials if available
 			var conn connector.Connector
 			if creds := getCredentialsForExchange("gateio"); creds != nil {
-				conn = gateio.NewGateConnectorWithCredentials(gateSymbols, 20, "usdt", creds.APIKey, creds.APISecret)
+				conn = gateio.NewGateConnectorWithCredentials(symbols, depth, "usdt", creds.APIKey, creds.APISecret)
 				log.Info().Msg("Added Gate.io connector with API credentials")
 			} else {
-				conn = gateio.NewGateConnector(gateSymbols, 20, "usdt")
+				conn = gateio.NewGateConnector(symbols, depth, "usdt")
 				log.Info().Msg("Added Gate.io connector (public endpoints only)")
 			}
 			connectors = append(connectors, conn)
 
 		case "bingx":
-			// Convert to BingX format: BTCUSDT -> BTC-USDT
-			bingxSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				bingxSymbols[i] = convertToBingXSymbol(s)
-			}
-
 			// Try to use credentials if available
 			var conn connector.Connector
 			if creds := getCredentialsForExchange("bingx"); creds != nil {
-				conn = bingx.NewBingXConnectorWithCredentials(bingxSymbols, 20, creds.APIKey, creds.APISecret)
+				conn = bingx.NewBingXConnectorWithCredentials(symbols, depth, creds.APIKey, creds.APISecret)
 				log.Info().Msg("Added BingX connector with API credentials")
 			} else {
-				conn = bingx.NewBingXConnector(bingxSymbols, 20)
+				conn = bingx.NewBingXConnector(symbols, depth)
 				log.Info().Msg("Added BingX connector (public endpoints only)")
 			}
 			connectors = append(connectors, conn)
 
 		case "coinex":
-			// CoinEx uses BTCUSD format
-			coinexSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				coinexSymbols[i] = convertToCoinExSymbol(s)
-			}
-			conn := coinex.NewCoinExConnector(coinexSymbols, 20)
+			conn := coinex.NewCoinExConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added CoinEx connector")
 
 		case "lbank":
-			// Convert to LBank format: BTCUSDT -> BTC_USDT
-			lbankSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				lbankSymbols[i] = convertToLBankSymbol(s)
-			}
-			conn := lbank.NewLBankConnector(lbankSymbols, 20)
+			conn := lbank.NewLBankConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added LBank connector")
 
 		case "htx":
-			// Convert to HTX format: BTCUSDT -> BTC-USDT
-			htxSymbols := make([]string, len(defaultSymbols))
-			for i, s := range defaultSymbols {
-				htxSymbols[i] = convertToHTXSymbol(s)
-			}
-			conn := htx.NewHTXConnector(htxSymbols, 20)
+			conn := htx.NewHTXConnector(symbols, depth)
 			connectors = append(connectors, conn)
 			log.Info().Msg("Added HTX connector")
 
+		case "whitebit":
+			conn := whitebit.NewWhiteBITConnector(symbols, depth)
+			connectors = append(connectors, conn)
+			log.Info().Msg("Added WhiteBIT connector")
+
+		case "bitmart":
+			// BitMart uses BTCUSDT format
+			conn := bitmart.NewBitMartConnector(symbols, depth)
+			connectors = append(connectors, conn)
+			log.Info().Msg("Added BitMart connector")
+
+		case "xt":
+			conn := xt.NewXTConnector(symbols, depth)
+			connectors = append(connectors, conn)
+			log.Info().Msg("Added XT.com connector")
+
+		case "bitrue":
+			conn := bitrue.NewBitrueConnector(symbols, depth)
+			connectors = append(connectors, conn)
+			log.Info().Msg("Added Bitrue connector")
+
+		case "deribit":
+			conn := deribit.NewDeribitConnector(symbols, depth)
+			connectors = append(connectors, conn)
+			log.Info().Msg("Added Deribit connector")
+
 		default:
 			log.Warn().Str("exchange", ex).Msg("Unknown exchange, skipping")
 		}
 	}
 
+	enabled := connectors[:0]
+	for _, conn := range connectors {
+		if !flagStore.ConnectorEnabled(conn.ID()) {
+			log.Warn().Str("exchange", string(conn.ID())).Msg("Connector disabled by feature flag")
+			continue
+		}
+		enabled = append(enabled, conn)
+	}
+	connectors = enabled
+
 	if len(connectors) == 0 {
 		log.Fatal().Msg("No exchange connectors enabled")
 	}
 
+	// Venues with alternative domains (Bybit's bytick.com, OKX's AWS
+	// endpoints) are probed and the fastest WebSocket and REST endpoint
+	// used. ENDPOINTS=okx.ws=url|url,binance.rest=url replaces a venue's
+	// candidates, a single URL pinning it; the Binance, Bybit and OKX
+	// connectors follow it. ENDPOINT_SELECTION=false keeps the defaults.
+	endpointConfig, err := endpoints.ParseCandidates(getEnv("ENDPOINTS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid ENDPOINTS")
+	}
+	endpointSelector := endpoints.New(endpointConfig)
+	if getEnv("ENDPOINT_SELECTION", "true") != "false" {
+		ids := make([]connector.ExchangeID, 0, len(connectors))
+		for _, conn := range connectors {
+			ids = append(ids, conn.ID())
+		}
+		endpoints.Apply(connectors, endpointSelector.Select(context.Background(), ids))
+	}
+	adminServer.RegisterEndpoints(endpointSelector)
+
+	// Per-exchange permessage-deflate: WS_COMPRESSION=all or a comma-separated exchange list
+	wsCompression := getEnv("WS_COMPRESSION", "")
+	compressed := make(map[string]bool)
+	for _, ex := range strings.Split(wsCompression, ",") {
+		compressed[strings.TrimSpace(strings.ToLower(ex))] = true
+	}
+	for _, conn := range connectors {
+		if compressed["all"] || compressed[string(conn.ID())] {
+			connector.SetCompression(conn.ID(), true)
+		}
+	}
+
+	// Soft caps on what each exchange's connectors hold: dials past
+	// CONNECTOR_MAX_SOCKETS are refused, goroutines past
+	// CONNECTOR_MAX_GOROUTINES are alerted on.
+	// CONNECTOR_BUDGETS=binance=32/512,okx=8/64 sets sockets/goroutines per venue.
+	defaultBudget := connector.Budget{MaxSockets: 16, MaxGoroutines: 256}
+	if v, err := strconv.Atoi(getEnv("CONNECTOR_MAX_SOCKETS", "16")); err == nil && v >= 0 {
+		defaultBudget.MaxSockets = v
+	}
+	if v, err := strconv.Atoi(getEnv("CONNECTOR_MAX_GOROUTINES", "256")); err == nil && v >= 0 {
+		defaultBudget.MaxGoroutines = v
+	}
+	connector.SetDefaultBudget(defaultBudget)
+	for _, entry := range strings.Split(getEnv("CONNECTOR_BUDGETS", ""), ",") {
+		ex, caps, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		sockets, goroutines, _ := strings.Cut(caps, "/")
+		budget := defaultBudget
+		if v, err := strconv.Atoi(sockets); err == nil && v >= 0 {
+			budget.MaxSockets = v
+		}
+		if v, err := strconv.Atoi(goroutines); err == nil && v >= 0 {
+			budget.MaxGoroutines = v
+		}
+		connector.SetBudget(connector.ExchangeID(strings.ToLower(ex)), budget)
+	}
+	adminServer.RegisterBudgets()
+	adminServer.RegisterFrameDebug()
+
+	// Merged depth: BOOK_AGGREGATION=coinex:BTCUSDT=1,bitget:ETHUSDT=0.5
+	// buckets a symbol's book by price step on venues that support it
+	aggregation := make(map[string]map[string]float64)
+	for _, entry := range strings.Split(getEnv("BOOK_AGGREGATION", ""), ",") {
+		ex, rest, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		symbol, step, ok := strings.Cut(rest, "=")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(step, 64); err == nil {
+			ex = strings.ToLower(ex)
+			if aggregation[ex] == nil {
+				aggregation[ex] = make(map[string]float64)
+			}
+			aggregation[ex][symbol] = v
+		}
+	}
+	for _, conn := range connectors {
+		setter, ok := conn.(connector.AggregationSetter)
+		if !ok {
+			if len(aggregation[string(conn.ID())]) > 0 {
+				log.Warn().Str("exchange", string(conn.ID())).Msg("Exchange does not support book aggregation")
+			}
+			continue
+		}
+		for symbol, step := range aggregation[string(conn.ID())] {
+			if err := setter.SetAggregation(symbol, step); err != nil {
+				log.Warn().Err(err).Str("exchange", string(conn.ID())).Str("symbol", symbol).Msg("Invalid book aggregation")
+			}
+		}
+	}
+	adminServer.RegisterAggregation(connectors)
+
+	// Published depth: PUBLISH_DEPTH=5 caps every symbol's book in Redis,
+	// PUBLISH_DEPTH_SYMBOLS=binance:BTCUSDT=1,okx:ETH-USDT-SWAP=full
+	// overrides single symbols. Levels are 1, 5, 20 or full; the exchange
+	// subscription keeps its own depth for spreads and the index.
+	if levels, err := publisher.ParseDepth(getEnv("PUBLISH_DEPTH", "full")); err == nil {
+		pub.SetDefaultDepth(levels)
+	} else {
+		log.Warn().Err(err).Msg("Invalid PUBLISH_DEPTH, publishing full depth")
+	}
+	for _, entry := range strings.Split(getEnv("PUBLISH_DEPTH_SYMBOLS", ""), ",") {
+		ex, rest, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		symbol, depth, ok := strings.Cut(rest, "=")
+		if !ok {
+			continue
+		}
+		levels, err := publisher.ParseDepth(depth)
+		if err != nil {
+			log.Warn().Err(err).Str("exchange", ex).Str("symbol", symbol).Msg("Invalid publish depth")
+			continue
+		}
+		pub.SetDepth(connector.ExchangeID(strings.ToLower(ex)), symbol, levels)
+	}
+	adminServer.RegisterPublishDepth(pub)
+
+	for _, conn := range connectors {
+		if connector.GetCapabilities(conn.ID()).QuoteOnly() {
+			log.Info().Str("exchange", string(conn.ID())).Msg("Exchange is quote-only (no trading client)")
+		}
+	}
+
 	// Create spread discovery service
 	spreadDiscovery := spread.NewSpreadDiscovery(norm, pub)
+	// Venues quoting an asset per 1000 units (1000PEPEUSDT) pair with those
+	// quoting the unit, priced in base units; the map fills from the
+	// instruments the REST loader fetches
+	spreadDiscovery.SetSymbolMap(norm.Symbols())
+
+	// Only the best SPREAD_TOP_N spreads per symbol by bps and by net edge
+	// are kept, so memory stays flat as venues and symbols are added
+	if v, err := strconv.Atoi(getEnv("SPREAD_TOP_N", strconv.Itoa(spread.DefaultTopN))); err == nil {
+		spreadDiscovery.SetTopN(v)
+	}
+
+	// Unit economics: FEE_TIERS=binance:2.5,okx:3 overrides taker fees in bps
+	economics := spread.DefaultEconomicsConfig()
+	for _, tier := range strings.Split(getEnv("FEE_TIERS", ""), ",") {
+		ex, fee, ok := strings.Cut(strings.TrimSpace(tier), ":")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fee, 64); err == nil {
+			economics.TakerFeeBps[connector.ExchangeID(strings.ToLower(ex))] = v
+		}
+	}
+	// SPOT_FEE_TIERS=binance:10,okx:8 sets spot taker fees for basis trades
+	for _, tier := range strings.Split(getEnv("SPOT_FEE_TIERS", ""), ",") {
+		ex, fee, ok := strings.Cut(strings.TrimSpace(tier), ":")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fee, 64); err == nil {
+			economics.SpotTakerFeeBps[connector.ExchangeID(strings.ToLower(ex))] = v
+		}
+	}
+	if v, err := strconv.ParseFloat(getEnv("TRANSFER_COST_USD", "2"), 64); err == nil {
+		economics.TransferCostUSD = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("TRADE_NOTIONAL_USD", "10000"), 64); err == nil {
+		economics.NotionalUSD = v
+	}
+	if v, err := time.ParseDuration(getEnv("HOLDING_PERIOD", "8h")); err == nil {
+		economics.HoldingPeriod = v
+	}
+	if v, err := time.ParseDuration(getEnv("TRANSFER_TIME", "1h")); err == nil {
+		economics.TransferTime = v
+	}
+	spreadDiscovery.SetEconomics(economics)
+
+	// Stale legs shrink the published effective edge; QUOTE_AGE_HALF_LIFE=0 disables
+	quoteAge := spread.DefaultQuoteAgeConfig()
+	if v, err := time.ParseDuration(getEnv("QUOTE_AGE_GRACE", "250ms")); err == nil {
+		quoteAge.Grace = v
+	}
+	if v, err := time.ParseDuration(getEnv("QUOTE_AGE_HALF_LIFE", "2s")); err == nil {
+		quoteAge.HalfLife = v
+	}
+	spreadDiscovery.SetQuoteAge(quoteAge)
+
+	// Spread keys expire once their quotes would have decayed away, so a
+	// crashed instance leaves nothing that looks live; SPREAD_TTL overrides
+	spreadTTL := publisher.DefaultSpreadTTLConfig()
+	if expiry := quoteAge.Expiry(); expiry > 0 {
+		spreadTTL.Data = expiry
+	}
+	if v, err := time.ParseDuration(getEnv("SPREAD_TTL", "")); err == nil && v > 0 {
+		spreadTTL.Data = v
+	}
+	if v, err := time.ParseDuration(getEnv("SPREAD_LIST_TTL", "30s")); err == nil && v > 0 {
+		spreadTTL.List = v
+	}
+	pub.SetSpreadTTL(spreadTTL)
+	log.Info().Dur("data", spreadTTL.Data).Dur("list", spreadTTL.List).Msg("Spread key TTLs")
+
+	// Skew-aware scoring: spreads adding to positions executors report are
+	// down-ranked, those unwinding them up-ranked
+	inventoryStore := inventory.New(pub.Client(), inventory.DefaultConfig())
+	if err := inventoryStore.Refresh(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load positions")
+	}
+	inventoryConfig := spread.DefaultInventoryConfig()
+	if v, err := strconv.ParseFloat(getEnv("INVENTORY_SKEW_SCALE_USD", "50000"), 64); err == nil {
+		inventoryConfig.ScaleUSD = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("INVENTORY_SKEW_WEIGHT", "0.5"), 64); err == nil {
+		inventoryConfig.Weight = v
+	}
+	spreadDiscovery.SetInventory(inventoryStore, inventoryConfig)
+	adminServer.RegisterInventory(inventoryStore)
+
+	// The universe config's thresholds replace the built-in defaults
+	builtinThresholds := spreadDiscovery.Thresholds()
+	spreadDiscovery.SetThresholds(universeThresholds(builtinThresholds, uni))
+
+	// Thresholds, symbol tiers and exchange pair mutes live in Redis so the
+	// backend, the admin API or another instance can change them at runtime;
+	// writes are announced and applied within seconds
+	settingsConfig := settings.DefaultConfig()
+	settingsConfig.Env = environment
+	if v, err := time.ParseDuration(getEnv("SETTINGS_REFRESH_INTERVAL", "10s")); err == nil && v > 0 {
+		settingsConfig.RefreshInterval = v
+	}
+	settingsStore := settings.New(pub.Client(), settingsConfig, spreadDiscovery)
+	if err := settingsStore.Refresh(context.Background(), "start"); err != nil {
+		log.Warn().Err(err).Msg("Failed to load runtime settings, using defaults")
+	}
+	adminServer.RegisterSettings(settingsStore)
+
+	// Operators can mute exchange pairs at runtime, e.g. while a venue misbehaves
+	adminServer.RegisterMutes(spreadDiscovery, settingsStore)
+	adminServer.RegisterSpreads(spreadDiscovery)
+
+	// Executors and operators tag published opportunities as false
+	// positives; venue symbols that keep producing them need wider spreads
+	var feedbackStore *feedback.Store
+	if getEnv("FALSE_POSITIVE_FEEDBACK", "true") == "true" {
+		feedbackConfig := feedback.DefaultConfig()
+		if v, err := time.ParseDuration(getEnv("FALSE_POSITIVE_WINDOW", "168h")); err == nil && v > 0 {
+			feedbackConfig.Window = v
+		} else {
+			log.Warn().Str("value", getEnv("FALSE_POSITIVE_WINDOW", "")).Msg("Ignoring invalid FALSE_POSITIVE_WINDOW")
+		}
+		if v, err := strconv.ParseFloat(getEnv("FALSE_POSITIVE_HALF_AT", "5"), 64); err == nil && v > 0 {
+			feedbackConfig.HalfAt = v
+		} else {
+			log.Warn().Str("value", getEnv("FALSE_POSITIVE_HALF_AT", "")).Msg("Ignoring invalid FALSE_POSITIVE_HALF_AT")
+		}
+		feedbackStore = feedback.New(pub.Client(), feedbackConfig)
+		if err := feedbackStore.Load(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to load false positive tags")
+		}
+		spreadDiscovery.SetQualitySource(feedbackStore)
+		adminServer.RegisterFeedback(feedbackStore)
+	}
+
+	// Venues are muted for their known maintenance windows, e.g.
+	// "bybit:sun@04:00/30m,okx:daily@08:00/5m", and not reconnected until
+	// the window is over
+	maintenanceConfig := maintenance.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("MAINTENANCE_LEAD", "1m")); err == nil && v >= 0 {
+		maintenanceConfig.Lead = v
+	}
+	maintenanceWindows, err := maintenance.ParseWindows(getEnv("MAINTENANCE_WINDOWS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid MAINTENANCE_WINDOWS")
+	}
+	maintenanceScheduler := maintenance.NewScheduler(maintenanceConfig, spreadDiscovery, maintenanceWindows)
+	adminServer.RegisterMaintenance(maintenanceScheduler)
+
+	// Symbols in maintenance, reduce-only or settlement are kept out of
+	// discovery and execution; transitions are published for executors
+	statusConfig := symbolstatus.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("SYMBOL_STATUS_POLL_INTERVAL", "1m")); err == nil && v > 0 {
+		statusConfig.PollInterval = v
+	}
+	statusTracker := symbolstatus.NewTracker(connectors, statusConfig)
+	statusTracker.SetHandler(func(t symbolstatus.Transition) {
+		data, err := json.Marshal(t)
+		if err != nil {
+			return
+		}
+		if err := pub.PublishSymbolStatus(data); err != nil {
+			log.Error().Err(err).Msg("Failed to publish symbol status")
+		}
+	})
+	spreadDiscovery.SetStatusGate(statusTracker)
+	adminServer.RegisterSymbolStatus(statusTracker)
+
+	// EXCHANGE_MODES=lbank=md-only,... keeps a venue quoting but hard-blocks it
+	// from the execution path, whatever credentials exist for it
+	modeSettings, err := execution.ParseModes(getEnv("EXCHANGE_MODES", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid EXCHANGE_MODES")
+	}
+	exchangeModes := execution.NewModes(modeSettings)
+	for id, mode := range modeSettings {
+		log.Info().Str("exchange", string(id)).Str("mode", mode).Msg("Exchange execution mode")
+	}
+	adminServer.RegisterModes(exchangeModes)
+
+	// Executors revalidate a spread against the freshest quotes before sending legs
+	validationConfig := execution.DefaultValidationConfig()
+	if v, err := strconv.ParseFloat(getEnv("EXEC_MIN_EDGE_FRACTION", "0.5"), 64); err == nil {
+		validationConfig.MinEdgeFraction = v
+	}
+	if v, err := time.ParseDuration(getEnv("EXEC_MAX_QUOTE_AGE", "250ms")); err == nil {
+		validationConfig.MaxCacheAge = v
+	}
+	validator := execution.NewValidator(spreadDiscovery, connectors, validationConfig)
+	validator.SetStatusGate(statusTracker)
+	validator.SetModes(exchangeModes)
+
+	// Block entries whose locally estimated liquidation price sits too
+	// close to mark. EXEC_LEVERAGE_VENUES=binance=5,okx=2 overrides EXEC_LEVERAGE.
+	liquidationConfig := execution.DefaultLiquidationConfig()
+	if v, err := strconv.ParseFloat(getEnv("EXEC_LIQ_MIN_DISTANCE_PCT", "15"), 64); err == nil {
+		liquidationConfig.MinDistancePct = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("EXEC_LEVERAGE", "3"), 64); err == nil && v > 0 {
+		liquidationConfig.Leverage = v
+	}
+	liquidationConfig.VenueLeverage = make(map[connector.ExchangeID]float64)
+	for _, entry := range strings.Split(getEnv("EXEC_LEVERAGE_VENUES", ""), ",") {
+		ex, lev, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(lev, 64); err == nil && v > 0 {
+			liquidationConfig.VenueLeverage[connector.ExchangeID(strings.ToLower(ex))] = v
+		} else {
+			log.Warn().Str("exchange", ex).Str("leverage", lev).Msg("Ignoring invalid venue leverage")
+		}
+	}
+	liquidationGuard := execution.NewLiquidationGuard(spreadDiscovery, spreadDiscovery, liquidationConfig)
+	validator.SetLiquidationGuard(liquidationGuard)
+	adminServer.RegisterExecution(spreadDiscovery, validator)
+
+	// Position mode, margin mode and leverage are verified on every
+	// subscribed venue symbol at startup and daily; entries are refused
+	// until they match. Leverage defaults to EXEC_LEVERAGE(_VENUES), and
+	// PREFLIGHT_VENUES=okx=one_way/isolated/5 overrides per venue.
+	var preflight *execution.Preflight
+	if getEnv("PREFLIGHT", "true") == "true" {
+		preflightConfig := execution.DefaultPreflightConfig()
+		if v := strings.ToLower(getEnv("PREFLIGHT_POSITION_MODE", connector.PositionModeHedge)); v == connector.PositionModeHedge || v == connector.PositionModeOneWay {
+			preflightConfig.Default.PositionMode = v
+		} else {
+			log.Fatal().Str("mode", v).Msg("Invalid PREFLIGHT_POSITION_MODE, want hedge or one_way")
+		}
+		if v := strings.ToLower(getEnv("PREFLIGHT_MARGIN_MODE", connector.MarginModeCross)); v == connector.MarginModeCross || v == connector.MarginModeIsolated {
+			preflightConfig.Default.MarginMode = v
+		} else {
+			log.Fatal().Str("mode", v).Msg("Invalid PREFLIGHT_MARGIN_MODE, want cross or isolated")
+		}
+		if v, err := time.ParseDuration(getEnv("PREFLIGHT_INTERVAL", "24h")); err == nil && v > 0 {
+			preflightConfig.Interval = v
+		}
+		preflightConfig.Default.Leverage = liquidationConfig.Leverage
+		for id, lev := range liquidationConfig.VenueLeverage {
+			preflightConfig.Venues[id] = execution.AccountExpectation{Leverage: lev}
+		}
+		preflightVenues, err := execution.ParseAccountExpectations(getEnv("PREFLIGHT_VENUES", ""))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid PREFLIGHT_VENUES")
+		}
+		for id, e := range preflightVenues {
+			if e.Leverage == 0 {
+				e.Leverage = preflightConfig.Venues[id].Leverage
+			}
+			preflightConfig.Venues[id] = e
+		}
+		preflight = execution.NewPreflight(connectors, preflightConfig)
+		preflight.SetModes(exchangeModes)
+		validator.SetPreflight(preflight)
+		adminServer.RegisterPreflight(preflight)
+	}
+	hedgeRanker := execution.NewHedgeRanker(spreadDiscovery, economics, execution.DefaultHedgeRankConfig())
+	hedgeRanker.SetStatusGate(statusTracker)
+	hedgeRanker.SetModes(exchangeModes)
+	migrations := execution.NewMigrations(pub.Client())
+	adminServer.RegisterHedging(hedgeRanker, migrations)
+
+	// Migration previews compare holding a leg on another venue with the cost
+	// of rolling it; md-ingest has no trading clients so it never executes them
+	migrateConfig := execution.DefaultMigrateConfig()
+	if v, err := strconv.ParseFloat(getEnv("MIGRATE_MAX_SLIPPAGE_BPS", "10"), 64); err == nil {
+		migrateConfig.MaxSlippageBps = v
+	}
+	migrator := execution.NewMigrator(spreadDiscovery, spreadDiscovery, economics, nil, migrateConfig)
+	migrator.SetStatusGate(statusTracker)
+	migrator.SetModes(exchangeModes)
+	adminServer.RegisterMigration(migrator, migrations)
+
+	// Single-leg orders are split across venues by book, fee and what
+	// SOR_BALANCES=binance=50000,okx=20000 (USD) allows on each; venues
+	// not listed are uncapped. md-ingest only previews the plans.
+	routerBalances, err := execution.ParseBalances(getEnv("SOR_BALANCES", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid SOR_BALANCES")
+	}
+	router := execution.NewRouter(spreadDiscovery, economics, nil, execution.DefaultRouterConfig())
+	router.SetBalances(routerBalances)
+	router.SetStatusGate(statusTracker)
+	router.SetModes(exchangeModes)
+	adminServer.RegisterRouter(router)
+
+	// Record published spreads into daily aggregates for the history query API
+	historyConfig := history.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("HISTORY_RETENTION", "720h")); err == nil {
+		historyConfig.Retention = v
+	}
+	historyStore := history.NewStore(pub.Client(), historyConfig)
+	historyStore.SetRenames(norm)
+	adminServer.RegisterHistory(historyStore)
+
+	// Recorded trades, books, funding and bars are exported as CSV, JSONL or
+	// Parquet for research, up to EXPORT_MAX_ROWS rows per request
+	exportConfig := export.DefaultConfig()
+	if v, err := strconv.Atoi(getEnv("EXPORT_MAX_ROWS", "1000000")); err == nil && v > 0 {
+		exportConfig.MaxRows = v
+	}
+	exporter := export.New(pub.Client(), exportConfig)
+	exporter.SetBarSource(historyStore)
+	adminServer.RegisterExport(exporter)
+
+	// Spread open and close events are POSTed, HMAC-signed, to webhooks
+	// registered through the admin API, retrying up to
+	// WEBHOOK_MAX_ATTEMPTS times with backoff
+	webhookConfig := webhook.DefaultConfig()
+	if v, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "6")); err == nil && v > 0 {
+		webhookConfig.MaxAttempts = v
+	}
+	if v, err := time.ParseDuration(getEnv("WEBHOOK_TIMEOUT", "5s")); err == nil && v > 0 {
+		webhookConfig.Timeout = v
+	}
+	webhooks := webhook.NewDispatcher(pub.Client(), webhookConfig)
+	if err := webhooks.Load(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load webhooks")
+	}
+	adminServer.RegisterWebhooks(webhooks)
+	spreadDiscovery.SetSpreadsHandler(func(spreads []*spread.SpreadOpportunity) {
+		historyStore.Record(spreads)
+		webhooks.Record(spreads)
+		if backend == publisher.BackendJetStream {
+			for _, sp := range spreads {
+				data, err := json.Marshal(sp)
+				if err != nil {
+					continue
+				}
+				if err := marketPub.SetSpread(sp.ID, data, spread.QuoteAge(sp)); err != nil {
+					log.Error().Err(err).Str("spread", sp.ID).Msg("Failed to publish spread to JetStream")
+				}
+			}
+		}
+	})
+
+	// Exchange pairs whose spreads move together are clustered and published
+	// so risk doesn't stack correlated opportunities
+	correlationConfig := history.DefaultCorrelationConfig()
+	if v, err := time.ParseDuration(getEnv("CORRELATION_INTERVAL", "15m")); err == nil && v > 0 {
+		correlationConfig.PublishInterval = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("CORRELATION_MIN", "0.6"), 64); err == nil {
+		correlationConfig.MinCorrelation = v
+	}
+	if v, err := strconv.Atoi(getEnv("CORRELATION_DAYS", "1")); err == nil && v > 0 && v <= history.MaxSessionDays {
+		correlationConfig.Days = v
+	}
+
+	// Order rate budget shared by strategies trading the same accounts; the
+	// buckets live in Redis, md-ingest only reports their state and scales
+	// degraded venues down
+	rateBudget := ratebudget.New(pub.Client(), ratebudget.DefaultConfig())
+	venueHealth.SetThrottle(rateBudget)
+	adminServer.RegisterRateBudget(rateBudget)
+	claimConfig := claim.DefaultConfig()
+	claimConfig.Observe = func(result string) { metrics.OpportunityClaims.WithLabelValues(result).Inc() }
+	adminServer.RegisterClaims(claim.New(pub.Client(), claimConfig))
+
+	// Tenants are the backend users holding credentials. Each gets its own
+	// spread stream limited to venues it holds keys on; executors route a
+	// tenant's orders with its own keys only (execution.TenantRouter).
+	tenantRefresh := 5 * time.Minute
+	if v, err := time.ParseDuration(getEnv("TENANT_REFRESH_INTERVAL", "5m")); err == nil && v > 0 {
+		tenantRefresh = v
+	}
+	tenantStore := credentials.NewTenantStore(credsFetcher, tenantRefresh)
+	spreadDiscovery.SetTenants(tenantStore)
+	adminServer.RegisterTenants(tenantStore)
+
+	// Create index price builder
+	indexConfig := index.DefaultConfig()
+	if v := getEnv("INDEX_CONSTITUENTS", ""); v != "" {
+		for _, ex := range strings.Split(v, ",") {
+			indexConfig.Constituents = append(indexConfig.Constituents, connector.ExchangeID(strings.TrimSpace(ex)))
+		}
+	}
+	if v, err := strconv.ParseFloat(getEnv("INDEX_OUTLIER_BPS", "100"), 64); err == nil {
+		indexConfig.OutlierBps = v
+	}
+	indexBuilder := index.NewBuilder(indexConfig, pub)
+
+	// Exposure is priced off the index of the underlying's USDT perp
+	adminServer.RegisterExposure(inventoryStore, indexBuilder.IndexPrice)
+
+	// Gross and net notional per underlying across all venues is capped:
+	// NOTIONAL_CAPS=BTC=5000000/1000000 (gross/net USD) overrides the
+	// NOTIONAL_MAX_GROSS_USD / NOTIONAL_MAX_NET_USD defaults, 0 = uncapped
+	notionalConfig := execution.DefaultNotionalLimitConfig()
+	notionalConfig.EntryNotionalUSD = economics.NotionalUSD
+	if v, err := strconv.ParseFloat(getEnv("NOTIONAL_MAX_GROSS_USD", "0"), 64); err == nil && v >= 0 {
+		notionalConfig.Default.MaxGrossUSD = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("NOTIONAL_MAX_NET_USD", "0"), 64); err == nil && v >= 0 {
+		notionalConfig.Default.MaxNetUSD = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("NOTIONAL_WARN_FRACTION", "0.8"), 64); err == nil {
+		notionalConfig.WarnFraction = v
+	}
+	notionalConfig.Underlyings, err = execution.ParseNotionalCaps(getEnv("NOTIONAL_CAPS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid NOTIONAL_CAPS")
+	}
+	notionalLimits := execution.NewNotionalLimits(inventoryStore, indexBuilder.IndexPrice, notionalConfig)
+	validator.SetNotionalLimits(notionalLimits)
+	router.SetNotionalLimits(notionalLimits)
+	adminServer.RegisterNotionalLimits(notionalLimits)
+
+	// A drop of DRAWDOWN_MAX_USD from the rolling DRAWDOWN_WINDOW peak of
+	// strategy PnL halves sizes and caps (DRAWDOWN_ACTION=halve) or halts
+	// entries (halt) until acknowledged through the admin API
+	drawdownConfig := execution.DefaultDrawdownConfig()
+	if v, err := strconv.ParseFloat(getEnv("DRAWDOWN_MAX_USD", "0"), 64); err == nil && v >= 0 {
+		drawdownConfig.MaxDrawdownUSD = v
+	}
+	if v, err := time.ParseDuration(getEnv("DRAWDOWN_WINDOW", "24h")); err == nil && v > 0 {
+		drawdownConfig.Window = v
+	}
+	if v := getEnv("DRAWDOWN_ACTION", execution.DeRiskHalve); execution.ValidDeRiskAction(v) {
+		drawdownConfig.Action = v
+	} else {
+		log.Fatal().Str("action", v).Msg("Invalid DRAWDOWN_ACTION, want halve or halt")
+	}
+	drawdownGuard := execution.NewDrawdownGuard(pub.Client(), drawdownConfig)
+	if err := drawdownGuard.Load(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load de-risk state")
+	}
+	notionalLimits.SetDrawdownGuard(drawdownGuard)
+	validator.SetDrawdownGuard(drawdownGuard)
+	router.SetDrawdownGuard(drawdownGuard)
+	adminServer.RegisterDrawdown(drawdownGuard)
+
+	// Equity held on a venue (balances plus unrealized PnL, written by
+	// executors) is capped as a share of the total across venues:
+	// VENUE_EXPOSURE_CAPS=lbank=10,mexc=15 (percent) overrides the
+	// VENUE_EXPOSURE_MAX_PCT default, 0 = uncapped. New entries with a leg
+	// on a venue at its cap are scaled by VENUE_EXPOSURE_AT_CAP_SIZE, 0
+	// rejects them.
+	creditConfig := execution.DefaultCreditConfig()
+	if v, err := strconv.ParseFloat(getEnv("VENUE_EXPOSURE_MAX_PCT", "0"), 64); err == nil && v >= 0 && v <= 100 {
+		creditConfig.DefaultPct = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("VENUE_EXPOSURE_WARN_FRACTION", "0.8"), 64); err == nil {
+		creditConfig.WarnFraction = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("VENUE_EXPOSURE_AT_CAP_SIZE", "0.25"), 64); err == nil && v >= 0 && v <= 1 {
+		creditConfig.AtCapSizeFactor = v
+	}
+	creditConfig.Venues, err = execution.ParseCreditCaps(getEnv("VENUE_EXPOSURE_CAPS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid VENUE_EXPOSURE_CAPS")
+	}
+	creditLimits := execution.NewCreditLimits(pub.Client(), creditConfig)
+	if err := creditLimits.Refresh(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to read venue equity")
+	}
+	validator.SetCreditLimits(creditLimits)
+	adminServer.RegisterCreditLimits(creditLimits)
+
+	// Deposit and withdrawal completion times per asset, chain and venue
+	// pair, read from venues with API credentials every
+	// TRANSFER_POLL_INTERVAL; spreads' rebalance times come from these
+	// instead of the economics' fixed TRANSFER_TIME
+	transferConfig := execution.DefaultTransferConfig()
+	if v, err := time.ParseDuration(getEnv("TRANSFER_POLL_INTERVAL", "2m")); err == nil && v > 0 {
+		transferConfig.PollInterval = v
+	}
+	if v, err := time.ParseDuration(getEnv("TRANSFER_LOOKBACK", "48h")); err == nil && v > 0 {
+		transferConfig.Lookback = v
+	}
+	if v, err := strconv.Atoi(getEnv("TRANSFER_MIN_SAMPLES", "3")); err == nil && v > 0 {
+		transferConfig.MinSamples = v
+	}
+	transferLatencies := execution.NewTransferLatencies(pub.Client(), connectors, transferConfig)
+	if creds := getCredentialsForExchange("bybit"); creds != nil {
+		transferLatencies.SetFetcher(connector.Bybit, bybit.NewRESTClient(bybit.RESTClientConfig{APIKey: creds.APIKey, APISecret: creds.APISecret}))
+	}
+	if creds := getCredentialsForExchange("coinex"); creds != nil {
+		transferLatencies.SetFetcher(connector.CoinEx, coinex.NewRESTClient(coinex.RESTClientConfig{APIKey: creds.APIKey, SecretKey: creds.APISecret}))
+	}
+	if err := transferLatencies.Load(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load transfer latencies")
+	}
+	adminServer.RegisterTransferLatencies(transferLatencies)
+	spreadDiscovery.SetTransferTimes(transferLatencies)
+
+	// Published levels and trades carry USD notional, contracts converted
+	// with the contract size: NOTIONAL_PRICE=index (default), level or off
+	if mode, err := publisher.ParseNotionalMode(getEnv("NOTIONAL_PRICE", publisher.NotionalIndex)); err == nil {
+		pub.SetNotional(mode, indexBuilder)
+	} else {
+		log.Warn().Err(err).Msg("Invalid NOTIONAL_PRICE, publishing without notional")
+	}
+
+	// OHLCV bars per venue and per canonical built from the trade stream:
+	// BAR_INTERVALS=1s,1m. Bars are keyed and closed by exchange event time;
+	// BAR_GRACE is how late a trade may be stamped and still count.
+	barConfig := bars.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("BAR_GRACE", "500ms")); err == nil && v >= 0 {
+		barConfig.Grace = v
+	}
+	if v, err := time.ParseDuration(getEnv("BAR_IDLE_TIMEOUT", "2s")); err == nil && v > 0 {
+		barConfig.IdleTimeout = v
+	}
+	if v := getEnv("BAR_INTERVALS", ""); v != "" {
+		barConfig.Intervals = nil
+		for _, s := range strings.Split(v, ",") {
+			if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil && d > 0 {
+				barConfig.Intervals = append(barConfig.Intervals, d)
+			}
+		}
+	}
+	barConfig.Consolidated = getEnv("BAR_CONSOLIDATED", "true") == "true"
+	barBuilder := bars.NewBuilder(barConfig, pub)
+
+	// Candles backfilled at startup and live bars of the same interval are
+	// kept in the history store: KLINE_BACKFILL_INTERVAL=1m, KLINE_BACKFILL_LOOKBACK=24h
+	klineConfig := loader.DefaultKlineBackfillConfig()
+	if v, err := time.ParseDuration(getEnv("KLINE_BACKFILL_INTERVAL", "1m")); err == nil && v > 0 {
+		klineConfig.Interval = v
+	}
+	if v, err := time.ParseDuration(getEnv("KLINE_BACKFILL_LOOKBACK", "24h")); err == nil {
+		klineConfig.Lookback = v
+	}
+	recordBars := func(bs []*bars.Bar) {
+		if err := historyStore.RecordBars(context.Background(), bs); err != nil {
+			log.Error().Err(err).Msg("Failed to record bar history")
+		}
+	}
+	historyInterval := bars.IntervalName(klineConfig.Interval)
+	barBuilder.SetHandler(func(closed []*bars.Bar) {
+		var venue []*bars.Bar
+		for _, bar := range closed {
+			if bar.Exchange != "" && bar.Interval == historyInterval {
+				venue = append(venue, bar)
+			}
+		}
+		if len(venue) > 0 {
+			recordBars(venue)
+		}
+	})
+
+	// Poll funding over REST for venues that don't push it over WebSocket
+	fundingConfig := funding.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("FUNDING_POLL_INTERVAL", "1m")); err == nil {
+		fundingConfig.DefaultInterval = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("FUNDING_SYMBOL_RATE", "2"), 64); err == nil {
+		fundingConfig.DefaultSymbolRate = v
+	}
+	// Announce funding settlements and fire pre-settlement actions, e.g.
+	// FUNDING_ACTIONS=reduce:0.0005:0.5,flip:0.002
+	settlementConfig := funding.DefaultSettlementConfig()
+	if v, err := time.ParseDuration(getEnv("FUNDING_SETTLEMENT_HORIZON", "1h")); err == nil {
+		settlementConfig.Horizon = v
+	}
+	if v, err := time.ParseDuration(getEnv("FUNDING_ACTION_LEAD", "5m")); err == nil {
+		settlementConfig.ActionLead = v
+	}
+	if rules, err := funding.ParseRules(getEnv("FUNDING_ACTIONS", "")); err != nil {
+		log.Warn().Err(err).Msg("Ignoring invalid FUNDING_ACTIONS")
+	} else {
+		settlementConfig.Rules = rules
+	}
+	settlementScheduler := funding.NewScheduler(settlementConfig, pub)
+	settlementScheduler.SetPositions(inventoryStore)
+	adminServer.RegisterSettlements(settlementScheduler)
+
+	// Funding rates are published with their 8h equivalent and APR. Each
+	// contract's interval comes from venue metadata or the step of its next
+	// funding time; FUNDING_INTERVALS=kucoin=4,binance:XYZUSDT=1 sets rules
+	// (a symbol rule beats everything, a venue rule only beats the default)
+	intervalConfig, err := funding.ParseIntervalRules(getEnv("FUNDING_INTERVALS", ""))
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring invalid FUNDING_INTERVALS")
+		intervalConfig = funding.DefaultIntervalConfig()
+	}
+	fundingIntervals := funding.NewIntervals(intervalConfig)
+	statusTracker.SetInstrumentsHandler(fundingIntervals.Learn)
+	adminServer.RegisterFundingIntervals(fundingIntervals)
+
+	// Event bus between connectors and consumers. Each consumer has its own
+	// queue: BUS_BUFFER=4096 sizes them, BUS_POLICIES=index=drop_oldest,bars=block
+	// picks what happens when one is full (block, drop_newest, drop_oldest)
+	eventBus := bus.New()
+	eventBus.SetRenamer(norm)
+	eventBus.SetFundingNormalizer(fundingIntervals)
+	busConfig := bus.DefaultSubscribeConfig()
+	if v, err := strconv.Atoi(getEnv("BUS_BUFFER", "4096")); err == nil && v > 0 {
+		busConfig.Buffer = v
+	}
+	busPolicies := make(map[string]bus.Policy)
+	for _, entry := range strings.Split(getEnv("BUS_POLICIES", ""), ",") {
+		name, policy, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if p, err := bus.ParsePolicy(policy); err == nil {
+			busPolicies[name] = p
+		} else {
+			log.Warn().Err(err).Str("subscriber", name).Msg("Ignoring invalid bus policy")
+		}
+	}
+	// Full in-memory books for debugging and executors needing more depth
+	// than is published
+	bookStore := books.NewStore()
+	adminServer.RegisterBooks(bookStore)
+
+	// Spread discovery prices the economics notional through full books:
+	// the ones connectors keep from snapshots and deltas, and the book store
+	// for venues whose connectors emit whole books
+	bookSources := orderbook.NewSources(bookStore)
+	for _, conn := range connectors {
+		if keeper, ok := conn.(orderbook.BookKeeper); ok {
+			if engine := keeper.Books(); engine != nil {
+				bookSources.Add(conn.ID(), engine)
+			}
+		}
+	}
+	spreadDiscovery.SetBooks(bookSources)
+
+	// Feeds whose message rate collapses against its learned baseline while
+	// the socket still reports connected are alerted on, e.g.
+	// FEED_WATCH_DROP_RATIO=0.2 of the baseline for FEED_WATCH_INTERVAL=10s x3
+	feedConfig := feedwatch.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("FEED_WATCH_INTERVAL", "10s")); err == nil && v > 0 {
+		feedConfig.Interval = v
+	}
+	if v, err := time.ParseDuration(getEnv("FEED_WATCH_WARMUP", "5m")); err == nil {
+		feedConfig.Warmup = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("FEED_WATCH_DROP_RATIO", "0.2"), 64); err == nil && v > 0 && v < 1 {
+		feedConfig.DropRatio = v
+	}
+	feedWatch := feedwatch.New(connectors, feedConfig, pub)
+	adminServer.RegisterFeedWatch(feedWatch)
+
+	subscribeConsumers(eventBus, func(name string) bus.SubscribeConfig {
+		cfg := busConfig
+		if p, ok := busPolicies[name]; ok {
+			cfg.Policy = p
+		}
+		return cfg
+	}, marketPub, spreadDiscovery, indexBuilder, barBuilder, settlementScheduler, bookStore, feedWatch)
+	adminServer.RegisterBus(eventBus)
+
+	var soakRunner *soak.Runner
+	if *soakMode {
+		soakConfig := soak.DefaultConfig()
+		soakConfig.Duration = *soakDuration
+		soakConfig.Warmup = min(soakConfig.Warmup, *soakDuration/4)
+		soakRunner = soak.New(soakConfig, spreadDiscovery)
+		eventBus.Orderbooks.Subscribe("soak", busConfig, soakRunner.HandleOrderbook)
+		eventBus.Trades.Subscribe("soak", busConfig, soakRunner.HandleTrade)
+	}
+
+	// Capture a window of discovery input; replay it with
+	// REPLAY_FRAMES=<file> go test ./internal/replay -run Capture
+	var frameRecorder *replay.Recorder
+	if *recordPath != "" {
+		if *recordRotate > 0 {
+			frameRecorder, err = replay.NewRotating(*recordPath, time.Now, *recordRotate, *recordWindow)
+		} else {
+			frameRecorder, err = replay.Create(*recordPath, *recordWindow)
+		}
+		if err != nil {
+			log.Fatal().Err(err).Str("path", *recordPath).Msg("Failed to create frame recording")
+		}
+		deltaConfig := books.DefaultDeltaConfig()
+		deltaConfig.SnapshotEvery = *recordSnapshotEvery
+		frameRecorder.SetDeltaConfig(deltaConfig)
+		eventBus.Orderbooks.Subscribe("recorder", busConfig, frameRecorder.HandleOrderbook)
+		eventBus.Funding.Subscribe("recorder", busConfig, frameRecorder.HandleFundingRate)
+		log.Info().Str("path", *recordPath).Dur("window", *recordWindow).Dur("rotate", *recordRotate).Msg("Recording frames")
+	}
+
+	// Completed recordings and exports dropped in ARCHIVE_DIR move to an
+	// S3-compatible bucket (GCS via its XML API and HMAC keys); fetch them
+	// for replay with REPLAY_ARCHIVE_FROM, see internal/replay
+	var archiver *archive.Archiver
+	if dir := getEnv("ARCHIVE_DIR", ""); dir != "" {
+		bucket, err := archive.NewS3(archive.S3Config{
+			Endpoint:  getEnv("ARCHIVE_ENDPOINT", ""),
+			Region:    getEnv("ARCHIVE_REGION", "us-east-1"),
+			Bucket:    getEnv("ARCHIVE_BUCKET", ""),
+			AccessKey: getEnv("ARCHIVE_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+			SecretKey: getEnv("ARCHIVE_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid archive bucket config")
+		}
+		archiveConfig := archive.DefaultConfig()
+		archiveConfig.Dir = dir
+		archiveConfig.Prefix = getEnv("ARCHIVE_PREFIX", archiveConfig.Prefix)
+		if v, err := time.ParseDuration(getEnv("ARCHIVE_KEEP_LOCAL", "0")); err == nil && v >= 0 {
+			archiveConfig.KeepLocal = v
+		}
+		if v, err := time.ParseDuration(getEnv("ARCHIVE_INTERVAL", "1m")); err == nil && v > 0 {
+			archiveConfig.Interval = v
+		}
+		// Retention is a lifecycle rule on the prefix, which replaces the
+		// bucket's lifecycle configuration: only for a dedicated bucket
+		if getEnv("ARCHIVE_LIFECYCLE", "false") == "true" {
+			rule := archive.Lifecycle{Prefix: archiveConfig.Prefix, StorageClass: getEnv("ARCHIVE_STORAGE_CLASS", "GLACIER_IR")}
+			rule.ExpireDays, _ = strconv.Atoi(getEnv("ARCHIVE_RETENTION_DAYS", "365"))
+			rule.TransitionDays, _ = strconv.Atoi(getEnv("ARCHIVE_TRANSITION_DAYS", "30"))
+			if err := bucket.SetLifecycle(context.Background(), rule); err != nil {
+				log.Error().Err(err).Msg("Failed to set archive lifecycle rule")
+			}
+		}
+		archiver = archive.New(bucket, archiveConfig)
+		log.Info().Str("dir", dir).Str("prefix", archiveConfig.Prefix).Msg("Archiving recordings to object storage")
+	}
+
+	// Strategies compiled in with a blank import of their package react to
+	// market data in-process: STRATEGIES=basis enables them and
+	// STRATEGY_CONFIG=basis.min_bps=5,basis.size=0.1 configures them
+	var strategyNames []string
+	for _, name := range strings.Split(getEnv("STRATEGIES", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			strategyNames = append(strategyNames, name)
+		}
+	}
+	strategyHost, err := strategy.NewHost(strategyNames, busConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create strategies")
+	}
+	strategyConfig, err := strategy.ParseConfig(getEnv("STRATEGY_CONFIG", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid STRATEGY_CONFIG")
+	}
+	if len(strategyNames) > 0 {
+		spreadDiscovery.SetSpreadHook(strategyHost.HandleSpread)
+	}
+	adminServer.RegisterStrategies(strategyHost)
+
+	fundingPoller := funding.NewPoller(connectors, fundingConfig)
+	fundingPoller.SetHandler(eventBus.PublishFunding)
+
+	// Alert on abnormal open interest builds and drops
+	oiConfig := openinterest.DefaultConfig()
+	if v, err := time.ParseDuration(getEnv("OI_POLL_INTERVAL", "1m")); err == nil {
+		oiConfig.PollInterval = v
+	}
+	if v, err := time.ParseDuration(getEnv("OI_WINDOW", "15m")); err == nil {
+		oiConfig.Window = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("OI_BUILD_PCT", "10"), 64); err == nil {
+		oiConfig.BuildPct = v
+	}
+	if v, err := strconv.ParseFloat(getEnv("OI_DROP_PCT", "10"), 64); err == nil {
+		oiConfig.DropPct = v
+	}
+	oiMonitor := openinterest.NewMonitor(connectors, oiConfig, pub)
+	adminServer.RegisterOpenInterest(oiMonitor)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Start spread discovery service
 	go spreadDiscovery.Start(ctx)
+	go indexBuilder.Start(ctx)
+	go barBuilder.Start(ctx)
+	go settlementScheduler.Start(ctx)
+	go statusTracker.Start(ctx)
+	go tenantStore.Start(ctx)
+	go apiVersions.Start(ctx)
+	go maintenanceScheduler.Start(ctx)
+	go historyStore.PublishClusters(ctx, correlationConfig)
+	var soakDone <-chan struct{}
+	if soakRunner != nil {
+		soakDone = soakRunner.Done()
+		go soakRunner.Start(ctx)
+	}
+
+	// Track memory per subsystem; the book cache trims depth under pressure
+	memManager.Register("book_cache", spreadDiscovery.MemoryUsage, spreadDiscovery.SetShedding)
+	memManager.Register("index_quotes", indexBuilder.MemoryUsage, nil)
+	memManager.Register("full_books", bookStore.MemoryUsage, nil)
+	go memManager.Start(ctx)
+	go flagStore.Start(ctx)
+	go settingsStore.Start(ctx)
+	// Universe reloads set the default thresholds; each mode below starts
+	// the reloader with whatever else a reload changes
+	applyUniverseThresholds := func(c *universe.Config) {
+		settingsStore.SetDefaults(universeThresholds(builtinThresholds, c))
+	}
+	go inventoryStore.Start(ctx)
+	go notionalLimits.Start(ctx)
+	go drawdownGuard.Start(ctx)
+	go creditLimits.Start(ctx)
+	if len(transferLatencies.Venues()) > 0 {
+		go transferLatencies.Start(ctx)
+	}
+	go venueHealth.Start(ctx)
+	go pub.RunPartialBook(ctx)
+	go pub.RunHeartbeats(ctx)
+	if feedbackStore != nil {
+		go feedbackStore.Start(ctx)
+	}
+	if archiver != nil {
+		go archiver.Start(ctx)
+	}
+	strategyHost.Start(ctx, eventBus, spreadDiscovery, strategyConfig)
+
+	// Books seeded from REST skip the bus, so renames are applied here
+	seedOrderbook := func(ob *connector.Orderbook) {
+		ob.Canonical = norm.Rename(ob.ExchangeID, ob.Canonical)
+		spreadDiscovery.SeedOrderbook(ob)
+	}
+
+	// Approximate spreads from all-tickers endpoints while books load; the
+	// leftovers no real book replaced are dropped when the window ends
+	if getEnv("COLD_START_TICKERS", "true") == "true" {
+		coldConfig := loader.DefaultColdStartConfig()
+		if v := getEnv("COLD_START_EXCHANGES", ""); v != "" {
+			coldConfig.Exchanges = nil
+			for _, ex := range strings.Split(v, ",") {
+				coldConfig.Exchanges = append(coldConfig.Exchanges, connector.ExchangeID(strings.ToLower(strings.TrimSpace(ex))))
+			}
+		}
+		if v, err := time.ParseDuration(getEnv("COLD_START_WINDOW", "30s")); err == nil {
+			coldConfig.Window = v
+		}
+		if v, err := time.ParseDuration(getEnv("COLD_START_INTERVAL", "5s")); err == nil && v > 0 {
+			coldConfig.Interval = v
+		}
+		coldStart := loader.NewColdStart(connectors, coldConfig)
+		coldStart.SetOrderbookHandler(seedOrderbook)
+		go func() {
+			coldStart.Run(ctx)
+			dropped := spreadDiscovery.DropApproximate()
+			metrics.ColdStartApproximateDropped.Add(float64(dropped))
+			log.Info().Int("dropped", dropped).Msg("Cold start window over, approximate books dropped")
+		}()
+	}
+
+	// Subscriptions skip blacklisted symbols and connectors switched off at
+	// runtime, whether made at startup or by a universe reload
+	symbolFilter := func(exchID connector.ExchangeID, symbols []string) []string {
+		return symbolBlacklist.Filter(exchID, flagStore.Filter(exchID, symbols))
+	}
 
 	if useTwoPhase {
 		// ========================================
@@ -250,16 +1517,154 @@ func main() {
 
 		// PHASE 1: Load all data from REST APIs
 		restLoader := loader.NewRestDataLoader(connectors)
+		restLoader.SetRenamer(norm)
+		restLoader.SetSymbolMap(norm.Symbols())
 		restLoader.SetMinSpreadBps(minSpreadBps)
+		if v, err := strconv.ParseFloat(getEnv("PREEMPT_RATIO", "0.7"), 64); err == nil {
+			restLoader.SetPreemptRatio(v)
+		}
+		if configPath != "" {
+			restLoader.SetUniverse(universeCanonicals(uni, connectors))
+		}
+
+		// Announced listings are subscribed ahead of the spread threshold and
+		// delisting contracts are dropped from discovery
+		if getEnv("LISTING_WATCH", "true") == "true" && flagStore.Enabled(flags.ListingWatch) {
+			listingConfig := listings.DefaultConfig()
+			if v, err := time.ParseDuration(getEnv("LISTING_POLL_INTERVAL", "2m")); err == nil {
+				listingConfig.PollInterval = v
+			}
+			watchFor, err := time.ParseDuration(getEnv("LISTING_WATCH_FOR", "72h"))
+			if err != nil {
+				watchFor = 72 * time.Hour
+			}
+			excludeFor, err := time.ParseDuration(getEnv("DELISTING_EXCLUDE_FOR", "168h"))
+			if err != nil {
+				excludeFor = 168 * time.Hour
+			}
+
+			exchangeIDs := make([]connector.ExchangeID, 0, len(connectors))
+			for _, conn := range connectors {
+				exchangeIDs = append(exchangeIDs, conn.ID())
+			}
+			sources := listings.DefaultSources(exchangeIDs)
+			// LISTING_RSS_FEEDS=kucoin=https://...,gateio=https://...
+			for _, feed := range strings.Split(getEnv("LISTING_RSS_FEEDS", ""), ",") {
+				ex, url, ok := strings.Cut(strings.TrimSpace(feed), "=")
+				if !ok {
+					continue
+				}
+				sources = append(sources, &listings.RSSSource{
+					ExchangeID: connector.ExchangeID(strings.ToLower(ex)),
+					FeedURL:    url,
+				})
+			}
+
+			listingWatcher := listings.NewWatcher(sources, listingConfig)
+			listingWatcher.SetHandler(func(ev listings.Event) {
+				switch ev.Kind {
+				case listings.KindListing:
+					restLoader.WatchCanonical(ev.Canonical, ev.DetectedAt.Add(watchFor))
+				case listings.KindDelisting:
+					restLoader.ExcludeCanonical(ev.Canonical, ev.DetectedAt.Add(excludeFor))
+				}
+			})
+			adminServer.RegisterListings(listingWatcher)
+			go listingWatcher.Start(ctx)
+		}
+
+		wsManager := loader.NewWebSocketManager(connectors)
+
+		// Market data goes onto the event bus
+		wsManager.SetOrderbookHandler(eventBus.PublishOrderbook)
+		wsManager.SetTradeHandler(eventBus.PublishTrade)
+		wsManager.SetFundingHandler(eventBus.PublishFunding)
+
+		wsManager.SetErrorHandler(func(err error) {
+			if handleSymbolError(symbolBlacklist, err, wsManager.RemoveSymbols) {
+				return
+			}
+			log.Error().Err(err).Msg("WebSocket error")
+		})
+		// Connectors switched off at runtime stop receiving new subscriptions
+		wsManager.SetSymbolFilter(symbolFilter)
+
+		wsManager.SetReconnectHold(maintenanceScheduler.HoldReconnect)
+
+		// Re-seed spread discovery from REST right after a venue reconnects
+		if v, err := strconv.Atoi(getEnv("RECONNECT_BACKFILL_DEPTH", "20")); err == nil {
+			wsManager.SetBackfillHandler(v, seedOrderbook)
+		}
+
+		// Reloads narrow discovery to the new universe and drop the symbols
+		// it leaves out; added ones are subscribed as their spreads are found
+		if configPath != "" {
+			go reloadUniverse(ctx, configPath, uni, connectors, symbolFilter, false, func(c *universe.Config) {
+				applyUniverseThresholds(c)
+				restLoader.SetUniverse(universeCanonicals(c, connectors))
+				for exchID, symbols := range restLoader.OutsideUniverse(wsManager.GetActiveSymbols()) {
+					if err := wsManager.RemoveSymbols(exchID, symbols); err != nil {
+						log.Error().Err(err).Str("exchange", string(exchID)).Msg("Failed to unsubscribe symbols left out of the universe")
+					}
+					log.Info().Str("exchange", string(exchID)).Strs("symbols", symbols).Msg("Dropped symbols left out of the universe")
+				}
+			})
+		}
+
+		// Warm start: resubscribe the set the last instance saved before
+		// Phase 1, which takes minutes over the full universe
+		var subscriptionState *loader.SubscriptionState
+		warm := false
+		if getEnv("SUBSCRIPTION_STATE", "true") == "true" {
+			stateConfig := loader.DefaultSubscriptionStateConfig()
+			if v, err := time.ParseDuration(getEnv("SUBSCRIPTION_STATE_INTERVAL", "30s")); err == nil && v > 0 {
+				stateConfig.SaveInterval = v
+			}
+			if v, err := time.ParseDuration(getEnv("SUBSCRIPTION_STATE_MAX_AGE", "1h")); err == nil && v > 0 {
+				stateConfig.MaxAge = v
+			}
+			subscriptionState = loader.NewSubscriptionState(pub.Client(), stateConfig)
+
+			saved, err := subscriptionState.Load(ctx)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to load saved subscriptions, starting cold")
+			} else if len(saved) > 0 {
+				if err := wsManager.ConnectForSpreads(ctx, saved); err != nil {
+					log.Error().Err(err).Msg("Some WebSocket connections failed")
+				}
+				warm = wsManager.GetTotalSymbolCount() > 0
+				log.Info().
+					Int("total_symbols", wsManager.GetTotalSymbolCount()).
+					Int("connected_exchanges", len(wsManager.GetConnectedExchanges())).
+					Msg("Warm start: resubscribed saved symbols ahead of Phase 1")
+			}
+		}
 
 		if err := restLoader.LoadAll(ctx); err != nil {
 			log.Fatal().Err(err).Msg("Failed to load REST data in Phase 1")
 		}
 
+		// Contract sizes convert venue trade quantities to base units in bars;
+		// tick and lot sizes set the decimals of published prices and sizes
+		for _, data := range restLoader.GetExchangeData() {
+			barBuilder.SetInstruments(data.Instruments)
+			pub.SetInstruments(data.Instruments)
+			liquidationGuard.SetInstruments(data.Instruments)
+		}
+
+		// Give analytics recent context before live bars accumulate
+		if getEnv("KLINE_BACKFILL", "true") == "true" {
+			go restLoader.BackfillKlines(ctx, klineConfig, recordBars)
+		}
+
 		// Update spread discovery with volume data from REST
 		volumeTickers := restLoader.GetVolumeData()
 		for _, ticker := range volumeTickers {
 			spreadDiscovery.HandleTicker(ticker)
+			indexBuilder.HandleTicker(ticker)
+			if frameRecorder != nil {
+				frameRecorder.HandleTicker(ticker)
+			}
 		}
 		log.Info().Int("tickers", len(volumeTickers)).Msg("Volume data loaded into spread discovery")
 
@@ -267,6 +1672,7 @@ func main() {
 		discoveredSpreads := restLoader.GetDiscoveredSpreads()
 		log.Info().
 			Int("spreads", len(discoveredSpreads)).
+			Int("near_threshold", len(restLoader.GetNearThresholdSpreads())).
 			Msg("Phase 1 complete: preliminary spreads discovered")
 
 		// Publish preliminary spreads
@@ -291,48 +1697,21 @@ func main() {
 			totalSymbols += len(symbols)
 		}
 
-		if totalSymbols == 0 {
+		if totalSymbols == 0 && !warm {
 			log.Warn().Msg("No spreads found, no WebSocket connections needed")
 			log.Info().Msg("Starting periodic REST refresh to check for new spreads")
 			restLoader.StartPeriodicRefresh(ctx)
 		} else {
-			// PHASE 2: Connect WebSocket for discovered spreads only
-			wsManager := loader.NewWebSocketManager(connectors)
-
-			// Setup handlers
-			wsManager.SetOrderbookHandler(func(ob *connector.Orderbook) {
-				if ob == nil {
-					log.Error().Msg("received nil orderbook")
-					return
-				}
-
-				// Debug logging to help trace message flow from connectors -> publisher
-				log.Info().
-					Str("exchange", string(ob.ExchangeID)).
-					Str("symbol", ob.Symbol).
-					Int("bids", len(ob.Bids)).
-					Int("asks", len(ob.Asks)).
-					Time("ts", ob.Timestamp).
-					Msg("Orderbook update received")
-
-				if err := pub.PublishOrderbook(ob); err != nil {
-					log.Error().Err(err).Msg("Failed to publish orderbook")
-				}
-				spreadDiscovery.HandleOrderbook(ob)
-			})
-
-			wsManager.SetFundingHandler(func(fr *connector.FundingRate) {
-				spreadDiscovery.HandleFundingRate(fr)
-			})
-
-			wsManager.SetErrorHandler(func(err error) {
-				log.Error().Err(err).Msg("WebSocket error")
-			})
-
-			// Connect WebSocket only for spread symbols
-			if err := wsManager.ConnectForSpreads(ctx, symbolsByExchange); err != nil {
+			// PHASE 2: Connect WebSocket for discovered spreads only. After a
+			// warm start the restored set is converged on the rebuilt one.
+			if warm {
+				wsManager.Reconcile(ctx, symbolsByExchange)
+			} else if err := wsManager.ConnectForSpreads(ctx, symbolsByExchange); err != nil {
 				log.Error().Err(err).Msg("Some WebSocket connections failed")
 			}
+			if subscriptionState != nil {
+				go subscriptionState.Run(ctx, wsManager.GetActiveSymbols)
+			}
 
 			log.Info().
 				Int("total_symbols", wsManager.GetTotalSymbolCount()).
@@ -342,20 +1721,78 @@ func main() {
 			// Start connection monitor
 			go wsManager.MonitorConnections(ctx, 30*time.Second)
 
+			// Poll funding only for subscribed symbols
+			fundingPoller.SetSymbolSource(wsManager.GetActiveSymbols)
+			go fundingPoller.Start(ctx)
+
+			// Judge feed rates per subscribed symbol
+			feedWatch.SetSymbolSource(wsManager.GetActiveSymbols)
+			go feedWatch.Start(ctx)
+
+			// Verify account setup of subscribed symbols before going live
+			if preflight != nil {
+				preflight.SetSymbolSource(wsManager.GetActiveSymbols)
+				go preflight.Start(ctx)
+			}
+
+			// Track open interest of subscribed symbols
+			if getEnv("OI_ALERTS", "true") == "true" {
+				oiMonitor.SetSymbolSource(wsManager.GetActiveSymbols)
+				go oiMonitor.Start(ctx)
+			}
+
+			// Contracts newly listed on a second venue are subscribed as soon
+			// as the listing shows up, not on the next REST refresh, and their
+			// spreads are tagged new_listing
+			listingPoll, err := time.ParseDuration(getEnv("NEW_LISTING_POLL_INTERVAL", "15s"))
+			if err != nil {
+				listingPoll = 15 * time.Second
+			}
+			newListingFor, err := time.ParseDuration(getEnv("NEW_LISTING_TAG_FOR", "24h"))
+			if err != nil {
+				newListingFor = 24 * time.Hour
+			}
+			listingMonitor := loader.NewListingMonitor(connectors, listingPoll)
+			listingMonitor.SetHandler(func(nl loader.NewListing) {
+				until := nl.DetectedAt.Add(newListingFor)
+				spreadDiscovery.MarkNewListing(nl.Canonical, until)
+				restLoader.WatchCanonical(nl.Canonical, until)
+
+				symbols := make(map[connector.ExchangeID][]string, len(nl.Symbols))
+				for exchID, symbol := range nl.Symbols {
+					if restLoader.InUniverse(exchID, nl.Canonical) {
+						symbols[exchID] = []string{symbol}
+					}
+				}
+				added := wsManager.AddSubscriptions(ctx, symbols)
+				for exchID, count := range added {
+					metrics.NewListingSubscriptions.WithLabelValues(string(exchID)).Add(float64(count))
+				}
+			})
+			go listingMonitor.Start(ctx)
+
 			// Start periodic REST refresh for new spread discovery with volume updates
 			restLoader.StartPeriodicRefreshWithCallback(ctx, func(rl *loader.RestDataLoader) {
 				// Update volume data after each refresh
 				volumeTickers := rl.GetVolumeData()
 				for _, ticker := range volumeTickers {
 					spreadDiscovery.HandleTicker(ticker)
+					indexBuilder.HandleTicker(ticker)
+					if frameRecorder != nil {
+						frameRecorder.HandleTicker(ticker)
+					}
 				}
 				log.Debug().Int("tickers", len(volumeTickers)).Msg("Volume data refreshed")
+
+				// Subscribe symbols of new and near-threshold spreads so live books
+				// are in place before the spread crosses the threshold
+				added := wsManager.AddSubscriptions(ctx, rl.GetSymbolsForWebSocket())
+				for exchID, count := range added {
+					metrics.PreemptiveSubscriptions.WithLabelValues(string(exchID)).Add(float64(count))
+				}
 			})
 
-			// Wait for shutdown signal
-			sigCh := make(chan os.Signal, 1)
-			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-			<-sigCh
+			waitForShutdown(soakDone)
 
 			log.Info().Msg("Shutting down...")
 
@@ -368,9 +1805,13 @@ func main() {
 		// ========================================
 		log.Info().Msg("Using legacy mode: connecting to all symbols via WebSocket")
 
+		if configPath != "" {
+			go reloadUniverse(ctx, configPath, uni, connectors, symbolFilter, true, applyUniverseThresholds)
+		}
+
 		// Setup handlers and connect
 		for _, conn := range connectors {
-			setupHandlers(conn, pub, spreadDiscovery)
+			setupHandlers(conn, eventBus, symbolBlacklist)
 
 			if err := conn.Connect(ctx); err != nil {
 				log.Error().Err(err).Str("exchange", string(conn.ID())).Msg("Failed to connect")
@@ -381,18 +1822,17 @@ func main() {
 			metrics.RecordConnectionStatus(string(conn.ID()), true)
 			log.Info().Str("exchange", string(conn.ID())).Msg("Connected to exchange")
 		}
+		go fundingPoller.Start(ctx)
+		go feedWatch.Start(ctx)
+		if preflight != nil {
+			go preflight.Start(ctx)
+		}
 
-		// Wait for shutdown signal
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-		<-sigCh
+		waitForShutdown(soakDone)
 	}
 
 	log.Info().Msg("Cleaning up...")
 
-	// Stop spread discovery
-	spreadDiscovery.Stop()
-
 	// Disconnect all (in case legacy mode was used)
 	for _, conn := range connectors {
 		if conn.IsConnected() {
@@ -403,170 +1843,302 @@ func main() {
 		}
 	}
 
-	// Stop metrics server
+	// Stop producers, then deliver what is queued on the bus before the
+	// consumers stop
+	fundingPoller.Stop()
+	eventBus.Close()
+
+	// Stop spread discovery, index builder and the other consumers
+	spreadDiscovery.Stop()
+	indexBuilder.Stop()
+	barBuilder.Stop()
+	oiMonitor.Stop()
+	feedWatch.Stop()
+	settlementScheduler.Stop()
+	statusTracker.Stop()
+	tenantStore.Stop()
+	apiVersions.Stop()
+	maintenanceScheduler.Stop()
+	flagStore.Stop()
+	settingsStore.Stop()
+	inventoryStore.Stop()
+	notionalLimits.Stop()
+	drawdownGuard.Stop()
+	creditLimits.Stop()
+	transferLatencies.Stop()
+	venueHealth.Stop()
+	webhooks.Stop()
+	strategyHost.Stop()
+	if frameRecorder != nil {
+		if err := frameRecorder.Close(); err != nil {
+			log.Error().Err(err).Str("path", *recordPath).Msg("Failed to write frame recording")
+		}
+	}
+
+	// Stop metrics and admin servers
 	if err := metricsServer.Stop(); err != nil {
 		log.Error().Err(err).Msg("Error stopping metrics server")
 	}
-}
+	if err := adminServer.Stop(); err != nil {
+		log.Error().Err(err).Msg("Error stopping admin server")
+	}
 
-// convertToOKXSymbol converts Binance-style symbols to OKX format
-// BTCUSDT -> BTC-USDT-SWAP
-func convertToOKXSymbol(symbol string) string {
-	// Handle common USDT pairs
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		return base + "-USDT-SWAP"
-	}
-	// Handle USDC pairs
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		return base + "-USDC-SWAP"
-	}
-	// Handle BUSD pairs
-	if strings.HasSuffix(symbol, "BUSD") {
-		base := strings.TrimSuffix(symbol, "BUSD")
-		return base + "-BUSD-SWAP"
-	}
-	return symbol + "-USDT-SWAP"
+	if soakRunner != nil {
+		report, err := soakRunner.WriteReport(*soakReportPath)
+		if err != nil {
+			log.Error().Err(err).Str("path", *soakReportPath).Msg("Failed to write soak report")
+		}
+		log.Info().Bool("passed", report.Passed).Interface("violations", report.Violations).Str("report", *soakReportPath).Msg("Soak run finished")
+		if err != nil || !report.Passed {
+			os.Exit(1)
+		}
+	}
 }
 
-// convertToKuCoinSymbol converts Binance-style symbols to KuCoin format
-// BTCUSDT -> XBTUSDTM (BTC is XBT on KuCoin futures)
-func convertToKuCoinSymbol(symbol string) string {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		if base == "BTC" {
-			base = "XBT"
+// exchangeSymbols converts universe symbols (BTCUSDT) to an exchange's
+// format, e.g. BTC-USDT-SWAP on OKX
+func exchangeSymbols(id connector.ExchangeID, symbols []string) []string {
+	out := make([]string, len(symbols))
+	for i, s := range symbols {
+		switch id {
+		case connector.Binance, connector.Bybit, connector.Bitget, connector.BitMart:
+			out[i] = s
+		default:
+			// Deribit lists no USDT perpetuals, so BTCUSDT maps to its
+			// USDC-margined BTC_USDC-PERPETUAL
+			out[i] = connector.ParsePair(s).ExchangeSymbol(id)
 		}
-		return base + "USDTM"
 	}
-	return symbol + "M"
+	return out
 }
 
-// convertToMEXCSymbol converts Binance-style symbols to MEXC format
-// BTCUSDT -> BTC_USDT
-func convertToMEXCSymbol(symbol string) string {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		return base + "_USDT"
-	}
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		return base + "_USDC"
+// universeCanonicals returns the canonicals of each exchange's universe
+// symbols, which two-phase discovery pairs spreads on. A multiple contract
+// (1000PEPEUSDT) counts as its unit asset, as the symbol map pairs it.
+func universeCanonicals(c *universe.Config, connectors []connector.Connector) map[connector.ExchangeID][]string {
+	out := make(map[connector.ExchangeID][]string, len(connectors))
+	for _, conn := range connectors {
+		id := conn.ID()
+		symbols := c.SymbolsFor(id)
+		canonicals := make([]string, 0, len(symbols))
+		for _, s := range symbols {
+			p := connector.ParsePair(s)
+			p.Base, _ = symbolmap.SplitMultiplier(p.Base)
+			canonicals = append(canonicals, p.Canonical())
+		}
+		out[id] = canonicals
 	}
-	return symbol
+	return out
 }
 
-// convertToGateSymbol converts Binance-style symbols to Gate.io format
-// BTCUSDT -> BTC_USDT
-func convertToGateSymbol(symbol string) string {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		return base + "_USDT"
+// universeThresholds returns the built-in thresholds with those the
+// universe config sets
+func universeThresholds(builtin spread.Thresholds, c *universe.Config) spread.Thresholds {
+	t := builtin
+	if c.MinSpreadBps > 0 {
+		t.MinSpreadBps = c.MinSpreadBps
 	}
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		return base + "_USDC"
+	if c.MinDepthUSD > 0 {
+		t.MinDepthUSD = c.MinDepthUSD
 	}
-	return symbol
+	return t
 }
 
-// convertToBingXSymbol converts Binance-style symbols to BingX format
-// BTCUSDT -> BTC-USDT
-func convertToBingXSymbol(symbol string) string {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		return base + "-USDT"
-	}
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		return base + "-USDC"
+// reloadUniverse reloads the universe config on SIGHUP until ctx is done.
+// An invalid file keeps the current config. apply takes each new config at
+// once; with resubscribe (legacy mode), connected exchanges also subscribe
+// to the added symbols filter passes and unsubscribe removed ones, which
+// reconnects keep. An exchange that is disconnected or fails catches up on
+// the next reload. Depth changes take a restart, so they are warned about
+// against the depth each exchange subscribed with.
+func reloadUniverse(ctx context.Context, path string, current *universe.Config, connectors []connector.Connector, filter func(connector.ExchangeID, []string) []string, resubscribe bool, apply func(*universe.Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// The config each exchange's subscriptions were last brought in line
+	// with, and the book depth its connector was created with
+	applied := make(map[connector.ExchangeID]*universe.Config, len(connectors))
+	depths := make(map[connector.ExchangeID]int, len(connectors))
+	for _, conn := range connectors {
+		applied[conn.ID()] = current
+		depths[conn.ID()] = current.DepthFor(conn.ID())
 	}
-	return symbol
-}
 
-// convertToCoinExSymbol converts Binance-style symbols to CoinEx format
-// BTCUSDT -> BTCUSDT (CoinEx uses same format)
-func convertToCoinExSymbol(symbol string) string {
-	return symbol
-}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
 
-// convertToLBankSymbol converts Binance-style symbols to LBank format
-// BTCUSDT -> BTC_USDT
-func convertToLBankSymbol(symbol string) string {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		return base + "_USDT"
-	}
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		return base + "_USDC"
+		next, err := universe.Load(path)
+		if err != nil {
+			metrics.UniverseReloads.WithLabelValues("error").Inc()
+			log.Error().Err(err).Str("path", path).Msg("Invalid symbol universe config, keeping the current one")
+			continue
+		}
+		apply(next)
+
+		for _, conn := range connectors {
+			id := conn.ID()
+			if next.DepthFor(id) != depths[id] {
+				log.Warn().Str("exchange", string(id)).Int("depth", next.DepthFor(id)).Int("subscribed_depth", depths[id]).Msg("Book depth change applies on restart")
+			}
+			if !resubscribe {
+				continue
+			}
+			added, removed := applied[id].Diff(next, id)
+			if len(added)+len(removed) == 0 {
+				applied[id] = next
+				continue
+			}
+			if !conn.IsConnected() {
+				log.Warn().Str("exchange", string(id)).Msg("Exchange disconnected, symbol universe change applies on the next reload")
+				continue
+			}
+			ok := true
+			if len(removed) > 0 {
+				if err := conn.Unsubscribe(exchangeSymbols(id, removed)); err != nil {
+					ok = false
+					log.Error().Err(err).Str("exchange", string(id)).Msg("Failed to unsubscribe removed symbols")
+				}
+			}
+			if symbols := filter(id, exchangeSymbols(id, added)); len(symbols) > 0 {
+				if err := conn.Subscribe(symbols); err != nil {
+					ok = false
+					log.Error().Err(err).Str("exchange", string(id)).Msg("Failed to subscribe added symbols")
+				}
+			}
+			if ok {
+				applied[id] = next
+				log.Info().Str("exchange", string(id)).Strs("added", added).Strs("removed", removed).Msg("Symbol universe resubscribed")
+			}
+		}
+
+		metrics.UniverseReloads.WithLabelValues("ok").Inc()
+		log.Info().
+			Str("path", path).
+			Int("symbols", len(next.Symbols)).
+			Float64("min_spread_bps", next.MinSpreadBps).
+			Float64("min_depth_usd", next.MinDepthUSD).
+			Msg("Reloaded symbol universe")
 	}
-	return symbol
 }
 
-// convertToHTXSymbol converts Binance-style symbols to HTX format
-// BTCUSDT -> BTC-USDT
-func convertToHTXSymbol(symbol string) string {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		return base + "-USDT"
-	}
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		return base + "-USDC"
+// waitForShutdown blocks until SIGINT or SIGTERM, or until done is closed
+// when a soak run ends
+func waitForShutdown(done <-chan struct{}) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-sigCh:
+	case <-done:
+		log.Info().Msg("Soak duration elapsed")
 	}
-	return symbol
 }
 
-func setupHandlers(conn connector.Connector, pub *publisher.RedisPublisher, sd *spread.SpreadDiscovery) {
-	exchangeID := string(conn.ID())
-
-	conn.SetOrderbookHandler(func(ob *connector.Orderbook) {
+// subscribeConsumers attaches the in-process consumers of market data to the
+// event bus. New consumers subscribe here; connectors are not touched.
+func subscribeConsumers(b *bus.Bus, cfg func(string) bus.SubscribeConfig, pub publisher.Publisher, sd *spread.SpreadDiscovery, ib *index.Builder, bb *bars.Builder, fs *funding.Scheduler, bs *books.Store, fw *feedwatch.Watchdog) {
+	b.Orderbooks.Subscribe("publisher", cfg("publisher"), func(ob *connector.Orderbook) {
 		timer := metrics.NewTimer()
 		if err := pub.PublishOrderbook(ob); err != nil {
 			log.Error().Err(err).Msg("Failed to publish orderbook")
 			metrics.RedisPublishErrors.WithLabelValues("orderbook").Inc()
-		} else {
-			timer.ObserveDuration(metrics.RedisPublishDuration, "orderbook")
-
-			// Record orderbook metrics
-			bestBid := ob.BestBid
-			bestAsk := ob.BestAsk
-			if len(ob.Bids) > 0 && bestBid == 0 {
-				bestBid = ob.Bids[0].Price
-			}
-			if len(ob.Asks) > 0 && bestAsk == 0 {
-				bestAsk = ob.Asks[0].Price
-			}
-			metrics.RecordOrderbookUpdate(exchangeID, ob.Symbol, len(ob.Bids), len(ob.Asks), bestBid, bestAsk)
+			return
+		}
+		timer.ObserveDuration(metrics.RedisPublishDuration, "orderbook")
 
-			// Forward to spread discovery
-			sd.HandleOrderbook(ob)
+		// Record orderbook metrics
+		bestBid := ob.BestBid
+		bestAsk := ob.BestAsk
+		if len(ob.Bids) > 0 && bestBid == 0 {
+			bestBid = ob.Bids[0].Price
 		}
+		if len(ob.Asks) > 0 && bestAsk == 0 {
+			bestAsk = ob.Asks[0].Price
+		}
+		metrics.RecordOrderbookUpdate(string(ob.ExchangeID), ob.Symbol, len(ob.Bids), len(ob.Asks), bestBid, bestAsk)
 	})
+	b.Orderbooks.Subscribe("spread", cfg("spread"), sd.HandleOrderbook)
+	b.Orderbooks.Subscribe("index", cfg("index"), ib.HandleOrderbook)
+	b.Orderbooks.Subscribe("books", cfg("books"), bs.HandleOrderbook)
+	b.Orderbooks.Subscribe("feedwatch", cfg("feedwatch"), fw.HandleOrderbook)
 
-	conn.SetTradeHandler(func(trade *connector.Trade) {
+	b.Trades.Subscribe("publisher", cfg("publisher"), func(trade *connector.Trade) {
 		if err := pub.PublishTrade(trade); err != nil {
 			log.Error().Err(err).Msg("Failed to publish trade")
 			metrics.RedisPublishErrors.WithLabelValues("trade").Inc()
-		} else {
-			metrics.RecordTrade(exchangeID, trade.Symbol, trade.Side, trade.Quantity)
+			return
 		}
+		metrics.RecordTrade(string(trade.ExchangeID), trade.Symbol, trade.Side, trade.Quantity)
 	})
+	b.Trades.Subscribe("bars", cfg("bars"), bb.HandleTrade)
+	b.Trades.Subscribe("feedwatch", cfg("feedwatch"), fw.HandleTrade)
 
-	conn.SetFundingHandler(func(fr *connector.FundingRate) {
-		// Forward to spread discovery
-		sd.HandleFundingRate(fr)
-		metrics.RecordFundingRate(exchangeID, fr.Symbol, fr.FundingRate)
+	b.Funding.Subscribe("publisher", cfg("publisher"), func(fr *connector.FundingRate) {
+		timer := metrics.NewTimer()
+		if err := pub.PublishFundingRate(fr); err != nil {
+			log.Error().Err(err).Msg("Failed to publish funding rate")
+			metrics.RedisPublishErrors.WithLabelValues("funding").Inc()
+			return
+		}
+		timer.ObserveDuration(metrics.RedisPublishDuration, "funding")
+		metrics.RecordFundingPublished(string(fr.ExchangeID), fr.Symbol, fr.MarkPrice)
+	})
+	b.Funding.Subscribe("spread", cfg("spread"), sd.HandleFundingRate)
+	b.Funding.Subscribe("settlements", cfg("settlements"), fs.HandleFundingRate)
+	b.Funding.Subscribe("metrics", cfg("metrics"), func(fr *connector.FundingRate) {
+		metrics.RecordFundingRate(string(fr.ExchangeID), fr.Symbol, fr.FundingRate)
 	})
+}
+
+// setupHandlers routes a connector's market data onto the event bus and
+// handles its errors
+func setupHandlers(conn connector.Connector, b *bus.Bus, bl *blacklist.Blacklist) {
+	exchangeID := string(conn.ID())
+	b.Attach(conn)
 
 	conn.SetErrorHandler(func(err error) {
+		unsubscribe := func(_ connector.ExchangeID, symbols []string) error {
+			return conn.Unsubscribe(symbols)
+		}
+		if handleSymbolError(bl, err, unsubscribe) {
+			return
+		}
 		log.Error().Err(err).Str("exchange", exchangeID).Msg("Connector error")
 		metrics.RecordConnectionError(exchangeID, "runtime_error")
 	})
 }
 
+// handleSymbolError records symbol-scoped errors in the blacklist and
+// unsubscribes the symbol once it is blacklisted. Errors for symbols that are
+// already blacklisted are dropped silently. Returns false if err is not
+// attributable to a single symbol.
+func handleSymbolError(bl *blacklist.Blacklist, err error, unsubscribe func(connector.ExchangeID, []string) error) bool {
+	var symErr *connector.SymbolError
+	if !errors.As(err, &symErr) {
+		return false
+	}
+
+	if bl.IsBlacklisted(symErr.Exchange, symErr.Symbol) {
+		bl.RecordError(symErr.Exchange, symErr.Symbol, symErr.Err)
+		return true
+	}
+
+	log.Warn().Err(err).Msg("Symbol error")
+	if bl.RecordError(symErr.Exchange, symErr.Symbol, symErr.Err) {
+		if err := unsubscribe(symErr.Exchange, []string{symErr.Symbol}); err != nil {
+			log.Error().Err(err).
+				Str("exchange", string(symErr.Exchange)).
+				Str("symbol", symErr.Symbol).
+				Msg("Failed to unsubscribe blacklisted symbol")
+		}
+	}
+	return true
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -575,12 +2147,29 @@ func getEnv(key, defaultValue string) string {
 }
 
 // getCredentialsForExchange tries to fetch API credentials for an exchange
-// Returns nil if no credentials are found or if fetching fails
+// Returns nil if no credentials are found or if fetching fails. With
+// MD_CREDENTIALS_TENANT set only that tenant's credentials are used.
 func getCredentialsForExchange(exchange string) *credentials.ExchangeCredentials {
 	if credsFetcher == nil {
 		return nil
 	}
 
+	if mdCredentialsTenant != "" {
+		list, err := credsFetcher.GetExchangeCredentials(exchange)
+		if err != nil {
+			log.Debug().Err(err).Str("exchange", exchange).Msg("No API credentials available, using public endpoints only")
+			return nil
+		}
+		for i := range list {
+			if list[i].UserID == mdCredentialsTenant {
+				log.Info().Str("exchange", exchange).Str("tenant", mdCredentialsTenant).Msg("Found tenant API credentials, will use authenticated endpoints")
+				return &list[i]
+			}
+		}
+		log.Info().Str("exchange", exchange).Str("tenant", mdCredentialsTenant).Msg("Tenant has no API credentials, using public endpoints only")
+		return nil
+	}
+
 	creds, err := credsFetcher.GetFirstCredentials(exchange)
 	if err != nil {
 		log.Debug().Str("exchange", exchange).Msg("No API credentials available, using public endpoints only")
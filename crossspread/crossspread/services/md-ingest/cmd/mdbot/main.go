@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"crossspread-md-ingest/internal/chatbot"
+)
+
+// mdbot answers chat commands (!spread BTC, !status, !pnl today) in Discord
+// from md-ingest's admin API
+//
+//	DISCORD_TOKEN=... go run ./cmd/mdbot -admin http://md-ingest:9091 -channels 123,456
+func main() {
+	admin := flag.String("admin", getEnv("MD_ADMIN_URL", "http://localhost:9091"), "md-ingest admin API base URL")
+	channels := flag.String("channels", getEnv("DISCORD_CHANNELS", ""), "comma-separated channel IDs to answer in (default all)")
+	flag.Parse()
+
+	token := os.Getenv("DISCORD_TOKEN")
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "DISCORD_TOKEN is required")
+		os.Exit(1)
+	}
+
+	var ids []string
+	for _, id := range strings.Split(*channels, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	chatbot.NewDiscord(token, chatbot.New(*admin), ids).Run(ctx)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// mddashboard writes the md-ingest Grafana dashboard, generated from the
+// metrics the service registers
+//
+//	go run ./cmd/mddashboard -out docs/grafana/md-ingest.json
+func main() {
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	data, err := json.MarshalIndent(metrics.BuildDashboard(), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/export"
+	"crossspread-md-ingest/internal/history"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// mdexport extracts recorded trades, books, funding rates or bars of one
+// symbol into a file for research, without access to Redis itself
+//
+//	go run ./cmd/mdexport -dataset trades -exchange binance -symbol BTCUSDT \
+//	    -from 2024-01-31T00:00:00Z -to 2024-01-31T06:00:00Z -format parquet -out btc.parquet
+func main() {
+	addr := flag.String("redis", getEnv("REDIS_HOST", "localhost")+":"+getEnv("REDIS_PORT", "6379"), "Redis address")
+	prefix := flag.String("prefix", getEnv("REDIS_KEY_PREFIX", ""), "key namespace of the md-ingest instance, e.g. md:prod-a:")
+	user := flag.String("user", getEnv("REDIS_USERNAME", ""), "Redis ACL user")
+	dataset := flag.String("dataset", export.DatasetTrades, "trades, orderbook, funding or bars")
+	exchange := flag.String("exchange", "", "exchange ID, e.g. binance")
+	symbol := flag.String("symbol", "", "exchange-native symbol, e.g. BTCUSDT")
+	interval := flag.String("interval", "1m", "bar interval, for -dataset bars")
+	from := flag.String("from", "", "start, RFC 3339 or Unix milliseconds (default 1h before -to)")
+	to := flag.String("to", "", "end, RFC 3339 or Unix milliseconds (default now)")
+	format := flag.String("format", export.FormatCSV, "csv, jsonl or parquet")
+	fields := flag.String("fields", "", "comma-separated columns in output order (default all)")
+	limit := flag.Int("limit", 0, "maximum rows (default the exporter's maximum)")
+	list := flag.Bool("list-fields", false, "print the dataset's columns and exit")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+	keyspace.SetPrefix(*prefix)
+
+	if *list {
+		cols, err := export.Columns(*dataset)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, c := range cols {
+			fmt.Printf("%-24s %s\n", c.Name, c.Type)
+		}
+		return
+	}
+
+	req := export.Request{
+		Dataset:  *dataset,
+		Exchange: connector.ExchangeID(*exchange),
+		Symbol:   *symbol,
+		Interval: *interval,
+		Format:   *format,
+		Fields:   export.ParseFields(*fields),
+		Limit:    *limit,
+		To:       time.Now(),
+	}
+	var err error
+	if *to != "" {
+		if req.To, err = export.ParseTime(*to); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	req.From = req.To.Add(-time.Hour)
+	if *from != "" {
+		if req.From, err = export.ParseTime(*from); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: *addr, Username: *user, Password: getEnv("REDIS_PASSWORD", "")})
+	defer client.Close()
+	exporter := export.New(client, export.DefaultConfig())
+	exporter.SetBarSource(history.NewStore(client, history.DefaultConfig()))
+	if err := exporter.Validate(req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "redis ping failed:", err)
+		os.Exit(1)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	rows, err := exporter.Export(ctx, req, w)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "export failed after", rows, "rows:", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d rows of %s %s:%s\n", rows, req.Dataset, req.Exchange, req.Symbol)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
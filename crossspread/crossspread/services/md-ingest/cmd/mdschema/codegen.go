@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"crossspread-md-ingest/internal/mdschema"
+)
+
+var placeholderRe = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// orderedTypes returns the schema types with dependencies before dependents
+func orderedTypes(schema *mdschema.Schema) []mdschema.TypeSchema {
+	byName := make(map[string]mdschema.TypeSchema, len(schema.Types))
+	for _, t := range schema.Types {
+		byName[t.Name] = t
+	}
+
+	visited := make(map[string]bool)
+	var ordered []mdschema.TypeSchema
+	var visit func(name string)
+	visit = func(name string) {
+		t, ok := byName[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, f := range t.Fields {
+			if f.Ref != "" {
+				visit(f.Ref)
+			}
+			if f.Type == "array" {
+				visit(f.Items)
+			}
+		}
+		ordered = append(ordered, t)
+	}
+	for _, t := range schema.Types {
+		visit(t.Name)
+	}
+	return ordered
+}
+
+// placeholders returns the {name} placeholders of a key pattern in order
+func placeholders(pattern string) []string {
+	var names []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(pattern, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// camelCase converts snake_case to camelCase
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// renderPython renders pydantic models and key helpers
+func renderPython(schema *mdschema.Schema) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# Code generated by md-ingest cmd/mdschema (schema v%d). DO NOT EDIT.\n", schema.Version)
+	b.WriteString("from __future__ import annotations\n\n")
+	b.WriteString("from datetime import datetime\n")
+	b.WriteString("from typing import Any, Dict, List, Optional\n\n")
+	b.WriteString("from pydantic import BaseModel\n\n")
+	fmt.Fprintf(&b, "SCHEMA_VERSION = %d\n", schema.Version)
+
+	for _, t := range orderedTypes(schema) {
+		fmt.Fprintf(&b, "\n\nclass %s(BaseModel):\n", t.Name)
+		if len(t.Fields) == 0 {
+			b.WriteString("    pass\n")
+			continue
+		}
+		for _, f := range t.Fields {
+			typ := pythonType(f)
+			if f.Optional {
+				fmt.Fprintf(&b, "    %s: Optional[%s] = None\n", f.Name, typ)
+			} else {
+				fmt.Fprintf(&b, "    %s: %s\n", f.Name, typ)
+			}
+		}
+	}
+
+	b.WriteString(pythonKeys(schema))
+	return b.Bytes()
+}
+
+// pythonKeys renders the key helpers and the namespace they share, which
+// set_prefix sets the way md-ingest reads REDIS_KEY_PREFIX
+func pythonKeys(schema *mdschema.Schema) string {
+	var b strings.Builder
+
+	b.WriteString("\n\n# Key and channel names, within the namespace set by set_prefix\n")
+	b.WriteString("_prefix = \"\"\n")
+	b.WriteString("\n\ndef set_prefix(prefix: str, instance: str = \"default\") -> None:\n")
+	b.WriteString("    \"\"\"Namespace every key and channel like md-ingest's REDIS_KEY_PREFIX: {instance} expands to the instance ID (INSTANCE_ID) and a missing trailing colon is added\"\"\"\n")
+	b.WriteString("    global _prefix\n")
+	b.WriteString("    prefix = prefix.replace(\"{instance}\", instance)\n")
+	b.WriteString("    if prefix and not prefix.endswith(\":\"):\n")
+	b.WriteString("        prefix += \":\"\n")
+	b.WriteString("    _prefix = prefix\n")
+	b.WriteString("\n\ndef key(name: str) -> str:\n")
+	b.WriteString("    \"\"\"Return a key or channel name within the namespace\"\"\"\n")
+	b.WriteString("    return _prefix + name\n")
+
+	seen := make(map[string]bool)
+	for _, e := range schema.Keys {
+		if seen[e.Name] {
+			continue
+		}
+		seen[e.Name] = true
+
+		args := placeholders(e.Pattern)
+		params := make([]string, len(args))
+		for i, a := range args {
+			params[i] = a + ": str"
+		}
+		fmt.Fprintf(&b, "\n\ndef %s(%s) -> str:\n", e.Name, strings.Join(params, ", "))
+		fmt.Fprintf(&b, "    \"\"\"%s (%s, payload %s)\"\"\"\n", e.Description, e.Kind, e.Payload)
+		if len(args) == 0 {
+			fmt.Fprintf(&b, "    return key(%q)\n", e.Pattern)
+		} else {
+			fmt.Fprintf(&b, "    return key(f%q)\n", e.Pattern)
+		}
+	}
+	return b.String()
+}
+
+func pythonType(f mdschema.Field) string {
+	switch f.Type {
+	case "string":
+		return "str"
+	case "number":
+		return "float"
+	case "integer":
+		return "int"
+	case "boolean":
+		return "bool"
+	case "timestamp":
+		return "datetime"
+	case "array":
+		return "List[" + pythonType(mdschema.Field{Type: f.Items, Ref: itemRef(f.Items)}) + "]"
+	case "object":
+		if f.Ref != "" {
+			return f.Ref
+		}
+		return "Dict[str, Any]"
+	default:
+		if f.Ref != "" {
+			return f.Ref
+		}
+		return "Any"
+	}
+}
+
+// renderTypeScript renders interfaces and key helpers
+func renderTypeScript(schema *mdschema.Schema) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by md-ingest cmd/mdschema (schema v%d). DO NOT EDIT.\n\n", schema.Version)
+	fmt.Fprintf(&b, "export const MD_SCHEMA_VERSION = %d;\n", schema.Version)
+
+	for _, t := range orderedTypes(schema) {
+		fmt.Fprintf(&b, "\nexport interface %s {\n", t.Name)
+		for _, f := range t.Fields {
+			opt := ""
+			if f.Optional {
+				opt = "?"
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", f.Name, opt, typeScriptType(f))
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(typeScriptKeys(schema))
+	return b.Bytes()
+}
+
+// typeScriptKeys renders the key helpers and the namespace they share,
+// which setMdKeyPrefix sets the way md-ingest reads REDIS_KEY_PREFIX
+func typeScriptKeys(schema *mdschema.Schema) string {
+	var b strings.Builder
+
+	b.WriteString("\n// Key and channel names, within the namespace set by setMdKeyPrefix\n")
+	b.WriteString("let mdKeyPrefix = '';\n\n")
+	b.WriteString("/** Namespace every key and channel like md-ingest's REDIS_KEY_PREFIX: {instance} expands to the instance ID (INSTANCE_ID) and a missing trailing colon is added */\n")
+	b.WriteString("export function setMdKeyPrefix(prefix: string, instance = 'default'): void {\n")
+	b.WriteString("  prefix = prefix.replaceAll('{instance}', instance);\n")
+	b.WriteString("  if (prefix !== '' && !prefix.endsWith(':')) {\n")
+	b.WriteString("    prefix += ':';\n")
+	b.WriteString("  }\n")
+	b.WriteString("  mdKeyPrefix = prefix;\n")
+	b.WriteString("}\n\n")
+	b.WriteString("/** Returns a key or channel name within the namespace */\n")
+	b.WriteString("export function mdKey(name: string): string {\n")
+	b.WriteString("  return mdKeyPrefix + name;\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("export const MdKeys = {\n")
+	seen := make(map[string]bool)
+	for _, e := range schema.Keys {
+		if seen[e.Name] {
+			continue
+		}
+		seen[e.Name] = true
+
+		args := placeholders(e.Pattern)
+		params := make([]string, len(args))
+		for i, a := range args {
+			params[i] = camelCase(a) + ": string"
+		}
+		tmpl := placeholderRe.ReplaceAllStringFunc(e.Pattern, func(m string) string {
+			return "${" + camelCase(m[1:len(m)-1]) + "}"
+		})
+		fmt.Fprintf(&b, "  /** %s (%s, payload %s) */\n", e.Description, e.Kind, e.Payload)
+		fmt.Fprintf(&b, "  %s: (%s): string => mdKey(`%s`),\n", camelCase(e.Name), strings.Join(params, ", "), tmpl)
+	}
+	b.WriteString("} as const;\n")
+	return b.String()
+}
+
+func typeScriptType(f mdschema.Field) string {
+	switch f.Type {
+	case "string", "timestamp":
+		return "string"
+	case "number", "integer":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		return typeScriptType(mdschema.Field{Type: f.Items, Ref: itemRef(f.Items)}) + "[]"
+	case "object":
+		if f.Ref != "" {
+			return f.Ref
+		}
+		return "Record<string, unknown>"
+	default:
+		if f.Ref != "" {
+			return f.Ref
+		}
+		return "unknown"
+	}
+}
+
+// itemRef returns the referenced type name for non-scalar array items
+func itemRef(items string) string {
+	switch items {
+	case "string", "number", "integer", "boolean", "timestamp", "object", "array":
+		return ""
+	}
+	return items
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/mdschema"
+)
+
+// The generated helpers must name the keys md-ingest writes under
+// REDIS_KEY_PREFIX=md:{instance} with INSTANCE_ID=prod-a
+func TestKeyHelpersPrefixed(t *testing.T) {
+	keyspace.SetPrefix(strings.ReplaceAll("md:{instance}", "{instance}", "prod-a"))
+	defer keyspace.SetPrefix("")
+	want := keyspace.OrderbookKey("binance", "BTCUSDT")
+	schema := mdschema.BuildSchema()
+
+	ts := typeScriptKeys(schema)
+	if !strings.Contains(ts, "orderbookStream: (exchange: string, symbol: string): string => mdKey(`orderbook:${exchange}:${symbol}`)") {
+		t.Errorf("typescript orderbookStream not namespaced:\n%s", ts)
+	}
+	if !strings.Contains(ts, "prefix.replaceAll('{instance}', instance)") {
+		t.Error("typescript setMdKeyPrefix does not expand {instance}")
+	}
+
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	script := pythonKeys(schema) + "\nset_prefix(\"md:{instance}\", \"prod-a\")\nprint(orderbook_stream(\"binance\", \"BTCUSDT\"), end=\"\")\n"
+	out, err := exec.Command(python, "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("python helpers: %v\n%s", err, out)
+	}
+	if string(out) != want {
+		t.Errorf("python orderbook_stream = %q, want %q", out, want)
+	}
+}
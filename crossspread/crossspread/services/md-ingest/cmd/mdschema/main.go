@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"crossspread-md-ingest/internal/mdschema"
+)
+
+// mdschema writes the md-ingest Redis keyspace schema as JSON, Markdown, or
+// Python/TypeScript bindings for downstream consumers
+//
+//	go run ./cmd/mdschema -format json -out keyspace.schema.json
+//	go run ./cmd/mdschema -format markdown -out KEYSPACE.md
+//	go run ./cmd/mdschema -format python -out md_types.py
+//	go run ./cmd/mdschema -format typescript -out mdIngest.ts
+func main() {
+	format := flag.String("format", "json", "output format: json, markdown, python or typescript")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	schema := mdschema.BuildSchema()
+
+	var data []byte
+	var err error
+	switch *format {
+	case "json":
+		data, err = json.MarshalIndent(schema, "", "  ")
+		data = append(data, '\n')
+	case "markdown", "md":
+		data = renderMarkdown(schema)
+	case "python", "py":
+		data = renderPython(schema)
+	case "typescript", "ts":
+		data = renderTypeScript(schema)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// renderMarkdown renders the schema as human-readable documentation
+func renderMarkdown(schema *mdschema.Schema) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# md-ingest Redis keyspace (schema v%d)\n\n", schema.Version)
+	b.WriteString("Generated by `go run ./cmd/mdschema -format markdown`. Do not edit by hand.\n\n")
+	b.WriteString("Patterns are relative to the instance namespace: with `REDIS_KEY_PREFIX=md:prod-a:` the orderbook stream is `md:prod-a:orderbook:{exchange}:{symbol}`. ")
+	b.WriteString("See [REDIS_ACL.md](REDIS_ACL.md) for restricting each instance to its namespace.\n\n")
+
+	b.WriteString("## Keys and channels\n\n")
+	b.WriteString("| Pattern | Kind | Payload | Retention | Description |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, e := range schema.Keys {
+		retention := "-"
+		if e.MaxLen > 0 {
+			retention = fmt.Sprintf("~%d entries", e.MaxLen)
+		} else if e.TTLSeconds > 0 {
+			retention = fmt.Sprintf("TTL %ds", e.TTLSeconds)
+		}
+		payload := e.Payload
+		if e.Field != "" {
+			payload = fmt.Sprintf("%s (field `%s`)", e.Payload, e.Field)
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", e.Pattern, e.Kind, payload, retention, e.Description)
+	}
+
+	b.WriteString("\n## Payload types\n")
+	for _, t := range schema.Types {
+		fmt.Fprintf(&b, "\n### %s\n\n", t.Name)
+		b.WriteString("| Field | Type | Optional |\n")
+		b.WriteString("|---|---|---|\n")
+		for _, f := range t.Fields {
+			typ := f.Type
+			switch {
+			case f.Type == "array":
+				typ = fmt.Sprintf("array of %s", f.Items)
+			case f.Ref != "":
+				typ = f.Ref
+			}
+			optional := ""
+			if f.Optional {
+				optional = "yes"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", f.Name, typ, optional)
+		}
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/archive"
+	"crossspread-md-ingest/internal/export"
+	"crossspread-md-ingest/internal/replay"
+	"crossspread-md-ingest/internal/spread"
+)
+
+// postmortem replays one opportunity from recorded frames and prints an
+// annotated timeline of both legs and of what discovery published, to
+// answer why a spread was missed or lost
+//
+//	go run ./cmd/postmortem -id BTC:binance:okx -at 2026-01-01T15:04:05Z -window 30s -frames ./recordings
+//
+// -frames takes recording files or directories of rotated recordings;
+// -archive fetches the recordings around -at from the bucket the ingest
+// ARCHIVE_* settings point at. -out saves the extracted frames so the
+// investigation can be shared and rerun with -frames.
+func main() {
+	id := flag.String("id", "", "opportunity ID, e.g. BTC:binance:okx")
+	at := flag.String("at", "", "when it happened, RFC 3339 or Unix milliseconds")
+	window := flag.Duration("window", 30*time.Second, "frames replayed before and after -at")
+	frames := flag.String("frames", "", "comma-separated recording files or directories of rotated recordings")
+	fromArchive := flag.Bool("archive", false, "fetch the recordings from object storage using the ARCHIVE_* settings")
+	cache := flag.String("cache", filepath.Join(os.TempDir(), "md-postmortem"), "where archived recordings are downloaded")
+	defaults := spread.NewSpreadDiscovery(nil, nil).Thresholds()
+	minSpread := flag.Float64("min-spread-bps", defaults.MinSpreadBps, "discovery's minimum spread, as configured live")
+	minDepth := flag.Float64("min-depth-usd", defaults.MinDepthUSD, "discovery's minimum depth, as configured live")
+	gap := flag.Duration("gap", replay.DefaultPostMortemConfig().Gap, "mark a leg silent after this long without a book")
+	books := flag.Bool("books", true, "include every book update in the timeline, not only discovery marks")
+	asJSON := flag.Bool("json", false, "print the timeline as JSON")
+	out := flag.String("out", "", "also write the extracted frames to this file")
+	flag.Parse()
+
+	canonical, venues, err := replay.Legs(*id)
+	if err != nil {
+		fail(err)
+	}
+	if *at == "" {
+		fail("-at is required")
+	}
+	center, err := export.ParseTime(*at)
+	if err != nil {
+		fail(err)
+	}
+	from, to := center.Add(-*window), center.Add(*window)
+
+	paths, err := recordings(*frames, *fromArchive, *cache, from, to)
+	if err != nil {
+		fail(err)
+	}
+	all, err := replay.LoadAll(paths)
+	if err != nil {
+		fail(err)
+	}
+	extracted := replay.Extract(all, canonical, venues, from, to)
+	if len(extracted) == 0 {
+		fail(fmt.Sprintf("no frames of %s on %v between %s and %s in %d recordings",
+			canonical, venues, from.Format(time.RFC3339), to.Format(time.RFC3339), len(paths)))
+	}
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fail(err)
+		}
+		if err := replay.Write(f, extracted); err != nil {
+			fail(err)
+		}
+		f.Close()
+	}
+
+	cfg := replay.DefaultPostMortemConfig()
+	cfg.Gap = *gap
+	cfg.Setup = func(sd *spread.SpreadDiscovery) {
+		sd.SetThresholds(spread.Thresholds{MinSpreadBps: *minSpread, MinDepthUSD: *minDepth})
+	}
+	marks := replay.PostMortem(extracted, *id, cfg)
+	if !*books {
+		kept := marks[:0]
+		for _, m := range marks {
+			if m.Kind != replay.MarkBook {
+				kept = append(kept, m)
+			}
+		}
+		marks = kept
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(marks)
+		return
+	}
+	fmt.Printf("%s at %s, ±%s: %d frames from %d recordings\n", *id, center.UTC().Format(time.RFC3339Nano), *window, len(extracted), len(paths))
+	for _, m := range marks {
+		who := string(m.Exchange)
+		if m.Leg != "" {
+			who = m.Leg + " " + who
+		}
+		if who == "" {
+			who = "discovery"
+		}
+		fmt.Printf("%+9.3fs  %-16s %-11s %s\n", m.At.Sub(center).Seconds(), who, m.Kind, m.Note)
+	}
+}
+
+// recordings resolves -frames or fetches the archived recordings covering
+// [from, to]
+func recordings(frames string, fromArchive bool, cache string, from, to time.Time) ([]string, error) {
+	if fromArchive {
+		bucket, err := archive.NewS3(archive.S3Config{
+			Endpoint:  getEnv("ARCHIVE_ENDPOINT", ""),
+			Region:    getEnv("ARCHIVE_REGION", "us-east-1"),
+			Bucket:    getEnv("ARCHIVE_BUCKET", ""),
+			AccessKey: getEnv("ARCHIVE_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+			SecretKey: getEnv("ARCHIVE_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return replay.Fetch(context.Background(), bucket, getEnv("ARCHIVE_PREFIX", archive.DefaultConfig().Prefix), from, to, cache)
+	}
+	if frames == "" {
+		return nil, errors.New("give -frames or -archive")
+	}
+	var paths []string
+	for _, p := range strings.Split(frames, ",") {
+		p = strings.TrimSpace(p)
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, p)
+			continue
+		}
+		found, err := replay.Recordings(p, from, to)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, found...)
+	}
+	return paths, nil
+}
+
+func fail(v interface{}) {
+	fmt.Fprintln(os.Stderr, v)
+	os.Exit(1)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/spreadwatch"
+	"crossspread-md-ingest/pkg/mdclient"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/term"
+)
+
+// spreadwatch shows the spreads md-ingest publishes as a live table in the
+// terminal, for operators without the web UI running
+//
+//	go run ./cmd/spreadwatch -filter 'net_edge_bps > 2'
+//
+// Press / to filter by pair or by a spread filter expression, s or 1-5 to
+// sort, r to reverse, j/k to scroll and q to quit.
+//
+// The table shows the spreads summary: the top 100 spreads by score of each
+// publish cycle. md-ingest stores and fans out only those, so spreads ranked
+// below them are not shown and no filter finds them.
+func main() {
+	addr := flag.String("redis", getEnv("REDIS_HOST", "localhost")+":"+getEnv("REDIS_PORT", "6379"), "Redis address")
+	prefix := flag.String("prefix", getEnv("REDIS_KEY_PREFIX", ""), "key namespace of the md-ingest instance, e.g. md:prod-a:")
+	user := flag.String("user", getEnv("REDIS_USERNAME", ""), "Redis ACL user")
+	filter := flag.String("filter", "", "initial filter: a pair substring or a spread filter expression")
+	refresh := flag.Duration("refresh", 250*time.Millisecond, "screen redraw interval")
+	quietAfter := flag.Duration("quiet-after", 5*time.Second, "how long without a summary before the producer shows as quiet")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n\n"+
+			"Shows the top 100 spreads md-ingest publishes each cycle; spreads ranked\n"+
+			"below them are not published and no filter finds them.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	mdclient.SetKeyPrefix(*prefix)
+
+	view := spreadwatch.New()
+	if err := view.SetFilter(*filter); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid filter:", err)
+		os.Exit(1)
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "spreadwatch needs a terminal")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	client := redis.NewClient(&redis.Options{Addr: *addr, Username: *user, Password: getEnv("REDIS_PASSWORD", "")})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "redis ping failed:", err)
+		os.Exit(1)
+	}
+	md := mdclient.NewClient(client)
+
+	// Show the stored list until the first summary arrives
+	if summary, err := md.GetSpreadsList(ctx); err == nil {
+		view.Update(summary, time.Now())
+	}
+	channel := keyspace.Key(keyspace.SpreadsSummaryChan)
+	summaries := md.SubscribeSummary(ctx)
+	heartbeats := md.SubscribeHeartbeats(ctx, channel)
+	monitor := mdclient.NewChannelMonitor(channel, *quietAfter)
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to set raw mode:", err)
+		os.Exit(1)
+	}
+	defer term.Restore(fd, state)
+	// Alternate screen, cursor hidden; both restored on exit
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	defer os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan []byte, 16)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	ticker := time.NewTicker(*refresh)
+	defer ticker.Stop()
+	draw := func() {
+		now := time.Now()
+		view.SetStatus(producerStatus(monitor.Status(now)))
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 100, 30
+		}
+		os.Stdout.WriteString(view.Render(now, width, height))
+	}
+	draw()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case summary, ok := <-summaries:
+			if !ok {
+				return
+			}
+			now := time.Now()
+			monitor.Message(now)
+			view.Update(summary, now)
+		case hb, ok := <-heartbeats:
+			if ok {
+				monitor.Heartbeat(hb, time.Now())
+			}
+		case b, ok := <-keys:
+			if !ok {
+				return
+			}
+			for _, k := range spreadwatch.ParseKeys(b) {
+				if view.Key(k) {
+					return
+				}
+			}
+			draw()
+		case <-ticker.C:
+			draw()
+		}
+	}
+}
+
+// producerStatus describes the summary channel's heartbeat state
+func producerStatus(s mdclient.ChannelStatus) string {
+	switch s.State {
+	case mdclient.ChannelWaiting:
+		return "producer: no heartbeat"
+	case mdclient.ChannelDead:
+		return fmt.Sprintf("producer: DEAD (last beat %s ago)", time.Since(s.LastHeartbeat).Truncate(time.Second))
+	default:
+		return fmt.Sprintf("producer: %s, lag %dms", s.State, s.Lag.Milliseconds())
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
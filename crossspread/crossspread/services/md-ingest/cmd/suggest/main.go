@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"crossspread-md-ingest/internal/feedback"
+	"crossspread-md-ingest/internal/history"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConfigPatch is a JSON merge patch (RFC 7386) for the strategy config
+type ConfigPatch struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Source      PatchSource    `json:"source"`
+	Strategy    StrategyConfig `json:"strategy"`
+}
+
+// PatchSource records the data and parameters a patch was derived from
+type PatchSource struct {
+	Dates          []string `json:"dates"`
+	Episodes       int      `json:"episodes"`
+	FalsePositives int      `json:"false_positives"` // Episodes left out because they were tagged
+	FillTimeMs     int64    `json:"fill_time_ms"`
+	MinProbability float64  `json:"min_probability"`
+	MinEpisodes    int      `json:"min_episodes"`
+}
+
+// StrategyConfig holds per-spread thresholds keyed by spread ID
+type StrategyConfig struct {
+	Pairs map[string]history.Suggestion `json:"pairs"`
+}
+
+// suggest analyses recorded spread episodes and writes suggested per-pair
+// entry/exit thresholds as a config patch the operator can review and apply
+//
+//	go run ./cmd/suggest -days 7 -fill-time 2s -min-prob 0.7 -out thresholds.json
+//
+// Episodes tagged as false positives through the admin API are left out, so
+// phantom spreads do not pull entry thresholds down.
+func main() {
+	addr := flag.String("redis", getEnv("REDIS_HOST", "localhost")+":"+getEnv("REDIS_PORT", "6379"), "Redis address")
+	days := flag.Int("days", 7, "number of UTC days of history to analyse, ending today")
+	fillTime := flag.Duration("fill-time", history.DefaultSuggestConfig().FillTime, "time a spread must stay above entry for both legs to fill")
+	minProb := flag.Float64("min-prob", history.DefaultSuggestConfig().MinProbability, "required fill-before-close probability")
+	minEpisodes := flag.Int("min-episodes", history.DefaultSuggestConfig().MinEpisodes, "minimum episodes reaching a level to trust it")
+	prefix := flag.String("prefix", getEnv("REDIS_KEY_PREFIX", ""), "key namespace of the md-ingest instance, e.g. md:prod-a:")
+	user := flag.String("user", getEnv("REDIS_USERNAME", ""), "Redis ACL user")
+	fpGrace := flag.Duration("fp-grace", time.Minute, "how long after an episode ends a false positive tag still applies to it; 0 keeps tagged episodes")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+	keyspace.SetPrefix(*prefix)
+
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: *addr, Username: *user, Password: getEnv("REDIS_PASSWORD", "")})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "redis ping failed:", err)
+		os.Exit(1)
+	}
+	store := history.NewStore(client, history.DefaultConfig())
+
+	var episodes []history.Episode
+	dates := make([]string, 0, *days)
+	now := time.Now().UTC()
+	for i := *days - 1; i >= 0; i-- {
+		date := now.AddDate(0, 0, -i).Format(history.DateLayout)
+		eps, err := store.Episodes(ctx, date)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to read episodes for", date+":", err)
+			os.Exit(1)
+		}
+		dates = append(dates, date)
+		episodes = append(episodes, eps...)
+	}
+
+	excluded := 0
+	if *fpGrace > 0 {
+		tags, err := feedback.New(client, feedback.DefaultConfig()).Tags(ctx, now.AddDate(0, 0, -*days))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to read false positive tags:", err)
+			os.Exit(1)
+		}
+		episodes, excluded = withoutFalsePositives(episodes, tags, *fpGrace)
+	}
+
+	cfg := history.SuggestConfig{
+		FillTime:       *fillTime,
+		MinProbability: *minProb,
+		MinEpisodes:    *minEpisodes,
+	}
+	patch := ConfigPatch{
+		GeneratedAt: now,
+		Source: PatchSource{
+			Dates:          dates,
+			Episodes:       len(episodes),
+			FalsePositives: excluded,
+			FillTimeMs:     fillTime.Milliseconds(),
+			MinProbability: *minProb,
+			MinEpisodes:    *minEpisodes,
+		},
+		Strategy: StrategyConfig{Pairs: history.Suggest(episodes, cfg)},
+	}
+
+	data, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	fmt.Fprintf(os.Stderr, "analysed %d episodes over %d days (%d tagged false positive left out), suggested thresholds for %d pairs\n",
+		len(episodes), len(dates), excluded, len(patch.Strategy.Pairs))
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// withoutFalsePositives drops episodes of a tagged spread that were open
+// when the tag was made, or had closed less than grace before
+func withoutFalsePositives(episodes []history.Episode, tags []feedback.Tag, grace time.Duration) ([]history.Episode, int) {
+	byID := make(map[string][]time.Time)
+	for _, tag := range tags {
+		byID[tag.OpportunityID] = append(byID[tag.OpportunityID], tag.At)
+	}
+
+	kept := episodes[:0]
+	excluded := 0
+	for _, ep := range episodes {
+		end := ep.Start.Add(time.Duration(ep.DurationMs)*time.Millisecond + grace)
+		tagged := false
+		for _, at := range byID[ep.ID] {
+			if !at.Before(ep.Start) && !at.After(end) {
+				tagged = true
+				break
+			}
+		}
+		if tagged {
+			excluded++
+			continue
+		}
+		kept = append(kept, ep)
+	}
+	return kept, excluded
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
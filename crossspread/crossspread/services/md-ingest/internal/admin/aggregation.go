@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RegisterAggregation exposes per-symbol orderbook aggregation on venues
+// that can merge depth:
+//
+//	GET /admin/aggregation                     buckets by exchange and symbol
+//	PUT /admin/aggregation/{exchange}/{symbol}  body {"step": 0.5}; 0 restores full precision
+func (s *Server) RegisterAggregation(connectors []connector.Connector) {
+	setters := make(map[connector.ExchangeID]connector.AggregationSetter)
+	for _, conn := range connectors {
+		if setter, ok := conn.(connector.AggregationSetter); ok {
+			setters[conn.ID()] = setter
+		}
+	}
+
+	s.Handle("GET /admin/aggregation", func(w http.ResponseWriter, r *http.Request) {
+		result := make(map[connector.ExchangeID]map[string]float64, len(setters))
+		for id, setter := range setters {
+			result[id] = setter.Aggregations()
+		}
+		WriteJSON(w, http.StatusOK, result)
+	})
+
+	s.Handle("PUT /admin/aggregation/{exchange}/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		id := connector.ExchangeID(strings.ToLower(r.PathValue("exchange")))
+		setter, ok := setters[id]
+		if !ok {
+			WriteError(w, http.StatusNotFound, "exchange does not support aggregation")
+			return
+		}
+
+		var body struct {
+			Step float64 `json:"step"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		symbol := r.PathValue("symbol")
+		if err := setter.SetAggregation(symbol, body.Step); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"exchange": id,
+			"symbol":   symbol,
+			"step":     body.Step,
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/apiversion"
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RegisterAPIVersions exposes the venue API versions in use and their
+// deprecation notices:
+//
+//	GET  /admin/api-versions           versions called per exchange and notices, soonest sunset first
+//	POST /admin/api-versions/sunsets   record an announced sunset, body {"exchange": "kucoin", "version": "/api/v1", "sunset": "2026-12-01", "detail": "", "by": ""}
+func (s *Server) RegisterAPIVersions(tracker *apiversion.Tracker) {
+	s.Handle("GET /admin/api-versions", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		notices := tracker.Notices()
+		type noticeView struct {
+			apiversion.Notice
+			DaysLeft *float64 `json:"days_left,omitempty"`
+		}
+		views := make([]noticeView, 0, len(notices))
+		for _, n := range notices {
+			view := noticeView{Notice: n}
+			if days, ok := n.DaysLeft(now); ok {
+				view.DaysLeft = &days
+			}
+			views = append(views, view)
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"versions": tracker.Usage(),
+			"notices":  views,
+		})
+	})
+
+	s.Handle("POST /admin/api-versions/sunsets", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Exchange string `json:"exchange"`
+			Version  string `json:"version"`
+			Sunset   string `json:"sunset"`
+			Detail   string `json:"detail"`
+			By       string `json:"by"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		exchange := strings.ToLower(body.Exchange)
+		if !knownExchange(exchange) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange "+body.Exchange)
+			return
+		}
+		if body.Version == "" {
+			WriteError(w, http.StatusBadRequest, "version is required")
+			return
+		}
+		sunset, err := time.Parse("2006-01-02", body.Sunset)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "sunset must be YYYY-MM-DD")
+			return
+		}
+		detail := body.Detail
+		if detail == "" {
+			detail = "announced by " + operator(r, body.By)
+		}
+		notice := apiversion.Notice{
+			Exchange: connector.ExchangeID(exchange),
+			Version:  strings.ToLower(body.Version),
+			Sunset:   sunset,
+			Source:   apiversion.SourceConfig,
+			Detail:   detail,
+		}
+		tracker.Report(notice)
+		WriteJSON(w, http.StatusOK, notice)
+	})
+}
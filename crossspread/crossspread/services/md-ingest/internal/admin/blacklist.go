@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/blacklist"
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RegisterBlacklist exposes the symbol blacklist:
+//
+//	GET    /admin/blacklist                            list blacklisted symbols
+//	DELETE /admin/blacklist?exchange=okx&symbol=BTCUSDT clear a symbol early
+func (s *Server) RegisterBlacklist(bl *blacklist.Blacklist) {
+	s.Handle("GET /admin/blacklist", func(w http.ResponseWriter, r *http.Request) {
+		entries := bl.List()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(entries),
+			"symbols": entries,
+		})
+	})
+
+	s.Handle("DELETE /admin/blacklist", func(w http.ResponseWriter, r *http.Request) {
+		exchange := r.URL.Query().Get("exchange")
+		symbol := r.URL.Query().Get("symbol")
+		if exchange == "" || symbol == "" {
+			WriteError(w, http.StatusBadRequest, "exchange and symbol are required")
+			return
+		}
+		if !bl.Clear(connector.ExchangeID(exchange), symbol) {
+			WriteError(w, http.StatusNotFound, "symbol not tracked")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "cleared"})
+	})
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crossspread-md-ingest/internal/books"
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RegisterBooks exposes the full in-memory L2 books, for debugging and for
+// executors that need more depth than the published top-N:
+//
+//	GET /admin/books                               every stored book with level counts and sequence
+//	GET /admin/books/{exchange}/{symbol}?depth=50  full book of an exchange-native symbol
+//
+// depth limits the levels returned per side and defaults to all of them.
+func (s *Server) RegisterBooks(store *books.Store) {
+	s.Handle("GET /admin/books", func(w http.ResponseWriter, r *http.Request) {
+		list := store.List()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count": len(list),
+			"books": list,
+		})
+	})
+
+	s.Handle("GET /admin/books/{exchange}/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		exchange := strings.ToLower(r.PathValue("exchange"))
+		if !knownExchange(exchange) {
+			WriteError(w, http.StatusNotFound, "unknown exchange")
+			return
+		}
+		depth := 0
+		if v := r.URL.Query().Get("depth"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				WriteError(w, http.StatusBadRequest, "depth must be a non-negative integer")
+				return
+			}
+			depth = n
+		}
+
+		book := store.Get(connector.ExchangeID(exchange), r.PathValue("symbol"), depth)
+		if book == nil {
+			WriteError(w, http.StatusNotFound, "no book for symbol")
+			return
+		}
+		WriteJSON(w, http.StatusOK, book)
+	})
+}
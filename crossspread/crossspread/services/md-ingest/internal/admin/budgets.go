@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RegisterBudgets exposes what each exchange's connectors hold against
+// their soft caps:
+//
+//	GET /admin/budgets   open WebSocket connections, sockets and goroutines per exchange
+func (s *Server) RegisterBudgets() {
+	s.Handle("GET /admin/budgets", func(w http.ResponseWriter, r *http.Request) {
+		usages := connector.Usages()
+		var over []connector.ExchangeID
+		for _, u := range usages {
+			if len(u.Over) > 0 {
+				over = append(over, u.Exchange)
+			}
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"exchanges": usages,
+			"over":      over,
+		})
+	})
+}
@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/bus"
+)
+
+// RegisterBus exposes the event bus between connectors and consumers:
+//
+//	GET /admin/bus   queue depth and backpressure policy of every subscriber
+func (s *Server) RegisterBus(b *bus.Bus) {
+	s.Handle("GET /admin/bus", func(w http.ResponseWriter, r *http.Request) {
+		stats := b.Stats()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":       len(stats),
+			"subscribers": stats,
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/claim"
+)
+
+// RegisterClaims exposes executor opportunity leases:
+//
+//	GET    /admin/claims?tenant=        current claims
+//	DELETE /admin/claims/{id}?tenant=   revoke a stuck executor's claim
+//
+// tenant selects one tenant's claims instead of the shared ones.
+func (s *Server) RegisterClaims(shared *claim.Claims) {
+	scoped := func(r *http.Request) *claim.Claims {
+		if tenant := r.URL.Query().Get("tenant"); tenant != "" {
+			return shared.ForTenant(tenant)
+		}
+		return shared
+	}
+
+	s.Handle("GET /admin/claims", func(w http.ResponseWriter, r *http.Request) {
+		claims, err := scoped(r).List(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":  len(claims),
+			"claims": claims,
+		})
+	})
+
+	s.Handle("DELETE /admin/claims/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ok, err := scoped(r).Revoke(r.Context(), r.PathValue("id"))
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !ok {
+			WriteError(w, http.StatusNotFound, "claim not found")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"revoked": r.PathValue("id"),
+		})
+	})
+}
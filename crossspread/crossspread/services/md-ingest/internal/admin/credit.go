@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/execution"
+)
+
+// RegisterCreditLimits exposes equity per venue against its cap:
+//
+//	GET /admin/risk/venues   most of a cap used first
+func (s *Server) RegisterCreditLimits(limits *execution.CreditLimits) {
+	s.Handle("GET /admin/risk/venues", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, limits.Status())
+	})
+}
@@ -0,0 +1,17 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// RegisterDashboard serves the Grafana dashboard generated from the
+// metrics this build registers:
+//
+//	GET /admin/dashboard   dashboard JSON, ready for Grafana's import
+func (s *Server) RegisterDashboard() {
+	s.Handle("GET /admin/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, metrics.BuildDashboard())
+	})
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RegisterFrameDebug exposes time-boxed raw frame logging for one exchange
+// symbol, for debugging parsing in production:
+//
+//	GET    /admin/debug/frames                      open windows
+//	POST   /admin/debug/frames                      open a window, body {"exchange": "okx", "symbol": "BTC-USDT-SWAP", "for": "30s", "rate": 20}
+//	DELETE /admin/debug/frames/{exchange}/{symbol}  close a window early
+//
+// The symbol is the exchange's own and matches anywhere in a frame. A
+// window closes by itself after "for", at most 10m, and logs at most
+// "rate" frames per second.
+func (s *Server) RegisterFrameDebug() {
+	s.Handle("GET /admin/debug/frames", func(w http.ResponseWriter, r *http.Request) {
+		windows := connector.FrameDebugs()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(windows),
+			"windows": windows,
+		})
+	})
+
+	s.Handle("POST /admin/debug/frames", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Exchange string `json:"exchange"`
+			Symbol   string `json:"symbol"`
+			For      string `json:"for"`
+			Rate     int    `json:"rate"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if !knownExchange(body.Exchange) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange "+body.Exchange)
+			return
+		}
+		if body.Symbol == "" {
+			WriteError(w, http.StatusBadRequest, "symbol is required")
+			return
+		}
+		d, err := time.ParseDuration(body.For)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "for must be a duration, e.g. 30s")
+			return
+		}
+
+		window, err := connector.EnableFrameDebug(connector.ExchangeID(strings.ToLower(body.Exchange)), body.Symbol, d, body.Rate)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, window)
+	})
+
+	s.Handle("DELETE /admin/debug/frames/{exchange}/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		exchange := connector.ExchangeID(strings.ToLower(r.PathValue("exchange")))
+		window, ok := connector.DisableFrameDebug(exchange, r.PathValue("symbol"))
+		if !ok {
+			WriteError(w, http.StatusNotFound, "no frame debug window for "+string(exchange)+" "+r.PathValue("symbol"))
+			return
+		}
+		WriteJSON(w, http.StatusOK, window)
+	})
+}
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/publisher"
+)
+
+// RegisterPublishDepth exposes how many orderbook levels are written to
+// Redis per symbol, independent of the exchange subscription:
+//
+//	GET    /admin/publish-depth                      default and overrides by exchange and symbol
+//	PUT    /admin/publish-depth                      body {"depth": 5}; default for every symbol
+//	PUT    /admin/publish-depth/{exchange}/{symbol}  body {"depth": 1}; 1, 5, 20 or "full"
+//	DELETE /admin/publish-depth/{exchange}/{symbol}  drop the override, back to the default
+func (s *Server) RegisterPublishDepth(pub *publisher.RedisPublisher) {
+	s.Handle("GET /admin/publish-depth", func(w http.ResponseWriter, r *http.Request) {
+		overrides := make(map[connector.ExchangeID]map[string]string)
+		for ex, symbols := range pub.Depths() {
+			overrides[ex] = make(map[string]string, len(symbols))
+			for symbol, levels := range symbols {
+				overrides[ex][symbol] = publisher.FormatDepth(levels)
+			}
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"default":   publisher.FormatDepth(pub.DefaultDepth()),
+			"overrides": overrides,
+		})
+	})
+
+	s.Handle("PUT /admin/publish-depth", func(w http.ResponseWriter, r *http.Request) {
+		levels, ok := decodeDepth(w, r)
+		if !ok {
+			return
+		}
+		if err := pub.SetDefaultDepth(levels); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"default": publisher.FormatDepth(levels),
+		})
+	})
+
+	s.Handle("PUT /admin/publish-depth/{exchange}/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		exchange := strings.ToLower(r.PathValue("exchange"))
+		if !knownExchange(exchange) {
+			WriteError(w, http.StatusNotFound, "unknown exchange")
+			return
+		}
+		levels, ok := decodeDepth(w, r)
+		if !ok {
+			return
+		}
+		symbol := r.PathValue("symbol")
+		if err := pub.SetDepth(connector.ExchangeID(exchange), symbol, levels); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"exchange": exchange,
+			"symbol":   symbol,
+			"depth":    publisher.FormatDepth(levels),
+		})
+	})
+
+	s.Handle("DELETE /admin/publish-depth/{exchange}/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		exchange := strings.ToLower(r.PathValue("exchange"))
+		symbol := r.PathValue("symbol")
+		pub.SetDepth(connector.ExchangeID(exchange), symbol, -1)
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"exchange": exchange,
+			"symbol":   symbol,
+			"depth":    publisher.FormatDepth(pub.DefaultDepth()),
+		})
+	})
+}
+
+// decodeDepth reads {"depth": 5} or {"depth": "full"} from the body
+func decodeDepth(w http.ResponseWriter, r *http.Request) (int, bool) {
+	var body struct {
+		Depth interface{} `json:"depth"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Depth == nil {
+		WriteError(w, http.StatusBadRequest, `body must be {"depth": 1, 5, 20 or "full"}`)
+		return 0, false
+	}
+	levels, err := publisher.ParseDepth(fmt.Sprint(body.Depth))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+	return levels, true
+}
@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"crossspread-md-ingest/internal/execution"
+)
+
+// RegisterDrawdown exposes drawdown de-risking:
+//
+//	GET  /admin/risk/drawdown       rolling PnL, drawdown and the de-risking in force
+//	POST /admin/risk/drawdown/ack   resume full size, body {"by": "alice"}
+//
+// De-risking, once tripped, stays in force until acknowledged here. The
+// operator is taken from the "by" field, then the X-Admin-User header.
+func (s *Server) RegisterDrawdown(guard *execution.DrawdownGuard) {
+	s.Handle("GET /admin/risk/drawdown", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, guard.Status())
+	})
+
+	s.Handle("POST /admin/risk/drawdown/ack", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			By string `json:"by"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				WriteError(w, http.StatusBadRequest, "invalid JSON body")
+				return
+			}
+		}
+		state, err := guard.Acknowledge(r.Context(), operator(r, body.By))
+		if errors.Is(err, execution.ErrNotDeRisked) {
+			WriteError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, state)
+	})
+}
@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/endpoints"
+)
+
+// RegisterEndpoints exposes the endpoint selection made at startup:
+//
+//	GET /admin/endpoints   chosen WebSocket and REST endpoint per venue with each candidate's handshake latency
+func (s *Server) RegisterEndpoints(sel *endpoints.Selector) {
+	s.Handle("GET /admin/endpoints", func(w http.ResponseWriter, r *http.Request) {
+		selections := sel.Selections()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":     len(selections),
+			"endpoints": selections,
+		})
+	})
+}
@@ -0,0 +1,211 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/execution"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RegisterExecution exposes pre-execution checks for operators:
+//
+//	GET /admin/execution/validate/{id}   revalidate a current spread as an executor would, without trading
+func (s *Server) RegisterExecution(sd *spread.SpreadDiscovery, v *execution.Validator) {
+	s.Handle("GET /admin/execution/validate/{id}", func(w http.ResponseWriter, r *http.Request) {
+		opp := sd.GetSpread(r.PathValue("id"))
+		if opp == nil {
+			WriteError(w, http.StatusNotFound, "spread not found")
+			return
+		}
+		result, err := v.Validate(r.Context(), opp)
+		if err != nil {
+			WriteError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, result)
+	})
+}
+
+// RegisterPreflight exposes the account preflight:
+//
+//	GET  /admin/execution/preflight   last check with its mismatches and read errors
+//	POST /admin/execution/preflight   check every venue symbol now
+func (s *Server) RegisterPreflight(p *execution.Preflight) {
+	s.Handle("GET /admin/execution/preflight", func(w http.ResponseWriter, r *http.Request) {
+		report := p.Report()
+		if report == nil {
+			WriteError(w, http.StatusServiceUnavailable, execution.ErrPreflightPending.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, report)
+	})
+
+	s.Handle("POST /admin/execution/preflight", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, p.Recheck(r.Context()))
+	})
+}
+
+// RegisterHedging exposes fallback hedge venues and positions awaiting migration:
+//
+//	GET    /admin/execution/hedge-venues/{canonical}?side=sell&quantity=0.5   cheapest venue first
+//	GET    /admin/execution/migrations                                        positions hedged on a fallback venue
+//	DELETE /admin/execution/migrations/{id}                                   clear a migrated or closed position
+func (s *Server) RegisterHedging(ranker *execution.HedgeRanker, migrations *execution.Migrations) {
+	s.Handle("GET /admin/execution/hedge-venues/{canonical}", func(w http.ResponseWriter, r *http.Request) {
+		side := r.URL.Query().Get("side")
+		if side != execution.SideBuy && side != execution.SideSell {
+			WriteError(w, http.StatusBadRequest, "side must be buy or sell")
+			return
+		}
+		var quantity float64
+		if v := r.URL.Query().Get("quantity"); v != "" {
+			q, err := strconv.ParseFloat(v, 64)
+			if err != nil || q < 0 {
+				WriteError(w, http.StatusBadRequest, "quantity must be a non-negative number")
+				return
+			}
+			quantity = q
+		}
+		venues := ranker.Rank(r.PathValue("canonical"), side, quantity)
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":  len(venues),
+			"venues": venues,
+		})
+	})
+
+	s.Handle("GET /admin/execution/migrations", func(w http.ResponseWriter, r *http.Request) {
+		flags, err := migrations.List(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":      len(flags),
+			"migrations": flags,
+		})
+	})
+
+	s.Handle("DELETE /admin/execution/migrations/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ok, err := migrations.Clear(r.Context(), r.PathValue("id"))
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !ok {
+			WriteError(w, http.StatusNotFound, "migration flag not found")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"cleared": r.PathValue("id"),
+		})
+	})
+}
+
+// RegisterRouter exposes dry-run plans of the single-leg order router.
+// Executors send the orders themselves through Router.Execute.
+//
+//	POST /admin/execution/route/preview   split an order across venues, body {"canonical": "BTC", "side": "buy", "quantity": 2}
+func (s *Server) RegisterRouter(router *execution.Router) {
+	s.Handle("POST /admin/execution/route/preview", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Canonical string  `json:"canonical"`
+			Side      string  `json:"side"`
+			Quantity  float64 `json:"quantity"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		plan, err := router.Plan(body.Canonical, body.Side, body.Quantity)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, plan)
+	})
+}
+
+// RegisterMigration exposes dry-run previews of moving a hedged leg to a
+// cheaper venue. Executors run the roll itself through Migrator.Execute.
+//
+//	POST /admin/execution/migrations/preview        preview a migration from the request body
+//	POST /admin/execution/migrations/{id}/preview   preview moving a fallback hedge back to its intended venue
+func (s *Server) RegisterMigration(migrator *execution.Migrator, migrations *execution.Migrations) {
+	s.Handle("POST /admin/execution/migrations/preview", func(w http.ResponseWriter, r *http.Request) {
+		var req execution.MigrationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid migration request: "+err.Error())
+			return
+		}
+		if !knownExchange(string(req.From)) || !knownExchange(string(req.To)) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange")
+			return
+		}
+		plan, err := migrator.Plan(req)
+		if err != nil {
+			WriteError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, plan)
+	})
+
+	s.Handle("POST /admin/execution/migrations/{id}/preview", func(w http.ResponseWriter, r *http.Request) {
+		flags, err := migrations.List(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, f := range flags {
+			if f.ID != r.PathValue("id") {
+				continue
+			}
+			plan, err := migrator.Plan(execution.RequestFromFlag(f))
+			if err != nil {
+				WriteError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			}
+			WriteJSON(w, http.StatusOK, plan)
+			return
+		}
+		WriteError(w, http.StatusNotFound, "migration flag not found")
+	})
+}
+
+// RegisterModes exposes each exchange's execution mode:
+//
+//	GET /admin/execution/modes              every venue's mode
+//	PUT /admin/execution/modes/{exchange}   set a mode until restart, body {"mode": "md-only"} or {"mode": "trade"}
+//
+// EXCHANGE_MODES holds the permanent ones.
+func (s *Server) RegisterModes(modes *execution.Modes) {
+	s.Handle("GET /admin/execution/modes", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, modes.List())
+	})
+
+	s.Handle("PUT /admin/execution/modes/{exchange}", func(w http.ResponseWriter, r *http.Request) {
+		if !knownExchange(r.PathValue("exchange")) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange "+r.PathValue("exchange"))
+			return
+		}
+		var body struct {
+			Mode string `json:"mode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		id := connector.ExchangeID(strings.ToLower(r.PathValue("exchange")))
+		if err := modes.Set(id, body.Mode); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Warn().Str("exchange", string(id)).Str("mode", body.Mode).Str("by", operator(r, "")).Msg("Exchange execution mode changed")
+		WriteJSON(w, http.StatusOK, execution.ExchangeMode{Exchange: id, Mode: modes.Mode(id)})
+	})
+}
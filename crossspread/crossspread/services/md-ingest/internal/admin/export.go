@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/export"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RegisterExport exposes recorded market data as downloadable files:
+//
+//	GET /admin/export?dataset=trades&exchange=binance&symbol=BTCUSDT&from=&to=&format=csv&fields=timestamp,price
+//	GET /admin/export/fields?dataset=orderbook  columns a dataset can export
+//
+// dataset is trades, orderbook, funding or bars (with interval=1m); format is
+// csv, jsonl or parquet. from and to are RFC 3339 times or Unix milliseconds
+// and default to the last hour. fields selects and orders the columns and
+// defaults to all of them; limit caps the rows.
+func (s *Server) RegisterExport(e *export.Exporter) {
+	s.Handle("GET /admin/export", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		req := export.Request{
+			Dataset:  q.Get("dataset"),
+			Exchange: connector.ExchangeID(q.Get("exchange")),
+			Symbol:   q.Get("symbol"),
+			Interval: q.Get("interval"),
+			Format:   q.Get("format"),
+			Fields:   export.ParseFields(q.Get("fields")),
+			To:       time.Now(),
+		}
+		if req.Format == "" {
+			req.Format = export.FormatCSV
+		}
+		req.From = req.To.Add(-time.Hour)
+		for name, t := range map[string]*time.Time{"from": &req.From, "to": &req.To} {
+			if v := q.Get(name); v != "" {
+				parsed, err := export.ParseTime(v)
+				if err != nil {
+					WriteError(w, http.StatusBadRequest, name+" must be an RFC 3339 time or Unix milliseconds")
+					return
+				}
+				*t = parsed
+			}
+		}
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				WriteError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			req.Limit = n
+		}
+		// Errors past the first byte can't change the status, so reject bad
+		// requests before streaming
+		if err := e.Validate(req); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		out := &exportWriter{w: w, req: req}
+		rows, err := e.Export(r.Context(), req, out)
+		if err != nil && !out.started {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Str("dataset", req.Dataset).Str("exchange", string(req.Exchange)).
+				Str("symbol", req.Symbol).Int("rows", rows).Msg("Export failed")
+			return
+		}
+		log.Info().Str("dataset", req.Dataset).Str("exchange", string(req.Exchange)).
+			Str("symbol", req.Symbol).Str("format", req.Format).Int("rows", rows).Msg("Exported market data")
+	})
+
+	s.Handle("GET /admin/export/fields", func(w http.ResponseWriter, r *http.Request) {
+		dataset := r.URL.Query().Get("dataset")
+		cols, err := export.Columns(dataset)
+		if errors.Is(err, export.ErrInvalidRequest) {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"dataset": dataset,
+			"fields":  cols,
+		})
+	})
+}
+
+// exportWriter sets the file headers on the first write, so a failure
+// before any data is sent can still be answered with an error
+type exportWriter struct {
+	w       http.ResponseWriter
+	req     export.Request
+	started bool
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	if !ew.started {
+		ew.started = true
+		ew.w.Header().Set("Content-Type", export.ContentType(ew.req.Format))
+		ew.w.Header().Set("Content-Disposition", `attachment; filename="`+export.Filename(ew.req)+`"`)
+	}
+	return ew.w.Write(p)
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/feedback"
+)
+
+// RegisterFeedback exposes false positive tagging of published opportunities:
+//
+//	POST /admin/feedback/false-positives  tag one, body {"opportunity_id": "BTC:binance:okx", "reason": "stale", "by": "", "note": ""}
+//	GET  /admin/feedback/false-positives?exchange=okx&canonical=BTC&limit=100  recent tags, newest first
+//	GET  /admin/feedback/quality          tag counts by reason and data quality per venue symbol
+//
+// Reasons are stale, unexecutable, wrong_mapping and other. Venues are taken
+// from the opportunity ID unless "exchanges" is given. The tagger is taken
+// from the "by" field, then the X-Admin-User header.
+func (s *Server) RegisterFeedback(store *feedback.Store) {
+	s.Handle("POST /admin/feedback/false-positives", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			OpportunityID string   `json:"opportunity_id"`
+			Canonical     string   `json:"canonical"`
+			Exchanges     []string `json:"exchanges"`
+			Reason        string   `json:"reason"`
+			By            string   `json:"by"`
+			Note          string   `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+
+		tag := feedback.Tag{
+			OpportunityID: body.OpportunityID,
+			Canonical:     body.Canonical,
+			Reason:        body.Reason,
+			Source:        operator(r, body.By),
+			Note:          body.Note,
+		}
+		for _, e := range body.Exchanges {
+			if !knownExchange(e) {
+				WriteError(w, http.StatusBadRequest, "unknown exchange "+e)
+				return
+			}
+			tag.Exchanges = append(tag.Exchanges, connector.ExchangeID(strings.ToLower(e)))
+		}
+
+		tag, err := store.Add(r.Context(), tag)
+		if errors.Is(err, feedback.ErrInvalidTag) {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusCreated, tag)
+	})
+
+	s.Handle("GET /admin/feedback/false-positives", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit := 100
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				WriteError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+		tags := store.Recent(connector.ExchangeID(strings.ToLower(q.Get("exchange"))), q.Get("canonical"), limit)
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count": len(tags),
+			"tags":  tags,
+		})
+	})
+
+	s.Handle("GET /admin/feedback/quality", func(w http.ResponseWriter, r *http.Request) {
+		symbols := store.Summary()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(symbols),
+			"symbols": symbols,
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"crossspread-md-ingest/internal/feedwatch"
+)
+
+// RegisterFeedWatch exposes the feed rate watchdog:
+//
+//	GET /admin/feeds                   rate against the learned baseline per exchange and channel
+//	GET /admin/feeds/alerts?limit=100  recent silent and recovered alerts, newest first
+func (s *Server) RegisterFeedWatch(w *feedwatch.Watchdog) {
+	s.Handle("GET /admin/feeds", func(rw http.ResponseWriter, r *http.Request) {
+		feeds := w.Feeds()
+		silent := 0
+		for _, f := range feeds {
+			if f.Silent {
+				silent++
+			}
+		}
+		WriteJSON(rw, http.StatusOK, map[string]interface{}{
+			"silent": silent,
+			"feeds":  feeds,
+		})
+	})
+
+	s.Handle("GET /admin/feeds/alerts", func(rw http.ResponseWriter, r *http.Request) {
+		limit := 100
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				WriteError(rw, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+		alerts := w.Alerts()
+		if len(alerts) > limit {
+			alerts = alerts[:limit]
+		}
+		WriteJSON(rw, http.StatusOK, map[string]interface{}{
+			"count":  len(alerts),
+			"alerts": alerts,
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crossspread-md-ingest/internal/flags"
+)
+
+// RegisterFlags exposes the environment's feature flags:
+//
+//	GET    /admin/flags           effective state of every flag
+//	PUT    /admin/flags/{name}    override a flag, body {"enabled": true}
+//	DELETE /admin/flags/{name}    drop the override and revert to the default
+//
+// Overrides are stored in Redis and apply to every service of the environment.
+func (s *Server) RegisterFlags(store *flags.Store) {
+	s.Handle("GET /admin/flags", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"env":   store.Env(),
+			"flags": store.List(),
+		})
+	})
+
+	s.Handle("PUT /admin/flags/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+			WriteError(w, http.StatusBadRequest, `body must be {"enabled": true|false}`)
+			return
+		}
+
+		name := r.PathValue("name")
+		if err := store.Set(r.Context(), name, *body.Enabled); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{"name": name, "enabled": *body.Enabled})
+	})
+
+	s.Handle("DELETE /admin/flags/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := store.Clear(r.Context(), name); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{"name": name, "enabled": store.Enabled(name)})
+	})
+}
@@ -0,0 +1,257 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crossspread-md-ingest/internal/history"
+)
+
+// RegisterHistory exposes research queries over the spread history store:
+//
+//	GET /admin/history/top?date=2024-01-31&limit=50             top opportunities by peak bps
+//	GET /admin/history/distribution?date=&long=okx&short=bybit  spread bps histogram per exchange pair
+//	GET /admin/history/persistence?date=&symbol=BTC             opportunity lifetimes per symbol
+//	GET /admin/history/bars?exchange=bybit&symbol=BTCUSDT&interval=1m&from=&to=  OHLCV bars
+//	GET /admin/history/sessions?from=&to=&long=okx&short=bybit  opportunities by UTC hour-of-day and weekday
+//	GET /admin/history/correlation?from=&to=&bucket=1m&min=0.6  correlation of exchange pairs' spreads and clusters moving together
+//	GET /admin/history/capture?date=&by=pair                    signal vs captured spread per pair or symbol
+//	POST /admin/history/capture                                 body history.Execution; executors report fills
+//
+// date is a UTC day and defaults to today. For bars, from and to are RFC 3339
+// times and default to the last 24 hours; for sessions they are UTC days, at
+// most 31 apart, and default to the last 7 days. Correlation takes the same
+// days, defaulting to today.
+func (s *Server) RegisterHistory(store *history.Store) {
+	s.Handle("GET /admin/history/top", func(w http.ResponseWriter, r *http.Request) {
+		date, ok := historyDate(w, r)
+		if !ok {
+			return
+		}
+		limit := 50
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > 1000 {
+				WriteError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+				return
+			}
+			limit = n
+		}
+
+		spreads, err := store.TopByDay(r.Context(), date, limit)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"date":    date,
+			"count":   len(spreads),
+			"spreads": spreads,
+		})
+	})
+
+	s.Handle("GET /admin/history/distribution", func(w http.ResponseWriter, r *http.Request) {
+		date, ok := historyDate(w, r)
+		if !ok {
+			return
+		}
+		long := r.URL.Query().Get("long")
+		short := r.URL.Query().Get("short")
+		if (long == "") != (short == "") {
+			WriteError(w, http.StatusBadRequest, "long and short must be given together")
+			return
+		}
+
+		pairs, err := store.Distribution(r.Context(), date, long, short)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"date":  date,
+			"pairs": pairs,
+		})
+	})
+
+	s.Handle("GET /admin/history/persistence", func(w http.ResponseWriter, r *http.Request) {
+		date, ok := historyDate(w, r)
+		if !ok {
+			return
+		}
+
+		stats, err := store.Persistence(r.Context(), date, r.URL.Query().Get("symbol"))
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"date":    date,
+			"symbols": stats,
+		})
+	})
+
+	s.Handle("GET /admin/history/bars", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		exchange, symbol := q.Get("exchange"), q.Get("symbol")
+		if exchange == "" || symbol == "" {
+			WriteError(w, http.StatusBadRequest, "exchange and symbol are required")
+			return
+		}
+		interval := q.Get("interval")
+		if interval == "" {
+			interval = "1m"
+		}
+
+		to := time.Now()
+		from := to.Add(-24 * time.Hour)
+		for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+			if v := q.Get(name); v != "" {
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					WriteError(w, http.StatusBadRequest, name+" must be an RFC 3339 time")
+					return
+				}
+				*t = parsed
+			}
+		}
+
+		result, err := store.Bars(r.Context(), exchange, symbol, interval, from, to)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"exchange": exchange,
+			"symbol":   symbol,
+			"interval": interval,
+			"count":    len(result),
+			"bars":     result,
+		})
+	})
+
+	s.Handle("GET /admin/history/sessions", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		long, short := q.Get("long"), q.Get("short")
+		if (long == "") != (short == "") {
+			WriteError(w, http.StatusBadRequest, "long and short must be given together")
+			return
+		}
+		today := time.Now().UTC()
+		from, to := q.Get("from"), q.Get("to")
+		if to == "" {
+			to = today.Format(history.DateLayout)
+		}
+		if from == "" {
+			from = today.AddDate(0, 0, -6).Format(history.DateLayout)
+		}
+
+		pairs, err := store.Sessions(r.Context(), from, to, long, short)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"from":  from,
+			"to":    to,
+			"pairs": pairs,
+		})
+	})
+
+	s.Handle("GET /admin/history/correlation", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		cfg := history.DefaultCorrelationConfig()
+		if v := q.Get("bucket"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < time.Minute {
+				WriteError(w, http.StatusBadRequest, "bucket must be a duration of at least 1m")
+				return
+			}
+			cfg.Bucket = d
+		}
+		if v := q.Get("min"); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < -1 || f > 1 {
+				WriteError(w, http.StatusBadRequest, "min must be a correlation between -1 and 1")
+				return
+			}
+			cfg.MinCorrelation = f
+		}
+		today := time.Now().UTC().Format(history.DateLayout)
+		from, to := q.Get("from"), q.Get("to")
+		if to == "" {
+			to = today
+		}
+		if from == "" {
+			from = to
+		}
+
+		corr, err := store.Correlation(r.Context(), from, to, cfg)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, corr)
+	})
+
+	s.Handle("GET /admin/history/capture", func(w http.ResponseWriter, r *http.Request) {
+		date, ok := historyDate(w, r)
+		if !ok {
+			return
+		}
+		by := r.URL.Query().Get("by")
+		if by == "" {
+			by = history.CaptureByPair
+		}
+		if by != history.CaptureByPair && by != history.CaptureBySymbol {
+			WriteError(w, http.StatusBadRequest, "by must be pair or symbol")
+			return
+		}
+
+		stats, err := store.Capture(r.Context(), date, by)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"date":   date,
+			"by":     by,
+			"groups": stats,
+		})
+	})
+
+	s.Handle("POST /admin/history/capture", func(w http.ResponseWriter, r *http.Request) {
+		var e history.Execution
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid execution: "+err.Error())
+			return
+		}
+		if err := store.RecordExecution(r.Context(), e); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, history.ErrInvalidExecution) {
+				status = http.StatusBadRequest
+			}
+			WriteError(w, status, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"opportunity_id": e.OpportunityID,
+			"captured_bps":   e.CapturedBps(),
+		})
+	})
+}
+
+// historyDate returns the validated date query parameter, defaulting to today (UTC)
+func historyDate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		return time.Now().UTC().Format(history.DateLayout), true
+	}
+	if _, err := time.Parse(history.DateLayout, date); err != nil {
+		WriteError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return "", false
+	}
+	return date, true
+}
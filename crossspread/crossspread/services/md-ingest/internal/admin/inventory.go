@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/execution"
+	"crossspread-md-ingest/internal/inventory"
+)
+
+// RegisterInventory exposes the positions used for skew-aware scoring:
+//
+//	GET /admin/inventory                           non-flat positions
+//	PUT /admin/inventory/{exchange}/{canonical}    body {"quantity": -0.5}; reconcile a position, 0 flattens
+func (s *Server) RegisterInventory(store *inventory.Store) {
+	s.Handle("GET /admin/inventory", func(w http.ResponseWriter, r *http.Request) {
+		positions := store.List()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":     len(positions),
+			"positions": positions,
+		})
+	})
+
+	s.Handle("PUT /admin/inventory/{exchange}/{canonical}", func(w http.ResponseWriter, r *http.Request) {
+		exchange := strings.ToLower(r.PathValue("exchange"))
+		if !knownExchange(exchange) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange "+exchange)
+			return
+		}
+		var body struct {
+			Quantity *float64 `json:"quantity"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Quantity == nil {
+			WriteError(w, http.StatusBadRequest, `body must be {"quantity": number}`)
+			return
+		}
+
+		p := inventory.Position{
+			Exchange:  connector.ExchangeID(exchange),
+			Canonical: r.PathValue("canonical"),
+			Quantity:  *body.Quantity,
+		}
+		if err := store.Set(r.Context(), p.Exchange, p.Canonical, p.Quantity); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, p)
+	})
+}
+
+// RegisterNotionalLimits exposes notional per underlying against its caps:
+//
+//	GET /admin/inventory/notional   most of a cap used first
+func (s *Server) RegisterNotionalLimits(limits *execution.NotionalLimits) {
+	s.Handle("GET /admin/inventory/notional", func(w http.ResponseWriter, r *http.Request) {
+		usages := limits.Usages()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":       len(usages),
+			"underlyings": usages,
+		})
+	})
+}
+
+// RegisterExposure exposes aggregate delta and gamma per underlying across
+// perp and option legs:
+//
+//	GET /admin/inventory/exposure   largest USD delta first
+func (s *Server) RegisterExposure(store *inventory.Store, prices inventory.PriceSource) {
+	s.Handle("GET /admin/inventory/exposure", func(w http.ResponseWriter, r *http.Request) {
+		exposures := store.Exposures(prices)
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":     len(exposures),
+			"exposures": exposures,
+		})
+	})
+}
@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/listings"
+)
+
+// RegisterListings exposes detected listing announcements:
+//
+//	GET /admin/listings    recent perpetual listings and delistings, newest first
+func (s *Server) RegisterListings(w *listings.Watcher) {
+	s.Handle("GET /admin/listings", func(rw http.ResponseWriter, r *http.Request) {
+		events := w.Recent()
+		WriteJSON(rw, http.StatusOK, map[string]interface{}{
+			"count":  len(events),
+			"events": events,
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/maintenance"
+)
+
+// RegisterMaintenance exposes the venues' scheduled maintenance windows:
+//
+//	GET    /admin/maintenance        windows, whether each is in effect and when it next starts
+//	POST   /admin/maintenance        add a window, body {"exchange": "okx", "schedule": "once", "start": "2026-10-20T02:00:00Z", "for": "2h", "reason": ""}
+//	DELETE /admin/maintenance/{id}   remove a window; a venue it was muting resumes
+//
+// Recurring windows take a schedule of daily or a weekday (sun..sat) and a
+// UTC time of day in "at", e.g. {"exchange": "bybit", "schedule": "sun", "at": "04:00", "for": "30m"}.
+// Windows added here last until restart; MAINTENANCE_WINDOWS holds the
+// permanent ones.
+func (s *Server) RegisterMaintenance(sched *maintenance.Scheduler) {
+	s.Handle("GET /admin/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		windows := sched.Windows()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(windows),
+			"windows": windows,
+		})
+	})
+
+	s.Handle("POST /admin/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Exchange string    `json:"exchange"`
+			Schedule string    `json:"schedule"`
+			At       string    `json:"at"`
+			Start    time.Time `json:"start"`
+			For      string    `json:"for"`
+			Reason   string    `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if !knownExchange(body.Exchange) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange "+body.Exchange)
+			return
+		}
+		d, err := time.ParseDuration(body.For)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "for must be a positive duration, e.g. 30m")
+			return
+		}
+		if body.Schedule == "" {
+			body.Schedule = maintenance.Once
+		}
+
+		window, err := sched.Add(maintenance.Window{
+			Exchange: connector.ExchangeID(strings.ToLower(body.Exchange)),
+			Schedule: body.Schedule,
+			At:       body.At,
+			Start:    body.Start,
+			Duration: d,
+			Reason:   body.Reason,
+		})
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, window.Status(time.Now()))
+	})
+
+	s.Handle("DELETE /admin/maintenance/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !sched.Remove(r.PathValue("id")) {
+			WriteError(w, http.StatusNotFound, "maintenance window not found")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+	})
+}
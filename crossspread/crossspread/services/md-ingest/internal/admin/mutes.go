@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/spread"
+)
+
+// RegisterMutes exposes runtime muting of exchange pairs in spread discovery:
+//
+//	GET    /admin/mutes           active mutes
+//	POST   /admin/mutes           mute a pair, body {"long": "lbank", "short": "", "reason": "", "for": "2h"}
+//	DELETE /admin/mutes/{id}      resume a pair, e.g. /admin/mutes/lbank:*
+//	GET    /admin/mutes/audit     who muted or resumed what and when, newest first
+//
+// An empty leg matches any exchange. The operator is taken from the "by"
+// field or query parameter, then the X-Admin-User header. Mutes are saved
+// to the store so every instance of the environment applies them.
+func (s *Server) RegisterMutes(sd *spread.SpreadDiscovery, store MuteStore) {
+	s.Handle("GET /admin/mutes", func(w http.ResponseWriter, r *http.Request) {
+		mutes := sd.Mutes()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count": len(mutes),
+			"mutes": mutes,
+		})
+	})
+
+	s.Handle("POST /admin/mutes", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Long   string `json:"long"`
+			Short  string `json:"short"`
+			Reason string `json:"reason"`
+			By     string `json:"by"`
+			For    string `json:"for"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if body.Long == "" && body.Short == "" {
+			WriteError(w, http.StatusBadRequest, "long or short is required")
+			return
+		}
+		for _, leg := range []string{body.Long, body.Short} {
+			if leg != "" && !knownExchange(leg) {
+				WriteError(w, http.StatusBadRequest, "unknown exchange "+leg)
+				return
+			}
+		}
+
+		m := spread.PairMute{
+			Long:   connector.ExchangeID(strings.ToLower(body.Long)),
+			Short:  connector.ExchangeID(strings.ToLower(body.Short)),
+			Reason: body.Reason,
+			By:     operator(r, body.By),
+			At:     time.Now(),
+		}
+		if body.For != "" {
+			d, err := time.ParseDuration(body.For)
+			if err != nil || d <= 0 {
+				WriteError(w, http.StatusBadRequest, "for must be a positive duration, e.g. 2h")
+				return
+			}
+			m.Until = m.At.Add(d)
+		}
+		if err := store.SaveMute(r.Context(), m); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, sd.MutePair(m))
+	})
+
+	s.Handle("DELETE /admin/mutes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		saved, err := store.DeleteMute(r.Context(), r.PathValue("id"))
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		m, ok := sd.ResumePair(r.PathValue("id"), operator(r, r.URL.Query().Get("by")))
+		if !ok && !saved {
+			WriteError(w, http.StatusNotFound, "mute not found")
+			return
+		}
+		m.ID = r.PathValue("id")
+		WriteJSON(w, http.StatusOK, m)
+	})
+
+	s.Handle("GET /admin/mutes/audit", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"audit": sd.MuteAudit(),
+		})
+	})
+}
+
+// MuteStore shares mutes between instances; *settings.Store implements it
+type MuteStore interface {
+	SaveMute(ctx context.Context, m spread.PairMute) error
+	DeleteMute(ctx context.Context, id string) (bool, error)
+}
+
+// operator returns who made an admin request; the remote address stands in
+// for callers that don't identify themselves
+func operator(r *http.Request, by string) string {
+	if by != "" {
+		return by
+	}
+	if v := r.Header.Get("X-Admin-User"); v != "" {
+		return v
+	}
+	return r.RemoteAddr
+}
+
+func knownExchange(id string) bool {
+	for _, known := range connector.Exchanges() {
+		if string(known) == strings.ToLower(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"crossspread-md-ingest/internal/openinterest"
+)
+
+// RegisterOpenInterest exposes recent open interest events:
+//
+//	GET /admin/openinterest/events?kind=build&limit=100   newest first
+func (s *Server) RegisterOpenInterest(m *openinterest.Monitor) {
+	s.Handle("GET /admin/openinterest/events", func(w http.ResponseWriter, r *http.Request) {
+		kind := r.URL.Query().Get("kind")
+		limit := 100
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				WriteError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		events := make([]openinterest.Event, 0, limit)
+		for _, e := range m.Events() {
+			if kind != "" && e.Kind != kind {
+				continue
+			}
+			events = append(events, e)
+			if len(events) == limit {
+				break
+			}
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":  len(events),
+			"events": events,
+		})
+	})
+}
@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/publisher"
+)
+
+// RegisterPublishClasses exposes the publisher's traffic classes:
+//
+//	GET /admin/publish/classes   queued and dropped publishes per class, highest priority first
+func (s *Server) RegisterPublishClasses(pub *publisher.RedisPublisher) {
+	s.Handle("GET /admin/publish/classes", func(w http.ResponseWriter, r *http.Request) {
+		stats := pub.PriorityStats()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"prioritized": stats != nil,
+			"classes":     stats,
+		})
+	})
+}
+
+// RegisterBBO exposes the BBO latency SLO of partial book publication:
+//
+//	GET /admin/publish/bbo   p99 BBO publish latency against its target, and whether it is breached
+func (s *Server) RegisterBBO(pub *publisher.RedisPublisher) {
+	s.Handle("GET /admin/publish/bbo", func(w http.ResponseWriter, r *http.Request) {
+		status := pub.BBOStatus()
+		if status == nil {
+			WriteError(w, http.StatusNotFound, "books are published in full; set BOOK_PUBLISH_MODE=partial")
+			return
+		}
+		WriteJSON(w, http.StatusOK, status)
+	})
+}
@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/ratebudget"
+)
+
+// RegisterRateBudget exposes the shared order rate budget:
+//
+//	GET /admin/ratebudget    token bucket state and per-priority floors per exchange
+func (s *Server) RegisterRateBudget(b *ratebudget.Budget) {
+	s.Handle("GET /admin/ratebudget", func(w http.ResponseWriter, r *http.Request) {
+		status, err := b.Status(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"exchanges": status,
+		})
+	})
+}
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/normalizer"
+)
+
+// RegisterRenames exposes the symbol rename table:
+//
+//	GET /admin/renames                              renames, oldest first
+//	GET /admin/renames?symbol=MATIC&exchange=bybit  the canonical a venue's symbol maps to now
+//
+// Renames are set at startup from SYMBOL_RENAMES on top of the built-in ones.
+func (s *Server) RegisterRenames(norm *normalizer.InstrumentNormalizer) {
+	s.Handle("GET /admin/renames", func(w http.ResponseWriter, r *http.Request) {
+		renames := norm.Renames()
+		result := map[string]interface{}{
+			"count":   len(renames),
+			"renames": renames,
+		}
+		if symbol := r.URL.Query().Get("symbol"); symbol != "" {
+			exchange := connector.ExchangeID(strings.ToLower(r.URL.Query().Get("exchange")))
+			result["symbol"] = symbol
+			result["canonical"] = norm.Rename(exchange, strings.ToUpper(symbol))
+		}
+		WriteJSON(w, http.StatusOK, result)
+	})
+}
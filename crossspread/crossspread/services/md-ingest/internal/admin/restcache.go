@@ -0,0 +1,26 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/restcache"
+)
+
+// RegisterRESTCache exposes the venue metadata cache:
+//
+//	GET    /admin/restcache             stored responses with their age and TTL
+//	DELETE /admin/restcache?match=okx   drop responses whose URL contains match, or all of them
+func (s *Server) RegisterRESTCache(cache *restcache.Cache) {
+	s.Handle("GET /admin/restcache", func(w http.ResponseWriter, r *http.Request) {
+		entries := cache.Entries()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(entries),
+			"entries": entries,
+		})
+	})
+
+	s.Handle("DELETE /admin/restcache", func(w http.ResponseWriter, r *http.Request) {
+		purged := cache.Purge(r.URL.Query().Get("match"))
+		WriteJSON(w, http.StatusOK, map[string]interface{}{"status": "purged", "count": purged})
+	})
+}
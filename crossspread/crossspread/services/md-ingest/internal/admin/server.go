@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Server exposes operational endpoints (blacklists, pauses, status) over HTTP.
+// Subsystems register their routes with Handle before Start is called.
+type Server struct {
+	addr   string
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+// NewServer creates a new admin server
+func NewServer(addr string) *Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/health", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	return &Server{
+		addr: addr,
+		mux:  mux,
+		server: &http.Server{
+			Addr:    addr,
+			Handler: mux,
+		},
+	}
+}
+
+// Handle registers a handler, e.g. Handle("GET /admin/blacklist", h)
+func (s *Server) Handle(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// Start starts the admin server
+func (s *Server) Start() error {
+	log.Info().Str("addr", s.addr).Msg("Starting admin server")
+	return s.server.ListenAndServe()
+}
+
+// Stop stops the admin server
+func (s *Server) Stop() error {
+	return s.server.Close()
+}
+
+// WriteJSON writes v as a JSON response
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("Failed to write admin response")
+	}
+}
+
+// WriteError writes a JSON error response
+func WriteError(w http.ResponseWriter, status int, msg string) {
+	WriteJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crossspread-md-ingest/internal/settings"
+)
+
+// RegisterSettings exposes the runtime settings shared through Redis:
+//
+//	GET    /admin/settings                     effective parameters, tiers and shared mutes
+//	PUT    /admin/settings/params/{name}       override a parameter, body {"value": 3}
+//	DELETE /admin/settings/params/{name}       drop the override and revert to the default
+//	PUT    /admin/settings/tiers/{canonical}   assign a symbol to a tier, body {"tier": "major"}
+//	DELETE /admin/settings/tiers/{canonical}   return a symbol to the default thresholds
+//	PUT    /admin/settings/filter              publish only matching opportunities, body {"expression": "spread_bps > 8"}
+//	DELETE /admin/settings/filter              publish every opportunity
+//
+// Parameters are spread.min_spread_bps, spread.min_depth_usd and
+// tier.{tier}.min_spread_bps / tier.{tier}.min_depth_usd. Writes apply to
+// every instance of the environment within seconds.
+func (s *Server) RegisterSettings(store *settings.Store) {
+	s.Handle("GET /admin/settings", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+
+	s.Handle("PUT /admin/settings/params/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Value *float64 `json:"value"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Value == nil {
+			WriteError(w, http.StatusBadRequest, `body must be {"value": <number>}`)
+			return
+		}
+		if err := store.SetParam(r.Context(), r.PathValue("name"), *body.Value); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+
+	s.Handle("DELETE /admin/settings/params/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if err := store.ClearParam(r.Context(), r.PathValue("name")); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+
+	s.Handle("PUT /admin/settings/tiers/{canonical}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Tier string `json:"tier"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if err := store.SetTier(r.Context(), r.PathValue("canonical"), body.Tier); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+
+	s.Handle("DELETE /admin/settings/tiers/{canonical}", func(w http.ResponseWriter, r *http.Request) {
+		if err := store.ClearTier(r.Context(), r.PathValue("canonical")); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+
+	s.Handle("PUT /admin/settings/filter", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Expression string `json:"expression"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Expression == "" {
+			WriteError(w, http.StatusBadRequest, `body must be {"expression": "<filter>"}`)
+			return
+		}
+		if err := store.SetFilter(r.Context(), body.Expression); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+
+	s.Handle("DELETE /admin/settings/filter", func(w http.ResponseWriter, r *http.Request) {
+		if err := store.ClearFilter(r.Context()); err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, store.State())
+	})
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/funding"
+)
+
+// RegisterSettlements exposes the funding settlement schedule:
+//
+//	GET /admin/funding/settlements   settlements within the horizon, soonest first
+//	GET /admin/funding/actions       recently fired pre-settlement actions, newest first
+func (s *Server) RegisterSettlements(sched *funding.Scheduler) {
+	s.Handle("GET /admin/funding/settlements", func(w http.ResponseWriter, r *http.Request) {
+		settlements := sched.Upcoming()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":       len(settlements),
+			"settlements": settlements,
+		})
+	})
+
+	s.Handle("GET /admin/funding/actions", func(w http.ResponseWriter, r *http.Request) {
+		actions := sched.Actions()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(actions),
+			"actions": actions,
+		})
+	})
+}
+
+// RegisterFundingIntervals exposes the funding interval resolver:
+//
+//	GET /admin/funding/intervals     resolved interval and its source per contract
+func (s *Server) RegisterFundingIntervals(iv *funding.Intervals) {
+	s.Handle("GET /admin/funding/intervals", func(w http.ResponseWriter, r *http.Request) {
+		intervals := iv.Intervals()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":     len(intervals),
+			"intervals": intervals,
+		})
+	})
+}
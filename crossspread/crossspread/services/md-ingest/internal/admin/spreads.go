@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crossspread-md-ingest/internal/spread"
+)
+
+// RegisterSpreads exposes the spreads discovery currently tracks:
+//
+//	GET /admin/spreads                      top spreads by score, limit=10 by default
+//	GET /admin/spreads?canonical=BTC        spreads of one symbol by score
+//	GET  /admin/spreads/filter              filter in force and the fields expressions may use
+//	POST /admin/spreads/filter              current spreads an expression matches, without applying it
+//
+// Filters are applied through /admin/settings/filter.
+func (s *Server) RegisterSpreads(sd *spread.SpreadDiscovery) {
+	s.Handle("GET /admin/spreads", func(w http.ResponseWriter, r *http.Request) {
+		limit := 10
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > 100 {
+				WriteError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+				return
+			}
+			limit = n
+		}
+
+		var spreads []*spread.SpreadOpportunity
+		canonical := strings.ToUpper(r.URL.Query().Get("canonical"))
+		if canonical != "" {
+			spreads = sd.GetSpreadsByCanonical(canonical)
+			spreads = spreads[:min(limit, len(spreads))]
+		} else {
+			spreads = sd.GetTopSpreads(limit)
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(spreads),
+			"spreads": spreads,
+		})
+	})
+
+	s.Handle("GET /admin/spreads/filter", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"filter": sd.Filter(),
+			"fields": spread.FilterFields(),
+		})
+	})
+
+	s.Handle("POST /admin/spreads/filter", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Expression string `json:"expression"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		expr, err := spread.CompileFilter(body.Expression)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		spreads := sd.MatchingSpreads(expr)
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(spreads),
+			"spreads": spreads[:min(len(spreads), 100)],
+		})
+	})
+}
@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/strategy"
+)
+
+// RegisterStrategies exposes the in-process strategy host:
+//
+//	GET /admin/strategies    compiled-in strategies, running ones and their spread queues
+func (s *Server) RegisterStrategies(host *strategy.Host) {
+	s.Handle("GET /admin/strategies", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"registered": strategy.Registered(),
+			"running":    host.Strategies(),
+		})
+	})
+}
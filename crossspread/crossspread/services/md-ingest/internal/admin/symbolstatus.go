@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/symbolstatus"
+)
+
+// RegisterSymbolStatus exposes the symbol statuses gating discovery and
+// execution:
+//
+//	GET    /admin/symbol-status                      symbols not open for trading and scheduled windows
+//	GET    /admin/symbol-status/transitions          recent status transitions, newest first
+//	POST   /admin/symbol-status/windows              schedule a window, body {"exchange": "bybit", "symbol": "", "status": "maintenance", "from": "", "until": "", "for": "2h", "reason": ""}
+//	DELETE /admin/symbol-status/windows/{id}         cancel a window, e.g. /admin/symbol-status/windows/bybit:*
+//
+// An empty symbol covers the whole exchange. from and until are RFC 3339
+// times; from defaults to now and "for" may be given instead of until.
+func (s *Server) RegisterSymbolStatus(tracker *symbolstatus.Tracker) {
+	s.Handle("GET /admin/symbol-status", func(w http.ResponseWriter, r *http.Request) {
+		gated := tracker.Gated()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":   len(gated),
+			"symbols": gated,
+			"windows": tracker.Windows(),
+		})
+	})
+
+	s.Handle("GET /admin/symbol-status/transitions", func(w http.ResponseWriter, r *http.Request) {
+		transitions := tracker.Recent()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":       len(transitions),
+			"transitions": transitions,
+		})
+	})
+
+	s.Handle("POST /admin/symbol-status/windows", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Exchange string    `json:"exchange"`
+			Symbol   string    `json:"symbol"`
+			Status   string    `json:"status"`
+			From     time.Time `json:"from"`
+			Until    time.Time `json:"until"`
+			For      string    `json:"for"`
+			Reason   string    `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		exchange := strings.ToLower(body.Exchange)
+		if !knownExchange(exchange) {
+			WriteError(w, http.StatusBadRequest, "unknown exchange "+body.Exchange)
+			return
+		}
+		if body.From.IsZero() {
+			body.From = time.Now()
+		}
+		if body.For != "" {
+			d, err := time.ParseDuration(body.For)
+			if err != nil || d <= 0 {
+				WriteError(w, http.StatusBadRequest, "for must be a positive duration, e.g. 2h")
+				return
+			}
+			body.Until = body.From.Add(d)
+		}
+
+		window, err := tracker.AddWindow(symbolstatus.Window{
+			Exchange: connector.ExchangeID(exchange),
+			Symbol:   body.Symbol,
+			Status:   body.Status,
+			From:     body.From,
+			Until:    body.Until,
+			Reason:   body.Reason,
+		})
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, window)
+	})
+
+	s.Handle("DELETE /admin/symbol-status/windows/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !tracker.RemoveWindow(id) {
+			WriteError(w, http.StatusNotFound, "no such window")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"removed": id,
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/credentials"
+)
+
+// RegisterTenants exposes the tenants with credentials, never their keys:
+//
+//	GET  /admin/tenants           tenants and the exchanges they hold credentials on
+//	POST /admin/tenants/refresh   reload credentials from the backend now
+func (s *Server) RegisterTenants(store *credentials.TenantStore) {
+	s.Handle("GET /admin/tenants", func(w http.ResponseWriter, r *http.Request) {
+		tenants := store.Tenants()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":        len(tenants),
+			"tenants":      tenants,
+			"refreshed_at": store.RefreshedAt(),
+		})
+	})
+
+	s.Handle("POST /admin/tenants/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if err := store.Refresh(); err != nil {
+			WriteError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count": len(store.Tenants()),
+		})
+	})
+}
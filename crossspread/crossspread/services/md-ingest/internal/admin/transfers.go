@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/execution"
+)
+
+// RegisterTransferLatencies exposes observed deposit and withdrawal
+// completion times:
+//
+//	GET /admin/transfers/latency                          every route, ?asset= to filter
+//	GET /admin/transfers/latency/{asset}/{from}/{to}      fastest chain, ?chain= for one chain
+func (s *Server) RegisterTransferLatencies(transfers *execution.TransferLatencies) {
+	s.Handle("GET /admin/transfers/latency", func(w http.ResponseWriter, r *http.Request) {
+		asset := r.URL.Query().Get("asset")
+		routes := transfers.Routes()
+		if asset != "" {
+			filtered := routes[:0]
+			for _, l := range routes {
+				if strings.EqualFold(l.Asset, asset) {
+					filtered = append(filtered, l)
+				}
+			}
+			routes = filtered
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"venues": transfers.Venues(),
+			"routes": routes,
+		})
+	})
+
+	s.Handle("GET /admin/transfers/latency/{asset}/{from}/{to}", func(w http.ResponseWriter, r *http.Request) {
+		asset := r.PathValue("asset")
+		from := connector.ExchangeID(strings.ToLower(r.PathValue("from")))
+		to := connector.ExchangeID(strings.ToLower(r.PathValue("to")))
+		if chain := r.URL.Query().Get("chain"); chain != "" {
+			e, ok := transfers.Expected(asset, chain, from, to)
+			if !ok {
+				WriteError(w, http.StatusNotFound, "no transfer latency for this route")
+				return
+			}
+			WriteJSON(w, http.StatusOK, e)
+			return
+		}
+		e, err := transfers.Fastest(asset, from, to)
+		if err != nil {
+			WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, e)
+	})
+}
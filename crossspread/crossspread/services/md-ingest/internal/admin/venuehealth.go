@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"net/http"
+
+	"crossspread-md-ingest/internal/venuehealth"
+)
+
+// RegisterVenueHealth exposes the REST health breaker:
+//
+//	GET /admin/venues/health    latency, error rate and state per venue called
+//
+// Degraded venues have their shared order rate scaled down and passive
+// quoting disabled until they recover.
+func (s *Server) RegisterVenueHealth(breaker *venuehealth.Breaker) {
+	s.Handle("GET /admin/venues/health", func(w http.ResponseWriter, r *http.Request) {
+		venues := breaker.Venues()
+		degraded := 0
+		for _, h := range venues {
+			if h.State == venuehealth.StateDegraded {
+				degraded++
+			}
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"degraded": degraded,
+			"venues":   venues,
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/webhook"
+)
+
+// RegisterWebhooks exposes spread event webhooks:
+//
+//	GET    /admin/webhooks         endpoints with delivery counters, secrets omitted
+//	POST   /admin/webhooks         register, body {"url": "https://...", "secret": "", "filter": {...}}
+//	DELETE /admin/webhooks/{id}    stop delivering and forget the endpoint
+//
+// The secret is generated when omitted and only returned by POST. Filters
+// take events, canonicals, exchanges, min_spread_bps, min_net_edge_bps and
+// profitable_only.
+func (s *Server) RegisterWebhooks(d *webhook.Dispatcher) {
+	s.Handle("GET /admin/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		endpoints := d.Endpoints()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"count":     len(endpoints),
+			"endpoints": endpoints,
+		})
+	})
+
+	s.Handle("POST /admin/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			URL    string         `json:"url"`
+			Secret string         `json:"secret"`
+			Filter webhook.Filter `json:"filter"`
+			By     string         `json:"by"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		for i, ex := range body.Filter.Exchanges {
+			if !knownExchange(string(ex)) {
+				WriteError(w, http.StatusBadRequest, "unknown exchange "+string(ex))
+				return
+			}
+			body.Filter.Exchanges[i] = connector.ExchangeID(strings.ToLower(string(ex)))
+		}
+
+		e, err := d.Register(r.Context(), body.URL, body.Secret, body.Filter, operator(r, body.By))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, e)
+	})
+
+	s.Handle("DELETE /admin/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		err := d.Delete(r.Context(), r.PathValue("id"))
+		if errors.Is(err, webhook.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{"deleted": r.PathValue("id")})
+	})
+}
@@ -0,0 +1,570 @@
+package apiversion
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Sources of a deprecation notice
+const (
+	SourceHeader   = "header"    // Deprecation, Sunset or Warning header on a REST response
+	SourceWSHeader = "ws_header" // Same headers on a WebSocket handshake
+	SourceBody     = "body"      // Deprecation or sunset field in a JSON response
+	SourceConfig   = "config"    // Announced sunset entered by an operator
+)
+
+// Unversioned is the version of requests whose path carries no vN segment
+const Unversioned = "unversioned"
+
+// hostSuffixes maps venue API domains to exchanges, spot and futures hosts alike
+var hostSuffixes = []struct {
+	suffix   string
+	exchange connector.ExchangeID
+}{
+	{"binance.com", connector.Binance},
+	{"bybit.com", connector.Bybit},
+	{"okx.com", connector.OKX},
+	{"kucoin.com", connector.KuCoin},
+	{"mexc.com", connector.MEXC},
+	{"bitget.com", connector.Bitget},
+	{"gateio.ws", connector.GateIO},
+	{"gate.io", connector.GateIO},
+	{"bingx.com", connector.BingX},
+	{"coinex.com", connector.CoinEx},
+	{"lbank.com", connector.LBank},
+	{"lbkex.com", connector.LBank},
+	{"lbkex.net", connector.LBank},
+	{"hbdm.com", connector.HTX},
+	{"hbdm.vn", connector.HTX},
+	{"huobi.pro", connector.HTX},
+	{"btcgateway.pro", connector.HTX},
+	{"whitebit.com", connector.WhiteBIT},
+	{"bitmart.com", connector.BitMart},
+	{"xt.com", connector.XT},
+	{"bitrue.com", connector.Bitrue},
+	{"deribit.com", connector.Deribit},
+}
+
+// ExchangeForHost returns the exchange serving an API host
+func ExchangeForHost(host string) (connector.ExchangeID, bool) {
+	host = strings.ToLower(host)
+	for _, h := range hostSuffixes {
+		if host == h.suffix || strings.HasSuffix(host, "."+h.suffix) {
+			return h.exchange, true
+		}
+	}
+	return "", false
+}
+
+// Version returns the API version of a request path: the path up to and
+// including its first vN segment, e.g. /v5, /api/v2 or /fapi/v1
+func Version(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if i >= 4 {
+			break
+		}
+		if len(s) >= 2 && (s[0] == 'v' || s[0] == 'V') {
+			if _, err := strconv.Atoi(s[1:]); err == nil {
+				return "/" + strings.ToLower(strings.Join(segments[:i+1], "/"))
+			}
+		}
+	}
+	return Unversioned
+}
+
+// Usage is an API version a connector has called
+type Usage struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Version   string               `json:"version"`
+	Requests  int64                `json:"requests"`
+	FirstSeen time.Time            `json:"first_seen"`
+	LastSeen  time.Time            `json:"last_seen"`
+}
+
+// Notice is a venue signalling that an API version is deprecated or going
+// away. Sunset is zero when no date was given.
+type Notice struct {
+	Exchange     connector.ExchangeID `json:"exchange"`
+	Version      string               `json:"version"`
+	DeprecatedAt time.Time            `json:"deprecated_at,omitempty"`
+	Sunset       time.Time            `json:"sunset,omitempty"`
+	Source       string               `json:"source"`
+	Detail       string               `json:"detail,omitempty"` // Header value, link or response excerpt
+	FirstSeen    time.Time            `json:"first_seen"`
+	LastSeen     time.Time            `json:"last_seen"`
+}
+
+// DaysLeft returns the days until the sunset, negative once past, or false
+// if the notice has no date
+func (n Notice) DaysLeft(now time.Time) (float64, bool) {
+	if n.Sunset.IsZero() {
+		return 0, false
+	}
+	return n.Sunset.Sub(now).Hours() / 24, true
+}
+
+type versionKey struct {
+	exchange connector.ExchangeID
+	version  string
+}
+
+// Config controls deprecation tracking
+type Config struct {
+	WarnBefore     time.Duration // Sunsets closer than this are warned about on every check
+	CheckInterval  time.Duration // How often days-to-sunset gauges are refreshed and warnings repeated
+	MaxBodyExcerpt int           // Bytes of a response kept after a deprecation field
+}
+
+// DefaultConfig warns 30 days ahead, re-checking hourly
+func DefaultConfig() Config {
+	return Config{
+		WarnBefore:     30 * 24 * time.Hour,
+		CheckInterval:  time.Hour,
+		MaxBodyExcerpt: 160,
+	}
+}
+
+// Tracker records the REST and WebSocket API versions each connector uses
+// and the deprecation signals venues attach to them, so a sunsetting
+// version shows up in metrics and logs before its feed breaks.
+type Tracker struct {
+	config Config
+
+	mu      sync.Mutex
+	usage   map[versionKey]*Usage
+	notices map[versionKey]*Notice
+	warned  map[versionKey]time.Time // Last warning logged
+	done    chan struct{}
+}
+
+// NewTracker creates a tracker
+func NewTracker(config Config) *Tracker {
+	return &Tracker{
+		config:  config,
+		usage:   make(map[versionKey]*Usage),
+		notices: make(map[versionKey]*Notice),
+		warned:  make(map[versionKey]time.Time),
+		done:    make(chan struct{}),
+	}
+}
+
+// Transport wraps base so every venue REST call is counted against its API
+// version and checked for deprecation headers and response fields. Install
+// it as http.DefaultTransport to cover the connectors' http.DefaultClient.
+func (t *Tracker) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{tracker: t, base: base}
+}
+
+type transport struct {
+	tracker *Tracker
+	base    http.RoundTripper
+}
+
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := tr.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	exchange, ok := ExchangeForHost(req.URL.Hostname())
+	if !ok {
+		return resp, nil
+	}
+	version := Version(req.URL.Path)
+	tr.tracker.observe(exchange, version, resp.Header, SourceHeader)
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		resp.Body = &scanBody{
+			ReadCloser: resp.Body,
+			limit:      tr.tracker.config.MaxBodyExcerpt,
+			onMatch: func(excerpt string) {
+				tr.tracker.Report(Notice{
+					Exchange: exchange,
+					Version:  version,
+					Sunset:   dateIn(excerpt),
+					Source:   SourceBody,
+					Detail:   excerpt,
+				})
+			},
+		}
+	}
+	return resp, nil
+}
+
+// ObserveHandshake records a WebSocket handshake response, for
+// connector.SetHandshakeObserver
+func (t *Tracker) ObserveHandshake(exchange connector.ExchangeID, u *url.URL, header http.Header) {
+	t.observe(exchange, Version(u.Path), header, SourceWSHeader)
+}
+
+// observe counts a call and reports the deprecation headers of its response:
+// Deprecation (RFC 9745, "@unix" or the legacy "true" or HTTP date), Sunset
+// (RFC 8594), a deprecation or sunset Link, a Warning mentioning
+// deprecation, or any other header naming deprecation
+func (t *Tracker) observe(exchange connector.ExchangeID, version string, header http.Header, source string) {
+	now := time.Now()
+	key := versionKey{exchange, version}
+
+	t.mu.Lock()
+	u := t.usage[key]
+	if u == nil {
+		u = &Usage{Exchange: exchange, Version: version, FirstSeen: now}
+		t.usage[key] = u
+	}
+	u.Requests++
+	u.LastSeen = now
+	t.mu.Unlock()
+	metrics.ExchangeAPIRequests.WithLabelValues(string(exchange), version).Inc()
+
+	var (
+		found   bool
+		notice  = Notice{Exchange: exchange, Version: version, Source: source}
+		details []string
+	)
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		value := strings.Join(values, ", ")
+		switch {
+		case lower == "sunset":
+			notice.Sunset, _ = http.ParseTime(value)
+		case lower == "deprecation":
+			notice.DeprecatedAt = parseDeprecation(value)
+		case lower == "link":
+			if !strings.Contains(value, `rel="deprecation"`) && !strings.Contains(value, `rel="sunset"`) {
+				continue
+			}
+		case lower == "warning":
+			if !strings.Contains(strings.ToLower(value), "deprecat") {
+				continue
+			}
+		case strings.Contains(lower, "deprecat") || strings.Contains(lower, "sunset"):
+			if notice.Sunset.IsZero() {
+				notice.Sunset = dateIn(value)
+			}
+		default:
+			continue
+		}
+		found = true
+		details = append(details, name+": "+value)
+	}
+	if !found {
+		return
+	}
+	sort.Strings(details)
+	notice.Detail = strings.Join(details, "; ")
+	t.Report(notice)
+}
+
+// Report records a deprecation notice, e.g. an announced sunset. Notices
+// for one version merge: the earliest sunset date wins.
+func (t *Tracker) Report(n Notice) {
+	now := time.Now()
+	key := versionKey{n.Exchange, n.Version}
+	metrics.ExchangeAPIDeprecationSignals.WithLabelValues(string(n.Exchange), n.Source).Inc()
+
+	t.mu.Lock()
+	existing := t.notices[key]
+	changed := existing == nil
+	if existing == nil {
+		n.FirstSeen = now
+		existing = &n
+		t.notices[key] = existing
+	} else {
+		if !n.Sunset.IsZero() && (existing.Sunset.IsZero() || n.Sunset.Before(existing.Sunset)) {
+			existing.Sunset = n.Sunset
+			changed = true
+		}
+		if existing.DeprecatedAt.IsZero() && !n.DeprecatedAt.IsZero() {
+			existing.DeprecatedAt = n.DeprecatedAt
+		}
+		if n.Detail != "" {
+			existing.Source, existing.Detail = n.Source, n.Detail
+		}
+	}
+	existing.LastSeen = now
+	notice := *existing
+	if changed {
+		t.warned[key] = now
+	}
+	t.mu.Unlock()
+
+	t.record(notice, now)
+	if changed {
+		warn(notice, now)
+	}
+}
+
+// record sets the gauges of a notice
+func (t *Tracker) record(n Notice, now time.Time) {
+	exchange := string(n.Exchange)
+	metrics.ExchangeAPIDeprecated.WithLabelValues(exchange, n.Version).Set(1)
+	if days, ok := n.DaysLeft(now); ok {
+		metrics.ExchangeAPISunsetTimestamp.WithLabelValues(exchange, n.Version).Set(float64(n.Sunset.Unix()))
+		metrics.ExchangeAPISunsetDays.WithLabelValues(exchange, n.Version).Set(days)
+	}
+}
+
+func warn(n Notice, now time.Time) {
+	event := log.Warn().
+		Str("exchange", string(n.Exchange)).
+		Str("version", n.Version).
+		Str("source", n.Source).
+		Str("detail", n.Detail)
+	if days, ok := n.DaysLeft(now); ok {
+		event.Time("sunset", n.Sunset).Float64("days_left", days).
+			Msg(fmt.Sprintf("%s API %s is sunsetting on %s", n.Exchange, n.Version, n.Sunset.Format("2006-01-02")))
+		return
+	}
+	event.Msg(fmt.Sprintf("%s API %s is deprecated", n.Exchange, n.Version))
+}
+
+// Start refreshes days-to-sunset gauges until ctx is done or Stop is called,
+// repeating the warning daily for sunsets within WarnBefore
+func (t *Tracker) Start(ctx context.Context) {
+	ticker := time.NewTicker(t.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.done:
+			return
+		case now := <-ticker.C:
+			t.check(now)
+		}
+	}
+}
+
+// Stop stops the periodic check
+func (t *Tracker) Stop() {
+	close(t.done)
+}
+
+func (t *Tracker) check(now time.Time) {
+	var due []Notice
+	t.mu.Lock()
+	for key, n := range t.notices {
+		t.record(*n, now)
+		days, ok := n.DaysLeft(now)
+		if !ok || days*24*float64(time.Hour) > float64(t.config.WarnBefore) {
+			continue
+		}
+		if now.Sub(t.warned[key]) < 24*time.Hour {
+			continue
+		}
+		t.warned[key] = now
+		due = append(due, *n)
+	}
+	t.mu.Unlock()
+
+	for _, n := range due {
+		warn(n, now)
+	}
+}
+
+// Usage returns the API versions called, by exchange then version
+func (t *Tracker) Usage() []Usage {
+	t.mu.Lock()
+	result := make([]Usage, 0, len(t.usage))
+	for _, u := range t.usage {
+		result = append(result, *u)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
+// Notices returns the deprecation notices, soonest sunset first and undated
+// ones last
+func (t *Tracker) Notices() []Notice {
+	t.mu.Lock()
+	result := make([]Notice, 0, len(t.notices))
+	for _, n := range t.notices {
+		result = append(result, *n)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Sunset.IsZero() != b.Sunset.IsZero() {
+			return b.Sunset.IsZero()
+		}
+		if !a.Sunset.Equal(b.Sunset) {
+			return a.Sunset.Before(b.Sunset)
+		}
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		return a.Version < b.Version
+	})
+	return result
+}
+
+// ParseSunsets parses announced sunsets "exchange:version=2006-01-02,...",
+// e.g. "kucoin:/api/v1=2026-12-01"
+func ParseSunsets(s string) ([]Notice, error) {
+	var notices []Notice
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target, date, ok := strings.Cut(part, "=")
+		exchange, version, ok2 := strings.Cut(target, ":")
+		if !ok || !ok2 || exchange == "" || version == "" {
+			return nil, fmt.Errorf("sunset %q must be exchange:version=YYYY-MM-DD", part)
+		}
+		sunset, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+		if err != nil {
+			return nil, fmt.Errorf("sunset %q: %w", part, err)
+		}
+		notices = append(notices, Notice{
+			Exchange: connector.ExchangeID(strings.ToLower(strings.TrimSpace(exchange))),
+			Version:  strings.ToLower(strings.TrimSpace(version)),
+			Sunset:   sunset,
+			Source:   SourceConfig,
+			Detail:   "announced",
+		})
+	}
+	return notices, nil
+}
+
+// parseDeprecation parses a Deprecation header: "@<unix seconds>" per RFC
+// 9745, or the draft's "true" or HTTP date. Returns zero when undated.
+func parseDeprecation(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "@") {
+		if sec, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at
+	}
+	return time.Time{}
+}
+
+var datePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// dateIn returns the first YYYY-MM-DD date in s, zero if none
+func dateIn(s string) time.Time {
+	if m := datePattern.FindString(s); m != "" {
+		if at, err := time.Parse("2006-01-02", m); err == nil {
+			return at
+		}
+	}
+	if at, err := http.ParseTime(strings.TrimSpace(s)); err == nil {
+		return at
+	}
+	return time.Time{}
+}
+
+// bodyMarkers are the field fragments venues use to announce deprecation
+// in responses, e.g. "deprecated", "deprecation_date", "sunsetTime"
+var bodyMarkers = [][]byte{
+	[]byte("deprecat"), []byte("Deprecat"), []byte("DEPRECAT"),
+	[]byte("sunset"), []byte("Sunset"),
+}
+
+// scanBody passes a response body through, reporting the first deprecation
+// marker it contains with the text that follows. Reads are scanned in place;
+// only the last few bytes of each are kept to catch a marker split between
+// reads.
+type scanBody struct {
+	io.ReadCloser
+	limit   int
+	onMatch func(excerpt string)
+	tail    []byte
+	matched bool
+}
+
+func (b *scanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.matched {
+		b.scan(p[:n])
+	}
+	return n, err
+}
+
+func (b *scanBody) scan(chunk []byte) {
+	const keep = 7 // len("deprecat") - 1
+	if len(b.tail) > 0 {
+		joined := append(b.tail, chunk[:min(len(chunk), keep)]...)
+		if excerpt, ok := findMarker(joined, b.limit); ok {
+			b.match(excerpt)
+			return
+		}
+	}
+	if excerpt, ok := findMarker(chunk, b.limit); ok {
+		b.match(excerpt)
+		return
+	}
+	b.tail = append(b.tail[:0], chunk[max(0, len(chunk)-keep):]...)
+}
+
+func (b *scanBody) match(excerpt string) {
+	b.matched = true
+	b.tail = nil
+	b.onMatch(excerpt)
+}
+
+// findMarker returns up to limit bytes starting at the first marker in data
+// whose field is set, skipping e.g. "isDeprecated":false
+func findMarker(data []byte, limit int) (string, bool) {
+	for offset := 0; offset < len(data); {
+		first := -1
+		for _, m := range bodyMarkers {
+			if i := bytes.Index(data[offset:], m); i >= 0 && (first < 0 || i < first) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return "", false
+		}
+		start := offset + first
+		excerpt := data[start:min(len(data), start+limit)]
+		if !unsetField(excerpt) {
+			return string(excerpt), true
+		}
+		offset = start + 1
+	}
+	return "", false
+}
+
+// unsetField returns true if the JSON field an excerpt starts in holds
+// false, null, zero or an empty string or collection
+func unsetField(excerpt []byte) bool {
+	key, value, ok := bytes.Cut(excerpt, []byte(":"))
+	if !ok || bytes.ContainsAny(key, ",{}[]") {
+		return false // Marker is in a value, not a key
+	}
+	value = bytes.TrimLeft(value, " \t\r\n")
+	for _, empty := range []string{"false", "null", "0,", "0}", `""`, "[]", "{}"} {
+		if bytes.HasPrefix(value, []byte(empty)) {
+			return true
+		}
+	}
+	return false
+}
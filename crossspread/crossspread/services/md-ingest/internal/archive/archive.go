@@ -0,0 +1,407 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ArchivedDir is the subdirectory of Config.Dir uploaded files are moved to
+// while they are kept locally
+const ArchivedDir = "archived"
+
+// Config controls what is archived and for how long it is kept
+type Config struct {
+	Dir        string   // Directory rotated files are picked up from
+	Prefix     string   // Key prefix in the bucket, e.g. md-ingest/prod-a/
+	Extensions []string // Files archived; others in Dir are left alone
+
+	Interval time.Duration // How often Dir is scanned
+	// Settle leaves files modified more recently alone, in case a writer
+	// other than the rotating recorder is still appending to them
+	Settle time.Duration
+	// KeepLocal keeps uploaded files in Dir/archived this long; zero
+	// deletes them once uploaded
+	KeepLocal time.Duration
+}
+
+// DefaultConfig archives JSONL and Parquet files every minute and deletes
+// them locally once uploaded
+func DefaultConfig() Config {
+	return Config{
+		Prefix:     "md-ingest/",
+		Extensions: []string{".jsonl", ".parquet"},
+		Interval:   time.Minute,
+		Settle:     time.Minute,
+	}
+}
+
+// Entry is an archived file in a manifest
+type Entry struct {
+	Name       string    `json:"name"`
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	From       time.Time `json:"from"` // Start of the data, from the name when it carries one
+	To         time.Time `json:"to"`   // End of the data, from the name or the file's modification time
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// Manifest indexes the files archived on one UTC day, by the end of their
+// data. Readers find files by time range without listing the bucket.
+type Manifest struct {
+	Date  string  `json:"date"` // YYYY-MM-DD
+	Files []Entry `json:"files"`
+}
+
+// Archiver moves completed recordings and exports from the ingest box to
+// object storage, so long-horizon history doesn't live on local disk.
+// Files land under {prefix}files/YYYY/MM/DD/ with a manifest per day under
+// {prefix}manifests/. Retention in the bucket is a lifecycle rule on the
+// prefix (see S3.SetLifecycle). One archiver writes each prefix.
+type Archiver struct {
+	config    Config
+	store     Store
+	now       func() time.Time
+	manifests map[string]*Manifest // By date, as last written
+}
+
+// New creates an archiver uploading from config.Dir to store
+func New(store Store, config Config) *Archiver {
+	return &Archiver{
+		config:    config,
+		store:     store,
+		now:       time.Now,
+		manifests: make(map[string]*Manifest),
+	}
+}
+
+// Start archives every Interval until ctx is done
+func (a *Archiver) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.Scan(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Str("dir", a.config.Dir).Msg("Archive scan failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan uploads every completed file in Dir and prunes local copies past
+// KeepLocal. Files that fail are retried on the next scan.
+func (a *Archiver) Scan(ctx context.Context) error {
+	entries, err := os.ReadDir(a.config.Dir)
+	if err != nil {
+		return err
+	}
+	now := a.now()
+	var pending []os.FileInfo
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !a.archivable(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < a.config.Settle {
+			continue
+		}
+		pending = append(pending, info)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ModTime().Before(pending[j].ModTime()) })
+	metrics.ArchivePending.Set(float64(len(pending)))
+
+	var firstErr error
+	for i, info := range pending {
+		if err := a.upload(ctx, info); err != nil {
+			metrics.ArchiveUploads.WithLabelValues("failed").Inc()
+			log.Warn().Err(err).Str("file", info.Name()).Msg("Failed to archive file")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		metrics.ArchiveUploads.WithLabelValues("uploaded").Inc()
+		metrics.ArchiveUploadedBytes.Add(float64(info.Size()))
+		metrics.ArchivePending.Set(float64(len(pending) - i - 1))
+	}
+	a.prune(now)
+	return firstErr
+}
+
+func (a *Archiver) archivable(name string) bool {
+	for _, ext := range a.config.Extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// upload stores one file, records it in its day's manifest and moves it
+// out of Dir
+func (a *Archiver) upload(ctx context.Context, info os.FileInfo) error {
+	path := filepath.Join(a.config.Dir, info.Name())
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	entry := Entry{
+		Name:   info.Name(),
+		Size:   info.Size(),
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}
+	entry.From, entry.To = span(info.Name(), info.ModTime())
+	date := entry.To.UTC().Format(time.DateOnly)
+	entry.Key = a.config.Prefix + "files/" + strings.ReplaceAll(date, "-", "/") + "/" + entry.Name
+
+	if err := a.store.Put(ctx, entry.Key, f, entry.Size, entry.SHA256, contentType(entry.Name)); err != nil {
+		return err
+	}
+	entry.UploadedAt = a.now().UTC()
+	if err := a.record(ctx, date, entry); err != nil {
+		return fmt.Errorf("manifest %s: %w", date, err)
+	}
+	f.Close()
+
+	if a.config.KeepLocal > 0 {
+		dir := filepath.Join(a.config.Dir, ArchivedDir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		err = os.Rename(path, filepath.Join(dir, entry.Name))
+	} else {
+		err = os.Remove(path)
+	}
+	log.Info().Str("file", entry.Name).Str("key", entry.Key).Int64("bytes", entry.Size).Msg("File archived")
+	return err
+}
+
+// record adds an entry to a day's manifest, replacing one of the same name
+// from an earlier attempt
+func (a *Archiver) record(ctx context.Context, date string, entry Entry) error {
+	m := a.manifests[date]
+	if m == nil {
+		var err error
+		if m, err = ReadManifest(ctx, a.store, a.config.Prefix, date); err != nil {
+			return err
+		}
+	}
+	files := make([]Entry, 0, len(m.Files)+1)
+	for _, e := range m.Files {
+		if e.Name != entry.Name {
+			files = append(files, e)
+		}
+	}
+	next := &Manifest{Date: date, Files: append(files, entry)}
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if err := a.store.Put(ctx, manifestKey(a.config.Prefix, date), bytes.NewReader(data), int64(len(data)),
+		hex.EncodeToString(sum[:]), "application/json"); err != nil {
+		return err
+	}
+	a.manifests[date] = next
+	// Only today's and yesterday's manifests still change
+	cutoff := a.now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	for d := range a.manifests {
+		if d < cutoff {
+			delete(a.manifests, d)
+		}
+	}
+	return nil
+}
+
+// prune deletes local copies of uploaded files past KeepLocal
+func (a *Archiver) prune(now time.Time) {
+	if a.config.KeepLocal <= 0 {
+		return
+	}
+	dir := filepath.Join(a.config.Dir, ArchivedDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < a.config.KeepLocal {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			log.Warn().Err(err).Str("file", e.Name()).Msg("Failed to prune archived file")
+		}
+	}
+}
+
+// span reads the data's time range from a file name carrying timestamps,
+// such as frames-20260101T150000Z.jsonl from a rotating recorder or
+// trades_binance_BTCUSDT_20260101T1500_20260101T1600.parquet from mdexport.
+// Without two, the range ends at the file's modification time.
+func span(name string, modified time.Time) (time.Time, time.Time) {
+	var times []time.Time
+	for _, token := range strings.FieldsFunc(strings.TrimSuffix(name, filepath.Ext(name)), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	}) {
+		for _, layout := range []string{"20060102T150405Z", "20060102T1504"} {
+			if t, err := time.Parse(layout, token); err == nil {
+				times = append(times, t)
+				break
+			}
+		}
+	}
+	to := modified.UTC()
+	switch {
+	case len(times) >= 2:
+		return times[0], times[len(times)-1]
+	case len(times) == 1 && times[0].Before(to):
+		return times[0], to
+	default:
+		return to, to
+	}
+}
+
+func contentType(name string) string {
+	switch filepath.Ext(name) {
+	case ".jsonl":
+		return "application/x-ndjson"
+	case ".parquet":
+		return "application/vnd.apache.parquet"
+	case ".csv":
+		return "text/csv"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func manifestKey(prefix, date string) string {
+	return prefix + "manifests/" + date + ".json"
+}
+
+// ReadManifest reads a day's manifest; days without one have no files
+func ReadManifest(ctx context.Context, store Store, prefix, date string) (*Manifest, error) {
+	body, err := store.Get(ctx, manifestKey(prefix, date))
+	if errors.Is(err, ErrNotFound) {
+		return &Manifest{Date: date}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var m Manifest
+	if err := json.NewDecoder(body).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Find returns the archived files whose data overlaps [from, to] and whose
+// names end in ext (any when empty), oldest first. A file is indexed under
+// the day its data ends, so the day after to is read as well.
+func Find(ctx context.Context, store Store, prefix string, from, to time.Time, ext string) ([]Entry, error) {
+	var found []Entry
+	last := to.UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	for day := from.UTC(); day.Format(time.DateOnly) <= last; day = day.AddDate(0, 0, 1) {
+		m, err := ReadManifest(ctx, store, prefix, day.Format(time.DateOnly))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range m.Files {
+			if e.From.After(to) || e.To.Before(from) || !strings.HasSuffix(e.Name, ext) {
+				continue
+			}
+			found = append(found, e)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].From.Before(found[j].From) })
+	return found, nil
+}
+
+// Download fetches archived files into dir, skipping ones already there
+// with the right checksum, and returns their local paths
+func Download(ctx context.Context, store Store, entries []Entry, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name)
+		if sum, err := fileSHA256(path); err == nil && sum == e.SHA256 {
+			paths = append(paths, path)
+			continue
+		}
+		if err := fetch(ctx, store, e, path); err != nil {
+			return paths, fmt.Errorf("%s: %w", e.Key, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// fetch downloads one file through a temporary name, checking its checksum
+func fetch(ctx context.Context, store Store, e Entry, path string) error {
+	body, err := store.Get(ctx, e.Key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp := path + ".download"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && hex.EncodeToString(h.Sum(nil)) != e.SHA256 {
+		err = errors.New("checksum mismatch")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
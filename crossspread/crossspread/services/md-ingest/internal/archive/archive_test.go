@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type memStore map[string][]byte
+
+func (m memStore) Put(ctx context.Context, key string, body io.Reader, size int64, sum, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m[key] = data
+	return nil
+}
+
+func (m memStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"frames-20260101T230000Z.jsonl":                              `{"at":"2026-01-01T23:00:00Z"}`,
+		"frames-20260102T000000Z.jsonl":                              `{"at":"2026-01-02T00:00:00Z"}`,
+		"frames-20260102T010000Z.jsonl.partial":                      `{}`,
+		"trades_binance_BTCUSDT_20260101T2300_20260102T0000.parquet": "PAR1",
+		"notes.txt": "left alone",
+	}
+	modified := time.Date(2026, 1, 2, 0, 30, 0, 0, time.UTC)
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modified, modified)
+	}
+
+	store := memStore{}
+	config := DefaultConfig()
+	config.Dir = dir
+	a := New(store, config)
+	a.now = func() time.Time { return modified.Add(time.Hour) }
+	if err := a.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	left, _ := os.ReadDir(dir)
+	if len(left) != 2 {
+		t.Errorf("%d files left in %s, want the partial recording and notes", len(left), dir)
+	}
+	if _, ok := store["md-ingest/files/2026/01/02/trades_binance_BTCUSDT_20260101T2300_20260102T0000.parquet"]; !ok {
+		t.Errorf("export not stored under the day its data ends: %v", store)
+	}
+
+	from := time.Date(2026, 1, 1, 22, 30, 0, 0, time.UTC)
+	entries, err := Find(context.Background(), store, config.Prefix, from, from.Add(time.Hour), ".jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "frames-20260101T230000Z.jsonl" {
+		t.Fatalf("found %+v, want the 23:00 recording", entries)
+	}
+
+	out := t.TempDir()
+	paths, err := Download(context.Background(), store, entries, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(paths[0])
+	if string(data) != files[entries[0].Name] {
+		t.Errorf("downloaded %q", data)
+	}
+
+	store[entries[0].Key] = []byte("corrupt")
+	os.Remove(paths[0])
+	if _, err := Download(context.Background(), store, entries, out); err == nil {
+		t.Error("downloaded a file failing its checksum")
+	}
+}
@@ -0,0 +1,281 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get for keys the bucket doesn't hold
+var ErrNotFound = errors.New("archive: object not found")
+
+// Store is the object storage the archiver writes to
+type Store interface {
+	// Put uploads size bytes of body under key. sum is the hex SHA-256 of
+	// the body.
+	Put(ctx context.Context, key string, body io.Reader, size int64, sum, contentType string) error
+	// Get downloads an object; the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// S3Config locates a bucket. Any S3-compatible endpoint works, including
+// GCS through its XML API with HMAC keys (endpoint
+// https://storage.googleapis.com, region auto).
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.eu-west-1.amazonaws.com; derived from Region when empty
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Timeout   time.Duration // Per request; uploads of large files need minutes
+}
+
+// S3 is a minimal S3 client signing requests with AWS Signature Version 4.
+// Objects are addressed path-style, endpoint/bucket/key.
+type S3 struct {
+	config S3Config
+	http   *http.Client
+	now    func() time.Time
+}
+
+// NewS3 creates a client for one bucket
+func NewS3(config S3Config) (*S3, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("archive: bucket is required")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("archive: access key and secret key are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Minute
+	}
+	return &S3{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+		now:    time.Now,
+	}, nil
+}
+
+// Put uploads an object in a single request, which S3 caps at 5 GB
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, sum, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, "", body, sum)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req)
+}
+
+// Get downloads an object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, key, "", nil, emptySHA256)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := status(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Lifecycle is a bucket lifecycle rule for the archive prefix
+type Lifecycle struct {
+	Prefix         string
+	ExpireDays     int    // Objects are deleted this many days after upload; 0 keeps them
+	TransitionDays int    // Objects move to StorageClass this many days after upload; 0 leaves them
+	StorageClass   string // e.g. GLACIER_IR or DEEP_ARCHIVE
+}
+
+type lifecycleXML struct {
+	XMLName xml.Name `xml:"LifecycleConfiguration"`
+	Rule    struct {
+		ID     string `xml:"ID"`
+		Filter struct {
+			Prefix string `xml:"Prefix"`
+		} `xml:"Filter"`
+		Status     string `xml:"Status"`
+		Transition *struct {
+			Days         int    `xml:"Days"`
+			StorageClass string `xml:"StorageClass"`
+		} `xml:"Transition,omitempty"`
+		Expiration *struct {
+			Days int `xml:"Days"`
+		} `xml:"Expiration,omitempty"`
+	} `xml:"Rule"`
+}
+
+// SetLifecycle replaces the bucket's lifecycle configuration with a single
+// rule for the archive prefix, so only use it on a bucket the archive has
+// to itself. GCS takes lifecycle rules in a different format; set them
+// there with gcloud instead.
+func (s *S3) SetLifecycle(ctx context.Context, rule Lifecycle) error {
+	var doc lifecycleXML
+	doc.Rule.ID = "md-ingest-archive"
+	doc.Rule.Filter.Prefix = rule.Prefix
+	doc.Rule.Status = "Enabled"
+	if rule.TransitionDays > 0 && rule.StorageClass != "" {
+		doc.Rule.Transition = &struct {
+			Days         int    `xml:"Days"`
+			StorageClass string `xml:"StorageClass"`
+		}{rule.TransitionDays, rule.StorageClass}
+	}
+	if rule.ExpireDays > 0 {
+		doc.Rule.Expiration = &struct {
+			Days int `xml:"Days"`
+		}{rule.ExpireDays}
+	}
+	if doc.Rule.Transition == nil && doc.Rule.Expiration == nil {
+		return errors.New("archive: lifecycle rule needs expiration or transition days")
+	}
+	body, err := xml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	req, err := s.request(ctx, http.MethodPut, "", "lifecycle=", bytes.NewReader(body), hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
+	digest := md5.Sum(body)
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(digest[:]))
+	return s.do(req)
+}
+
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// request builds a signed request for an object, or for the bucket itself
+// when key is empty. query is already in canonical form.
+func (s *S3) request(ctx context.Context, method, key, query string, body io.Reader, sum string) (*http.Request, error) {
+	path := "/" + s.config.Bucket
+	if key != "" {
+		path += "/" + escapePath(key)
+	}
+	target := s.config.Endpoint + path
+	if query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, path, query, sum)
+	return req, nil
+}
+
+// sign adds Signature Version 4 headers covering host, payload hash and date
+func (s *S3) sign(req *http.Request, path, query, sum string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", sum)
+
+	const signed = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + sum,
+		"x-amz-date:" + amzDate,
+		"",
+		signed,
+		sum,
+	}, "\n")
+	scope := day + "/" + s.config.Region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), day)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signed, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath percent-encodes a key the way SigV4 expects: every byte but
+// unreserved characters and the slashes between segments
+func escapePath(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+// do sends a request and drains the response
+func (s *S3) do(req *http.Request) error {
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := status(resp); err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return nil
+}
+
+// status turns an error response into an error carrying S3's message
+func status(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	xml.Unmarshal(body, &e)
+	if resp.StatusCode == http.StatusNotFound && e.Code != "NoSuchBucket" {
+		return ErrNotFound
+	}
+	if e.Code != "" {
+		return fmt.Errorf("archive: HTTP %d %s: %s", resp.StatusCode, e.Code, e.Message)
+	}
+	return errors.New("archive: HTTP " + strconv.Itoa(resp.StatusCode))
+}
@@ -0,0 +1,404 @@
+package bars
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Bar is an OHLCV bar built from the trade stream
+type Bar = mdtypes.Bar
+
+// Config controls bar building
+type Config struct {
+	Intervals []time.Duration
+	// Grace is how far behind the newest trade a venue's trades may be
+	// stamped and still land in their bar; venue timestamps arrive out of
+	// order across connections. Later trades are counted late and dropped.
+	Grace time.Duration
+	// IdleTimeout lets a venue's event time advance with the wall clock once
+	// it has sent no trades for this long, so bars of quiet venues close
+	IdleTimeout   time.Duration
+	FlushInterval time.Duration // How often closed bars are published and idle venues advanced
+	Consolidated  bool          // Also build per-canonical bars across venues
+}
+
+// DefaultConfig returns 1s and 1m bars, per venue and consolidated
+func DefaultConfig() Config {
+	return Config{
+		Intervals:     []time.Duration{time.Second, time.Minute},
+		Grace:         500 * time.Millisecond,
+		IdleTimeout:   2 * time.Second,
+		FlushInterval: 100 * time.Millisecond,
+		Consolidated:  true,
+	}
+}
+
+type barKey struct {
+	exchange  connector.ExchangeID // Empty for consolidated bars
+	symbol    string
+	canonical string
+	interval  time.Duration
+}
+
+// windowKey is one bar of a series; several may be open while late trades
+// are still accepted
+type windowKey struct {
+	barKey
+	start int64 // Unix nanoseconds
+}
+
+// openBar is a bar still taking trades
+type openBar struct {
+	Bar
+	openAt, closeAt time.Time // Event times of the open and close trades
+}
+
+// watermark tracks how far a venue's event time has progressed
+type watermark struct {
+	eventTime time.Time // Newest trade timestamp
+	seenAt    time.Time // Wall time eventTime last advanced
+	mark      time.Time // eventTime, advanced further while the venue is idle; never moves back
+	closed    time.Time // Bars ending at or before this are closed
+}
+
+type sizeKey struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+// Builder aggregates trades into bars by exchange event time and publishes
+// each bar once it closes. Bars close on the venue's watermark, its newest
+// trade timestamp less Grace, never on arrival time, so a replay of the same
+// trades produces the same bars as the live run. Consolidated bars close on
+// the slowest venue's watermark. Intervals without trades produce no bar.
+type Builder struct {
+	mu sync.Mutex
+
+	config    Config
+	publisher *publisher.RedisPublisher
+
+	// Contract size per venue symbol; trade quantities are multiplied by it
+	sizes map[sizeKey]float64
+
+	open       map[windowKey]*openBar
+	published  map[barKey]time.Time // Start of the last bar handed to publishing
+	pending    []*Bar               // Closed bars waiting for the next flush
+	watermarks map[connector.ExchangeID]*watermark
+	closedAll  time.Time // Consolidated bars ending at or before this are closed
+
+	// Called with the bars closed in each flush (e.g. history recording)
+	handler func([]*Bar)
+
+	done chan struct{}
+}
+
+// NewBuilder creates a new bar builder
+func NewBuilder(config Config, pub *publisher.RedisPublisher) *Builder {
+	return &Builder{
+		config:     config,
+		publisher:  pub,
+		sizes:      make(map[sizeKey]float64),
+		open:       make(map[windowKey]*openBar),
+		published:  make(map[barKey]time.Time),
+		watermarks: make(map[connector.ExchangeID]*watermark),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetHandler sets a callback receiving the bars closed in each flush
+func (b *Builder) SetHandler(handler func([]*Bar)) {
+	b.handler = handler
+}
+
+// SetInstruments records contract sizes so bar volume is in base units on
+// venues quoting trades in contracts
+func (b *Builder) SetInstruments(instruments []connector.Instrument) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, inst := range instruments {
+		if inst.ContractSize > 0 {
+			b.sizes[sizeKey{inst.ExchangeID, inst.Symbol}] = inst.ContractSize
+		}
+	}
+}
+
+// Start closes and publishes bars until the context is cancelled or Stop is called
+func (b *Builder) Start(ctx context.Context) {
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.flush(time.Now())
+		}
+	}
+}
+
+// Stop stops the builder
+func (b *Builder) Stop() {
+	close(b.done)
+}
+
+// HandleTrade adds a trade to its venue's and its canonical's bars
+func (b *Builder) HandleTrade(trade *connector.Trade) {
+	if trade.Price <= 0 || trade.Quantity <= 0 {
+		return
+	}
+
+	at := trade.Timestamp
+	if at.IsZero() || at.Unix() <= 0 {
+		at = trade.ReceivedAt
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	canonical := trade.Canonical
+	if canonical == "" {
+		canonical = connector.ParsePair(trade.Symbol).Canonical()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(trade.ExchangeID, at, time.Now())
+
+	qty := trade.Quantity
+	if size, ok := b.sizes[sizeKey{trade.ExchangeID, trade.Symbol}]; ok {
+		qty *= size
+	}
+	buy := trade.Side == connector.SideBuy
+
+	for _, interval := range b.config.Intervals {
+		b.add(barKey{trade.ExchangeID, trade.Symbol, canonical, interval}, at, trade.Price, qty, buy)
+		if b.config.Consolidated {
+			b.add(barKey{canonical: canonical, interval: interval}, at, trade.Price, qty, buy)
+		}
+	}
+}
+
+// advance moves a venue's watermark to a trade's event time. Caller holds b.mu.
+func (b *Builder) advance(exchange connector.ExchangeID, at, now time.Time) {
+	w := b.watermarks[exchange]
+	if w == nil {
+		w = &watermark{}
+		b.watermarks[exchange] = w
+	}
+	if at.After(w.eventTime) {
+		w.eventTime = at
+		w.seenAt = now
+	}
+	if at.After(w.mark) {
+		w.mark = at
+		b.closeUpTo(exchange, w)
+	}
+}
+
+// idle advances the watermark of venues that have sent no trades for
+// IdleTimeout by the wall time since. Caller holds b.mu.
+func (b *Builder) idle(now time.Time) {
+	for exchange, w := range b.watermarks {
+		if idle := now.Sub(w.seenAt) - b.config.IdleTimeout; idle > 0 {
+			if mark := w.eventTime.Add(idle); mark.After(w.mark) {
+				w.mark = mark
+				b.closeUpTo(exchange, w)
+			}
+		}
+		metrics.BarWatermarkLag.WithLabelValues(string(exchange)).Set(now.Sub(w.mark).Seconds())
+	}
+}
+
+// closeUpTo closes a venue's bars the watermark has passed by Grace, then
+// consolidated bars the slowest venue has passed. Bars end on multiples of
+// the smallest interval, so open bars are only scanned when the watermark
+// crosses one. Caller holds b.mu.
+func (b *Builder) closeUpTo(exchange connector.ExchangeID, w *watermark) {
+	step := b.minInterval()
+	boundary := w.mark.Add(-b.config.Grace).Truncate(step)
+	if !boundary.After(w.closed) {
+		return
+	}
+	w.closed = boundary
+	b.closeBars(func(k barKey) bool { return k.exchange == exchange }, boundary)
+
+	if !b.config.Consolidated {
+		return
+	}
+	slowest := boundary
+	for _, other := range b.watermarks {
+		if other.closed.Before(slowest) {
+			slowest = other.closed
+		}
+	}
+	if slowest.After(b.closedAll) {
+		b.closedAll = slowest
+		b.closeBars(func(k barKey) bool { return k.exchange == "" }, slowest)
+	}
+}
+
+// closeBars closes the open bars matching scope that end at or before
+// boundary. Caller holds b.mu.
+func (b *Builder) closeBars(scope func(barKey) bool, boundary time.Time) {
+	for k, bar := range b.open {
+		if scope(k.barKey) && !bar.End.After(boundary) {
+			b.close(k, bar)
+		}
+	}
+}
+
+// minInterval returns the smallest configured interval
+func (b *Builder) minInterval() time.Duration {
+	if len(b.config.Intervals) == 0 {
+		return time.Second
+	}
+	step := b.config.Intervals[0]
+	for _, d := range b.config.Intervals[1:] {
+		step = min(step, d)
+	}
+	return step
+}
+
+// add applies a trade to its bar. Caller holds b.mu.
+func (b *Builder) add(k barKey, at time.Time, price, qty float64, buy bool) {
+	start := at.Truncate(k.interval)
+	if last, ok := b.published[k]; ok && !start.After(last) {
+		b.late(k)
+		return
+	}
+
+	wk := windowKey{k, start.UnixNano()}
+	bar := b.open[wk]
+	if bar == nil {
+		bar = &openBar{
+			Bar: Bar{
+				Exchange:  k.exchange,
+				Symbol:    k.symbol,
+				Canonical: k.canonical,
+				Interval:  IntervalName(k.interval),
+				Open:      price,
+				High:      price,
+				Low:       price,
+				Start:     start,
+				End:       start.Add(k.interval),
+			},
+			openAt:  at,
+			closeAt: at,
+		}
+		b.open[wk] = bar
+	}
+
+	// Open and close follow event time, so a trade arriving out of order
+	// within the grace lands where it happened
+	if at.Before(bar.openAt) {
+		bar.Open = price
+		bar.openAt = at
+	}
+	if !at.Before(bar.closeAt) {
+		bar.Close = price
+		bar.closeAt = at
+	}
+
+	if price > bar.High {
+		bar.High = price
+	}
+	if price < bar.Low {
+		bar.Low = price
+	}
+	bar.Volume += qty
+	bar.QuoteVolume += price * qty
+	if buy {
+		bar.BuyVolume += qty
+	}
+	bar.Trades++
+}
+
+// close moves a bar to the pending list. Caller holds b.mu.
+func (b *Builder) close(k windowKey, bar *openBar) {
+	if bar.Volume > 0 {
+		bar.VWAP = bar.QuoteVolume / bar.Volume
+	}
+	delete(b.open, k)
+	if last, ok := b.published[k.barKey]; !ok || bar.Start.After(last) {
+		b.published[k.barKey] = bar.Start
+	}
+	b.pending = append(b.pending, &bar.Bar)
+}
+
+// late counts a trade for a bar that was already closed. Caller holds b.mu.
+func (b *Builder) late(k barKey) {
+	scope := string(k.exchange)
+	if scope == "" {
+		scope = "consolidated"
+	}
+	metrics.BarLateTrades.WithLabelValues(scope, IntervalName(k.interval)).Inc()
+}
+
+// flush advances idle venues and publishes every closed bar
+func (b *Builder) flush(now time.Time) {
+	b.mu.Lock()
+	b.idle(now)
+	closed := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	sort.Slice(closed, func(i, j int) bool { return closed[i].Start.Before(closed[j].Start) })
+	for _, bar := range closed {
+		b.publish(bar)
+	}
+	if b.handler != nil && len(closed) > 0 {
+		b.handler(closed)
+	}
+}
+
+func (b *Builder) publish(bar *Bar) {
+	scope := "venue"
+	key := keyspace.BarsKey(string(bar.Exchange), bar.Symbol, bar.Interval)
+	if bar.Exchange == "" {
+		scope = "consolidated"
+		key = keyspace.ConsolidatedBarsKey(bar.Canonical, bar.Interval)
+	}
+	metrics.BarsPublished.WithLabelValues(scope, bar.Interval).Inc()
+
+	if b.publisher == nil {
+		return
+	}
+	data, err := json.Marshal(bar)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to marshal bar")
+		return
+	}
+	if err := b.publisher.PublishBar(key, data); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to publish bar")
+		metrics.RedisPublishErrors.WithLabelValues("bar").Inc()
+	}
+}
+
+// IntervalName returns the key suffix of an interval, e.g. 1s, 1m, 1h
+func IntervalName(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d >= time.Minute && d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d >= time.Second && d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	default:
+		return d.String()
+	}
+}
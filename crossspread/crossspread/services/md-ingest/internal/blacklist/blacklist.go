@@ -0,0 +1,176 @@
+package blacklist
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Config controls when a symbol is blacklisted and for how long
+type Config struct {
+	MaxErrors int           // Errors within Window that trigger a blacklist
+	Window    time.Duration // Sliding window for counting errors
+	Cooldown  time.Duration // How long a symbol stays blacklisted
+}
+
+// DefaultConfig returns the default blacklist configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxErrors: 5,
+		Window:    5 * time.Minute,
+		Cooldown:  30 * time.Minute,
+	}
+}
+
+// Entry describes a blacklisted symbol
+type Entry struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Symbol    string               `json:"symbol"`
+	Errors    int                  `json:"errors"`     // Errors that triggered the blacklist
+	LastError string               `json:"last_error"` // Most recent error message
+	Since     time.Time            `json:"since"`
+	Until     time.Time            `json:"until"`
+}
+
+type key struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+type symbolState struct {
+	errors    []time.Time // Error times within the window
+	lastError string
+	until     time.Time // Zero unless blacklisted
+	since     time.Time
+	count     int // Errors that triggered the current blacklist
+}
+
+// Blacklist tracks per-symbol errors and blacklists symbols that keep failing
+type Blacklist struct {
+	mu      sync.Mutex
+	config  Config
+	symbols map[key]*symbolState
+}
+
+// New creates a new symbol blacklist
+func New(config Config) *Blacklist {
+	return &Blacklist{
+		config:  config,
+		symbols: make(map[key]*symbolState),
+	}
+}
+
+// RecordError counts an error for a symbol. Returns true if this error
+// caused the symbol to be blacklisted.
+func (b *Blacklist) RecordError(exchange connector.ExchangeID, symbol string, err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	k := key{exchange, symbol}
+	st, ok := b.symbols[k]
+	if !ok {
+		st = &symbolState{}
+		b.symbols[k] = st
+	}
+	st.lastError = err.Error()
+
+	if now.Before(st.until) {
+		return false // Already blacklisted
+	}
+
+	// Drop errors outside the window
+	cutoff := now.Add(-b.config.Window)
+	kept := st.errors[:0]
+	for _, t := range st.errors {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	st.errors = append(kept, now)
+
+	if len(st.errors) < b.config.MaxErrors {
+		return false
+	}
+
+	st.count = len(st.errors)
+	st.errors = nil
+	st.since = now
+	st.until = now.Add(b.config.Cooldown)
+	metrics.SymbolsBlacklisted.WithLabelValues(string(exchange)).Inc()
+
+	log.Warn().
+		Str("exchange", string(exchange)).
+		Str("symbol", symbol).
+		Int("errors", st.count).
+		Time("until", st.until).
+		Str("last_error", st.lastError).
+		Msg("Symbol blacklisted after repeated errors")
+
+	return true
+}
+
+// IsBlacklisted returns true if the symbol is currently blacklisted
+func (b *Blacklist) IsBlacklisted(exchange connector.ExchangeID, symbol string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, ok := b.symbols[key{exchange, symbol}]
+	return ok && time.Now().Before(st.until)
+}
+
+// Filter returns symbols that are not currently blacklisted
+func (b *Blacklist) Filter(exchange connector.ExchangeID, symbols []string) []string {
+	result := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if !b.IsBlacklisted(exchange, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Clear removes a symbol from the blacklist and resets its error count
+func (b *Blacklist) Clear(exchange connector.ExchangeID, symbol string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	k := key{exchange, symbol}
+	if _, ok := b.symbols[k]; !ok {
+		return false
+	}
+	delete(b.symbols, k)
+	return true
+}
+
+// List returns all currently blacklisted symbols, soonest to expire first
+func (b *Blacklist) List() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]Entry, 0)
+	for k, st := range b.symbols {
+		if !now.Before(st.until) {
+			continue
+		}
+		entries = append(entries, Entry{
+			Exchange:  k.exchange,
+			Symbol:    k.symbol,
+			Errors:    st.count,
+			LastError: st.lastError,
+			Since:     st.since,
+			Until:     st.until,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Until.Before(entries[j].Until)
+	})
+	return entries
+}
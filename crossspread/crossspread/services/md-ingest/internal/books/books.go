@@ -0,0 +1,247 @@
+package books
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Book is the full in-memory L2 book of one venue symbol, as maintained
+// from the connector stream before any publication trimming
+type Book struct {
+	ExchangeID   connector.ExchangeID   `json:"exchange_id"`
+	Symbol       string                 `json:"symbol"`
+	Canonical    string                 `json:"canonical"`
+	Bids         []connector.PriceLevel `json:"bids"` // Sorted desc by price
+	Asks         []connector.PriceLevel `json:"asks"` // Sorted asc by price
+	BestBid      float64                `json:"best_bid"`
+	BestAsk      float64                `json:"best_ask"`
+	SequenceID   int64                  `json:"sequence_id,omitempty"`
+	Timestamp    time.Time              `json:"timestamp"`     // Exchange time of the last update
+	ReceivedAt   time.Time              `json:"received_at"`   // Last update read from the socket
+	NormalizedAt time.Time              `json:"normalized_at"` // Last update emitted by the connector
+	SnapshotAt   time.Time              `json:"snapshot_at"`   // Last full snapshot applied
+	UpdatedAt    time.Time              `json:"updated_at"`    // Last update applied to the store
+	Updates      int64                  `json:"updates"`       // Diffs applied since the snapshot
+	Synced       bool                   `json:"synced"`        // A snapshot has been applied
+}
+
+// Summary describes a stored book without its levels
+type Summary struct {
+	ExchangeID connector.ExchangeID `json:"exchange_id"`
+	Symbol     string               `json:"symbol"`
+	Canonical  string               `json:"canonical"`
+	BidLevels  int                  `json:"bid_levels"`
+	AskLevels  int                  `json:"ask_levels"`
+	SequenceID int64                `json:"sequence_id,omitempty"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	Synced     bool                 `json:"synced"`
+}
+
+// Store keeps the latest full book of every venue symbol so debugging tools
+// and executors can read more depth than the published top-N.
+//
+// Snapshots replace the stored book. Diffs are merged level by level, a
+// zero quantity removing the level; until a venue sends its first snapshot
+// the book holds only the diffs seen and is reported as not synced. Venues
+// interleaving top-of-book updates with full books (CoinEx BBO) may show a
+// stale level ahead of the new top until their next full book.
+type Store struct {
+	mu    sync.RWMutex
+	books map[connector.ExchangeID]map[string]*Book
+}
+
+// NewStore creates an empty book store
+func NewStore() *Store {
+	return &Store{
+		books: make(map[connector.ExchangeID]map[string]*Book),
+	}
+}
+
+// HandleOrderbook applies a streamed book. The store never modifies ob, so
+// it may be shared with other consumers.
+func (s *Store) HandleOrderbook(ob *connector.Orderbook) {
+	if ob == nil || ob.Symbol == "" {
+		return
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbols := s.books[ob.ExchangeID]
+	if symbols == nil {
+		symbols = make(map[string]*Book)
+		s.books[ob.ExchangeID] = symbols
+	}
+	prev := symbols[ob.Symbol]
+
+	book := &Book{
+		ExchangeID:   ob.ExchangeID,
+		Symbol:       ob.Symbol,
+		Canonical:    ob.Canonical,
+		SequenceID:   ob.SequenceID,
+		Timestamp:    ob.Timestamp,
+		ReceivedAt:   ob.ReceivedAt,
+		NormalizedAt: ob.NormalizedAt,
+		UpdatedAt:    now,
+	}
+
+	if ob.IsSnapshot || prev == nil {
+		book.Bids = withoutEmpty(ob.Bids)
+		book.Asks = withoutEmpty(ob.Asks)
+		if ob.IsSnapshot {
+			book.SnapshotAt = now
+			book.Synced = true
+		}
+	} else {
+		book.Bids = merge(prev.Bids, ob.Bids, true)
+		book.Asks = merge(prev.Asks, ob.Asks, false)
+		book.SnapshotAt = prev.SnapshotAt
+		book.Synced = prev.Synced
+		book.Updates = prev.Updates + 1
+		if book.SequenceID == 0 {
+			book.SequenceID = prev.SequenceID
+		}
+	}
+
+	if len(book.Bids) > 0 {
+		book.BestBid = book.Bids[0].Price
+	}
+	if len(book.Asks) > 0 {
+		book.BestAsk = book.Asks[0].Price
+	}
+	symbols[ob.Symbol] = book
+}
+
+// Get returns the book of a venue symbol trimmed to depth levels per side
+// (0 for all), or nil if none has been seen. The book is a copy.
+func (s *Store) Get(exchange connector.ExchangeID, symbol string, depth int) *Book {
+	s.mu.RLock()
+	book := s.books[exchange][symbol]
+	s.mu.RUnlock()
+	if book == nil {
+		return nil
+	}
+
+	// Stored books are replaced, never mutated, so copying outside the lock is safe
+	cp := *book
+	cp.Bids = copyLevels(book.Bids, depth)
+	cp.Asks = copyLevels(book.Asks, depth)
+	return &cp
+}
+
+// Levels returns a venue symbol's levels without copying them, and whether
+// a snapshot has been applied. Stored books are replaced, never mutated,
+// so the levels stay valid but must not be modified.
+func (s *Store) Levels(exchange connector.ExchangeID, symbol string) (bids, asks []connector.PriceLevel, synced bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	book := s.books[exchange][symbol]
+	if book == nil {
+		return nil, nil, false
+	}
+	return book.Bids, book.Asks, book.Synced
+}
+
+// List returns a summary of every stored book, by exchange then symbol
+func (s *Store) List() []Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Summary
+	for _, symbols := range s.books {
+		for _, book := range symbols {
+			result = append(result, Summary{
+				ExchangeID: book.ExchangeID,
+				Symbol:     book.Symbol,
+				Canonical:  book.Canonical,
+				BidLevels:  len(book.Bids),
+				AskLevels:  len(book.Asks),
+				SequenceID: book.SequenceID,
+				UpdatedAt:  book.UpdatedAt,
+				Synced:     book.Synced,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ExchangeID != result[j].ExchangeID {
+			return result[i].ExchangeID < result[j].ExchangeID
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// MemoryUsage estimates the bytes held by stored books
+func (s *Store) MemoryUsage() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	const (
+		levelSize = 24  // PriceLevel: three float64
+		bookSize  = 250 // Book struct and strings
+	)
+
+	var total int64
+	for _, symbols := range s.books {
+		for _, book := range symbols {
+			total += bookSize + int64(cap(book.Bids)+cap(book.Asks))*levelSize
+		}
+	}
+	return total
+}
+
+// merge applies diff levels to a sorted side and returns a new slice; a
+// zero quantity removes the level
+func merge(levels, diff []connector.PriceLevel, isBid bool) []connector.PriceLevel {
+	updates := make(map[float64]float64, len(diff))
+	for _, l := range diff {
+		updates[l.Price] = l.Quantity
+	}
+
+	result := make([]connector.PriceLevel, 0, len(levels)+len(diff))
+	for _, l := range levels {
+		if qty, ok := updates[l.Price]; ok {
+			delete(updates, l.Price)
+			if qty <= 0 {
+				continue
+			}
+			l.Quantity = qty
+		}
+		result = append(result, l)
+	}
+	for price, qty := range updates {
+		if qty > 0 {
+			result = append(result, connector.PriceLevel{Price: price, Quantity: qty})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if isBid {
+			return result[i].Price > result[j].Price
+		}
+		return result[i].Price < result[j].Price
+	})
+	return result
+}
+
+// withoutEmpty copies a snapshot side, skipping zero-quantity levels
+func withoutEmpty(levels []connector.PriceLevel) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, l := range levels {
+		if l.Quantity > 0 {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+func copyLevels(levels []connector.PriceLevel, depth int) []connector.PriceLevel {
+	if depth > 0 && len(levels) > depth {
+		levels = levels[:depth]
+	}
+	return append([]connector.PriceLevel{}, levels...)
+}
@@ -0,0 +1,233 @@
+package books
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// DeltaConfig controls how recorded books are split into snapshots and deltas
+type DeltaConfig struct {
+	SnapshotEvery    int           // Deltas between two snapshots of a symbol; 0 stores every book whole
+	SnapshotInterval time.Duration // Longest time between two snapshots of a symbol
+}
+
+// DefaultDeltaConfig snapshots each symbol every 100 updates or 30 seconds
+func DefaultDeltaConfig() DeltaConfig {
+	return DeltaConfig{
+		SnapshotEvery:    100,
+		SnapshotInterval: 30 * time.Second,
+	}
+}
+
+// Level is a changed price level as [price, quantity]; a zero quantity
+// removes the level
+type Level [2]float64
+
+// Delta is a book stored as the levels that changed since the previous book
+// of its symbol. Times are Unix nanoseconds, zero when unset, and come back
+// in UTC.
+type Delta struct {
+	ExchangeID   connector.ExchangeID `json:"exchange"`
+	Symbol       string               `json:"symbol"`
+	SequenceID   int64                `json:"seq,omitempty"`
+	Timestamp    int64                `json:"ts,omitempty"`
+	ReceivedAt   int64                `json:"received,omitempty"`
+	NormalizedAt int64                `json:"normalized,omitempty"`
+	PublishedAt  int64                `json:"published,omitempty"`
+	BestBid      float64              `json:"best_bid,omitempty"`
+	BestAsk      float64              `json:"best_ask,omitempty"`
+	SpreadBps    float64              `json:"spread_bps,omitempty"`
+	Bids         []Level              `json:"bids,omitempty"`
+	Asks         []Level              `json:"asks,omitempty"`
+}
+
+type bookKey struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+type encoded struct {
+	book       *connector.Orderbook
+	snapshotAt time.Time
+	deltas     int
+}
+
+// DeltaEncoder turns a stream of full books into periodic snapshots with
+// deltas in between. A delta is only produced when applying it to the
+// previous book gives back the book exactly; anything else is stored whole.
+type DeltaEncoder struct {
+	config DeltaConfig
+
+	mu   sync.Mutex
+	last map[bookKey]*encoded
+}
+
+// NewDeltaEncoder creates an encoder with no books seen
+func NewDeltaEncoder(config DeltaConfig) *DeltaEncoder {
+	return &DeltaEncoder{
+		config: config,
+		last:   make(map[bookKey]*encoded),
+	}
+}
+
+// Encode returns ob as a delta from its symbol's previous book, or nil if
+// ob is to be stored whole as a snapshot. at is when the book is recorded.
+func (e *DeltaEncoder) Encode(ob *connector.Orderbook, at time.Time) *Delta {
+	key := bookKey{ob.ExchangeID, ob.Symbol}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	prev := e.last[key]
+	if prev != nil && e.config.SnapshotEvery > 0 && prev.deltas < e.config.SnapshotEvery &&
+		(e.config.SnapshotInterval <= 0 || at.Sub(prev.snapshotAt) < e.config.SnapshotInterval) {
+		if d := diff(prev.book, ob); d != nil {
+			prev.book = ob.Clone()
+			prev.deltas++
+			return d
+		}
+	}
+
+	e.last[key] = &encoded{book: ob.Clone(), snapshotAt: at}
+	return nil
+}
+
+// diff returns the delta from prev to ob, or nil if ob can't be rebuilt
+// from one exactly
+func diff(prev, ob *connector.Orderbook) *Delta {
+	if ob.Canonical != prev.Canonical || ob.Market != prev.Market || ob.IsSnapshot != prev.IsSnapshot ||
+		ob.Approximate != prev.Approximate || ob.Aggregation != prev.Aggregation {
+		return nil
+	}
+
+	d := &Delta{
+		ExchangeID:   ob.ExchangeID,
+		Symbol:       ob.Symbol,
+		SequenceID:   ob.SequenceID,
+		Timestamp:    unixNano(ob.Timestamp),
+		ReceivedAt:   unixNano(ob.ReceivedAt),
+		NormalizedAt: unixNano(ob.NormalizedAt),
+		PublishedAt:  unixNano(ob.PublishedAt),
+		BestBid:      ob.BestBid,
+		BestAsk:      ob.BestAsk,
+		SpreadBps:    ob.SpreadBps,
+		Bids:         changed(prev.Bids, ob.Bids),
+		Asks:         changed(prev.Asks, ob.Asks),
+	}
+	if !sameLevels(apply(prev.Bids, d.Bids, true), ob.Bids) || !sameLevels(apply(prev.Asks, d.Asks, false), ob.Asks) {
+		return nil
+	}
+	return d
+}
+
+// changed returns the levels of cur that differ from prev, and prev's
+// levels missing from cur with a zero quantity
+func changed(prev, cur []connector.PriceLevel) []Level {
+	before := make(map[float64]float64, len(prev))
+	for _, l := range prev {
+		before[l.Price] = l.Quantity
+	}
+
+	var result []Level
+	for _, l := range cur {
+		if qty, ok := before[l.Price]; !ok || qty != l.Quantity {
+			result = append(result, Level{l.Price, l.Quantity})
+		}
+		delete(before, l.Price)
+	}
+	for _, l := range prev {
+		if _, ok := before[l.Price]; ok {
+			result = append(result, Level{l.Price, 0})
+		}
+	}
+	return result
+}
+
+// apply merges changed levels into a sorted side
+func apply(levels []connector.PriceLevel, changes []Level, isBid bool) []connector.PriceLevel {
+	if len(changes) == 0 {
+		return append([]connector.PriceLevel{}, levels...)
+	}
+	diff := make([]connector.PriceLevel, len(changes))
+	for i, c := range changes {
+		diff[i] = connector.PriceLevel{Price: c[0], Quantity: c[1]}
+	}
+	return merge(levels, diff, isBid)
+}
+
+func sameLevels(a, b []connector.PriceLevel) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+// Reconstructor rebuilds full books from snapshots and the deltas recorded
+// after them, in recording order
+type Reconstructor struct {
+	books map[bookKey]*connector.Orderbook
+}
+
+// NewReconstructor creates a reconstructor with no books
+func NewReconstructor() *Reconstructor {
+	return &Reconstructor{books: make(map[bookKey]*connector.Orderbook)}
+}
+
+// Snapshot records a whole book as the base of its symbol's next delta
+func (r *Reconstructor) Snapshot(ob *connector.Orderbook) {
+	r.books[bookKey{ob.ExchangeID, ob.Symbol}] = ob
+}
+
+// Apply returns the book a delta encodes. The book is new; earlier books
+// returned are not modified.
+func (r *Reconstructor) Apply(d *Delta) (*connector.Orderbook, error) {
+	key := bookKey{d.ExchangeID, d.Symbol}
+	prev := r.books[key]
+	if prev == nil {
+		return nil, fmt.Errorf("delta for %s %s without a snapshot", d.ExchangeID, d.Symbol)
+	}
+
+	ob := &connector.Orderbook{
+		ExchangeID:   d.ExchangeID,
+		Symbol:       d.Symbol,
+		Canonical:    prev.Canonical,
+		Market:       prev.Market,
+		Bids:         apply(prev.Bids, d.Bids, true),
+		Asks:         apply(prev.Asks, d.Asks, false),
+		BestBid:      d.BestBid,
+		BestAsk:      d.BestAsk,
+		SpreadBps:    d.SpreadBps,
+		Timestamp:    fromUnixNano(d.Timestamp),
+		SequenceID:   d.SequenceID,
+		IsSnapshot:   prev.IsSnapshot,
+		Approximate:  prev.Approximate,
+		Aggregation:  prev.Aggregation,
+		ReceivedAt:   fromUnixNano(d.ReceivedAt),
+		NormalizedAt: fromUnixNano(d.NormalizedAt),
+		PublishedAt:  fromUnixNano(d.PublishedAt),
+	}
+	r.books[key] = ob
+	return ob, nil
+}
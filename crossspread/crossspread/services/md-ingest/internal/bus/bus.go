@@ -0,0 +1,338 @@
+package bus
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Policy decides what Publish does when a subscriber's queue is full
+type Policy int
+
+const (
+	// Block waits until the subscriber has room; nothing is lost, but a
+	// slow subscriber stalls the connector read loop
+	Block Policy = iota
+	// DropNewest discards the incoming event
+	DropNewest
+	// DropOldest evicts the oldest queued event to make room
+	DropOldest
+)
+
+var policyNames = map[Policy]string{
+	Block:      "block",
+	DropNewest: "drop_newest",
+	DropOldest: "drop_oldest",
+}
+
+func (p Policy) String() string {
+	if name, ok := policyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("policy(%d)", int(p))
+}
+
+// MarshalText renders the policy name in admin responses
+func (p Policy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// ParsePolicy parses "block", "drop_newest" or "drop_oldest"
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	for p, name := range policyNames {
+		if s == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown bus policy %q: want block, drop_newest or drop_oldest", s)
+}
+
+// SubscribeConfig sizes a subscriber's queue
+type SubscribeConfig struct {
+	Buffer int    // Queued events before the policy applies
+	Policy Policy // What to do when the queue is full
+}
+
+// DefaultSubscribeConfig returns a lossless subscription with a deep queue
+func DefaultSubscribeConfig() SubscribeConfig {
+	return SubscribeConfig{
+		Buffer: 4096,
+		Policy: Block,
+	}
+}
+
+// SubscriberStats describes one subscriber's queue
+type SubscriberStats struct {
+	Topic      string `json:"topic"`
+	Subscriber string `json:"subscriber"`
+	Policy     Policy `json:"policy"`
+	Buffer     int    `json:"buffer"`
+	Queued     int    `json:"queued"`
+}
+
+type subscriber[T any] struct {
+	name   string
+	policy Policy
+	ch     chan T
+	fn     func(T)
+}
+
+// Topic fans events of one type out to named subscribers. Each subscriber
+// has its own queue and goroutine, so events reach it in publish order and
+// a slow subscriber only affects others under the Block policy.
+type Topic[T any] struct {
+	name   string
+	mu     sync.RWMutex
+	subs   []*subscriber[T]
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewTopic creates a topic; name labels its metrics
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+// Subscribe registers fn under name and starts delivering to it. Subscribe
+// before connectors start; events published earlier are not replayed.
+func (t *Topic[T]) Subscribe(name string, cfg SubscribeConfig, fn func(T)) {
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = DefaultSubscribeConfig().Buffer
+	}
+	sub := &subscriber[T]{
+		name:   name,
+		policy: cfg.Policy,
+		ch:     make(chan T, cfg.Buffer),
+		fn:     fn,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.subs = append(t.subs, sub)
+	t.wg.Add(1)
+	go t.run(sub)
+}
+
+// Publish hands v to every subscriber according to its policy
+func (t *Topic[T]) Publish(v T) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		metrics.BusDropped.WithLabelValues(t.name, "closed").Inc()
+		return
+	}
+
+	for _, sub := range t.subs {
+		switch sub.policy {
+		case DropNewest:
+			select {
+			case sub.ch <- v:
+			default:
+				metrics.BusDropped.WithLabelValues(t.name, sub.name).Inc()
+			}
+		case DropOldest:
+			for sent := false; !sent; {
+				select {
+				case sub.ch <- v:
+					sent = true
+				default:
+					select {
+					case <-sub.ch:
+						metrics.BusDropped.WithLabelValues(t.name, sub.name).Inc()
+					default:
+					}
+				}
+			}
+		default:
+			sub.ch <- v
+		}
+		metrics.BusQueueDepth.WithLabelValues(t.name, sub.name).Set(float64(len(sub.ch)))
+	}
+}
+
+// Close stops accepting events, delivers what is queued and waits for the
+// subscribers to finish
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	for _, sub := range t.subs {
+		close(sub.ch)
+	}
+	t.mu.Unlock()
+
+	t.wg.Wait()
+}
+
+// Stats returns the queue of every subscriber
+func (t *Topic[T]) Stats() []SubscriberStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	stats := make([]SubscriberStats, 0, len(t.subs))
+	for _, sub := range t.subs {
+		stats = append(stats, SubscriberStats{
+			Topic:      t.name,
+			Subscriber: sub.name,
+			Policy:     sub.policy,
+			Buffer:     cap(sub.ch),
+			Queued:     len(sub.ch),
+		})
+	}
+	return stats
+}
+
+func (t *Topic[T]) run(sub *subscriber[T]) {
+	defer t.wg.Done()
+	for v := range sub.ch {
+		t.deliver(sub, v)
+	}
+}
+
+// deliver calls the subscriber, recovering a panic so one bad event does
+// not stop the subscriber
+func (t *Topic[T]) deliver(sub *subscriber[T], v T) {
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.BusPanics.WithLabelValues(t.name, sub.name).Inc()
+			log.Error().
+				Str("topic", t.name).
+				Str("subscriber", sub.name).
+				Interface("panic", r).
+				Str("stack", string(debug.Stack())).
+				Msg("Bus subscriber panicked")
+		}
+	}()
+	sub.fn(v)
+}
+
+// Renamer maps the canonical a connector emitted to the one in effect after
+// symbol renames; *normalizer.InstrumentNormalizer implements it
+type Renamer interface {
+	Rename(exchange connector.ExchangeID, canonical string) string
+}
+
+// FundingNormalizer resolves a rate's interval and stamps its normalized
+// values; *funding.Intervals implements it
+type FundingNormalizer interface {
+	Normalize(fr *connector.FundingRate)
+}
+
+// Bus carries market data from connectors to in-process consumers
+// (publisher, spread discovery, index, bars, funding settlements).
+// Connectors publish through Attach or the Publish* handlers; consumers
+// subscribe to the topic they need.
+type Bus struct {
+	Orderbooks *Topic[*connector.Orderbook]
+	Trades     *Topic[*connector.Trade]
+	Funding    *Topic[*connector.FundingRate]
+
+	renamer Renamer
+	funding FundingNormalizer
+}
+
+// New creates a bus with empty topics
+func New() *Bus {
+	return &Bus{
+		Orderbooks: NewTopic[*connector.Orderbook]("orderbook"),
+		Trades:     NewTopic[*connector.Trade]("trade"),
+		Funding:    NewTopic[*connector.FundingRate]("funding"),
+	}
+}
+
+// SetRenamer renames the canonical of every event before it is published,
+// so data from a venue still quoting an asset's old name matches the rest.
+// Call it before connectors start.
+func (b *Bus) SetRenamer(r Renamer) {
+	b.renamer = r
+}
+
+// SetFundingNormalizer normalizes every funding rate before it is published.
+// Call it before connectors start.
+func (b *Bus) SetFundingNormalizer(n FundingNormalizer) {
+	b.funding = n
+}
+
+func (b *Bus) rename(exchange connector.ExchangeID, canonical string) string {
+	if b.renamer == nil {
+		return canonical
+	}
+	return b.renamer.Rename(exchange, canonical)
+}
+
+// PublishOrderbook publishes a copy of ob. Connectors keep mutating the
+// books they emit, so subscribers must not share the original. Every
+// subscriber gets the same copy, so events are read-only once published:
+// a consumer changing one, e.g. stamping PublishedAt, works on its own copy.
+func (b *Bus) PublishOrderbook(ob *connector.Orderbook) {
+	if ob == nil {
+		return
+	}
+	clone := ob.Clone()
+	clone.Canonical = b.rename(clone.ExchangeID, clone.Canonical)
+	b.Orderbooks.Publish(clone)
+}
+
+// PublishTrade publishes a trade
+func (b *Bus) PublishTrade(trade *connector.Trade) {
+	if trade == nil {
+		return
+	}
+	trade.Canonical = b.rename(trade.ExchangeID, trade.Canonical)
+	b.Trades.Publish(trade)
+}
+
+// PublishFunding publishes a funding rate
+func (b *Bus) PublishFunding(fr *connector.FundingRate) {
+	if fr == nil {
+		return
+	}
+	fr.Canonical = b.rename(fr.ExchangeID, fr.Canonical)
+	if b.funding != nil {
+		b.funding.Normalize(fr)
+	}
+	b.Funding.Publish(fr)
+}
+
+// Attach routes a connector's market data onto the bus. Errors stay with
+// the caller, which knows how to unsubscribe the connector's symbols.
+func (b *Bus) Attach(conn connector.Connector) {
+	conn.SetOrderbookHandler(b.PublishOrderbook)
+	conn.SetTradeHandler(b.PublishTrade)
+	conn.SetFundingHandler(b.PublishFunding)
+}
+
+// Stats returns every subscriber's queue, by topic then subscriber
+func (b *Bus) Stats() []SubscriberStats {
+	stats := append(b.Orderbooks.Stats(), b.Trades.Stats()...)
+	stats = append(stats, b.Funding.Stats()...)
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Topic != stats[j].Topic {
+			return stats[i].Topic < stats[j].Topic
+		}
+		return stats[i].Subscriber < stats[j].Subscriber
+	})
+	return stats
+}
+
+// Close drains every topic. Call it after connectors stop emitting and
+// before the subscribers' components are stopped.
+func (b *Bus) Close() {
+	b.Orderbooks.Close()
+	b.Trades.Close()
+	b.Funding.Close()
+}
@@ -0,0 +1,232 @@
+package chatbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/execution"
+	"crossspread-md-ingest/internal/spread"
+	"crossspread-md-ingest/internal/venuehealth"
+)
+
+// Prefix starts every command
+const Prefix = "!"
+
+// MaxReply is the longest reply sent; Discord refuses messages over 2000
+// characters
+const MaxReply = 1900
+
+// help lists the commands
+const help = "Commands:\n" +
+	"`!spread BTC` best current spreads of a symbol\n" +
+	"`!status` connections and REST health per venue\n" +
+	"`!pnl` strategy PnL and drawdown, `!pnl today` since 00:00 UTC"
+
+// Bot answers chat commands from md-ingest's admin API, so it runs
+// anywhere the API is reachable and holds no state of its own
+type Bot struct {
+	adminURL string
+	client   *http.Client
+}
+
+// New creates a bot querying the admin API at adminURL,
+// e.g. http://md-ingest:9091
+func New(adminURL string) *Bot {
+	return &Bot{
+		adminURL: strings.TrimSuffix(adminURL, "/"),
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Answer returns the reply to a message, or false if the message is not a
+// command. Failed queries are answered with the error.
+func (b *Bot) Answer(ctx context.Context, text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], Prefix) {
+		return "", false
+	}
+
+	var reply string
+	var err error
+	switch strings.ToLower(strings.TrimPrefix(fields[0], Prefix)) {
+	case "spread", "spreads":
+		if len(fields) < 2 {
+			return "Usage: `!spread BTC`", true
+		}
+		reply, err = b.spreads(ctx, fields[1])
+	case "status":
+		reply, err = b.status(ctx)
+	case "pnl":
+		reply, err = b.pnl(ctx, len(fields) > 1 && strings.EqualFold(fields[1], "today"))
+	case "help":
+		reply = help
+	default:
+		return "", false
+	}
+	if err != nil {
+		reply = "Query failed: " + err.Error()
+	}
+	if len(reply) > MaxReply {
+		cut := strings.LastIndex(reply[:MaxReply], "\n")
+		if cut < 0 {
+			cut = MaxReply
+		}
+		reply = strings.ToValidUTF8(reply[:cut], "") + "\n…"
+	}
+	return reply, true
+}
+
+// get decodes an admin API response
+func (b *Bot) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.adminURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("%s: HTTP %d %s", path, resp.StatusCode, body.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (b *Bot) spreads(ctx context.Context, symbol string) (string, error) {
+	canonical := strings.ToUpper(symbol)
+	var result struct {
+		Spreads []*spread.SpreadOpportunity `json:"spreads"`
+	}
+	if err := b.get(ctx, "/admin/spreads?limit=5&canonical="+url.QueryEscape(canonical), &result); err != nil {
+		return "", err
+	}
+	if len(result.Spreads) == 0 {
+		return fmt.Sprintf("No spreads on %s right now", canonical), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** best spreads\n", canonical)
+	for _, opp := range result.Spreads {
+		fmt.Fprintf(&sb, "• long %s / short %s: %.1f bps, net %+.1f bps, depth %s",
+			opp.LongExchange, opp.ShortExchange, opp.SpreadBps, opp.NetEdgeBps, usd(opp.MinDepthUSD))
+		if opp.Profitable {
+			sb.WriteString(" ✅")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func (b *Bot) status(ctx context.Context) (string, error) {
+	var budgets struct {
+		Exchanges []connector.Usage `json:"exchanges"`
+	}
+	if err := b.get(ctx, "/admin/budgets", &budgets); err != nil {
+		return "", err
+	}
+	var health struct {
+		Venues []venuehealth.Health `json:"venues"`
+	}
+	if err := b.get(ctx, "/admin/venues/health", &health); err != nil {
+		return "", err
+	}
+
+	rest := make(map[connector.ExchangeID]venuehealth.Health, len(health.Venues))
+	ids := make(map[connector.ExchangeID]bool)
+	for _, h := range health.Venues {
+		rest[h.Exchange] = h
+		ids[h.Exchange] = true
+	}
+	conns := make(map[connector.ExchangeID]int64, len(budgets.Exchanges))
+	for _, u := range budgets.Exchanges {
+		conns[u.Exchange] = u.Connections
+		ids[u.Exchange] = true
+	}
+	sorted := make([]connector.ExchangeID, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sb strings.Builder
+	sb.WriteString("**Venues**\n")
+	for _, id := range sorted {
+		icon := "🟢"
+		if conns[id] == 0 {
+			icon = "🔴"
+		}
+		fmt.Fprintf(&sb, "%s %s: %d ws", icon, id, conns[id])
+		if h, ok := rest[id]; ok {
+			if h.State == venuehealth.StateDegraded {
+				fmt.Fprintf(&sb, ", REST **degraded** (%s)", h.Reason)
+			} else {
+				sb.WriteString(", REST ok")
+			}
+			fmt.Fprintf(&sb, ", p90 %.0fms, %.0f%% errors", h.P90LatencyMs, h.ErrorRate*100)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func (b *Bot) pnl(ctx context.Context, today bool) (string, error) {
+	var status execution.DrawdownStatus
+	if err := b.get(ctx, "/admin/risk/drawdown", &status); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if today {
+		fmt.Fprintf(&sb, "**PnL today** %s (total %s)\n", signedUSD(status.TodayUSD), signedUSD(status.PnLUSD))
+	} else {
+		fmt.Fprintf(&sb, "**PnL** %s\n", signedUSD(status.PnLUSD))
+		names := make([]string, 0, len(status.Strategies))
+		for name := range status.Strategies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&sb, "• %s: %s\n", name, signedUSD(status.Strategies[name]))
+		}
+	}
+	fmt.Fprintf(&sb, "Drawdown %s from the peak over %s", usd(status.DrawdownUSD), status.Window)
+	if status.MaxDrawdownUSD > 0 {
+		fmt.Fprintf(&sb, " (limit %s)", usd(status.MaxDrawdownUSD))
+	}
+	if status.State.Active {
+		fmt.Fprintf(&sb, "\n⚠️ De-risking active: %s since %s", status.State.Action, status.State.TrippedAt.UTC().Format("15:04 UTC"))
+	}
+	return sb.String(), nil
+}
+
+// usd formats a dollar amount compactly, e.g. $12.3k
+func usd(v float64) string {
+	switch a := math.Abs(v); {
+	case a >= 1e6:
+		return fmt.Sprintf("$%.2fM", v/1e6)
+	case a >= 1e3:
+		return fmt.Sprintf("$%.1fk", v/1e3)
+	default:
+		return fmt.Sprintf("$%.0f", v)
+	}
+}
+
+func signedUSD(v float64) string {
+	if v < 0 {
+		return "-" + usd(-v)
+	}
+	return "+" + usd(v)
+}
@@ -0,0 +1,246 @@
+package chatbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// Discord endpoints
+const (
+	DiscordGatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+	DiscordAPIURL     = "https://discord.com/api/v10"
+)
+
+// Gateway opcodes and intents used by the bot
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+
+	intentGuildMessages  = 1 << 9
+	intentDirectMessages = 1 << 12
+	intentMessageContent = 1 << 15 // Privileged; enable it on the bot's application page
+)
+
+type gatewayPayload struct {
+	Op   int             `json:"op"`
+	Data json.RawMessage `json:"d,omitempty"`
+	Seq  *int64          `json:"s,omitempty"`
+	Type string          `json:"t,omitempty"`
+}
+
+type discordMessage struct {
+	ChannelID string `json:"channel_id"`
+	Content   string `json:"content"`
+	Author    struct {
+		Bot bool `json:"bot"`
+	} `json:"author"`
+}
+
+// Discord connects a bot to the Discord gateway and replies to commands in
+// the channels it reads
+type Discord struct {
+	token    string
+	bot      *Bot
+	channels map[string]bool // Empty answers in every channel
+	client   *http.Client
+
+	writeMu sync.Mutex
+}
+
+// NewDiscord creates a Discord client for a bot token. channels limits the
+// channel IDs answered in; empty answers everywhere the bot can read.
+func NewDiscord(token string, bot *Bot, channels []string) *Discord {
+	allowed := make(map[string]bool, len(channels))
+	for _, id := range channels {
+		allowed[id] = true
+	}
+	return &Discord{
+		token:    token,
+		bot:      bot,
+		channels: allowed,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run keeps a gateway session open until ctx is done, reconnecting with
+// backoff when Discord drops it
+func (d *Discord) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := d.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		log.Warn().Err(err).Dur("retry_in", backoff).Msg("Discord gateway session ended")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 2*time.Minute)
+	}
+}
+
+// session runs one gateway connection: hello, identify, then heartbeats
+// alongside the dispatch loop
+func (d *Discord) session(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, DiscordGatewayURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var hello gatewayPayload
+	if err := conn.ReadJSON(&hello); err != nil {
+		return err
+	}
+	if hello.Op != opHello {
+		return fmt.Errorf("expected hello, got op %d", hello.Op)
+	}
+	var h struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	if err := json.Unmarshal(hello.Data, &h); err != nil {
+		return err
+	}
+
+	identify := map[string]interface{}{
+		"token":   d.token,
+		"intents": intentGuildMessages | intentDirectMessages | intentMessageContent,
+		"properties": map[string]string{
+			"os":      "linux",
+			"browser": "md-ingest",
+			"device":  "md-ingest",
+		},
+	}
+	if err := d.send(conn, opIdentify, identify); err != nil {
+		return err
+	}
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var seq struct {
+		sync.Mutex
+		last *int64
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(h.HeartbeatInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sessionCtx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				seq.Lock()
+				last := seq.last
+				seq.Unlock()
+				if err := d.send(conn, opHeartbeat, last); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	log.Info().Msg("Discord gateway connected")
+	for {
+		var p gatewayPayload
+		if err := conn.ReadJSON(&p); err != nil {
+			return err
+		}
+		if p.Seq != nil {
+			seq.Lock()
+			seq.last = p.Seq
+			seq.Unlock()
+		}
+
+		switch p.Op {
+		case opHeartbeat:
+			seq.Lock()
+			last := seq.last
+			seq.Unlock()
+			if err := d.send(conn, opHeartbeat, last); err != nil {
+				return err
+			}
+		case opReconnect:
+			return errors.New("gateway asked to reconnect")
+		case opInvalidSession:
+			return errors.New("gateway invalidated the session")
+		case opDispatch:
+			if p.Type != "MESSAGE_CREATE" {
+				continue
+			}
+			var m discordMessage
+			if err := json.Unmarshal(p.Data, &m); err != nil || m.Author.Bot {
+				continue
+			}
+			if len(d.channels) > 0 && !d.channels[m.ChannelID] {
+				continue
+			}
+			go d.answer(sessionCtx, m)
+		}
+	}
+}
+
+// answer replies to a message if it is a command
+func (d *Discord) answer(ctx context.Context, m discordMessage) {
+	reply, ok := d.bot.Answer(ctx, m.Content)
+	if !ok {
+		return
+	}
+	if err := d.post(ctx, m.ChannelID, reply); err != nil {
+		log.Warn().Err(err).Str("channel", m.ChannelID).Msg("Failed to send Discord reply")
+	}
+}
+
+// send writes a gateway payload
+func (d *Discord) send(conn *websocket.Conn, op int, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	return conn.WriteJSON(gatewayPayload{Op: op, Data: raw})
+}
+
+// post sends a message to a channel through the REST API
+func (d *Discord) post(ctx context.Context, channelID, content string) error {
+	body, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, DiscordAPIURL+"/channels/"+channelID+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
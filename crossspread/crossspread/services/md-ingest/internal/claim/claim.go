@@ -0,0 +1,288 @@
+package claim
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrClaimed is returned when another executor holds the opportunity
+	ErrClaimed = errors.New("claim: opportunity claimed by another executor")
+
+	// ErrLeaseLost is returned when a lease expired or was released by an
+	// operator before the holder renewed or finished it
+	ErrLeaseLost = errors.New("claim: lease lost")
+)
+
+// Claim states
+const (
+	StateActive = "active" // An executor is working the opportunity
+	StateDone   = "done"   // Executed; held so the edge isn't sized twice
+)
+
+// Config controls opportunity leases
+type Config struct {
+	// TTL is how long a lease lasts without renewal. An executor that dies
+	// mid-execution frees the opportunity after at most this long.
+	TTL time.Duration
+	// FinishHold keeps a finished opportunity claimed so other executors
+	// don't size the same edge before the fills show up in the books
+	FinishHold time.Duration
+	// Observe, if set, is called with the result of every lease operation:
+	// claimed, contended, lost, finished, released or revoked. md-ingest
+	// counts them in md_opportunity_claims_total; the package doesn't
+	// register metrics itself so executors can import it through mdclient.
+	Observe func(result string)
+}
+
+// DefaultConfig returns a lease long enough to send and confirm both legs
+func DefaultConfig() Config {
+	return Config{
+		TTL:        5 * time.Second,
+		FinishHold: 2 * time.Second,
+	}
+}
+
+// claimScript takes the lease if nobody else holds it. Re-claiming with the
+// same token renews it. Returns {1} on success or {0, owner, pttl}.
+var claimScript = redis.NewScript(`
+local token = redis.call('HGET', KEYS[1], 'token')
+if token and token ~= ARGV[1] then
+	return {0, redis.call('HGET', KEYS[1], 'owner') or '', redis.call('PTTL', KEYS[1])}
+end
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('HSET', KEYS[1], 'token', ARGV[1], 'owner', ARGV[2], 'state', 'active', 'claimed_ms', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1}
+`)
+
+// renewScript extends an active lease held with the token
+var renewScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] or redis.call('HGET', KEYS[1], 'state') ~= 'active' then
+	return 0
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// finishScript marks a lease done and holds it for ARGV[2] ms, or deletes
+// it when the hold is zero
+var finishScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[2]) <= 0 then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('HSET', KEYS[1], 'state', 'done')
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// Lease is an executor's exclusive claim on an opportunity
+type Lease struct {
+	OpportunityID string    `json:"opportunity_id"`
+	Owner         string    `json:"owner"`
+	Token         string    `json:"token"` // Unique per claim; two instances with the same owner name don't collide
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// Holder is the current claim on an opportunity
+type Holder struct {
+	OpportunityID string    `json:"opportunity_id"`
+	Tenant        string    `json:"tenant,omitempty"`
+	Owner         string    `json:"owner"`
+	State         string    `json:"state"`
+	ClaimedAt     time.Time `json:"claimed_at"`
+	ExpiresInMs   int64     `json:"expires_in_ms"`
+}
+
+// Claims arbitrates executors consuming the same spread stream so only one
+// acts on an opportunity ID at a time. State lives in Redis so executors in
+// separate processes see the same claims.
+type Claims struct {
+	client *redis.Client
+	config Config
+	tenant string // Empty for the shared, single-account claims
+}
+
+// New creates a new opportunity claim registry
+func New(client *redis.Client, config Config) *Claims {
+	return &Claims{client: client, config: config}
+}
+
+// ForTenant returns the claims of one tenant. Tenants trade separate
+// accounts, so each claims an opportunity independently of the others.
+func (c *Claims) ForTenant(tenant string) *Claims {
+	return &Claims{client: c.client, config: c.config, tenant: tenant}
+}
+
+// observe reports the result of a lease operation
+func (c *Claims) observe(result string) {
+	if c.config.Observe != nil {
+		c.config.Observe(result)
+	}
+}
+
+// key returns the claim key of an opportunity in this registry's scope
+func (c *Claims) key(opportunityID string) string {
+	if c.tenant != "" {
+		return keyspace.TenantClaimKey(c.tenant, opportunityID)
+	}
+	return keyspace.ClaimKey(opportunityID)
+}
+
+// Claim takes an opportunity for owner. Returns an error wrapping
+// ErrClaimed if another executor holds it or finished it within the hold.
+// Renew the lease before it expires while legs are in flight.
+func (c *Claims) Claim(ctx context.Context, opportunityID, owner string) (*Lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	lease := &Lease{OpportunityID: opportunityID, Owner: owner, Token: token}
+
+	res, err := claimScript.Run(ctx, c.client, []string{c.key(opportunityID)},
+		token, owner, c.config.TTL.Milliseconds()).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res[0].(int64); n != 1 {
+		c.observe("contended")
+		holder, _ := res[1].(string)
+		return nil, fmt.Errorf("%w: held by %s", ErrClaimed, holder)
+	}
+
+	lease.ExpiresAt = time.Now().Add(c.config.TTL)
+	c.observe("claimed")
+	return lease, nil
+}
+
+// Renew extends a lease by the TTL. Returns ErrLeaseLost if it already
+// expired; the executor must stop sending legs.
+func (c *Claims) Renew(ctx context.Context, lease *Lease) error {
+	ok, err := renewScript.Run(ctx, c.client, []string{c.key(lease.OpportunityID)},
+		lease.Token, c.config.TTL.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok != 1 {
+		c.observe("lost")
+		return ErrLeaseLost
+	}
+	lease.ExpiresAt = time.Now().Add(c.config.TTL)
+	return nil
+}
+
+// Finish marks an executed opportunity done. It stays claimed for the
+// finish hold so no other executor sizes the same edge again.
+func (c *Claims) Finish(ctx context.Context, lease *Lease) error {
+	return c.end(ctx, lease, c.config.FinishHold, "finished")
+}
+
+// Release gives up an opportunity that was not executed so another
+// executor may take it at once
+func (c *Claims) Release(ctx context.Context, lease *Lease) error {
+	return c.end(ctx, lease, 0, "released")
+}
+
+func (c *Claims) end(ctx context.Context, lease *Lease, hold time.Duration, result string) error {
+	ok, err := finishScript.Run(ctx, c.client, []string{c.key(lease.OpportunityID)},
+		lease.Token, hold.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok != 1 {
+		c.observe("lost")
+		return ErrLeaseLost
+	}
+	c.observe(result)
+	return nil
+}
+
+// Holder returns the current claim on an opportunity, or nil if unclaimed
+func (c *Claims) Holder(ctx context.Context, opportunityID string) (*Holder, error) {
+	key := c.key(opportunityID)
+	pipe := c.client.Pipeline()
+	fields := pipe.HGetAll(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	v := fields.Val()
+	if len(v) == 0 {
+		return nil, nil
+	}
+	claimedMs, _ := strconv.ParseInt(v["claimed_ms"], 10, 64)
+	return &Holder{
+		OpportunityID: opportunityID,
+		Tenant:        c.tenant,
+		Owner:         v["owner"],
+		State:         v["state"],
+		ClaimedAt:     time.UnixMilli(claimedMs),
+		ExpiresInMs:   pttl.Val().Milliseconds(),
+	}, nil
+}
+
+// List returns every current claim in this registry's scope sorted by
+// opportunity ID
+func (c *Claims) List(ctx context.Context) ([]Holder, error) {
+	prefix := c.key("")
+	tenantPrefix := keyspace.Key(strings.TrimSuffix(keyspace.TenantClaimPattern, "{tenant}:{opportunity_id}"))
+
+	var result []Holder
+	iter := c.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		// Tenant claims share the shared claims' prefix
+		if c.tenant == "" && strings.HasPrefix(iter.Val(), tenantPrefix) {
+			continue
+		}
+		h, err := c.Holder(ctx, strings.TrimPrefix(iter.Val(), prefix))
+		if err != nil {
+			return nil, err
+		}
+		if h != nil {
+			result = append(result, *h)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].OpportunityID < result[j].OpportunityID })
+	return result, nil
+}
+
+// Revoke drops a claim whatever its holder, for operators clearing a stuck
+// executor. The holder's next renew fails with ErrLeaseLost.
+func (c *Claims) Revoke(ctx context.Context, opportunityID string) (bool, error) {
+	n, err := c.client.Del(ctx, c.key(opportunityID)).Result()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		c.observe("revoked")
+	}
+	return n > 0, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
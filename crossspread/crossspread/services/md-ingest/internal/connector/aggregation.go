@@ -0,0 +1,89 @@
+package connector
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AggregationSetter is implemented by connectors whose books can be merged
+// into wider price buckets per symbol
+type AggregationSetter interface {
+	// SetAggregation sets the price bucket of a symbol's book. Zero restores
+	// full precision.
+	SetAggregation(symbol string, step float64) error
+
+	// Aggregations returns the bucket of every aggregated symbol
+	Aggregations() map[string]float64
+}
+
+// AggregateBook merges a book into buckets of step and recomputes the top of
+// book. Bids round down and asks round up, so an aggregated book is never
+// tighter than the raw one.
+func AggregateBook(ob *Orderbook, step float64) {
+	if step <= 0 {
+		return
+	}
+	ob.Aggregation = step
+	ob.Bids = AggregateLevels(ob.Bids, step, false)
+	ob.Asks = AggregateLevels(ob.Asks, step, true)
+
+	ob.BestBid, ob.BestAsk, ob.SpreadBps = 0, 0, 0
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+}
+
+// AggregateLevels sums the quantity of levels falling in the same bucket of
+// step. Prices are rounded up when up is set (asks) and down otherwise
+// (bids), then trimmed to the step's decimals so venues that merge on their
+// side and venues merged here print the same prices. Level order is kept.
+func AggregateLevels(levels []PriceLevel, step float64, up bool) []PriceLevel {
+	if step <= 0 || len(levels) == 0 {
+		return levels
+	}
+
+	result := make([]PriceLevel, 0, len(levels))
+	for _, l := range levels {
+		price := RoundToStep(l.Price, step, up)
+		if n := len(result); n > 0 && result[n-1].Price == price {
+			result[n-1].Quantity += l.Quantity
+			continue
+		}
+		result = append(result, PriceLevel{Price: price, Quantity: l.Quantity})
+	}
+	return result
+}
+
+// RoundToStep rounds a price to a multiple of step, up or down
+func RoundToStep(price, step float64, up bool) float64 {
+	if step <= 0 {
+		return price
+	}
+	// Tolerate float noise so a price already on the grid stays put
+	n := price / step
+	if up {
+		n = math.Ceil(n - 1e-9)
+	} else {
+		n = math.Floor(n + 1e-9)
+	}
+	scale := math.Pow10(StepDecimals(step))
+	return math.Round(n*step*scale) / scale
+}
+
+// StepDecimals returns the number of decimals of a price or size step, e.g.
+// 2 for 0.01. Steps computed in float64 (1e-4 as 0.00010000000000000002)
+// are read at 12 decimals at most.
+func StepDecimals(step float64) int {
+	s := strings.TrimRight(strconv.FormatFloat(step, 'f', 12, 64), "0")
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
@@ -5,12 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
@@ -19,6 +19,12 @@ import (
 const (
 	wsBaseURL   = "wss://fstream.binance.com"
 	restBaseURL = "https://fapi.binance.com"
+
+	// snapshotDepth is the REST depth used to seed local books. Diffs only
+	// touch the top 1000 levels, so a full-depth snapshot stays correct.
+	snapshotDepth = 1000
+	// maxSnapshotFetches bounds concurrent snapshot requests (weight 20 each)
+	maxSnapshotFetches = 4
 )
 
 // BinanceConnector implements the Connector interface for Binance Futures
@@ -30,6 +36,11 @@ type BinanceConnector struct {
 	done          chan struct{}
 	depthLevels   int
 	symbols       []string
+	requestID     int64 // ID of the last live SUBSCRIBE request
+
+	booksMu     sync.Mutex
+	books       map[string]*OrderbookManager // Local books built from @depth diffs
+	snapshotSem chan struct{}
 }
 
 // NewBinanceConnector creates a new Binance connector
@@ -50,6 +61,8 @@ func NewBinanceConnector(symbols []string, depthLevels int) *BinanceConnector {
 		done:          make(chan struct{}),
 		depthLevels:   depthLevels,
 		symbols:       symbols,
+		books:         make(map[string]*OrderbookManager),
+		snapshotSem:   make(chan struct{}, maxSnapshotFetches),
 	}
 
 	// Pre-populate subscriptions
@@ -68,24 +81,21 @@ func (c *BinanceConnector) Connect(ctx context.Context) error {
 		return fmt.Errorf("no symbols to subscribe")
 	}
 
-	url := fmt.Sprintf("%s/stream?streams=%s", wsBaseURL, streams)
+	url := fmt.Sprintf("%s/stream?streams=%s", c.WsURL(), streams)
 	log.Info().Str("url", url).Msg("Connecting to Binance WebSocket")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, url, nil)
+	conn, err := c.Dial(ctx, url, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
 
 	c.conn = conn
+	c.resetBooks()
 	c.SetConnected(true)
 	log.Info().Msg("Connected to Binance WebSocket")
 
 	// Start reading messages
-	go c.readLoop()
+	c.Go(c.readLoop)
 
 	return nil
 }
@@ -107,27 +117,24 @@ func (c *BinanceConnector) ConnectForSymbols(ctx context.Context, symbols []stri
 
 	// Build stream URL only for requested symbols
 	streams := c.buildStreamNames()
-	url := fmt.Sprintf("%s/stream?streams=%s", wsBaseURL, streams)
+	url := fmt.Sprintf("%s/stream?streams=%s", c.WsURL(), streams)
 	log.Info().
 		Str("url", url).
 		Int("symbols", len(symbols)).
 		Msg("Connecting to Binance WebSocket for selected symbols")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, url, nil)
+	conn, err := c.Dial(ctx, url, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
 
 	c.conn = conn
+	c.resetBooks()
 	c.SetConnected(true)
 	log.Info().Int("symbols", len(symbols)).Msg("Connected to Binance WebSocket (selective)")
 
 	// Start reading messages
-	go c.readLoop()
+	c.Go(c.readLoop)
 
 	return nil
 }
@@ -142,15 +149,27 @@ func (c *BinanceConnector) Disconnect() error {
 	return nil
 }
 
-// Subscribe adds symbol subscriptions
+// Subscribe adds symbol subscriptions. On a live connection the streams are
+// subscribed immediately; otherwise they are included on the next connect.
 func (c *BinanceConnector) Subscribe(symbols []string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	params := make([]string, 0, len(symbols))
 	for _, s := range symbols {
 		c.subscriptions[s] = true
+		params = append(params, fmt.Sprintf("%s@depth@100ms", toLower(s)))
 	}
-	return nil
+
+	if c.conn == nil || !c.IsConnected() || len(params) == 0 {
+		return nil
+	}
+	c.requestID++
+	return c.conn.WriteJSON(map[string]interface{}{
+		"method": "SUBSCRIBE",
+		"params": params,
+		"id":     c.requestID,
+	})
 }
 
 // Unsubscribe removes symbol subscriptions
@@ -158,15 +177,18 @@ func (c *BinanceConnector) Unsubscribe(symbols []string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.booksMu.Lock()
 	for _, s := range symbols {
 		delete(c.subscriptions, s)
+		delete(c.books, s)
 	}
+	c.booksMu.Unlock()
 	return nil
 }
 
 // FetchInstruments fetches all USDT perpetual futures
 func (c *BinanceConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
-	url := fmt.Sprintf("%s/fapi/v1/exchangeInfo", restBaseURL)
+	url := fmt.Sprintf("%s/fapi/v1/exchangeInfo", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -186,6 +208,7 @@ func (c *BinanceConnector) FetchInstruments(ctx context.Context) ([]connector.In
 			BaseAsset    string `json:"baseAsset"`
 			QuoteAsset   string `json:"quoteAsset"`
 			ContractType string `json:"contractType"`
+			MaintMargin  string `json:"maintMarginPercent"`
 			Filters      []struct {
 				FilterType  string `json:"filterType"`
 				TickSize    string `json:"tickSize,omitempty"`
@@ -216,6 +239,9 @@ func (c *BinanceConnector) FetchInstruments(ctx context.Context) ([]connector.In
 			MakerFee:       0.0002,
 			TakerFee:       0.0004,
 		}
+		if pct, err := strconv.ParseFloat(s.MaintMargin, 64); err == nil {
+			inst.MaintenanceRate = pct / 100
+		}
 
 		// Extract filters
 		for _, f := range s.Filters {
@@ -241,9 +267,9 @@ func (c *BinanceConnector) FetchInstruments(ctx context.Context) ([]connector.In
 	return instruments, nil
 }
 
-// FetchOrderbookSnapshot fetches orderbook via REST API
-func (c *BinanceConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
-	url := fmt.Sprintf("%s/fapi/v1/depth?symbol=%s&limit=%d", restBaseURL, symbol, depth)
+// fetchDepth fetches the raw REST depth snapshot
+func (c *BinanceConnector) fetchDepth(ctx context.Context, symbol string, depth int) (*DepthResponse, error) {
+	url := fmt.Sprintf("%s/fapi/v1/depth?symbol=%s&limit=%d", c.RestURL(), symbol, depth)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -256,21 +282,30 @@ func (c *BinanceConnector) FetchOrderbookSnapshot(ctx context.Context, symbol st
 	}
 	defer resp.Body.Close()
 
-	var data struct {
-		LastUpdateID int64      `json:"lastUpdateId"`
-		Bids         [][]string `json:"bids"`
-		Asks         [][]string `json:"asks"`
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
+	var data DepthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
 		return nil, err
 	}
+	return &data, nil
+}
+
+// FetchOrderbookSnapshot fetches orderbook via REST API
+func (c *BinanceConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
+	data, err := c.fetchDepth(ctx, symbol, depth)
+	if err != nil {
+		return nil, err
+	}
 
 	ob := &connector.Orderbook{
 		ExchangeID: connector.Binance,
 		Symbol:     symbol,
+		Canonical:  extractCanonical(symbol),
 		Timestamp:  time.Now(),
-		SequenceID: data.LastUpdateID,
+		SequenceID: data.LastUpdateId,
 		IsSnapshot: true,
 	}
 
@@ -292,7 +327,7 @@ func (c *BinanceConnector) FetchOrderbookSnapshot(ctx context.Context, symbol st
 
 // FetchFundingRates fetches current funding rates
 func (c *BinanceConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
-	url := fmt.Sprintf("%s/fapi/v1/premiumIndex", restBaseURL)
+	url := fmt.Sprintf("%s/fapi/v1/premiumIndex", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -307,6 +342,7 @@ func (c *BinanceConnector) FetchFundingRates(ctx context.Context) ([]connector.F
 
 	var data []struct {
 		Symbol          string `json:"symbol"`
+		MarkPrice       string `json:"markPrice"`
 		LastFundingRate string `json:"lastFundingRate"`
 		NextFundingTime int64  `json:"nextFundingTime"`
 	}
@@ -318,10 +354,12 @@ func (c *BinanceConnector) FetchFundingRates(ctx context.Context) ([]connector.F
 	var rates []connector.FundingRate
 	for _, d := range data {
 		rate, _ := strconv.ParseFloat(d.LastFundingRate, 64)
+		markPrice, _ := strconv.ParseFloat(d.MarkPrice, 64)
 		rates = append(rates, connector.FundingRate{
 			ExchangeID:           connector.Binance,
 			Symbol:               d.Symbol,
 			FundingRate:          rate,
+			MarkPrice:            markPrice,
 			NextFundingTime:      time.UnixMilli(d.NextFundingTime),
 			FundingIntervalHours: 8,
 			Timestamp:            time.Now(),
@@ -333,6 +371,7 @@ func (c *BinanceConnector) FetchFundingRates(ctx context.Context) ([]connector.F
 
 // readLoop reads messages from WebSocket
 func (c *BinanceConnector) readLoop() {
+	defer c.Recover("readLoop")
 	defer c.SetConnected(false)
 
 	for {
@@ -340,7 +379,7 @@ func (c *BinanceConnector) readLoop() {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("websocket read error: %w", err))
 				return
@@ -365,46 +404,116 @@ func (c *BinanceConnector) handleMessage(message []byte) {
 
 	// Depth update
 	if len(wrapper.Stream) > 0 && wrapper.Data != nil {
-		var depth struct {
-			EventType     string     `json:"e"`
-			EventTime     int64      `json:"E"`
-			Symbol        string     `json:"s"`
-			FirstUpdateID int64      `json:"U"`
-			FinalUpdateID int64      `json:"u"`
-			Bids          [][]string `json:"b"`
-			Asks          [][]string `json:"a"`
-		}
-
+		var depth WSDepthEvent
 		if err := json.Unmarshal(wrapper.Data, &depth); err != nil {
 			c.EmitError(fmt.Errorf("unmarshal depth failed: %w", err))
 			return
 		}
 
 		if depth.EventType == "depthUpdate" {
-			ob := &connector.Orderbook{
-				ExchangeID: connector.Binance,
-				Symbol:     depth.Symbol,
-				Canonical:  extractCanonical(depth.Symbol),
-				Timestamp:  time.UnixMilli(depth.EventTime),
-				SequenceID: depth.FinalUpdateID,
-				IsSnapshot: false,
-				Bids:       parseLevels(depth.Bids),
-				Asks:       parseLevels(depth.Asks),
-			}
+			c.handleDepth(&depth)
+		}
+	}
+}
 
-			if len(ob.Bids) > 0 {
-				ob.BestBid = ob.Bids[0].Price
-			}
-			if len(ob.Asks) > 0 {
-				ob.BestAsk = ob.Asks[0].Price
-			}
-			if ob.BestBid > 0 && ob.BestAsk > 0 {
-				ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
-			}
+// handleDepth feeds a diff to the symbol's local book and emits the book
+// once it is in sync with the exchange
+func (c *BinanceConnector) handleDepth(event *WSDepthEvent) {
+	book := c.book(event.Symbol)
+
+	switch book.ApplyUpdate(event) {
+	case DepthApplied:
+		c.emitBook(book, time.UnixMilli(event.EventTime))
+	case DepthNeedsSnapshot:
+		symbol := event.Symbol
+		c.Go(func() { c.syncBook(symbol, book) })
+	case DepthGap:
+		metrics.OrderbookResyncs.WithLabelValues(string(connector.Binance)).Inc()
+		log.Warn().
+			Str("symbol", event.Symbol).
+			Int64("pu", event.PrevFinalId).
+			Msg("Binance orderbook sequence gap, resyncing from snapshot")
+		symbol := event.Symbol
+		c.Go(func() { c.syncBook(symbol, book) })
+	}
+}
 
-			c.EmitOrderbook(ob)
-		}
+// book returns the local book for a symbol, creating it on first use
+func (c *BinanceConnector) book(symbol string) *OrderbookManager {
+	c.booksMu.Lock()
+	defer c.booksMu.Unlock()
+
+	book, ok := c.books[symbol]
+	if !ok {
+		book = NewOrderbookManager(symbol)
+		c.books[symbol] = book
 	}
+	return book
+}
+
+// resetBooks drops all local books; a new connection restarts every stream
+func (c *BinanceConnector) resetBooks() {
+	c.booksMu.Lock()
+	c.books = make(map[string]*OrderbookManager)
+	c.booksMu.Unlock()
+}
+
+// syncBook loads a REST snapshot into a book whose diffs are being buffered
+func (c *BinanceConnector) syncBook(symbol string, book *OrderbookManager) {
+	defer c.Recover("syncBook")
+	c.snapshotSem <- struct{}{}
+	defer func() { <-c.snapshotSem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshot, err := c.fetchDepth(ctx, symbol, snapshotDepth)
+	if err != nil {
+		// Reset so the next diff requests another snapshot
+		book.Reset()
+		c.EmitError(fmt.Errorf("depth snapshot for %s failed: %w", symbol, err))
+		return
+	}
+
+	if !book.InitializeFromSnapshot(snapshot) {
+		// The buffered diffs start after the snapshot; the next diff retries
+		metrics.OrderbookResyncs.WithLabelValues(string(connector.Binance)).Inc()
+		log.Warn().
+			Str("symbol", symbol).
+			Int64("last_update_id", snapshot.LastUpdateId).
+			Msg("Binance depth snapshot does not connect to buffered diffs, retrying")
+		return
+	}
+
+	c.emitBook(book, time.UnixMilli(snapshot.E))
+}
+
+// emitBook emits the top levels of a synced local book
+func (c *BinanceConnector) emitBook(book *OrderbookManager, ts time.Time) {
+	bids, asks := book.GetTopLevels(c.depthLevels)
+
+	ob := &connector.Orderbook{
+		ExchangeID: connector.Binance,
+		Symbol:     book.symbol,
+		Canonical:  extractCanonical(book.symbol),
+		Timestamp:  ts,
+		SequenceID: book.LastUpdateId(),
+		IsSnapshot: true, // Full top-of-book from the local book, not the raw diff
+		Bids:       toConnectorLevels(bids),
+		Asks:       toConnectorLevels(asks),
+	}
+
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+
+	c.EmitOrderbook(ob)
 }
 
 // buildStreamNames builds the combined stream URL parameter
@@ -428,7 +537,17 @@ func (c *BinanceConnector) buildStreamNames() string {
 	return result
 }
 
-// parseLevels converts string arrays to PriceLevel slice
+// toConnectorLevels converts local book levels to connector levels
+func toConnectorLevels(levels []PriceLevel) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, len(levels))
+	for i, l := range levels {
+		result[i] = connector.PriceLevel{Price: l.Price, Quantity: l.Quantity}
+	}
+	return result
+}
+
+// parseLevels converts string arrays to PriceLevel slice. REST depth is
+// already sorted best-first on both sides.
 func parseLevels(data [][]string) []connector.PriceLevel {
 	levels := make([]connector.PriceLevel, 0, len(data))
 	for _, item := range data {
@@ -444,12 +563,6 @@ func parseLevels(data [][]string) []connector.PriceLevel {
 			})
 		}
 	}
-
-	// Sort bids descending, asks ascending
-	sort.Slice(levels, func(i, j int) bool {
-		return levels[i].Price > levels[j].Price
-	})
-
 	return levels
 }
 
@@ -469,7 +582,7 @@ func toLower(s string) string {
 // This is used for Phase 1 spread discovery before WebSocket connection
 func (c *BinanceConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
 	// Use 24hr ticker endpoint to get volume data as well
-	url := fmt.Sprintf("%s/fapi/v1/ticker/24hr", restBaseURL)
+	url := fmt.Sprintf("%s/fapi/v1/ticker/24hr", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -541,7 +654,7 @@ func (c *BinanceConnector) FetchPriceTickers(ctx context.Context) ([]connector.P
 // FetchBookTickers fetches current best bid/ask for all symbols via REST API
 // More detailed than FetchPriceTickers, includes bid/ask spreads
 func (c *BinanceConnector) FetchBookTickers(ctx context.Context) ([]connector.PriceTicker, error) {
-	url := fmt.Sprintf("%s/fapi/v1/ticker/bookTicker", restBaseURL)
+	url := fmt.Sprintf("%s/fapi/v1/ticker/bookTicker", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -602,7 +715,7 @@ func (c *BinanceConnector) FetchBookTickers(ctx context.Context) ([]connector.Pr
 // For unauthenticated access, we return basic asset info from exchangeInfo
 func (c *BinanceConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetInfo, error) {
 	// Fetch from exchangeInfo to get list of assets
-	url := fmt.Sprintf("%s/fapi/v1/exchangeInfo", restBaseURL)
+	url := fmt.Sprintf("%s/fapi/v1/exchangeInfo", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -672,3 +785,93 @@ func extractCanonical(symbol string) string {
 	}
 	return symbol
 }
+
+// FetchOpenInterest fetches the open interest of a contract in base units
+func (c *BinanceConnector) FetchOpenInterest(ctx context.Context, symbol string) (*connector.OpenInterest, error) {
+	url := fmt.Sprintf("%s/fapi/v1/openInterest?symbol=%s", c.RestURL(), symbol)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var oi OpenInterest
+	if err := json.NewDecoder(resp.Body).Decode(&oi); err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseFloat(oi.OpenInterest, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse open interest: %w", err)
+	}
+
+	return &connector.OpenInterest{
+		ExchangeID:   connector.Binance,
+		Symbol:       symbol,
+		OpenInterest: value,
+		Timestamp:    time.UnixMilli(oi.Time),
+	}, nil
+}
+
+// FetchBookTicker fetches the best bid/ask of one symbol via REST API
+func (c *BinanceConnector) FetchBookTicker(ctx context.Context, symbol string) (*connector.Orderbook, error) {
+	url := fmt.Sprintf("%s/fapi/v1/ticker/bookTicker?symbol=%s", c.RestURL(), symbol)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Symbol   string `json:"symbol"`
+		BidPrice string `json:"bidPrice"`
+		AskPrice string `json:"askPrice"`
+		BidQty   string `json:"bidQty"`
+		AskQty   string `json:"askQty"`
+		Time     int64  `json:"time"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	bidPrice, _ := strconv.ParseFloat(data.BidPrice, 64)
+	askPrice, _ := strconv.ParseFloat(data.AskPrice, 64)
+	bidQty, _ := strconv.ParseFloat(data.BidQty, 64)
+	askQty, _ := strconv.ParseFloat(data.AskQty, 64)
+
+	ob := &connector.Orderbook{
+		ExchangeID: connector.Binance,
+		Symbol:     symbol,
+		Canonical:  extractCanonical(symbol),
+		Bids:       []connector.PriceLevel{{Price: bidPrice, Quantity: bidQty}},
+		Asks:       []connector.PriceLevel{{Price: askPrice, Quantity: askQty}},
+		BestBid:    bidPrice,
+		BestAsk:    askPrice,
+		Timestamp:  time.UnixMilli(data.Time),
+		IsSnapshot: true,
+		ReceivedAt: time.Now(),
+	}
+	if bidPrice > 0 && askPrice > 0 {
+		ob.SpreadBps = (askPrice - bidPrice) / bidPrice * 10000
+	}
+	return ob, nil
+}
@@ -246,26 +246,16 @@ func (c *Client) handleDepthUpdate(event *WSDepthEvent) {
 	}
 	c.obMu.Unlock()
 
-	if !ob.initialized {
-		// Need to fetch snapshot first
+	switch ob.ApplyUpdate(event) {
+	case DepthNeedsSnapshot:
 		go c.initializeOrderbook(context.Background(), event.Symbol)
-		return
-	}
-
-	if !ob.ApplyUpdate(event) {
-		// Sequence gap, need to resync
+	case DepthGap:
 		log.Warn().Str("symbol", event.Symbol).Msg("Orderbook sequence gap, resyncing")
 		go c.initializeOrderbook(context.Background(), event.Symbol)
 	}
 }
 
 func (c *Client) initializeOrderbook(ctx context.Context, symbol string) {
-	snapshot, err := c.Rest.FetchDepth(ctx, symbol, 100)
-	if err != nil {
-		log.Error().Err(err).Str("symbol", symbol).Msg("Failed to fetch orderbook snapshot")
-		return
-	}
-
 	c.obMu.Lock()
 	ob, exists := c.orderbooks[symbol]
 	if !exists {
@@ -274,7 +264,18 @@ func (c *Client) initializeOrderbook(ctx context.Context, symbol string) {
 	}
 	c.obMu.Unlock()
 
-	ob.InitializeFromSnapshot(snapshot)
+	snapshot, err := c.Rest.FetchDepth(ctx, symbol, 1000)
+	if err != nil {
+		// Reset so the next diff requests another snapshot
+		ob.Reset()
+		log.Error().Err(err).Str("symbol", symbol).Msg("Failed to fetch orderbook snapshot")
+		return
+	}
+
+	if !ob.InitializeFromSnapshot(snapshot) {
+		log.Warn().Str("symbol", symbol).Msg("Orderbook snapshot older than buffered diffs, resyncing")
+		return
+	}
 	log.Debug().Str("symbol", symbol).Msg("Orderbook initialized from snapshot")
 }
 
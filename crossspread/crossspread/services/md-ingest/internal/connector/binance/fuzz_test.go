@@ -0,0 +1,45 @@
+package binance
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary frames through the market data stream
+// decoder. Depth diffs are applied to a book already synced from a
+// snapshot, so sequence checks and level updates run on every input.
+// The connector's own handleMessage is not fuzzed directly: unsynced diffs
+// make it fetch REST snapshots.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1695716059516,"T":1695716059514,"s":"BTCUSDT","U":101,"u":105,"pu":100,"b":[["26000.50","1.200"],["25999.90","0"]],"a":[["26001.00","0.700"]]}}`))
+	f.Add([]byte(`{"e":"depthUpdate","E":1,"s":"BTCUSDT","U":90,"u":120,"pu":100,"b":[["1"],[]],"a":null}`))
+	f.Add([]byte(`{"stream":"btcusdt@trade","data":{"e":"trade","E":1,"s":"BTCUSDT","t":1,"p":"26000.5","q":"0.01","T":1,"m":true}}`))
+	f.Add([]byte(`{"stream":"btcusdt@markPrice@1s","data":{"e":"markPriceUpdate","E":1,"s":"BTCUSDT","p":"26000.1","i":"26000","P":"26000","r":"0.0001","T":1695744000000}}`))
+	f.Add([]byte(`{"stream":"btcusdt@kline_1m","data":{"e":"kline","E":1,"s":"BTCUSDT","k":{"t":1,"T":2,"s":"BTCUSDT","i":"1m","o":"1","c":"2","h":"3","l":"0.5","v":"10","x":false}}}`))
+	f.Add([]byte(`{"stream":"!miniTicker@arr","data":[{"e":"24hrMiniTicker","s":"BTCUSDT","c":"26000"}]}`))
+	f.Add([]byte(`{"result":null,"id":1}`))
+
+	snapshot := &DepthResponse{
+		LastUpdateId: 100,
+		Bids:         [][]string{{"26000.00", "2.5"}, {"25999.90", "1"}, {"1"}},
+		Asks:         [][]string{{"26001.00", "3"}, {}},
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		book := NewOrderbookManager("BTCUSDT")
+		book.InitializeFromSnapshot(snapshot)
+
+		stream := NewMarketDataStream(&MarketDataHandler{
+			OnTrade: func(*WSTradeEvent) {},
+			OnDepth: func(event *WSDepthEvent) {
+				ParseDepthLevels(event.Bids)
+				ParseDepthLevels(event.Asks)
+				if book.ApplyUpdate(event) == DepthApplied {
+					book.GetBestBidAsk()
+					book.GetTopLevels(20)
+				}
+			},
+			OnMarkPrice:  func(*WSMarkPriceEvent) {},
+			OnKline:      func(*WSKlineEvent) {},
+			OnMiniTicker: func(*WSMiniTickerEvent) {},
+		})
+		stream.handleMessage(data)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -285,14 +286,36 @@ type PriceLevel struct {
 // Orderbook Manager (for maintaining local orderbook)
 // =============================================================================
 
-// OrderbookManager maintains a local copy of the orderbook
+// maxBufferedDepthEvents bounds the diffs held while a snapshot is in flight
+// (100s of a @depth@100ms stream). The oldest are dropped first; they are the
+// ones the snapshot is most likely to already cover.
+const maxBufferedDepthEvents = 1000
+
+// DepthSyncState is the outcome of feeding a diff to an OrderbookManager
+type DepthSyncState int
+
+const (
+	DepthApplied       DepthSyncState = iota // Applied, or older than the book and dropped
+	DepthBuffered                            // Held until the pending snapshot arrives
+	DepthNeedsSnapshot                       // Held; the caller must fetch a snapshot
+	DepthGap                                 // Sequence broken; book reset, the caller must fetch a snapshot
+)
+
+// OrderbookManager maintains a local copy of the orderbook following the
+// documented futures sync procedure: buffer @depth diffs, load a REST
+// snapshot, drop diffs with u < lastUpdateId, require the first applied diff
+// to have U <= lastUpdateId <= u, then require each diff's pu to equal the
+// previous diff's u.
 type OrderbookManager struct {
 	symbol       string
 	lastUpdateId int64
 	bids         map[string]float64 // price -> quantity
 	asks         map[string]float64 // price -> quantity
 	mu           sync.RWMutex
-	initialized  bool
+	initialized  bool            // Snapshot loaded
+	synced       bool            // First diff after the snapshot validated
+	pending      bool            // Snapshot requested, diffs are being buffered
+	buffer       []*WSDepthEvent // Diffs received before the snapshot
 }
 
 // NewOrderbookManager creates a new orderbook manager
@@ -304,13 +327,48 @@ func NewOrderbookManager(symbol string) *OrderbookManager {
 	}
 }
 
-// InitializeFromSnapshot initializes the orderbook from a REST snapshot
-func (o *OrderbookManager) InitializeFromSnapshot(snapshot *DepthResponse) {
+// Initialized returns true once a snapshot has been loaded and the buffered
+// diffs replayed onto it
+func (o *OrderbookManager) Initialized() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.initialized
+}
+
+// LastUpdateId returns the update ID the book reflects
+func (o *OrderbookManager) LastUpdateId() int64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lastUpdateId
+}
+
+// Reset discards the book and any buffered diffs. The next diff requests a
+// new snapshot.
+func (o *OrderbookManager) Reset() {
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	o.reset()
+}
 
+func (o *OrderbookManager) reset() {
 	o.bids = make(map[string]float64)
 	o.asks = make(map[string]float64)
+	o.lastUpdateId = 0
+	o.initialized = false
+	o.synced = false
+	o.pending = false
+	o.buffer = nil
+}
+
+// InitializeFromSnapshot loads a REST snapshot and replays the diffs buffered
+// while it was fetched. Returns false if the buffered diffs don't connect to
+// the snapshot, in which case the book is reset.
+func (o *OrderbookManager) InitializeFromSnapshot(snapshot *DepthResponse) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	buffered := o.buffer
+	o.reset()
 
 	for _, bid := range snapshot.Bids {
 		if len(bid) >= 2 {
@@ -332,21 +390,64 @@ func (o *OrderbookManager) InitializeFromSnapshot(snapshot *DepthResponse) {
 
 	o.lastUpdateId = snapshot.LastUpdateId
 	o.initialized = true
+
+	for _, event := range buffered {
+		if !o.apply(event) {
+			o.reset()
+			return false
+		}
+	}
+	return true
 }
 
-// ApplyUpdate applies a depth update to the orderbook
-func (o *OrderbookManager) ApplyUpdate(event *WSDepthEvent) bool {
+// ApplyUpdate applies a depth update to the orderbook, or buffers it until
+// a snapshot is loaded
+func (o *OrderbookManager) ApplyUpdate(event *WSDepthEvent) DepthSyncState {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	// Validate update sequence
 	if !o.initialized {
-		return false
+		return o.bufferEvent(event)
+	}
+
+	if !o.apply(event) {
+		o.reset()
+		o.bufferEvent(event)
+		return DepthGap
 	}
+	return DepthApplied
+}
 
-	// First update after snapshot
-	if event.PrevFinalId != o.lastUpdateId && event.FirstUpdateId > o.lastUpdateId+1 {
-		return false // Need to resync
+// bufferEvent holds a diff until the snapshot arrives
+func (o *OrderbookManager) bufferEvent(event *WSDepthEvent) DepthSyncState {
+	if len(o.buffer) >= maxBufferedDepthEvents {
+		o.buffer = o.buffer[1:]
+	}
+	o.buffer = append(o.buffer, event)
+
+	if o.pending {
+		return DepthBuffered
+	}
+	o.pending = true
+	return DepthNeedsSnapshot
+}
+
+// apply validates a diff against the book's sequence and applies it.
+// Returns false on a gap.
+func (o *OrderbookManager) apply(event *WSDepthEvent) bool {
+	// Already reflected in the snapshot
+	if event.FinalUpdateId < o.lastUpdateId {
+		return true
+	}
+
+	if !o.synced {
+		// First diff must straddle the snapshot
+		if event.FirstUpdateId > o.lastUpdateId {
+			return false
+		}
+		o.synced = true
+	} else if event.PrevFinalId != o.lastUpdateId {
+		return false
 	}
 
 	// Apply bid updates
@@ -425,23 +526,8 @@ func (o *OrderbookManager) GetTopLevels(n int) (bids, asks []PriceLevel) {
 		askSlice = append(askSlice, PriceLevel{Price: price, Quantity: qty})
 	}
 
-	// Sort bids descending
-	for i := 0; i < len(bidSlice)-1; i++ {
-		for j := i + 1; j < len(bidSlice); j++ {
-			if bidSlice[i].Price < bidSlice[j].Price {
-				bidSlice[i], bidSlice[j] = bidSlice[j], bidSlice[i]
-			}
-		}
-	}
-
-	// Sort asks ascending
-	for i := 0; i < len(askSlice)-1; i++ {
-		for j := i + 1; j < len(askSlice); j++ {
-			if askSlice[i].Price > askSlice[j].Price {
-				askSlice[i], askSlice[j] = askSlice[j], askSlice[i]
-			}
-		}
-	}
+	sort.Slice(bidSlice, func(i, j int) bool { return bidSlice[i].Price > bidSlice[j].Price })
+	sort.Slice(askSlice, func(i, j int) bool { return askSlice[i].Price < askSlice[j].Price })
 
 	// Return top N
 	if len(bidSlice) > n {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +27,7 @@ type BingXConnector struct {
 	*connector.BaseConnector
 	conn          *websocket.Conn
 	subscriptions map[string]bool
+	rest          *RESTClient // Public market data only
 	mu            sync.RWMutex
 	done          chan struct{}
 
@@ -48,6 +50,7 @@ func NewBingXConnector(symbols []string, depthLevels int) *BingXConnector {
 	c := &BingXConnector{
 		BaseConnector: connector.NewBaseConnector(config),
 		subscriptions: make(map[string]bool),
+		rest:          NewRESTClient(RESTClientConfig{}),
 		done:          make(chan struct{}),
 	}
 
@@ -77,11 +80,7 @@ func (c *BingXConnector) GetClient() *Client {
 func (c *BingXConnector) Connect(ctx context.Context) error {
 	log.Info().Str("url", wsBaseURL).Msg("Connecting to BingX WebSocket")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, wsBaseURL, nil)
+	conn, err := c.Dial(ctx, wsBaseURL, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
@@ -97,8 +96,8 @@ func (c *BingXConnector) Connect(ctx context.Context) error {
 		}
 	}
 
-	go c.readLoop()
-	go c.pingLoop()
+	c.Go(c.readLoop)
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -285,6 +284,7 @@ func (c *BingXConnector) FetchFundingRates(ctx context.Context) ([]connector.Fun
 		Code int `json:"code"`
 		Data []struct {
 			Symbol          string `json:"symbol"`
+			MarkPrice       string `json:"markPrice"`
 			LastFundingRate string `json:"lastFundingRate"`
 			NextFundingTime int64  `json:"nextFundingTime"`
 		} `json:"data"`
@@ -297,10 +297,12 @@ func (c *BingXConnector) FetchFundingRates(ctx context.Context) ([]connector.Fun
 	var rates []connector.FundingRate
 	for _, d := range result.Data {
 		rate, _ := strconv.ParseFloat(d.LastFundingRate, 64)
+		markPrice, _ := strconv.ParseFloat(d.MarkPrice, 64)
 		rates = append(rates, connector.FundingRate{
 			ExchangeID:           connector.BingX,
 			Symbol:               d.Symbol,
 			FundingRate:          rate,
+			MarkPrice:            markPrice,
 			NextFundingTime:      time.UnixMilli(d.NextFundingTime),
 			FundingIntervalHours: 8,
 			Timestamp:            time.Now(),
@@ -311,6 +313,7 @@ func (c *BingXConnector) FetchFundingRates(ctx context.Context) ([]connector.Fun
 }
 
 func (c *BingXConnector) readLoop() {
+	defer c.Recover("readLoop")
 	defer c.SetConnected(false)
 
 	for {
@@ -318,7 +321,7 @@ func (c *BingXConnector) readLoop() {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("websocket read error: %w", err))
 				return
@@ -612,6 +615,51 @@ func (c *BingXConnector) GetOpenOrders(ctx context.Context, symbol string) ([]*O
 	return c.client.GetOpenOrders(ctx, symbol)
 }
 
+// FetchAccountConfig reads a symbol's leverage and margin type (requires
+// credentials). BingX has no position mode endpoint, so the mode is taken
+// from the side of open positions and left empty when there are none.
+func (c *BingXConnector) FetchAccountConfig(ctx context.Context, symbol string) (*connector.AccountConfig, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("client not initialized with credentials")
+	}
+
+	leverage, err := c.client.REST.GetLeverage(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("get leverage: %w", err)
+	}
+	marginType, err := c.client.REST.GetMarginType(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("get margin type: %w", err)
+	}
+
+	config := &connector.AccountConfig{
+		ExchangeID:    connector.BingX,
+		Symbol:        symbol,
+		LongLeverage:  float64(leverage.LongLeverage),
+		ShortLeverage: float64(leverage.ShortLeverage),
+	}
+	switch strings.ToUpper(marginType.MarginType) {
+	case "CROSSED", "CROSS":
+		config.MarginMode = connector.MarginModeCross
+	case "ISOLATED":
+		config.MarginMode = connector.MarginModeIsolated
+	}
+
+	positions, err := c.client.REST.GetPositions(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("get positions: %w", err)
+	}
+	for _, p := range positions {
+		switch strings.ToUpper(p.PositionSide) {
+		case "LONG", "SHORT":
+			config.PositionMode = connector.PositionModeHedge
+		case "BOTH":
+			config.PositionMode = connector.PositionModeOneWay
+		}
+	}
+	return config, nil
+}
+
 // SetLeverage sets leverage for a symbol (requires credentials)
 func (c *BingXConnector) SetLeverage(ctx context.Context, symbol string, leverage int) error {
 	if c.client == nil || c.client.Trading == nil {
@@ -654,3 +702,23 @@ func (c *BingXConnector) InitTrading(handler *TradingHandler) error {
 	c.client.InitTrading()
 	return nil
 }
+
+// FetchOpenInterest fetches the open interest of a contract. BingX reports
+// the position amount in quote currency.
+func (c *BingXConnector) FetchOpenInterest(ctx context.Context, symbol string) (*connector.OpenInterest, error) {
+	oi, err := c.rest.GetOpenInterest(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseFloat(oi.OpenInterest, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse open interest: %w", err)
+	}
+
+	return &connector.OpenInterest{
+		ExchangeID:   connector.BingX,
+		Symbol:       symbol,
+		OpenInterest: value,
+		Timestamp:    time.UnixMilli(oi.Time),
+	}, nil
+}
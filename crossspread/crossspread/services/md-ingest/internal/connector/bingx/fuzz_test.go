@@ -0,0 +1,18 @@
+package bingx
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary (already decompressed) frames through
+// the public WebSocket decoder. Any panic is a bug.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"code":0,"dataType":"BTC-USDT@depth20","data":{"bids":[["26000.5","1.2"],["26000.0","3.4"]],"asks":[["26001.0","0.7"]],"T":1695716059516}}`))
+	f.Add([]byte(`{"code":0,"dataType":"ETH-USDT@depth20","data":{"bids":[["1"]],"asks":[[]],"T":0}}`))
+	f.Add([]byte(`{"id":"1","code":80015,"msg":"dataType not support","dataType":""}`))
+	f.Add([]byte(`{"dataType":"@","data":null}`))
+	f.Add([]byte(`Pong`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewBingXConnector([]string{"BTC-USDT"}, 20)
+		c.handleMessage(data)
+	})
+}
@@ -26,7 +26,10 @@ type BitgetConnector struct {
 	*connector.BaseConnector
 	conn          *websocket.Conn
 	subscriptions map[string]bool
+	rest          *RESTClient        // Public market data only
+	aggregation   map[string]float64 // Price bucket per symbol, merged here
 	mu            sync.RWMutex
+	writeMu       sync.Mutex // One writer at a time on conn
 	done          chan struct{}
 }
 
@@ -45,6 +48,8 @@ func NewBitgetConnector(symbols []string, depthLevels int) *BitgetConnector {
 	c := &BitgetConnector{
 		BaseConnector: connector.NewBaseConnector(config),
 		subscriptions: make(map[string]bool),
+		rest:          NewRESTClient(RESTClientConfig{}),
+		aggregation:   make(map[string]float64),
 		done:          make(chan struct{}),
 	}
 
@@ -59,11 +64,7 @@ func NewBitgetConnector(symbols []string, depthLevels int) *BitgetConnector {
 func (c *BitgetConnector) Connect(ctx context.Context) error {
 	log.Info().Str("url", wsBaseURL).Msg("Connecting to Bitget WebSocket")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, wsBaseURL, nil)
+	conn, err := c.Dial(ctx, wsBaseURL, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
@@ -77,8 +78,8 @@ func (c *BitgetConnector) Connect(ctx context.Context) error {
 		log.Error().Err(err).Msg("Failed to subscribe")
 	}
 
-	go c.readLoop()
-	go c.pingLoop()
+	c.Go(c.readLoop)
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -101,7 +102,26 @@ func (c *BitgetConnector) subscribeAll() error {
 		"args": args,
 	}
 
-	return c.conn.WriteJSON(msg)
+	return c.writeJSON(msg)
+}
+
+func (c *BitgetConnector) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return c.conn.WriteJSON(v)
+}
+
+// ping sends Bitget's text ping, serialized with other writes
+func (c *BitgetConnector) ping() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, []byte("ping"))
 }
 
 // Disconnect closes the WebSocket connection
@@ -134,6 +154,35 @@ func (c *BitgetConnector) Unsubscribe(symbols []string) error {
 	return nil
 }
 
+// SetAggregation sets the price bucket of a symbol's book. Bitget only
+// merges depth over REST, so WebSocket books are merged here. Zero restores
+// full precision.
+func (c *BitgetConnector) SetAggregation(symbol string, step float64) error {
+	if step < 0 {
+		return fmt.Errorf("bitget: aggregation step must not be negative, got %v", step)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if step == 0 {
+		delete(c.aggregation, symbol)
+	} else {
+		c.aggregation[symbol] = step
+	}
+	return nil
+}
+
+// Aggregations returns the price bucket of every aggregated symbol
+func (c *BitgetConnector) Aggregations() map[string]float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make(map[string]float64, len(c.aggregation))
+	for symbol, step := range c.aggregation {
+		result[symbol] = step
+	}
+	return result
+}
+
 // FetchInstruments fetches all USDT perpetual futures
 func (c *BitgetConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
 	url := fmt.Sprintf("%s/api/v2/mix/market/contracts?productType=USDT-FUTURES", restBaseURL)
@@ -170,7 +219,8 @@ func (c *BitgetConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 
 	var instruments []connector.Instrument
 	for _, s := range result.Data {
-		if s.SymbolStatus != "normal" {
+		status, listed := instrumentStatus(s.SymbolStatus)
+		if !listed {
 			continue
 		}
 
@@ -201,6 +251,7 @@ func (c *BitgetConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 			ContractSize:   multiplier,
 			TakerFee:       takerFee,
 			MakerFee:       makerFee,
+			Status:         status,
 		}
 		instruments = append(instruments, inst)
 	}
@@ -208,6 +259,20 @@ func (c *BitgetConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 	return instruments, nil
 }
 
+// instrumentStatus maps a Bitget symbolStatus to a connector status.
+// Contracts that are off or not yet open are not listed.
+func instrumentStatus(symbolStatus string) (string, bool) {
+	switch symbolStatus {
+	case "normal":
+		return connector.StatusTrading, true
+	case "limit_open":
+		return connector.StatusLimitOpen, true
+	case "maintain", "restrictedAPI":
+		return connector.StatusMaintenance, true
+	}
+	return "", false
+}
+
 // FetchOrderbookSnapshot fetches orderbook via REST API
 func (c *BitgetConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
 	url := fmt.Sprintf("%s/api/v2/mix/market/depth?symbol=%s&productType=USDT-FUTURES&limit=%d", restBaseURL, symbol, depth)
@@ -258,6 +323,11 @@ func (c *BitgetConnector) FetchOrderbookSnapshot(ctx context.Context, symbol str
 		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
 	}
 
+	c.mu.RLock()
+	step := c.aggregation[symbol]
+	c.mu.RUnlock()
+	connector.AggregateBook(ob, step)
+
 	return ob, nil
 }
 
@@ -281,6 +351,7 @@ func (c *BitgetConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 		Data []struct {
 			Symbol      string `json:"symbol"`
 			FundingRate string `json:"fundingRate"`
+			MarkPrice   string `json:"markPrice"`
 		} `json:"data"`
 	}
 
@@ -291,15 +362,17 @@ func (c *BitgetConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 	var rates []connector.FundingRate
 	for _, d := range result.Data {
 		rate, _ := strconv.ParseFloat(d.FundingRate, 64)
-		
+		markPrice, _ := strconv.ParseFloat(d.MarkPrice, 64)
+
 		// Extract canonical from symbol (e.g., BTCUSDT -> BTC)
 		canonical := extractCanonical(d.Symbol)
-		
+
 		rates = append(rates, connector.FundingRate{
 			ExchangeID:           connector.Bitget,
 			Symbol:               d.Symbol,
 			Canonical:            canonical,
 			FundingRate:          rate,
+			MarkPrice:            markPrice,
 			FundingIntervalHours: 8,
 			Timestamp:            time.Now(),
 		})
@@ -309,6 +382,7 @@ func (c *BitgetConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 }
 
 func (c *BitgetConnector) readLoop() {
+	defer c.Recover("readLoop")
 	defer c.SetConnected(false)
 
 	for {
@@ -316,7 +390,7 @@ func (c *BitgetConnector) readLoop() {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("websocket read error: %w", err))
 				return
@@ -335,7 +409,7 @@ func (c *BitgetConnector) pingLoop() {
 		case <-c.done:
 			return
 		case <-ticker.C:
-			if err := c.conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+			if err := c.ping(); err != nil {
 				log.Error().Err(err).Msg("Failed to send ping")
 			}
 		}
@@ -356,9 +430,10 @@ func (c *BitgetConnector) handleMessage(message []byte) {
 			InstId   string `json:"instId"`
 		} `json:"arg"`
 		Data []struct {
-			Bids [][]string `json:"bids"`
-			Asks [][]string `json:"asks"`
-			Ts   string     `json:"ts"`
+			Bids     [][]string `json:"bids"`
+			Asks     [][]string `json:"asks"`
+			Ts       string     `json:"ts"`
+			Checksum int64      `json:"checksum"` // Zero when the channel doesn't send one
 		} `json:"data"`
 	}
 
@@ -370,6 +445,13 @@ func (c *BitgetConnector) handleMessage(message []byte) {
 		return
 	}
 
+	// A book failing its checksum is dropped and the symbol resubscribed
+	// for a fresh snapshot
+	if !connector.VerifyBookChecksum(connector.Bitget, msg.Data[0].Bids, msg.Data[0].Asks, msg.Data[0].Checksum) {
+		c.resubscribe(msg.Arg.InstId, msg.Data[0].Checksum)
+		return
+	}
+
 	ts, _ := strconv.ParseInt(msg.Data[0].Ts, 10, 64)
 
 	ob := &connector.Orderbook{
@@ -393,9 +475,37 @@ func (c *BitgetConnector) handleMessage(message []byte) {
 		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
 	}
 
+	c.mu.RLock()
+	step := c.aggregation[msg.Arg.InstId]
+	c.mu.RUnlock()
+	connector.AggregateBook(ob, step)
+
 	c.EmitOrderbook(ob)
 }
 
+// resubscribe unsubscribes and resubscribes a symbol whose book failed its
+// checksum; Bitget answers the subscription with a new snapshot
+func (c *BitgetConnector) resubscribe(symbol string, checksum int64) {
+	log.Warn().
+		Str("exchange", string(connector.Bitget)).
+		Str("symbol", symbol).
+		Int64("checksum", checksum).
+		Msg("Orderbook checksum mismatch, resubscribing")
+	if c.conn == nil || !c.IsConnected() {
+		return // The next connection subscribes afresh
+	}
+	c.Go(func() {
+		defer c.Recover("resubscribe")
+		arg := map[string]string{"instType": "USDT-FUTURES", "channel": "books15", "instId": symbol}
+		for _, op := range []string{"unsubscribe", "subscribe"} {
+			if err := c.writeJSON(map[string]interface{}{"op": op, "args": []map[string]string{arg}}); err != nil {
+				c.EmitError(fmt.Errorf("resubscribe %s: %w", symbol, err))
+				return
+			}
+		}
+	})
+}
+
 func parseStringLevels(data [][]string) []connector.PriceLevel {
 	levels := make([]connector.PriceLevel, 0, len(data))
 	for _, item := range data {
@@ -468,6 +578,8 @@ func (c *BitgetConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pr
 			LastPrice string `json:"lastPr"`
 			BidPrice  string `json:"bidPr"`
 			AskPrice  string `json:"askPr"`
+			BidSize   string `json:"bidSz"`
+			AskSize   string `json:"askSz"`
 			Volume24h string `json:"baseVolume"`
 		} `json:"data"`
 	}
@@ -481,6 +593,8 @@ func (c *BitgetConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pr
 		price, _ := strconv.ParseFloat(t.LastPrice, 64)
 		bidPrice, _ := strconv.ParseFloat(t.BidPrice, 64)
 		askPrice, _ := strconv.ParseFloat(t.AskPrice, 64)
+		bidSize, _ := strconv.ParseFloat(t.BidSize, 64)
+		askSize, _ := strconv.ParseFloat(t.AskSize, 64)
 		volume, _ := strconv.ParseFloat(t.Volume24h, 64)
 
 		tickers = append(tickers, connector.PriceTicker{
@@ -490,6 +604,8 @@ func (c *BitgetConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pr
 			Price:      price,
 			BidPrice:   bidPrice,
 			AskPrice:   askPrice,
+			BidSize:    bidSize,
+			AskSize:    askSize,
 			Volume24h:  volume,
 			Timestamp:  time.Now(),
 		})
@@ -565,3 +681,54 @@ func (c *BitgetConnector) FetchAssetInfo(ctx context.Context) ([]connector.Asset
 	log.Debug().Int("count", len(assets)).Msg("Fetched Bitget asset info from contracts")
 	return assets, nil
 }
+
+// FetchKlines fetches the most recent candles of a USDT-margined contract
+func (c *BitgetConnector) FetchKlines(ctx context.Context, symbol string, interval time.Duration, limit int) ([]connector.Kline, error) {
+	granularity, ok := klineGranularities[interval]
+	if !ok {
+		return nil, fmt.Errorf("bitget: unsupported kline interval %s", interval)
+	}
+
+	candles, err := c.rest.GetCandles(ctx, symbol, ProductTypeUSDTFutures, granularity, 0, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	klines := make([]connector.Kline, 0, len(candles))
+	for _, candle := range candles {
+		if candle.Ts == 0 {
+			continue
+		}
+		open, _ := strconv.ParseFloat(candle.Open, 64)
+		high, _ := strconv.ParseFloat(candle.High, 64)
+		low, _ := strconv.ParseFloat(candle.Low, 64)
+		closePrice, _ := strconv.ParseFloat(candle.Close, 64)
+		volume, _ := strconv.ParseFloat(candle.BaseVolume, 64)
+		quoteVolume, _ := strconv.ParseFloat(candle.QuoteVolume, 64)
+
+		klines = append(klines, connector.Kline{
+			ExchangeID:  connector.Bitget,
+			Symbol:      symbol,
+			Start:       time.UnixMilli(int64(candle.Ts)),
+			Interval:    interval,
+			Open:        open,
+			High:        high,
+			Low:         low,
+			Close:       closePrice,
+			Volume:      volume,
+			QuoteVolume: quoteVolume,
+		})
+	}
+	sort.Slice(klines, func(i, j int) bool { return klines[i].Start.Before(klines[j].Start) })
+	return klines, nil
+}
+
+// klineGranularities maps candle intervals to Bitget granularities
+var klineGranularities = map[time.Duration]string{
+	time.Minute:      Granularity1m,
+	5 * time.Minute:  Granularity5m,
+	15 * time.Minute: Granularity15m,
+	time.Hour:        Granularity1H,
+	4 * time.Hour:    Granularity4H,
+	24 * time.Hour:   Granularity1D,
+}
@@ -0,0 +1,78 @@
+package bitget
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Resubscribing after a checksum mismatch writes from its own goroutine
+// while the ping loop writes too; run with -race
+func TestResubscribeWhilePinging(t *testing.T) {
+	ops := make(chan string, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var m struct {
+				Op string `json:"op"`
+			}
+			if json.Unmarshal(msg, &m) == nil && m.Op != "" {
+				ops <- m.Op
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := NewBitgetConnector([]string{"BTCUSDT"}, 15)
+	c.conn = conn
+	c.SetConnected(true)
+
+	stop := make(chan struct{})
+	pinging := make(chan struct{})
+	go func() {
+		defer close(pinging)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := c.ping(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	c.resubscribe("BTCUSDT", 123)
+	for _, want := range []string{"unsubscribe", "subscribe"} {
+		select {
+		case op := <-ops:
+			if op != want {
+				t.Fatalf("sent %q, want %q", op, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s sent", want)
+		}
+	}
+	close(stop)
+	<-pinging
+}
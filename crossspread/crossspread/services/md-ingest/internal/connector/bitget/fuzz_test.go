@@ -0,0 +1,56 @@
+package bitget
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// FuzzHandleMessage feeds arbitrary frames through the public WebSocket
+// decoder. Any panic is a bug: the read loop must survive malformed input.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"action":"snapshot","arg":{"instType":"USDT-FUTURES","channel":"books15","instId":"BTCUSDT"},"data":[{"asks":[["27000.5","8.760"],["27001.0","0.400"]],"bids":[["27000.0","2.710"],["26999.5","1.460"]],"checksum":0,"seq":123,"ts":"1695716059516"}],"ts":1695716059516}`))
+	f.Add([]byte(`{"action":"snapshot","arg":{"instType":"USDT-FUTURES","channel":"books15","instId":"ETHUSDT"},"data":[{"asks":[["1"]],"bids":[[]],"ts":"x"}]}`))
+	f.Add([]byte(`{"event":"subscribe","arg":{"instType":"USDT-FUTURES","channel":"books15","instId":"BTCUSDT"}}`))
+	f.Add([]byte(`{"event":"error","code":30001,"msg":"instType:USDT-FUTURES,channel:books15,instId:FOOUSDT doesn't exist"}`))
+	f.Add([]byte(`{"action":"snapshot","arg":{"instType":"USDT-FUTURES","channel":"books15","instId":"BTCUSDT"},"data":[{"asks":[["3366.8","9"],["3368","8"]],"bids":[["3366.1","7"],["3366","6"]],"ts":"1695710946294","checksum":-1881014294}]}`))
+	f.Add([]byte(`{"action":"snapshot","arg":{"instType":"USDT-FUTURES","channel":"books15","instId":"BTCUSDT"},"data":[{"asks":[["3366.8","9"]],"bids":[["3366.1","7"]],"ts":"1695710946294","checksum":-1}]}`))
+	f.Add([]byte(`pong`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewBitgetConnector([]string{"BTCUSDT", "ETHUSDT"}, 15)
+		c.handleMessage(data)
+	})
+}
+
+// FuzzOrderBookLevel covers the array-format level decoder ([price, size]).
+func FuzzOrderBookLevel(f *testing.F) {
+	f.Add([]byte(`["27000.5","8.760"]`))
+	f.Add([]byte(`["27000.5"]`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var level OrderBookLevel
+		if err := json.Unmarshal(data, &level); err != nil {
+			return
+		}
+		if _, err := json.Marshal(level); err != nil {
+			t.Fatalf("re-marshal decoded level: %v", err)
+		}
+	})
+}
+
+// FuzzCandlestick covers the REST and WebSocket candle decoders
+// ([ts, open, high, low, close, baseVol, quoteVol]).
+func FuzzCandlestick(f *testing.F) {
+	f.Add([]byte(`["1695835800000","26210.5","26210.5","26194.5","26194.5","26.26","687897.63"]`))
+	f.Add([]byte(`["x","1","2","3","4","5","6"]`))
+	f.Add([]byte(`["1695835800000","1"]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var candle Candlestick
+		_ = json.Unmarshal(data, &candle)
+		var wsCandle WSCandleData
+		_ = json.Unmarshal(data, &wsCandle)
+	})
+}
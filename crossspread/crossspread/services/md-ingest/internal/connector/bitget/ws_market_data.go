@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -45,6 +47,9 @@ type MarketDataWSClient struct {
 	subscriptions map[string]WSSubscribeArg // channel+instID -> arg
 	subMu         sync.RWMutex
 
+	books   map[string]*connector.ChecksumBook // channel+instID -> local book checked against pushed checksums
+	booksMu sync.Mutex
+
 	writeMu sync.Mutex
 	done    chan struct{}
 	wg      sync.WaitGroup
@@ -95,6 +100,7 @@ func NewMarketDataWSClient(cfg MarketDataWSConfig) *MarketDataWSClient {
 		handler:       cfg.Handler,
 		instType:      cfg.InstType,
 		subscriptions: make(map[string]WSSubscribeArg),
+		books:         make(map[string]*connector.ChecksumBook),
 		done:          make(chan struct{}),
 		reconnect:     true,
 		reconnectWait: cfg.ReconnectWait,
@@ -326,6 +332,10 @@ func (c *MarketDataWSClient) processChannelData(arg WSSubscribeArg, action strin
 			return
 		}
 		for i := range books {
+			if !c.verifyBook(arg, action, &books[i]) {
+				c.resubscribeBook(arg)
+				return
+			}
 			c.handler.OnOrderBook(arg.InstID, action, &books[i])
 		}
 
@@ -412,6 +422,42 @@ func (c *MarketDataWSClient) sendUnsubscribe(arg WSSubscribeArg) error {
 	return c.conn.WriteJSON(req)
 }
 
+// verifyBook merges a book push into the channel's local book and checks
+// the checksum over its top 25 levels. A snapshot replaces the local book;
+// an update without one fails so the channel is resubscribed.
+func (c *MarketDataWSClient) verifyBook(arg WSSubscribeArg, action string, b *WSOrderBookData) bool {
+	bids, asks := checksumLevels(b.Bids), checksumLevels(b.Asks)
+	key := subscriptionKey(arg.Channel, arg.InstID)
+
+	c.booksMu.Lock()
+	defer c.booksMu.Unlock()
+
+	book := c.books[key]
+	if action == "snapshot" {
+		book = connector.NewChecksumBook(bids, asks)
+		c.books[key] = book
+	} else if book != nil {
+		book.Apply(bids, asks)
+	}
+	if book == nil || !book.Verify(connector.Bitget, b.Checksum) {
+		delete(c.books, key)
+		return false
+	}
+	return true
+}
+
+// resubscribeBook resubscribes a book that failed its checksum for a new
+// snapshot
+func (c *MarketDataWSClient) resubscribeBook(arg WSSubscribeArg) {
+	c.handler.OnError(connector.ResubscribeBook(arg.InstID,
+		func() error { return c.sendUnsubscribe(arg) },
+		func() error { return c.sendSubscribe(arg) }))
+}
+
+func checksumLevels(levels []OrderBookLevel) [][]string {
+	return connector.LevelStrings(levels, func(l OrderBookLevel) (string, string) { return l.Price, l.Size })
+}
+
 // subscriptionKey generates a unique key for subscription
 func subscriptionKey(channel, instID string) string {
 	return channel + ":" + instID
@@ -491,6 +537,10 @@ func (c *MarketDataWSClient) UnsubscribeOrderBook(instID string, channel string)
 	delete(c.subscriptions, key)
 	c.subMu.Unlock()
 
+	c.booksMu.Lock()
+	delete(c.books, key)
+	c.booksMu.Unlock()
+
 	return c.sendUnsubscribe(arg)
 }
 
@@ -0,0 +1,671 @@
+package bitmart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	bitmartWsURL   = "wss://openapi-ws-v2.bitmart.com/api?protocol=1.1"
+	bitmartRestURL = "https://api-cloud-v2.bitmart.com"
+
+	productTypePerpetual = 1
+)
+
+// BitMartConnector implements the Connector interface for BitMart USDT-M perpetuals
+// BitMart quotes book and trade volume in contracts; quantities are converted to
+// base units using each contract's size
+type BitMartConnector struct {
+	*connector.BaseConnector
+	conn          *websocket.Conn
+	writeMu       sync.Mutex
+	symbols       []string
+	depth         int
+	mu            sync.RWMutex
+	contractSizes map[string]float64
+	done          chan struct{}
+}
+
+// NewBitMartConnector creates a new BitMart connector
+func NewBitMartConnector(symbols []string, depth int) *BitMartConnector {
+	config := connector.ConnectorConfig{
+		ExchangeID:     connector.BitMart,
+		WsURL:          bitmartWsURL,
+		RestURL:        bitmartRestURL,
+		Symbols:        symbols,
+		DepthLevels:    depth,
+		ReconnectDelay: 5 * time.Second,
+		PingInterval:   15 * time.Second,
+	}
+
+	// BitMart offers depth5, depth20 and depth50 channels
+	switch {
+	case depth <= 5:
+		depth = 5
+	case depth <= 20:
+		depth = 20
+	default:
+		depth = 50
+	}
+
+	return &BitMartConnector{
+		BaseConnector: connector.NewBaseConnector(config),
+		symbols:       symbols,
+		depth:         depth,
+		contractSizes: make(map[string]float64),
+		done:          make(chan struct{}),
+	}
+}
+
+// Connect establishes WebSocket connection to BitMart
+func (c *BitMartConnector) Connect(ctx context.Context) error {
+	c.mu.RLock()
+	symbols := c.symbols
+	c.mu.RUnlock()
+
+	return c.connect(ctx, symbols)
+}
+
+// ConnectForSymbols establishes WebSocket connection for specific symbols only
+// Used for Phase 2 selective subscription after spread discovery
+func (c *BitMartConnector) ConnectForSymbols(ctx context.Context, symbols []string) error {
+	c.mu.Lock()
+	c.symbols = symbols
+	c.mu.Unlock()
+
+	if err := c.connect(ctx, symbols); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("symbols", len(symbols)).
+		Msg("Connected to BitMart WebSocket (selective)")
+
+	return nil
+}
+
+func (c *BitMartConnector) connect(ctx context.Context, symbols []string) error {
+	// Contract sizes are needed to convert volumes before the first update arrives
+	c.mu.RLock()
+	haveSizes := len(c.contractSizes) > 0
+	c.mu.RUnlock()
+	if !haveSizes {
+		if _, err := c.FetchInstruments(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to load BitMart contract sizes, volumes will be in contracts")
+		}
+	}
+
+	conn, err := c.Dial(ctx, bitmartWsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to BitMart WebSocket: %w", err)
+	}
+
+	c.conn = conn
+	c.done = make(chan struct{})
+	c.SetConnected(true)
+
+	if err := c.Subscribe(symbols); err != nil {
+		return err
+	}
+
+	c.Go(c.readMessages)
+	c.Go(c.pingLoop)
+
+	return nil
+}
+
+// Disconnect closes the WebSocket connection
+func (c *BitMartConnector) Disconnect() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.SetConnected(false)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe subscribes to depth, trade and funding channels for symbols and
+// keeps them for reconnects
+func (c *BitMartConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.send("subscribe", c.topics(symbols))
+}
+
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
+func (c *BitMartConnector) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.send("unsubscribe", c.topics(symbols))
+}
+
+func (c *BitMartConnector) topics(symbols []string) []string {
+	args := make([]string, 0, len(symbols)*3)
+	for _, symbol := range symbols {
+		args = append(args,
+			fmt.Sprintf("futures/depth%d:%s", c.depth, symbol),
+			fmt.Sprintf("futures/trade:%s", symbol),
+			fmt.Sprintf("futures/fundingRate:%s", symbol),
+		)
+	}
+	return args
+}
+
+func (c *BitMartConnector) send(action string, args []string) error {
+	if c.conn == nil {
+		return fmt.Errorf("bitmart: not connected")
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.conn.WriteJSON(map[string]interface{}{
+		"action": action,
+		"args":   args,
+	})
+}
+
+// contractDetail is an entry of /contract/public/details (all contracts in one call)
+type contractDetail struct {
+	Symbol               string `json:"symbol"`
+	ProductType          int    `json:"product_type"`
+	BaseCurrency         string `json:"base_currency"`
+	QuoteCurrency        string `json:"quote_currency"`
+	LastPrice            string `json:"last_price"`
+	Volume24h            string `json:"volume_24h"`
+	Turnover24h          string `json:"turnover_24h"`
+	IndexPrice           string `json:"index_price"`
+	ContractSize         string `json:"contract_size"`
+	PricePrecision       string `json:"price_precision"`
+	VolPrecision         string `json:"vol_precision"`
+	MinVolume            string `json:"min_volume"`
+	FundingRate          string `json:"funding_rate"`
+	FundingIntervalHours int    `json:"funding_interval_hours"`
+	Status               string `json:"status"`
+}
+
+func (c *BitMartConnector) fetchDetails(ctx context.Context) ([]contractDetail, error) {
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Symbols []contractDetail `json:"symbols"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, bitmartRestURL+"/contract/public/details", &result); err != nil {
+		return nil, err
+	}
+	if result.Code != 1000 {
+		return nil, fmt.Errorf("API error: code %d: %s", result.Code, result.Message)
+	}
+
+	details := make([]contractDetail, 0, len(result.Data.Symbols))
+	for _, d := range result.Data.Symbols {
+		if d.ProductType == productTypePerpetual && d.QuoteCurrency == "USDT" {
+			details = append(details, d)
+		}
+	}
+	return details, nil
+}
+
+// FetchInstruments fetches all USDT-M perpetual contracts
+func (c *BitMartConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
+	details, err := c.fetchDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := make([]connector.Instrument, 0, len(details))
+	sizes := make(map[string]float64, len(details))
+	for _, d := range details {
+		contractSize, _ := strconv.ParseFloat(d.ContractSize, 64)
+		tickSize, _ := strconv.ParseFloat(d.PricePrecision, 64)
+		volStep, _ := strconv.ParseFloat(d.VolPrecision, 64)
+		minVol, _ := strconv.ParseFloat(d.MinVolume, 64)
+		lastPrice, _ := strconv.ParseFloat(d.LastPrice, 64)
+
+		sizes[d.Symbol] = contractSize
+
+		instruments = append(instruments, connector.Instrument{
+			ExchangeID:     connector.BitMart,
+			Symbol:         d.Symbol,
+			Canonical:      normalizeSymbol(d.Symbol),
+			BaseAsset:      d.BaseCurrency,
+			QuoteAsset:     d.QuoteCurrency,
+			InstrumentType: "perpetual",
+			ContractSize:   contractSize,
+			TickSize:       tickSize,
+			LotSize:        volStep * contractSize,
+			MinNotional:    minVol * contractSize * lastPrice,
+			MakerFee:       0.0002, // 0.02%
+			TakerFee:       0.0006, // 0.06%
+
+			FundingIntervalHours: d.FundingIntervalHours,
+		})
+	}
+
+	c.mu.Lock()
+	c.contractSizes = sizes
+	c.mu.Unlock()
+
+	return instruments, nil
+}
+
+// FetchOrderbookSnapshot fetches current orderbook via REST
+func (c *BitMartConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
+	var result struct {
+		Code int `json:"code"`
+		Data struct {
+			Asks      [][]string `json:"asks"` // [price, vol, total]
+			Bids      [][]string `json:"bids"`
+			Timestamp int64      `json:"timestamp"`
+			Symbol    string     `json:"symbol"`
+		} `json:"data"`
+	}
+	url := fmt.Sprintf("%s/contract/public/depth?symbol=%s", bitmartRestURL, symbol)
+	if err := getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+	if result.Code != 1000 {
+		return nil, fmt.Errorf("API error: code %d", result.Code)
+	}
+
+	size := c.contractSize(symbol)
+	ob := &connector.Orderbook{
+		ExchangeID: connector.BitMart,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseArrayLevels(result.Data.Bids, size),
+		Asks:       parseArrayLevels(result.Data.Asks, size),
+		Timestamp:  time.UnixMilli(result.Data.Timestamp),
+		IsSnapshot: true,
+	}
+	if depth > 0 {
+		if len(ob.Bids) > depth {
+			ob.Bids = ob.Bids[:depth]
+		}
+		if len(ob.Asks) > depth {
+			ob.Asks = ob.Asks[:depth]
+		}
+	}
+	updateSpread(ob)
+
+	return ob, nil
+}
+
+// FetchFundingRates fetches current funding rates for all contracts
+func (c *BitMartConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
+	details, err := c.fetchDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rates := make([]connector.FundingRate, 0, len(details))
+	for _, d := range details {
+		rate, _ := strconv.ParseFloat(d.FundingRate, 64)
+		interval := d.FundingIntervalHours
+		if interval <= 0 {
+			interval = 8
+		}
+
+		rates = append(rates, connector.FundingRate{
+			ExchangeID:           connector.BitMart,
+			Symbol:               d.Symbol,
+			Canonical:            normalizeSymbol(d.Symbol),
+			FundingRate:          rate,
+			NextFundingTime:      now.UTC().Truncate(time.Duration(interval) * time.Hour).Add(time.Duration(interval) * time.Hour),
+			FundingIntervalHours: interval,
+			Timestamp:            now,
+		})
+	}
+
+	return rates, nil
+}
+
+// FetchPriceTickers fetches current prices for all contracts in a single call
+// The details endpoint has no bid/ask, so spreads fall back to the last price
+func (c *BitMartConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
+	details, err := c.fetchDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make([]connector.PriceTicker, 0, len(details))
+	for _, d := range details {
+		if d.Status != "" && d.Status != "Trading" {
+			continue
+		}
+
+		price, _ := strconv.ParseFloat(d.LastPrice, 64)
+		turnover, _ := strconv.ParseFloat(d.Turnover24h, 64)
+		if price <= 0 {
+			continue
+		}
+
+		tickers = append(tickers, connector.PriceTicker{
+			ExchangeID: connector.BitMart,
+			Symbol:     d.Symbol,
+			Canonical:  normalizeSymbol(d.Symbol),
+			Price:      price,
+			Volume24h:  turnover,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	log.Info().Int("count", len(tickers)).Msg("Fetched BitMart price tickers")
+	return tickers, nil
+}
+
+// FetchAssetInfo fetches deposit/withdrawal status for assets
+func (c *BitMartConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetInfo, error) {
+	var result struct {
+		Code int `json:"code"`
+		Data struct {
+			Currencies []struct {
+				Currency        string `json:"currency"`
+				Network         string `json:"network"`
+				WithdrawEnabled bool   `json:"withdraw_enabled"`
+				DepositEnabled  bool   `json:"deposit_enabled"`
+			} `json:"currencies"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, "https://api-cloud.bitmart.com/account/v1/currencies", &result); err != nil {
+		return nil, err
+	}
+
+	assetMap := make(map[string]*connector.AssetInfo)
+	for _, cur := range result.Data.Currencies {
+		// Currencies are listed per network, e.g. USDT-TRC20
+		asset := strings.SplitN(cur.Currency, "-", 2)[0]
+		info, ok := assetMap[asset]
+		if !ok {
+			info = &connector.AssetInfo{
+				ExchangeID: connector.BitMart,
+				Asset:      asset,
+				Timestamp:  time.Now(),
+			}
+			assetMap[asset] = info
+		}
+		info.DepositEnabled = info.DepositEnabled || cur.DepositEnabled
+		info.WithdrawEnabled = info.WithdrawEnabled || cur.WithdrawEnabled
+		if cur.Network != "" {
+			info.Networks = append(info.Networks, cur.Network)
+		}
+	}
+
+	infos := make([]connector.AssetInfo, 0, len(assetMap))
+	for _, info := range assetMap {
+		infos = append(infos, *info)
+	}
+
+	log.Info().Int("count", len(infos)).Msg("Fetched BitMart asset info")
+	return infos, nil
+}
+
+func (c *BitMartConnector) readMessages() {
+	defer c.Recover("readMessages")
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+			_, message, err := c.ReadMessage(c.conn)
+			if err != nil {
+				c.EmitError(fmt.Errorf("read error: %w", err))
+				c.SetConnected(false)
+				return
+			}
+
+			c.processMessage(message)
+		}
+	}
+}
+
+func (c *BitMartConnector) processMessage(data []byte) {
+	var msg struct {
+		Group        string          `json:"group"`
+		Data         json.RawMessage `json:"data"`
+		Action       string          `json:"action"`
+		Success      *bool           `json:"success"`
+		ErrorMessage string          `json:"error"`
+	}
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	if msg.Success != nil && !*msg.Success {
+		c.EmitError(fmt.Errorf("bitmart %s failed: %s", msg.Action, msg.ErrorMessage))
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(msg.Group, "futures/depth"):
+		c.processDepth(msg.Data)
+	case strings.HasPrefix(msg.Group, "futures/trade:"):
+		c.processTrades(msg.Data)
+	case strings.HasPrefix(msg.Group, "futures/fundingRate:"):
+		c.processFunding(msg.Data)
+	}
+}
+
+type wsLevel struct {
+	Price string `json:"price"`
+	Vol   string `json:"vol"`
+}
+
+// processDepth handles full depth pushes: {symbol, asks:[{price,vol}], bids:[...], ms_t}
+func (c *BitMartConnector) processDepth(data json.RawMessage) {
+	var depth struct {
+		Symbol string    `json:"symbol"`
+		Asks   []wsLevel `json:"asks"`
+		Bids   []wsLevel `json:"bids"`
+		MsT    int64     `json:"ms_t"`
+	}
+	if err := json.Unmarshal(data, &depth); err != nil {
+		log.Error().Err(err).Msg("Failed to parse BitMart depth")
+		return
+	}
+
+	size := c.contractSize(depth.Symbol)
+	ob := &connector.Orderbook{
+		ExchangeID: connector.BitMart,
+		Symbol:     depth.Symbol,
+		Canonical:  normalizeSymbol(depth.Symbol),
+		Bids:       parseObjectLevels(depth.Bids, size),
+		Asks:       parseObjectLevels(depth.Asks, size),
+		Timestamp:  time.UnixMilli(depth.MsT),
+		IsSnapshot: true,
+	}
+
+	sort.Slice(ob.Bids, func(i, j int) bool { return ob.Bids[i].Price > ob.Bids[j].Price })
+	sort.Slice(ob.Asks, func(i, j int) bool { return ob.Asks[i].Price < ob.Asks[j].Price })
+
+	updateSpread(ob)
+	c.EmitOrderbook(ob)
+}
+
+// processTrades handles trade pushes; m=true means the buyer was the maker
+func (c *BitMartConnector) processTrades(data json.RawMessage) {
+	var trades []struct {
+		Symbol    string `json:"symbol"`
+		Price     string `json:"deal_price"`
+		Vol       string `json:"deal_vol"`
+		CreatedAt string `json:"created_at"`
+		M         bool   `json:"m"`
+	}
+	if err := json.Unmarshal(data, &trades); err != nil {
+		return
+	}
+
+	for _, t := range trades {
+		price, _ := strconv.ParseFloat(t.Price, 64)
+		vol, _ := strconv.ParseFloat(t.Vol, 64)
+		ts, err := time.Parse(time.RFC3339Nano, t.CreatedAt)
+		if err != nil {
+			ts = time.Now()
+		}
+
+		side := "buy"
+		if t.M {
+			side = "sell"
+		}
+
+		c.EmitTrade(&connector.Trade{
+			ExchangeID: connector.BitMart,
+			Symbol:     t.Symbol,
+			Canonical:  normalizeSymbol(t.Symbol),
+			Price:      price,
+			Quantity:   vol * c.contractSize(t.Symbol),
+			Side:       side,
+			Timestamp:  ts,
+		})
+	}
+}
+
+// processFunding handles funding pushes: {symbol, fundingRate, fundingTime, funding_interval_hours}
+func (c *BitMartConnector) processFunding(data json.RawMessage) {
+	var fr struct {
+		Symbol          string `json:"symbol"`
+		FundingRate     string `json:"fundingRate"`
+		FundingTime     int64  `json:"fundingTime"`
+		IntervalHours   int    `json:"funding_interval_hours"`
+		NextFundingTime int64  `json:"nextFundingTime"`
+	}
+	if err := json.Unmarshal(data, &fr); err != nil {
+		return
+	}
+
+	rate, _ := strconv.ParseFloat(fr.FundingRate, 64)
+	interval := fr.IntervalHours
+	if interval <= 0 {
+		interval = 8
+	}
+
+	c.EmitFunding(&connector.FundingRate{
+		ExchangeID:           connector.BitMart,
+		Symbol:               fr.Symbol,
+		Canonical:            normalizeSymbol(fr.Symbol),
+		FundingRate:          rate,
+		NextFundingTime:      time.UnixMilli(fr.FundingTime),
+		FundingIntervalHours: interval,
+		Timestamp:            time.Now(),
+	})
+}
+
+func (c *BitMartConnector) pingLoop() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"ping"}`))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.EmitError(fmt.Errorf("ping error: %w", err))
+			}
+		}
+	}
+}
+
+func (c *BitMartConnector) contractSize(symbol string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if size, ok := c.contractSizes[symbol]; ok && size > 0 {
+		return size
+	}
+	return 1
+}
+
+// =============================================================================
+// Helper Functions
+// =============================================================================
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func parseArrayLevels(levels [][]string, contractSize float64) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(level[0], 64)
+		vol, _ := strconv.ParseFloat(level[1], 64)
+		if vol > 0 {
+			result = append(result, connector.PriceLevel{Price: price, Quantity: vol * contractSize})
+		}
+	}
+	return result
+}
+
+func parseObjectLevels(levels []wsLevel, contractSize float64) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		price, _ := strconv.ParseFloat(level.Price, 64)
+		vol, _ := strconv.ParseFloat(level.Vol, 64)
+		if vol > 0 {
+			result = append(result, connector.PriceLevel{Price: price, Quantity: vol * contractSize})
+		}
+	}
+	return result
+}
+
+func updateSpread(ob *connector.Orderbook) {
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+}
+
+// normalizeSymbol converts BTCUSDT to the canonical base asset BTC
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSuffix(symbol, "USDT"))
+}
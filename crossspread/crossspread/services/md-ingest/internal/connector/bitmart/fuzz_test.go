@@ -0,0 +1,18 @@
+package bitmart
+
+import "testing"
+
+// FuzzProcessMessage feeds arbitrary frames through the public WebSocket
+// decoder, covering the depth, trade and funding groups.
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"group":"futures/depth20:BTCUSDT","data":{"symbol":"BTCUSDT","asks":[{"price":"26001.0","vol":"120"}],"bids":[{"price":"26000.5","vol":"7"},{"price":"26000","vol":"0"}],"ms_t":1695716059516}}`))
+	f.Add([]byte(`{"group":"futures/trade:BTCUSDT","data":[{"symbol":"BTCUSDT","deal_price":"26000.5","deal_vol":"3","created_at":"2023-09-26T08:14:19.516Z","m":true}]}`))
+	f.Add([]byte(`{"group":"futures/fundingRate:BTCUSDT","data":{"symbol":"BTCUSDT","fundingRate":"0.0001","fundingTime":1695744000000,"funding_interval_hours":8,"nextFundingTime":1695772800000}}`))
+	f.Add([]byte(`{"action":"subscribe","group":"futures/depth20:FOOUSDT","success":false,"error":"symbol not found"}`))
+	f.Add([]byte(`{"group":"futures/depth20:BTCUSDT","data":null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewBitMartConnector([]string{"BTCUSDT"}, 20)
+		c.processMessage(data)
+	})
+}
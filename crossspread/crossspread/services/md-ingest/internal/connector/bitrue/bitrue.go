@@ -0,0 +1,575 @@
+package bitrue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	bitrueWsURL   = "wss://fmarket-ws.bitrue.com/kline-api/ws"
+	bitrueRestURL = "https://fapi.bitrue.com"
+
+	// Max concurrent per-contract REST calls (tickers have no bulk endpoint)
+	restConcurrency = 8
+)
+
+// BitrueConnector implements the Connector interface for Bitrue USDT-M perpetuals
+// REST symbols use the E-BTC-USDT format; WS channels use e_btcusdt.
+// Quote-only: there is no Bitrue trading client yet.
+type BitrueConnector struct {
+	*connector.BaseConnector
+	conn          *websocket.Conn
+	writeMu       sync.Mutex
+	symbols       []string
+	depth         int
+	mu            sync.RWMutex
+	contractSizes map[string]float64
+	done          chan struct{}
+}
+
+// NewBitrueConnector creates a new Bitrue connector
+func NewBitrueConnector(symbols []string, depth int) *BitrueConnector {
+	config := connector.ConnectorConfig{
+		ExchangeID:     connector.Bitrue,
+		WsURL:          bitrueWsURL,
+		RestURL:        bitrueRestURL,
+		Symbols:        symbols,
+		DepthLevels:    depth,
+		ReconnectDelay: 5 * time.Second,
+		PingInterval:   20 * time.Second,
+	}
+
+	return &BitrueConnector{
+		BaseConnector: connector.NewBaseConnector(config),
+		symbols:       symbols,
+		depth:         depth,
+		contractSizes: make(map[string]float64),
+		done:          make(chan struct{}),
+	}
+}
+
+// Connect establishes WebSocket connection to Bitrue
+func (c *BitrueConnector) Connect(ctx context.Context) error {
+	c.mu.RLock()
+	symbols := c.symbols
+	c.mu.RUnlock()
+
+	return c.connect(ctx, symbols)
+}
+
+// ConnectForSymbols establishes WebSocket connection for specific symbols only
+// Used for Phase 2 selective subscription after spread discovery
+func (c *BitrueConnector) ConnectForSymbols(ctx context.Context, symbols []string) error {
+	c.mu.Lock()
+	c.symbols = symbols
+	c.mu.Unlock()
+
+	if err := c.connect(ctx, symbols); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("symbols", len(symbols)).
+		Msg("Connected to Bitrue WebSocket (selective)")
+
+	return nil
+}
+
+func (c *BitrueConnector) connect(ctx context.Context, symbols []string) error {
+	c.mu.RLock()
+	haveSizes := len(c.contractSizes) > 0
+	c.mu.RUnlock()
+	if !haveSizes {
+		if _, err := c.FetchInstruments(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to load Bitrue contract sizes, volumes will be in contracts")
+		}
+	}
+
+	conn, err := c.Dial(ctx, bitrueWsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Bitrue WebSocket: %w", err)
+	}
+
+	c.conn = conn
+	c.done = make(chan struct{})
+	c.SetConnected(true)
+
+	if err := c.Subscribe(symbols); err != nil {
+		return err
+	}
+
+	c.Go(c.readMessages)
+
+	return nil
+}
+
+// Disconnect closes the WebSocket connection
+func (c *BitrueConnector) Disconnect() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.SetConnected(false)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe subscribes to depth and trade channels for symbols and
+// keeps them for reconnects
+func (c *BitrueConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.sendChannels("sub", symbols)
+}
+
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
+func (c *BitrueConnector) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.sendChannels("unsub", symbols)
+}
+
+func (c *BitrueConnector) sendChannels(event string, symbols []string) error {
+	if c.conn == nil {
+		return fmt.Errorf("bitrue: not connected")
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	for _, symbol := range symbols {
+		ws := wsSymbol(symbol)
+		for _, channel := range []string{
+			fmt.Sprintf("market_%s_depth_step0", ws),
+			fmt.Sprintf("market_%s_trade_ticker", ws),
+		} {
+			msg := map[string]interface{}{
+				"event": event,
+				"params": map[string]string{
+					"cb_id":   ws,
+					"channel": channel,
+				},
+			}
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return fmt.Errorf("failed to %s %s: %w", event, channel, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// FetchInstruments fetches all USDT-M perpetual contracts
+func (c *BitrueConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
+	var contracts []struct {
+		Symbol         string  `json:"symbol"`
+		PricePrecision int     `json:"pricePrecision"`
+		Multiplier     float64 `json:"multiplier"`
+		MinOrderVolume float64 `json:"minOrderVolume"`
+		MinOrderMoney  float64 `json:"minOrderMoney"`
+		MultiplierCoin string  `json:"multiplierCoin"`
+		Type           string  `json:"type"`
+		Status         int     `json:"status"`
+	}
+	if err := getJSON(ctx, bitrueRestURL+"/fapi/v1/contracts", &contracts); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]connector.Instrument, 0, len(contracts))
+	sizes := make(map[string]float64, len(contracts))
+	for _, ct := range contracts {
+		// E = USDT-margined perpetual; status 1 = trading
+		if ct.Type != "E" || ct.Status != 1 || !strings.HasSuffix(ct.Symbol, "-USDT") {
+			continue
+		}
+
+		sizes[ct.Symbol] = ct.Multiplier
+
+		instruments = append(instruments, connector.Instrument{
+			ExchangeID:     connector.Bitrue,
+			Symbol:         ct.Symbol,
+			Canonical:      normalizeSymbol(ct.Symbol),
+			BaseAsset:      strings.ToUpper(ct.MultiplierCoin),
+			QuoteAsset:     "USDT",
+			InstrumentType: "perpetual",
+			ContractSize:   ct.Multiplier,
+			TickSize:       precisionToStep(ct.PricePrecision),
+			LotSize:        ct.Multiplier,
+			MinNotional:    ct.MinOrderMoney,
+		})
+	}
+
+	c.mu.Lock()
+	c.contractSizes = sizes
+	c.mu.Unlock()
+
+	return instruments, nil
+}
+
+// FetchOrderbookSnapshot fetches current orderbook via REST
+func (c *BitrueConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
+	var result struct {
+		Asks [][]json.Number `json:"asks"`
+		Bids [][]json.Number `json:"bids"`
+		Time int64           `json:"time"`
+	}
+	url := fmt.Sprintf("%s/fapi/v1/depth?contractName=%s&limit=%d", bitrueRestURL, symbol, depth)
+	if err := getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+
+	size := c.contractSize(symbol)
+	ob := &connector.Orderbook{
+		ExchangeID: connector.Bitrue,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseLevels(result.Bids, size, depth),
+		Asks:       parseLevels(result.Asks, size, depth),
+		Timestamp:  time.UnixMilli(result.Time),
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+
+	return ob, nil
+}
+
+// FetchFundingRates is not supported: Bitrue does not publish funding rates
+// on its public futures API. Spreads on Bitrue are priced without funding.
+func (c *BitrueConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
+	return nil, nil
+}
+
+// FetchPriceTickers fetches BBO and volume per contract with bounded concurrency
+func (c *BitrueConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
+	instruments, err := c.FetchInstruments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		tickers = make([]connector.PriceTicker, 0, len(instruments))
+		sem     = make(chan struct{}, restConcurrency)
+	)
+
+	for _, inst := range instruments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var t struct {
+				Last string `json:"last"`
+				Buy  string `json:"buy"`
+				Sell string `json:"sell"`
+				Vol  string `json:"vol"`
+				Time int64  `json:"time"`
+			}
+			url := bitrueRestURL + "/fapi/v1/ticker?contractName=" + symbol
+			if err := getJSON(ctx, url, &t); err != nil {
+				log.Debug().Err(err).Str("symbol", symbol).Msg("Failed to fetch Bitrue ticker")
+				return
+			}
+
+			price, _ := strconv.ParseFloat(t.Last, 64)
+			bid, _ := strconv.ParseFloat(t.Buy, 64)
+			ask, _ := strconv.ParseFloat(t.Sell, 64)
+			vol, _ := strconv.ParseFloat(t.Vol, 64)
+			if price <= 0 {
+				return
+			}
+
+			mu.Lock()
+			tickers = append(tickers, connector.PriceTicker{
+				ExchangeID: connector.Bitrue,
+				Symbol:     symbol,
+				Canonical:  normalizeSymbol(symbol),
+				Price:      price,
+				BidPrice:   bid,
+				AskPrice:   ask,
+				Volume24h:  vol * c.contractSize(symbol) * price,
+				Timestamp:  time.UnixMilli(t.Time),
+			})
+			mu.Unlock()
+		}(inst.Symbol)
+	}
+	wg.Wait()
+
+	log.Info().Int("count", len(tickers)).Msg("Fetched Bitrue price tickers")
+	return tickers, nil
+}
+
+// FetchAssetInfo derives asset info from instruments; wallet status requires auth
+func (c *BitrueConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetInfo, error) {
+	instruments, err := c.FetchInstruments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	assetMap := make(map[string]bool)
+	for _, inst := range instruments {
+		assetMap[inst.BaseAsset] = true
+	}
+
+	infos := make([]connector.AssetInfo, 0, len(assetMap))
+	for asset := range assetMap {
+		infos = append(infos, connector.AssetInfo{
+			ExchangeID:      connector.Bitrue,
+			Asset:           asset,
+			DepositEnabled:  true,
+			WithdrawEnabled: true,
+			Timestamp:       time.Now(),
+		})
+	}
+
+	return infos, nil
+}
+
+func (c *BitrueConnector) readMessages() {
+	defer c.Recover("readMessages")
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+			_, message, err := c.ReadMessage(c.conn)
+			if err != nil {
+				c.EmitError(fmt.Errorf("read error: %w", err))
+				c.SetConnected(false)
+				return
+			}
+
+			// Bitrue sends gzip compressed messages
+			decompressed, err := gzipDecompress(message)
+			if err != nil {
+				c.processMessage(message) // Try uncompressed
+			} else {
+				c.processMessage(decompressed)
+			}
+		}
+	}
+}
+
+func (c *BitrueConnector) processMessage(data []byte) {
+	var msg struct {
+		Ping    int64           `json:"ping"`
+		Channel string          `json:"channel"`
+		Ts      int64           `json:"ts"`
+		Tick    json.RawMessage `json:"tick"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	if msg.Ping > 0 {
+		c.writeMu.Lock()
+		err := c.conn.WriteJSON(map[string]int64{"pong": msg.Ping})
+		c.writeMu.Unlock()
+		if err != nil {
+			c.EmitError(fmt.Errorf("pong error: %w", err))
+		}
+		return
+	}
+
+	if msg.Channel == "" || len(msg.Tick) == 0 {
+		return
+	}
+
+	symbol := c.symbolForChannel(msg.Channel)
+	if symbol == "" {
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(msg.Channel, "_depth_step0"):
+		c.processDepth(symbol, msg.Ts, msg.Tick)
+	case strings.HasSuffix(msg.Channel, "_trade_ticker"):
+		c.processTrades(symbol, msg.Tick)
+	}
+}
+
+// processDepth handles full-depth pushes: {buys: [[p, q]], asks: [[p, q]]}
+func (c *BitrueConnector) processDepth(symbol string, ts int64, data json.RawMessage) {
+	var tick struct {
+		Buys [][]json.Number `json:"buys"`
+		Asks [][]json.Number `json:"asks"`
+	}
+	if err := json.Unmarshal(data, &tick); err != nil {
+		log.Error().Err(err).Msg("Failed to parse Bitrue depth")
+		return
+	}
+
+	size := c.contractSize(symbol)
+	ob := &connector.Orderbook{
+		ExchangeID: connector.Bitrue,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseLevels(tick.Buys, size, c.depth),
+		Asks:       parseLevels(tick.Asks, size, c.depth),
+		Timestamp:  time.UnixMilli(ts),
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+	c.EmitOrderbook(ob)
+}
+
+func (c *BitrueConnector) processTrades(symbol string, data json.RawMessage) {
+	var tick struct {
+		Data []struct {
+			ID    int64       `json:"id"`
+			Price json.Number `json:"price"`
+			Vol   json.Number `json:"vol"`
+			Side  string      `json:"side"`
+			Ts    int64       `json:"ts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &tick); err != nil {
+		return
+	}
+
+	size := c.contractSize(symbol)
+	for _, t := range tick.Data {
+		price, _ := t.Price.Float64()
+		qty, _ := t.Vol.Float64()
+
+		c.EmitTrade(&connector.Trade{
+			ExchangeID: connector.Bitrue,
+			Symbol:     symbol,
+			Canonical:  normalizeSymbol(symbol),
+			TradeID:    strconv.FormatInt(t.ID, 10),
+			Price:      price,
+			Quantity:   qty * size,
+			Side:       strings.ToLower(t.Side),
+			Timestamp:  time.UnixMilli(t.Ts),
+		})
+	}
+}
+
+// symbolForChannel maps market_e_btcusdt_depth_step0 back to E-BTC-USDT
+func (c *BitrueConnector) symbolForChannel(channel string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, symbol := range c.symbols {
+		if strings.HasPrefix(channel, "market_"+wsSymbol(symbol)+"_") {
+			return symbol
+		}
+	}
+	return ""
+}
+
+func (c *BitrueConnector) contractSize(symbol string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if size, ok := c.contractSizes[symbol]; ok && size > 0 {
+		return size
+	}
+	return 1
+}
+
+// =============================================================================
+// Helper Functions
+// =============================================================================
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func gzipDecompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func parseLevels(levels [][]json.Number, contractSize float64, depth int) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		if depth > 0 && len(result) >= depth {
+			break
+		}
+		price, _ := level[0].Float64()
+		qty, _ := level[1].Float64()
+		if qty > 0 {
+			result = append(result, connector.PriceLevel{Price: price, Quantity: qty * contractSize})
+		}
+	}
+	return result
+}
+
+func updateSpread(ob *connector.Orderbook) {
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+}
+
+// precisionToStep converts a decimal precision (e.g. 2) to a step size (0.01)
+func precisionToStep(prec int) float64 {
+	step := 1.0
+	for i := 0; i < prec; i++ {
+		step /= 10
+	}
+	return step
+}
+
+// wsSymbol converts E-BTC-USDT to the WS channel form e_btcusdt
+func wsSymbol(symbol string) string {
+	parts := strings.SplitN(strings.ToLower(symbol), "-", 2)
+	if len(parts) != 2 {
+		return strings.ToLower(symbol)
+	}
+	return parts[0] + "_" + strings.ReplaceAll(parts[1], "-", "")
+}
+
+// normalizeSymbol converts E-BTC-USDT to the canonical base asset BTC
+func normalizeSymbol(symbol string) string {
+	s := strings.TrimPrefix(symbol, "E-")
+	return strings.TrimSuffix(s, "-USDT")
+}
@@ -0,0 +1,27 @@
+package bitrue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// FuzzProcessMessage feeds arbitrary (already gunzipped) frames through the
+// public WebSocket decoder, covering depth and trade channels.
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"channel":"market_e_btcusdt_depth_step0","ts":1695716059516,"tick":{"buys":[["26000.5","120"],[26000,7]],"asks":[["26001","3"]]}}`))
+	f.Add([]byte(`{"channel":"market_e_btcusdt_trade_ticker","ts":1695716059516,"tick":{"data":[{"id":1,"price":"26000.5","vol":"3","side":"BUY","ts":1695716059516}]}}`))
+	f.Add([]byte(`{"channel":"market_e_btcusdt_depth_step0","ts":1,"tick":{"buys":[["1"],[]],"asks":null}}`))
+	f.Add([]byte(`{"event_rep":"sub","channel":"market_e_foousdt_depth_step0","status":"error"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Pings are answered on the socket, which is not connected here.
+		var ping struct {
+			Ping int64 `json:"ping"`
+		}
+		if json.Unmarshal(data, &ping) == nil && ping.Ping > 0 {
+			t.Skip()
+		}
+		c := NewBitrueConnector([]string{"E-BTC-USDT"}, 20)
+		c.processMessage(data)
+	})
+}
@@ -0,0 +1,187 @@
+package connector
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Budget is an exchange's soft caps. Zero means no cap.
+type Budget struct {
+	// MaxSockets caps open sockets: a dial past it is refused, so a
+	// reconnect loop that leaks connections can't exhaust file descriptors
+	MaxSockets int `json:"max_sockets"`
+	// MaxGoroutines is alert-only: connector goroutines past it are logged
+	// and counted, never refused, since a refused read loop would stall the feed
+	MaxGoroutines int `json:"max_goroutines"`
+}
+
+// Resources a budget caps
+const (
+	ResourceSockets    = "sockets"
+	ResourceGoroutines = "goroutines"
+)
+
+// ErrSocketBudget is returned by Dial when an exchange already holds its
+// budget of open sockets
+var ErrSocketBudget = errors.New("socket budget exhausted")
+
+// Usage is what an exchange's connectors hold right now
+type Usage struct {
+	Exchange ExchangeID `json:"exchange"`
+	// Open WebSocket connections, after a successful handshake
+	Connections int64 `json:"connections"`
+	// Open sockets, each a file descriptor. More sockets than connections
+	// means handshakes in flight or sockets never closed after a failed one.
+	Sockets    int64    `json:"sockets"`
+	Goroutines int64    `json:"goroutines"` // Started with BaseConnector.Go and still running
+	Budget     Budget   `json:"budget"`
+	Over       []string `json:"over,omitempty"` // Resources past their cap
+}
+
+type venueUsage struct {
+	connections atomic.Int64
+	sockets     atomic.Int64
+	goroutines  atomic.Int64
+}
+
+// budgets holds the caps and live counts of every exchange. Counts cover
+// connectors dialing through BaseConnector.Dial; venues whose market data
+// clients dial on their own (CoinEx, MEXC, LBank) report no sockets.
+var budgets = struct {
+	sync.RWMutex
+	defaults  Budget
+	exchanges map[ExchangeID]Budget
+	usage     map[ExchangeID]*venueUsage
+}{
+	exchanges: make(map[ExchangeID]Budget),
+	usage:     make(map[ExchangeID]*venueUsage),
+}
+
+// SetDefaultBudget sets the caps of exchanges without their own
+func SetDefaultBudget(b Budget) {
+	budgets.Lock()
+	defer budgets.Unlock()
+	budgets.defaults = b
+}
+
+// SetBudget sets an exchange's caps
+func SetBudget(id ExchangeID, b Budget) {
+	budgets.Lock()
+	defer budgets.Unlock()
+	budgets.exchanges[id] = b
+}
+
+// BudgetFor returns an exchange's caps
+func BudgetFor(id ExchangeID) Budget {
+	budgets.RLock()
+	defer budgets.RUnlock()
+	if b, ok := budgets.exchanges[id]; ok {
+		return b
+	}
+	return budgets.defaults
+}
+
+func usageOf(id ExchangeID) *venueUsage {
+	budgets.RLock()
+	u := budgets.usage[id]
+	budgets.RUnlock()
+	if u != nil {
+		return u
+	}
+
+	budgets.Lock()
+	defer budgets.Unlock()
+	if u = budgets.usage[id]; u == nil {
+		u = &venueUsage{}
+		budgets.usage[id] = u
+	}
+	return u
+}
+
+// Usages returns the usage of every exchange that has held a socket or
+// goroutine, sorted by ID
+func Usages() []Usage {
+	budgets.RLock()
+	ids := make([]ExchangeID, 0, len(budgets.usage))
+	for id := range budgets.usage {
+		ids = append(ids, id)
+	}
+	budgets.RUnlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	result := make([]Usage, 0, len(ids))
+	for _, id := range ids {
+		u := usageOf(id)
+		usage := Usage{
+			Exchange:    id,
+			Connections: u.connections.Load(),
+			Sockets:     u.sockets.Load(),
+			Goroutines:  u.goroutines.Load(),
+			Budget:      BudgetFor(id),
+		}
+		if usage.Budget.MaxSockets > 0 && usage.Sockets > int64(usage.Budget.MaxSockets) {
+			usage.Over = append(usage.Over, ResourceSockets)
+		}
+		if usage.Budget.MaxGoroutines > 0 && usage.Goroutines > int64(usage.Budget.MaxGoroutines) {
+			usage.Over = append(usage.Over, ResourceGoroutines)
+		}
+		result = append(result, usage)
+	}
+	return result
+}
+
+// reserveSocket checks the socket budget before a dial
+func reserveSocket(id ExchangeID) error {
+	limit := BudgetFor(id).MaxSockets
+	if open := usageOf(id).sockets.Load(); limit > 0 && open >= int64(limit) {
+		metrics.ExchangeBudgetExceeded.WithLabelValues(string(id), ResourceSockets).Inc()
+		log.Warn().Str("exchange", string(id)).Int64("sockets", open).Int("budget", limit).Msg("Socket budget exhausted, dial refused")
+		return fmt.Errorf("%s: %w (%d open)", id, ErrSocketBudget, open)
+	}
+	return nil
+}
+
+// socketOpened and socketClosed track a dialed socket; connected marks its
+// WebSocket handshake done
+func socketOpened(id ExchangeID) {
+	metrics.ExchangeSockets.WithLabelValues(string(id)).Set(float64(usageOf(id).sockets.Add(1)))
+}
+
+func socketClosed(id ExchangeID, connected bool) {
+	u := usageOf(id)
+	metrics.ExchangeSockets.WithLabelValues(string(id)).Set(float64(u.sockets.Add(-1)))
+	if connected {
+		metrics.WSConnections.WithLabelValues(string(id)).Set(float64(u.connections.Add(-1)))
+	}
+}
+
+func socketConnected(id ExchangeID) {
+	metrics.WSConnections.WithLabelValues(string(id)).Set(float64(usageOf(id).connections.Add(1)))
+}
+
+// Go runs fn in a goroutine counted against the exchange's budget. Read,
+// ping and resync loops use it so leaked loops show up per venue.
+func (c *BaseConnector) Go(fn func()) {
+	id := c.config.ExchangeID
+	u := usageOf(id)
+	n := u.goroutines.Add(1)
+	metrics.ExchangeGoroutines.WithLabelValues(string(id)).Set(float64(n))
+	if limit := BudgetFor(id).MaxGoroutines; limit > 0 && n > int64(limit) {
+		metrics.ExchangeBudgetExceeded.WithLabelValues(string(id), ResourceGoroutines).Inc()
+		log.Warn().Str("exchange", string(id)).Int64("goroutines", n).Int("budget", limit).Msg("Connector goroutines over budget")
+	}
+
+	go func() {
+		defer func() {
+			metrics.ExchangeGoroutines.WithLabelValues(string(id)).Set(float64(u.goroutines.Add(-1)))
+		}()
+		fn()
+	}()
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/orderbook"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
@@ -24,12 +26,14 @@ const (
 // BybitConnector implements the Connector interface for Bybit
 type BybitConnector struct {
 	*connector.BaseConnector
-	conn       *websocket.Conn
-	symbols    []string
-	depth      int
-	mu         sync.RWMutex
-	orderbooks map[string]*connector.Orderbook
-	done       chan struct{}
+	conn    *websocket.Conn
+	symbols []string
+	depth   int
+	mu      sync.RWMutex
+	books   *orderbook.Engine // Local books from snapshot and delta messages
+	writeMu sync.Mutex        // One writer at a time on conn
+	rest    *RESTClient       // Public market data only
+	done    chan struct{}
 }
 
 // NewBybitConnector creates a new Bybit connector
@@ -44,22 +48,21 @@ func NewBybitConnector(symbols []string, depth int) *BybitConnector {
 		PingInterval:   20 * time.Second,
 	}
 
-	return &BybitConnector{
+	c := &BybitConnector{
 		BaseConnector: connector.NewBaseConnector(config),
 		symbols:       symbols,
 		depth:         depth,
-		orderbooks:    make(map[string]*connector.Orderbook),
+		books:         orderbook.NewEngine(orderbook.DefaultConfig()),
+		rest:          NewRESTClient(RESTClientConfig{}),
 		done:          make(chan struct{}),
 	}
+	c.books.SetResync(c.resync)
+	return c
 }
 
 // Connect establishes WebSocket connection to Bybit
 func (c *BybitConnector) Connect(ctx context.Context) error {
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, bybitWsURL, nil)
+	conn, err := c.Dial(ctx, c.WsURL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Bybit WebSocket: %w", err)
 	}
@@ -73,10 +76,10 @@ func (c *BybitConnector) Connect(ctx context.Context) error {
 	}
 
 	// Start message handler
-	go c.readMessages()
+	c.Go(c.readMessages)
 
 	// Start ping handler
-	go c.pingLoop()
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -88,11 +91,7 @@ func (c *BybitConnector) ConnectForSymbols(ctx context.Context, symbols []string
 	c.symbols = symbols
 	c.mu.Unlock()
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, bybitWsURL, nil)
+	conn, err := c.Dial(ctx, c.WsURL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Bybit WebSocket: %w", err)
 	}
@@ -106,10 +105,10 @@ func (c *BybitConnector) ConnectForSymbols(ctx context.Context, symbols []string
 	}
 
 	// Start message handler
-	go c.readMessages()
+	c.Go(c.readMessages)
 
 	// Start ping handler
-	go c.pingLoop()
+	c.Go(c.pingLoop)
 
 	log.Info().
 		Int("symbols", len(symbols)).
@@ -128,8 +127,13 @@ func (c *BybitConnector) Disconnect() error {
 	return nil
 }
 
-// Subscribe subscribes to orderbook updates for symbols
+// Subscribe subscribes to orderbook updates for symbols and
+// keeps them for reconnects
 func (c *BybitConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+
 	args := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
 		// Bybit uses format: orderbook.50.BTCUSDT
@@ -141,11 +145,20 @@ func (c *BybitConnector) Subscribe(symbols []string) error {
 		"args": args,
 	}
 
-	return c.conn.WriteJSON(msg)
+	return c.writeJSON(msg)
+}
+
+// Books returns the local books built from snapshot and delta messages
+func (c *BybitConnector) Books() *orderbook.Engine {
+	return c.books
 }
 
-// Unsubscribe removes subscriptions
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
 func (c *BybitConnector) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+
 	args := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
 		args = append(args, fmt.Sprintf("orderbook.%d.%s", c.depth, symbol))
@@ -156,12 +169,12 @@ func (c *BybitConnector) Unsubscribe(symbols []string) error {
 		"args": args,
 	}
 
-	return c.conn.WriteJSON(msg)
+	return c.writeJSON(msg)
 }
 
 // FetchInstruments fetches all available instruments
 func (c *BybitConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
-	url := fmt.Sprintf("%s/v5/market/instruments-info?category=linear", bybitRestURL)
+	url := fmt.Sprintf("%s/v5/market/instruments-info?category=linear", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -181,8 +194,12 @@ func (c *BybitConnector) FetchInstruments(ctx context.Context) ([]connector.Inst
 				Symbol       string `json:"symbol"`
 				BaseCoin     string `json:"baseCoin"`
 				QuoteCoin    string `json:"quoteCoin"`
+				SettleCoin   string `json:"settleCoin"`
 				ContractType string `json:"contractType"`
-				PriceFilter  struct {
+				Status       string `json:"status"`
+				// Minutes between funding settlements
+				FundingInterval int `json:"fundingInterval"`
+				PriceFilter     struct {
 					TickSize string `json:"tickSize"`
 				} `json:"priceFilter"`
 				LotSizeFilter struct {
@@ -199,6 +216,10 @@ func (c *BybitConnector) FetchInstruments(ctx context.Context) ([]connector.Inst
 
 	instruments := make([]connector.Instrument, 0, len(result.Result.List))
 	for _, item := range result.Result.List {
+		status, listed := instrumentStatus(item.Status)
+		if !listed {
+			continue
+		}
 		tickSize, _ := strconv.ParseFloat(item.PriceFilter.TickSize, 64)
 		lotSize, _ := strconv.ParseFloat(item.LotSizeFilter.QtyStep, 64)
 		minQty, _ := strconv.ParseFloat(item.LotSizeFilter.MinOrderQty, 64)
@@ -206,24 +227,40 @@ func (c *BybitConnector) FetchInstruments(ctx context.Context) ([]connector.Inst
 		instruments = append(instruments, connector.Instrument{
 			ExchangeID:     connector.Bybit,
 			Symbol:         item.Symbol,
-			Canonical:      normalizeSymbol(item.BaseCoin),
+			Canonical:      connector.NewPair(item.BaseCoin, item.QuoteCoin, item.SettleCoin).Canonical(),
 			BaseAsset:      item.BaseCoin,
 			QuoteAsset:     item.QuoteCoin,
+			SettleAsset:    item.SettleCoin,
 			InstrumentType: "perpetual",
 			TickSize:       tickSize,
 			LotSize:        lotSize,
 			MinNotional:    minQty * tickSize,
 			MakerFee:       0.0001, // 0.01%
 			TakerFee:       0.0006, // 0.06%
+			Status:         status,
+
+			FundingIntervalHours: item.FundingInterval / 60,
 		})
 	}
 
 	return instruments, nil
 }
 
+// instrumentStatus maps a Bybit instrument status to a connector status.
+// Closed contracts are not listed; an empty status is taken as trading.
+func instrumentStatus(status string) (string, bool) {
+	switch status {
+	case "Trading", "PreLaunch", "":
+		return connector.StatusTrading, true
+	case "Settling", "Delivering":
+		return connector.StatusSettling, true
+	}
+	return "", false
+}
+
 // FetchOrderbookSnapshot fetches current orderbook via REST
 func (c *BybitConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
-	url := fmt.Sprintf("%s/v5/market/orderbook?category=linear&symbol=%s&limit=%d", bybitRestURL, symbol, depth)
+	url := fmt.Sprintf("%s/v5/market/orderbook?category=linear&symbol=%s&limit=%d", c.RestURL(), symbol, depth)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -253,7 +290,7 @@ func (c *BybitConnector) FetchOrderbookSnapshot(ctx context.Context, symbol stri
 	ob := &connector.Orderbook{
 		ExchangeID: connector.Bybit,
 		Symbol:     symbol,
-		Canonical:  normalizeSymbol(strings.TrimSuffix(symbol, "USDT")),
+		Canonical:  normalizeSymbol(symbol),
 		Bids:       make([]connector.PriceLevel, 0, len(result.Result.Bids)),
 		Asks:       make([]connector.PriceLevel, 0, len(result.Result.Asks)),
 		Timestamp:  time.UnixMilli(result.Result.Ts),
@@ -261,12 +298,18 @@ func (c *BybitConnector) FetchOrderbookSnapshot(ctx context.Context, symbol stri
 	}
 
 	for _, bid := range result.Result.Bids {
+		if len(bid) < 2 {
+			continue
+		}
 		price, _ := strconv.ParseFloat(bid[0], 64)
 		qty, _ := strconv.ParseFloat(bid[1], 64)
 		ob.Bids = append(ob.Bids, connector.PriceLevel{Price: price, Quantity: qty})
 	}
 
 	for _, ask := range result.Result.Asks {
+		if len(ask) < 2 {
+			continue
+		}
 		price, _ := strconv.ParseFloat(ask[0], 64)
 		qty, _ := strconv.ParseFloat(ask[1], 64)
 		ob.Asks = append(ob.Asks, connector.PriceLevel{Price: price, Quantity: qty})
@@ -287,7 +330,7 @@ func (c *BybitConnector) FetchOrderbookSnapshot(ctx context.Context, symbol stri
 
 // FetchFundingRates fetches current funding rates
 func (c *BybitConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
-	url := fmt.Sprintf("%s/v5/market/tickers?category=linear", bybitRestURL)
+	url := fmt.Sprintf("%s/v5/market/tickers?category=linear", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -306,6 +349,7 @@ func (c *BybitConnector) FetchFundingRates(ctx context.Context) ([]connector.Fun
 			List []struct {
 				Symbol          string `json:"symbol"`
 				FundingRate     string `json:"fundingRate"`
+				MarkPrice       string `json:"markPrice"`
 				NextFundingTime string `json:"nextFundingTime"`
 			} `json:"list"`
 		} `json:"result"`
@@ -319,12 +363,14 @@ func (c *BybitConnector) FetchFundingRates(ctx context.Context) ([]connector.Fun
 	for _, item := range result.Result.List {
 		rate, _ := strconv.ParseFloat(item.FundingRate, 64)
 		nextTime, _ := strconv.ParseInt(item.NextFundingTime, 10, 64)
+		markPrice, _ := strconv.ParseFloat(item.MarkPrice, 64)
 
 		rates = append(rates, connector.FundingRate{
 			ExchangeID:           connector.Bybit,
 			Symbol:               item.Symbol,
-			Canonical:            normalizeSymbol(strings.TrimSuffix(item.Symbol, "USDT")),
+			Canonical:            normalizeSymbol(item.Symbol),
 			FundingRate:          rate,
+			MarkPrice:            markPrice,
 			NextFundingTime:      time.UnixMilli(nextTime),
 			FundingIntervalHours: 8,
 			Timestamp:            time.Now(),
@@ -335,12 +381,13 @@ func (c *BybitConnector) FetchFundingRates(ctx context.Context) ([]connector.Fun
 }
 
 func (c *BybitConnector) readMessages() {
+	defer c.Recover("readMessages")
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("read error: %w", err))
 				c.SetConnected(false)
@@ -354,22 +401,41 @@ func (c *BybitConnector) readMessages() {
 
 func (c *BybitConnector) processMessage(data []byte) {
 	var msg struct {
-		Topic string          `json:"topic"`
-		Type  string          `json:"type"`
-		Data  json.RawMessage `json:"data"`
-		Ts    int64           `json:"ts"`
+		Topic   string          `json:"topic"`
+		Type    string          `json:"type"`
+		Data    json.RawMessage `json:"data"`
+		Ts      int64           `json:"ts"`
+		Op      string          `json:"op"`
+		Success *bool           `json:"success"`
+		RetMsg  string          `json:"ret_msg"`
 	}
 
 	if err := json.Unmarshal(data, &msg); err != nil {
 		return
 	}
 
+	// Rejected subscriptions list the offending topics, e.g.
+	// "Invalid symbol :[orderbook.50.FOOUSDT]"
+	if msg.Op == "subscribe" && msg.Success != nil && !*msg.Success {
+		err := fmt.Errorf("subscription rejected: %s", msg.RetMsg)
+		matches := bybitTopicPattern.FindAllStringSubmatch(msg.RetMsg, -1)
+		if len(matches) == 0 {
+			c.EmitError(err)
+		}
+		for _, m := range matches {
+			c.EmitSymbolError(m[1], err)
+		}
+		return
+	}
+
 	// Handle orderbook messages
 	if strings.HasPrefix(msg.Topic, "orderbook.") {
 		c.processOrderbook(msg.Topic, msg.Type, msg.Data, msg.Ts)
 	}
 }
 
+var bybitTopicPattern = regexp.MustCompile(`orderbook\.\d+\.([A-Z0-9]+)`)
+
 func (c *BybitConnector) processOrderbook(topic, msgType string, data json.RawMessage, ts int64) {
 	// Extract symbol from topic: orderbook.50.BTCUSDT
 	parts := strings.Split(topic, ".")
@@ -379,10 +445,11 @@ func (c *BybitConnector) processOrderbook(topic, msgType string, data json.RawMe
 	symbol := parts[2]
 
 	var obData struct {
-		Symbol string     `json:"s"`
-		Bids   [][]string `json:"b"`
-		Asks   [][]string `json:"a"`
-		Seq    int64      `json:"seq"`
+		Symbol   string     `json:"s"`
+		Bids     [][]string `json:"b"`
+		Asks     [][]string `json:"a"`
+		UpdateID int64      `json:"u"`
+		Seq      int64      `json:"seq"`
 	}
 
 	if err := json.Unmarshal(data, &obData); err != nil {
@@ -390,114 +457,71 @@ func (c *BybitConnector) processOrderbook(topic, msgType string, data json.RawMe
 		return
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	isSnapshot := msgType == "snapshot"
-
-	if isSnapshot {
-		// Create new orderbook
-		ob := &connector.Orderbook{
-			ExchangeID: connector.Bybit,
-			Symbol:     symbol,
-			Canonical:  normalizeSymbol(strings.TrimSuffix(symbol, "USDT")),
-			Bids:       make([]connector.PriceLevel, 0, len(obData.Bids)),
-			Asks:       make([]connector.PriceLevel, 0, len(obData.Asks)),
-			Timestamp:  time.UnixMilli(ts),
-			SequenceID: obData.Seq,
-			IsSnapshot: true,
-		}
-
-		for _, bid := range obData.Bids {
-			price, _ := strconv.ParseFloat(bid[0], 64)
-			qty, _ := strconv.ParseFloat(bid[1], 64)
-			ob.Bids = append(ob.Bids, connector.PriceLevel{Price: price, Quantity: qty})
-		}
-
-		for _, ask := range obData.Asks {
-			price, _ := strconv.ParseFloat(ask[0], 64)
-			qty, _ := strconv.ParseFloat(ask[1], 64)
-			ob.Asks = append(ob.Asks, connector.PriceLevel{Price: price, Quantity: qty})
-		}
-
-		c.orderbooks[symbol] = ob
-		c.updateSpread(ob)
-		c.EmitOrderbook(ob)
-	} else {
-		// Apply delta update
-		ob, exists := c.orderbooks[symbol]
-		if !exists {
-			return
-		}
-
-		// Update bids
-		for _, bid := range obData.Bids {
-			price, _ := strconv.ParseFloat(bid[0], 64)
-			qty, _ := strconv.ParseFloat(bid[1], 64)
-			c.updateLevel(&ob.Bids, price, qty, true)
-		}
-
-		// Update asks
-		for _, ask := range obData.Asks {
-			price, _ := strconv.ParseFloat(ask[0], 64)
-			qty, _ := strconv.ParseFloat(ask[1], 64)
-			c.updateLevel(&ob.Asks, price, qty, false)
+	// Update IDs run one per message; u=1 is a snapshot after a service
+	// restart, whatever the type says
+	err := c.books.Apply(orderbook.Update{
+		Exchange:  connector.Bybit,
+		Symbol:    symbol,
+		Canonical: normalizeSymbol(symbol),
+		Snapshot:  msgType == "snapshot" || obData.UpdateID == 1,
+		FirstSeq:  obData.UpdateID,
+		LastSeq:   obData.UpdateID,
+		Bids:      parseLevels(obData.Bids),
+		Asks:      parseLevels(obData.Asks),
+		Timestamp: time.UnixMilli(ts),
+	})
+	if err != nil {
+		if err == orderbook.ErrGap {
+			log.Warn().Str("symbol", symbol).Int64("u", obData.UpdateID).Msg("Bybit orderbook sequence gap, resubscribing")
 		}
+		return
+	}
 
-		ob.Timestamp = time.UnixMilli(ts)
-		ob.SequenceID = obData.Seq
-		ob.IsSnapshot = false
-
-		c.updateSpread(ob)
-		c.EmitOrderbook(ob)
+	ob := c.books.Orderbook(connector.Bybit, symbol, c.depth)
+	if ob == nil {
+		return
 	}
+	ob.SequenceID = obData.Seq
+	c.EmitOrderbook(ob)
 }
 
-func (c *BybitConnector) updateLevel(levels *[]connector.PriceLevel, price, qty float64, isBid bool) {
-	if qty == 0 {
-		// Remove level
-		for i, level := range *levels {
-			if level.Price == price {
-				*levels = append((*levels)[:i], (*levels)[i+1:]...)
-				return
-			}
-		}
+// resync resubscribes a symbol's book topic, which makes Bybit send a
+// fresh snapshot
+func (c *BybitConnector) resync(_ connector.ExchangeID, symbol string) {
+	if !c.IsConnected() {
+		return // Subscribing after reconnecting sends snapshots
+	}
+	if err := c.Unsubscribe([]string{symbol}); err != nil {
+		c.EmitSymbolError(symbol, fmt.Errorf("resync unsubscribe: %w", err))
 		return
 	}
-
-	// Update or insert level
-	for i, level := range *levels {
-		if level.Price == price {
-			(*levels)[i].Quantity = qty
-			return
-		}
+	if err := c.Subscribe([]string{symbol}); err != nil {
+		c.EmitSymbolError(symbol, fmt.Errorf("resync subscribe: %w", err))
 	}
+}
 
-	// Insert at correct position
-	newLevel := connector.PriceLevel{Price: price, Quantity: qty}
-	inserted := false
-	for i, level := range *levels {
-		if (isBid && price > level.Price) || (!isBid && price < level.Price) {
-			*levels = append((*levels)[:i], append([]connector.PriceLevel{newLevel}, (*levels)[i:]...)...)
-			inserted = true
-			break
+// parseLevels parses [price, size] string pairs; a zero size removes a level
+func parseLevels(raw [][]string) []connector.PriceLevel {
+	levels := make([]connector.PriceLevel, 0, len(raw))
+	for _, l := range raw {
+		if len(l) < 2 {
+			continue
 		}
+		price, _ := strconv.ParseFloat(l[0], 64)
+		qty, _ := strconv.ParseFloat(l[1], 64)
+		levels = append(levels, connector.PriceLevel{Price: price, Quantity: qty})
 	}
-	if !inserted {
-		*levels = append(*levels, newLevel)
-	}
+	return levels
 }
 
-func (c *BybitConnector) updateSpread(ob *connector.Orderbook) {
-	if len(ob.Bids) > 0 {
-		ob.BestBid = ob.Bids[0].Price
-	}
-	if len(ob.Asks) > 0 {
-		ob.BestAsk = ob.Asks[0].Price
-	}
-	if ob.BestBid > 0 && ob.BestAsk > 0 {
-		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+// writeJSON sends a message; resyncs write from their own goroutines
+func (c *BybitConnector) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
 	}
+	return c.conn.WriteJSON(v)
 }
 
 func (c *BybitConnector) pingLoop() {
@@ -512,7 +536,7 @@ func (c *BybitConnector) pingLoop() {
 			ping := map[string]interface{}{
 				"op": "ping",
 			}
-			if err := c.conn.WriteJSON(ping); err != nil {
+			if err := c.writeJSON(ping); err != nil {
 				c.EmitError(fmt.Errorf("ping error: %w", err))
 			}
 		}
@@ -520,12 +544,12 @@ func (c *BybitConnector) pingLoop() {
 }
 
 func normalizeSymbol(symbol string) string {
-	return strings.ToUpper(strings.TrimSuffix(symbol, "USDT"))
+	return connector.ParsePair(symbol).Canonical()
 }
 
 // FetchPriceTickers fetches current prices for all symbols via REST API
 func (c *BybitConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
-	url := fmt.Sprintf("%s/v5/market/tickers?category=linear", bybitRestURL)
+	url := fmt.Sprintf("%s/v5/market/tickers?category=linear", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -546,6 +570,8 @@ func (c *BybitConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pri
 				LastPrice string `json:"lastPrice"`
 				Bid1Price string `json:"bid1Price"`
 				Ask1Price string `json:"ask1Price"`
+				Bid1Size  string `json:"bid1Size"`
+				Ask1Size  string `json:"ask1Size"`
 				Volume24h string `json:"volume24h"`
 				UpdatedAt string `json:"updatedTime"`
 			} `json:"list"`
@@ -570,6 +596,8 @@ func (c *BybitConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pri
 		price, _ := strconv.ParseFloat(t.LastPrice, 64)
 		bidPrice, _ := strconv.ParseFloat(t.Bid1Price, 64)
 		askPrice, _ := strconv.ParseFloat(t.Ask1Price, 64)
+		bidSize, _ := strconv.ParseFloat(t.Bid1Size, 64)
+		askSize, _ := strconv.ParseFloat(t.Ask1Size, 64)
 		volume, _ := strconv.ParseFloat(t.Volume24h, 64)
 		updatedAt, _ := strconv.ParseInt(t.UpdatedAt, 10, 64)
 
@@ -585,6 +613,8 @@ func (c *BybitConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pri
 			Price:      price,
 			BidPrice:   bidPrice,
 			AskPrice:   askPrice,
+			BidSize:    bidSize,
+			AskSize:    askSize,
 			Volume24h:  volume,
 			Timestamp:  time.UnixMilli(updatedAt),
 		})
@@ -621,3 +651,81 @@ func (c *BybitConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetI
 	log.Info().Int("count", len(assetInfos)).Msg("Fetched Bybit asset info")
 	return assetInfos, nil
 }
+
+// FetchKlines fetches the most recent candles of a linear contract
+func (c *BybitConnector) FetchKlines(ctx context.Context, symbol string, interval time.Duration, limit int) ([]connector.Kline, error) {
+	bybitInterval, ok := klineIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("bybit: unsupported kline interval %s", interval)
+	}
+
+	resp, err := c.rest.GetKline(ctx, "linear", symbol, bybitInterval, 0, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bybit lists candles newest first
+	klines := make([]connector.Kline, 0, len(resp.Result.List))
+	for i := len(resp.Result.List) - 1; i >= 0; i-- {
+		row := resp.Result.List[i]
+		if len(row) < 7 {
+			continue
+		}
+		start, _ := strconv.ParseInt(row[0], 10, 64)
+		open, _ := strconv.ParseFloat(row[1], 64)
+		high, _ := strconv.ParseFloat(row[2], 64)
+		low, _ := strconv.ParseFloat(row[3], 64)
+		closePrice, _ := strconv.ParseFloat(row[4], 64)
+		volume, _ := strconv.ParseFloat(row[5], 64)
+		turnover, _ := strconv.ParseFloat(row[6], 64)
+
+		klines = append(klines, connector.Kline{
+			ExchangeID:  connector.Bybit,
+			Symbol:      symbol,
+			Start:       time.UnixMilli(start),
+			Interval:    interval,
+			Open:        open,
+			High:        high,
+			Low:         low,
+			Close:       closePrice,
+			Volume:      volume,
+			QuoteVolume: turnover,
+		})
+	}
+	return klines, nil
+}
+
+// klineIntervals maps candle intervals to Bybit kline interval names
+var klineIntervals = map[time.Duration]string{
+	time.Minute:      "1",
+	5 * time.Minute:  "5",
+	15 * time.Minute: "15",
+	time.Hour:        "60",
+	4 * time.Hour:    "240",
+	24 * time.Hour:   "D",
+}
+
+// FetchOpenInterest fetches the latest open interest of a linear contract in base units
+func (c *BybitConnector) FetchOpenInterest(ctx context.Context, symbol string) (*connector.OpenInterest, error) {
+	resp, err := c.rest.GetOpenInterest(ctx, "linear", symbol, "5min", 0, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Result.List) == 0 {
+		return nil, fmt.Errorf("bybit: no open interest for %s", symbol)
+	}
+
+	item := resp.Result.List[0]
+	value, err := strconv.ParseFloat(item.OpenInterest, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse open interest: %w", err)
+	}
+	ts, _ := strconv.ParseInt(item.Timestamp, 10, 64)
+
+	return &connector.OpenInterest{
+		ExchangeID:   connector.Bybit,
+		Symbol:       symbol,
+		OpenInterest: value,
+		Timestamp:    time.UnixMilli(ts),
+	}, nil
+}
@@ -0,0 +1,22 @@
+package bybit
+
+import "testing"
+
+// FuzzProcessMessage feeds arbitrary frames through the public WebSocket
+// decoder. Each input starts with a snapshot so deltas hit a live book.
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"topic":"orderbook.50.BTCUSDT","type":"delta","ts":1687940967466,"data":{"s":"BTCUSDT","b":[["30247.20","30.028"],["30245.40","0"]],"a":[["30248.70","0"]],"u":177400507,"seq":66544703342}}`))
+	f.Add([]byte(`{"topic":"orderbook.50.BTCUSDT","type":"snapshot","ts":1687940967466,"data":{"s":"BTCUSDT","b":[["30247.20","30.028"]],"a":[["30248.70","1.2"]],"u":1,"seq":1}}`))
+	f.Add([]byte(`{"success":false,"ret_msg":"Invalid symbol :[orderbook.50.FOOUSDT]","conn_id":"x","op":"subscribe"}`))
+	f.Add([]byte(`{"success":true,"ret_msg":"","conn_id":"x","op":"subscribe"}`))
+	f.Add([]byte(`{"topic":"orderbook.50.BTCUSDT","type":"delta","ts":1,"data":{"b":[["1"]],"a":[[]]}}`))
+	f.Add([]byte(`{"topic":"orderbook.","type":"snapshot","data":null}`))
+
+	snapshot := []byte(`{"topic":"orderbook.50.BTCUSDT","type":"snapshot","ts":1687940967466,"data":{"s":"BTCUSDT","b":[["30247.20","30.028"],["30247.10","1"]],"a":[["30248.70","1.2"],["30249","2"]],"u":1,"seq":1}}`)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewBybitConnector([]string{"BTCUSDT"}, 50)
+		c.processMessage(snapshot)
+		c.processMessage(data)
+	})
+}
@@ -16,6 +16,8 @@ import (
 	"strings"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/rs/zerolog/log"
 )
 
@@ -906,6 +908,82 @@ func (c *RESTClient) GetWithdrawRecords(ctx context.Context, coin string, withdr
 	return &resp, nil
 }
 
+// FetchTransfers lists on-chain deposits and withdrawals since the given
+// time, failed and cancelled ones left out. Bybit reports only when a
+// deposit was credited, so deposits carry no creation time.
+func (c *RESTClient) FetchTransfers(ctx context.Context, since time.Time) ([]connector.TransferRecord, error) {
+	// Bybit caps a query at 30 days
+	now := time.Now()
+	if since.Before(now.Add(-30 * 24 * time.Hour)) {
+		since = now.Add(-30*24*time.Hour + time.Minute)
+	}
+	start, end := since.UnixMilli(), now.UnixMilli()
+
+	deposits, err := c.GetDepositRecords(ctx, "", start, end, 50)
+	if err != nil {
+		return nil, fmt.Errorf("deposit records: %w", err)
+	}
+	withdrawals, err := c.GetWithdrawRecords(ctx, "", 0, start, end, 50)
+	if err != nil {
+		return nil, fmt.Errorf("withdraw records: %w", err)
+	}
+
+	var records []connector.TransferRecord
+	for _, d := range deposits.Result.Rows {
+		// 4 is failed; internal transfers carry no hash
+		if d.Status == 4 || d.TxID == "" {
+			continue
+		}
+		amount, _ := strconv.ParseFloat(d.Amount, 64)
+		r := connector.TransferRecord{
+			ExchangeID: connector.Bybit,
+			Direction:  connector.TransferDeposit,
+			ID:         d.TxID + ":" + d.TxIndex,
+			TxID:       d.TxID,
+			Asset:      d.Coin,
+			Chain:      connector.NormalizeChain(d.Chain),
+			Amount:     amount,
+			Completed:  d.Status == 3,
+		}
+		if r.Completed {
+			r.CompletedAt = parseMillis(d.SuccessAt)
+		}
+		records = append(records, r)
+	}
+	for _, w := range withdrawals.Result.Rows {
+		switch w.Status {
+		case "CancelByUser", "Reject", "Fail":
+			continue
+		}
+		amount, _ := strconv.ParseFloat(w.Amount, 64)
+		r := connector.TransferRecord{
+			ExchangeID: connector.Bybit,
+			Direction:  connector.TransferWithdrawal,
+			ID:         w.WithdrawId,
+			TxID:       w.TxID,
+			Asset:      w.Coin,
+			Chain:      connector.NormalizeChain(w.Chain),
+			Amount:     amount,
+			Completed:  w.Status == "success",
+			CreatedAt:  parseMillis(w.CreateTime),
+		}
+		if r.Completed {
+			r.CompletedAt = parseMillis(w.UpdateTime)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// parseMillis parses a millisecond timestamp string, zero if empty
+func parseMillis(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
 // =============================================================================
 // Helper Methods
 // =============================================================================
@@ -0,0 +1,74 @@
+package connector
+
+import "sort"
+
+// Capabilities describes what we can do on an exchange beyond reading quotes
+type Capabilities struct {
+	MarketData   bool `json:"market_data"`
+	Trading      bool `json:"trading"`        // An order-entry client exists
+	WSOrderEntry bool `json:"ws_order_entry"` // Orders can be sent over WebSocket
+	FundingWS    bool `json:"funding_ws"`     // Funding rates are pushed over WebSocket
+}
+
+// QuoteOnly returns true if the exchange can be used for price discovery only
+func (c Capabilities) QuoteOnly() bool {
+	return !c.Trading
+}
+
+// exchangeCapabilities records which venues have trading clients in this repo.
+// Venues without one are quote-only until a trading client lands.
+var exchangeCapabilities = map[ExchangeID]Capabilities{
+	Binance:  {MarketData: true, Trading: true, WSOrderEntry: true},
+	Bybit:    {MarketData: true, Trading: true, WSOrderEntry: true},
+	OKX:      {MarketData: true, Trading: true, WSOrderEntry: true},
+	KuCoin:   {MarketData: true, Trading: true, WSOrderEntry: true},
+	MEXC:     {MarketData: true, Trading: true, WSOrderEntry: true},
+	Bitget:   {MarketData: true, Trading: true, WSOrderEntry: true},
+	GateIO:   {MarketData: true, Trading: true, WSOrderEntry: true},
+	BingX:    {MarketData: true, Trading: true, WSOrderEntry: true},
+	CoinEx:   {MarketData: true, Trading: true},
+	LBank:    {MarketData: true},
+	HTX:      {MarketData: true},
+	WhiteBIT: {MarketData: true},
+	BitMart:  {MarketData: true, FundingWS: true},
+	XT:       {MarketData: true},
+	Bitrue:   {MarketData: true},
+	Deribit:  {MarketData: true, FundingWS: true},
+}
+
+// GetCapabilities returns the capabilities of an exchange.
+// Unknown exchanges are treated as quote-only.
+func GetCapabilities(id ExchangeID) Capabilities {
+	if caps, ok := exchangeCapabilities[id]; ok {
+		return caps
+	}
+	return Capabilities{MarketData: true}
+}
+
+// HasFundingWS returns true if the exchange pushes funding rates over WebSocket.
+// Other venues' funding comes from REST polling.
+func HasFundingWS(id ExchangeID) bool {
+	return GetCapabilities(id).FundingWS
+}
+
+// Exchanges returns every known exchange, sorted by ID
+func Exchanges() []ExchangeID {
+	ids := make([]ExchangeID, 0, len(exchangeCapabilities))
+	for id := range exchangeCapabilities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// TradingExchanges returns the exchanges with a trading client, sorted by ID
+func TradingExchanges() []ExchangeID {
+	ids := make([]ExchangeID, 0, len(exchangeCapabilities))
+	for id, caps := range exchangeCapabilities {
+		if caps.Trading {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
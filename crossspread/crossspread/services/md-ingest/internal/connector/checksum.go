@@ -0,0 +1,144 @@
+package connector
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// ChecksumLevels is how many levels per side OKX and Bitget cover in their
+// book checksums
+const ChecksumLevels = 25
+
+// BookChecksum computes the CRC32 OKX and Bitget send with book pushes:
+// the first 25 bids and asks interleaved as bid price:bid size:ask
+// price:ask size..., joined by colons, read as a signed 32-bit integer.
+// Levels are [price, size, ...] in the exchange's own strings, best first;
+// reformatting a number changes the checksum.
+func BookChecksum(bids, asks [][]string) int32 {
+	var sb strings.Builder
+	for i := 0; i < ChecksumLevels && (i < len(bids) || i < len(asks)); i++ {
+		if i < len(bids) && len(bids[i]) >= 2 {
+			writeChecksumLevel(&sb, bids[i])
+		}
+		if i < len(asks) && len(asks[i]) >= 2 {
+			writeChecksumLevel(&sb, asks[i])
+		}
+	}
+	return int32(crc32.ChecksumIEEE([]byte(sb.String())))
+}
+
+func writeChecksumLevel(sb *strings.Builder, level []string) {
+	if sb.Len() > 0 {
+		sb.WriteByte(':')
+	}
+	sb.WriteString(level[0])
+	sb.WriteByte(':')
+	sb.WriteString(level[1])
+}
+
+// VerifyBookChecksum reports whether a book matches the checksum pushed
+// with it, counting mismatches per exchange. A zero checksum means the
+// channel doesn't send one and always verifies.
+func VerifyBookChecksum(exchange ExchangeID, bids, asks [][]string, checksum int64) bool {
+	if checksum == 0 || int64(BookChecksum(bids, asks)) == checksum {
+		return true
+	}
+	metrics.OrderbookChecksumFailures.WithLabelValues(string(exchange)).Inc()
+	return false
+}
+
+// LevelStrings converts a connector's parsed levels back to the
+// [price, size] strings BookChecksum reads
+func LevelStrings[L any](levels []L, level func(L) (price, size string)) [][]string {
+	out := make([][]string, len(levels))
+	for i, l := range levels {
+		price, size := level(l)
+		out[i] = []string{price, size}
+	}
+	return out
+}
+
+// ResubscribeBook unsubscribes and resubscribes a book that failed its
+// checksum; OKX and Bitget answer the subscription with a new snapshot. The
+// returned error reports the mismatch for the connector's error handler.
+func ResubscribeBook(symbol string, unsubscribe, subscribe func() error) error {
+	err := unsubscribe()
+	if err == nil {
+		err = subscribe()
+	}
+	if err != nil {
+		return fmt.Errorf("order book checksum mismatch for %s, resubscribe failed: %w", symbol, err)
+	}
+	return fmt.Errorf("order book checksum mismatch for %s, resubscribed", symbol)
+}
+
+// ChecksumBook is a local book kept in the exchange's own strings, so the
+// checksum pushed with an incremental update can be checked against the
+// merged book
+type ChecksumBook struct {
+	bids map[string]string // price -> size
+	asks map[string]string
+}
+
+// NewChecksumBook creates a book from a snapshot's levels
+func NewChecksumBook(bids, asks [][]string) *ChecksumBook {
+	b := &ChecksumBook{bids: make(map[string]string), asks: make(map[string]string)}
+	b.Apply(bids, asks)
+	return b
+}
+
+// Apply merges an update's levels; a zero size removes a level
+func (b *ChecksumBook) Apply(bids, asks [][]string) {
+	applyChecksumSide(b.bids, bids)
+	applyChecksumSide(b.asks, asks)
+}
+
+func applyChecksumSide(side map[string]string, levels [][]string) {
+	for _, l := range levels {
+		if len(l) < 2 {
+			continue
+		}
+		if size, _ := strconv.ParseFloat(l[1], 64); size == 0 {
+			delete(side, l[0])
+		} else {
+			side[l[0]] = l[1]
+		}
+	}
+}
+
+// Verify checks the book's top ChecksumLevels levels against a pushed
+// checksum, counting a mismatch like VerifyBookChecksum
+func (b *ChecksumBook) Verify(exchange ExchangeID, checksum int64) bool {
+	return VerifyBookChecksum(exchange, topChecksumSide(b.bids, true), topChecksumSide(b.asks, false), checksum)
+}
+
+// topChecksumSide returns a side's best ChecksumLevels levels, bids by
+// price descending and asks ascending, compared as numbers
+func topChecksumSide(side map[string]string, descending bool) [][]string {
+	type level struct {
+		price float64
+		raw   []string
+	}
+	levels := make([]level, 0, len(side))
+	for price, size := range side {
+		p, _ := strconv.ParseFloat(price, 64)
+		levels = append(levels, level{p, []string{price, size}})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].price > levels[j].price
+		}
+		return levels[i].price < levels[j].price
+	})
+	n := min(len(levels), ChecksumLevels)
+	out := make([][]string, n)
+	for i := 0; i < n; i++ {
+		out[i] = levels[i].raw
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package connector
+
+import "testing"
+
+// The vectors are the examples in OKX's order book checksum docs
+func TestBookChecksum(t *testing.T) {
+	tests := []struct {
+		name       string
+		bids, asks [][]string
+		want       int32
+	}{
+		{
+			name: "equal sides",
+			bids: [][]string{{"3366.1", "7", "0", "3"}, {"3366", "6", "3", "4"}},
+			asks: [][]string{{"3366.8", "9", "10", "3"}, {"3368", "8", "3", "4"}},
+			want: -1881014294,
+		},
+		{
+			name: "more asks than bids",
+			bids: [][]string{{"3366.1", "7", "0", "3"}},
+			asks: [][]string{{"3366.8", "9", "10", "3"}, {"3368", "8", "3", "4"}, {"3372", "8", "3", "4"}},
+			want: 831078360,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BookChecksum(tt.bids, tt.asks); got != tt.want {
+				t.Errorf("BookChecksum = %d, want %d", got, tt.want)
+			}
+			if !VerifyBookChecksum(OKX, tt.bids, tt.asks, int64(tt.want)) {
+				t.Error("VerifyBookChecksum rejected the documented checksum")
+			}
+		})
+	}
+}
+
+func TestVerifyBookChecksumMismatch(t *testing.T) {
+	bids := [][]string{{"3366.1", "7"}, {"3366", "6"}}
+	asks := [][]string{{"3366.8", "9"}, {"3368", "8"}}
+
+	// Reformatting a size changes the checksum
+	if VerifyBookChecksum(OKX, [][]string{{"3366.1", "7.0"}, {"3366", "6"}}, asks, -1881014294) {
+		t.Error("reformatted level verified")
+	}
+	if VerifyBookChecksum(OKX, bids, asks, -1881014295) {
+		t.Error("wrong checksum verified")
+	}
+	if !VerifyBookChecksum(OKX, bids, asks, 0) {
+		t.Error("zero checksum, sent by channels without one, failed")
+	}
+}
+
+// An update is checked against the merged book, not its own levels
+func TestChecksumBookApply(t *testing.T) {
+	b := NewChecksumBook(
+		[][]string{{"3366.1", "7"}, {"3366", "6"}},
+		[][]string{{"3366.8", "9"}, {"3368", "8"}},
+	)
+	b.Apply([][]string{{"3366", "0"}}, [][]string{{"3372", "8"}})
+	if !b.Verify(OKX, 831078360) {
+		t.Error("merged book failed the checksum of its top levels")
+	}
+	if b.Verify(OKX, -1881014294) {
+		t.Error("merged book verified against the pre-update checksum")
+	}
+}
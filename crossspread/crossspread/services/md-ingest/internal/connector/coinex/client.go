@@ -248,6 +248,15 @@ func (c *Client) SubscribeOrderbook(markets []string, depth int, isFull bool) er
 	return c.WSMarketData.SubscribeDepth(markets, depth, "0", isFull)
 }
 
+// SubscribeOrderbookMerged subscribes to depth merged into price buckets of
+// interval (one of the DepthInterval constants)
+func (c *Client) SubscribeOrderbookMerged(markets []string, depth int, interval string, isFull bool) error {
+	if c.WSMarketData == nil {
+		return fmt.Errorf("market data websocket not enabled")
+	}
+	return c.WSMarketData.SubscribeDepth(markets, depth, interval, isFull)
+}
+
 // SubscribeTrades subscribes to trade updates for given markets
 func (c *Client) SubscribeTrades(markets []string) error {
 	if c.WSMarketData == nil {
@@ -3,6 +3,7 @@ package coinex
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"sync"
@@ -22,6 +23,7 @@ type CoinExConnector struct {
 	mu            sync.RWMutex
 	done          chan struct{}
 	depthLevels   int
+	aggregation   map[string]float64 // Merged depth interval per market
 }
 
 // CoinExConnectorConfig holds configuration for the CoinEx connector
@@ -65,6 +67,7 @@ func NewCoinExConnectorWithConfig(cfg CoinExConnectorConfig) *CoinExConnector {
 		subscriptions: make(map[string]bool),
 		done:          make(chan struct{}),
 		depthLevels:   cfg.DepthLevels,
+		aggregation:   make(map[string]float64),
 	}
 
 	if c.depthLevels == 0 {
@@ -124,7 +127,7 @@ func (c *CoinExConnector) Connect(ctx context.Context) error {
 	c.mu.RUnlock()
 
 	if len(symbols) > 0 {
-		if err := c.client.SubscribeOrderbook(symbols, c.depthLevels, true); err != nil {
+		if err := c.subscribeDepth(symbols); err != nil {
 			log.Error().Err(err).Msg("Failed to subscribe to orderbook")
 		}
 		if err := c.client.SubscribeBBO(symbols); err != nil {
@@ -155,7 +158,7 @@ func (c *CoinExConnector) Subscribe(symbols []string) error {
 	c.mu.Unlock()
 
 	if c.IsConnected() {
-		if err := c.client.SubscribeOrderbook(symbols, c.depthLevels, true); err != nil {
+		if err := c.subscribeDepth(symbols); err != nil {
 			return err
 		}
 		if err := c.client.SubscribeBBO(symbols); err != nil {
@@ -175,6 +178,72 @@ func (c *CoinExConnector) Unsubscribe(symbols []string) error {
 	return nil
 }
 
+// SetAggregation sets the merged depth interval of a market. CoinEx merges
+// on its side in powers of ten (0.01, 0.1, 1, 10...); zero restores full
+// depth. A connected market is resubscribed with the new interval.
+func (c *CoinExConnector) SetAggregation(symbol string, step float64) error {
+	if step < 0 || (step > 0 && !isPowerOfTen(step)) {
+		return fmt.Errorf("coinex: depth interval must be a power of ten, got %v", step)
+	}
+
+	c.mu.Lock()
+	if step == 0 {
+		delete(c.aggregation, symbol)
+	} else {
+		c.aggregation[symbol] = step
+	}
+	subscribed := c.subscriptions[symbol]
+	c.mu.Unlock()
+
+	if subscribed && c.IsConnected() {
+		return c.subscribeDepth([]string{symbol})
+	}
+	return nil
+}
+
+// Aggregations returns the merged depth interval of every aggregated market
+func (c *CoinExConnector) Aggregations() map[string]float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make(map[string]float64, len(c.aggregation))
+	for symbol, step := range c.aggregation {
+		result[symbol] = step
+	}
+	return result
+}
+
+// subscribeDepth subscribes markets to depth, one request per merge interval
+func (c *CoinExConnector) subscribeDepth(symbols []string) error {
+	byInterval := make(map[string][]string)
+	c.mu.RLock()
+	for _, s := range symbols {
+		interval := DepthIntervalNone
+		if step := c.aggregation[s]; step > 0 {
+			interval = strconv.FormatFloat(step, 'f', -1, 64)
+		}
+		byInterval[interval] = append(byInterval[interval], s)
+	}
+	c.mu.RUnlock()
+
+	for interval, markets := range byInterval {
+		if err := c.client.SubscribeOrderbookMerged(markets, c.depthLevels, interval, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CoinExConnector) aggregationOf(symbol string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.aggregation[symbol]
+}
+
+func isPowerOfTen(step float64) bool {
+	exp := math.Round(math.Log10(step))
+	return math.Abs(step-math.Pow10(int(exp))) < 1e-12*math.Max(1, step)
+}
+
 // FetchInstruments fetches all perpetual futures using v2 API
 func (c *CoinExConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
 	markets, err := c.client.GetAllMarkets(ctx)
@@ -210,7 +279,12 @@ func (c *CoinExConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 
 // FetchOrderbookSnapshot fetches orderbook via REST API
 func (c *CoinExConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
-	depthData, err := c.client.GetOrderbook(ctx, symbol, depth)
+	interval := DepthIntervalNone
+	step := c.aggregationOf(symbol)
+	if step > 0 {
+		interval = strconv.FormatFloat(step, 'f', -1, 64)
+	}
+	depthData, err := c.client.REST.GetDepth(ctx, symbol, depth, interval)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch orderbook: %w", err)
 	}
@@ -236,6 +310,7 @@ func (c *CoinExConnector) FetchOrderbookSnapshot(ctx context.Context, symbol str
 	if ob.BestBid > 0 && ob.BestAsk > 0 {
 		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
 	}
+	connector.AggregateBook(ob, step)
 
 	return ob, nil
 }
@@ -409,6 +484,9 @@ func (c *CoinExConnector) handleDepthUpdate(update *WSDepthUpdate) {
 		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
 	}
 
+	// Merged prices arrive with the venue's full decimals
+	connector.AggregateBook(ob, c.aggregationOf(update.Market))
+
 	c.EmitOrderbook(ob)
 }
 
@@ -451,6 +529,9 @@ func (c *CoinExConnector) handleBBOUpdate(update *WSBBOUpdate) {
 		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
 	}
 
+	// BBO is never merged by the venue; bucket it like the depth
+	connector.AggregateBook(ob, c.aggregationOf(update.Market))
+
 	c.EmitOrderbook(ob)
 }
 
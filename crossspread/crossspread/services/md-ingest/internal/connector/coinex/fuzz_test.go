@@ -0,0 +1,20 @@
+package coinex
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary (already inflated) frames through the
+// market data client with the connector's callbacks attached, so decoding
+// and conversion to connector types both run.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"method":"depth.update","data":{"market":"BTCUSDT","is_full":true,"depth":{"asks":[["30740.00","0.31763545"]],"bids":[["30736.00","0.04857373"],["30735","0"]],"last":"30746.28","updated_at":1689152421692,"checksum":2578768879}},"id":null}`))
+	f.Add([]byte(`{"method":"deals.update","data":{"market":"BTCUSDT","deal_list":[{"deal_id":3514376759,"created_at":1689152421692,"side":"buy","price":"30718.42","amount":"0.00000531"}]},"id":null}`))
+	f.Add([]byte(`{"method":"bbo.update","data":{"market":"BTCUSDT","updated_at":1656660154,"best_bid_price":"20000","best_bid_size":"0.1","best_ask_price":"20001","best_ask_size":"0.15"},"id":null}`))
+	f.Add([]byte(`{"method":"depth.update","data":{"market":"BTCUSDT","depth":{"asks":[["1"]],"bids":[[]]}},"id":null}`))
+	f.Add([]byte(`{"id":1,"code":20001,"message":"invalid argument: market FOOUSDT"}`))
+	f.Add([]byte(`{"method":"server.pong","data":null,"id":null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewCoinExConnector([]string{"BTCUSDT"}, 20)
+		c.client.WSMarketData.handleMessage(data)
+	})
+}
@@ -16,6 +16,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"crossspread-md-ingest/internal/connector"
 )
 
 // REST API endpoints
@@ -547,6 +549,67 @@ func (c *RESTClient) GetWithdrawHistory(ctx context.Context, ccy, status string,
 	return result, nil
 }
 
+// FetchTransfers lists on-chain deposits and withdrawals created since the
+// given time, failed and cancelled ones left out. CoinEx doesn't report
+// when a transfer completed, so completed records carry no completion time.
+func (c *RESTClient) FetchTransfers(ctx context.Context, since time.Time) ([]connector.TransferRecord, error) {
+	deposits, err := c.GetDepositHistory(ctx, "", "", 1, 50)
+	if err != nil {
+		return nil, fmt.Errorf("deposit history: %w", err)
+	}
+	withdrawals, err := c.GetWithdrawHistory(ctx, "", "", 1, 50)
+	if err != nil {
+		return nil, fmt.Errorf("withdraw history: %w", err)
+	}
+
+	var records []connector.TransferRecord
+	for _, d := range deposits {
+		created := time.UnixMilli(d.CreatedAt)
+		if d.DepositMethod != "on_chain" || created.Before(since) {
+			continue
+		}
+		switch d.Status {
+		case "cancelled", "too_small", "exception":
+			continue
+		}
+		amount, _ := strconv.ParseFloat(d.Amount, 64)
+		records = append(records, connector.TransferRecord{
+			ExchangeID: connector.CoinEx,
+			Direction:  connector.TransferDeposit,
+			ID:         strconv.FormatInt(d.DepositID, 10),
+			TxID:       d.TxID,
+			Asset:      d.Ccy,
+			Chain:      connector.NormalizeChain(d.Chain),
+			Amount:     amount,
+			Completed:  d.Status == "finished",
+			CreatedAt:  created,
+		})
+	}
+	for _, w := range withdrawals {
+		created := time.UnixMilli(w.CreatedAt)
+		if w.WithdrawMethod != "on_chain" || created.Before(since) {
+			continue
+		}
+		switch w.Status {
+		case "cancelled", "failed":
+			continue
+		}
+		amount, _ := strconv.ParseFloat(w.Amount, 64)
+		records = append(records, connector.TransferRecord{
+			ExchangeID: connector.CoinEx,
+			Direction:  connector.TransferWithdrawal,
+			ID:         strconv.FormatInt(w.WithdrawID, 10),
+			TxID:       w.TxID,
+			Asset:      w.Ccy,
+			Chain:      connector.NormalizeChain(w.Chain),
+			Amount:     amount,
+			Completed:  w.Status == "finished",
+			CreatedAt:  created,
+		})
+	}
+	return records, nil
+}
+
 // =============================================================================
 // Private Trading API
 // =============================================================================
@@ -13,6 +13,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
@@ -24,6 +26,7 @@ type WSMarketDataClient struct {
 	connected atomic.Bool
 	reqID     atomic.Int64
 	mu        sync.RWMutex
+	writeMu   sync.Mutex // One writer at a time on conn
 	done      chan struct{}
 	reconnect chan struct{}
 
@@ -180,6 +183,8 @@ func (c *WSMarketDataClient) sendMessage(msg interface{}) error {
 		return fmt.Errorf("not connected")
 	}
 
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	return conn.WriteJSON(msg)
 }
 
@@ -357,6 +362,7 @@ func (c *WSMarketDataClient) UnsubscribeIndex(markets []string) error {
 // =============================================================================
 
 func (c *WSMarketDataClient) readLoop(ctx context.Context) {
+	defer connector.Recover(connector.CoinEx, "readLoop", c.closeConn)
 	defer c.connected.Store(false)
 
 	for {
@@ -394,6 +400,16 @@ func (c *WSMarketDataClient) readLoop(ctx context.Context) {
 	}
 }
 
+// closeConn closes the socket after a recovered panic so the connector's
+// connection monitor reconnects
+func (c *WSMarketDataClient) closeConn() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
 func (c *WSMarketDataClient) decompressMessage(data []byte) ([]byte, error) {
 	// Check for gzip magic bytes (0x1f 0x8b)
 	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
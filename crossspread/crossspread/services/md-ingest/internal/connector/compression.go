@@ -0,0 +1,151 @@
+package connector
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// compressionEnabled holds the per-exchange permessage-deflate toggles.
+// Compression is off unless enabled: venues that already gzip frames at the
+// application level (HTX, Bitrue, BitMart) gain nothing from it.
+var compressionEnabled = struct {
+	sync.RWMutex
+	exchanges map[ExchangeID]bool
+}{exchanges: make(map[ExchangeID]bool)}
+
+// SetCompression enables or disables permessage-deflate negotiation for an exchange
+func SetCompression(id ExchangeID, enabled bool) {
+	compressionEnabled.Lock()
+	defer compressionEnabled.Unlock()
+	compressionEnabled.exchanges[id] = enabled
+}
+
+// CompressionEnabled returns true if permessage-deflate is requested for an exchange
+func CompressionEnabled(id ExchangeID) bool {
+	compressionEnabled.RLock()
+	defer compressionEnabled.RUnlock()
+	return compressionEnabled.exchanges[id]
+}
+
+// meteredConn counts bytes read off the socket and the time spent blocked
+// in Read, so ReadMessage can separate network wait from CPU work
+type meteredConn struct {
+	net.Conn
+	exchange  ExchangeID
+	wireBytes prometheus.Counter
+	blocked   atomic.Int64 // Cumulative nanoseconds inside Read
+	connected atomic.Bool  // WebSocket handshake completed
+	closeOnce sync.Once
+}
+
+func (m *meteredConn) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := m.Conn.Read(p)
+	m.blocked.Add(int64(time.Since(start)))
+	if n > 0 {
+		m.wireBytes.Add(float64(n))
+	}
+	return n, err
+}
+
+// Close closes the socket and releases it from the exchange's budget
+func (m *meteredConn) Close() error {
+	err := m.Conn.Close()
+	m.closeOnce.Do(func() {
+		socketClosed(m.exchange, m.connected.Load())
+	})
+	return err
+}
+
+// Dial opens a WebSocket connection for this exchange, negotiating
+// permessage-deflate if enabled and metering wire bytes. The dial is
+// refused once the exchange holds its budget of open sockets.
+func (c *BaseConnector) Dial(ctx context.Context, url string, header http.Header) (*websocket.Conn, error) {
+	exchange := string(c.config.ExchangeID)
+	if err := reserveSocket(c.config.ExchangeID); err != nil {
+		return nil, err
+	}
+	var metered *meteredConn
+	netDialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout:  10 * time.Second,
+		EnableCompression: CompressionEnabled(c.config.ExchangeID),
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := netDialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			metered = &meteredConn{
+				Conn:      conn,
+				exchange:  c.config.ExchangeID,
+				wireBytes: metrics.WSWireBytes.WithLabelValues(exchange),
+			}
+			socketOpened(c.config.ExchangeID)
+			c.metered.Store(metered)
+			return metered, nil
+		},
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+	if metered != nil && metered.connected.CompareAndSwap(false, true) {
+		socketConnected(c.config.ExchangeID)
+	}
+
+	observeHandshake(c.config.ExchangeID, url, resp)
+
+	negotiated := resp != nil && strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	metrics.RecordWSCompression(exchange, negotiated)
+	if dialer.EnableCompression {
+		log.Info().
+			Str("exchange", exchange).
+			Bool("negotiated", negotiated).
+			Msg("WebSocket permessage-deflate requested")
+	}
+
+	return conn, nil
+}
+
+// ReadMessage reads a frame and records its payload size, the CPU time spent
+// on TLS, framing and inflate, and the receive timestamp for latency tracking
+func (c *BaseConnector) ReadMessage(conn *websocket.Conn) (int, []byte, error) {
+	metered := c.metered.Load()
+	var blockedBefore int64
+	if metered != nil {
+		blockedBefore = metered.blocked.Load()
+	}
+	start := time.Now()
+
+	messageType, message, err := conn.ReadMessage()
+	if err != nil {
+		return messageType, message, err
+	}
+	c.MarkReceived()
+	c.lastFrame.Store(&message)
+	debugFrame(c.config.ExchangeID, message)
+
+	exchange := string(c.config.ExchangeID)
+	metrics.WSPayloadBytes.WithLabelValues(exchange).Add(float64(len(message)))
+	if metered != nil {
+		cpu := time.Since(start) - time.Duration(metered.blocked.Load()-blockedBefore)
+		if cpu > 0 {
+			metrics.WSFrameCPU.WithLabelValues(exchange).Add(cpu.Seconds())
+		}
+	}
+
+	return messageType, message, nil
+}
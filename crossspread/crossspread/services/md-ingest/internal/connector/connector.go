@@ -2,69 +2,45 @@ package connector
 
 import (
 	"context"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"crossspread-md-ingest/pkg/mdtypes"
 )
 
-// ExchangeID represents supported exchange identifiers
-type ExchangeID string
+// The market data payloads are defined in pkg/mdtypes, which clients
+// import to decode them without linking the service
+type (
+	ExchangeID  = mdtypes.ExchangeID
+	PriceLevel  = mdtypes.PriceLevel
+	Orderbook   = mdtypes.Orderbook
+	Trade       = mdtypes.Trade
+	FundingRate = mdtypes.FundingRate
+)
 
 const (
-	Binance ExchangeID = "binance"
-	Bybit   ExchangeID = "bybit"
-	OKX     ExchangeID = "okx"
-	KuCoin  ExchangeID = "kucoin"
-	MEXC    ExchangeID = "mexc"
-	Bitget  ExchangeID = "bitget"
-	GateIO  ExchangeID = "gateio"
-	BingX   ExchangeID = "bingx"
-	CoinEx  ExchangeID = "coinex"
-	LBank   ExchangeID = "lbank"
-	HTX     ExchangeID = "htx"
+	Binance  ExchangeID = "binance"
+	Bybit    ExchangeID = "bybit"
+	OKX      ExchangeID = "okx"
+	KuCoin   ExchangeID = "kucoin"
+	MEXC     ExchangeID = "mexc"
+	Bitget   ExchangeID = "bitget"
+	GateIO   ExchangeID = "gateio"
+	BingX    ExchangeID = "bingx"
+	CoinEx   ExchangeID = "coinex"
+	LBank    ExchangeID = "lbank"
+	HTX      ExchangeID = "htx"
+	WhiteBIT ExchangeID = "whitebit"
+	BitMart  ExchangeID = "bitmart"
+	XT       ExchangeID = "xt"
+	Bitrue   ExchangeID = "bitrue"
+	Deribit  ExchangeID = "deribit"
 )
 
-// PriceLevel represents a single level in the orderbook
-type PriceLevel struct {
-	Price    float64 `json:"price"`
-	Quantity float64 `json:"quantity"`
-}
-
-// Orderbook represents an L2 orderbook snapshot or update
-type Orderbook struct {
-	ExchangeID ExchangeID   `json:"exchange_id"`
-	Symbol     string       `json:"symbol"`    // Exchange-native symbol
-	Canonical  string       `json:"canonical"` // Normalized symbol
-	Bids       []PriceLevel `json:"bids"`      // Sorted desc by price
-	Asks       []PriceLevel `json:"asks"`      // Sorted asc by price
-	BestBid    float64      `json:"best_bid"`
-	BestAsk    float64      `json:"best_ask"`
-	SpreadBps  float64      `json:"spread_bps"`
-	Timestamp  time.Time    `json:"timestamp"`
-	SequenceID int64        `json:"sequence_id,omitempty"`
-	IsSnapshot bool         `json:"is_snapshot"`
-}
-
-// Trade represents a single trade event
-type Trade struct {
-	ExchangeID ExchangeID `json:"exchange_id"`
-	Symbol     string     `json:"symbol"`
-	Canonical  string     `json:"canonical"`
-	TradeID    string     `json:"trade_id"`
-	Price      float64    `json:"price"`
-	Quantity   float64    `json:"quantity"`
-	Side       string     `json:"side"` // "buy" or "sell"
-	Timestamp  time.Time  `json:"timestamp"`
-}
-
-// FundingRate represents funding rate info for perpetuals
-type FundingRate struct {
-	ExchangeID           ExchangeID `json:"exchange_id"`
-	Symbol               string     `json:"symbol"`
-	Canonical            string     `json:"canonical"`
-	FundingRate          float64    `json:"funding_rate"`
-	NextFundingTime      time.Time  `json:"next_funding_time"`
-	FundingIntervalHours int        `json:"funding_interval_hours"`
-	Timestamp            time.Time  `json:"timestamp"`
-}
+// MarketSpot marks books of a venue's spot market. Books without a market
+// are perpetuals, which is all connectors stream today.
+const MarketSpot = "spot"
 
 // Instrument represents a tradeable instrument
 type Instrument struct {
@@ -73,6 +49,7 @@ type Instrument struct {
 	Canonical      string     `json:"canonical"`
 	BaseAsset      string     `json:"base_asset"`
 	QuoteAsset     string     `json:"quote_asset"`
+	SettleAsset    string     `json:"settle_asset"`    // Margin currency; empty means quote asset (linear)
 	InstrumentType string     `json:"instrument_type"` // perpetual, future, spot
 	ContractSize   float64    `json:"contract_size"`
 	TickSize       float64    `json:"tick_size"`
@@ -80,8 +57,27 @@ type Instrument struct {
 	MinNotional    float64    `json:"min_notional"`
 	MakerFee       float64    `json:"maker_fee"`
 	TakerFee       float64    `json:"taker_fee"`
+	Status         string     `json:"status,omitempty"` // One of the Status constants; empty means trading
+
+	// Maintenance margin rate of the lowest risk tier, e.g. 0.004; zero if
+	// the venue doesn't list it with its contracts
+	MaintenanceRate float64 `json:"maintenance_rate,omitempty"`
+
+	// Hours between funding settlements; zero if the venue doesn't list it
+	// with its contracts
+	FundingIntervalHours int `json:"funding_interval_hours,omitempty"`
 }
 
+// Instrument statuses, normalized from the venues' own values (Bitget
+// maintain/limit_open, Gate in_delisting, Bybit Settling)
+const (
+	StatusTrading     = "trading"     // Orders accepted both ways
+	StatusLimitOpen   = "limit_open"  // Reduce-only: positions may be closed, not opened
+	StatusDelisting   = "delisting"   // Reduce-only until the contract is removed
+	StatusSettling    = "settling"    // Settling or delivering; no orders accepted
+	StatusMaintenance = "maintenance" // Suspended by the venue; no orders accepted
+)
+
 // PriceTicker represents current price info for a symbol (REST API response)
 type PriceTicker struct {
 	ExchangeID ExchangeID `json:"exchange_id"`
@@ -90,6 +86,8 @@ type PriceTicker struct {
 	Price      float64    `json:"price"`
 	BidPrice   float64    `json:"bid_price,omitempty"`
 	AskPrice   float64    `json:"ask_price,omitempty"`
+	BidSize    float64    `json:"bid_size,omitempty"` // Size at the best bid, in the venue's book units
+	AskSize    float64    `json:"ask_size,omitempty"` // Size at the best ask, in the venue's book units
 	Volume24h  float64    `json:"volume_24h,omitempty"`
 	Timestamp  time.Time  `json:"timestamp"`
 }
@@ -192,6 +190,129 @@ type Connector interface {
 	LastMessageTime() time.Time
 }
 
+// SymbolFundingFetcher is implemented by connectors whose venue has no bulk
+// funding endpoint, so rates are fetched one symbol at a time
+type SymbolFundingFetcher interface {
+	FetchFundingRate(ctx context.Context, symbol string) (*FundingRate, error)
+}
+
+// BookTickerFetcher is implemented by connectors with a single-symbol best
+// bid/ask endpoint, lighter than a depth snapshot. The book has one level a side.
+type BookTickerFetcher interface {
+	FetchBookTicker(ctx context.Context, symbol string) (*Orderbook, error)
+}
+
+// OpenInterest is a venue's open interest in one contract
+type OpenInterest struct {
+	ExchangeID   ExchangeID `json:"exchange_id"`
+	Symbol       string     `json:"symbol"`
+	Canonical    string     `json:"canonical"`
+	OpenInterest float64    `json:"open_interest"` // Venue units; only compare changes within a venue
+	Timestamp    time.Time  `json:"timestamp"`
+}
+
+// OpenInterestFetcher is implemented by connectors that can fetch a
+// contract's open interest
+type OpenInterestFetcher interface {
+	FetchOpenInterest(ctx context.Context, symbol string) (*OpenInterest, error)
+}
+
+// Account position and margin modes
+const (
+	PositionModeHedge  = "hedge"   // Separate long and short positions per symbol
+	PositionModeOneWay = "one_way" // One net position per symbol
+	MarginModeCross    = "cross"
+	MarginModeIsolated = "isolated"
+)
+
+// AccountConfig is how an account is set up to trade one contract. Empty
+// modes and zero leverages are settings the venue did not report.
+type AccountConfig struct {
+	ExchangeID    ExchangeID `json:"exchange_id"`
+	Symbol        string     `json:"symbol"`
+	PositionMode  string     `json:"position_mode,omitempty"` // hedge or one_way
+	MarginMode    string     `json:"margin_mode,omitempty"`   // cross or isolated
+	LongLeverage  float64    `json:"long_leverage,omitempty"`
+	ShortLeverage float64    `json:"short_leverage,omitempty"` // Same as long on one-way venues
+}
+
+// AccountConfigFetcher is implemented by connectors holding API credentials
+// that can read an account's position mode, margin mode and leverage
+type AccountConfigFetcher interface {
+	FetchAccountConfig(ctx context.Context, symbol string) (*AccountConfig, error)
+}
+
+// Transfer directions
+const (
+	TransferDeposit    = "deposit"
+	TransferWithdrawal = "withdrawal"
+)
+
+// TransferRecord is a deposit to or withdrawal from a venue account. Zero
+// times are ones the venue did not report.
+type TransferRecord struct {
+	ExchangeID  ExchangeID
+	Direction   string // deposit or withdrawal
+	ID          string // Venue's record ID, unique per direction
+	TxID        string // On-chain hash; matches a withdrawal to the deposit it funded
+	Asset       string
+	Chain       string // Normalized with NormalizeChain
+	Amount      float64
+	Completed   bool
+	CreatedAt   time.Time // Withdrawal requested, or deposit first seen on-chain
+	CompletedAt time.Time // Withdrawal broadcast and confirmed, or deposit credited
+}
+
+// TransferFetcher is implemented by clients holding API credentials that
+// can list an account's recent deposits and withdrawals
+type TransferFetcher interface {
+	FetchTransfers(ctx context.Context, since time.Time) ([]TransferRecord, error)
+}
+
+// chainAliases maps token-standard chain names to the network's own
+var chainAliases = map[string]string{
+	"ERC20":    "ETH",
+	"TRC20":    "TRX",
+	"BEP20":    "BSC",
+	"BNB":      "BSC",
+	"SPL":      "SOL",
+	"ARBITRUM": "ARBI",
+	"ARB":      "ARBI",
+	"OPTIMISM": "OP",
+	"MATIC":    "POLYGON",
+}
+
+// NormalizeChain maps a venue's chain name to one shared across venues,
+// so ERC20 on one venue and ETH on another are the same network
+func NormalizeChain(chain string) string {
+	chain = strings.ToUpper(strings.TrimSpace(chain))
+	if alias, ok := chainAliases[chain]; ok {
+		return alias
+	}
+	return chain
+}
+
+// Kline is an OHLCV candle fetched from a venue's REST API
+type Kline struct {
+	ExchangeID  ExchangeID
+	Symbol      string
+	Start       time.Time
+	Interval    time.Duration
+	Open        float64
+	High        float64
+	Low         float64
+	Close       float64
+	Volume      float64 // Base units unless Contracts is set
+	QuoteVolume float64 // Zero if the venue doesn't report turnover
+	Contracts   bool    // Volume is in contracts; multiply by the contract size
+}
+
+// KlineFetcher is implemented by connectors that can fetch recent candles.
+// Klines are returned oldest first.
+type KlineFetcher interface {
+	FetchKlines(ctx context.Context, symbol string, interval time.Duration, limit int) ([]Kline, error)
+}
+
 // BaseConnector provides common functionality for connectors
 type BaseConnector struct {
 	config           ConnectorConfig
@@ -201,6 +322,10 @@ type BaseConnector struct {
 	errorHandler     ErrorHandler
 	connected        bool
 	lastMessageTime  time.Time
+	lastReceived     atomic.Int64           // UnixNano of the frame being processed
+	lastFrame        atomic.Pointer[[]byte] // Frame being processed, for crash reports
+	metered          atomic.Pointer[meteredConn]
+	sides            sideInferrer // Taker side inference for trades; see side.go
 }
 
 // NewBaseConnector creates a new base connector
@@ -215,6 +340,28 @@ func (c *BaseConnector) ID() ExchangeID {
 	return c.config.ExchangeID
 }
 
+// WsURL returns the WebSocket endpoint
+func (c *BaseConnector) WsURL() string {
+	return c.config.WsURL
+}
+
+// RestURL returns the REST base URL
+func (c *BaseConnector) RestURL() string {
+	return c.config.RestURL
+}
+
+// SetEndpoints replaces the WebSocket and REST endpoints; an empty URL keeps
+// the current one. Only connectors reading them through WsURL and RestURL
+// follow it. Call it before connecting.
+func (c *BaseConnector) SetEndpoints(ws, rest string) {
+	if ws != "" {
+		c.config.WsURL = ws
+	}
+	if rest != "" {
+		c.config.RestURL = rest
+	}
+}
+
 // SetOrderbookHandler sets the orderbook handler
 func (c *BaseConnector) SetOrderbookHandler(handler OrderbookHandler) {
 	c.orderbookHandler = handler
@@ -245,10 +392,31 @@ func (c *BaseConnector) LastMessageTime() time.Time {
 	return c.lastMessageTime
 }
 
+// MarkReceived records the receive time of the frame about to be parsed.
+// Read loops call it right after ReadMessage so emitted messages carry it.
+func (c *BaseConnector) MarkReceived() {
+	c.lastReceived.Store(time.Now().UnixNano())
+}
+
+// receivedAt returns the receive time of the current frame, or now if the
+// connector's read loop doesn't call MarkReceived
+func (c *BaseConnector) receivedAt(now time.Time) time.Time {
+	if ns := c.lastReceived.Load(); ns > 0 {
+		return time.Unix(0, ns)
+	}
+	return now
+}
+
 // EmitOrderbook sends orderbook to handler
 func (c *BaseConnector) EmitOrderbook(ob *Orderbook) {
 	c.lastMessageTime = time.Now()
+	if ob.ReceivedAt.IsZero() {
+		ob.ReceivedAt = c.receivedAt(c.lastMessageTime)
+	}
+	ob.NormalizedAt = c.lastMessageTime
+	c.sides.observeBook(ob, c.lastMessageTime)
 	if c.orderbookHandler != nil {
+		defer c.recoverHandler("orderbook", ob.Symbol)
 		c.orderbookHandler(ob)
 	}
 }
@@ -256,7 +424,13 @@ func (c *BaseConnector) EmitOrderbook(ob *Orderbook) {
 // EmitTrade sends trade to handler
 func (c *BaseConnector) EmitTrade(trade *Trade) {
 	c.lastMessageTime = time.Now()
+	if trade.ReceivedAt.IsZero() {
+		trade.ReceivedAt = c.receivedAt(c.lastMessageTime)
+	}
+	trade.NormalizedAt = c.lastMessageTime
+	c.resolveSide(trade, c.lastMessageTime)
 	if c.tradeHandler != nil {
+		defer c.recoverHandler("trade", trade.Symbol)
 		c.tradeHandler(trade)
 	}
 }
@@ -265,6 +439,7 @@ func (c *BaseConnector) EmitTrade(trade *Trade) {
 func (c *BaseConnector) EmitFunding(fr *FundingRate) {
 	c.lastMessageTime = time.Now()
 	if c.fundingHandler != nil {
+		defer c.recoverHandler("funding", fr.Symbol)
 		c.fundingHandler(fr)
 	}
 }
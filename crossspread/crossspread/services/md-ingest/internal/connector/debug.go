@@ -0,0 +1,207 @@
+package connector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Frame debug limits
+const (
+	// MaxFrameDebug is the longest a frame debug window can stay open
+	MaxFrameDebug = 10 * time.Minute
+	// DefaultFrameDebugRate is the frames per second a window logs if unset
+	DefaultFrameDebugRate = 20
+	// frameDebugExcerpt caps the logged part of a frame
+	frameDebugExcerpt = 4096
+)
+
+// ErrFrameDebugDuration is returned for a window that is not positive or
+// longer than MaxFrameDebug
+var ErrFrameDebugDuration = errors.New("frame debug duration must be positive and at most 10m")
+
+// FrameDebug is a window during which an exchange's raw frames mentioning a
+// symbol are logged, at most Rate per second. It closes by itself at Until.
+type FrameDebug struct {
+	Exchange   ExchangeID `json:"exchange"`
+	Symbol     string     `json:"symbol"` // Exchange symbol, matched case-insensitively anywhere in the frame
+	Rate       int        `json:"rate"`
+	Since      time.Time  `json:"since"`
+	Until      time.Time  `json:"until"`
+	Logged     int64      `json:"logged"`
+	Suppressed int64      `json:"suppressed"` // Matching frames over the rate
+}
+
+type frameDebugKey struct {
+	exchange ExchangeID
+	symbol   string
+}
+
+type frameDebugWindow struct {
+	FrameDebug
+	timer  *time.Timer
+	second int64 // Unix second the rate counts
+	count  int
+}
+
+// frameDebugs holds the open windows. active lets ReadMessage skip the lock
+// while none are open. Only frames read through BaseConnector.ReadMessage
+// are seen; venues whose market data clients read on their own (CoinEx,
+// MEXC, LBank) log nothing.
+var frameDebugs = struct {
+	sync.Mutex
+	active  atomic.Int32
+	windows map[frameDebugKey]*frameDebugWindow
+}{windows: make(map[frameDebugKey]*frameDebugWindow)}
+
+// EnableFrameDebug opens a window logging an exchange's raw frames that
+// mention a symbol, for d. Opening a window for the same exchange and
+// symbol again replaces it.
+func EnableFrameDebug(exchange ExchangeID, symbol string, d time.Duration, rate int) (FrameDebug, error) {
+	if d <= 0 || d > MaxFrameDebug {
+		return FrameDebug{}, ErrFrameDebugDuration
+	}
+	if rate <= 0 {
+		rate = DefaultFrameDebugRate
+	}
+	now := time.Now()
+	key := frameDebugKey{exchange, strings.ToUpper(symbol)}
+	w := &frameDebugWindow{FrameDebug: FrameDebug{
+		Exchange: exchange,
+		Symbol:   key.symbol,
+		Rate:     rate,
+		Since:    now,
+		Until:    now.Add(d),
+	}}
+
+	frameDebugs.Lock()
+	defer frameDebugs.Unlock()
+	if prev := frameDebugs.windows[key]; prev != nil {
+		prev.timer.Stop()
+	} else {
+		frameDebugs.active.Add(1)
+	}
+	w.timer = time.AfterFunc(d, func() { closeFrameDebug(key, w) })
+	frameDebugs.windows[key] = w
+
+	log.Info().Str("exchange", string(exchange)).Str("symbol", key.symbol).Dur("for", d).Int("rate", rate).Msg("Frame debug enabled")
+	return w.FrameDebug, nil
+}
+
+// DisableFrameDebug closes a window early and returns it, or false if none
+// was open
+func DisableFrameDebug(exchange ExchangeID, symbol string) (FrameDebug, bool) {
+	key := frameDebugKey{exchange, strings.ToUpper(symbol)}
+	frameDebugs.Lock()
+	w := frameDebugs.windows[key]
+	frameDebugs.Unlock()
+	if w == nil {
+		return FrameDebug{}, false
+	}
+	w.timer.Stop()
+	return closeFrameDebug(key, w), true
+}
+
+// closeFrameDebug removes a window if it is still the open one for its key
+func closeFrameDebug(key frameDebugKey, w *frameDebugWindow) FrameDebug {
+	frameDebugs.Lock()
+	defer frameDebugs.Unlock()
+	if frameDebugs.windows[key] == w {
+		delete(frameDebugs.windows, key)
+		frameDebugs.active.Add(-1)
+		log.Info().
+			Str("exchange", string(key.exchange)).
+			Str("symbol", key.symbol).
+			Int64("logged", w.Logged).
+			Int64("suppressed", w.Suppressed).
+			Msg("Frame debug ended")
+	}
+	return w.FrameDebug
+}
+
+// FrameDebugs returns the open windows, sorted by exchange and symbol
+func FrameDebugs() []FrameDebug {
+	frameDebugs.Lock()
+	defer frameDebugs.Unlock()
+	result := make([]FrameDebug, 0, len(frameDebugs.windows))
+	for _, w := range frameDebugs.windows {
+		result = append(result, w.FrameDebug)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// debugFrame logs a frame for every open window of the exchange it
+// mentions the symbol of. Gzipped frames (HTX, Bitrue) are inflated first.
+func debugFrame(exchange ExchangeID, frame []byte) {
+	if frameDebugs.active.Load() == 0 {
+		return
+	}
+
+	frameDebugs.Lock()
+	defer frameDebugs.Unlock()
+
+	var text []byte
+	now := time.Now()
+	for key, w := range frameDebugs.windows {
+		if key.exchange != exchange || now.After(w.Until) {
+			continue
+		}
+		if text == nil {
+			text = inflateFrame(frame)
+		}
+		if !bytes.Contains(bytes.ToUpper(text), []byte(key.symbol)) {
+			continue
+		}
+		if sec := now.Unix(); sec != w.second {
+			w.second, w.count = sec, 0
+		}
+		if w.count >= w.Rate {
+			w.Suppressed++
+			continue
+		}
+		w.count++
+		w.Logged++
+
+		excerpt := text
+		if len(excerpt) > frameDebugExcerpt {
+			excerpt = excerpt[:frameDebugExcerpt]
+		}
+		log.Info().
+			Str("exchange", string(exchange)).
+			Str("symbol", key.symbol).
+			Int("frame_bytes", len(text)).
+			Bytes("frame", excerpt).
+			Msg("Raw frame")
+	}
+}
+
+// inflateFrame returns a gzipped frame inflated, and any other frame as is
+func inflateFrame(frame []byte) []byte {
+	if len(frame) < 2 || frame[0] != 0x1f || frame[1] != 0x8b {
+		return frame
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(frame))
+	if err != nil {
+		return frame
+	}
+	defer reader.Close()
+	inflated, err := io.ReadAll(io.LimitReader(reader, 1<<20))
+	if err != nil {
+		return frame
+	}
+	return inflated
+}
@@ -0,0 +1,611 @@
+package deribit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	deribitWsURL   = "wss://www.deribit.com/ws/api/v2"
+	deribitRestURL = "https://www.deribit.com"
+
+	// Channels per subscribe request
+	subscribeBatch = 150
+)
+
+// DeribitConnector implements the Connector interface for Deribit perpetual swaps.
+// Coin-margined perpetuals are named BASE-PERPETUAL and sized in USD; their
+// books and trades are converted to base units at each price so depth
+// compares with other venues. USDC-margined linear perpetuals are named
+// BASE_USDC-PERPETUAL and sized in base units already.
+type DeribitConnector struct {
+	*connector.BaseConnector
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	symbols   []string
+	depth     int
+	mu        sync.RWMutex
+	done      chan struct{}
+	requestID int64
+}
+
+// NewDeribitConnector creates a new Deribit connector
+func NewDeribitConnector(symbols []string, depth int) *DeribitConnector {
+	config := connector.ConnectorConfig{
+		ExchangeID:     connector.Deribit,
+		WsURL:          deribitWsURL,
+		RestURL:        deribitRestURL,
+		Symbols:        symbols,
+		DepthLevels:    depth,
+		ReconnectDelay: 5 * time.Second,
+		PingInterval:   30 * time.Second,
+	}
+
+	// Deribit's grouped book channel offers 1, 10 or 20 levels
+	switch {
+	case depth <= 1:
+		depth = 1
+	case depth <= 10:
+		depth = 10
+	default:
+		depth = 20
+	}
+
+	return &DeribitConnector{
+		BaseConnector: connector.NewBaseConnector(config),
+		symbols:       symbols,
+		depth:         depth,
+		done:          make(chan struct{}),
+	}
+}
+
+// Connect establishes WebSocket connection to Deribit
+func (c *DeribitConnector) Connect(ctx context.Context) error {
+	c.mu.RLock()
+	symbols := c.symbols
+	c.mu.RUnlock()
+
+	return c.connect(ctx, symbols)
+}
+
+// ConnectForSymbols establishes WebSocket connection for specific symbols only
+// Used for Phase 2 selective subscription after spread discovery
+func (c *DeribitConnector) ConnectForSymbols(ctx context.Context, symbols []string) error {
+	c.mu.Lock()
+	c.symbols = symbols
+	c.mu.Unlock()
+
+	if err := c.connect(ctx, symbols); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("symbols", len(symbols)).
+		Msg("Connected to Deribit WebSocket (selective)")
+
+	return nil
+}
+
+func (c *DeribitConnector) connect(ctx context.Context, symbols []string) error {
+	conn, err := c.Dial(ctx, deribitWsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Deribit WebSocket: %w", err)
+	}
+
+	c.conn = conn
+	c.done = make(chan struct{})
+	c.SetConnected(true)
+
+	// Deribit sends test_request heartbeats at this interval and drops the
+	// connection if they go unanswered
+	if err := c.send("public/set_heartbeat", map[string]interface{}{"interval": 30}); err != nil {
+		return err
+	}
+	if err := c.Subscribe(symbols); err != nil {
+		return err
+	}
+
+	c.Go(c.readMessages)
+
+	return nil
+}
+
+// Disconnect closes the WebSocket connection
+func (c *DeribitConnector) Disconnect() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.SetConnected(false)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe subscribes to orderbook, trade and ticker (funding) updates for symbols and
+// keeps them for reconnects
+func (c *DeribitConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.sendChannels("public/subscribe", symbols)
+}
+
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
+func (c *DeribitConnector) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.sendChannels("public/unsubscribe", symbols)
+}
+
+func (c *DeribitConnector) sendChannels(method string, symbols []string) error {
+	channels := make([]string, 0, 3*len(symbols))
+	for _, s := range symbols {
+		channels = append(channels,
+			fmt.Sprintf("book.%s.none.%d.100ms", s, c.depth),
+			"trades."+s+".100ms",
+			"ticker."+s+".agg2",
+		)
+	}
+	for start := 0; start < len(channels); start += subscribeBatch {
+		end := min(start+subscribeBatch, len(channels))
+		if err := c.send(method, map[string]interface{}{"channels": channels[start:end]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DeribitConnector) send(method string, params interface{}) error {
+	if c.conn == nil {
+		return fmt.Errorf("deribit: not connected")
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.requestID++
+	return c.conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.requestID,
+		"method":  method,
+		"params":  params,
+	})
+}
+
+// getResult fetches a public JSON-RPC method over REST and decodes its result
+func getResult(ctx context.Context, path string, v interface{}) error {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := getJSON(ctx, deribitRestURL+path, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("API error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return json.Unmarshal(resp.Result, v)
+}
+
+// bookSummary is an entry of the book summary endpoint (all futures in one call)
+type bookSummary struct {
+	InstrumentName string  `json:"instrument_name"`
+	Last           float64 `json:"last"`
+	BidPrice       float64 `json:"bid_price"`
+	AskPrice       float64 `json:"ask_price"`
+	MarkPrice      float64 `json:"mark_price"`
+	VolumeUSD      float64 `json:"volume_usd"`
+	Funding8h      float64 `json:"funding_8h"`
+}
+
+// fetchPerpetualSummaries returns the book summaries of every perpetual
+func (c *DeribitConnector) fetchPerpetualSummaries(ctx context.Context) ([]bookSummary, error) {
+	var summaries []bookSummary
+	if err := getResult(ctx, "/api/v2/public/get_book_summary_by_currency?currency=any&kind=future", &summaries); err != nil {
+		return nil, err
+	}
+	perps := summaries[:0]
+	for _, s := range summaries {
+		if strings.HasSuffix(s.InstrumentName, "-PERPETUAL") {
+			perps = append(perps, s)
+		}
+	}
+	return perps, nil
+}
+
+// FetchInstruments fetches all available perpetual instruments
+func (c *DeribitConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
+	var result []struct {
+		InstrumentName     string  `json:"instrument_name"`
+		BaseCurrency       string  `json:"base_currency"`
+		QuoteCurrency      string  `json:"quote_currency"`
+		SettlementCurrency string  `json:"settlement_currency"`
+		SettlementPeriod   string  `json:"settlement_period"`
+		InstrumentType     string  `json:"instrument_type"` // reversed or linear
+		ContractSize       float64 `json:"contract_size"`
+		TickSize           float64 `json:"tick_size"`
+		MinTradeAmount     float64 `json:"min_trade_amount"`
+		MakerCommission    float64 `json:"maker_commission"`
+		TakerCommission    float64 `json:"taker_commission"`
+		IsActive           bool    `json:"is_active"`
+	}
+	if err := getResult(ctx, "/api/v2/public/get_instruments?currency=any&kind=future", &result); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]connector.Instrument, 0)
+	for _, r := range result {
+		if r.SettlementPeriod != "perpetual" || !r.IsActive {
+			continue
+		}
+
+		inst := connector.Instrument{
+			ExchangeID:     connector.Deribit,
+			Symbol:         r.InstrumentName,
+			Canonical:      normalizeSymbol(r.InstrumentName),
+			BaseAsset:      r.BaseCurrency,
+			QuoteAsset:     r.QuoteCurrency,
+			SettleAsset:    r.SettlementCurrency,
+			InstrumentType: "perpetual",
+			ContractSize:   1, // Books are emitted in base units
+			TickSize:       r.TickSize,
+			LotSize:        r.MinTradeAmount,
+			MakerFee:       r.MakerCommission,
+			TakerFee:       r.TakerCommission,
+		}
+		if r.InstrumentType == "reversed" {
+			// Amounts are USD: no lot in base units maps to them
+			inst.LotSize = 0
+			inst.MinNotional = r.MinTradeAmount
+		}
+		instruments = append(instruments, inst)
+	}
+
+	return instruments, nil
+}
+
+// FetchOrderbookSnapshot fetches current orderbook via REST
+func (c *DeribitConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
+	var result struct {
+		Timestamp int64       `json:"timestamp"`
+		Bids      [][]float64 `json:"bids"`
+		Asks      [][]float64 `json:"asks"`
+	}
+	path := fmt.Sprintf("/api/v2/public/get_order_book?instrument_name=%s&depth=%d", symbol, depth)
+	if err := getResult(ctx, path, &result); err != nil {
+		return nil, err
+	}
+
+	ob := &connector.Orderbook{
+		ExchangeID: connector.Deribit,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseLevels(result.Bids, inverse(symbol)),
+		Asks:       parseLevels(result.Asks, inverse(symbol)),
+		Timestamp:  time.UnixMilli(result.Timestamp),
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+
+	return ob, nil
+}
+
+// FetchFundingRates fetches current funding rates from the book summaries.
+// Deribit accrues funding continuously; funding_8h is the rate over the
+// last 8 hours, the interval other venues settle at.
+func (c *DeribitConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
+	summaries, err := c.fetchPerpetualSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rates := make([]connector.FundingRate, 0, len(summaries))
+	for _, s := range summaries {
+		rates = append(rates, connector.FundingRate{
+			ExchangeID:           connector.Deribit,
+			Symbol:               s.InstrumentName,
+			Canonical:            normalizeSymbol(s.InstrumentName),
+			FundingRate:          s.Funding8h,
+			MarkPrice:            s.MarkPrice,
+			NextFundingTime:      nextFunding(now),
+			FundingIntervalHours: 8,
+			Timestamp:            now,
+		})
+	}
+
+	return rates, nil
+}
+
+// FetchPriceTickers fetches current prices for all perpetuals in a single call
+func (c *DeribitConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
+	summaries, err := c.fetchPerpetualSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make([]connector.PriceTicker, 0, len(summaries))
+	for _, s := range summaries {
+		if s.Last <= 0 {
+			continue
+		}
+		tickers = append(tickers, connector.PriceTicker{
+			ExchangeID: connector.Deribit,
+			Symbol:     s.InstrumentName,
+			Canonical:  normalizeSymbol(s.InstrumentName),
+			Price:      s.Last,
+			BidPrice:   s.BidPrice,
+			AskPrice:   s.AskPrice,
+			Volume24h:  s.VolumeUSD,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	log.Info().Int("count", len(tickers)).Msg("Fetched Deribit price tickers")
+	return tickers, nil
+}
+
+// FetchAssetInfo fetches withdrawal fees for assets. Deribit doesn't
+// publish deposit or withdrawal suspensions, so both are reported enabled.
+func (c *DeribitConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetInfo, error) {
+	var currencies []struct {
+		Currency      string  `json:"currency"`
+		WithdrawalFee float64 `json:"withdrawal_fee"`
+	}
+	if err := getResult(ctx, "/api/v2/public/get_currencies", &currencies); err != nil {
+		return nil, err
+	}
+
+	infos := make([]connector.AssetInfo, 0, len(currencies))
+	for _, cur := range currencies {
+		infos = append(infos, connector.AssetInfo{
+			ExchangeID:      connector.Deribit,
+			Asset:           cur.Currency,
+			DepositEnabled:  true,
+			WithdrawEnabled: true,
+			WithdrawFee:     cur.WithdrawalFee,
+			Timestamp:       time.Now(),
+		})
+	}
+
+	log.Info().Int("count", len(infos)).Msg("Fetched Deribit asset info")
+	return infos, nil
+}
+
+func (c *DeribitConnector) readMessages() {
+	defer c.Recover("readMessages")
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+			_, message, err := c.ReadMessage(c.conn)
+			if err != nil {
+				c.EmitError(fmt.Errorf("read error: %w", err))
+				c.SetConnected(false)
+				return
+			}
+
+			c.processMessage(message)
+		}
+	}
+}
+
+func (c *DeribitConnector) processMessage(data []byte) {
+	var msg struct {
+		Method string `json:"method"`
+		Params struct {
+			Channel string          `json:"channel"`
+			Data    json.RawMessage `json:"data"`
+			Type    string          `json:"type"` // Heartbeats
+		} `json:"params"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	if msg.Error != nil {
+		c.EmitError(fmt.Errorf("deribit error %d: %s", msg.Error.Code, msg.Error.Message))
+		return
+	}
+
+	switch msg.Method {
+	case "heartbeat":
+		if msg.Params.Type == "test_request" {
+			if err := c.send("public/test", map[string]interface{}{}); err != nil {
+				c.EmitError(fmt.Errorf("heartbeat error: %w", err))
+			}
+		}
+	case "subscription":
+		kind, rest, _ := strings.Cut(msg.Params.Channel, ".")
+		symbol, _, _ := strings.Cut(rest, ".")
+		switch kind {
+		case "book":
+			c.processBook(symbol, msg.Params.Data)
+		case "trades":
+			c.processTrades(symbol, msg.Params.Data)
+		case "ticker":
+			c.processTicker(symbol, msg.Params.Data)
+		}
+	}
+}
+
+// processBook handles grouped book pushes, the full top of book each time:
+// {timestamp, instrument_name, change_id, bids: [[price, amount]], asks}
+func (c *DeribitConnector) processBook(symbol string, data json.RawMessage) {
+	var book struct {
+		Timestamp int64       `json:"timestamp"`
+		ChangeID  int64       `json:"change_id"`
+		Bids      [][]float64 `json:"bids"`
+		Asks      [][]float64 `json:"asks"`
+	}
+	if err := json.Unmarshal(data, &book); err != nil {
+		log.Error().Err(err).Msg("Failed to parse Deribit book")
+		return
+	}
+
+	ob := &connector.Orderbook{
+		ExchangeID: connector.Deribit,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseLevels(book.Bids, inverse(symbol)),
+		Asks:       parseLevels(book.Asks, inverse(symbol)),
+		Timestamp:  time.UnixMilli(book.Timestamp),
+		SequenceID: book.ChangeID,
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+	c.EmitOrderbook(ob)
+}
+
+// processTrades handles trades pushes: [{trade_id, timestamp, price, amount, direction}]
+func (c *DeribitConnector) processTrades(symbol string, data json.RawMessage) {
+	var trades []struct {
+		TradeID   string  `json:"trade_id"`
+		Timestamp int64   `json:"timestamp"`
+		Price     float64 `json:"price"`
+		Amount    float64 `json:"amount"`
+		Direction string  `json:"direction"` // Taker side, buy or sell
+	}
+	if err := json.Unmarshal(data, &trades); err != nil {
+		return
+	}
+
+	for _, t := range trades {
+		qty := t.Amount
+		if inverse(symbol) {
+			if t.Price <= 0 {
+				continue
+			}
+			qty /= t.Price
+		}
+
+		c.EmitTrade(&connector.Trade{
+			ExchangeID: connector.Deribit,
+			Symbol:     symbol,
+			Canonical:  normalizeSymbol(symbol),
+			TradeID:    t.TradeID,
+			Price:      t.Price,
+			Quantity:   qty,
+			Side:       t.Direction,
+			Timestamp:  time.UnixMilli(t.Timestamp),
+		})
+	}
+}
+
+// processTicker handles ticker pushes for their funding: {timestamp, funding_8h, mark_price}
+func (c *DeribitConnector) processTicker(symbol string, data json.RawMessage) {
+	var ticker struct {
+		Timestamp int64    `json:"timestamp"`
+		Funding8h *float64 `json:"funding_8h"`
+		MarkPrice float64  `json:"mark_price"`
+	}
+	if err := json.Unmarshal(data, &ticker); err != nil || ticker.Funding8h == nil {
+		return
+	}
+
+	ts := time.UnixMilli(ticker.Timestamp)
+	c.EmitFunding(&connector.FundingRate{
+		ExchangeID:           connector.Deribit,
+		Symbol:               symbol,
+		Canonical:            normalizeSymbol(symbol),
+		FundingRate:          *ticker.Funding8h,
+		MarkPrice:            ticker.MarkPrice,
+		NextFundingTime:      nextFunding(ts),
+		FundingIntervalHours: 8,
+		Timestamp:            ts,
+	})
+}
+
+// =============================================================================
+// Helper Functions
+// =============================================================================
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseLevels converts [price, amount] levels, amounts in USD for inverse
+// contracts, to base units
+func parseLevels(levels [][]float64, inverse bool) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 || level[0] <= 0 || level[1] <= 0 {
+			continue
+		}
+		qty := level[1]
+		if inverse {
+			qty /= level[0]
+		}
+		result = append(result, connector.PriceLevel{Price: level[0], Quantity: qty})
+	}
+	return result
+}
+
+func updateSpread(ob *connector.Orderbook) {
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+}
+
+// nextFunding returns the next 8h boundary. Deribit accrues funding
+// continuously rather than at set times; the boundary keeps its 8h rate
+// comparable with venues that settle every 8 hours.
+func nextFunding(now time.Time) time.Time {
+	return now.UTC().Truncate(8 * time.Hour).Add(8 * time.Hour)
+}
+
+// inverse reports whether a symbol is a coin-margined perpetual, BTC-PERPETUAL
+// rather than BTC_USDC-PERPETUAL
+func inverse(symbol string) bool {
+	return connector.ParseSymbol(connector.Deribit, symbol).Inverse()
+}
+
+// normalizeSymbol converts BTC-PERPETUAL to BTC-USD-BTC and
+// BTC_USDC-PERPETUAL to BTC, matching other venues' USDT perpetuals
+func normalizeSymbol(symbol string) string {
+	return connector.ParseSymbol(connector.Deribit, symbol).Canonical()
+}
@@ -0,0 +1,20 @@
+package deribit
+
+import "testing"
+
+// FuzzProcessMessage feeds arbitrary frames through the public WebSocket
+// decoder, for both coin-margined and linear perpetuals
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"book.BTC-PERPETUAL.none.20.100ms","data":{"timestamp":1695716059516,"instrument_name":"BTC-PERPETUAL","change_id":1,"bids":[[26000.5,30000]],"asks":[[26001,12000]]}}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"book.BTC_USDC-PERPETUAL.none.20.100ms","data":{"timestamp":1695716059516,"bids":[[26000.5,0.3]],"asks":[[26001,0]]}}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"trades.BTC-PERPETUAL.100ms","data":[{"trade_id":"1","timestamp":1695716059516,"price":26000.5,"amount":100,"direction":"sell"}]}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"ticker.ETH-PERPETUAL.agg2","data":{"timestamp":1695716059516,"funding_8h":0.0001,"mark_price":1600}}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"heartbeat","params":{"type":"test_request"}}`))
+	f.Add([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":11050,"message":"bad_request"}}`))
+	f.Add([]byte(`{"method":"subscription","params":{"channel":"book","data":{"bids":[[1]],"asks":[[]]}}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewDeribitConnector([]string{"BTC-PERPETUAL"}, 20)
+		c.processMessage(data)
+	})
+}
@@ -0,0 +1,29 @@
+package connector
+
+import (
+	"fmt"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// SymbolError is an error attributable to a single symbol on an exchange,
+// such as a rejected subscription or an unknown instrument
+type SymbolError struct {
+	Exchange ExchangeID
+	Symbol   string
+	Err      error
+}
+
+func (e *SymbolError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Exchange, e.Symbol, e.Err)
+}
+
+func (e *SymbolError) Unwrap() error {
+	return e.Err
+}
+
+// EmitSymbolError sends a symbol-scoped error to the error handler
+func (c *BaseConnector) EmitSymbolError(symbol string, err error) {
+	metrics.SymbolErrors.WithLabelValues(string(c.config.ExchangeID)).Inc()
+	c.EmitError(&SymbolError{Exchange: c.config.ExchangeID, Symbol: symbol, Err: err})
+}
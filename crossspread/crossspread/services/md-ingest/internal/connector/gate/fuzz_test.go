@@ -0,0 +1,29 @@
+package gate
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary frames through the market data client
+// with the connector's adapter attached, so decoding and conversion to
+// connector types both run.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"time":1615366381,"time_ms":1615366381417,"channel":"futures.order_book","event":"all","result":{"t":1615366381417,"contract":"BTC_USDT","id":2517661101,"asks":[{"p":"49525.6","s":7726}],"bids":[{"p":"49525.5","s":8000},{"p":"49525.4","s":0}]}}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.order_book_update","event":"update","result":{"t":1615366381417,"s":"BTC_USDT","U":2517661101,"u":2517661113,"b":[{"p":"54672.1","s":0}],"a":[{"p":"54743.6","s":-5}]}}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.trades","event":"update","result":[{"size":-108,"id":27753479,"create_time":1545136464,"create_time_ms":1545136464123,"price":"96.4","contract":"BTC_USDT"}]}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.tickers","event":"update","result":[{"contract":"BTC_USDT","last":"118.4","mark_price":"118.35","funding_rate":"-0.000114"}]}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.book_ticker","event":"update","result":{"t":1615366379123,"u":2517661076,"s":"BTC_USDT","b":"54696.6","B":37000,"a":"54696.7","A":47061}}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.candlesticks","event":"update","result":[{"t":1545129300,"v":27525555,"c":"95.4","h":"96.9","l":"89.5","o":"94.3","n":"1m_BTC_USD"}]}`))
+	f.Add([]byte(`{"time":1615366380,"channel":"futures.order_book","event":"subscribe","error":{"code":2,"message":"unknown contract FOO_USDT"},"result":null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		a := &marketDataHandlerAdapter{connector: NewGateConnector([]string{"BTC_USDT"}, 20, SettleUSDT)}
+		client := NewWSMarketDataClient("", &WSMarketDataHandler{
+			OnTicker:     a.OnTicker,
+			OnOrderBook:  a.OnOrderBook,
+			OnTrade:      a.OnTrade,
+			OnBookTicker: a.OnBookTicker,
+			OnKline:      a.OnKline,
+			OnError:      a.OnError,
+		})
+		client.handleMessage(SettleUSDT, data)
+	})
+}
@@ -105,10 +105,8 @@ func (a *marketDataHandlerAdapter) OnOrderBook(settle string, book *WSOrderBookD
 
 func (a *marketDataHandlerAdapter) OnTrade(settle string, trade *WSTradeData) {
 	price, _ := strconv.ParseFloat(trade.Price, 64)
-	side := "buy"
-	if trade.Size < 0 {
-		side = "sell"
-	}
+	// Gate signs the size with the taker direction
+	side := connector.SideFromSize(float64(trade.Size))
 
 	t := &connector.Trade{
 		ExchangeID: connector.GateIO,
@@ -261,9 +259,10 @@ func (c *GateConnector) FetchInstruments(ctx context.Context) ([]connector.Instr
 
 	var instruments []connector.Instrument
 	for _, contract := range contracts {
-		// Skip delisting contracts
+		// Delisting contracts stay listed so positions can still be closed
+		status := connector.StatusTrading
 		if contract.InDelisting {
-			continue
+			status = connector.StatusDelisting
 		}
 
 		// Parse tick size
@@ -272,6 +271,7 @@ func (c *GateConnector) FetchInstruments(ctx context.Context) ([]connector.Instr
 		// Parse fees
 		makerFee, _ := strconv.ParseFloat(contract.MakerFeeRate, 64)
 		takerFee, _ := strconv.ParseFloat(contract.TakerFeeRate, 64)
+		maintenanceRate, _ := strconv.ParseFloat(contract.MaintenanceRate, 64)
 
 		// Parse canonical: BTC_USDT -> BTC-USDT-PERP
 		parts := strings.Split(contract.Name, "_")
@@ -282,17 +282,21 @@ func (c *GateConnector) FetchInstruments(ctx context.Context) ([]connector.Instr
 		}
 
 		inst := connector.Instrument{
-			ExchangeID:     connector.GateIO,
-			Symbol:         contract.Name,
-			Canonical:      fmt.Sprintf("%s-%s-PERP", base, quote),
-			BaseAsset:      base,
-			QuoteAsset:     quote,
-			InstrumentType: "perpetual",
-			TickSize:       tickSize,
-			LotSize:        1, // Gate uses contracts
-			ContractSize:   1,
-			TakerFee:       takerFee,
-			MakerFee:       makerFee,
+			ExchangeID:      connector.GateIO,
+			Symbol:          contract.Name,
+			Canonical:       fmt.Sprintf("%s-%s-PERP", base, quote),
+			BaseAsset:       base,
+			QuoteAsset:      quote,
+			InstrumentType:  "perpetual",
+			TickSize:        tickSize,
+			LotSize:         1, // Gate uses contracts
+			ContractSize:    1,
+			TakerFee:        takerFee,
+			MakerFee:        makerFee,
+			Status:          status,
+			MaintenanceRate: maintenanceRate,
+
+			FundingIntervalHours: contract.FundingInterval / 3600,
 		}
 		instruments = append(instruments, inst)
 	}
@@ -487,3 +491,50 @@ func abs(n int64) int64 {
 	}
 	return n
 }
+
+// FetchKlines fetches the most recent candles of a contract. Gate reports
+// volume in contracts.
+func (c *GateConnector) FetchKlines(ctx context.Context, symbol string, interval time.Duration, limit int) ([]connector.Kline, error) {
+	gateInterval, ok := klineIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("gate: unsupported kline interval %s", interval)
+	}
+
+	candles, err := c.getRESTClient().GetCandlesticks(ctx, c.settle, symbol, gateInterval, 0, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	klines := make([]connector.Kline, 0, len(candles))
+	for _, candle := range candles {
+		open, _ := strconv.ParseFloat(candle.O, 64)
+		high, _ := strconv.ParseFloat(candle.H, 64)
+		low, _ := strconv.ParseFloat(candle.L, 64)
+		closePrice, _ := strconv.ParseFloat(candle.C, 64)
+
+		klines = append(klines, connector.Kline{
+			ExchangeID: connector.GateIO,
+			Symbol:     symbol,
+			Start:      time.Unix(candle.T, 0),
+			Interval:   interval,
+			Open:       open,
+			High:       high,
+			Low:        low,
+			Close:      closePrice,
+			Volume:     float64(candle.V),
+			Contracts:  true,
+		})
+	}
+	return klines, nil
+}
+
+// klineIntervals maps candle intervals to Gate candlestick intervals
+var klineIntervals = map[time.Duration]string{
+	10 * time.Second: Interval10s,
+	time.Minute:      Interval1m,
+	5 * time.Minute:  Interval5m,
+	15 * time.Minute: Interval15m,
+	time.Hour:        Interval1h,
+	4 * time.Hour:    Interval4h,
+	24 * time.Hour:   Interval1d,
+}
@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -117,6 +119,11 @@ func (c *WSMarketDataClient) connectInternal(settle string) error {
 
 // readLoop reads messages from the WebSocket connection
 func (c *WSMarketDataClient) readLoop(wsConn *wsConnection) {
+	// A panic parsing a frame reconnects the settle's socket like a read error
+	defer connector.Recover(connector.GateIO, "readLoop", func() {
+		wsConn.conn.Close()
+		c.handleReconnect(wsConn.settle)
+	})
 	defer func() {
 		wsConn.mu.Lock()
 		wsConn.isConnected = false
@@ -0,0 +1,18 @@
+package gateio
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary frames through the public WebSocket
+// decoder. Any panic is a bug: the read loop must survive malformed input.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"time":1615366379,"time_ms":1615366379123,"channel":"futures.order_book","event":"all","result":{"t":1615366379123,"contract":"BTC_USDT","s":"BTC_USDT","u":123,"b":[{"p":"54000.1","s":10},{"p":"53999","s":0}],"a":[{"p":"54001","s":5}]}}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.order_book_update","event":"update","result":{"t":1615366381417,"s":"ETH_USDT","u":2517661101,"b":[{"p":"1","s":-3}],"a":[]}}`))
+	f.Add([]byte(`{"time":1615366380,"channel":"futures.order_book","event":"subscribe","error":{"code":2,"message":"unknown contract FOO_USDT"},"result":null}`))
+	f.Add([]byte(`{"time":1615366381,"channel":"futures.order_book_update","event":"update","result":{"t":1615366381417,"s":"BTC_USDT","U":2517661101,"u":2517661113,"b":[{"p":"54000.1","s":0}],"a":[{"p":"54001","s":7}]}}`))
+	f.Add([]byte(`{"channel":"futures.pong","event":"","result":null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewGateIOConnector([]string{"BTC_USDT"}, 20)
+		c.handleMessage(data)
+	})
+}
@@ -5,12 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/orderbook"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
@@ -19,6 +19,9 @@ import (
 const (
 	wsBaseURL   = "wss://fx-ws.gateio.ws/v4/ws/usdt"
 	restBaseURL = "https://api.gateio.ws"
+
+	gateBookDepth     = 20  // Levels subscribed and emitted
+	gateSnapshotDepth = 100 // Levels fetched to resync
 )
 
 // GateIOConnector implements the Connector interface for Gate.io Futures
@@ -26,6 +29,7 @@ type GateIOConnector struct {
 	*connector.BaseConnector
 	conn          *websocket.Conn
 	subscriptions map[string]bool
+	books         *orderbook.Engine // Local books from snapshot and delta messages
 	mu            sync.RWMutex
 	done          chan struct{}
 }
@@ -45,8 +49,10 @@ func NewGateIOConnector(symbols []string, depthLevels int) *GateIOConnector {
 	c := &GateIOConnector{
 		BaseConnector: connector.NewBaseConnector(config),
 		subscriptions: make(map[string]bool),
+		books:         orderbook.NewEngine(orderbook.DefaultConfig()),
 		done:          make(chan struct{}),
 	}
+	c.books.SetResync(c.resync)
 
 	for _, s := range symbols {
 		c.subscriptions[s] = true
@@ -59,11 +65,7 @@ func NewGateIOConnector(symbols []string, depthLevels int) *GateIOConnector {
 func (c *GateIOConnector) Connect(ctx context.Context) error {
 	log.Info().Str("url", wsBaseURL).Msg("Connecting to Gate.io WebSocket")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, wsBaseURL, nil)
+	conn, err := c.Dial(ctx, wsBaseURL, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
@@ -79,8 +81,8 @@ func (c *GateIOConnector) Connect(ctx context.Context) error {
 		}
 	}
 
-	go c.readLoop()
-	go c.pingLoop()
+	c.Go(c.readLoop)
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -92,7 +94,7 @@ func (c *GateIOConnector) subscribeSymbol(symbol string) error {
 		"time":    time.Now().Unix(),
 		"channel": "futures.order_book_update",
 		"event":   "subscribe",
-		"payload": []string{symbol, "100ms", "20"},
+		"payload": []string{symbol, "100ms", strconv.Itoa(gateBookDepth)},
 	}
 	return c.conn.WriteJSON(msg)
 }
@@ -127,6 +129,11 @@ func (c *GateIOConnector) Unsubscribe(symbols []string) error {
 	return nil
 }
 
+// Books returns the local books built from snapshot and delta messages
+func (c *GateIOConnector) Books() *orderbook.Engine {
+	return c.books
+}
+
 // FetchInstruments fetches all USDT perpetual futures
 func (c *GateIOConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
 	url := fmt.Sprintf("%s/api/v4/futures/usdt/contracts", restBaseURL)
@@ -152,6 +159,7 @@ func (c *GateIOConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 		OrderPriceRound string  `json:"order_price_round"`
 		OrderSizeMin    float64 `json:"order_size_min"`
 		InDelisting     bool    `json:"in_delisting"`
+		FundingInterval int     `json:"funding_interval"` // Seconds
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&contracts); err != nil {
@@ -160,8 +168,9 @@ func (c *GateIOConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 
 	var instruments []connector.Instrument
 	for _, s := range contracts {
+		status := connector.StatusTrading
 		if s.InDelisting {
-			continue
+			status = connector.StatusDelisting
 		}
 
 		takerFee, _ := strconv.ParseFloat(s.TakerFeeRate, 64)
@@ -185,6 +194,9 @@ func (c *GateIOConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 			ContractSize:   multiplier,
 			TakerFee:       takerFee,
 			MakerFee:       makerFee,
+			Status:         status,
+
+			FundingIntervalHours: s.FundingInterval / 3600,
 		}
 		instruments = append(instruments, inst)
 	}
@@ -194,7 +206,7 @@ func (c *GateIOConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 
 // FetchOrderbookSnapshot fetches orderbook via REST API
 func (c *GateIOConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
-	url := fmt.Sprintf("%s/api/v4/futures/usdt/order_book?contract=%s&limit=%d", restBaseURL, symbol, depth)
+	url := fmt.Sprintf("%s/api/v4/futures/usdt/order_book?contract=%s&limit=%d&with_id=true", restBaseURL, symbol, depth)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -208,6 +220,7 @@ func (c *GateIOConnector) FetchOrderbookSnapshot(ctx context.Context, symbol str
 	defer resp.Body.Close()
 
 	var result struct {
+		ID      int64   `json:"id"` // Update ID the snapshot is at
 		Current float64 `json:"current"`
 		Bids    []struct {
 			P string `json:"p"`
@@ -227,6 +240,7 @@ func (c *GateIOConnector) FetchOrderbookSnapshot(ctx context.Context, symbol str
 		ExchangeID: connector.GateIO,
 		Symbol:     symbol,
 		Timestamp:  time.Now(),
+		SequenceID: result.ID,
 		IsSnapshot: true,
 	}
 
@@ -277,6 +291,7 @@ func (c *GateIOConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 	var contracts []struct {
 		Name             string `json:"name"`
 		FundingRate      string `json:"funding_rate"`
+		MarkPrice        string `json:"mark_price"`
 		FundingNextApply int64  `json:"funding_next_apply"`
 		FundingInterval  int    `json:"funding_interval"`
 	}
@@ -288,10 +303,12 @@ func (c *GateIOConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 	var rates []connector.FundingRate
 	for _, d := range contracts {
 		rate, _ := strconv.ParseFloat(d.FundingRate, 64)
+		markPrice, _ := strconv.ParseFloat(d.MarkPrice, 64)
 		rates = append(rates, connector.FundingRate{
 			ExchangeID:           connector.GateIO,
 			Symbol:               d.Name,
 			FundingRate:          rate,
+			MarkPrice:            markPrice,
 			NextFundingTime:      time.Unix(d.FundingNextApply, 0),
 			FundingIntervalHours: d.FundingInterval / 3600,
 			Timestamp:            time.Now(),
@@ -302,6 +319,7 @@ func (c *GateIOConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 }
 
 func (c *GateIOConnector) readLoop() {
+	defer c.Recover("readLoop")
 	defer c.SetConnected(false)
 
 	for {
@@ -309,7 +327,7 @@ func (c *GateIOConnector) readLoop() {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("websocket read error: %w", err))
 				return
@@ -344,10 +362,11 @@ func (c *GateIOConnector) handleMessage(message []byte) {
 		Channel string `json:"channel"`
 		Event   string `json:"event"`
 		Result  struct {
-			T int64  `json:"t"` // timestamp
-			S string `json:"s"` // contract name
-			U int64  `json:"u"` // update ID
-			B []struct {
+			T     int64  `json:"t"` // timestamp
+			S     string `json:"s"` // contract name
+			First int64  `json:"U"` // first update ID, order_book_update only
+			Last  int64  `json:"u"` // last update ID
+			B     []struct {
 				P string `json:"p"`
 				S int64  `json:"s"`
 			} `json:"b"` // bids
@@ -376,55 +395,65 @@ func (c *GateIOConnector) handleMessage(message []byte) {
 		return
 	}
 
-	ob := &connector.Orderbook{
-		ExchangeID: connector.GateIO,
-		Symbol:     symbol,
-		Canonical:  extractCanonical(symbol),
-		Timestamp:  time.UnixMilli(msg.Result.T),
-		SequenceID: msg.Result.U,
-		IsSnapshot: msg.Event == "all",
+	// A zero size removes the level
+	u := orderbook.Update{
+		Exchange:  connector.GateIO,
+		Symbol:    symbol,
+		Canonical: extractCanonical(symbol),
+		Snapshot:  msg.Event == "all",
+		FirstSeq:  msg.Result.First,
+		LastSeq:   msg.Result.Last,
+		Timestamp: time.UnixMilli(msg.Result.T),
 	}
-
 	for _, b := range msg.Result.B {
 		price, _ := strconv.ParseFloat(b.P, 64)
-		if b.S > 0 { // Only add non-zero sizes
-			ob.Bids = append(ob.Bids, connector.PriceLevel{
-				Price:    price,
-				Quantity: float64(b.S),
-			})
-		}
+		u.Bids = append(u.Bids, connector.PriceLevel{Price: price, Quantity: float64(b.S)})
 	}
-
 	for _, a := range msg.Result.A {
 		price, _ := strconv.ParseFloat(a.P, 64)
-		if a.S > 0 { // Only add non-zero sizes
-			ob.Asks = append(ob.Asks, connector.PriceLevel{
-				Price:    price,
-				Quantity: float64(a.S),
-			})
+		u.Asks = append(u.Asks, connector.PriceLevel{Price: price, Quantity: float64(a.S)})
+	}
+
+	if err := c.books.Apply(u); err != nil {
+		if err == orderbook.ErrGap {
+			log.Warn().Str("symbol", symbol).Int64("U", u.FirstSeq).Msg("Gate.io orderbook sequence gap, fetching snapshot")
 		}
+		return
+	}
+	if ob := c.books.Orderbook(connector.GateIO, symbol, gateBookDepth); ob != nil {
+		c.EmitOrderbook(ob)
 	}
+}
 
-	// Sort bids descending
-	sort.Slice(ob.Bids, func(i, j int) bool {
-		return ob.Bids[i].Price > ob.Bids[j].Price
-	})
-	// Sort asks ascending
-	sort.Slice(ob.Asks, func(i, j int) bool {
-		return ob.Asks[i].Price < ob.Asks[j].Price
-	})
+// resync fetches a REST snapshot of a symbol's book; buffered updates
+// following its ID are applied on top
+func (c *GateIOConnector) resync(_ connector.ExchangeID, symbol string) {
+	if !c.IsConnected() {
+		return // The snapshot after reconnecting resyncs
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	if len(ob.Bids) > 0 {
-		ob.BestBid = ob.Bids[0].Price
+	ob, err := c.FetchOrderbookSnapshot(ctx, symbol, gateSnapshotDepth)
+	if err != nil {
+		c.EmitSymbolError(symbol, fmt.Errorf("orderbook resync: %w", err))
+		return
 	}
-	if len(ob.Asks) > 0 {
-		ob.BestAsk = ob.Asks[0].Price
+	if err := c.books.Apply(orderbook.Update{
+		Exchange:  connector.GateIO,
+		Symbol:    symbol,
+		Canonical: extractCanonical(symbol),
+		Snapshot:  true,
+		LastSeq:   ob.SequenceID,
+		Bids:      ob.Bids,
+		Asks:      ob.Asks,
+		Timestamp: ob.Timestamp,
+	}); err != nil {
+		return
 	}
-	if ob.BestBid > 0 && ob.BestAsk > 0 {
-		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	if book := c.books.Orderbook(connector.GateIO, symbol, gateBookDepth); book != nil {
+		c.EmitOrderbook(book)
 	}
-
-	c.EmitOrderbook(ob)
 }
 
 // extractCanonical extracts base asset from symbol (BTC_USDT -> BTC)
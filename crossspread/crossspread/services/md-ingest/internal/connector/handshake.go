@@ -0,0 +1,31 @@
+package connector
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// HandshakeObserver receives the URL and response headers of every
+// WebSocket handshake, e.g. to watch for API deprecation headers
+type HandshakeObserver func(id ExchangeID, u *url.URL, header http.Header)
+
+var handshakeObserver atomic.Pointer[HandshakeObserver]
+
+// SetHandshakeObserver sets the observer called after each successful Dial
+func SetHandshakeObserver(observer HandshakeObserver) {
+	handshakeObserver.Store(&observer)
+}
+
+// observeHandshake hands a handshake response to the observer, if any
+func observeHandshake(id ExchangeID, rawURL string, resp *http.Response) {
+	observer := handshakeObserver.Load()
+	if observer == nil || *observer == nil || resp == nil {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	(*observer)(id, u, resp.Header)
+}
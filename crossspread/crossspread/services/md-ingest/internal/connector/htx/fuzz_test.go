@@ -0,0 +1,27 @@
+package htx
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// FuzzHandleMessage feeds arbitrary (already gunzipped) frames through the
+// public WebSocket decoder. Any panic is a bug.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"ch":"market.BTC-USDT.depth.step0","ts":1603707576468,"tick":{"mrid":1,"id":1603707576,"bids":[[13081.9,1],[13081.8,20]],"asks":[[13082,95],[13082.1,3]],"ts":1603707576467,"version":1,"ch":"market.BTC-USDT.depth.step0"}}`))
+	f.Add([]byte(`{"ch":"market.ETH-USDT.depth.step0","ts":1,"tick":{"bids":[[1]],"asks":[[]]}}`))
+	f.Add([]byte(`{"id":"1","status":"error","err-code":"bad-request","err-msg":"invalid topic market.FOO-USDT.depth.step0","ts":1}`))
+	f.Add([]byte(`{"ch":"market..depth","tick":{"bids":[]}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Pings are answered on the socket, which is not connected here.
+		var ping struct {
+			Ping int64 `json:"ping"`
+		}
+		if json.Unmarshal(data, &ping) == nil && ping.Ping > 0 {
+			t.Skip()
+		}
+		c := NewHTXConnector([]string{"BTC-USDT"}, 20)
+		c.handleMessage(data)
+	})
+}
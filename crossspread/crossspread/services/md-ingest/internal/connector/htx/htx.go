@@ -62,11 +62,7 @@ func NewHTXConnector(symbols []string, depthLevels int) *HTXConnector {
 func (c *HTXConnector) Connect(ctx context.Context) error {
 	log.Info().Str("url", wsBaseURL).Msg("Connecting to HTX WebSocket")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, wsBaseURL, nil)
+	conn, err := c.Dial(ctx, wsBaseURL, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
@@ -82,7 +78,7 @@ func (c *HTXConnector) Connect(ctx context.Context) error {
 		}
 	}
 
-	go c.readLoop()
+	c.Go(c.readLoop)
 
 	return nil
 }
@@ -278,6 +274,7 @@ func (c *HTXConnector) FetchFundingRates(ctx context.Context) ([]connector.Fundi
 }
 
 func (c *HTXConnector) readLoop() {
+	defer c.Recover("readLoop")
 	defer c.SetConnected(false)
 
 	for {
@@ -285,7 +282,7 @@ func (c *HTXConnector) readLoop() {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("websocket read error: %w", err))
 				return
@@ -0,0 +1,19 @@
+package kucoin
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary frames through the public WebSocket
+// decoder. KuCoin levels arrive as mixed string/number arrays, so element
+// types are fuzzed as well as lengths.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"type":"message","topic":"/contractMarket/level2Depth50:XBTUSDTM","subject":"level2","data":{"sequence":1,"bids":[["26000.5",120],["26000",5]],"asks":[["26001",7]],"timestamp":1695716059516}}`))
+	f.Add([]byte(`{"type":"message","topic":"/contractMarket/level2Depth50:ETHUSDTM","subject":"level2","data":{"bids":[[26000.5,"1"],[1],[]],"asks":[[null,{}]]}}`))
+	f.Add([]byte(`{"id":"1","type":"error","code":404,"data":"topic /contractMarket/level2Depth50:FOOUSDTM is not found"}`))
+	f.Add([]byte(`{"id":"1","type":"welcome"}`))
+	f.Add([]byte(`{"type":"message","topic":"","subject":"level2","data":{}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewKuCoinConnector([]string{"XBTUSDTM"}, 50)
+		c.handleMessage(data)
+	})
+}
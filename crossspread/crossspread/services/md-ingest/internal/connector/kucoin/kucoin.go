@@ -60,12 +60,8 @@ func (c *KuCoinConnector) Connect(ctx context.Context) error {
 
 	log.Info().Str("endpoint", c.wsEndpoint).Msg("Connecting to KuCoin WebSocket")
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
 	url := fmt.Sprintf("%s?token=%s", c.wsEndpoint, c.token)
-	conn, _, err := dialer.DialContext(ctx, url, nil)
+	conn, err := c.Dial(ctx, url, nil)
 	if err != nil {
 		return fmt.Errorf("websocket dial failed: %w", err)
 	}
@@ -82,8 +78,8 @@ func (c *KuCoinConnector) Connect(ctx context.Context) error {
 	}
 
 	// Start reading messages
-	go c.readLoop()
-	go c.pingLoop()
+	c.Go(c.readLoop)
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -203,6 +199,7 @@ func (c *KuCoinConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 			TakerFeeRate   json.Number `json:"takerFeeRate"`
 			MakerFeeRate   json.Number `json:"makerFeeRate"`
 			FundingFeeRate json.Number `json:"fundingFeeRate"`
+			MaintainMargin float64     `json:"maintainMargin"`
 		} `json:"data"`
 	}
 
@@ -220,17 +217,18 @@ func (c *KuCoinConnector) FetchInstruments(ctx context.Context) ([]connector.Ins
 		makerFee, _ := s.MakerFeeRate.Float64()
 
 		inst := connector.Instrument{
-			ExchangeID:     connector.KuCoin,
-			Symbol:         s.Symbol,
-			Canonical:      fmt.Sprintf("%s-%s-PERP", s.BaseCurrency, s.QuoteCurrency),
-			BaseAsset:      s.BaseCurrency,
-			QuoteAsset:     s.QuoteCurrency,
-			InstrumentType: "perpetual",
-			TickSize:       s.TickSize,
-			LotSize:        s.LotSize,
-			ContractSize:   s.Multiplier,
-			TakerFee:       takerFee,
-			MakerFee:       makerFee,
+			ExchangeID:      connector.KuCoin,
+			Symbol:          s.Symbol,
+			Canonical:       fmt.Sprintf("%s-%s-PERP", s.BaseCurrency, s.QuoteCurrency),
+			BaseAsset:       s.BaseCurrency,
+			QuoteAsset:      s.QuoteCurrency,
+			InstrumentType:  "perpetual",
+			TickSize:        s.TickSize,
+			LotSize:         s.LotSize,
+			ContractSize:    s.Multiplier,
+			TakerFee:        takerFee,
+			MakerFee:        makerFee,
+			MaintenanceRate: s.MaintainMargin,
 		}
 
 		instruments = append(instruments, inst)
@@ -312,6 +310,7 @@ func (c *KuCoinConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 		Data []struct {
 			Symbol              string      `json:"symbol"`
 			FundingFeeRate      json.Number `json:"fundingFeeRate"` // Can be string or number
+			MarkPrice           float64     `json:"markPrice"`
 			NextFundingRateTime int64       `json:"nextFundingRateTime"`
 		} `json:"data"`
 	}
@@ -327,6 +326,7 @@ func (c *KuCoinConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 			ExchangeID:           connector.KuCoin,
 			Symbol:               d.Symbol,
 			FundingRate:          rate,
+			MarkPrice:            d.MarkPrice,
 			NextFundingTime:      time.UnixMilli(d.NextFundingRateTime),
 			FundingIntervalHours: 8,
 			Timestamp:            time.Now(),
@@ -338,6 +338,7 @@ func (c *KuCoinConnector) FetchFundingRates(ctx context.Context) ([]connector.Fu
 
 // readLoop reads messages from WebSocket
 func (c *KuCoinConnector) readLoop() {
+	defer c.Recover("readLoop")
 	defer c.SetConnected(false)
 
 	for {
@@ -345,7 +346,7 @@ func (c *KuCoinConnector) readLoop() {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("websocket read error: %w", err))
 				return
@@ -570,6 +571,8 @@ func (c *KuCoinConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pr
 			Price        string `json:"price"`
 			BestBidPrice string `json:"bestBidPrice"`
 			BestAskPrice string `json:"bestAskPrice"`
+			BestBidSize  int    `json:"bestBidSize"`
+			BestAskSize  int    `json:"bestAskSize"`
 			Size         int    `json:"size"`
 			Ts           int64  `json:"ts"`
 		} `json:"data"`
@@ -592,6 +595,8 @@ func (c *KuCoinConnector) FetchPriceTickers(ctx context.Context) ([]connector.Pr
 			Price:      price,
 			BidPrice:   bidPrice,
 			AskPrice:   askPrice,
+			BidSize:    float64(t.BestBidSize),
+			AskSize:    float64(t.BestAskSize),
 			Volume24h:  float64(t.Size),
 			Timestamp:  time.Now(),
 		})
@@ -0,0 +1,26 @@
+package lbank
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary frames through the market data client
+// with the connector's callbacks attached, so decoding and conversion to
+// connector types both run.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"depth":{"asks":[[30740.5,0.58],[30741,1.2]],"bids":[[30740,5.2],[30739.5,0]]},"count":100,"type":"depth","pair":"btc_usdt","SERVER":"V2","TS":"2023-07-12T17:49:22.722"}`))
+	f.Add([]byte(`{"trade":{"volume":6.3607,"amount":77148.9303,"price":12129,"direction":"sell","TS":"2023-07-12T19:55:49.460"},"type":"trade","pair":"btc_usdt","SERVER":"V2","TS":"2023-07-12T19:55:49.466"}`))
+	f.Add([]byte(`{"tick":{"to_cny":76643.5,"high":12368.77,"vol":10476.5,"low":11812.32,"change":2.44,"usd":12121.5,"to_usd":12121.5,"dir":"buy","turnover":125719123.6,"latest":12121.5,"cny":76643.5},"type":"tick","pair":"btc_usdt","SERVER":"V2","TS":"2023-07-12T20:18:10.089"}`))
+	f.Add([]byte(`{"depth":{"asks":[[1]],"bids":[[]]},"type":"depth","pair":"btc_usdt","TS":"bad"}`))
+	f.Add([]byte(`{"action":"pong","pong":"0ca8f854-7ba7-4341-9d86-d3327e52804e"}`))
+	f.Add([]byte(`{"action":"action","errorCode":10001,"message":"pair not found"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewLBankConnector([]string{"btc_usdt"}, 20)
+		client := NewWsMarketDataClient(&ClientConfig{UseContractAPI: true}, &MarketDataHandler{
+			OnDepth:  c.handleDepthUpdate,
+			OnTrade:  c.handleTradeUpdate,
+			OnTicker: c.handleTickerUpdate,
+			OnError:  c.EmitError,
+		})
+		client.handleMessage(data)
+	})
+}
@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
@@ -36,6 +38,7 @@ type WsMarketDataClient struct {
 	reconnectDelay time.Duration
 
 	mu          sync.RWMutex
+	writeMu     sync.Mutex // One writer at a time on conn
 	done        chan struct{}
 	ctx         context.Context
 	cancel      context.CancelFunc
@@ -332,6 +335,8 @@ func (c *WsMarketDataClient) sendMessage(msg interface{}) error {
 	}
 
 	log.Debug().Str("msg", string(data)).Msg("Sending WS message")
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	return conn.WriteMessage(websocket.TextMessage, data)
 }
 
@@ -392,6 +397,13 @@ func (c *WsMarketDataClient) resubscribe() {
 
 // readLoop reads messages from WebSocket
 func (c *WsMarketDataClient) readLoop() {
+	defer connector.Recover(connector.LBank, "readLoop", func() {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
 	defer func() {
 		c.mu.Lock()
 		c.isConnected = false
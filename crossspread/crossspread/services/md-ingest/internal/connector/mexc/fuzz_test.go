@@ -0,0 +1,24 @@
+package mexc
+
+import "testing"
+
+// FuzzHandleMessage feeds arbitrary frames through the market data client
+// with the connector's adapter attached, so decoding and conversion to
+// connector types both run.
+func FuzzHandleMessage(f *testing.F) {
+	f.Add([]byte(`{"channel":"push.depth","data":{"asks":[[6859.5,3251,1]],"bids":[[6859,120,2],[6858.5,0,0]],"version":96801927},"symbol":"BTC_USDT","ts":1587442022003}`))
+	f.Add([]byte(`{"channel":"push.depth.full","data":{"asks":[[6859.5,3251,1]],"bids":[[6859,120,2]],"version":96801927},"symbol":"BTC_USDT","ts":1587442022003}`))
+	f.Add([]byte(`{"channel":"push.deal","data":{"M":1,"O":1,"T":1,"p":6866.5,"t":1587442049632,"v":2096},"symbol":"BTC_USDT","ts":1587442022003}`))
+	f.Add([]byte(`{"channel":"push.ticker","data":{"symbol":"BTC_USDT","lastPrice":6865.5,"bid1":6865,"ask1":6866.5,"fundingRate":0.0008},"symbol":"BTC_USDT","ts":1587442022003}`))
+	f.Add([]byte(`{"channel":"push.kline","data":{"a":233.74,"c":6885,"h":6910.5,"interval":"Min60","l":6885,"o":6894.5,"q":1611754,"symbol":"BTC_USDT","t":1587448800},"symbol":"BTC_USDT"}`))
+	f.Add([]byte(`{"channel":"push.depth","data":{"asks":[[1]],"bids":[[]]},"symbol":"BTC_USDT"}`))
+	f.Add([]byte(`{"channel":"rs.error","data":"contract not exists","ts":1587442022003}`))
+	f.Add([]byte(`{"channel":"pong","data":1587453241453}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		client := NewMarketDataWSClient(MarketDataWSConfig{
+			Handler: &marketDataHandlerAdapter{connector: NewMEXCConnector([]string{"BTC_USDT"}, 20)},
+		})
+		client.handleMessage(data)
+	})
+}
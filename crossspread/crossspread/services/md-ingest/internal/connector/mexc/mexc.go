@@ -244,17 +244,18 @@ func (c *MEXCConnector) FetchInstruments(ctx context.Context) ([]connector.Instr
 			}
 
 			inst := connector.Instrument{
-				ExchangeID:     connector.MEXC,
-				Symbol:         contract.Symbol,
-				Canonical:      fmt.Sprintf("%s-%s-PERP", contract.BaseCoin, contract.QuoteCoin),
-				BaseAsset:      contract.BaseCoin,
-				QuoteAsset:     contract.QuoteCoin,
-				InstrumentType: "perpetual",
-				TickSize:       contract.PriceUnit,
-				LotSize:        contract.VolUnit,
-				ContractSize:   contract.ContractSize,
-				TakerFee:       contract.TakerFeeRate,
-				MakerFee:       contract.MakerFeeRate,
+				ExchangeID:      connector.MEXC,
+				Symbol:          contract.Symbol,
+				Canonical:       fmt.Sprintf("%s-%s-PERP", contract.BaseCoin, contract.QuoteCoin),
+				BaseAsset:       contract.BaseCoin,
+				QuoteAsset:      contract.QuoteCoin,
+				InstrumentType:  "perpetual",
+				TickSize:        contract.PriceUnit,
+				LotSize:         contract.VolUnit,
+				ContractSize:    contract.ContractSize,
+				TakerFee:        contract.TakerFeeRate,
+				MakerFee:        contract.MakerFeeRate,
+				MaintenanceRate: contract.MaintenanceMarginRate,
 			}
 			instruments = append(instruments, inst)
 		}
@@ -279,17 +280,18 @@ func (c *MEXCConnector) FetchInstruments(ctx context.Context) ([]connector.Instr
 		}
 
 		inst := connector.Instrument{
-			ExchangeID:     connector.MEXC,
-			Symbol:         contract.Symbol,
-			Canonical:      fmt.Sprintf("%s-%s-PERP", contract.BaseCoin, contract.QuoteCoin),
-			BaseAsset:      contract.BaseCoin,
-			QuoteAsset:     contract.QuoteCoin,
-			InstrumentType: "perpetual",
-			TickSize:       contract.PriceUnit,
-			LotSize:        contract.VolUnit,
-			ContractSize:   contract.ContractSize,
-			TakerFee:       contract.TakerFeeRate,
-			MakerFee:       contract.MakerFeeRate,
+			ExchangeID:      connector.MEXC,
+			Symbol:          contract.Symbol,
+			Canonical:       fmt.Sprintf("%s-%s-PERP", contract.BaseCoin, contract.QuoteCoin),
+			BaseAsset:       contract.BaseCoin,
+			QuoteAsset:      contract.QuoteCoin,
+			InstrumentType:  "perpetual",
+			TickSize:        contract.PriceUnit,
+			LotSize:         contract.VolUnit,
+			ContractSize:    contract.ContractSize,
+			TakerFee:        contract.TakerFeeRate,
+			MakerFee:        contract.MakerFeeRate,
+			MaintenanceRate: contract.MaintenanceMarginRate,
 		}
 		instruments = append(instruments, inst)
 	}
@@ -515,10 +517,13 @@ func convertOrderBookLevels(data [][]float64) []connector.PriceLevel {
 }
 
 func getSideString(tradeType int) string {
-	if tradeType == 1 {
-		return "buy"
+	switch tradeType {
+	case 1:
+		return connector.SideBuy
+	case 2:
+		return connector.SideSell
 	}
-	return "sell"
+	return "" // Inferred on emit
 }
 
 // extractCanonical extracts base asset from MEXC symbol (BTC_USDT -> BTC)
@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -147,6 +149,7 @@ func (c *MarketDataWSClient) Close() error {
 // readLoop reads messages from WebSocket
 func (c *MarketDataWSClient) readLoop() {
 	defer c.wg.Done()
+	defer connector.Recover(connector.MEXC, "readLoop", func() { c.conn.Close() })
 	defer c.handleDisconnect()
 
 	for {
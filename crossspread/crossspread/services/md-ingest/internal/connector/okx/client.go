@@ -73,6 +73,7 @@ func NewClient(cfg ClientConfig) *Client {
 			DemoMode: cfg.DemoMode,
 			Handler:  cfg.MarketDataHandler,
 		})
+		client.orderBook.SetResync(client.MarketData.ResyncBook)
 	}
 
 	// Create trading WebSocket client if credentials provided
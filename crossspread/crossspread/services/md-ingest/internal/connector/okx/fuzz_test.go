@@ -0,0 +1,71 @@
+package okx
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// FuzzProcessMessage feeds arbitrary frames through the public WebSocket
+// decoder. Any panic is a bug: the read loop must survive malformed input.
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"arg":{"channel":"books5","instId":"BTC-USDT-SWAP"},"data":[{"asks":[["8476.98","415","0","13"],["8477","7","0","2"]],"bids":[["8476.97","256","0","12"]],"instId":"BTC-USDT-SWAP","ts":"1597026383085"}]}`))
+	f.Add([]byte(`{"arg":{"channel":"books5","instId":"ETH-USDT-SWAP"},"data":[{"asks":[],"bids":[],"ts":""}]}`))
+	f.Add([]byte(`{"event":"subscribe","arg":{"channel":"books5","instId":"BTC-USDT-SWAP"},"connId":"a4d3ae55"}`))
+	f.Add([]byte(`{"event":"error","code":"60018","msg":"Wrong URL or channel:books5,instId:FOO-USDT-SWAP doesn't exist","connId":"a4d3ae55"}`))
+	f.Add([]byte(`{"arg":{"channel":"books5","instId":"BTC-USDT-SWAP"},"data":[{"asks":[["1"]],"bids":[[]],"ts":"1"}]}`))
+	f.Add([]byte(`{"arg":{"channel":"books5","instId":"BTC-USDT-SWAP"},"data":[{"asks":[["3366.8","9","10","3"],["3368","8","3","4"]],"bids":[["3366.1","7","0","3"],["3366","6","3","4"]],"ts":"1597026383085","checksum":-1881014294}]}`))
+	f.Add([]byte(`{"arg":{"channel":"books5","instId":"BTC-USDT-SWAP"},"data":[{"asks":[["3366.8","9","10","3"]],"bids":[["3366.1","7","0","3"]],"ts":"1597026383085","checksum":-1}]}`))
+	f.Add([]byte(`{"arg":{"channel":"books","instId":"BTC-USDT-SWAP"},"action":"snapshot","data":[{"asks":[["8476.98","415","0","13"]],"bids":[["8476.97","256","0","12"]],"ts":"1597026383085","checksum":-855196043,"prevSeqId":-1,"seqId":123456}]}`))
+	f.Add([]byte(`{"arg":{"channel":"books","instId":"BTC-USDT-SWAP"},"action":"update","data":[{"asks":[["8476.98","0","0","0"]],"bids":[["8476.9","5","0","1"]],"ts":"1597026383185","checksum":1,"prevSeqId":123456,"seqId":123457}]}`))
+	f.Add([]byte(`pong`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewOKXConnector([]string{"BTCUSDT", "ETHUSDT"}, 5)
+		c.processMessage(data)
+	})
+}
+
+// FuzzOrderBookLevel covers the array-format level decoder
+// ([price, size, deprecated, orderCount]).
+func FuzzOrderBookLevel(f *testing.F) {
+	f.Add([]byte(`["8476.98","415","0","13"]`))
+	f.Add([]byte(`["8476.98","415"]`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var level OrderBookLevel
+		if err := json.Unmarshal(data, &level); err != nil {
+			return
+		}
+		if _, err := json.Marshal(level); err != nil {
+			t.Fatalf("re-marshal decoded level: %v", err)
+		}
+	})
+}
+
+// FuzzCandlestick covers the array-format candle decoder
+// ([ts, open, high, low, close, vol, volCcy, volCcyQuote, confirm]).
+func FuzzCandlestick(f *testing.F) {
+	f.Add([]byte(`["1597026383085","8533.02","8553.74","8527.17","8548.26","45247","529.5858061","5063.7","1"]`))
+	f.Add([]byte(`["x","1","2","3","4","5","6","7","0"]`))
+	f.Add([]byte(`["1597026383085"]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var candle Candlestick
+		_ = json.Unmarshal(data, &candle)
+	})
+}
+
+// FuzzTimestamp covers timestamps sent either as strings or numbers.
+func FuzzTimestamp(f *testing.F) {
+	f.Add([]byte(`"1597026383085"`))
+	f.Add([]byte(`1597026383085`))
+	f.Add([]byte(`""`))
+	f.Add([]byte(`"1.5e3"`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var ts Timestamp
+		_ = json.Unmarshal(data, &ts)
+	})
+}
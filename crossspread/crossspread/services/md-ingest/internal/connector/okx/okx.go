@@ -5,14 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/orderbook"
 
 	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -23,12 +26,17 @@ const (
 // OKXConnector implements the Connector interface for OKX
 type OKXConnector struct {
 	*connector.BaseConnector
-	conn       *websocket.Conn
-	symbols    []string
-	depth      int
-	mu         sync.RWMutex
-	orderbooks map[string]*connector.Orderbook
-	done       chan struct{}
+	conn    *websocket.Conn
+	symbols []string
+	depth   int
+	mu      sync.RWMutex
+	writeMu sync.Mutex        // One writer at a time on conn
+	books   *orderbook.Engine // Local books of the books channel
+	done    chan struct{}
+
+	// The books channel's levels as sent, per instId, for its checksums
+	checksumMu sync.Mutex
+	checksums  map[string]*connector.ChecksumBook
 }
 
 // NewOKXConnector creates a new OKX connector
@@ -43,22 +51,28 @@ func NewOKXConnector(symbols []string, depth int) *OKXConnector {
 		PingInterval:   25 * time.Second,
 	}
 
-	return &OKXConnector{
+	c := &OKXConnector{
 		BaseConnector: connector.NewBaseConnector(config),
 		symbols:       symbols,
 		depth:         depth,
-		orderbooks:    make(map[string]*connector.Orderbook),
+		books:         orderbook.NewEngine(orderbook.DefaultConfig()),
 		done:          make(chan struct{}),
+		checksums:     make(map[string]*connector.ChecksumBook),
 	}
+	c.books.SetResync(func(_ connector.ExchangeID, symbol string) {
+		instId := c.toOKXSymbol(symbol)
+		log.Warn().
+			Str("exchange", string(connector.OKX)).
+			Str("instId", instId).
+			Msg("Orderbook sequence gap, resubscribing")
+		c.resubscribe(instId)
+	})
+	return c
 }
 
 // Connect establishes WebSocket connection to OKX
 func (c *OKXConnector) Connect(ctx context.Context) error {
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, okxWsURL, nil)
+	conn, err := c.Dial(ctx, c.WsURL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to OKX WebSocket: %w", err)
 	}
@@ -72,10 +86,10 @@ func (c *OKXConnector) Connect(ctx context.Context) error {
 	}
 
 	// Start message handler
-	go c.readMessages()
+	c.Go(c.readMessages)
 
 	// Start ping handler
-	go c.pingLoop()
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -87,11 +101,7 @@ func (c *OKXConnector) ConnectForSymbols(ctx context.Context, symbols []string)
 	c.symbols = symbols
 	c.mu.Unlock()
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, okxWsURL, nil)
+	conn, err := c.Dial(ctx, c.WsURL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to OKX WebSocket: %w", err)
 	}
@@ -105,10 +115,10 @@ func (c *OKXConnector) ConnectForSymbols(ctx context.Context, symbols []string)
 	}
 
 	// Start message handler
-	go c.readMessages()
+	c.Go(c.readMessages)
 
 	// Start ping handler
-	go c.pingLoop()
+	c.Go(c.pingLoop)
 
 	return nil
 }
@@ -123,14 +133,19 @@ func (c *OKXConnector) Disconnect() error {
 	return nil
 }
 
-// Subscribe subscribes to orderbook updates for symbols
+// Subscribe subscribes to orderbook updates for symbols and
+// keeps them for reconnects
 func (c *OKXConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+
 	args := make([]map[string]string, 0, len(symbols))
 	for _, symbol := range symbols {
 		// OKX uses format: BTC-USDT-SWAP for perpetuals
 		instId := c.toOKXSymbol(symbol)
 		args = append(args, map[string]string{
-			"channel": "books5", // Top 5 levels, fast updates
+			"channel": c.channel(),
 			"instId":  instId,
 		})
 	}
@@ -140,16 +155,20 @@ func (c *OKXConnector) Subscribe(symbols []string) error {
 		"args": args,
 	}
 
-	return c.conn.WriteJSON(msg)
+	return c.writeJSON(msg)
 }
 
-// Unsubscribe removes subscriptions
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
 func (c *OKXConnector) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+
 	args := make([]map[string]string, 0, len(symbols))
 	for _, symbol := range symbols {
 		instId := c.toOKXSymbol(symbol)
 		args = append(args, map[string]string{
-			"channel": "books5",
+			"channel": c.channel(),
 			"instId":  instId,
 		})
 	}
@@ -159,13 +178,50 @@ func (c *OKXConnector) Unsubscribe(symbols []string) error {
 		"args": args,
 	}
 
-	return c.conn.WriteJSON(msg)
+	return c.writeJSON(msg)
+}
+
+func (c *OKXConnector) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return c.conn.WriteJSON(v)
+}
+
+// ping sends OKX's text ping, serialized with other writes
+func (c *OKXConnector) ping() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, []byte("ping"))
+}
+
+// channel returns the book channel for the configured depth: books5 pushes
+// the top 5 levels whole; books pushes 400 levels as a snapshot and then
+// sequenced deltas
+func (c *OKXConnector) channel() string {
+	if c.depth > 5 {
+		return "books"
+	}
+	return "books5"
+}
+
+// Books returns the local books of the books channel, or nil on books5,
+// whose books are emitted whole
+func (c *OKXConnector) Books() *orderbook.Engine {
+	if c.channel() != "books" {
+		return nil
+	}
+	return c.books
 }
 
 // toOKXSymbol converts BTCUSDT to BTC-USDT-SWAP
 func (c *OKXConnector) toOKXSymbol(symbol string) string {
-	base := strings.TrimSuffix(symbol, "USDT")
-	return fmt.Sprintf("%s-USDT-SWAP", base)
+	return connector.ParsePair(symbol).ExchangeSymbol(connector.OKX)
 }
 
 // fromOKXSymbol converts BTC-USDT-SWAP to BTCUSDT
@@ -179,7 +235,7 @@ func (c *OKXConnector) fromOKXSymbol(instId string) string {
 
 // FetchInstruments fetches all available instruments
 func (c *OKXConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
-	url := fmt.Sprintf("%s/api/v5/public/instruments?instType=SWAP", okxRestURL)
+	url := fmt.Sprintf("%s/api/v5/public/instruments?instType=SWAP", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -195,13 +251,14 @@ func (c *OKXConnector) FetchInstruments(ctx context.Context) ([]connector.Instru
 	var result struct {
 		Code string `json:"code"`
 		Data []struct {
-			InstId   string `json:"instId"`
-			BaseCcy  string `json:"baseCcy"`
-			QuoteCcy string `json:"quoteCcy"`
-			CtVal    string `json:"ctVal"`
-			TickSz   string `json:"tickSz"`
-			LotSz    string `json:"lotSz"`
-			MinSz    string `json:"minSz"`
+			InstId    string `json:"instId"`
+			BaseCcy   string `json:"baseCcy"`
+			QuoteCcy  string `json:"quoteCcy"`
+			SettleCcy string `json:"settleCcy"`
+			CtVal     string `json:"ctVal"`
+			TickSz    string `json:"tickSz"`
+			LotSz     string `json:"lotSz"`
+			MinSz     string `json:"minSz"`
 		} `json:"data"`
 	}
 
@@ -211,8 +268,9 @@ func (c *OKXConnector) FetchInstruments(ctx context.Context) ([]connector.Instru
 
 	instruments := make([]connector.Instrument, 0, len(result.Data))
 	for _, item := range result.Data {
-		// Only include USDT-margined perps
-		if !strings.HasSuffix(item.InstId, "-USDT-SWAP") {
+		// Only include perpetual swaps; USDT, USDC and coin-margined are
+		// kept apart by their canonical
+		if !strings.HasSuffix(item.InstId, "-SWAP") {
 			continue
 		}
 
@@ -223,9 +281,10 @@ func (c *OKXConnector) FetchInstruments(ctx context.Context) ([]connector.Instru
 		instruments = append(instruments, connector.Instrument{
 			ExchangeID:     connector.OKX,
 			Symbol:         c.fromOKXSymbol(item.InstId),
-			Canonical:      strings.ToUpper(item.BaseCcy),
+			Canonical:      connector.NewPair(item.BaseCcy, item.QuoteCcy, item.SettleCcy).Canonical(),
 			BaseAsset:      item.BaseCcy,
 			QuoteAsset:     item.QuoteCcy,
+			SettleAsset:    item.SettleCcy,
 			InstrumentType: "perpetual",
 			ContractSize:   ctVal,
 			TickSize:       tickSize,
@@ -241,7 +300,7 @@ func (c *OKXConnector) FetchInstruments(ctx context.Context) ([]connector.Instru
 // FetchOrderbookSnapshot fetches current orderbook via REST
 func (c *OKXConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
 	instId := c.toOKXSymbol(symbol)
-	url := fmt.Sprintf("%s/api/v5/market/books?instId=%s&sz=%d", okxRestURL, instId, depth)
+	url := fmt.Sprintf("%s/api/v5/market/books?instId=%s&sz=%d", c.RestURL(), instId, depth)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -277,7 +336,7 @@ func (c *OKXConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string
 	ob := &connector.Orderbook{
 		ExchangeID: connector.OKX,
 		Symbol:     symbol,
-		Canonical:  strings.TrimSuffix(symbol, "USDT"),
+		Canonical:  connector.ParsePair(symbol).Canonical(),
 		Bids:       make([]connector.PriceLevel, 0, len(data.Bids)),
 		Asks:       make([]connector.PriceLevel, 0, len(data.Asks)),
 		Timestamp:  time.UnixMilli(ts),
@@ -285,12 +344,18 @@ func (c *OKXConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string
 	}
 
 	for _, bid := range data.Bids {
+		if len(bid) < 2 {
+			continue
+		}
 		price, _ := strconv.ParseFloat(bid[0], 64)
 		qty, _ := strconv.ParseFloat(bid[1], 64)
 		ob.Bids = append(ob.Bids, connector.PriceLevel{Price: price, Quantity: qty})
 	}
 
 	for _, ask := range data.Asks {
+		if len(ask) < 2 {
+			continue
+		}
 		price, _ := strconv.ParseFloat(ask[0], 64)
 		qty, _ := strconv.ParseFloat(ask[1], 64)
 		ob.Asks = append(ob.Asks, connector.PriceLevel{Price: price, Quantity: qty})
@@ -302,7 +367,7 @@ func (c *OKXConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string
 
 // FetchFundingRates fetches current funding rates
 func (c *OKXConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
-	url := fmt.Sprintf("%s/api/v5/public/funding-rate?instType=SWAP", okxRestURL)
+	url := fmt.Sprintf("%s/api/v5/public/funding-rate?instType=SWAP", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -340,7 +405,7 @@ func (c *OKXConnector) FetchFundingRates(ctx context.Context) ([]connector.Fundi
 		rates = append(rates, connector.FundingRate{
 			ExchangeID:           connector.OKX,
 			Symbol:               c.fromOKXSymbol(item.InstId),
-			Canonical:            strings.Split(item.InstId, "-")[0],
+			Canonical:            connector.ParsePair(item.InstId).Canonical(),
 			FundingRate:          rate,
 			NextFundingTime:      time.UnixMilli(nextTime),
 			FundingIntervalHours: 8,
@@ -352,12 +417,13 @@ func (c *OKXConnector) FetchFundingRates(ctx context.Context) ([]connector.Fundi
 }
 
 func (c *OKXConnector) readMessages() {
+	defer c.Recover("readMessages")
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := c.ReadMessage(c.conn)
 			if err != nil {
 				c.EmitError(fmt.Errorf("read error: %w", err))
 				c.SetConnected(false)
@@ -371,40 +437,96 @@ func (c *OKXConnector) readMessages() {
 
 func (c *OKXConnector) processMessage(data []byte) {
 	var msg struct {
-		Event string `json:"event"`
-		Arg   struct {
+		Event  string `json:"event"`
+		Code   string `json:"code"`
+		Msg    string `json:"msg"`
+		Action string `json:"action"` // snapshot or update, books channel only
+		Arg    struct {
 			Channel string `json:"channel"`
 			InstId  string `json:"instId"`
 		} `json:"arg"`
-		Data []struct {
-			Bids [][]string `json:"bids"`
-			Asks [][]string `json:"asks"`
-			Ts   string     `json:"ts"`
-		} `json:"data"`
+		Data []bookPush `json:"data"`
 	}
 
 	if err := json.Unmarshal(data, &msg); err != nil {
 		return
 	}
 
-	// Handle orderbook data
-	if len(msg.Data) > 0 && msg.Arg.Channel == "books5" {
-		c.processOrderbook(msg.Arg.InstId, msg.Data[0])
+	// Subscription errors name the instrument in the message text
+	if msg.Event == "error" {
+		err := fmt.Errorf("subscription error %s: %s", msg.Code, msg.Msg)
+		if m := okxInstIDPattern.FindStringSubmatch(msg.Msg); m != nil {
+			c.EmitSymbolError(c.fromOKXSymbol(m[1]), err)
+		} else {
+			c.EmitError(err)
+		}
+		return
+	}
+
+	if len(msg.Data) == 0 {
+		return
+	}
+	switch d := msg.Data[0]; msg.Arg.Channel {
+	case "books5":
+		// A book failing its checksum is dropped and the instrument
+		// resubscribed for a fresh snapshot
+		if !connector.VerifyBookChecksum(connector.OKX, d.Bids, d.Asks, d.Checksum) {
+			log.Warn().
+				Str("exchange", string(connector.OKX)).
+				Str("instId", msg.Arg.InstId).
+				Int64("checksum", d.Checksum).
+				Msg("Orderbook checksum mismatch, resubscribing")
+			c.resubscribe(msg.Arg.InstId)
+			return
+		}
+		c.processOrderbook(msg.Arg.InstId, d)
+	case "books":
+		c.processBookUpdate(msg.Arg.InstId, msg.Action == "snapshot", d)
 	}
 }
 
-func (c *OKXConnector) processOrderbook(instId string, data struct {
-	Bids [][]string `json:"bids"`
-	Asks [][]string `json:"asks"`
-	Ts   string     `json:"ts"`
-}) {
+// resubscribe unsubscribes and resubscribes an instrument whose book is
+// broken; OKX answers the subscription with a new snapshot
+func (c *OKXConnector) resubscribe(instId string) {
+	if c.conn == nil || !c.IsConnected() {
+		return // The next connection subscribes afresh
+	}
+	symbol := c.fromOKXSymbol(instId)
+	c.Go(func() {
+		defer c.Recover("resubscribe")
+		if err := c.Unsubscribe([]string{symbol}); err != nil {
+			c.EmitError(fmt.Errorf("resubscribe %s: %w", instId, err))
+			return
+		}
+		if err := c.Subscribe([]string{symbol}); err != nil {
+			c.EmitError(fmt.Errorf("resubscribe %s: %w", instId, err))
+		}
+	})
+}
+
+// okxInstIDPattern extracts the instrument from error messages like
+// "Wrong URL or channel:books5,instId:FOO-USDT-SWAP doesn't exist."
+var okxInstIDPattern = regexp.MustCompile(`instId:([A-Za-z0-9-]+)`)
+
+// bookPush is one book in a books5 push, or one snapshot or delta in a
+// books push
+type bookPush struct {
+	Bids      [][]string `json:"bids"`
+	Asks      [][]string `json:"asks"`
+	Ts        string     `json:"ts"`
+	Checksum  int64      `json:"checksum"`  // Zero when the channel doesn't send one
+	SeqID     int64      `json:"seqId"`     // books only
+	PrevSeqID int64      `json:"prevSeqId"` // books only; -1 on a snapshot
+}
+
+func (c *OKXConnector) processOrderbook(instId string, data bookPush) {
 	symbol := c.fromOKXSymbol(instId)
 	ts, _ := strconv.ParseInt(data.Ts, 10, 64)
 
 	ob := &connector.Orderbook{
 		ExchangeID: connector.OKX,
 		Symbol:     symbol,
-		Canonical:  strings.Split(instId, "-")[0],
+		Canonical:  connector.ParsePair(instId).Canonical(),
 		Bids:       make([]connector.PriceLevel, 0, len(data.Bids)),
 		Asks:       make([]connector.PriceLevel, 0, len(data.Asks)),
 		Timestamp:  time.UnixMilli(ts),
@@ -412,24 +534,101 @@ func (c *OKXConnector) processOrderbook(instId string, data struct {
 	}
 
 	for _, bid := range data.Bids {
+		if len(bid) < 2 {
+			continue
+		}
 		price, _ := strconv.ParseFloat(bid[0], 64)
 		qty, _ := strconv.ParseFloat(bid[1], 64)
 		ob.Bids = append(ob.Bids, connector.PriceLevel{Price: price, Quantity: qty})
 	}
 
 	for _, ask := range data.Asks {
+		if len(ask) < 2 {
+			continue
+		}
 		price, _ := strconv.ParseFloat(ask[0], 64)
 		qty, _ := strconv.ParseFloat(ask[1], 64)
 		ob.Asks = append(ob.Asks, connector.PriceLevel{Price: price, Quantity: qty})
 	}
 
 	c.updateSpread(ob)
+	c.EmitOrderbook(ob)
+}
 
-	c.mu.Lock()
-	c.orderbooks[symbol] = ob
-	c.mu.Unlock()
+// processBookUpdate applies a books snapshot or delta to the local book
+// and emits the book. Deltas are checked by sequence, and every push's
+// checksum against the top 25 levels of the book it leaves; a book failing
+// either is dropped and the instrument resubscribed.
+func (c *OKXConnector) processBookUpdate(instId string, snapshot bool, data bookPush) {
+	symbol := c.fromOKXSymbol(instId)
+	ts, _ := strconv.ParseInt(data.Ts, 10, 64)
 
-	c.EmitOrderbook(ob)
+	u := orderbook.Update{
+		Exchange:  connector.OKX,
+		Symbol:    symbol,
+		Canonical: connector.ParsePair(instId).Canonical(),
+		Snapshot:  snapshot,
+		LastSeq:   data.SeqID,
+		Bids:      parseLevels(data.Bids),
+		Asks:      parseLevels(data.Asks),
+		Timestamp: time.UnixMilli(ts),
+	}
+	if !snapshot {
+		u.PrevSeq = data.PrevSeqID
+	}
+	if err := c.books.Apply(u); err != nil {
+		return
+	}
+	if !c.verifyBook(instId, snapshot, data) {
+		log.Warn().
+			Str("exchange", string(connector.OKX)).
+			Str("instId", instId).
+			Int64("checksum", data.Checksum).
+			Msg("Orderbook checksum mismatch, resubscribing")
+		c.books.Remove(connector.OKX, symbol)
+		c.resubscribe(instId)
+		return
+	}
+	if ob := c.books.Orderbook(connector.OKX, symbol, c.depth); ob != nil {
+		c.EmitOrderbook(ob)
+	}
+}
+
+// verifyBook merges a push the engine accepted into the instrument's
+// checksum book, which holds the same levels as the venue's strings, and
+// checks the pushed checksum against its top 25 levels. A snapshot
+// replaces the checksum book; a failed book is forgotten until the next.
+func (c *OKXConnector) verifyBook(instId string, snapshot bool, data bookPush) bool {
+	c.checksumMu.Lock()
+	defer c.checksumMu.Unlock()
+
+	book := c.checksums[instId]
+	if snapshot {
+		book = connector.NewChecksumBook(data.Bids, data.Asks)
+		c.checksums[instId] = book
+	} else if book != nil {
+		book.Apply(data.Bids, data.Asks)
+	}
+	if book == nil || !book.Verify(connector.OKX, data.Checksum) {
+		delete(c.checksums, instId)
+		return false
+	}
+	return true
+}
+
+// parseLevels parses [price, size, ...] string levels; a zero size removes
+// a level
+func parseLevels(raw [][]string) []connector.PriceLevel {
+	levels := make([]connector.PriceLevel, 0, len(raw))
+	for _, l := range raw {
+		if len(l) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(l[0], 64)
+		qty, _ := strconv.ParseFloat(l[1], 64)
+		levels = append(levels, connector.PriceLevel{Price: price, Quantity: qty})
+	}
+	return levels
 }
 
 func (c *OKXConnector) updateSpread(ob *connector.Orderbook) {
@@ -453,7 +652,7 @@ func (c *OKXConnector) pingLoop() {
 		case <-c.done:
 			return
 		case <-ticker.C:
-			if err := c.conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+			if err := c.ping(); err != nil {
 				c.EmitError(fmt.Errorf("ping error: %w", err))
 			}
 		}
@@ -462,7 +661,7 @@ func (c *OKXConnector) pingLoop() {
 
 // FetchPriceTickers fetches current prices for all symbols via REST API
 func (c *OKXConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
-	url := fmt.Sprintf("%s/api/v5/market/tickers?instType=SWAP", okxRestURL)
+	url := fmt.Sprintf("%s/api/v5/market/tickers?instType=SWAP", c.RestURL())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -512,9 +711,8 @@ func (c *OKXConnector) FetchPriceTickers(ctx context.Context) ([]connector.Price
 			continue
 		}
 
-		// Extract canonical from BTC-USDT-SWAP -> BTC
-		parts := strings.Split(t.InstId, "-")
-		canonical := parts[0]
+		// BTC-USDT-SWAP -> BTC, BTC-USD-SWAP -> BTC-USD-BTC
+		canonical := connector.ParsePair(t.InstId).Canonical()
 
 		tickers = append(tickers, connector.PriceTicker{
 			ExchangeID: connector.OKX,
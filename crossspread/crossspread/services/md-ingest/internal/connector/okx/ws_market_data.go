@@ -5,9 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"crossspread-md-ingest/internal/connector"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -350,6 +354,13 @@ func (c *MarketDataWSClient) processChannelData(arg WSChannelArg, action string,
 			return
 		}
 		for _, b := range books {
+			// Snapshots carry their own checksum; OrderBookManager checks
+			// updates against the book they apply to
+			if action == "snapshot" && !connector.VerifyBookChecksum(connector.OKX,
+				checksumLevels(b.Bids), checksumLevels(b.Asks), b.Checksum) {
+				c.resubscribeBook(WSSubscribeArg{Channel: arg.Channel, InstID: arg.InstID})
+				return
+			}
 			c.handler.OnOrderBook(arg.InstID, action, &b)
 		}
 
@@ -424,6 +435,37 @@ func (c *MarketDataWSClient) sendUnsubscribe(arg WSSubscribeArg) error {
 	return c.conn.WriteJSON(req)
 }
 
+// resubscribeBook resubscribes a book that failed its checksum for a new
+// snapshot
+func (c *MarketDataWSClient) resubscribeBook(arg WSSubscribeArg) {
+	err := connector.ResubscribeBook(arg.InstID,
+		func() error { return c.sendUnsubscribe(arg) },
+		func() error { return c.sendSubscribe(arg) })
+	if c.handler != nil {
+		c.handler.OnError(err)
+	}
+}
+
+// ResyncBook resubscribes an instrument's book channels for a new snapshot.
+// It is the resync hook of the client's OrderBookManager.
+func (c *MarketDataWSClient) ResyncBook(instID string) {
+	c.subMu.RLock()
+	var args []WSSubscribeArg
+	for _, arg := range c.subscriptions {
+		switch arg.Channel {
+		case ChannelBooks, ChannelBooks5, ChannelBooksBBO, ChannelBooks50TBT, ChannelBooks400TBT:
+			if arg.InstID == instID {
+				args = append(args, arg)
+			}
+		}
+	}
+	c.subMu.RUnlock()
+
+	for _, arg := range args {
+		c.resubscribeBook(arg)
+	}
+}
+
 // subscriptionKey generates a unique key for subscription
 func subscriptionKey(channel, instID string) string {
 	return channel + ":" + instID
@@ -699,8 +741,9 @@ func (c *MarketDataWSClient) SubscribeMultipleOrderBooks(instIDs []string, chann
 
 // OrderBookManager maintains a local order book from WebSocket updates
 type OrderBookManager struct {
-	books map[string]*LocalOrderBook
-	mu    sync.RWMutex
+	books  map[string]*LocalOrderBook
+	resync func(instID string)
+	mu     sync.RWMutex
 }
 
 // LocalOrderBook represents a locally maintained order book
@@ -721,11 +764,37 @@ func NewOrderBookManager() *OrderBookManager {
 	}
 }
 
-// ProcessUpdate processes an order book update
+// SetResync sets the function called when a book needs a new snapshot,
+// e.g. MarketDataWSClient.ResyncBook. It runs on its own goroutine.
+func (m *OrderBookManager) SetResync(fn func(instID string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resync = fn
+}
+
+// ProcessUpdate applies a snapshot or incremental update and checks the
+// result against the update's CRC32 checksum over the top 25 levels. It
+// returns nil when the book needs a new snapshot: before the first one,
+// after a sequence gap or after a checksum mismatch. The resync hook is then
+// called to get one.
 func (m *OrderBookManager) ProcessUpdate(instID string, action string, data *WSOrderBookData) *LocalOrderBook {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	book := m.apply(instID, action, data)
+	if book == nil {
+		delete(m.books, instID)
+		if m.resync != nil {
+			go m.resync(instID)
+		}
+	}
+	return book
+}
+
+// apply applies an update, returning nil if the book needs a snapshot.
+// Caller holds m.mu.
+func (m *OrderBookManager) apply(instID string, action string, data *WSOrderBookData) *LocalOrderBook {
+
 	if action == "snapshot" {
 		// Create new order book from snapshot
 		book := &LocalOrderBook{
@@ -744,6 +813,9 @@ func (m *OrderBookManager) ProcessUpdate(instID string, action string, data *WSO
 			book.Bids[bid.Price] = bid
 		}
 
+		if !book.verify(data.Checksum) {
+			return nil
+		}
 		m.books[instID] = book
 		return book
 	}
@@ -760,7 +832,6 @@ func (m *OrderBookManager) ProcessUpdate(instID string, action string, data *WSO
 	// Verify sequence
 	if data.PrevSeqID != 0 && data.PrevSeqID != book.SeqID {
 		// Sequence mismatch - need resync
-		delete(m.books, instID)
 		return nil
 	}
 
@@ -785,9 +856,42 @@ func (m *OrderBookManager) ProcessUpdate(instID string, action string, data *WSO
 	book.Checksum = data.Checksum
 	book.Ts = data.Ts
 
+	if !book.verify(data.Checksum) {
+		return nil
+	}
 	return book
 }
 
+// verify checks the top levels against a pushed checksum. Caller holds the
+// book's lock or owns it.
+func (b *LocalOrderBook) verify(checksum int64) bool {
+	bids := checksumLevels(sortLevels(b.Bids, true))
+	asks := checksumLevels(sortLevels(b.Asks, false))
+	return connector.VerifyBookChecksum(connector.OKX, bids, asks, checksum)
+}
+
+func checksumLevels(levels []OrderBookLevel) [][]string {
+	return connector.LevelStrings(levels, func(l OrderBookLevel) (string, string) { return l.Price, l.Size })
+}
+
+// sortLevels returns a side's levels best first: bids by price descending,
+// asks ascending. Prices are compared as numbers, not strings.
+func sortLevels(side map[string]OrderBookLevel, descending bool) []OrderBookLevel {
+	levels := make([]OrderBookLevel, 0, len(side))
+	prices := make(map[string]float64, len(side))
+	for price, level := range side {
+		levels = append(levels, level)
+		prices[price], _ = strconv.ParseFloat(price, 64)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return prices[levels[i].Price] > prices[levels[j].Price]
+		}
+		return prices[levels[i].Price] < prices[levels[j].Price]
+	})
+	return levels
+}
+
 // GetOrderBook returns the current order book for an instrument
 func (m *OrderBookManager) GetOrderBook(instID string) *LocalOrderBook {
 	m.mu.RLock()
@@ -800,19 +904,7 @@ func (b *LocalOrderBook) GetSortedAsks(limit int) []OrderBookLevel {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	asks := make([]OrderBookLevel, 0, len(b.Asks))
-	for _, level := range b.Asks {
-		asks = append(asks, level)
-	}
-
-	// Sort by price ascending
-	for i := 0; i < len(asks)-1; i++ {
-		for j := i + 1; j < len(asks); j++ {
-			if asks[i].Price > asks[j].Price {
-				asks[i], asks[j] = asks[j], asks[i]
-			}
-		}
-	}
+	asks := sortLevels(b.Asks, false)
 
 	if limit > 0 && limit < len(asks) {
 		return asks[:limit]
@@ -825,19 +917,7 @@ func (b *LocalOrderBook) GetSortedBids(limit int) []OrderBookLevel {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	bids := make([]OrderBookLevel, 0, len(b.Bids))
-	for _, level := range b.Bids {
-		bids = append(bids, level)
-	}
-
-	// Sort by price descending
-	for i := 0; i < len(bids)-1; i++ {
-		for j := i + 1; j < len(bids); j++ {
-			if bids[i].Price < bids[j].Price {
-				bids[i], bids[j] = bids[j], bids[i]
-			}
-		}
-	}
+	bids := sortLevels(b.Bids, true)
 
 	if limit > 0 && limit < len(bids) {
 		return bids[:limit]
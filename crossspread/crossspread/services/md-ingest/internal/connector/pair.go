@@ -0,0 +1,218 @@
+package connector
+
+import "strings"
+
+// Pair is a base/quote/settle triple. Settle is the currency margin and PnL
+// are held in: the quote for linear contracts (BTC/USDT settled in USDT), the
+// base for inverse coin-margined contracts (BTC/USD settled in BTC).
+type Pair struct {
+	Base   string `json:"base"`
+	Quote  string `json:"quote"`
+	Settle string `json:"settle"`
+}
+
+// defaultQuote is the quote of the default market: linear USDT-margined
+// perpetuals keep the bare base as their canonical ("BTC")
+const defaultQuote = "USDT"
+
+// knownQuotes are matched against the end of symbols without a separator
+// (BTCUSDT, XBTUSDTM), longest first so FDUSD wins over USD
+var knownQuotes = []string{"FDUSD", "USDT", "USDC", "BUSD", "USD", "EUR", "TRY", "BRL", "GBP"}
+
+// usdcLinearVenues list no USDT-margined perpetuals. Their linear
+// USDC-margined ones are the match for other venues' USDT perpetuals and
+// share their canonical ("BTC"); legs on them record the USDC quote.
+var usdcLinearVenues = map[ExchangeID]bool{
+	Deribit: true,
+}
+
+// contractTokens are symbol parts that name the contract type rather than a currency
+var contractTokens = map[string]bool{
+	"SWAP":      true,
+	"PERP":      true,
+	"PERPETUAL": true,
+}
+
+// NewPair builds a pair, defaulting settle to the quote (linear) or, for
+// USD-quoted contracts, to the base (inverse)
+func NewPair(base, quote, settle string) Pair {
+	p := Pair{
+		Base:   strings.ToUpper(strings.TrimSpace(base)),
+		Quote:  strings.ToUpper(strings.TrimSpace(quote)),
+		Settle: strings.ToUpper(strings.TrimSpace(settle)),
+	}
+	if p.Base == "XBT" {
+		p.Base = "BTC"
+	}
+	if p.Settle == "" {
+		if p.Quote == "USD" {
+			p.Settle = p.Base
+		} else {
+			p.Settle = p.Quote
+		}
+	}
+	return p
+}
+
+// ParsePair parses a symbol in any common exchange format: BTCUSDT,
+// BTC-USDT-SWAP, BTC_USDT, btc_usdt, E-BTC-USDT, XBTUSDTM, BTCUSD_PERP,
+// BTC/EUR, or BTC/USD:BTC with an explicit settle currency
+func ParsePair(symbol string) Pair {
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	s = strings.TrimPrefix(s, "E-") // Bitrue
+
+	var settle string
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		s, settle = s[:i], s[i+1:]
+	}
+
+	var tokens []string
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' || r == '/' }) {
+		if !contractTokens[t] {
+			tokens = append(tokens, t)
+		}
+	}
+
+	switch len(tokens) {
+	case 0:
+		return NewPair(s, defaultQuote, settle)
+	case 1:
+		base, quote := splitConcatenated(tokens[0])
+		return NewPair(base, quote, settle)
+	default:
+		return NewPair(tokens[0], tokens[1], settle)
+	}
+}
+
+// ParseSymbol parses an exchange's perpetual symbol into the pair it is
+// matched as across venues. It is ParsePair but for Deribit's
+// coin-margined perpetuals, which name no quote (BTC-PERPETUAL) and are
+// USD-quoted, and VenuePair's USDC-for-USDT matching.
+func ParseSymbol(id ExchangeID, symbol string) Pair {
+	if id == Deribit {
+		s := strings.ToUpper(strings.TrimSpace(symbol))
+		if base, ok := strings.CutSuffix(s, "-PERPETUAL"); ok && !strings.Contains(base, "_") {
+			return NewPair(base, "USD", base)
+		}
+	}
+	return VenuePair(id, ParsePair(symbol))
+}
+
+// VenuePair returns the pair a venue's contract is matched as across
+// venues: linear USDC perpetuals on venues without USDT ones count as USDT
+// (Deribit's BTC_USDC-PERPETUAL matches BTCUSDT elsewhere)
+func VenuePair(id ExchangeID, p Pair) Pair {
+	if usdcLinearVenues[id] && p.Quote == "USDC" && p.Linear() {
+		p.Quote, p.Settle = defaultQuote, defaultQuote
+	}
+	return p
+}
+
+// NativeQuote returns the quote a venue's contract is actually in when it
+// differs from its canonical's, e.g. USDC for Deribit's linear perpetuals
+// matched as USDT, and "" otherwise
+func NativeQuote(id ExchangeID, canonical string) string {
+	if !usdcLinearVenues[id] {
+		return ""
+	}
+	if p := ParseCanonical(canonical); p.Quote == defaultQuote && p.Linear() {
+		return "USDC"
+	}
+	return ""
+}
+
+// splitConcatenated splits BTCUSDT into BTC and USDT. KuCoin's trailing M
+// (XBTUSDTM) is dropped and Bybit's BTCPERP is USDC-margined. Symbols
+// without a known quote are assumed USDT.
+func splitConcatenated(s string) (string, string) {
+	if len(s) > 4 && strings.HasSuffix(s, "PERP") {
+		return strings.TrimSuffix(s, "PERP"), "USDC"
+	}
+	for _, quote := range knownQuotes {
+		for _, suffix := range []string{quote, quote + "M"} {
+			if len(s) > len(suffix) && strings.HasSuffix(s, suffix) {
+				return strings.TrimSuffix(s, suffix), quote
+			}
+		}
+	}
+	return s, defaultQuote
+}
+
+// Linear returns true if the contract settles in its quote currency
+func (p Pair) Linear() bool {
+	return p.Settle == p.Quote
+}
+
+// Inverse returns true if the contract is coin-margined
+func (p Pair) Inverse() bool {
+	return p.Settle == p.Base && p.Quote != p.Base
+}
+
+// Canonical returns the cross-exchange key for the pair. Linear USDT
+// contracts use the bare base ("BTC"), everything else is BASE-QUOTE, with
+// -SETTLE appended if settle differs from quote ("BTC-USDC", "BTC-EUR",
+// "BTC-USD-BTC").
+func (p Pair) Canonical() string {
+	if p.Quote == defaultQuote && p.Linear() {
+		return p.Base
+	}
+	if p.Linear() {
+		return p.Base + "-" + p.Quote
+	}
+	return p.Base + "-" + p.Quote + "-" + p.Settle
+}
+
+// ParseCanonical is the inverse of Pair.Canonical
+func ParseCanonical(canonical string) Pair {
+	parts := strings.Split(canonical, "-")
+	switch len(parts) {
+	case 1:
+		return NewPair(parts[0], defaultQuote, "")
+	case 2:
+		return NewPair(parts[0], parts[1], parts[1])
+	default:
+		return NewPair(parts[0], parts[1], parts[2])
+	}
+}
+
+// ExchangeSymbol formats the pair as an exchange's perpetual symbol
+func (p Pair) ExchangeSymbol(id ExchangeID) string {
+	base, quote := p.Base, p.Quote
+	switch id {
+	case Binance:
+		if p.Inverse() {
+			return base + quote + "_PERP"
+		}
+		return base + quote
+	case OKX:
+		return base + "-" + quote + "-SWAP"
+	case KuCoin:
+		if base == "BTC" {
+			base = "XBT"
+		}
+		return base + quote + "M"
+	case MEXC, GateIO, LBank:
+		return base + "_" + quote
+	case BingX, HTX:
+		return base + "-" + quote
+	case WhiteBIT:
+		if quote == "USDT" {
+			return base + "_PERP"
+		}
+		return base + "_" + quote + "_PERP"
+	case XT:
+		return strings.ToLower(base + "_" + quote)
+	case Bitrue:
+		return "E-" + base + "-" + quote
+	case Deribit:
+		if p.Inverse() {
+			return base + "-PERPETUAL"
+		}
+		if quote == defaultQuote {
+			quote = "USDC" // Matched as USDT; see VenuePair
+		}
+		return base + "_" + quote + "-PERPETUAL"
+	default:
+		return base + quote
+	}
+}
@@ -0,0 +1,104 @@
+package connector
+
+import (
+	"fmt"
+	"regexp"
+	"runtime/debug"
+
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// excerptLen caps the frame excerpt in crash reports
+const excerptLen = 512
+
+// symbolField finds the symbol of a frame across venue message formats
+var symbolField = regexp.MustCompile(`"(?:s|symbol|instId|market|contract|contractName|ch|topic)"\s*:\s*"([^"]+)"`)
+
+// PanicError is emitted to the error handler when a connector goroutine
+// panics. The connector is marked disconnected so the connection monitor
+// reconnects it.
+type PanicError struct {
+	Exchange ExchangeID
+	Where    string
+	Value    interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s %s panicked: %v", e.Exchange, e.Where, e.Value)
+}
+
+// ReportPanic logs a structured crash report for a recovered panic and
+// counts it. frame is the message being processed, if known.
+func ReportPanic(exchange ExchangeID, where string, value interface{}, frame []byte) {
+	metrics.ConnectorPanics.WithLabelValues(string(exchange), where).Inc()
+
+	event := log.Error().
+		Str("exchange", string(exchange)).
+		Str("where", where).
+		Str("panic", fmt.Sprint(value)).
+		Bytes("stack", debug.Stack())
+	if len(frame) > 0 {
+		if m := symbolField.FindSubmatch(frame); m != nil {
+			event = event.Str("symbol", string(m[1]))
+		}
+		excerpt := frame
+		if len(excerpt) > excerptLen {
+			excerpt = excerpt[:excerptLen]
+		}
+		event = event.Bytes("excerpt", excerpt).Int("frame_bytes", len(frame))
+	}
+	event.Msg("Recovered panic")
+}
+
+// Recover is deferred first in goroutines of venue clients that don't embed
+// BaseConnector. onPanic runs after the report, typically to close the
+// socket so the client's own disconnect handling reconnects.
+func Recover(exchange ExchangeID, where string, onPanic func()) {
+	if r := recover(); r != nil {
+		ReportPanic(exchange, where, r, nil)
+		if onPanic != nil {
+			onPanic()
+		}
+	}
+}
+
+// Recover is deferred first in connector goroutines. A panic is reported
+// with an excerpt of the frame being parsed, the socket is closed and the
+// connector marked disconnected so the connection monitor restarts it.
+func (c *BaseConnector) Recover(where string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	var frame []byte
+	if f := c.lastFrame.Load(); f != nil {
+		frame = *f
+	}
+	ReportPanic(c.config.ExchangeID, where, r, frame)
+
+	if m := c.metered.Load(); m != nil {
+		m.Close()
+	}
+	c.SetConnected(false)
+	c.EmitError(&PanicError{Exchange: c.config.ExchangeID, Where: where, Value: r})
+}
+
+// recoverHandler is deferred around handler callbacks. A panicking handler
+// drops the message; the connector itself is unaffected and keeps running.
+func (c *BaseConnector) recoverHandler(kind, symbol string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	metrics.ConnectorPanics.WithLabelValues(string(c.config.ExchangeID), kind+"_handler").Inc()
+	log.Error().
+		Str("exchange", string(c.config.ExchangeID)).
+		Str("where", kind+"_handler").
+		Str("symbol", symbol).
+		Str("panic", fmt.Sprint(r)).
+		Bytes("stack", debug.Stack()).
+		Msg("Recovered panic")
+}
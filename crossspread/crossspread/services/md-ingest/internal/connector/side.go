@@ -0,0 +1,143 @@
+package connector
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// Taker sides. Trade.Side is one of these after EmitTrade, or empty when
+// the venue did not say and there was nothing to infer it from.
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// quoteMaxAge is how old a book may be for the quote rule; older books
+// fall back to the tick rule
+const quoteMaxAge = 5 * time.Second
+
+// NormalizeSide maps venue spellings of the taker side to SideBuy or
+// SideSell: "Buy", "BUY", "bid", "b", "buy_market", "1" (MEXC) and their
+// sell counterparts. Unrecognised values return "".
+func NormalizeSide(raw string) string {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if i := strings.IndexAny(s, "_-"); i > 0 {
+		s = s[:i] // buy_market, sell-limit
+	}
+	switch s {
+	case "buy", "b", "bid", "long", "1":
+		return SideBuy
+	case "sell", "s", "ask", "offer", "short", "2":
+		return SideSell
+	}
+	return ""
+}
+
+// SideFromSize returns the taker side for venues that sign the trade size
+// (Gate: negative is a taker sell). Zero returns "".
+func SideFromSize(size float64) string {
+	switch {
+	case size > 0:
+		return SideBuy
+	case size < 0:
+		return SideSell
+	}
+	return ""
+}
+
+// sideQuote is the top of a symbol's latest book
+type sideQuote struct {
+	bid, ask float64
+	at       time.Time
+}
+
+// sideTick is a symbol's previous trade, for the tick rule
+type sideTick struct {
+	price float64
+	side  string
+}
+
+// sideInferrer infers the taker side of trades a venue sent without one,
+// using the connector's own books (quote rule) and trade history (tick
+// rule), in the spirit of Lee-Ready.
+type sideInferrer struct {
+	mu     sync.Mutex
+	quotes map[string]sideQuote
+	ticks  map[string]sideTick
+}
+
+// observeBook records the top of book for the quote rule
+func (s *sideInferrer) observeBook(ob *Orderbook, now time.Time) {
+	bid, ask := ob.BestBid, ob.BestAsk
+	if bid == 0 && len(ob.Bids) > 0 {
+		bid = ob.Bids[0].Price
+	}
+	if ask == 0 && len(ob.Asks) > 0 {
+		ask = ob.Asks[0].Price
+	}
+	if bid <= 0 || ask <= 0 || bid > ask {
+		return
+	}
+
+	s.mu.Lock()
+	if s.quotes == nil {
+		s.quotes = make(map[string]sideQuote)
+	}
+	s.quotes[ob.Symbol] = sideQuote{bid: bid, ask: ask, at: now}
+	s.mu.Unlock()
+}
+
+// resolve normalizes the trade's side, infers it when missing and records
+// the trade for the tick rule. Returns the rule used: "" when the venue
+// gave the side, "quote", "tick" or "none".
+func (s *sideInferrer) resolve(trade *Trade, now time.Time) string {
+	trade.Side = NormalizeSide(trade.Side)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ticks == nil {
+		s.ticks = make(map[string]sideTick)
+	}
+
+	rule := ""
+	prev, hasPrev := s.ticks[trade.Symbol]
+	if trade.Side == "" {
+		rule = "none"
+		if q, ok := s.quotes[trade.Symbol]; ok && now.Sub(q.at) <= quoteMaxAge {
+			mid := (q.bid + q.ask) / 2
+			switch {
+			case trade.Price > mid:
+				trade.Side, rule = SideBuy, "quote"
+			case trade.Price < mid:
+				trade.Side, rule = SideSell, "quote"
+			}
+		}
+		if trade.Side == "" && hasPrev {
+			switch {
+			case trade.Price > prev.price:
+				trade.Side = SideBuy
+			case trade.Price < prev.price:
+				trade.Side = SideSell
+			default:
+				trade.Side = prev.side // Zero tick keeps the last direction
+			}
+			if trade.Side != "" {
+				rule = "tick"
+			}
+		}
+		trade.SideInferred = trade.Side != ""
+	}
+
+	s.ticks[trade.Symbol] = sideTick{price: trade.Price, side: trade.Side}
+	return rule
+}
+
+// resolveSide runs the connector's side inference on a trade
+func (c *BaseConnector) resolveSide(trade *Trade, now time.Time) {
+	if rule := c.sides.resolve(trade, now); rule != "" {
+		metrics.TradeSidesInferred.WithLabelValues(string(c.config.ExchangeID), rule).Inc()
+	}
+}
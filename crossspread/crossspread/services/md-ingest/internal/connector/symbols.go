@@ -0,0 +1,35 @@
+package connector
+
+// WithSymbols returns symbols with those in add it lacks appended, for
+// connectors keeping the list they subscribe on connecting. symbols is not
+// modified.
+func WithSymbols(symbols, add []string) []string {
+	have := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		have[s] = true
+	}
+	out := symbols[:len(symbols):len(symbols)]
+	for _, s := range add {
+		if !have[s] {
+			have[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// WithoutSymbols returns symbols without those in remove. symbols is not
+// modified.
+func WithoutSymbols(symbols, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, s := range remove {
+		removed[s] = true
+	}
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if !removed[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
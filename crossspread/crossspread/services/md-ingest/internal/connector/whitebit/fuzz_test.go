@@ -0,0 +1,23 @@
+package whitebit
+
+import "testing"
+
+// FuzzProcessMessage feeds arbitrary frames through the public WebSocket
+// decoder. Each input starts with a full reload so incremental updates hit
+// a live book.
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"id":null,"method":"depth_update","params":[false,{"timestamp":1695716060.1,"asks":[["26001","0"]],"bids":[["26000.7","2"]]},"BTC_PERP"]}`))
+	f.Add([]byte(`{"id":null,"method":"depth_update","params":[true,{"timestamp":1695716059.516,"asks":[["26001","1.2"]],"bids":[["26000.5","3"]]},"BTC_PERP"]}`))
+	f.Add([]byte(`{"id":null,"method":"trades_update","params":["BTC_PERP",[{"id":1,"time":1695716059.516,"price":"26000.5","amount":"0.3","type":"buy"}]]}`))
+	f.Add([]byte(`{"id":1,"result":null,"error":{"code":2,"message":"market FOO_PERP not found"}}`))
+	f.Add([]byte(`{"method":"depth_update","params":[false,{"asks":[["1"]],"bids":[[]]},"BTC_PERP"]}`))
+	f.Add([]byte(`{"method":"depth_update","params":[true]}`))
+
+	snapshot := []byte(`{"id":null,"method":"depth_update","params":[true,{"timestamp":1695716059.516,"asks":[["26001","1.2"],["26002","4"]],"bids":[["26000.5","3"],["26000","1"]]},"BTC_PERP"]}`)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewWhiteBITConnector([]string{"BTC_PERP"}, 20)
+		c.processMessage(snapshot)
+		c.processMessage(data)
+	})
+}
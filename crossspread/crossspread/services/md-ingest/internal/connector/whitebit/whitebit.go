@@ -0,0 +1,663 @@
+package whitebit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	whitebitWsURL   = "wss://api.whitebit.com/ws"
+	whitebitRestURL = "https://whitebit.com"
+)
+
+// WhiteBITConnector implements the Connector interface for WhiteBIT perpetual futures
+// Symbols use the BASE_PERP format (USDT-margined), e.g. BTC_PERP
+type WhiteBITConnector struct {
+	*connector.BaseConnector
+	conn       *websocket.Conn
+	writeMu    sync.Mutex
+	symbols    []string
+	depth      int
+	mu         sync.RWMutex
+	orderbooks map[string]*connector.Orderbook
+	done       chan struct{}
+	requestID  int64
+}
+
+// NewWhiteBITConnector creates a new WhiteBIT connector
+func NewWhiteBITConnector(symbols []string, depth int) *WhiteBITConnector {
+	config := connector.ConnectorConfig{
+		ExchangeID:     connector.WhiteBIT,
+		WsURL:          whitebitWsURL,
+		RestURL:        whitebitRestURL,
+		Symbols:        symbols,
+		DepthLevels:    depth,
+		ReconnectDelay: 5 * time.Second,
+		PingInterval:   20 * time.Second,
+	}
+
+	// WhiteBIT accepts 1, 5, 10, 20, 30, 50 or 100 levels
+	if depth <= 0 || depth > 100 {
+		depth = 20
+	}
+
+	return &WhiteBITConnector{
+		BaseConnector: connector.NewBaseConnector(config),
+		symbols:       symbols,
+		depth:         depth,
+		orderbooks:    make(map[string]*connector.Orderbook),
+		done:          make(chan struct{}),
+	}
+}
+
+// Connect establishes WebSocket connection to WhiteBIT
+func (c *WhiteBITConnector) Connect(ctx context.Context) error {
+	c.mu.RLock()
+	symbols := c.symbols
+	c.mu.RUnlock()
+
+	return c.connect(ctx, symbols)
+}
+
+// ConnectForSymbols establishes WebSocket connection for specific symbols only
+// Used for Phase 2 selective subscription after spread discovery
+func (c *WhiteBITConnector) ConnectForSymbols(ctx context.Context, symbols []string) error {
+	c.mu.Lock()
+	c.symbols = symbols
+	c.mu.Unlock()
+
+	if err := c.connect(ctx, symbols); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("symbols", len(symbols)).
+		Msg("Connected to WhiteBIT WebSocket (selective)")
+
+	return nil
+}
+
+func (c *WhiteBITConnector) connect(ctx context.Context, symbols []string) error {
+	conn, err := c.Dial(ctx, whitebitWsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to WhiteBIT WebSocket: %w", err)
+	}
+
+	c.conn = conn
+	c.done = make(chan struct{})
+	c.SetConnected(true)
+
+	if err := c.Subscribe(symbols); err != nil {
+		return err
+	}
+
+	c.Go(c.readMessages)
+	c.Go(c.pingLoop)
+
+	return nil
+}
+
+// Disconnect closes the WebSocket connection
+func (c *WhiteBITConnector) Disconnect() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.SetConnected(false)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe subscribes to orderbook and trade updates for symbols and
+// keeps them for reconnects
+func (c *WhiteBITConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	markets := c.symbols
+	c.mu.Unlock()
+
+	for _, symbol := range symbols {
+		// params: market, limit, price interval ("0" = no merging), multiple subscriptions
+		if err := c.send("depth_subscribe", []interface{}{symbol, c.depth, "0", true}); err != nil {
+			return err
+		}
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+	return c.subscribeTrades(markets)
+}
+
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
+func (c *WhiteBITConnector) Unsubscribe(symbols []string) error {
+	for _, symbol := range symbols {
+		if err := c.send("depth_unsubscribe", []interface{}{symbol}); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		delete(c.orderbooks, symbol)
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	markets := c.symbols
+	c.mu.Unlock()
+	if len(symbols) == 0 || len(markets) == 0 {
+		return nil
+	}
+	return c.subscribeTrades(markets)
+}
+
+// subscribeTrades subscribes to trades of markets. trades_subscribe replaces
+// the previous trade subscription, so every market is sent at once.
+func (c *WhiteBITConnector) subscribeTrades(markets []string) error {
+	params := make([]interface{}, len(markets))
+	for i, s := range markets {
+		params[i] = s
+	}
+	return c.send("trades_subscribe", params)
+}
+
+func (c *WhiteBITConnector) send(method string, params []interface{}) error {
+	if c.conn == nil {
+		return fmt.Errorf("whitebit: not connected")
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.requestID++
+	return c.conn.WriteJSON(map[string]interface{}{
+		"id":     c.requestID,
+		"method": method,
+		"params": params,
+	})
+}
+
+// futuresTicker is an entry of the public futures endpoint (all perpetuals in one call)
+type futuresTicker struct {
+	TickerID            string `json:"ticker_id"`
+	StockCurrency       string `json:"stock_currency"`
+	MoneyCurrency       string `json:"money_currency"`
+	LastPrice           string `json:"last_price"`
+	StockVolume         string `json:"stock_volume"`
+	MoneyVolume         string `json:"money_volume"`
+	Bid                 string `json:"bid"`
+	Ask                 string `json:"ask"`
+	ProductType         string `json:"product_type"`
+	OpenInterest        string `json:"open_interest"`
+	IndexPrice          string `json:"index_price"`
+	FundingRate         string `json:"funding_rate"`
+	NextFundingRateTime string `json:"next_funding_rate_timestamp"`
+}
+
+func (c *WhiteBITConnector) fetchFutures(ctx context.Context) ([]futuresTicker, error) {
+	var result struct {
+		Success bool            `json:"success"`
+		Message interface{}     `json:"message"`
+		Result  []futuresTicker `json:"result"`
+	}
+	if err := getJSON(ctx, whitebitRestURL+"/api/v4/public/futures", &result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("API error: %v", result.Message)
+	}
+	return result.Result, nil
+}
+
+// FetchInstruments fetches all available perpetual instruments
+func (c *WhiteBITConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
+	var markets []struct {
+		Name      string `json:"name"`
+		Stock     string `json:"stock"`
+		Money     string `json:"money"`
+		StockPrec string `json:"stockPrec"`
+		MoneyPrec string `json:"moneyPrec"`
+		MakerFee  string `json:"makerFee"` // percent
+		TakerFee  string `json:"takerFee"` // percent
+		MinAmount string `json:"minAmount"`
+		MinTotal  string `json:"minTotal"`
+		Type      string `json:"type"`
+		Trading   bool   `json:"tradesEnabled"`
+	}
+	if err := getJSON(ctx, whitebitRestURL+"/api/v4/public/markets", &markets); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]connector.Instrument, 0)
+	for _, m := range markets {
+		if m.Type != "futures" || !strings.HasSuffix(m.Name, "_PERP") {
+			continue
+		}
+
+		stockPrec, _ := strconv.Atoi(m.StockPrec)
+		moneyPrec, _ := strconv.Atoi(m.MoneyPrec)
+		makerFee, _ := strconv.ParseFloat(m.MakerFee, 64)
+		takerFee, _ := strconv.ParseFloat(m.TakerFee, 64)
+		minTotal, _ := strconv.ParseFloat(m.MinTotal, 64)
+
+		instruments = append(instruments, connector.Instrument{
+			ExchangeID:     connector.WhiteBIT,
+			Symbol:         m.Name,
+			Canonical:      normalizeSymbol(m.Name),
+			BaseAsset:      m.Stock,
+			QuoteAsset:     m.Money,
+			InstrumentType: "perpetual",
+			ContractSize:   1,
+			TickSize:       precisionToStep(moneyPrec),
+			LotSize:        precisionToStep(stockPrec),
+			MinNotional:    minTotal,
+			MakerFee:       makerFee / 100,
+			TakerFee:       takerFee / 100,
+		})
+	}
+
+	return instruments, nil
+}
+
+// FetchOrderbookSnapshot fetches current orderbook via REST
+func (c *WhiteBITConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
+	var result struct {
+		TickerID  string     `json:"ticker_id"`
+		Timestamp int64      `json:"timestamp"`
+		Asks      [][]string `json:"asks"`
+		Bids      [][]string `json:"bids"`
+	}
+	url := fmt.Sprintf("%s/api/v4/public/orderbook/%s?limit=%d", whitebitRestURL, symbol, depth)
+	if err := getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+
+	ob := &connector.Orderbook{
+		ExchangeID: connector.WhiteBIT,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseLevels(result.Bids),
+		Asks:       parseLevels(result.Asks),
+		Timestamp:  time.Unix(result.Timestamp, 0),
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+
+	return ob, nil
+}
+
+// FetchFundingRates fetches current funding rates from the futures endpoint
+func (c *WhiteBITConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
+	futures, err := c.fetchFutures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]connector.FundingRate, 0, len(futures))
+	for _, f := range futures {
+		if f.ProductType != "Perpetual" {
+			continue
+		}
+		rate, _ := strconv.ParseFloat(f.FundingRate, 64)
+		nextTime, _ := strconv.ParseInt(f.NextFundingRateTime, 10, 64)
+
+		rates = append(rates, connector.FundingRate{
+			ExchangeID:           connector.WhiteBIT,
+			Symbol:               f.TickerID,
+			Canonical:            normalizeSymbol(f.TickerID),
+			FundingRate:          rate,
+			NextFundingTime:      time.UnixMilli(nextTime),
+			FundingIntervalHours: 8,
+			Timestamp:            time.Now(),
+		})
+	}
+
+	return rates, nil
+}
+
+// FetchPriceTickers fetches current prices for all perpetuals in a single call
+func (c *WhiteBITConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
+	futures, err := c.fetchFutures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make([]connector.PriceTicker, 0, len(futures))
+	for _, f := range futures {
+		if f.ProductType != "Perpetual" {
+			continue
+		}
+
+		price, _ := strconv.ParseFloat(f.LastPrice, 64)
+		bid, _ := strconv.ParseFloat(f.Bid, 64)
+		ask, _ := strconv.ParseFloat(f.Ask, 64)
+		volume, _ := strconv.ParseFloat(f.MoneyVolume, 64)
+
+		if price <= 0 {
+			continue
+		}
+
+		tickers = append(tickers, connector.PriceTicker{
+			ExchangeID: connector.WhiteBIT,
+			Symbol:     f.TickerID,
+			Canonical:  normalizeSymbol(f.TickerID),
+			Price:      price,
+			BidPrice:   bid,
+			AskPrice:   ask,
+			Volume24h:  volume,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	log.Info().Int("count", len(tickers)).Msg("Fetched WhiteBIT price tickers")
+	return tickers, nil
+}
+
+// FetchAssetInfo fetches deposit/withdrawal status for assets
+func (c *WhiteBITConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetInfo, error) {
+	var assets map[string]struct {
+		Name        string `json:"name"`
+		CanWithdraw bool   `json:"can_withdraw"`
+		CanDeposit  bool   `json:"can_deposit"`
+		MinWithdraw string `json:"min_withdraw"`
+		Networks    struct {
+			Withdraws []string `json:"withdraws"`
+		} `json:"networks"`
+	}
+	if err := getJSON(ctx, whitebitRestURL+"/api/v4/public/assets", &assets); err != nil {
+		return nil, err
+	}
+
+	infos := make([]connector.AssetInfo, 0, len(assets))
+	for asset, a := range assets {
+		minWithdraw, _ := strconv.ParseFloat(a.MinWithdraw, 64)
+		infos = append(infos, connector.AssetInfo{
+			ExchangeID:      connector.WhiteBIT,
+			Asset:           asset,
+			DepositEnabled:  a.CanDeposit,
+			WithdrawEnabled: a.CanWithdraw,
+			MinWithdraw:     minWithdraw,
+			Networks:        a.Networks.Withdraws,
+			Timestamp:       time.Now(),
+		})
+	}
+
+	log.Info().Int("count", len(infos)).Msg("Fetched WhiteBIT asset info")
+	return infos, nil
+}
+
+func (c *WhiteBITConnector) readMessages() {
+	defer c.Recover("readMessages")
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+			_, message, err := c.ReadMessage(c.conn)
+			if err != nil {
+				c.EmitError(fmt.Errorf("read error: %w", err))
+				c.SetConnected(false)
+				return
+			}
+
+			c.processMessage(message)
+		}
+	}
+}
+
+func (c *WhiteBITConnector) processMessage(data []byte) {
+	var msg struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	if msg.Error != nil {
+		c.EmitError(fmt.Errorf("whitebit error %d: %s", msg.Error.Code, msg.Error.Message))
+		return
+	}
+
+	switch msg.Method {
+	case "depth_update":
+		c.processDepth(msg.Params)
+	case "trades_update":
+		c.processTrades(msg.Params)
+	}
+}
+
+// processDepth handles depth_update: [fullReload bool, {timestamp, asks, bids}, market]
+func (c *WhiteBITConnector) processDepth(params []json.RawMessage) {
+	if len(params) < 3 {
+		return
+	}
+
+	var fullReload bool
+	var symbol string
+	var update struct {
+		Timestamp float64    `json:"timestamp"`
+		Asks      [][]string `json:"asks"`
+		Bids      [][]string `json:"bids"`
+	}
+	if json.Unmarshal(params[0], &fullReload) != nil ||
+		json.Unmarshal(params[1], &update) != nil ||
+		json.Unmarshal(params[2], &symbol) != nil {
+		log.Error().Msg("Failed to parse WhiteBIT depth update")
+		return
+	}
+
+	ts := time.UnixMilli(int64(update.Timestamp * 1000))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if fullReload {
+		ob := &connector.Orderbook{
+			ExchangeID: connector.WhiteBIT,
+			Symbol:     symbol,
+			Canonical:  normalizeSymbol(symbol),
+			Bids:       parseLevels(update.Bids),
+			Asks:       parseLevels(update.Asks),
+			Timestamp:  ts,
+			IsSnapshot: true,
+		}
+		c.orderbooks[symbol] = ob
+		updateSpread(ob)
+		c.EmitOrderbook(ob)
+		return
+	}
+
+	ob, exists := c.orderbooks[symbol]
+	if !exists {
+		return
+	}
+
+	for _, bid := range update.Bids {
+		if len(bid) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(bid[0], 64)
+		qty, _ := strconv.ParseFloat(bid[1], 64)
+		updateLevel(&ob.Bids, price, qty, true)
+	}
+	for _, ask := range update.Asks {
+		if len(ask) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(ask[0], 64)
+		qty, _ := strconv.ParseFloat(ask[1], 64)
+		updateLevel(&ob.Asks, price, qty, false)
+	}
+
+	// Keep only the subscribed depth
+	if len(ob.Bids) > c.depth {
+		ob.Bids = ob.Bids[:c.depth]
+	}
+	if len(ob.Asks) > c.depth {
+		ob.Asks = ob.Asks[:c.depth]
+	}
+
+	ob.Timestamp = ts
+	ob.IsSnapshot = true // Full book from the local copy, not the raw update
+	updateSpread(ob)
+	c.EmitOrderbook(ob)
+}
+
+// processTrades handles trades_update: [market, [{id, time, price, amount, type}]]
+func (c *WhiteBITConnector) processTrades(params []json.RawMessage) {
+	if len(params) < 2 {
+		return
+	}
+
+	var symbol string
+	var trades []struct {
+		ID     int64   `json:"id"`
+		Time   float64 `json:"time"`
+		Price  string  `json:"price"`
+		Amount string  `json:"amount"`
+		Type   string  `json:"type"`
+	}
+	if json.Unmarshal(params[0], &symbol) != nil || json.Unmarshal(params[1], &trades) != nil {
+		return
+	}
+
+	for _, t := range trades {
+		price, _ := strconv.ParseFloat(t.Price, 64)
+		qty, _ := strconv.ParseFloat(t.Amount, 64)
+
+		c.EmitTrade(&connector.Trade{
+			ExchangeID: connector.WhiteBIT,
+			Symbol:     symbol,
+			Canonical:  normalizeSymbol(symbol),
+			TradeID:    strconv.FormatInt(t.ID, 10),
+			Price:      price,
+			Quantity:   qty,
+			Side:       t.Type, // "buy" or "sell" (taker side)
+			Timestamp:  time.UnixMilli(int64(t.Time * 1000)),
+		})
+	}
+}
+
+func (c *WhiteBITConnector) pingLoop() {
+	ticker := time.NewTicker(20 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.send("ping", []interface{}{}); err != nil {
+				c.EmitError(fmt.Errorf("ping error: %w", err))
+			}
+		}
+	}
+}
+
+// =============================================================================
+// Helper Functions
+// =============================================================================
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func parseLevels(levels [][]string) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(level[0], 64)
+		qty, _ := strconv.ParseFloat(level[1], 64)
+		if qty > 0 {
+			result = append(result, connector.PriceLevel{Price: price, Quantity: qty})
+		}
+	}
+	return result
+}
+
+func updateLevel(levels *[]connector.PriceLevel, price, qty float64, isBid bool) {
+	for i, level := range *levels {
+		if level.Price == price {
+			if qty == 0 {
+				*levels = append((*levels)[:i], (*levels)[i+1:]...)
+			} else {
+				(*levels)[i].Quantity = qty
+			}
+			return
+		}
+	}
+	if qty == 0 {
+		return
+	}
+
+	newLevel := connector.PriceLevel{Price: price, Quantity: qty}
+	for i, level := range *levels {
+		if (isBid && price > level.Price) || (!isBid && price < level.Price) {
+			*levels = append((*levels)[:i], append([]connector.PriceLevel{newLevel}, (*levels)[i:]...)...)
+			return
+		}
+	}
+	*levels = append(*levels, newLevel)
+}
+
+func updateSpread(ob *connector.Orderbook) {
+	ob.BestBid, ob.BestAsk, ob.SpreadBps = 0, 0, 0
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+}
+
+// precisionToStep converts a decimal precision (e.g. 2) to a step size (0.01)
+func precisionToStep(prec int) float64 {
+	step := 1.0
+	for i := 0; i < prec; i++ {
+		step /= 10
+	}
+	return step
+}
+
+// normalizeSymbol converts BTC_PERP to the canonical base asset BTC
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSuffix(symbol, "_PERP"))
+}
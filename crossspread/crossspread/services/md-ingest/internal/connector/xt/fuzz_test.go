@@ -0,0 +1,18 @@
+package xt
+
+import "testing"
+
+// FuzzProcessMessage feeds arbitrary frames through the public WebSocket
+// decoder, covering depth and trade topics.
+func FuzzProcessMessage(f *testing.F) {
+	f.Add([]byte(`{"topic":"depth","event":"depth@btc_usdt,20","data":{"s":"btc_usdt","U":1,"u":2,"b":[["26000.5","120"]],"a":[["26001","7"],["26002","0"]],"t":1695716059516}}`))
+	f.Add([]byte(`{"topic":"trade","event":"trade@btc_usdt","data":{"s":"btc_usdt","i":"1","p":"26000.5","a":"3","m":"ASK","t":1695716059516}}`))
+	f.Add([]byte(`{"topic":"depth","event":"depth@btc_usdt,20","data":{"s":"btc_usdt","b":[["1"],[]],"a":null}}`))
+	f.Add([]byte(`{"id":"1","code":1,"msg":"invalid symbol foo_usdt"}`))
+	f.Add([]byte(`pong`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewXTConnector([]string{"btc_usdt"}, 20)
+		c.processMessage(data)
+	})
+}
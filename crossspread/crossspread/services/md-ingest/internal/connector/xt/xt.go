@@ -0,0 +1,627 @@
+package xt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	xtWsURL   = "wss://fstream.xt.com/ws/market"
+	xtRestURL = "https://fapi.xt.com"
+
+	// Max concurrent per-symbol REST calls (funding has no bulk endpoint)
+	restConcurrency = 8
+)
+
+// XTConnector implements the Connector interface for XT.com USDT-M perpetuals
+// Symbols use the lowercase base_quote format, e.g. btc_usdt. Book and trade
+// quantities are in contracts and converted to base units.
+// Quote-only: there is no XT trading client yet.
+type XTConnector struct {
+	*connector.BaseConnector
+	conn          *websocket.Conn
+	writeMu       sync.Mutex
+	symbols       []string
+	depth         int
+	mu            sync.RWMutex
+	contractSizes map[string]float64
+	done          chan struct{}
+}
+
+// NewXTConnector creates a new XT.com connector
+func NewXTConnector(symbols []string, depth int) *XTConnector {
+	config := connector.ConnectorConfig{
+		ExchangeID:     connector.XT,
+		WsURL:          xtWsURL,
+		RestURL:        xtRestURL,
+		Symbols:        symbols,
+		DepthLevels:    depth,
+		ReconnectDelay: 5 * time.Second,
+		PingInterval:   20 * time.Second,
+	}
+
+	// XT offers 5, 10, 20 and 50 level depth streams
+	switch {
+	case depth <= 5:
+		depth = 5
+	case depth <= 10:
+		depth = 10
+	case depth <= 20:
+		depth = 20
+	default:
+		depth = 50
+	}
+
+	return &XTConnector{
+		BaseConnector: connector.NewBaseConnector(config),
+		symbols:       symbols,
+		depth:         depth,
+		contractSizes: make(map[string]float64),
+		done:          make(chan struct{}),
+	}
+}
+
+// Connect establishes WebSocket connection to XT.com
+func (c *XTConnector) Connect(ctx context.Context) error {
+	c.mu.RLock()
+	symbols := c.symbols
+	c.mu.RUnlock()
+
+	return c.connect(ctx, symbols)
+}
+
+// ConnectForSymbols establishes WebSocket connection for specific symbols only
+// Used for Phase 2 selective subscription after spread discovery
+func (c *XTConnector) ConnectForSymbols(ctx context.Context, symbols []string) error {
+	c.mu.Lock()
+	c.symbols = symbols
+	c.mu.Unlock()
+
+	if err := c.connect(ctx, symbols); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("symbols", len(symbols)).
+		Msg("Connected to XT.com WebSocket (selective)")
+
+	return nil
+}
+
+func (c *XTConnector) connect(ctx context.Context, symbols []string) error {
+	c.mu.RLock()
+	haveSizes := len(c.contractSizes) > 0
+	c.mu.RUnlock()
+	if !haveSizes {
+		if _, err := c.FetchInstruments(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to load XT.com contract sizes, volumes will be in contracts")
+		}
+	}
+
+	conn, err := c.Dial(ctx, xtWsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to XT.com WebSocket: %w", err)
+	}
+
+	c.conn = conn
+	c.done = make(chan struct{})
+	c.SetConnected(true)
+
+	if err := c.Subscribe(symbols); err != nil {
+		return err
+	}
+
+	c.Go(c.readMessages)
+	c.Go(c.pingLoop)
+
+	return nil
+}
+
+// Disconnect closes the WebSocket connection
+func (c *XTConnector) Disconnect() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.SetConnected(false)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe subscribes to depth and trade streams for symbols and
+// keeps them for reconnects
+func (c *XTConnector) Subscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.send("SUBSCRIBE", c.topics(symbols))
+}
+
+// Unsubscribe removes subscriptions, also from those reconnects resubscribe
+func (c *XTConnector) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	c.symbols = connector.WithoutSymbols(c.symbols, symbols)
+	c.mu.Unlock()
+	return c.send("UNSUBSCRIBE", c.topics(symbols))
+}
+
+func (c *XTConnector) topics(symbols []string) []string {
+	params := make([]string, 0, len(symbols)*2)
+	for _, symbol := range symbols {
+		params = append(params,
+			fmt.Sprintf("depth@%s,%d", symbol, c.depth),
+			fmt.Sprintf("trade@%s", symbol),
+		)
+	}
+	return params
+}
+
+func (c *XTConnector) send(method string, params []string) error {
+	if c.conn == nil {
+		return fmt.Errorf("xt: not connected")
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.conn.WriteJSON(map[string]interface{}{
+		"method": method,
+		"params": params,
+		"id":     strconv.FormatInt(time.Now().UnixNano(), 10),
+	})
+}
+
+// xtResponse is the common REST envelope
+type xtResponse struct {
+	ReturnCode int             `json:"returnCode"`
+	MsgInfo    string          `json:"msgInfo"`
+	Result     json.RawMessage `json:"result"`
+}
+
+func (c *XTConnector) getResult(ctx context.Context, path string, v interface{}) error {
+	var resp xtResponse
+	if err := getJSON(ctx, xtRestURL+path, &resp); err != nil {
+		return err
+	}
+	if resp.ReturnCode != 0 {
+		return fmt.Errorf("API error: code %d: %s", resp.ReturnCode, resp.MsgInfo)
+	}
+	return json.Unmarshal(resp.Result, v)
+}
+
+// FetchInstruments fetches all USDT-M perpetual contracts
+func (c *XTConnector) FetchInstruments(ctx context.Context) ([]connector.Instrument, error) {
+	var result struct {
+		Symbols []struct {
+			Symbol            string `json:"symbol"`
+			ContractType      string `json:"contractType"`
+			UnderlyingType    string `json:"underlyingType"`
+			ContractSize      string `json:"contractSize"`
+			TradeSwitch       bool   `json:"tradeSwitch"`
+			BaseCoin          string `json:"baseCoin"`
+			QuoteCoin         string `json:"quoteCoin"`
+			PricePrecision    int    `json:"pricePrecision"`
+			QuantityPrecision int    `json:"quantityPrecision"`
+			MinNotional       string `json:"minNotional"`
+			MakerFee          string `json:"makerFee"`
+			TakerFee          string `json:"takerFee"`
+		} `json:"symbols"`
+	}
+	if err := c.getResult(ctx, "/future/market/v1/public/symbol/list", &result); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]connector.Instrument, 0, len(result.Symbols))
+	sizes := make(map[string]float64, len(result.Symbols))
+	for _, s := range result.Symbols {
+		if s.ContractType != "PERPETUAL" || s.UnderlyingType != "U_BASED" {
+			continue
+		}
+
+		contractSize, _ := strconv.ParseFloat(s.ContractSize, 64)
+		minNotional, _ := strconv.ParseFloat(s.MinNotional, 64)
+		makerFee, _ := strconv.ParseFloat(s.MakerFee, 64)
+		takerFee, _ := strconv.ParseFloat(s.TakerFee, 64)
+
+		sizes[s.Symbol] = contractSize
+
+		instruments = append(instruments, connector.Instrument{
+			ExchangeID:     connector.XT,
+			Symbol:         s.Symbol,
+			Canonical:      normalizeSymbol(s.Symbol),
+			BaseAsset:      strings.ToUpper(s.BaseCoin),
+			QuoteAsset:     strings.ToUpper(s.QuoteCoin),
+			InstrumentType: "perpetual",
+			ContractSize:   contractSize,
+			TickSize:       precisionToStep(s.PricePrecision),
+			LotSize:        precisionToStep(s.QuantityPrecision) * contractSize,
+			MinNotional:    minNotional,
+			MakerFee:       makerFee,
+			TakerFee:       takerFee,
+		})
+	}
+
+	c.mu.Lock()
+	c.contractSizes = sizes
+	c.mu.Unlock()
+
+	return instruments, nil
+}
+
+// FetchOrderbookSnapshot fetches current orderbook via REST
+func (c *XTConnector) FetchOrderbookSnapshot(ctx context.Context, symbol string, depth int) (*connector.Orderbook, error) {
+	var result struct {
+		T int64      `json:"t"`
+		S string     `json:"s"`
+		U int64      `json:"u"`
+		B [][]string `json:"b"`
+		A [][]string `json:"a"`
+	}
+	path := fmt.Sprintf("/future/market/v1/public/q/depth?symbol=%s&level=%d", symbol, depth)
+	if err := c.getResult(ctx, path, &result); err != nil {
+		return nil, err
+	}
+
+	size := c.contractSize(symbol)
+	ob := &connector.Orderbook{
+		ExchangeID: connector.XT,
+		Symbol:     symbol,
+		Canonical:  normalizeSymbol(symbol),
+		Bids:       parseLevels(result.B, size),
+		Asks:       parseLevels(result.A, size),
+		Timestamp:  time.UnixMilli(result.T),
+		SequenceID: result.U,
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+
+	return ob, nil
+}
+
+// FetchFundingRates fetches funding rates. XT has no bulk endpoint, so
+// symbols are queried individually with bounded concurrency.
+func (c *XTConnector) FetchFundingRates(ctx context.Context) ([]connector.FundingRate, error) {
+	instruments, err := c.FetchInstruments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		rates = make([]connector.FundingRate, 0, len(instruments))
+		sem   = make(chan struct{}, restConcurrency)
+	)
+
+	for _, inst := range instruments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fr, err := c.FetchFundingRate(ctx, symbol)
+			if err != nil {
+				log.Debug().Err(err).Str("symbol", symbol).Msg("Failed to fetch XT.com funding rate")
+				return
+			}
+
+			mu.Lock()
+			rates = append(rates, *fr)
+			mu.Unlock()
+		}(inst.Symbol)
+	}
+	wg.Wait()
+
+	return rates, nil
+}
+
+// FetchFundingRate fetches the funding rate of a single symbol
+func (c *XTConnector) FetchFundingRate(ctx context.Context, symbol string) (*connector.FundingRate, error) {
+	var fr struct {
+		Symbol             string `json:"symbol"`
+		FundingRate        string `json:"fundingRate"`
+		NextCollectionTime int64  `json:"nextCollectionTime"`
+		CollectionInternal int    `json:"collectionInternal"`
+	}
+	path := "/future/market/v1/public/q/funding-rate?symbol=" + symbol
+	if err := c.getResult(ctx, path, &fr); err != nil {
+		return nil, err
+	}
+
+	rate, _ := strconv.ParseFloat(fr.FundingRate, 64)
+	interval := fr.CollectionInternal
+	if interval <= 0 {
+		interval = 8
+	}
+
+	return &connector.FundingRate{
+		ExchangeID:           connector.XT,
+		Symbol:               symbol,
+		Canonical:            normalizeSymbol(symbol),
+		FundingRate:          rate,
+		NextFundingTime:      time.UnixMilli(fr.NextCollectionTime),
+		FundingIntervalHours: interval,
+		Timestamp:            time.Now(),
+	}, nil
+}
+
+// FetchPriceTickers fetches BBO and volume for all symbols in a single call
+func (c *XTConnector) FetchPriceTickers(ctx context.Context) ([]connector.PriceTicker, error) {
+	var result []struct {
+		S  string `json:"s"`  // symbol
+		T  int64  `json:"t"`  // time
+		C  string `json:"c"`  // last price
+		V  string `json:"v"`  // 24h turnover
+		Bp string `json:"bp"` // best bid
+		Ap string `json:"ap"` // best ask
+	}
+	if err := c.getResult(ctx, "/future/market/v1/public/q/agg-tickers", &result); err != nil {
+		return nil, err
+	}
+
+	tickers := make([]connector.PriceTicker, 0, len(result))
+	for _, t := range result {
+		if !strings.HasSuffix(t.S, "_usdt") {
+			continue
+		}
+
+		price, _ := strconv.ParseFloat(t.C, 64)
+		bid, _ := strconv.ParseFloat(t.Bp, 64)
+		ask, _ := strconv.ParseFloat(t.Ap, 64)
+		volume, _ := strconv.ParseFloat(t.V, 64)
+		if price <= 0 {
+			continue
+		}
+
+		tickers = append(tickers, connector.PriceTicker{
+			ExchangeID: connector.XT,
+			Symbol:     t.S,
+			Canonical:  normalizeSymbol(t.S),
+			Price:      price,
+			BidPrice:   bid,
+			AskPrice:   ask,
+			Volume24h:  volume,
+			Timestamp:  time.UnixMilli(t.T),
+		})
+	}
+
+	log.Info().Int("count", len(tickers)).Msg("Fetched XT.com price tickers")
+	return tickers, nil
+}
+
+// FetchAssetInfo derives asset info from instruments; wallet status requires auth
+func (c *XTConnector) FetchAssetInfo(ctx context.Context) ([]connector.AssetInfo, error) {
+	instruments, err := c.FetchInstruments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	assetMap := make(map[string]bool)
+	for _, inst := range instruments {
+		assetMap[inst.BaseAsset] = true
+	}
+
+	infos := make([]connector.AssetInfo, 0, len(assetMap))
+	for asset := range assetMap {
+		infos = append(infos, connector.AssetInfo{
+			ExchangeID:      connector.XT,
+			Asset:           asset,
+			DepositEnabled:  true,
+			WithdrawEnabled: true,
+			Timestamp:       time.Now(),
+		})
+	}
+
+	return infos, nil
+}
+
+func (c *XTConnector) readMessages() {
+	defer c.Recover("readMessages")
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+			_, message, err := c.ReadMessage(c.conn)
+			if err != nil {
+				c.EmitError(fmt.Errorf("read error: %w", err))
+				c.SetConnected(false)
+				return
+			}
+
+			c.processMessage(message)
+		}
+	}
+}
+
+func (c *XTConnector) processMessage(data []byte) {
+	if string(data) == "pong" {
+		return
+	}
+
+	var msg struct {
+		Topic string          `json:"topic"`
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	switch msg.Topic {
+	case "depth":
+		c.processDepth(msg.Data)
+	case "trade":
+		c.processTrade(msg.Data)
+	}
+}
+
+// processDepth handles limited-depth snapshots: {s, U, u, b, a, t}
+func (c *XTConnector) processDepth(data json.RawMessage) {
+	var depth struct {
+		S string     `json:"s"`
+		U int64      `json:"u"`
+		B [][]string `json:"b"`
+		A [][]string `json:"a"`
+		T int64      `json:"t"`
+	}
+	if err := json.Unmarshal(data, &depth); err != nil {
+		log.Error().Err(err).Msg("Failed to parse XT.com depth")
+		return
+	}
+
+	size := c.contractSize(depth.S)
+	ob := &connector.Orderbook{
+		ExchangeID: connector.XT,
+		Symbol:     depth.S,
+		Canonical:  normalizeSymbol(depth.S),
+		Bids:       parseLevels(depth.B, size),
+		Asks:       parseLevels(depth.A, size),
+		Timestamp:  time.UnixMilli(depth.T),
+		SequenceID: depth.U,
+		IsSnapshot: true,
+	}
+	updateSpread(ob)
+	c.EmitOrderbook(ob)
+}
+
+// processTrade handles trade pushes; m is the taker side (BID = buy)
+func (c *XTConnector) processTrade(data json.RawMessage) {
+	var trade struct {
+		S string `json:"s"`
+		I string `json:"i"`
+		P string `json:"p"`
+		A string `json:"a"`
+		M string `json:"m"`
+		T int64  `json:"t"`
+	}
+	if err := json.Unmarshal(data, &trade); err != nil {
+		return
+	}
+
+	price, _ := strconv.ParseFloat(trade.P, 64)
+	qty, _ := strconv.ParseFloat(trade.A, 64)
+
+	side := connector.NormalizeSide(trade.M) // BID or ASK
+
+	c.EmitTrade(&connector.Trade{
+		ExchangeID: connector.XT,
+		Symbol:     trade.S,
+		Canonical:  normalizeSymbol(trade.S),
+		TradeID:    trade.I,
+		Price:      price,
+		Quantity:   qty * c.contractSize(trade.S),
+		Side:       side,
+		Timestamp:  time.UnixMilli(trade.T),
+	})
+}
+
+func (c *XTConnector) pingLoop() {
+	ticker := time.NewTicker(20 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteMessage(websocket.TextMessage, []byte("ping"))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.EmitError(fmt.Errorf("ping error: %w", err))
+			}
+		}
+	}
+}
+
+func (c *XTConnector) contractSize(symbol string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if size, ok := c.contractSizes[symbol]; ok && size > 0 {
+		return size
+	}
+	return 1
+}
+
+// =============================================================================
+// Helper Functions
+// =============================================================================
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func parseLevels(levels [][]string, contractSize float64) []connector.PriceLevel {
+	result := make([]connector.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(level[0], 64)
+		qty, _ := strconv.ParseFloat(level[1], 64)
+		if qty > 0 {
+			result = append(result, connector.PriceLevel{Price: price, Quantity: qty * contractSize})
+		}
+	}
+	return result
+}
+
+func updateSpread(ob *connector.Orderbook) {
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+}
+
+// precisionToStep converts a decimal precision (e.g. 2) to a step size (0.01)
+func precisionToStep(prec int) float64 {
+	step := 1.0
+	for i := 0; i < prec; i++ {
+		step /= 10
+	}
+	return step
+}
+
+// normalizeSymbol converts btc_usdt to the canonical base asset BTC
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSuffix(symbol, "_usdt"))
+}
@@ -18,18 +18,19 @@ type ExchangeCredentials struct {
 	UserID     string `json:"userId"`
 }
 
-// CredentialsFetcher fetches API credentials from the backend API
+// CredentialsFetcher fetches API credentials from the backend API. Requests
+// carry a short-lived signed service token instead of a static secret.
 type CredentialsFetcher struct {
-	backendURL    string
-	serviceSecret string
-	httpClient    *http.Client
+	backendURL string
+	signer     *TokenSigner
+	httpClient *http.Client
 }
 
 // NewCredentialsFetcher creates a new credentials fetcher
-func NewCredentialsFetcher(backendURL, serviceSecret string) *CredentialsFetcher {
+func NewCredentialsFetcher(backendURL string, signer *TokenSigner) *CredentialsFetcher {
 	return &CredentialsFetcher{
-		backendURL:    backendURL,
-		serviceSecret: serviceSecret,
+		backendURL: backendURL,
+		signer:     signer,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -40,34 +41,10 @@ func NewCredentialsFetcher(backendURL, serviceSecret string) *CredentialsFetcher
 func (f *CredentialsFetcher) GetAllCredentials() (map[string][]ExchangeCredentials, error) {
 	url := fmt.Sprintf("%s/api/v1/internal/credentials", f.backendURL)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Service %s", f.serviceSecret))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := f.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch credentials: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("unauthorized: invalid service credentials")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-	}
-
 	var result map[string][]ExchangeCredentials
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := f.get(url, &result); err != nil {
+		return nil, err
 	}
-
 	return result, nil
 }
 
@@ -75,37 +52,59 @@ func (f *CredentialsFetcher) GetAllCredentials() (map[string][]ExchangeCredentia
 func (f *CredentialsFetcher) GetExchangeCredentials(exchange string) ([]ExchangeCredentials, error) {
 	url := fmt.Sprintf("%s/api/v1/internal/credentials/%s", f.backendURL, exchange)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Service %s", f.serviceSecret))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := f.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch credentials: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("unauthorized: invalid service credentials")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-	}
-
 	var result []ExchangeCredentials
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := f.get(url, &result); err != nil {
+		return nil, err
 	}
-
 	return result, nil
 }
 
+// get fetches url into v. A rejected token is re-signed and the request
+// retried once, which covers the backend rotating keys under a cached token.
+func (f *CredentialsFetcher) get(url string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := f.signer.Token()
+		if err != nil {
+			return fmt.Errorf("failed to sign service token: %w", err)
+		}
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := f.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to fetch credentials: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			f.signer.Invalidate()
+			if attempt == 0 {
+				continue
+			}
+			return fmt.Errorf("unauthorized: service token rejected (key %s)", f.signer.KeyID())
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(v)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+}
+
 // HasCredentials checks if any credentials exist for the given exchange
 func (f *CredentialsFetcher) HasCredentials(exchange string) bool {
 	creds, err := f.GetExchangeCredentials(exchange)
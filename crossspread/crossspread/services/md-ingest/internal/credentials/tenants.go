@@ -0,0 +1,170 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrNoCredentials is returned when a tenant has no credentials on an exchange
+var ErrNoCredentials = errors.New("credentials: no credentials for tenant")
+
+// Tenant lists the exchanges a tenant has credentials on, without secrets
+type Tenant struct {
+	ID        string   `json:"id"`
+	Exchanges []string `json:"exchanges"`
+}
+
+// TenantStore scopes credentials by tenant, the backend user owning them.
+// Lookups always name the tenant and never fall back to another tenant's
+// keys, so one user's credentials can't end up on another user's orders.
+type TenantStore struct {
+	fetcher  *CredentialsFetcher
+	interval time.Duration
+
+	mu          sync.RWMutex
+	tenants     map[string]map[string]ExchangeCredentials // tenant -> exchange -> credentials
+	refreshedAt time.Time
+	done        chan struct{}
+}
+
+// NewTenantStore creates a tenant store refreshed from the backend every
+// interval
+func NewTenantStore(fetcher *CredentialsFetcher, interval time.Duration) *TenantStore {
+	return &TenantStore{
+		fetcher:  fetcher,
+		interval: interval,
+		tenants:  make(map[string]map[string]ExchangeCredentials),
+		done:     make(chan struct{}),
+	}
+}
+
+// Refresh reloads every tenant's credentials from the backend. Credentials
+// without a user can't be scoped and are dropped; a tenant with several
+// keys on one exchange uses the first.
+func (s *TenantStore) Refresh() error {
+	all, err := s.fetcher.GetAllCredentials()
+	if err != nil {
+		return err
+	}
+
+	tenants := make(map[string]map[string]ExchangeCredentials)
+	for exchange, list := range all {
+		exchange = strings.ToLower(exchange)
+		for _, creds := range list {
+			if creds.UserID == "" {
+				log.Warn().Str("exchange", exchange).Msg("Dropping credentials without a user, they can't be scoped to a tenant")
+				continue
+			}
+			if tenants[creds.UserID] == nil {
+				tenants[creds.UserID] = make(map[string]ExchangeCredentials)
+			}
+			if _, ok := tenants[creds.UserID][exchange]; ok {
+				log.Warn().Str("tenant", creds.UserID).Str("exchange", exchange).Msg("Tenant has several credentials on exchange, using the first")
+				continue
+			}
+			tenants[creds.UserID][exchange] = creds
+		}
+	}
+
+	s.mu.Lock()
+	s.tenants = tenants
+	s.refreshedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Start refreshes the store until ctx is done or Stop is called
+func (s *TenantStore) Start(ctx context.Context) {
+	if err := s.Refresh(); err != nil {
+		log.Warn().Err(err).Msg("Failed to load tenant credentials")
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.Refresh(); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh tenant credentials")
+			}
+		}
+	}
+}
+
+// Stop stops refreshing
+func (s *TenantStore) Stop() {
+	close(s.done)
+}
+
+// Credentials returns a tenant's credentials on an exchange. Returns an
+// error wrapping ErrNoCredentials if the tenant has none there.
+func (s *TenantStore) Credentials(tenant, exchange string) (ExchangeCredentials, error) {
+	s.mu.RLock()
+	creds, ok := s.tenants[tenant][strings.ToLower(exchange)]
+	s.mu.RUnlock()
+	if !ok {
+		return ExchangeCredentials{}, fmt.Errorf("%w %s on %s", ErrNoCredentials, tenant, exchange)
+	}
+	// Keyed by owner when loaded; checked again so a bug there can't leak keys
+	if creds.UserID != tenant {
+		return ExchangeCredentials{}, fmt.Errorf("credentials: %s keys on %s belong to another tenant", tenant, exchange)
+	}
+	return creds, nil
+}
+
+// Has returns true if a tenant has credentials on an exchange
+func (s *TenantStore) Has(tenant, exchange string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.tenants[tenant][strings.ToLower(exchange)]
+	return ok
+}
+
+// Exchanges returns the exchanges a tenant has credentials on, sorted
+func (s *TenantStore) Exchanges(tenant string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exchanges := make([]string, 0, len(s.tenants[tenant]))
+	for exchange := range s.tenants[tenant] {
+		exchanges = append(exchanges, exchange)
+	}
+	sort.Strings(exchanges)
+	return exchanges
+}
+
+// Tenants returns every tenant with credentials, sorted by ID
+func (s *TenantStore) Tenants() []Tenant {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.tenants))
+	for id := range s.tenants {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+	sort.Strings(ids)
+
+	result := make([]Tenant, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, Tenant{ID: id, Exchanges: s.Exchanges(id)})
+	}
+	return result
+}
+
+// RefreshedAt returns when the store was last loaded, zero if never
+func (s *TenantStore) RefreshedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.refreshedAt
+}
@@ -0,0 +1,251 @@
+package credentials
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned when a service token fails verification
+var ErrInvalidToken = errors.New("credentials: invalid service token")
+
+// SigningKey is a shared secret signing service tokens. The ID travels in
+// the token header so the backend can hold several keys during rotation.
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// ParseSigningKeys parses "kid:secret,kid:secret". The first key signs; all
+// of them verify, so a new key can be deployed to the verifier before it is
+// moved to the front here.
+func ParseSigningKeys(s string) ([]SigningKey, error) {
+	var keys []SigningKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(part, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("signing key %q must be kid:secret", part)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate signing key id %q", id)
+		}
+		seen[id] = true
+		keys = append(keys, SigningKey{ID: id, Secret: []byte(secret)})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys")
+	}
+	return keys, nil
+}
+
+// TokenClaims are the claims of a service token (JWT, HS256)
+type TokenClaims struct {
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// TokenConfig controls service tokens sent to the backend
+type TokenConfig struct {
+	Issuer    string        // Service presenting the token
+	Audience  string        // API the token is valid for; the verifier rejects any other
+	TTL       time.Duration // Token lifetime
+	ClockSkew time.Duration // Clock difference tolerated by VerifyToken
+	Keys      []SigningKey  // First key signs
+}
+
+// DefaultTokenConfig returns one-minute tokens for the credentials API,
+// tolerating 30s of clock skew
+func DefaultTokenConfig() TokenConfig {
+	return TokenConfig{
+		Issuer:    "md-ingest",
+		Audience:  "backend-api:credentials",
+		TTL:       time.Minute,
+		ClockSkew: 30 * time.Second,
+	}
+}
+
+// TokenSigner issues short-lived service tokens. A token is reused until
+// half its lifetime is gone so every request doesn't sign a new one.
+type TokenSigner struct {
+	mu      sync.Mutex
+	config  TokenConfig
+	token   string
+	renewAt time.Time
+}
+
+// NewTokenSigner creates a signer; config must have at least one key
+func NewTokenSigner(config TokenConfig) (*TokenSigner, error) {
+	if len(config.Keys) == 0 {
+		return nil, fmt.Errorf("token signer needs a signing key")
+	}
+	if config.TTL <= 0 {
+		return nil, fmt.Errorf("token TTL must be positive")
+	}
+	return &TokenSigner{config: config}, nil
+}
+
+// Token returns a valid token, signing a new one when the cached one is
+// past half its lifetime
+func (s *TokenSigner) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.token != "" && now.Before(s.renewAt) {
+		return s.token, nil
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	token, err := SignToken(s.config.Keys[0], TokenClaims{
+		Issuer:    s.config.Issuer,
+		Audience:  s.config.Audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(s.config.TTL).Unix(),
+		ID:        hex.EncodeToString(id),
+	})
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.renewAt = now.Add(s.config.TTL / 2)
+	return token, nil
+}
+
+// Invalidate drops the cached token, e.g. after the backend rejected it
+func (s *TokenSigner) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// SetKeys replaces the signing keys; the next token is signed with the
+// first one
+func (s *TokenSigner) SetKeys(keys []SigningKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("token signer needs a signing key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Keys = keys
+	s.token = ""
+	return nil
+}
+
+// KeyID returns the ID of the key currently signing
+func (s *TokenSigner) KeyID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.Keys[0].ID
+}
+
+// SignToken signs claims as an HS256 JWT carrying the key ID
+func SignToken(key SigningKey, claims TokenClaims) (string, error) {
+	header, err := json.Marshal(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := encodeSegment(header) + "." + encodeSegment(payload)
+	return signed + "." + encodeSegment(sign(key.Secret, signed)), nil
+}
+
+// VerifyToken checks a service token's signature against keys, its audience
+// and its validity window widened by skew on both ends. It is the contract
+// the backend implements; md-ingest uses it for tooling and tests.
+func VerifyToken(token string, keys []SigningKey, audience string, skew time.Duration, now time.Time) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	signed := parts[0] + "." + parts[1]
+	valid := false
+	for _, key := range keys {
+		if header.Kid != "" && key.ID != header.Kid {
+			continue
+		}
+		if hmac.Equal(sig, sign(key.Secret, signed)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: bad signature or unknown key %q", ErrInvalidToken, header.Kid)
+	}
+
+	var claims TokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if claims.Audience != audience {
+		return nil, fmt.Errorf("%w: audience %q", ErrInvalidToken, claims.Audience)
+	}
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(skew)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	notBefore := max(claims.NotBefore, claims.IssuedAt)
+	if now.Before(time.Unix(notBefore, 0).Add(-skew)) {
+		return nil, fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+func sign(secret []byte, data string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
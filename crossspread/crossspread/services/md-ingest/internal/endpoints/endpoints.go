@@ -0,0 +1,297 @@
+package endpoints
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Endpoint kinds
+const (
+	KindWS   = "ws"
+	KindREST = "rest"
+)
+
+// Candidates are the interchangeable endpoints of a venue, as the
+// connector's WsURL and RestURL hold them
+type Candidates struct {
+	WS   []string
+	REST []string
+}
+
+// Config controls endpoint selection
+type Config struct {
+	Candidates map[connector.ExchangeID]Candidates
+	Samples    int           // Handshakes timed per candidate; the median counts
+	Timeout    time.Duration // Per handshake; a candidate timing out every sample is skipped
+}
+
+// DefaultConfig lists the venues' documented alternative domains: Bybit's
+// bytick.com mirror and OKX's AWS endpoints, next to the defaults
+func DefaultConfig() Config {
+	return Config{
+		Candidates: map[connector.ExchangeID]Candidates{
+			connector.Binance: {
+				WS:   []string{"wss://fstream.binance.com"},
+				REST: []string{"https://fapi.binance.com"},
+			},
+			connector.Bybit: {
+				WS:   []string{"wss://stream.bybit.com/v5/public/linear", "wss://stream.bytick.com/v5/public/linear"},
+				REST: []string{"https://api.bybit.com", "https://api.bytick.com"},
+			},
+			connector.OKX: {
+				WS:   []string{"wss://ws.okx.com:8443/ws/v5/public", "wss://wsaws.okx.com:8443/ws/v5/public"},
+				REST: []string{"https://www.okx.com", "https://aws.okx.com"},
+			},
+		},
+		Samples: 3,
+		Timeout: 2 * time.Second,
+	}
+}
+
+// ParseCandidates returns the default config with candidates replaced per
+// venue and kind, e.g. "okx.ws=wss://wsaws.okx.com:8443/ws/v5/public,binance.rest=https://fapi.binance.com|https://fapi1.example".
+// A single candidate pins the endpoint without probing.
+func ParseCandidates(s string) (Config, error) {
+	config := DefaultConfig()
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target, value, ok := strings.Cut(part, "=")
+		exchange, kind, dotted := strings.Cut(strings.TrimSpace(target), ".")
+		if !ok || !dotted || (kind != KindWS && kind != KindREST) {
+			return config, fmt.Errorf("invalid endpoint candidates %q: want exchange.ws=url|url or exchange.rest=url|url", part)
+		}
+		var urls []string
+		for _, raw := range strings.Split(value, "|") {
+			raw = strings.TrimSpace(raw)
+			if _, err := hostPort(raw); err != nil {
+				return config, fmt.Errorf("invalid endpoint candidates %q: %w", part, err)
+			}
+			urls = append(urls, raw)
+		}
+		id := connector.ExchangeID(strings.ToLower(exchange))
+		c := config.Candidates[id]
+		if kind == KindWS {
+			c.WS = urls
+		} else {
+			c.REST = urls
+		}
+		config.Candidates[id] = c
+	}
+	return config, nil
+}
+
+// hostPort returns the address a URL's handshake is timed against
+func hostPort(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL", raw)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	switch u.Scheme {
+	case "wss", "https":
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	case "ws", "http":
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	}
+	return "", fmt.Errorf("%q has no port and an unknown scheme", raw)
+}
+
+// Probe is a candidate's measured latency
+type Probe struct {
+	URL       string  `json:"url"`
+	LatencyMs float64 `json:"latency_ms,omitempty"` // Median TCP and TLS handshake time
+	Error     string  `json:"error,omitempty"`      // Set when every sample failed
+}
+
+// Selection is the endpoint picked for a venue and kind
+type Selection struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Kind     string               `json:"kind"` // ws, rest
+	URL      string               `json:"url"`
+	Probes   []Probe              `json:"probes,omitempty"` // Empty when a single candidate was pinned
+}
+
+// Selector times each venue's candidate endpoints and picks the fastest.
+// Handshake time tracks network distance, which is what colocation changes;
+// it is measured once, at startup.
+type Selector struct {
+	config Config
+	dial   func(ctx context.Context, addr string, useTLS bool) (time.Duration, error)
+
+	mu         sync.RWMutex
+	selections []Selection
+}
+
+// New creates a selector
+func New(config Config) *Selector {
+	if config.Samples <= 0 {
+		config.Samples = 1
+	}
+	return &Selector{config: config, dial: handshake}
+}
+
+// handshake times a TCP connect plus, for TLS endpoints, the TLS handshake
+func handshake(ctx context.Context, addr string, useTLS bool) (time.Duration, error) {
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start), nil
+}
+
+// Select probes the candidates of the venues given, in parallel, and
+// returns the fastest endpoint of each kind. A venue whose candidates all
+// fail keeps its first one.
+func (s *Selector) Select(ctx context.Context, exchanges []connector.ExchangeID) []Selection {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var result []Selection
+	for _, id := range exchanges {
+		c, ok := s.config.Candidates[id]
+		if !ok {
+			continue
+		}
+		for kind, urls := range map[string][]string{KindWS: c.WS, KindREST: c.REST} {
+			if len(urls) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func(id connector.ExchangeID, kind string, urls []string) {
+				defer wg.Done()
+				sel := s.pick(ctx, id, kind, urls)
+				mu.Lock()
+				result = append(result, sel)
+				mu.Unlock()
+			}(id, kind, urls)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	s.mu.Lock()
+	s.selections = result
+	s.mu.Unlock()
+	return result
+}
+
+func (s *Selector) pick(ctx context.Context, id connector.ExchangeID, kind string, urls []string) Selection {
+	sel := Selection{Exchange: id, Kind: kind, URL: urls[0]}
+	if len(urls) == 1 {
+		return sel
+	}
+
+	best := time.Duration(-1)
+	for _, u := range urls {
+		probe := Probe{URL: u}
+		latency, err := s.measure(ctx, u)
+		if err != nil {
+			probe.Error = err.Error()
+		} else {
+			probe.LatencyMs = float64(latency.Microseconds()) / 1000
+			metrics.EndpointLatency.WithLabelValues(string(id), kind, u).Set(latency.Seconds())
+			if best < 0 || latency < best {
+				best, sel.URL = latency, u
+			}
+		}
+		sel.Probes = append(sel.Probes, probe)
+	}
+	return sel
+}
+
+// measure returns the median of the candidate's successful handshakes
+func (s *Selector) measure(ctx context.Context, raw string) (time.Duration, error) {
+	addr, err := hostPort(raw)
+	if err != nil {
+		return 0, err
+	}
+	useTLS := strings.HasPrefix(raw, "wss://") || strings.HasPrefix(raw, "https://")
+	var samples []time.Duration
+	var lastErr error
+	for i := 0; i < s.config.Samples; i++ {
+		sctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+		d, err := s.dial(sctx, addr, useTLS)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		samples = append(samples, d)
+	}
+	if len(samples) == 0 {
+		return 0, lastErr
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2], nil
+}
+
+// Configurable is a connector whose endpoints can be replaced before it
+// connects
+type Configurable interface {
+	ID() connector.ExchangeID
+	SetEndpoints(ws, rest string)
+}
+
+// Apply sets each connector's selected endpoints and logs the choice
+func Apply(connectors []connector.Connector, selections []Selection) {
+	for _, conn := range connectors {
+		c, ok := conn.(Configurable)
+		if !ok {
+			continue
+		}
+		var ws, rest string
+		for _, sel := range selections {
+			if sel.Exchange != c.ID() {
+				continue
+			}
+			if sel.Kind == KindWS {
+				ws = sel.URL
+			} else {
+				rest = sel.URL
+			}
+			if len(sel.Probes) > 0 {
+				log.Info().Str("exchange", string(sel.Exchange)).Str("kind", sel.Kind).
+					Str("url", sel.URL).Interface("probes", sel.Probes).Msg("Selected fastest endpoint")
+			}
+		}
+		c.SetEndpoints(ws, rest)
+	}
+}
+
+// Selections returns the last selection
+func (s *Selector) Selections() []Selection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.selections
+}
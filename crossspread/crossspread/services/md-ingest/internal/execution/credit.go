@@ -0,0 +1,315 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// CreditConfig caps the share of total equity held on each venue, the
+// budget for the risk of a venue failing with the account's funds on it
+type CreditConfig struct {
+	DefaultPct float64                          // Share of total equity any venue may hold, in percent; 0 is uncapped
+	Venues     map[connector.ExchangeID]float64 // Per-venue caps in percent, overriding the default
+	// WarnFraction of a cap used raises an alert; entries keep full size
+	WarnFraction float64
+	// AtCapSizeFactor scales new entries with a leg on a venue at or past
+	// its cap; 0 rejects them
+	AtCapSizeFactor float64
+	RefreshInterval time.Duration // How often venue equity is read
+}
+
+// DefaultCreditConfig leaves every venue uncapped, alerts at 80% of a cap
+// once set and quarters entries on venues at their cap
+func DefaultCreditConfig() CreditConfig {
+	return CreditConfig{
+		Venues:          make(map[connector.ExchangeID]float64),
+		WarnFraction:    0.8,
+		AtCapSizeFactor: 0.25,
+		RefreshInterval: 10 * time.Second,
+	}
+}
+
+// ParseCreditCaps parses "lbank=10,mexc=15" as percent of total equity
+func ParseCreditCaps(s string) (map[connector.ExchangeID]float64, error) {
+	caps := make(map[connector.ExchangeID]float64)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ex, pct, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("venue cap %q: want exchange=percent", entry)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(pct), "%"), 64)
+		if err != nil || v < 0 || v > 100 {
+			return nil, fmt.Errorf("venue cap %q: percent must be between 0 and 100", entry)
+		}
+		caps[connector.ExchangeID(strings.ToLower(strings.TrimSpace(ex)))] = v
+	}
+	return caps, nil
+}
+
+// VenueEquity is what an account holds on a venue, written by executors
+// from the venue's balances
+type VenueEquity struct {
+	BalanceUSD       float64   `json:"balance_usd"` // Wallet balance, collateral included
+	UnrealizedPnLUSD float64   `json:"unrealized_pnl_usd"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// VenueExposure is a venue's equity against its share of total equity
+type VenueExposure struct {
+	Exchange         connector.ExchangeID `json:"exchange"`
+	BalanceUSD       float64              `json:"balance_usd"`
+	UnrealizedPnLUSD float64              `json:"unrealized_pnl_usd"`
+	EquityUSD        float64              `json:"equity_usd"`
+	SharePct         float64              `json:"share_pct"` // Of total equity across venues
+	CapPct           float64              `json:"cap_pct"`   // Zero when uncapped
+	Used             float64              `json:"used,omitempty"`
+	Warning          bool                 `json:"warning,omitempty"` // At or past the warn fraction
+	AtCap            bool                 `json:"at_cap,omitempty"`
+	SizeFactor       float64              `json:"size_factor"` // Scale of new entries with a leg here
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+// CreditStatus is every venue's exposure, most of a cap used first
+type CreditStatus struct {
+	TotalEquityUSD float64         `json:"total_equity_usd"`
+	Venues         []VenueExposure `json:"venues"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// CreditLimits tracks the equity held on each venue, balances plus
+// unrealized PnL, as a share of the total across venues. Venues past the
+// warn fraction of their cap alert; new entries with a leg on a venue at
+// its cap are scaled down. A nil *CreditLimits never scales entries.
+type CreditLimits struct {
+	config CreditConfig
+	client *redis.Client
+
+	mu     sync.RWMutex
+	status CreditStatus
+	byID   map[connector.ExchangeID]VenueExposure
+	warned map[connector.ExchangeID]string // warn or cap, for venues currently alerted
+	done   chan struct{}
+}
+
+// NewCreditLimits creates the limits reading venue equity from Redis
+func NewCreditLimits(client *redis.Client, config CreditConfig) *CreditLimits {
+	return &CreditLimits{
+		config: config,
+		client: client,
+		byID:   make(map[connector.ExchangeID]VenueExposure),
+		warned: make(map[connector.ExchangeID]string),
+		done:   make(chan struct{}),
+	}
+}
+
+// capFor returns a venue's cap in percent
+func (l *CreditLimits) capFor(exchange connector.ExchangeID) float64 {
+	if pct, ok := l.config.Venues[exchange]; ok {
+		return pct
+	}
+	return l.config.DefaultPct
+}
+
+// Refresh reads every venue's equity and re-evaluates the shares. Fields
+// that don't parse are ignored.
+func (l *CreditLimits) Refresh(ctx context.Context) error {
+	raw, err := l.client.HGetAll(ctx, keyspace.Key(keyspace.EquityKey)).Result()
+	if err != nil {
+		return err
+	}
+	venues := make(map[connector.ExchangeID]VenueEquity, len(raw))
+	for exchange, v := range raw {
+		var e VenueEquity
+		if err := json.Unmarshal([]byte(v), &e); err != nil {
+			log.Warn().Str("exchange", exchange).Str("value", v).Msg("Ignoring invalid venue equity")
+			continue
+		}
+		venues[connector.ExchangeID(exchange)] = e
+	}
+	l.record(time.Now(), venues)
+	return nil
+}
+
+// record replaces the exposures with shares of the given equity
+func (l *CreditLimits) record(at time.Time, venues map[connector.ExchangeID]VenueEquity) {
+	status := CreditStatus{UpdatedAt: at, Venues: make([]VenueExposure, 0, len(venues))}
+	for _, e := range venues {
+		if equity := e.BalanceUSD + e.UnrealizedPnLUSD; equity > 0 {
+			status.TotalEquityUSD += equity
+		}
+	}
+	for exchange, e := range venues {
+		x := VenueExposure{
+			Exchange:         exchange,
+			BalanceUSD:       e.BalanceUSD,
+			UnrealizedPnLUSD: e.UnrealizedPnLUSD,
+			EquityUSD:        e.BalanceUSD + e.UnrealizedPnLUSD,
+			CapPct:           l.capFor(exchange),
+			SizeFactor:       1,
+			UpdatedAt:        e.UpdatedAt,
+		}
+		if status.TotalEquityUSD > 0 && x.EquityUSD > 0 {
+			x.SharePct = x.EquityUSD / status.TotalEquityUSD * 100
+		}
+		if x.CapPct > 0 {
+			x.Used = x.SharePct / x.CapPct
+			x.Warning = l.config.WarnFraction > 0 && x.Used >= l.config.WarnFraction
+			x.AtCap = x.Used >= 1
+		}
+		if x.AtCap {
+			x.SizeFactor = l.config.AtCapSizeFactor
+		}
+		status.Venues = append(status.Venues, x)
+	}
+	sort.Slice(status.Venues, func(i, j int) bool {
+		vi, vj := status.Venues[i], status.Venues[j]
+		if vi.Used != vj.Used {
+			return vi.Used > vj.Used
+		}
+		if vi.SharePct != vj.SharePct {
+			return vi.SharePct > vj.SharePct
+		}
+		return vi.Exchange < vj.Exchange
+	})
+
+	byID := make(map[connector.ExchangeID]VenueExposure, len(status.Venues))
+	for _, x := range status.Venues {
+		byID[x.Exchange] = x
+		l.alert(x)
+	}
+	l.mu.Lock()
+	l.status = status
+	l.byID = byID
+	l.mu.Unlock()
+}
+
+// alert updates a venue's gauges and logs once on each level change: up to
+// the warn fraction or the cap, counted as an alert, and back down from
+// either
+func (l *CreditLimits) alert(x VenueExposure) {
+	metrics.VenueEquityShare.WithLabelValues(string(x.Exchange)).Set(x.SharePct / 100)
+	metrics.VenueExposureUtilization.WithLabelValues(string(x.Exchange)).Set(x.Used)
+
+	level := ""
+	switch {
+	case x.AtCap:
+		level = "cap"
+	case x.Warning:
+		level = "warn"
+	}
+
+	l.mu.Lock()
+	prev := l.warned[x.Exchange]
+	if level == "" {
+		delete(l.warned, x.Exchange)
+	} else {
+		l.warned[x.Exchange] = level
+	}
+	l.mu.Unlock()
+
+	switch {
+	case level == prev:
+	case level == "":
+		log.Info().Str("exchange", string(x.Exchange)).Float64("share_pct", x.SharePct).Float64("cap_pct", x.CapPct).Msg("Venue exposure back under warn level")
+	case prev == "cap":
+		log.Info().Str("exchange", string(x.Exchange)).Float64("share_pct", x.SharePct).Float64("cap_pct", x.CapPct).Msg("Venue exposure back under cap, entries at full size")
+	default:
+		metrics.VenueExposureAlerts.WithLabelValues(string(x.Exchange), level).Inc()
+		event := log.Warn()
+		msg := "Venue exposure near cap"
+		if level == "cap" {
+			event = event.Float64("size_factor", x.SizeFactor)
+			msg = "Venue exposure at cap, scaling down new entries"
+		}
+		event.
+			Str("exchange", string(x.Exchange)).
+			Float64("equity_usd", x.EquityUSD).
+			Float64("share_pct", x.SharePct).
+			Float64("cap_pct", x.CapPct).
+			Msg(msg)
+	}
+}
+
+// Status returns every venue's exposure
+func (l *CreditLimits) Status() CreditStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	status := l.status
+	status.Venues = append([]VenueExposure(nil), l.status.Venues...)
+	return status
+}
+
+// Exposure returns a venue's exposure, if its equity is known
+func (l *CreditLimits) Exposure(exchange connector.ExchangeID) (VenueExposure, bool) {
+	if l == nil {
+		return VenueExposure{}, false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	x, ok := l.byID[exchange]
+	return x, ok
+}
+
+// SizeFactor returns the scale of a new entry with legs on the given
+// venues: the smallest of theirs, 1 when none is at its cap
+func (l *CreditLimits) SizeFactor(exchanges ...connector.ExchangeID) float64 {
+	factor := 1.0
+	for _, exchange := range exchanges {
+		if x, ok := l.Exposure(exchange); ok && x.SizeFactor < factor {
+			factor = x.SizeFactor
+		}
+	}
+	return factor
+}
+
+// CheckEntry rejects an entry with a leg on a venue at its cap when
+// entries there are scaled to nothing
+func (l *CreditLimits) CheckEntry(exchanges ...connector.ExchangeID) error {
+	for _, exchange := range exchanges {
+		if x, ok := l.Exposure(exchange); ok && x.AtCap && x.SizeFactor <= 0 {
+			return fmt.Errorf("%s holds %.1f%% of equity, cap %.1f%%", exchange, x.SharePct, x.CapPct)
+		}
+	}
+	return nil
+}
+
+// Start reads venue equity until the context is cancelled or Stop is called
+func (l *CreditLimits) Start(ctx context.Context) {
+	ticker := time.NewTicker(l.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.done:
+			return
+		case <-ticker.C:
+			if err := l.Refresh(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh venue equity")
+			}
+		}
+	}
+}
+
+// Stop stops the refresh loop
+func (l *CreditLimits) Stop() {
+	close(l.done)
+}
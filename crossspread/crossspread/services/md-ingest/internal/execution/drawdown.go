@@ -0,0 +1,360 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// De-risking actions taken after a drawdown
+const (
+	DeRiskHalve = "halve" // Entry sizes and notional caps are scaled by SizeFactor
+	DeRiskHalt  = "halt"  // New entries are rejected
+)
+
+var (
+	// ErrEntriesHalted is returned for entries while a drawdown halt is active
+	ErrEntriesHalted = errors.New("new entries halted after drawdown")
+	// ErrNotDeRisked is returned when acknowledging with no de-risking active
+	ErrNotDeRisked = errors.New("no de-risking active")
+)
+
+// DrawdownConfig controls automatic de-risking on strategy drawdown
+type DrawdownConfig struct {
+	MaxDrawdownUSD  float64       // Drop from the rolling PnL peak that trips de-risking; 0 disables
+	Window          time.Duration // The peak is the highest PnL over this trailing window
+	Action          string        // halve or halt
+	SizeFactor      float64       // Scale of entry sizes and caps while halved
+	RefreshInterval time.Duration // How often strategy PnL is read
+}
+
+// DefaultDrawdownConfig halves sizes on a drawdown from the 24h peak, once
+// a maximum is set
+func DefaultDrawdownConfig() DrawdownConfig {
+	return DrawdownConfig{
+		Window:          24 * time.Hour,
+		Action:          DeRiskHalve,
+		SizeFactor:      0.5,
+		RefreshInterval: 5 * time.Second,
+	}
+}
+
+// ValidDeRiskAction reports whether action is halve or halt
+func ValidDeRiskAction(action string) bool {
+	return action == DeRiskHalve || action == DeRiskHalt
+}
+
+// DeRiskState is the de-risking in force. It stays until acknowledged, is
+// saved to Redis so restarts keep it, and executors read it from there.
+type DeRiskState struct {
+	Active      bool               `json:"active"`
+	Action      string             `json:"action,omitempty"` // halve or halt
+	SizeFactor  float64            `json:"size_factor"`      // 1 unless halved, 0 when halted
+	DrawdownUSD float64            `json:"drawdown_usd,omitempty"`
+	PeakUSD     float64            `json:"peak_usd,omitempty"`
+	PnLUSD      float64            `json:"pnl_usd,omitempty"`
+	Strategies  map[string]float64 `json:"strategies,omitempty"` // PnL per strategy when tripped
+	TrippedAt   time.Time          `json:"tripped_at,omitempty"`
+	// The acknowledgement that ended the last de-risking
+	AcknowledgedBy string    `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// DrawdownStatus is the rolling drawdown now, with the state in force
+type DrawdownStatus struct {
+	State          DeRiskState        `json:"state"`
+	PnLUSD         float64            `json:"pnl_usd"`
+	PeakUSD        float64            `json:"peak_usd"`
+	DrawdownUSD    float64            `json:"drawdown_usd"`
+	MaxDrawdownUSD float64            `json:"max_drawdown_usd"`
+	TodayUSD       float64            `json:"today_usd"` // Since 00:00 UTC, or the oldest sample if later
+	Window         string             `json:"window"`
+	Strategies     map[string]float64 `json:"strategies"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type pnlSample struct {
+	at  time.Time
+	pnl float64
+}
+
+// DrawdownGuard tracks the rolling PnL of all strategies together, from
+// the cumulative PnL executors write per strategy, and de-risks once it
+// falls MaxDrawdownUSD below its peak over the window. Trading resumes at
+// full size only after an operator acknowledges. A nil *DrawdownGuard
+// never de-risks.
+type DrawdownGuard struct {
+	config DrawdownConfig
+	client *redis.Client
+
+	mu         sync.RWMutex
+	samples    []pnlSample // Oldest first, within the window
+	strategies map[string]float64
+	updatedAt  time.Time
+	state      DeRiskState
+	done       chan struct{}
+}
+
+// NewDrawdownGuard creates a guard reading strategy PnL from Redis
+func NewDrawdownGuard(client *redis.Client, config DrawdownConfig) *DrawdownGuard {
+	return &DrawdownGuard{
+		config:     config,
+		client:     client,
+		strategies: make(map[string]float64),
+		state:      DeRiskState{SizeFactor: 1},
+		done:       make(chan struct{}),
+	}
+}
+
+// Load restores the de-risking state saved before a restart
+func (g *DrawdownGuard) Load(ctx context.Context) error {
+	data, err := g.client.Get(ctx, keyspace.Key(keyspace.DeRiskKey)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state DeRiskState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("de-risk state: %w", err)
+	}
+
+	g.mu.Lock()
+	g.state = state
+	g.mu.Unlock()
+	g.observe(state)
+	if state.Active {
+		log.Warn().Str("action", state.Action).Time("tripped_at", state.TrippedAt).Msg("De-risking still active from before restart")
+	}
+	return nil
+}
+
+// Refresh reads every strategy's cumulative PnL and re-evaluates the
+// drawdown. Fields that don't parse are ignored.
+func (g *DrawdownGuard) Refresh(ctx context.Context) error {
+	raw, err := g.client.HGetAll(ctx, keyspace.Key(keyspace.PnLKey)).Result()
+	if err != nil {
+		return err
+	}
+	strategies := make(map[string]float64, len(raw))
+	for strategy, v := range raw {
+		pnl, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Warn().Str("strategy", strategy).Str("value", v).Msg("Ignoring invalid strategy PnL")
+			continue
+		}
+		strategies[strategy] = pnl
+	}
+
+	if state, tripped := g.record(time.Now(), strategies); tripped {
+		if err := g.save(ctx, state); err != nil {
+			return fmt.Errorf("save de-risk state: %w", err)
+		}
+	}
+	return nil
+}
+
+// record adds a PnL sample and trips de-risking if the drawdown reached
+// the maximum. Returns the new state if it tripped.
+func (g *DrawdownGuard) record(at time.Time, strategies map[string]float64) (DeRiskState, bool) {
+	var pnl float64
+	for _, v := range strategies {
+		pnl += v
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.strategies = strategies
+	g.updatedAt = at
+	g.samples = append(g.samples, pnlSample{at: at, pnl: pnl})
+	cutoff := at.Add(-g.config.Window)
+	drop := 0
+	for drop < len(g.samples)-1 && g.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	g.samples = g.samples[drop:]
+
+	peak, drawdown := g.drawdown()
+	metrics.DrawdownUSD.Set(drawdown)
+	if g.state.Active || g.config.MaxDrawdownUSD <= 0 || drawdown < g.config.MaxDrawdownUSD {
+		return g.state, false
+	}
+
+	g.state = DeRiskState{
+		Active:         true,
+		Action:         g.config.Action,
+		SizeFactor:     g.config.SizeFactor,
+		DrawdownUSD:    drawdown,
+		PeakUSD:        peak,
+		PnLUSD:         pnl,
+		Strategies:     strategies,
+		TrippedAt:      at,
+		AcknowledgedBy: g.state.AcknowledgedBy,
+		AcknowledgedAt: g.state.AcknowledgedAt,
+	}
+	if g.config.Action == DeRiskHalt {
+		g.state.SizeFactor = 0
+	}
+	metrics.DeRiskTrips.WithLabelValues(g.config.Action).Inc()
+	log.Warn().
+		Str("action", g.config.Action).
+		Float64("drawdown_usd", drawdown).
+		Float64("peak_usd", peak).
+		Float64("pnl_usd", pnl).
+		Msg("Drawdown limit hit, de-risking until acknowledged")
+	return g.state, true
+}
+
+// drawdown returns the peak over the window and the drop from it to the
+// latest sample. Caller holds g.mu.
+func (g *DrawdownGuard) drawdown() (float64, float64) {
+	if len(g.samples) == 0 {
+		return 0, 0
+	}
+	peak := g.samples[0].pnl
+	for _, s := range g.samples[1:] {
+		if s.pnl > peak {
+			peak = s.pnl
+		}
+	}
+	return peak, peak - g.samples[len(g.samples)-1].pnl
+}
+
+// Acknowledge ends de-risking. The rolling peak restarts from the current
+// PnL, so the drawdown already taken does not trip it again.
+func (g *DrawdownGuard) Acknowledge(ctx context.Context, by string) (DeRiskState, error) {
+	g.mu.Lock()
+	if !g.state.Active {
+		g.mu.Unlock()
+		return DeRiskState{}, ErrNotDeRisked
+	}
+	prev := g.state
+	g.state = DeRiskState{SizeFactor: 1, AcknowledgedBy: by, AcknowledgedAt: time.Now()}
+	if n := len(g.samples); n > 0 {
+		g.samples = g.samples[n-1:]
+	}
+	state := g.state
+	g.mu.Unlock()
+
+	log.Info().
+		Str("by", by).
+		Str("action", prev.Action).
+		Time("tripped_at", prev.TrippedAt).
+		Msg("De-risking acknowledged, resuming full size")
+	return state, g.save(ctx, state)
+}
+
+// save stores the state for restarts and executors
+func (g *DrawdownGuard) save(ctx context.Context, state DeRiskState) error {
+	g.observe(state)
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return g.client.Set(ctx, keyspace.Key(keyspace.DeRiskKey), data, 0).Err()
+}
+
+func (g *DrawdownGuard) observe(state DeRiskState) {
+	active := 0.0
+	if state.Active {
+		active = 1
+	}
+	metrics.DeRiskActive.Set(active)
+}
+
+// State returns the de-risking in force
+func (g *DrawdownGuard) State() DeRiskState {
+	if g == nil {
+		return DeRiskState{SizeFactor: 1}
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.state
+}
+
+// Status returns the rolling drawdown and the state in force
+func (g *DrawdownGuard) Status() DrawdownStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	peak, drawdown := g.drawdown()
+	status := DrawdownStatus{
+		State:          g.state,
+		PeakUSD:        peak,
+		DrawdownUSD:    drawdown,
+		MaxDrawdownUSD: g.config.MaxDrawdownUSD,
+		Window:         g.config.Window.String(),
+		Strategies:     make(map[string]float64, len(g.strategies)),
+		UpdatedAt:      g.updatedAt,
+	}
+	if n := len(g.samples); n > 0 {
+		status.PnLUSD = g.samples[n-1].pnl
+		midnight := g.samples[n-1].at.UTC().Truncate(24 * time.Hour)
+		for _, s := range g.samples {
+			if !s.at.Before(midnight) {
+				status.TodayUSD = status.PnLUSD - s.pnl
+				break
+			}
+		}
+	}
+	names := make([]string, 0, len(g.strategies))
+	for name := range g.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		status.Strategies[name] = g.strategies[name]
+	}
+	return status
+}
+
+// SizeFactor returns the scale of entry sizes and notional caps: 1 at full
+// size, SizeFactor while halved, 0 while halted
+func (g *DrawdownGuard) SizeFactor() float64 {
+	return g.State().SizeFactor
+}
+
+// CheckEntry returns ErrEntriesHalted while entries are halted
+func (g *DrawdownGuard) CheckEntry() error {
+	if state := g.State(); state.Active && state.Action == DeRiskHalt {
+		return ErrEntriesHalted
+	}
+	return nil
+}
+
+// Start reads strategy PnL until the context is cancelled or Stop is called
+func (g *DrawdownGuard) Start(ctx context.Context) {
+	ticker := time.NewTicker(g.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.done:
+			return
+		case <-ticker.C:
+			if err := g.Refresh(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh strategy PnL")
+			}
+		}
+	}
+}
+
+// Stop stops the refresh loop
+func (g *DrawdownGuard) Stop() {
+	close(g.done)
+}
@@ -0,0 +1,241 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HedgeRankConfig controls how alternate hedge venues are ranked
+type HedgeRankConfig struct {
+	MaxQuoteAge time.Duration // Venues with older books are not ranked
+	MinDepthUSD float64       // Venues with less on the hedged side are not ranked
+	// LatencyBpsPer100ms converts a venue's quote latency into cost; a
+	// slow venue's touch is more likely gone by the time the hedge lands
+	LatencyBpsPer100ms float64
+}
+
+// DefaultHedgeRankConfig returns a one second freshness bound and half a
+// bp of cost per 100ms of quote latency
+func DefaultHedgeRankConfig() HedgeRankConfig {
+	return HedgeRankConfig{
+		MaxQuoteAge:        time.Second,
+		MinDepthUSD:        1000,
+		LatencyBpsPer100ms: 0.5,
+	}
+}
+
+// HedgeVenue is a candidate venue for hedging a leg
+type HedgeVenue struct {
+	Exchange    connector.ExchangeID `json:"exchange"`
+	Symbol      string               `json:"symbol"`
+	Touch       float64              `json:"touch"`     // Ask for a buy, bid for a sell
+	AvgPrice    float64              `json:"avg_price"` // Walking the book for the quantity
+	DepthUSD    float64              `json:"depth_usd"` // On the hedged side
+	Partial     bool                 `json:"partial"`   // The book cannot fill the whole quantity
+	TakerFeeBps float64              `json:"taker_fee_bps"`
+	LatencyMs   float64              `json:"latency_ms"`
+	CostBps     float64              `json:"cost_bps"` // Price vs the best touch + fee + latency penalty
+}
+
+// HedgeRanker ranks the venues a leg can be hedged on. Rankings are
+// computed from the live books on every call, so they are never stale.
+type HedgeRanker struct {
+	config    HedgeRankConfig
+	cache     BookCache
+	economics spread.EconomicsConfig
+	status    StatusGate // Optional; venues that cannot open are not ranked
+	modes     *Modes     // Optional; md-only venues are not ranked
+}
+
+// NewHedgeRanker creates a hedge venue ranker using the account's taker fees
+func NewHedgeRanker(cache BookCache, economics spread.EconomicsConfig, config HedgeRankConfig) *HedgeRanker {
+	return &HedgeRanker{config: config, cache: cache, economics: economics}
+}
+
+// SetStatusGate skips venues whose symbol cannot open positions
+func (r *HedgeRanker) SetStatusGate(gate StatusGate) {
+	r.status = gate
+}
+
+// SetModes skips venues in md-only mode
+func (r *HedgeRanker) SetModes(modes *Modes) {
+	r.modes = modes
+}
+
+// Rank returns the trading venues quoting a canonical symbol, cheapest to
+// hedge quantity (base units) on first. Venues that can only fill part of
+// it rank after those that can fill all of it. Excluded venues are skipped.
+func (r *HedgeRanker) Rank(canonical, side string, quantity float64, exclude ...connector.ExchangeID) []HedgeVenue {
+	skip := make(map[connector.ExchangeID]bool, len(exclude))
+	for _, id := range exclude {
+		skip[id] = true
+	}
+
+	now := time.Now()
+	var venues []HedgeVenue
+	for id, ob := range r.cache.Orderbooks(canonical) {
+		if skip[id] || connector.GetCapabilities(id).QuoteOnly() || !r.modes.CanTrade(id) {
+			continue
+		}
+		if ob.ReceivedAt.IsZero() || now.Sub(ob.ReceivedAt) > r.config.MaxQuoteAge {
+			continue
+		}
+		if checkOpen(r.status, id, ob.Symbol) != nil {
+			continue
+		}
+		levels := ob.Asks
+		if side == SideSell {
+			levels = ob.Bids
+		}
+		if len(levels) == 0 || levels[0].Price <= 0 {
+			continue
+		}
+
+		v := HedgeVenue{
+			Exchange:    id,
+			Symbol:      ob.Symbol,
+			Touch:       levels[0].Price,
+			TakerFeeBps: r.economics.TakerFee(id),
+			LatencyMs:   quoteLatencyMs(ob, now),
+		}
+		v.AvgPrice, v.Partial = walkBook(levels, quantity)
+		for _, l := range levels {
+			v.DepthUSD += l.Price * l.Quantity
+		}
+		if v.DepthUSD < r.config.MinDepthUSD {
+			continue
+		}
+		venues = append(venues, v)
+	}
+	if len(venues) == 0 {
+		return nil
+	}
+
+	// Price cost is measured against the best touch across venues
+	best := venues[0].Touch
+	for _, v := range venues[1:] {
+		if (side == SideBuy && v.Touch < best) || (side == SideSell && v.Touch > best) {
+			best = v.Touch
+		}
+	}
+	for i := range venues {
+		v := &venues[i]
+		priceBps := (v.AvgPrice - best) / best * 10000
+		if side == SideSell {
+			priceBps = -priceBps
+		}
+		v.CostBps = priceBps + v.TakerFeeBps + v.LatencyMs/100*r.config.LatencyBpsPer100ms
+	}
+
+	sort.Slice(venues, func(i, j int) bool {
+		if venues[i].Partial != venues[j].Partial {
+			return !venues[i].Partial
+		}
+		return venues[i].CostBps < venues[j].CostBps
+	})
+	return venues
+}
+
+// walkBook returns the average price of filling quantity through levels and
+// whether the levels ran out first
+func walkBook(levels []connector.PriceLevel, quantity float64) (float64, bool) {
+	if quantity <= 0 {
+		return levels[0].Price, false
+	}
+	var filled, notional float64
+	for _, l := range levels {
+		take := math.Min(l.Quantity, quantity-filled)
+		filled += take
+		notional += take * l.Price
+		if filled >= quantity {
+			return notional / filled, false
+		}
+	}
+	if filled == 0 {
+		return levels[0].Price, true
+	}
+	return notional / filled, true
+}
+
+func quoteLatencyMs(ob *connector.Orderbook, now time.Time) float64 {
+	from := ob.Timestamp
+	if from.IsZero() || from.Unix() <= 0 {
+		from = ob.ReceivedAt
+	}
+	return math.Max(float64(now.Sub(from))/float64(time.Millisecond), 0)
+}
+
+// MigrationFlag marks quantity hedged on a venue other than the intended
+// one, to be moved to the intended pair later
+type MigrationFlag struct {
+	ID        string               `json:"id"`
+	Canonical string               `json:"canonical"`
+	Side      string               `json:"side"`
+	Intended  connector.ExchangeID `json:"intended"`
+	Actual    connector.ExchangeID `json:"actual"`
+	Symbol    string               `json:"symbol"` // Symbol on the actual venue
+	Quantity  float64              `json:"quantity"`
+	AvgPrice  float64              `json:"avg_price"`
+	At        time.Time            `json:"at"`
+}
+
+// Migrations records positions hedged away from their intended venue. State
+// lives in Redis so every executor and the migration tooling see the same flags.
+type Migrations struct {
+	client *redis.Client
+}
+
+// NewMigrations creates a migration flag store
+func NewMigrations(client *redis.Client) *Migrations {
+	return &Migrations{client: client}
+}
+
+// Flag records a fallback hedge
+func (m *Migrations) Flag(ctx context.Context, f MigrationFlag) (MigrationFlag, error) {
+	if f.At.IsZero() {
+		f.At = time.Now()
+	}
+	if f.ID == "" {
+		f.ID = fmt.Sprintf("%s:%s:%s:%d", f.Canonical, f.Intended, f.Actual, f.At.UnixMilli())
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return f, err
+	}
+	return f, m.client.HSet(ctx, keyspace.Key(keyspace.MigrationsKey), f.ID, data).Err()
+}
+
+// List returns every flagged position, oldest first
+func (m *Migrations) List(ctx context.Context) ([]MigrationFlag, error) {
+	values, err := m.client.HGetAll(ctx, keyspace.Key(keyspace.MigrationsKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]MigrationFlag, 0, len(values))
+	for _, v := range values {
+		var f MigrationFlag
+		if err := json.Unmarshal([]byte(v), &f); err != nil {
+			continue
+		}
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].At.Before(result[j].At) })
+	return result, nil
+}
+
+// Clear removes a flag once the position has been migrated or closed.
+// Returns false if no such flag exists.
+func (m *Migrations) Clear(ctx context.Context, id string) (bool, error) {
+	n, err := m.client.HDel(ctx, keyspace.Key(keyspace.MigrationsKey), id).Result()
+	return n > 0, err
+}
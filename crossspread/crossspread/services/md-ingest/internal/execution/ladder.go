@@ -0,0 +1,325 @@
+package execution
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Order sides
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// Order is an immediate-or-cancel limit order. Quantity is in base units;
+// senders convert to contracts on venues that trade in contracts.
+type Order struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Symbol   string               `json:"symbol"`
+	Side     string               `json:"side"`
+	Price    float64              `json:"price"`
+	Quantity float64              `json:"quantity"`
+}
+
+// Fill is what an IOC order executed before the rest was cancelled
+type Fill struct {
+	Quantity float64 `json:"quantity"` // Base units
+	AvgPrice float64 `json:"avg_price"`
+}
+
+// OrderSender sends IOC orders. Executors adapt their venue trading
+// clients to it.
+type OrderSender interface {
+	SendIOC(ctx context.Context, order Order) (Fill, error)
+}
+
+// LadderConfig controls retries of a leg that missed
+type LadderConfig struct {
+	MaxAttempts int           // IOC attempts on the intended venue
+	MaxDuration time.Duration // Time budget for the whole ladder
+	StartBps    float64       // First attempt crosses the touch by this much
+	StepBps     float64       // Added to the offset after every miss
+	MaxBps      float64       // Offset cap; the ladder never pays more than this over the touch
+	MaxQuoteAge time.Duration // Oldest streamed quote a price is built from
+
+	// Fallback hedges what is left on other venues, best price first, once
+	// the ladder runs out, instead of leaving the other leg naked
+	Fallback    bool
+	FallbackBps float64 // Offset over the alternate venue's touch
+}
+
+// DefaultLadderConfig returns five attempts over 1.5s, widening from 2 to
+// at most 20 bps, then falling back to alternate venues
+func DefaultLadderConfig() LadderConfig {
+	return LadderConfig{
+		MaxAttempts: 5,
+		MaxDuration: 1500 * time.Millisecond,
+		StartBps:    2,
+		StepBps:     4,
+		MaxBps:      20,
+		MaxQuoteAge: time.Second,
+		Fallback:    true,
+		FallbackBps: 20,
+	}
+}
+
+// Attempt is one IOC order sent while working a leg
+type Attempt struct {
+	Order     Order     `json:"order"`
+	OffsetBps float64   `json:"offset_bps"`
+	Filled    float64   `json:"filled"`
+	FillPrice float64   `json:"fill_price,omitempty"`
+	Fallback  bool      `json:"fallback"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// LegResult is the outcome of working a leg. Unfilled is quantity still
+// unhedged after the ladder and the fallback; the caller must unwind or
+// escalate it.
+type LegResult struct {
+	Canonical string    `json:"canonical"`
+	Side      string    `json:"side"`
+	Requested float64   `json:"requested"`
+	Filled    float64   `json:"filled"`
+	AvgPrice  float64   `json:"avg_price"`
+	Unfilled  float64   `json:"unfilled"`
+	Venues    []string  `json:"venues"` // Venues that filled, intended venue first
+	Attempts  []Attempt `json:"attempts"`
+	ElapsedMs float64   `json:"elapsed_ms"`
+
+	// Fills on alternate venues, flagged for migration to the intended venue
+	Migrations []MigrationFlag `json:"migrations,omitempty"`
+}
+
+// Ladder works a leg with IOC orders at a widening price, then hedges the
+// remainder on the next-best venue
+type Ladder struct {
+	config     LadderConfig
+	cache      BookCache
+	sender     OrderSender
+	ranker     *HedgeRanker
+	migrations *Migrations // Optional; fallback fills are flagged here
+	modes      *Modes      // Optional; orders to md-only venues are refused
+}
+
+// NewLadder creates a retry ladder. Fallback venues are ranked with base
+// tier fees until SetRanker is called.
+func NewLadder(cache BookCache, sender OrderSender, config LadderConfig) *Ladder {
+	return &Ladder{
+		config: config,
+		cache:  cache,
+		sender: sender,
+		ranker: NewHedgeRanker(cache, spread.DefaultEconomicsConfig(), DefaultHedgeRankConfig()),
+	}
+}
+
+// SetRanker sets the ranker choosing fallback hedge venues
+func (l *Ladder) SetRanker(ranker *HedgeRanker) {
+	l.ranker = ranker
+	if l.modes != nil {
+		l.ranker.SetModes(l.modes)
+	}
+}
+
+// SetMigrations sets the store fallback hedges are flagged in
+func (l *Ladder) SetMigrations(migrations *Migrations) {
+	l.migrations = migrations
+}
+
+// SetModes refuses orders to venues in md-only mode, on the intended venue
+// and in the fallback alike
+func (l *Ladder) SetModes(modes *Modes) {
+	l.modes = modes
+	l.ranker.SetModes(modes)
+}
+
+// Execute fills quantity on the intended venue, retrying with a wider
+// price after every miss until filled, out of attempts or out of time,
+// then falls back to alternate venues for the rest
+func (l *Ladder) Execute(ctx context.Context, canonical string, exchange connector.ExchangeID, symbol, side string, quantity float64) *LegResult {
+	start := time.Now()
+	deadline := start.Add(l.config.MaxDuration)
+	result := &LegResult{Canonical: canonical, Side: side, Requested: quantity}
+
+	offset := l.config.StartBps
+	for i := 0; i < l.config.MaxAttempts && l.remaining(result) > 0 && time.Now().Before(deadline); i++ {
+		touch, ok := l.touch(canonical, exchange, side)
+		if !ok {
+			log.Warn().Str("exchange", string(exchange)).Str("symbol", symbol).Msg("No fresh quote for ladder attempt")
+			break
+		}
+
+		attemptCtx, cancel := context.WithDeadline(ctx, deadline)
+		l.send(attemptCtx, result, Order{
+			Exchange: exchange,
+			Symbol:   symbol,
+			Side:     side,
+			Price:    crossPrice(touch, offset, side),
+			Quantity: l.remaining(result),
+		}, offset, false)
+		cancel()
+
+		offset = math.Min(offset+l.config.StepBps, l.config.MaxBps)
+	}
+
+	if l.remaining(result) > 0 && l.config.Fallback {
+		l.fallback(ctx, result, exchange)
+		l.flagMigrations(ctx, result, exchange)
+	}
+
+	result.Unfilled = l.remaining(result)
+	result.ElapsedMs = float64(time.Since(start)) / float64(time.Millisecond)
+	if result.Unfilled > 0 {
+		log.Error().
+			Str("canonical", canonical).
+			Str("exchange", string(exchange)).
+			Str("side", side).
+			Float64("unfilled", result.Unfilled).
+			Msg("Leg left partly unhedged after ladder and fallback")
+	}
+	return result
+}
+
+// fallback hedges the remainder on other trading venues quoting the
+// canonical, cheapest first by the ranker, one IOC each
+func (l *Ladder) fallback(ctx context.Context, result *LegResult, intended connector.ExchangeID) {
+	for _, v := range l.ranker.Rank(result.Canonical, result.Side, l.remaining(result), intended) {
+		if l.remaining(result) <= 0 || ctx.Err() != nil {
+			return
+		}
+		before := result.Filled
+		l.send(ctx, result, Order{
+			Exchange: v.Exchange,
+			Symbol:   v.Symbol,
+			Side:     result.Side,
+			Price:    crossPrice(v.Touch, l.config.FallbackBps, result.Side),
+			Quantity: l.remaining(result),
+		}, l.config.FallbackBps, true)
+
+		outcome := "missed"
+		if result.Filled > before {
+			outcome = "filled"
+		}
+		metrics.ExecutionFallbackHedges.WithLabelValues(string(v.Exchange), outcome).Inc()
+	}
+}
+
+// flagMigrations records every venue that filled part of the leg in the
+// fallback, so the position can be moved to the intended venue later
+func (l *Ladder) flagMigrations(ctx context.Context, result *LegResult, intended connector.ExchangeID) {
+	byVenue := make(map[connector.ExchangeID]*MigrationFlag)
+	var order []connector.ExchangeID
+	for _, a := range result.Attempts {
+		if !a.Fallback || a.Filled <= 0 {
+			continue
+		}
+		f := byVenue[a.Order.Exchange]
+		if f == nil {
+			f = &MigrationFlag{
+				Canonical: result.Canonical,
+				Side:      result.Side,
+				Intended:  intended,
+				Actual:    a.Order.Exchange,
+				Symbol:    a.Order.Symbol,
+				At:        a.At,
+			}
+			byVenue[a.Order.Exchange] = f
+			order = append(order, a.Order.Exchange)
+		}
+		f.Quantity += a.Filled
+		f.AvgPrice = a.FillPrice // One fallback IOC per venue
+	}
+
+	for _, id := range order {
+		f := *byVenue[id]
+		if l.migrations != nil {
+			flagged, err := l.migrations.Flag(ctx, f)
+			if err != nil {
+				log.Error().Err(err).Str("canonical", f.Canonical).Str("exchange", string(id)).Msg("Failed to flag position for migration")
+			}
+			f = flagged
+		}
+		result.Migrations = append(result.Migrations, f)
+		log.Warn().
+			Str("canonical", f.Canonical).
+			Str("intended", string(intended)).
+			Str("actual", string(id)).
+			Float64("quantity", f.Quantity).
+			Msg("Leg hedged on fallback venue, flagged for migration")
+	}
+}
+
+// send places one IOC order and records it on the result
+func (l *Ladder) send(ctx context.Context, result *LegResult, order Order, offset float64, fallback bool) {
+	attempt := Attempt{Order: order, OffsetBps: offset, Fallback: fallback, At: time.Now()}
+	fill, err := Fill{}, checkTrade(l.modes, order.Exchange)
+	if err == nil {
+		fill, err = l.sender.SendIOC(ctx, order)
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+		metrics.ExecutionLadderAttempts.WithLabelValues(string(order.Exchange), "error").Inc()
+	} else if fill.Quantity > 0 {
+		attempt.Filled = fill.Quantity
+		attempt.FillPrice = fill.AvgPrice
+		result.AvgPrice = (result.AvgPrice*result.Filled + fill.AvgPrice*fill.Quantity) / (result.Filled + fill.Quantity)
+		result.Filled += fill.Quantity
+		if len(result.Venues) == 0 || result.Venues[len(result.Venues)-1] != string(order.Exchange) {
+			result.Venues = append(result.Venues, string(order.Exchange))
+		}
+		metrics.ExecutionLadderAttempts.WithLabelValues(string(order.Exchange), "filled").Inc()
+	} else {
+		metrics.ExecutionLadderAttempts.WithLabelValues(string(order.Exchange), "missed").Inc()
+	}
+	result.Attempts = append(result.Attempts, attempt)
+}
+
+// touch returns the price a side executes against on a venue: the ask for
+// a buy, the bid for a sell
+func (l *Ladder) touch(canonical string, exchange connector.ExchangeID, side string) (float64, bool) {
+	ob := l.cache.Orderbook(canonical, exchange)
+	if ob == nil {
+		return 0, false
+	}
+	return l.bookTouch(ob, side)
+}
+
+func (l *Ladder) bookTouch(ob *connector.Orderbook, side string) (float64, bool) {
+	if ob.ReceivedAt.IsZero() || time.Since(ob.ReceivedAt) > l.config.MaxQuoteAge {
+		return 0, false
+	}
+	levels := ob.Asks
+	if side == SideSell {
+		levels = ob.Bids
+	}
+	if len(levels) == 0 || levels[0].Price <= 0 {
+		return 0, false
+	}
+	return levels[0].Price, true
+}
+
+func (l *Ladder) remaining(result *LegResult) float64 {
+	rest := result.Requested - result.Filled
+	// Ignore float dust left by partial fills
+	if rest <= result.Requested*1e-9 {
+		return 0
+	}
+	return rest
+}
+
+// crossPrice returns a limit price offset bps through the touch: above the
+// ask for a buy, below the bid for a sell
+func crossPrice(touch, offsetBps float64, side string) float64 {
+	if side == SideSell {
+		return touch * (1 - offsetBps/10000)
+	}
+	return touch * (1 + offsetBps/10000)
+}
@@ -0,0 +1,342 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/inventory"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Notional measures capped per underlying
+const (
+	NotionalGross = "gross" // Sum of every venue's position, long and short alike
+	NotionalNet   = "net"   // Longs less shorts across venues
+)
+
+// NotionalCap is the most notional an underlying may carry, in USD. Zero
+// means no cap.
+type NotionalCap struct {
+	MaxGrossUSD float64 `json:"max_gross_usd"`
+	MaxNetUSD   float64 `json:"max_net_usd"`
+}
+
+// NotionalLimitConfig caps notional per underlying across all venues and
+// strategies sharing the position store
+type NotionalLimitConfig struct {
+	Default     NotionalCap            // Underlyings without their own cap
+	Underlyings map[string]NotionalCap // By base asset, e.g. BTC
+	// WarnFraction of a cap used raises an alert; entries are still allowed
+	WarnFraction float64
+	// EntryNotionalUSD is the size of a spread entry checked by the validator
+	EntryNotionalUSD float64
+	CheckInterval    time.Duration // How often open positions are checked against the caps
+}
+
+// DefaultNotionalLimitConfig leaves every underlying uncapped and alerts
+// at 80% of a cap once set
+func DefaultNotionalLimitConfig() NotionalLimitConfig {
+	return NotionalLimitConfig{
+		Underlyings:      make(map[string]NotionalCap),
+		WarnFraction:     0.8,
+		EntryNotionalUSD: 10000,
+		CheckInterval:    10 * time.Second,
+	}
+}
+
+// ParseNotionalCaps parses "BTC=5000000/1000000,ETH=2000000/500000" as
+// gross/net USD per underlying; either side may be left empty
+func ParseNotionalCaps(s string) (map[string]NotionalCap, error) {
+	caps := make(map[string]NotionalCap)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		underlying, limits, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("notional cap %q: want UNDERLYING=gross/net", entry)
+		}
+		gross, net, _ := strings.Cut(limits, "/")
+		var c NotionalCap
+		for _, f := range []struct {
+			s string
+			v *float64
+		}{{gross, &c.MaxGrossUSD}, {net, &c.MaxNetUSD}} {
+			if strings.TrimSpace(f.s) == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(f.s), 64)
+			if err != nil || v < 0 {
+				return nil, fmt.Errorf("notional cap %q: caps must be non-negative numbers", entry)
+			}
+			*f.v = v
+		}
+		caps[strings.ToUpper(strings.TrimSpace(underlying))] = c
+	}
+	return caps, nil
+}
+
+// NotionalUsage is an underlying's notional against its cap
+type NotionalUsage struct {
+	Underlying  string      `json:"underlying"`
+	Price       float64     `json:"price"`
+	GrossUSD    float64     `json:"gross_usd"`
+	NetUSD      float64     `json:"net_usd"` // Signed; negative is net short
+	Cap         NotionalCap `json:"cap"`
+	GrossUsed   float64     `json:"gross_used,omitempty"` // Fraction of the cap, zero when uncapped
+	NetUsed     float64     `json:"net_used,omitempty"`
+	Warning     []string    `json:"warning,omitempty"`  // Measures at or past the warn fraction
+	Breached    []string    `json:"breached,omitempty"` // Measures past their cap
+	Venues      int         `json:"venues"`
+	PriceSource string      `json:"price_source"` // index or entry
+}
+
+// NotionalCheck is the verdict on a proposed change of positions
+type NotionalCheck struct {
+	Before  NotionalUsage `json:"before"`
+	After   NotionalUsage `json:"after"`
+	Allowed bool          `json:"allowed"`
+	Reason  string        `json:"reason,omitempty"`
+}
+
+// NotionalLimits caps gross and net notional per underlying summed over
+// every venue, rejecting entries that would take an underlying past a cap.
+// Changes that shrink a measure already past its cap are allowed, so
+// positions can always be reduced.
+type NotionalLimits struct {
+	config    NotionalLimitConfig
+	positions *inventory.Store
+	prices    inventory.PriceSource
+
+	drawdown *DrawdownGuard // Optional; caps shrink while sizes are halved
+
+	mu     sync.Mutex
+	warned map[string]bool // underlying:measure currently alerted
+	done   chan struct{}
+}
+
+// NewNotionalLimits creates the limits over a position store, pricing
+// underlyings with prices
+func NewNotionalLimits(positions *inventory.Store, prices inventory.PriceSource, config NotionalLimitConfig) *NotionalLimits {
+	return &NotionalLimits{
+		config:    config,
+		positions: positions,
+		prices:    prices,
+		warned:    make(map[string]bool),
+		done:      make(chan struct{}),
+	}
+}
+
+// EntryNotionalUSD returns the size spread entries are checked at
+func (l *NotionalLimits) EntryNotionalUSD() float64 {
+	return l.config.EntryNotionalUSD
+}
+
+// SetDrawdownGuard scales the caps down while sizes are halved after a
+// drawdown
+func (l *NotionalLimits) SetDrawdownGuard(guard *DrawdownGuard) {
+	l.drawdown = guard
+}
+
+// capFor returns an underlying's cap in force
+func (l *NotionalLimits) capFor(underlying string) NotionalCap {
+	c, ok := l.config.Underlyings[underlying]
+	if !ok {
+		c = l.config.Default
+	}
+	if f := l.drawdown.SizeFactor(); f > 0 && f < 1 {
+		c.MaxGrossUSD *= f
+		c.MaxNetUSD *= f
+	}
+	return c
+}
+
+// CheckSpread checks opening quantity (base units) long on one venue and
+// short on another at price
+func (l *NotionalLimits) CheckSpread(canonical string, long, short connector.ExchangeID, quantity, price float64) NotionalCheck {
+	return l.Check(canonical, map[connector.ExchangeID]float64{long: quantity, short: -quantity}, price)
+}
+
+// Check checks adding deltas (base units per venue, negative for sells) to
+// the positions in canonical. price stands in if the underlying's index is
+// unknown. Checks don't alert; the After usage lists measures past the
+// warn fraction for the caller.
+func (l *NotionalLimits) Check(canonical string, deltas map[connector.ExchangeID]float64, price float64) NotionalCheck {
+	underlying := connector.ParseCanonical(canonical).Base
+	current := l.venuePositions(underlying)
+	next := make(map[connector.ExchangeID]float64, len(current)+len(deltas))
+	for id, qty := range current {
+		next[id] = qty
+	}
+	for id, qty := range deltas {
+		next[id] += qty
+	}
+
+	c := NotionalCheck{
+		Before: l.usage(underlying, current, price),
+		After:  l.usage(underlying, next, price),
+	}
+	limit := c.After.Cap
+	switch {
+	case limit.MaxGrossUSD > 0 && c.After.GrossUSD > limit.MaxGrossUSD && c.After.GrossUSD > c.Before.GrossUSD:
+		c.Reason = fmt.Sprintf("%s gross notional would reach $%.0f, cap $%.0f", underlying, c.After.GrossUSD, limit.MaxGrossUSD)
+		metrics.NotionalBlocks.WithLabelValues(underlying, NotionalGross).Inc()
+	case limit.MaxNetUSD > 0 && math.Abs(c.After.NetUSD) > limit.MaxNetUSD && math.Abs(c.After.NetUSD) > math.Abs(c.Before.NetUSD):
+		c.Reason = fmt.Sprintf("%s net notional would reach $%.0f, cap $%.0f", underlying, math.Abs(c.After.NetUSD), limit.MaxNetUSD)
+		metrics.NotionalBlocks.WithLabelValues(underlying, NotionalNet).Inc()
+	default:
+		c.Allowed = true
+	}
+	return c
+}
+
+// Usages returns every underlying with an open position, most of a cap
+// used first
+func (l *NotionalLimits) Usages() []NotionalUsage {
+	byUnderlying := make(map[string]map[connector.ExchangeID]float64)
+	for _, p := range l.positions.List() {
+		underlying := connector.ParseCanonical(p.Canonical).Base
+		if byUnderlying[underlying] == nil {
+			byUnderlying[underlying] = make(map[connector.ExchangeID]float64)
+		}
+		byUnderlying[underlying][p.Exchange] += p.Quantity
+	}
+
+	result := make([]NotionalUsage, 0, len(byUnderlying))
+	for underlying, venues := range byUnderlying {
+		result = append(result, l.usage(underlying, venues, 0))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ui := math.Max(result[i].GrossUsed, result[i].NetUsed)
+		uj := math.Max(result[j].GrossUsed, result[j].NetUsed)
+		if ui != uj {
+			return ui > uj
+		}
+		return result[i].Underlying < result[j].Underlying
+	})
+	return result
+}
+
+// venuePositions returns the positions in an underlying per venue, summed
+// over its contracts
+func (l *NotionalLimits) venuePositions(underlying string) map[connector.ExchangeID]float64 {
+	result := make(map[connector.ExchangeID]float64)
+	for _, p := range l.positions.List() {
+		if connector.ParseCanonical(p.Canonical).Base == underlying {
+			result[p.Exchange] += p.Quantity
+		}
+	}
+	return result
+}
+
+// usage prices positions per venue. The underlying's index is used when
+// known, fallback otherwise.
+func (l *NotionalLimits) usage(underlying string, venues map[connector.ExchangeID]float64, fallback float64) NotionalUsage {
+	u := NotionalUsage{Underlying: underlying, Cap: l.capFor(underlying), Price: fallback, PriceSource: "entry"}
+	if l.prices != nil {
+		if price, ok := l.prices(underlying); ok && price > 0 {
+			u.Price, u.PriceSource = price, "index"
+		}
+	}
+
+	var gross, net float64
+	for _, qty := range venues {
+		if qty == 0 {
+			continue
+		}
+		gross += math.Abs(qty)
+		net += qty
+		u.Venues++
+	}
+	u.GrossUSD = gross * u.Price
+	u.NetUSD = net * u.Price
+
+	if u.Cap.MaxGrossUSD > 0 {
+		u.GrossUsed = u.GrossUSD / u.Cap.MaxGrossUSD
+	}
+	if u.Cap.MaxNetUSD > 0 {
+		u.NetUsed = math.Abs(u.NetUSD) / u.Cap.MaxNetUSD
+	}
+	for _, m := range []struct {
+		measure string
+		used    float64
+	}{{NotionalGross, u.GrossUsed}, {NotionalNet, u.NetUsed}} {
+		if m.used > 1 {
+			u.Breached = append(u.Breached, m.measure)
+		}
+		if l.config.WarnFraction > 0 && m.used >= l.config.WarnFraction {
+			u.Warning = append(u.Warning, m.measure)
+		}
+	}
+	return u
+}
+
+// alert updates an underlying's utilization gauges and logs each measure
+// once when it crosses the warn fraction, and again once it falls back
+func (l *NotionalLimits) alert(u NotionalUsage) {
+	metrics.NotionalUtilization.WithLabelValues(u.Underlying, NotionalGross).Set(u.GrossUsed)
+	metrics.NotionalUtilization.WithLabelValues(u.Underlying, NotionalNet).Set(u.NetUsed)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, m := range []struct {
+		measure string
+		used    float64
+		limit   float64
+	}{{NotionalGross, u.GrossUsed, u.Cap.MaxGrossUSD}, {NotionalNet, u.NetUsed, u.Cap.MaxNetUSD}} {
+		key := u.Underlying + ":" + m.measure
+		over := l.config.WarnFraction > 0 && m.used >= l.config.WarnFraction
+		switch {
+		case over && !l.warned[key]:
+			l.warned[key] = true
+			metrics.NotionalAlerts.WithLabelValues(u.Underlying, m.measure).Inc()
+			log.Warn().
+				Str("underlying", u.Underlying).
+				Str("measure", m.measure).
+				Float64("used", m.used).
+				Float64("cap_usd", m.limit).
+				Msg("Notional exposure near cap")
+		case !over && l.warned[key]:
+			delete(l.warned, key)
+			log.Info().Str("underlying", u.Underlying).Str("measure", m.measure).Float64("used", m.used).Msg("Notional exposure back under warn level")
+		}
+	}
+}
+
+// Start checks open positions against the caps until the context is
+// cancelled or Stop is called, so fills that bypass Check still alert
+func (l *NotionalLimits) Start(ctx context.Context) {
+	if l.config.CheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(l.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.done:
+			return
+		case <-ticker.C:
+			for _, u := range l.Usages() {
+				l.alert(u)
+			}
+		}
+	}
+}
+
+// Stop stops the check loop
+func (l *NotionalLimits) Stop() {
+	close(l.done)
+}
@@ -0,0 +1,180 @@
+package execution
+
+import (
+	"fmt"
+	"sync"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// Where a liquidation check took the current price from
+const (
+	MarkFromVenue = "mark"  // Mark price the venue published with its funding rate
+	MarkFromBook  = "mid"   // Mid of the streamed book
+	MarkFromEntry = "entry" // Neither known; the entry price stands in
+)
+
+// LiquidationConfig controls the local liquidation guard
+type LiquidationConfig struct {
+	// MinDistancePct is how far from mark, in percent, an entry's estimated
+	// liquidation price must be for the entry to go ahead
+	MinDistancePct float64
+	Leverage       float64                          // Leverage legs are opened at
+	VenueLeverage  map[connector.ExchangeID]float64 // Per-venue overrides of Leverage
+	// Used for contracts whose venue doesn't list a maintenance rate or
+	// taker fee; set above typical base tiers so unknowns err on the safe side
+	FallbackMaintenanceRate float64
+	FallbackTakerFee        float64
+}
+
+// DefaultLiquidationConfig blocks entries at 3x whose liquidation price
+// would sit within 15% of mark
+func DefaultLiquidationConfig() LiquidationConfig {
+	return LiquidationConfig{
+		MinDistancePct:          15,
+		Leverage:                3,
+		FallbackMaintenanceRate: 0.01,
+		FallbackTakerFee:        0.0006,
+	}
+}
+
+// MarkSource returns venues' latest mark prices; *spread.SpreadDiscovery
+// implements it
+type MarkSource interface {
+	MarkPrice(canonical string, exchange connector.ExchangeID) (float64, bool)
+}
+
+// LiquidationCheck is the guard's verdict on one leg
+type LiquidationCheck struct {
+	Exchange         connector.ExchangeID `json:"exchange"`
+	Symbol           string               `json:"symbol"`
+	Side             string               `json:"side"`
+	EntryPrice       float64              `json:"entry_price"`
+	MarkPrice        float64              `json:"mark_price"`
+	MarkSource       string               `json:"mark_source"` // mark, mid or entry
+	Leverage         float64              `json:"leverage"`
+	MaintenanceRate  float64              `json:"maintenance_rate"`
+	LiquidationPrice float64              `json:"liquidation_price"` // Zero if the position cannot be liquidated
+	DistancePct      float64              `json:"distance_pct"`      // From mark to the liquidation price
+	Allowed          bool                 `json:"allowed"`
+	Reason           string               `json:"reason,omitempty"`
+}
+
+type instrumentKey struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+// LiquidationGuard estimates where a new leg would be liquidated from its
+// entry price, leverage and the contract's maintenance rate, and blocks
+// entries liquidated too close to the current mark. The estimate is made
+// locally and errs conservative, so it holds whatever the venue reports.
+type LiquidationGuard struct {
+	config LiquidationConfig
+	marks  MarkSource
+	cache  BookCache
+
+	mu          sync.RWMutex
+	instruments map[instrumentKey]connector.Instrument
+}
+
+// NewLiquidationGuard creates a guard reading marks from marks, or the
+// streamed books' mid for venues without one
+func NewLiquidationGuard(cache BookCache, marks MarkSource, config LiquidationConfig) *LiquidationGuard {
+	return &LiquidationGuard{
+		config:      config,
+		marks:       marks,
+		cache:       cache,
+		instruments: make(map[instrumentKey]connector.Instrument),
+	}
+}
+
+// SetInstruments records each contract's maintenance rate and taker fee
+func (g *LiquidationGuard) SetInstruments(instruments []connector.Instrument) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, inst := range instruments {
+		g.instruments[instrumentKey{inst.ExchangeID, inst.Symbol}] = inst
+	}
+}
+
+// LiquidationPrice estimates the liquidation price of an isolated linear
+// position opened at entry: where the loss has eaten the initial margin
+// down to the maintenance margin plus the fee to close. Zero means the
+// position cannot be liquidated (a long at 1x or less).
+func LiquidationPrice(side string, entry, leverage, maintenanceRate, feeRate float64) float64 {
+	if leverage <= 0 {
+		return 0
+	}
+	if side == SideSell {
+		return entry * (1 + 1/leverage) / (1 + maintenanceRate + feeRate)
+	}
+	denom := 1 - maintenanceRate - feeRate
+	if leverage <= 1 || denom <= 0 {
+		return 0
+	}
+	return entry * (1 - 1/leverage) / denom
+}
+
+// Check estimates the liquidation price of a leg opened at entry and
+// whether it clears the minimum distance from mark
+func (g *LiquidationGuard) Check(canonical string, exchange connector.ExchangeID, symbol, side string, entry float64) LiquidationCheck {
+	c := LiquidationCheck{
+		Exchange:   exchange,
+		Symbol:     symbol,
+		Side:       side,
+		EntryPrice: entry,
+		Leverage:   g.config.Leverage,
+	}
+	if lev, ok := g.config.VenueLeverage[exchange]; ok {
+		c.Leverage = lev
+	}
+
+	g.mu.RLock()
+	inst, ok := g.instruments[instrumentKey{exchange, symbol}]
+	g.mu.RUnlock()
+	c.MaintenanceRate = g.config.FallbackMaintenanceRate
+	fee := g.config.FallbackTakerFee
+	if ok && inst.MaintenanceRate > 0 {
+		c.MaintenanceRate = inst.MaintenanceRate
+	}
+	if ok && inst.TakerFee > 0 {
+		fee = inst.TakerFee
+	}
+
+	c.MarkPrice, c.MarkSource = g.mark(canonical, exchange, entry)
+	c.LiquidationPrice = LiquidationPrice(side, entry, c.Leverage, c.MaintenanceRate, fee)
+
+	switch {
+	case c.LiquidationPrice <= 0:
+		c.DistancePct = 100
+	case side == SideSell:
+		c.DistancePct = (c.LiquidationPrice - c.MarkPrice) / c.MarkPrice * 100
+	default:
+		c.DistancePct = (c.MarkPrice - c.LiquidationPrice) / c.MarkPrice * 100
+	}
+
+	c.Allowed = c.DistancePct >= g.config.MinDistancePct
+	if !c.Allowed {
+		c.Reason = fmt.Sprintf("%s %s on %s liquidates at %.6g, %.1f%% from mark %.6g, need %.1f%%",
+			side, symbol, exchange, c.LiquidationPrice, c.DistancePct, c.MarkPrice, g.config.MinDistancePct)
+		metrics.ExecutionLiquidationBlocks.WithLabelValues(string(exchange), side).Inc()
+	}
+	return c
+}
+
+// mark returns the price liquidation distance is measured from
+func (g *LiquidationGuard) mark(canonical string, exchange connector.ExchangeID, entry float64) (float64, string) {
+	if g.marks != nil {
+		if mark, ok := g.marks.MarkPrice(canonical, exchange); ok && mark > 0 {
+			return mark, MarkFromVenue
+		}
+	}
+	if g.cache != nil {
+		if ob := g.cache.Orderbook(canonical, exchange); ob != nil && len(ob.Bids) > 0 && len(ob.Asks) > 0 {
+			return (ob.Bids[0].Price + ob.Asks[0].Price) / 2, MarkFromBook
+		}
+	}
+	return entry, MarkFromEntry
+}
@@ -0,0 +1,323 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/inventory"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FundingSource returns the latest funding rate of a venue for a canonical
+// symbol; *spread.SpreadDiscovery implements it
+type FundingSource interface {
+	FundingRate(canonical string, exchange connector.ExchangeID) (float64, bool)
+}
+
+// MigrationRequest moves one leg of a hedged position from one venue to
+// another, e.g. the short leg of (A long, B short) from B to C
+type MigrationRequest struct {
+	Canonical string               `json:"canonical"`
+	From      connector.ExchangeID `json:"from"`
+	To        connector.ExchangeID `json:"to"`
+	Side      string               `json:"side"`     // Side of the leg being moved: buy for a long leg, sell for a short leg
+	Quantity  float64              `json:"quantity"` // Base units
+	FlagID    string               `json:"flag_id,omitempty"`
+}
+
+// RequestFromFlag returns the migration moving a fallback hedge back to the
+// venue it was intended for
+func RequestFromFlag(f MigrationFlag) MigrationRequest {
+	return MigrationRequest{
+		Canonical: f.Canonical,
+		From:      f.Actual,
+		To:        f.Intended,
+		Side:      f.Side,
+		Quantity:  f.Quantity,
+		FlagID:    f.ID,
+	}
+}
+
+// MigrateConfig controls position migration
+type MigrateConfig struct {
+	MaxSlippageBps float64       // Each leg's limit is at most this far through the touch
+	MaxQuoteAge    time.Duration // Plans are only built from quotes this fresh
+}
+
+// DefaultMigrateConfig allows 10 bps of slippage per leg
+func DefaultMigrateConfig() MigrateConfig {
+	return MigrateConfig{
+		MaxSlippageBps: 10,
+		MaxQuoteAge:    time.Second,
+	}
+}
+
+// MigrationPlan is the dry-run preview of a migration. Both legs are
+// limit IOC orders capped at the slippage guard.
+type MigrationPlan struct {
+	Request  MigrationRequest `json:"request"`
+	OpenLeg  Order            `json:"open_leg"`  // Opens the leg on the new venue, sent first
+	CloseLeg Order            `json:"close_leg"` // Closes the leg on the old venue
+
+	FromFunding float64 `json:"from_funding"`
+	ToFunding   float64 `json:"to_funding"`
+	// Funding paid (positive) or received (negative) holding the leg over
+	// the economics holding period on each venue
+	FromHoldBps float64 `json:"from_hold_bps"`
+	ToHoldBps   float64 `json:"to_hold_bps"`
+	SavingBps   float64 `json:"saving_bps"`
+
+	FeesBps     float64   `json:"fees_bps"`     // Taker fee on both legs
+	CrossingBps float64   `json:"crossing_bps"` // Price given up between the two touches
+	RollCostBps float64   `json:"roll_cost_bps"`
+	NetBps      float64   `json:"net_bps"` // saving - roll cost
+	Worthwhile  bool      `json:"worthwhile"`
+	At          time.Time `json:"at"`
+}
+
+// MigrationResult is the outcome of executing a plan
+type MigrationResult struct {
+	Plan     *MigrationPlan `json:"plan"`
+	Opened   Fill           `json:"opened"`
+	Closed   Fill           `json:"closed"`
+	Complete bool           `json:"complete"` // Both legs filled the whole quantity
+	Error    string         `json:"error,omitempty"`
+}
+
+// Migrator moves hedged legs between venues when another venue is cheaper
+// to hold, leg by leg with slippage guards
+type Migrator struct {
+	config     MigrateConfig
+	cache      BookCache
+	funding    FundingSource
+	economics  spread.EconomicsConfig
+	sender     OrderSender      // Nil allows previews only
+	inventory  *inventory.Store // Optional; adjusted with every fill
+	migrations *Migrations      // Optional; the source flag is cleared once migrated
+	status     StatusGate       // Optional; refuses legs the venue does not accept
+	modes      *Modes           // Optional; refuses legs on md-only venues
+}
+
+// NewMigrator creates a position migrator. Without a sender it can only
+// build previews.
+func NewMigrator(cache BookCache, funding FundingSource, economics spread.EconomicsConfig, sender OrderSender, config MigrateConfig) *Migrator {
+	return &Migrator{config: config, cache: cache, funding: funding, economics: economics, sender: sender}
+}
+
+// SetInventory sets the position store adjusted with migration fills
+func (m *Migrator) SetInventory(store *inventory.Store) {
+	m.inventory = store
+}
+
+// SetMigrations sets the flag store cleared when a flagged position is migrated
+func (m *Migrator) SetMigrations(migrations *Migrations) {
+	m.migrations = migrations
+}
+
+// SetStatusGate refuses migrations opening on a symbol that cannot open
+// positions or closing on one that accepts no orders
+func (m *Migrator) SetStatusGate(gate StatusGate) {
+	m.status = gate
+}
+
+// SetModes refuses migrations with either leg on a venue in md-only mode
+func (m *Migrator) SetModes(modes *Modes) {
+	m.modes = modes
+}
+
+// Plan builds a dry-run preview from current quotes and funding rates
+func (m *Migrator) Plan(req MigrationRequest) (*MigrationPlan, error) {
+	if req.Side != SideBuy && req.Side != SideSell {
+		return nil, fmt.Errorf("side must be buy or sell")
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive")
+	}
+	if req.From == req.To {
+		return nil, fmt.Errorf("from and to are the same venue")
+	}
+	if connector.GetCapabilities(req.To).QuoteOnly() {
+		return nil, fmt.Errorf("%s has no trading client", req.To)
+	}
+	for _, id := range []connector.ExchangeID{req.To, req.From} {
+		if err := checkTrade(m.modes, id); err != nil {
+			return nil, err
+		}
+	}
+
+	// Opening repeats the leg's side on the new venue; closing reverses it
+	closeSide := SideSell
+	if req.Side == SideSell {
+		closeSide = SideBuy
+	}
+	openOb, openTouch, err := m.quote(req.Canonical, req.To, req.Side)
+	if err != nil {
+		return nil, err
+	}
+	closeOb, closeTouch, err := m.quote(req.Canonical, req.From, closeSide)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOpen(m.status, req.To, openOb.Symbol); err != nil {
+		return nil, err
+	}
+	if err := checkClose(m.status, req.From, closeOb.Symbol); err != nil {
+		return nil, err
+	}
+
+	plan := &MigrationPlan{
+		Request: req,
+		OpenLeg: Order{
+			Exchange: req.To,
+			Symbol:   openOb.Symbol,
+			Side:     req.Side,
+			Price:    crossPrice(openTouch, m.config.MaxSlippageBps, req.Side),
+			Quantity: req.Quantity,
+		},
+		CloseLeg: Order{
+			Exchange: req.From,
+			Symbol:   closeOb.Symbol,
+			Side:     closeSide,
+			Price:    crossPrice(closeTouch, m.config.MaxSlippageBps, closeSide),
+			Quantity: req.Quantity,
+		},
+		At: time.Now(),
+	}
+
+	plan.FromFunding, _ = m.funding.FundingRate(req.Canonical, req.From)
+	plan.ToFunding, _ = m.funding.FundingRate(req.Canonical, req.To)
+	plan.FromHoldBps = m.holdBps(req.Side, plan.FromFunding)
+	plan.ToHoldBps = m.holdBps(req.Side, plan.ToFunding)
+	plan.SavingBps = plan.FromHoldBps - plan.ToHoldBps
+
+	// Moving a short: buy back at the old venue's ask, sell at the new
+	// venue's bid. Moving a long is the mirror image.
+	mid := (openTouch + closeTouch) / 2
+	plan.CrossingBps = (closeTouch - openTouch) / mid * 10000
+	if req.Side == SideBuy {
+		plan.CrossingBps = -plan.CrossingBps
+	}
+	plan.FeesBps = m.economics.TakerFee(req.From) + m.economics.TakerFee(req.To)
+	plan.RollCostBps = plan.FeesBps + plan.CrossingBps
+	plan.NetBps = plan.SavingBps - plan.RollCostBps
+	plan.Worthwhile = plan.NetBps > 0
+	return plan, nil
+}
+
+// Execute migrates a leg: it opens on the new venue first, so a failure
+// leaves the position over-hedged rather than naked, then closes only what
+// was opened on the old venue. Each leg is a single IOC capped at the
+// slippage guard. Plans that are not worthwhile are refused unless forced.
+func (m *Migrator) Execute(ctx context.Context, req MigrationRequest, force bool) (*MigrationResult, error) {
+	if m.sender == nil {
+		return nil, fmt.Errorf("migrator has no order sender")
+	}
+	plan, err := m.Plan(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &MigrationResult{Plan: plan}
+	if !plan.Worthwhile && !force {
+		result.Error = fmt.Sprintf("not worthwhile: net %.2f bps", plan.NetBps)
+		return result, nil
+	}
+
+	result.Opened, err = m.sender.SendIOC(ctx, plan.OpenLeg)
+	if err != nil {
+		metrics.PositionMigrations.WithLabelValues(string(req.From), string(req.To), "error").Inc()
+		result.Error = "open leg: " + err.Error()
+		return result, nil
+	}
+	m.adjust(ctx, plan.OpenLeg, result.Opened)
+	if result.Opened.Quantity <= 0 {
+		metrics.PositionMigrations.WithLabelValues(string(req.From), string(req.To), "missed").Inc()
+		result.Error = "open leg did not fill within the slippage guard"
+		return result, nil
+	}
+
+	closeLeg := plan.CloseLeg
+	closeLeg.Quantity = result.Opened.Quantity
+	result.Closed, err = m.sender.SendIOC(ctx, closeLeg)
+	if err != nil {
+		result.Error = "close leg: " + err.Error()
+	}
+	m.adjust(ctx, closeLeg, result.Closed)
+
+	result.Complete = err == nil &&
+		result.Opened.Quantity >= req.Quantity*(1-1e-9) &&
+		result.Closed.Quantity >= result.Opened.Quantity*(1-1e-9)
+	outcome := "partial"
+	if result.Complete {
+		outcome = "complete"
+		if req.FlagID != "" && m.migrations != nil {
+			if _, err := m.migrations.Clear(ctx, req.FlagID); err != nil {
+				log.Error().Err(err).Str("flag", req.FlagID).Msg("Failed to clear migration flag")
+			}
+		}
+	}
+	metrics.PositionMigrations.WithLabelValues(string(req.From), string(req.To), outcome).Inc()
+
+	log.Info().
+		Str("canonical", req.Canonical).
+		Str("from", string(req.From)).
+		Str("to", string(req.To)).
+		Float64("opened", result.Opened.Quantity).
+		Float64("closed", result.Closed.Quantity).
+		Float64("net_bps", plan.NetBps).
+		Bool("complete", result.Complete).
+		Msg("Position migration executed")
+	return result, nil
+}
+
+// holdBps returns the funding a leg pays over the holding period, in bps;
+// longs pay positive rates and shorts receive them
+func (m *Migrator) holdBps(side string, rate float64) float64 {
+	if m.economics.FundingInterval <= 0 {
+		return 0
+	}
+	intervals := float64(m.economics.HoldingPeriod) / float64(m.economics.FundingInterval)
+	bps := rate * intervals * 10000
+	if side == SideSell {
+		return -bps
+	}
+	return bps
+}
+
+// quote returns a venue's book and the touch a side executes against
+func (m *Migrator) quote(canonical string, exchange connector.ExchangeID, side string) (*connector.Orderbook, float64, error) {
+	ob := m.cache.Orderbook(canonical, exchange)
+	if ob == nil {
+		return nil, 0, fmt.Errorf("no book for %s on %s", canonical, exchange)
+	}
+	if ob.ReceivedAt.IsZero() || time.Since(ob.ReceivedAt) > m.config.MaxQuoteAge {
+		return nil, 0, fmt.Errorf("quote for %s on %s is stale", canonical, exchange)
+	}
+	levels := ob.Asks
+	if side == SideSell {
+		levels = ob.Bids
+	}
+	if len(levels) == 0 || levels[0].Price <= 0 {
+		return nil, 0, fmt.Errorf("empty %s book for %s on %s", side, canonical, exchange)
+	}
+	return ob, levels[0].Price, nil
+}
+
+// adjust records a fill in the position store
+func (m *Migrator) adjust(ctx context.Context, order Order, fill Fill) {
+	if m.inventory == nil || fill.Quantity <= 0 {
+		return
+	}
+	delta := fill.Quantity
+	if order.Side == SideSell {
+		delta = -delta
+	}
+	canonical := connector.ParsePair(order.Symbol).Canonical()
+	if _, err := m.inventory.Adjust(ctx, order.Exchange, canonical, delta); err != nil {
+		log.Error().Err(err).Str("exchange", string(order.Exchange)).Msg("Failed to record migration fill")
+	}
+}
@@ -0,0 +1,127 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Exchange modes
+const (
+	ModeTrade  = "trade"   // Quotes and orders
+	ModeMDOnly = "md-only" // Quotes only; the execution path refuses every order
+)
+
+// ErrMarketDataOnly is returned for orders to a venue in md-only mode
+var ErrMarketDataOnly = errors.New("exchange is in md-only mode")
+
+// ExchangeMode is one venue's mode
+type ExchangeMode struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Mode     string               `json:"mode"`
+}
+
+// Modes holds whether each exchange may trade. A venue can be onboarded
+// for quotes and kept out of execution whether or not credentials for it
+// exist. Venues not listed trade. A nil *Modes allows everything.
+type Modes struct {
+	mu    sync.RWMutex
+	modes map[connector.ExchangeID]string
+}
+
+// NewModes creates the mode table from per-exchange settings
+func NewModes(modes map[connector.ExchangeID]string) *Modes {
+	m := &Modes{modes: make(map[connector.ExchangeID]string, len(modes))}
+	for id, mode := range modes {
+		m.modes[id] = mode
+	}
+	return m
+}
+
+// ParseModes parses "lbank=md-only,htx=md-only"
+func ParseModes(s string) (map[connector.ExchangeID]string, error) {
+	modes := make(map[connector.ExchangeID]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ex, mode, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("exchange mode %q: want exchange=mode", entry)
+		}
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		if !validMode(mode) {
+			return nil, fmt.Errorf("exchange mode %q: mode must be %s or %s", entry, ModeMDOnly, ModeTrade)
+		}
+		modes[connector.ExchangeID(strings.ToLower(strings.TrimSpace(ex)))] = mode
+	}
+	return modes, nil
+}
+
+func validMode(mode string) bool {
+	return mode == ModeTrade || mode == ModeMDOnly
+}
+
+// Mode returns an exchange's mode
+func (m *Modes) Mode(exchange connector.ExchangeID) string {
+	if m == nil {
+		return ModeTrade
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if mode, ok := m.modes[exchange]; ok {
+		return mode
+	}
+	return ModeTrade
+}
+
+// CanTrade reports whether orders may be sent to an exchange
+func (m *Modes) CanTrade(exchange connector.ExchangeID) bool {
+	return m.Mode(exchange) == ModeTrade
+}
+
+// Set changes an exchange's mode until restart
+func (m *Modes) Set(exchange connector.ExchangeID, mode string) error {
+	if !validMode(mode) {
+		return fmt.Errorf("mode must be %s or %s", ModeMDOnly, ModeTrade)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.modes[exchange] = mode
+	return nil
+}
+
+// List returns the mode of every known exchange, sorted by ID
+func (m *Modes) List() []ExchangeMode {
+	ids := connector.Exchanges()
+	result := make([]ExchangeMode, 0, len(ids))
+	seen := make(map[connector.ExchangeID]bool, len(ids))
+	for _, id := range ids {
+		result = append(result, ExchangeMode{Exchange: id, Mode: m.Mode(id)})
+		seen[id] = true
+	}
+	if m != nil {
+		m.mu.RLock()
+		for id, mode := range m.modes {
+			if !seen[id] {
+				result = append(result, ExchangeMode{Exchange: id, Mode: mode})
+			}
+		}
+		m.mu.RUnlock()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Exchange < result[j].Exchange })
+	return result
+}
+
+// checkTrade returns an error if orders may not be sent to an exchange
+func checkTrade(modes *Modes, exchange connector.ExchangeID) error {
+	if modes.CanTrade(exchange) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", exchange, ErrMarketDataOnly)
+}
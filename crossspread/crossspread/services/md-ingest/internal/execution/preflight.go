@@ -0,0 +1,349 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Preflight errors
+var (
+	ErrPreflightPending = errors.New("account preflight has not completed")
+	ErrPreflightFailed  = errors.New("account preflight failed")
+)
+
+// AccountExpectation is the account setup a venue must have before going
+// live. Empty modes and zero leverage are not checked.
+type AccountExpectation struct {
+	PositionMode string  `json:"position_mode,omitempty"` // hedge or one_way
+	MarginMode   string  `json:"margin_mode,omitempty"`   // cross or isolated
+	Leverage     float64 `json:"leverage,omitempty"`
+}
+
+// PreflightConfig controls the account sanity checks run before trading
+type PreflightConfig struct {
+	Default AccountExpectation
+	// Venues override the default field by field
+	Venues   map[connector.ExchangeID]AccountExpectation
+	Interval time.Duration // Between full checks; a trading session is a day
+	Timeout  time.Duration // Per venue symbol
+}
+
+// DefaultPreflightConfig expects hedge mode on cross margin and rechecks
+// daily. Leverage is left to the caller, which knows what it sizes for.
+func DefaultPreflightConfig() PreflightConfig {
+	return PreflightConfig{
+		Default: AccountExpectation{
+			PositionMode: connector.PositionModeHedge,
+			MarginMode:   connector.MarginModeCross,
+		},
+		Venues:   make(map[connector.ExchangeID]AccountExpectation),
+		Interval: 24 * time.Hour,
+		Timeout:  10 * time.Second,
+	}
+}
+
+// ParseAccountExpectations parses "okx=one_way/isolated/5,bybit=//10" as
+// position mode, margin mode and leverage; empty parts are not checked
+func ParseAccountExpectations(s string) (map[connector.ExchangeID]AccountExpectation, error) {
+	venues := make(map[connector.ExchangeID]AccountExpectation)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ex, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("account expectation %q: want exchange=position/margin/leverage", entry)
+		}
+		parts := strings.Split(spec, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("account expectation %q: want exchange=position/margin/leverage", entry)
+		}
+		var e AccountExpectation
+		switch mode := strings.ToLower(strings.TrimSpace(parts[0])); mode {
+		case "", connector.PositionModeHedge, connector.PositionModeOneWay:
+			e.PositionMode = mode
+		default:
+			return nil, fmt.Errorf("account expectation %q: position mode must be %s or %s", entry, connector.PositionModeHedge, connector.PositionModeOneWay)
+		}
+		switch mode := strings.ToLower(strings.TrimSpace(parts[1])); mode {
+		case "", connector.MarginModeCross, connector.MarginModeIsolated:
+			e.MarginMode = mode
+		default:
+			return nil, fmt.Errorf("account expectation %q: margin mode must be %s or %s", entry, connector.MarginModeCross, connector.MarginModeIsolated)
+		}
+		if lev := strings.TrimSpace(parts[2]); lev != "" {
+			v, err := strconv.ParseFloat(lev, 64)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("account expectation %q: leverage must be a positive number", entry)
+			}
+			e.Leverage = v
+		}
+		venues[connector.ExchangeID(strings.ToLower(strings.TrimSpace(ex)))] = e
+	}
+	return venues, nil
+}
+
+// Mismatch is one account setting that differs from config
+type Mismatch struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Symbol   string               `json:"symbol"`
+	Field    string               `json:"field"` // position_mode, margin_mode, long_leverage, short_leverage
+	Expected string               `json:"expected"`
+	Actual   string               `json:"actual"`
+}
+
+// PreflightError is a venue symbol whose setup could not be read
+type PreflightError struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Symbol   string               `json:"symbol"`
+	Error    string               `json:"error"`
+}
+
+// PreflightReport is the outcome of one check of every venue symbol
+type PreflightReport struct {
+	Live       bool                   `json:"live"` // No mismatches and nothing unreadable
+	Checked    int                    `json:"checked"`
+	Unchecked  []connector.ExchangeID `json:"unchecked,omitempty"` // Venues without an account config reader
+	Mismatches []Mismatch             `json:"mismatches"`
+	Errors     []PreflightError       `json:"errors"`
+	CheckedAt  time.Time              `json:"checked_at"`
+	ElapsedMs  float64                `json:"elapsed_ms"`
+}
+
+// Diff renders the mismatches and read errors one per line
+func (r *PreflightReport) Diff() string {
+	var b strings.Builder
+	for _, m := range r.Mismatches {
+		fmt.Fprintf(&b, "%s %s %s: want %s, have %s\n", m.Exchange, m.Symbol, m.Field, m.Expected, m.Actual)
+	}
+	for _, e := range r.Errors {
+		fmt.Fprintf(&b, "%s %s: %s\n", e.Exchange, e.Symbol, e.Error)
+	}
+	return b.String()
+}
+
+// Preflight verifies position mode, margin mode and leverage on every venue
+// symbol that may be traded before the session goes live, and again every
+// Interval. Until a check passes, CheckLive refuses entries, so a venue left
+// in one-way mode or at the wrong leverage never gets a leg.
+type Preflight struct {
+	config  PreflightConfig
+	modes   *Modes // Optional; md-only venues are not checked
+	symbols func() map[connector.ExchangeID][]string
+
+	mu       sync.RWMutex
+	fetchers map[connector.ExchangeID]connector.AccountConfigFetcher
+	report   *PreflightReport
+}
+
+// NewPreflight creates the checker. Connectors implementing
+// connector.AccountConfigFetcher are read directly; executors add their own
+// trading clients with SetFetcher.
+func NewPreflight(connectors []connector.Connector, config PreflightConfig) *Preflight {
+	p := &Preflight{
+		config:   config,
+		fetchers: make(map[connector.ExchangeID]connector.AccountConfigFetcher),
+	}
+	for _, conn := range connectors {
+		if f, ok := conn.(connector.AccountConfigFetcher); ok {
+			p.fetchers[conn.ID()] = f
+		}
+	}
+	return p
+}
+
+// SetFetcher reads a venue's account setup through f
+func (p *Preflight) SetFetcher(exchange connector.ExchangeID, f connector.AccountConfigFetcher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetchers[exchange] = f
+}
+
+// SetModes skips venues in md-only mode
+func (p *Preflight) SetModes(modes *Modes) {
+	p.modes = modes
+}
+
+// SetSymbolSource sets the symbols per exchange that may be traded
+func (p *Preflight) SetSymbolSource(fn func() map[connector.ExchangeID][]string) {
+	p.symbols = fn
+}
+
+// expected returns the setup a venue must have
+func (p *Preflight) expected(exchange connector.ExchangeID) AccountExpectation {
+	e := p.config.Default
+	if v, ok := p.config.Venues[exchange]; ok {
+		if v.PositionMode != "" {
+			e.PositionMode = v.PositionMode
+		}
+		if v.MarginMode != "" {
+			e.MarginMode = v.MarginMode
+		}
+		if v.Leverage > 0 {
+			e.Leverage = v.Leverage
+		}
+	}
+	return e
+}
+
+// Check reads the setup of every venue symbol and compares it with config
+func (p *Preflight) Check(ctx context.Context, symbols map[connector.ExchangeID][]string) *PreflightReport {
+	start := time.Now()
+	report := &PreflightReport{Mismatches: []Mismatch{}, Errors: []PreflightError{}}
+
+	exchanges := make([]connector.ExchangeID, 0, len(symbols))
+	for id := range symbols {
+		exchanges = append(exchanges, id)
+	}
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i] < exchanges[j] })
+
+	p.mu.RLock()
+	fetchers := make(map[connector.ExchangeID]connector.AccountConfigFetcher, len(p.fetchers))
+	for id, f := range p.fetchers {
+		fetchers[id] = f
+	}
+	p.mu.RUnlock()
+
+	for _, exchange := range exchanges {
+		if checkTrade(p.modes, exchange) != nil {
+			continue
+		}
+		fetcher, ok := fetchers[exchange]
+		if !ok {
+			report.Unchecked = append(report.Unchecked, exchange)
+			continue
+		}
+		want := p.expected(exchange)
+		syms := append([]string(nil), symbols[exchange]...)
+		sort.Strings(syms)
+
+		mismatches := 0
+		for _, symbol := range syms {
+			fctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+			have, err := fetcher.FetchAccountConfig(fctx, symbol)
+			cancel()
+			report.Checked++
+			if err != nil {
+				report.Errors = append(report.Errors, PreflightError{Exchange: exchange, Symbol: symbol, Error: err.Error()})
+				continue
+			}
+			found := compareAccount(exchange, symbol, want, have)
+			mismatches += len(found)
+			report.Mismatches = append(report.Mismatches, found...)
+		}
+		metrics.PreflightMismatches.WithLabelValues(string(exchange)).Set(float64(mismatches))
+	}
+
+	report.Live = len(report.Mismatches) == 0 && len(report.Errors) == 0
+	report.CheckedAt = time.Now()
+	report.ElapsedMs = float64(report.CheckedAt.Sub(start)) / float64(time.Millisecond)
+
+	if report.Live {
+		metrics.PreflightLive.Set(1)
+		log.Info().
+			Int("checked", report.Checked).
+			Int("unchecked_venues", len(report.Unchecked)).
+			Msg("Account preflight passed")
+	} else {
+		metrics.PreflightLive.Set(0)
+		log.Error().
+			Int("checked", report.Checked).
+			Int("mismatches", len(report.Mismatches)).
+			Int("errors", len(report.Errors)).
+			Str("diff", report.Diff()).
+			Msg("Account preflight failed, refusing to go live")
+	}
+
+	p.mu.Lock()
+	p.report = report
+	p.mu.Unlock()
+	return report
+}
+
+// compareAccount returns the settings of have that differ from want.
+// Settings the venue did not report are not compared.
+func compareAccount(exchange connector.ExchangeID, symbol string, want AccountExpectation, have *connector.AccountConfig) []Mismatch {
+	var out []Mismatch
+	add := func(field, expected, actual string) {
+		out = append(out, Mismatch{Exchange: exchange, Symbol: symbol, Field: field, Expected: expected, Actual: actual})
+	}
+	if want.PositionMode != "" && have.PositionMode != "" && have.PositionMode != want.PositionMode {
+		add("position_mode", want.PositionMode, have.PositionMode)
+	}
+	if want.MarginMode != "" && have.MarginMode != "" && have.MarginMode != want.MarginMode {
+		add("margin_mode", want.MarginMode, have.MarginMode)
+	}
+	if want.Leverage > 0 {
+		expected := strconv.FormatFloat(want.Leverage, 'f', -1, 64)
+		if have.LongLeverage > 0 && have.LongLeverage != want.Leverage {
+			add("long_leverage", expected, strconv.FormatFloat(have.LongLeverage, 'f', -1, 64))
+		}
+		if have.ShortLeverage > 0 && have.ShortLeverage != want.Leverage {
+			add("short_leverage", expected, strconv.FormatFloat(have.ShortLeverage, 'f', -1, 64))
+		}
+	}
+	return out
+}
+
+// Report returns the last check, or nil before the first one
+func (p *Preflight) Report() *PreflightReport {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.report
+}
+
+// CheckLive returns an error unless the last check passed. A nil
+// *Preflight allows everything.
+func (p *Preflight) CheckLive() error {
+	if p == nil {
+		return nil
+	}
+	report := p.Report()
+	if report == nil {
+		return ErrPreflightPending
+	}
+	if !report.Live {
+		return fmt.Errorf("%w: %d mismatches, %d unreadable", ErrPreflightFailed, len(report.Mismatches), len(report.Errors))
+	}
+	return nil
+}
+
+// Recheck checks the symbols of the source now
+func (p *Preflight) Recheck(ctx context.Context) *PreflightReport {
+	return p.Check(ctx, p.currentSymbols())
+}
+
+// Start checks at once and then every Interval until ctx is done
+func (p *Preflight) Start(ctx context.Context) {
+	p.Recheck(ctx)
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Recheck(ctx)
+		}
+	}
+}
+
+func (p *Preflight) currentSymbols() map[connector.ExchangeID][]string {
+	if p.symbols == nil {
+		return nil
+	}
+	return p.symbols()
+}
@@ -0,0 +1,477 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/inventory"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RouterConfig controls how single-leg orders are split across venues
+type RouterConfig struct {
+	MaxQuoteAge time.Duration // Venues with older books are not routed to
+	MinChildUSD float64       // Smaller slices of a venue are dropped and routed elsewhere
+}
+
+// DefaultRouterConfig returns a one second freshness bound and $10 slices
+func DefaultRouterConfig() RouterConfig {
+	return RouterConfig{
+		MaxQuoteAge: time.Second,
+		MinChildUSD: 10,
+	}
+}
+
+// Balances reports the USD an account can still commit on each venue.
+// Venues it reports nothing for are not capped.
+type Balances interface {
+	Available(exchange connector.ExchangeID) (float64, bool)
+}
+
+// StaticBalances is a fixed per-venue USD cap
+type StaticBalances map[connector.ExchangeID]float64
+
+// Available returns a venue's cap
+func (b StaticBalances) Available(exchange connector.ExchangeID) (float64, bool) {
+	v, ok := b[exchange]
+	return v, ok
+}
+
+// ParseBalances parses "binance=50000,okx=20000" in USD
+func ParseBalances(s string) (StaticBalances, error) {
+	balances := make(StaticBalances)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ex, usd, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("balance %q: want exchange=usd", entry)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(usd), 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("balance %q: usd must be a non-negative number", entry)
+		}
+		balances[connector.ExchangeID(strings.ToLower(strings.TrimSpace(ex)))] = v
+	}
+	return balances, nil
+}
+
+// RouteChild is the slice of a routed order sent to one venue, as an IOC
+// limited at the deepest level it was planned to take
+type RouteChild struct {
+	Order
+	AvgPrice    float64 `json:"avg_price"` // Planned, walking the venue's book
+	NotionalUSD float64 `json:"notional_usd"`
+	TakerFeeBps float64 `json:"taker_fee_bps"`
+	Capped      bool    `json:"capped"` // The venue's balance ran out before its book did
+}
+
+// RoutePlan splits an order across venues, cheapest liquidity after fees
+// first. Prices are compared against the best touch across the venues
+// routed to, the price of the order if all of it filled there.
+type RoutePlan struct {
+	Canonical string       `json:"canonical"`
+	Side      string       `json:"side"`
+	Requested float64      `json:"requested"`
+	Routed    float64      `json:"routed"`
+	Unrouted  float64      `json:"unrouted"` // More than the venues' books and balances hold
+	Children  []RouteChild `json:"children"` // Largest first
+
+	BestTouch      float64 `json:"best_touch"` // Theoretical best price
+	AvgPrice       float64 `json:"avg_price"`
+	EffectivePrice float64 `json:"effective_price"` // Including taker fees
+	SlippageBps    float64 `json:"slippage_bps"`    // avg_price vs best_touch
+	CostBps        float64 `json:"cost_bps"`        // effective_price vs best_touch
+
+	// Best venue that could fill all of it alone, and what splitting saves over it
+	SingleVenue      connector.ExchangeID `json:"single_venue,omitempty"`
+	SingleVenuePrice float64              `json:"single_venue_price,omitempty"` // Including its taker fee
+	SavingBps        float64              `json:"saving_bps,omitempty"`
+
+	// Underlying's notional with the plan filled, if limits are set
+	Notional *NotionalCheck `json:"notional,omitempty"`
+	At       time.Time      `json:"at"`
+}
+
+// RouteFill is what one child order executed
+type RouteFill struct {
+	Order Order  `json:"order"`
+	Fill  Fill   `json:"fill"`
+	Error string `json:"error,omitempty"`
+}
+
+// RouteResult is the outcome of executing a plan, achieved vs planned and
+// vs the theoretical best price
+type RouteResult struct {
+	Plan           *RoutePlan  `json:"plan"`
+	Fills          []RouteFill `json:"fills"`
+	Filled         float64     `json:"filled"`
+	AvgPrice       float64     `json:"avg_price"`
+	EffectivePrice float64     `json:"effective_price"`
+	VsBestBps      float64     `json:"vs_best_bps"` // effective_price vs the plan's best touch
+	VsPlanBps      float64     `json:"vs_plan_bps"` // effective_price vs the plan's effective price
+	Complete       bool        `json:"complete"`
+	Error          string      `json:"error,omitempty"`
+}
+
+// Router is a smart order router for single-leg orders: it splits an order
+// across the venues quoting a canonical symbol from their live books, the
+// account's taker fees and what each venue's balance allows
+type Router struct {
+	config    RouterConfig
+	cache     BookCache
+	economics spread.EconomicsConfig
+	sender    OrderSender      // Nil allows plans only
+	balances  Balances         // Optional; venues are uncapped without it
+	inventory *inventory.Store // Optional; adjusted with every fill
+	status    StatusGate       // Optional; venues that cannot open are not routed to
+	modes     *Modes           // Optional; md-only venues are not routed to
+	limits    *NotionalLimits  // Optional; plans past the underlying's notional cap are not sent
+	drawdown  *DrawdownGuard   // Optional; nothing is sent while entries are halted
+}
+
+// NewRouter creates an order router. Without a sender it can only plan.
+func NewRouter(cache BookCache, economics spread.EconomicsConfig, sender OrderSender, config RouterConfig) *Router {
+	return &Router{config: config, cache: cache, economics: economics, sender: sender}
+}
+
+// SetBalances caps what is routed to each venue
+func (r *Router) SetBalances(balances Balances) {
+	r.balances = balances
+}
+
+// SetInventory sets the position store adjusted with routed fills
+func (r *Router) SetInventory(store *inventory.Store) {
+	r.inventory = store
+}
+
+// SetStatusGate skips venues whose symbol cannot open positions
+func (r *Router) SetStatusGate(gate StatusGate) {
+	r.status = gate
+}
+
+// SetModes skips venues in md-only mode
+func (r *Router) SetModes(modes *Modes) {
+	r.modes = modes
+}
+
+// SetNotionalLimits refuses to send plans that would take the underlying
+// past its gross or net notional cap across venues
+func (r *Router) SetNotionalLimits(limits *NotionalLimits) {
+	r.limits = limits
+}
+
+// SetDrawdownGuard refuses to send plans while entries are halted after a
+// drawdown
+func (r *Router) SetDrawdownGuard(guard *DrawdownGuard) {
+	r.drawdown = guard
+}
+
+// routeLevel is a price level of one venue, priced with its taker fee
+type routeLevel struct {
+	venue     int
+	price     float64
+	quantity  float64
+	effective float64
+}
+
+type routeVenue struct {
+	ob     *connector.Orderbook
+	fee    float64
+	capUSD float64
+	child  RouteChild
+}
+
+// Plan splits quantity (base units) across the venues quoting a canonical
+// symbol, taking the cheapest level after fees across all of them until
+// the order is filled or the books and balances run out
+func (r *Router) Plan(canonical, side string, quantity float64) (*RoutePlan, error) {
+	if side != SideBuy && side != SideSell {
+		return nil, fmt.Errorf("side must be %s or %s", SideBuy, SideSell)
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive")
+	}
+
+	now := time.Now()
+	var venues []*routeVenue
+	var levels []routeLevel
+	for id, ob := range r.cache.Orderbooks(canonical) {
+		if connector.GetCapabilities(id).QuoteOnly() || !r.modes.CanTrade(id) {
+			continue
+		}
+		if ob.ReceivedAt.IsZero() || now.Sub(ob.ReceivedAt) > r.config.MaxQuoteAge {
+			continue
+		}
+		if checkOpen(r.status, id, ob.Symbol) != nil {
+			continue
+		}
+		book := ob.Asks
+		if side == SideSell {
+			book = ob.Bids
+		}
+		if len(book) == 0 || book[0].Price <= 0 {
+			continue
+		}
+		v := &routeVenue{ob: ob, fee: r.economics.TakerFee(id), capUSD: math.Inf(1)}
+		if r.balances != nil {
+			if usd, ok := r.balances.Available(id); ok {
+				v.capUSD = usd
+			}
+		}
+		if v.capUSD <= 0 {
+			continue
+		}
+		for _, l := range book {
+			if l.Price <= 0 || l.Quantity <= 0 {
+				continue
+			}
+			levels = append(levels, routeLevel{
+				venue:     len(venues),
+				price:     l.Price,
+				quantity:  l.Quantity,
+				effective: withFee(l.Price, v.fee, side),
+			})
+		}
+		venues = append(venues, v)
+	}
+	if len(venues) == 0 {
+		return nil, fmt.Errorf("no venue can take a %s of %s", side, canonical)
+	}
+
+	// Cheapest liquidity after fees first; the book order of a venue is kept
+	sort.SliceStable(levels, func(i, j int) bool {
+		a, b := levels[i], levels[j]
+		if a.effective != b.effective {
+			if side == SideSell {
+				return a.effective > b.effective
+			}
+			return a.effective < b.effective
+		}
+		return venues[a.venue].ob.ExchangeID < venues[b.venue].ob.ExchangeID
+	})
+
+	plan := &RoutePlan{Canonical: canonical, Side: side, Requested: quantity, At: now}
+	remaining := quantity
+	for _, l := range levels {
+		if remaining <= 0 {
+			break
+		}
+		v := venues[l.venue]
+		take := math.Min(l.quantity, remaining)
+		if spend := v.capUSD - v.child.NotionalUSD; take*l.price > spend {
+			take = spend / l.price
+			v.child.Capped = true
+		}
+		if take <= 0 {
+			continue
+		}
+		v.child.Quantity += take
+		v.child.NotionalUSD += take * l.price
+		v.child.Price = l.price // Deepest level taken on the venue
+		remaining -= take
+	}
+
+	// Slices under MinChildUSD are not worth an order and stay unrouted
+	var notional, effective float64
+	for _, v := range venues {
+		c := v.child
+		if c.Quantity <= 0 || c.NotionalUSD < r.config.MinChildUSD {
+			continue
+		}
+		c.Exchange = v.ob.ExchangeID
+		c.Symbol = v.ob.Symbol
+		c.Side = side
+		c.AvgPrice = c.NotionalUSD / c.Quantity
+		c.TakerFeeBps = v.fee
+		plan.Children = append(plan.Children, c)
+		plan.Routed += c.Quantity
+		notional += c.NotionalUSD
+		effective += withFee(c.NotionalUSD, v.fee, side)
+
+		touch := v.ob.Asks
+		if side == SideSell {
+			touch = v.ob.Bids
+		}
+		if plan.BestTouch == 0 || better(touch[0].Price, plan.BestTouch, side) {
+			plan.BestTouch = touch[0].Price
+		}
+	}
+	plan.Unrouted = quantity - plan.Routed
+	sort.Slice(plan.Children, func(i, j int) bool {
+		if plan.Children[i].Quantity != plan.Children[j].Quantity {
+			return plan.Children[i].Quantity > plan.Children[j].Quantity
+		}
+		return plan.Children[i].Exchange < plan.Children[j].Exchange
+	})
+	if plan.Routed > 0 {
+		plan.AvgPrice = notional / plan.Routed
+		plan.EffectivePrice = effective / plan.Routed
+		plan.SlippageBps = costBps(plan.AvgPrice, plan.BestTouch, side)
+		plan.CostBps = costBps(plan.EffectivePrice, plan.BestTouch, side)
+	}
+
+	r.compareSingleVenue(plan, venues, quantity)
+	if r.limits != nil && plan.Routed > 0 {
+		deltas := make(map[connector.ExchangeID]float64, len(plan.Children))
+		for _, c := range plan.Children {
+			if side == SideSell {
+				deltas[c.Exchange] -= c.Quantity
+			} else {
+				deltas[c.Exchange] += c.Quantity
+			}
+		}
+		check := r.limits.Check(canonical, deltas, plan.AvgPrice)
+		plan.Notional = &check
+	}
+	return plan, nil
+}
+
+// compareSingleVenue records the best venue that could fill the whole
+// order alone within its balance, and what the split saves over it
+func (r *Router) compareSingleVenue(plan *RoutePlan, venues []*routeVenue, quantity float64) {
+	for _, v := range venues {
+		book := v.ob.Asks
+		if plan.Side == SideSell {
+			book = v.ob.Bids
+		}
+		avg, partial := walkBook(book, quantity)
+		if partial || avg*quantity > v.capUSD {
+			continue
+		}
+		price := withFee(avg, v.fee, plan.Side)
+		if plan.SingleVenue == "" || better(price, plan.SingleVenuePrice, plan.Side) ||
+			(price == plan.SingleVenuePrice && v.ob.ExchangeID < plan.SingleVenue) {
+			plan.SingleVenue = v.ob.ExchangeID
+			plan.SingleVenuePrice = price
+		}
+	}
+	if plan.SingleVenue != "" && plan.Unrouted <= 0 {
+		plan.SavingBps = costBps(plan.SingleVenuePrice, plan.EffectivePrice, plan.Side)
+	}
+}
+
+// Execute plans an order and sends each child as an IOC, largest first,
+// then reports the achieved price against the plan and the best touch.
+// Children that miss are not retried; the caller decides what to do with
+// the rest.
+func (r *Router) Execute(ctx context.Context, canonical, side string, quantity float64) (*RouteResult, error) {
+	if r.sender == nil {
+		return nil, fmt.Errorf("router has no order sender")
+	}
+	plan, err := r.Plan(canonical, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RouteResult{Plan: plan}
+	if err := r.drawdown.CheckEntry(); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if plan.Notional != nil && !plan.Notional.Allowed {
+		result.Error = plan.Notional.Reason
+		return result, nil
+	}
+	var notional, effective float64
+	for _, c := range plan.Children {
+		rf := RouteFill{Order: c.Order}
+		if err := checkTrade(r.modes, c.Exchange); err != nil {
+			rf.Error = err.Error()
+			result.Fills = append(result.Fills, rf)
+			metrics.RoutedOrders.WithLabelValues(string(c.Exchange), "refused").Inc()
+			continue
+		}
+		rf.Fill, err = r.sender.SendIOC(ctx, c.Order)
+		outcome := "filled"
+		switch {
+		case err != nil:
+			rf.Error = err.Error()
+			outcome = "error"
+		case rf.Fill.Quantity < c.Quantity*(1-1e-9):
+			outcome = "partial"
+		}
+		metrics.RoutedOrders.WithLabelValues(string(c.Exchange), outcome).Inc()
+		result.Fills = append(result.Fills, rf)
+
+		if rf.Fill.Quantity > 0 {
+			r.adjust(ctx, canonical, c.Order, rf.Fill)
+			result.Filled += rf.Fill.Quantity
+			notional += rf.Fill.Quantity * rf.Fill.AvgPrice
+			effective += withFee(rf.Fill.Quantity*rf.Fill.AvgPrice, c.TakerFeeBps, side)
+		}
+	}
+
+	if result.Filled > 0 {
+		result.AvgPrice = notional / result.Filled
+		result.EffectivePrice = effective / result.Filled
+		result.VsBestBps = costBps(result.EffectivePrice, plan.BestTouch, side)
+		result.VsPlanBps = costBps(result.EffectivePrice, plan.EffectivePrice, side)
+	}
+	result.Complete = result.Filled >= quantity*(1-1e-9)
+
+	log.Info().
+		Str("canonical", canonical).
+		Str("side", side).
+		Float64("requested", quantity).
+		Float64("filled", result.Filled).
+		Int("venues", len(plan.Children)).
+		Float64("vs_best_bps", result.VsBestBps).
+		Float64("vs_plan_bps", result.VsPlanBps).
+		Msg("Routed order executed")
+	return result, nil
+}
+
+// adjust records a fill in the position store
+func (r *Router) adjust(ctx context.Context, canonical string, order Order, fill Fill) {
+	if r.inventory == nil {
+		return
+	}
+	delta := fill.Quantity
+	if order.Side == SideSell {
+		delta = -delta
+	}
+	if _, err := r.inventory.Adjust(ctx, order.Exchange, canonical, delta); err != nil {
+		log.Error().Err(err).Str("exchange", string(order.Exchange)).Msg("Failed to record routed fill")
+	}
+}
+
+// withFee returns an amount after a taker fee: paid on top by a buyer,
+// taken out of the proceeds of a seller
+func withFee(amount, feeBps float64, side string) float64 {
+	if side == SideSell {
+		return amount * (1 - feeBps/10000)
+	}
+	return amount * (1 + feeBps/10000)
+}
+
+// better reports whether price a beats b for a side
+func better(a, b float64, side string) bool {
+	if side == SideSell {
+		return a > b
+	}
+	return a < b
+}
+
+// costBps returns how much worse price is than ref for a side, in bps
+func costBps(price, ref float64, side string) float64 {
+	if ref <= 0 {
+		return 0
+	}
+	bps := (price - ref) / ref * 10000
+	if side == SideSell {
+		return -bps
+	}
+	return bps
+}
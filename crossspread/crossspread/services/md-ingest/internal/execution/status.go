@@ -0,0 +1,32 @@
+package execution
+
+import (
+	"fmt"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// StatusGate reports whether a venue symbol accepts orders;
+// *symbolstatus.Tracker implements it
+type StatusGate interface {
+	Status(exchange connector.ExchangeID, symbol string) string
+	CanOpen(exchange connector.ExchangeID, symbol string) bool
+	CanClose(exchange connector.ExchangeID, symbol string) bool
+}
+
+// checkOpen returns an error if a leg may not open a position. A nil gate
+// allows everything.
+func checkOpen(gate StatusGate, exchange connector.ExchangeID, symbol string) error {
+	if gate == nil || gate.CanOpen(exchange, symbol) {
+		return nil
+	}
+	return fmt.Errorf("%s on %s is %s, opening not allowed", symbol, exchange, gate.Status(exchange, symbol))
+}
+
+// checkClose returns an error if a leg may not reduce a position
+func checkClose(gate StatusGate, exchange connector.ExchangeID, symbol string) error {
+	if gate == nil || gate.CanClose(exchange, symbol) {
+		return nil
+	}
+	return fmt.Errorf("%s on %s is %s, no orders accepted", symbol, exchange, gate.Status(exchange, symbol))
+}
@@ -0,0 +1,121 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/credentials"
+)
+
+// TenantCredentials returns one tenant's credentials on an exchange, never
+// another tenant's (credentials.TenantStore)
+type TenantCredentials interface {
+	Credentials(tenant, exchange string) (credentials.ExchangeCredentials, error)
+}
+
+// SenderFactory builds an order sender trading one tenant's account on an
+// exchange with the given credentials
+type SenderFactory func(tenant string, exchange connector.ExchangeID, creds credentials.ExchangeCredentials) (OrderSender, error)
+
+// TenantRouter hands out order senders bound to a tenant. Every order is
+// sent with the named tenant's credentials on the order's venue; a tenant
+// without credentials there gets an error, never another tenant's account.
+type TenantRouter struct {
+	creds   TenantCredentials
+	factory SenderFactory
+	modes   *Modes // Optional; md-only venues get no sender
+
+	mu      sync.Mutex
+	senders map[tenantVenue]tenantSender
+}
+
+type tenantVenue struct {
+	tenant   string
+	exchange connector.ExchangeID
+}
+
+type tenantSender struct {
+	apiKey string // Key the sender was built with; a rotated key rebuilds it
+	sender OrderSender
+}
+
+// NewTenantRouter creates a router building senders from each tenant's
+// credentials
+func NewTenantRouter(creds TenantCredentials, factory SenderFactory) *TenantRouter {
+	return &TenantRouter{
+		creds:   creds,
+		factory: factory,
+		senders: make(map[tenantVenue]tenantSender),
+	}
+}
+
+// SetModes refuses senders for venues in md-only mode, whatever
+// credentials the tenant holds there
+func (r *TenantRouter) SetModes(modes *Modes) {
+	r.modes = modes
+}
+
+// For returns an order sender for a tenant, to pass to a Ladder or Migrator
+// working that tenant's strategy
+func (r *TenantRouter) For(tenant string) OrderSender {
+	return &boundSender{router: r, tenant: tenant}
+}
+
+// Forget drops a tenant's cached senders, e.g. once the tenant is removed
+func (r *TenantRouter) Forget(tenant string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.senders {
+		if key.tenant == tenant {
+			delete(r.senders, key)
+		}
+	}
+}
+
+// sender returns the tenant's sender for an exchange. Credentials are looked
+// up on every order so revoked keys stop trading at once.
+func (r *TenantRouter) sender(tenant string, exchange connector.ExchangeID) (OrderSender, error) {
+	if tenant == "" {
+		return nil, fmt.Errorf("order has no tenant")
+	}
+	if err := checkTrade(r.modes, exchange); err != nil {
+		return nil, err
+	}
+	key := tenantVenue{tenant: tenant, exchange: exchange}
+
+	creds, err := r.creds.Credentials(tenant, string(exchange))
+	if err != nil {
+		r.mu.Lock()
+		delete(r.senders, key)
+		r.mu.Unlock()
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.senders[key]; ok && cached.apiKey == creds.APIKey {
+		return cached.sender, nil
+	}
+	sender, err := r.factory(tenant, exchange, creds)
+	if err != nil {
+		return nil, fmt.Errorf("build %s sender for %s: %w", exchange, tenant, err)
+	}
+	r.senders[key] = tenantSender{apiKey: creds.APIKey, sender: sender}
+	return sender, nil
+}
+
+// boundSender sends every order with one tenant's credentials
+type boundSender struct {
+	router *TenantRouter
+	tenant string
+}
+
+func (b *boundSender) SendIOC(ctx context.Context, order Order) (Fill, error) {
+	sender, err := b.router.sender(b.tenant, order.Exchange)
+	if err != nil {
+		return Fill{}, err
+	}
+	return sender.SendIOC(ctx, order)
+}
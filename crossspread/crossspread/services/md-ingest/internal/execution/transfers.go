@@ -0,0 +1,496 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// TransferConfig sets how deposit and withdrawal records are read and how
+// many completion times are kept per route
+type TransferConfig struct {
+	PollInterval time.Duration // How often records are read
+	// Lookback is how far back records are read, and how long a withdrawal
+	// waits to be matched with the deposit it funded
+	Lookback   time.Duration
+	MaxSamples int // Completion times kept per route
+	MinSamples int // Needed before a route's latency is expected
+}
+
+// DefaultTransferConfig reads the last two days every two minutes and
+// expects a route's latency after three transfers
+func DefaultTransferConfig() TransferConfig {
+	return TransferConfig{
+		PollInterval: 2 * time.Minute,
+		Lookback:     48 * time.Hour,
+		MaxSamples:   50,
+		MinSamples:   3,
+	}
+}
+
+// TransferSample is one completed transfer on a route
+type TransferSample struct {
+	ID      string    `json:"id"` // direction:exchange:record ID of the completing record
+	Seconds float64   `json:"seconds"`
+	At      time.Time `json:"at"` // Completion
+}
+
+// TransferLatency is the completion times observed on a route: one asset
+// over one chain from a venue to another. From is empty for deposits whose
+// withdrawal wasn't seen, To for withdrawals whose deposit wasn't.
+type TransferLatency struct {
+	Asset      string               `json:"asset"`
+	Chain      string               `json:"chain"`
+	From       connector.ExchangeID `json:"from,omitempty"`
+	To         connector.ExchangeID `json:"to,omitempty"`
+	Samples    []TransferSample     `json:"samples"` // Oldest first
+	P50Seconds float64              `json:"p50_seconds"`
+	P90Seconds float64              `json:"p90_seconds"`
+	MaxSeconds float64              `json:"max_seconds"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// key returns the route's field in the transfers hash
+func (l *TransferLatency) key() string {
+	return transferRouteKey(l.Asset, l.Chain, l.From, l.To)
+}
+
+func transferRouteKey(asset, chain string, from, to connector.ExchangeID) string {
+	return strings.ToUpper(asset) + ":" + chain + ":" + string(from) + ":" + string(to)
+}
+
+// add records a sample unless it is already held, keeping the newest
+// MaxSamples, and recomputes the percentiles
+func (l *TransferLatency) add(s TransferSample, max int) bool {
+	for _, have := range l.Samples {
+		if have.ID == s.ID {
+			return false
+		}
+	}
+	l.Samples = append(l.Samples, s)
+	sort.Slice(l.Samples, func(i, j int) bool { return l.Samples[i].At.Before(l.Samples[j].At) })
+	if max > 0 && len(l.Samples) > max {
+		l.Samples = l.Samples[len(l.Samples)-max:]
+	}
+
+	secs := make([]float64, len(l.Samples))
+	for i, s := range l.Samples {
+		secs[i] = s.Seconds
+	}
+	sort.Float64s(secs)
+	l.P50Seconds = percentileOf(secs, 0.5)
+	l.P90Seconds = percentileOf(secs, 0.9)
+	l.MaxSeconds = secs[len(secs)-1]
+	l.UpdatedAt = s.At
+	return true
+}
+
+// percentileOf returns the nearest-rank percentile of sorted values
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// TransferEstimate is how long moving an asset between venues is expected
+// to take
+type TransferEstimate struct {
+	Asset   string               `json:"asset"`
+	Chain   string               `json:"chain"`
+	From    connector.ExchangeID `json:"from"`
+	To      connector.ExchangeID `json:"to"`
+	P50     time.Duration        `json:"p50"`
+	P90     time.Duration        `json:"p90"`
+	Samples int                  `json:"samples"`
+	// Composed is set when no transfer was seen end to end and the
+	// estimate adds the source's withdrawal leg to the destination's
+	// deposit leg
+	Composed bool `json:"composed,omitempty"`
+}
+
+// TransferLatencies records how long deposits and withdrawals take to
+// complete per asset, chain and venue pair. Withdrawals are matched to the
+// deposit they funded on another venue by transaction hash; legs whose
+// other side isn't tracked are kept on their own. Venues that don't report
+// completion times are timed from the poll that first saw the record
+// pending to the one that saw it complete.
+type TransferLatencies struct {
+	config TransferConfig
+	client *redis.Client
+
+	mu          sync.RWMutex
+	fetchers    map[connector.ExchangeID]connector.TransferFetcher
+	routes      map[string]*TransferLatency
+	pending     map[string]time.Time                // Record key -> first seen pending
+	withdrawals map[string]connector.TransferRecord // asset:txid -> withdrawal awaiting its deposit
+	seen        map[string]time.Time                // Completed record keys -> completion
+	done        chan struct{}
+}
+
+// NewTransferLatencies creates the store. Connectors implementing
+// connector.TransferFetcher are read directly; others are added with
+// SetFetcher.
+func NewTransferLatencies(client *redis.Client, connectors []connector.Connector, config TransferConfig) *TransferLatencies {
+	t := &TransferLatencies{
+		config:      config,
+		client:      client,
+		fetchers:    make(map[connector.ExchangeID]connector.TransferFetcher),
+		routes:      make(map[string]*TransferLatency),
+		pending:     make(map[string]time.Time),
+		withdrawals: make(map[string]connector.TransferRecord),
+		seen:        make(map[string]time.Time),
+		done:        make(chan struct{}),
+	}
+	for _, conn := range connectors {
+		if f, ok := conn.(connector.TransferFetcher); ok {
+			t.fetchers[conn.ID()] = f
+		}
+	}
+	return t
+}
+
+// SetFetcher reads a venue's deposits and withdrawals through f
+func (t *TransferLatencies) SetFetcher(exchange connector.ExchangeID, f connector.TransferFetcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fetchers[exchange] = f
+}
+
+// Venues returns the venues whose transfers are read
+func (t *TransferLatencies) Venues() []connector.ExchangeID {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	venues := make([]connector.ExchangeID, 0, len(t.fetchers))
+	for id := range t.fetchers {
+		venues = append(venues, id)
+	}
+	sort.Slice(venues, func(i, j int) bool { return venues[i] < venues[j] })
+	return venues
+}
+
+// Load reads the recorded routes. Fields that don't parse are ignored.
+func (t *TransferLatencies) Load(ctx context.Context) error {
+	raw, err := t.client.HGetAll(ctx, keyspace.Key(keyspace.TransfersKey)).Result()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for field, v := range raw {
+		var l TransferLatency
+		if err := json.Unmarshal([]byte(v), &l); err != nil {
+			log.Warn().Str("route", field).Str("value", v).Msg("Ignoring invalid transfer latency")
+			continue
+		}
+		l.Chain = connector.NormalizeChain(l.Chain)
+		t.routes[l.key()] = &l
+		for _, s := range l.Samples {
+			t.seen[s.ID] = s.At
+		}
+	}
+	return nil
+}
+
+// Poll reads every venue's recent records and records the transfers that
+// completed since the last poll
+func (t *TransferLatencies) Poll(ctx context.Context) {
+	now := time.Now()
+	since := now.Add(-t.config.Lookback)
+
+	t.mu.RLock()
+	fetchers := make(map[connector.ExchangeID]connector.TransferFetcher, len(t.fetchers))
+	for id, f := range t.fetchers {
+		fetchers[id] = f
+	}
+	t.mu.RUnlock()
+
+	var records []connector.TransferRecord
+	for exchange, f := range fetchers {
+		fctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		got, err := f.FetchTransfers(fctx, since)
+		cancel()
+		if err != nil {
+			metrics.TransferFetchFailures.WithLabelValues(string(exchange)).Inc()
+			log.Warn().Err(err).Str("exchange", string(exchange)).Msg("Failed to read transfers")
+			continue
+		}
+		records = append(records, got...)
+	}
+
+	changed := t.record(now, records)
+	if len(changed) == 0 {
+		return
+	}
+	fields := make(map[string]interface{}, len(changed))
+	for _, l := range changed {
+		b, err := json.Marshal(l)
+		if err != nil {
+			continue
+		}
+		fields[l.key()] = b
+	}
+	if err := t.client.HSet(ctx, keyspace.Key(keyspace.TransfersKey), fields).Err(); err != nil {
+		log.Warn().Err(err).Msg("Failed to save transfer latencies")
+	}
+}
+
+// record folds one poll's records into the routes and returns copies of
+// the routes that gained samples. Withdrawals go first so a deposit read
+// in the same poll finds the withdrawal that funded it.
+func (t *TransferLatencies) record(now time.Time, records []connector.TransferRecord) []TransferLatency {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Direction == connector.TransferWithdrawal && records[j].Direction != connector.TransferWithdrawal
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changed := make(map[string]*TransferLatency)
+	for _, r := range records {
+		id := r.Direction + ":" + string(r.ExchangeID) + ":" + r.ID
+		if _, ok := t.seen[id]; ok {
+			continue
+		}
+		if r.Direction == connector.TransferWithdrawal && r.TxID != "" {
+			t.withdrawals[strings.ToUpper(r.Asset)+":"+r.TxID] = r
+		}
+		if !r.Completed {
+			if _, ok := t.pending[id]; !ok {
+				t.pending[id] = now
+			}
+			continue
+		}
+
+		completed := r.CompletedAt
+		if completed.IsZero() {
+			// Completed between this poll and the last one; records first
+			// seen complete can't be timed
+			if _, ok := t.pending[id]; ok {
+				completed = now
+			}
+		}
+		delete(t.pending, id)
+		if completed.IsZero() {
+			t.seen[id] = now
+			continue
+		}
+		t.seen[id] = completed
+
+		// Routes are keyed by normalized chain, as Expected looks them up
+		from, to := r.ExchangeID, connector.ExchangeID("")
+		started := r.CreatedAt
+		chain := connector.NormalizeChain(r.Chain)
+		if r.Direction == connector.TransferDeposit {
+			from, to = "", r.ExchangeID
+			if w, ok := t.withdrawals[strings.ToUpper(r.Asset)+":"+r.TxID]; ok && r.TxID != "" && !w.CreatedAt.IsZero() {
+				from, started = w.ExchangeID, w.CreatedAt
+				if chain == "" {
+					chain = connector.NormalizeChain(w.Chain)
+				}
+			}
+		}
+		if started.IsZero() || !completed.After(started) {
+			continue
+		}
+
+		key := transferRouteKey(r.Asset, chain, from, to)
+		l, ok := t.routes[key]
+		if !ok {
+			l = &TransferLatency{Asset: strings.ToUpper(r.Asset), Chain: chain, From: from, To: to}
+			t.routes[key] = l
+		}
+		secs := completed.Sub(started).Seconds()
+		if l.add(TransferSample{ID: id, Seconds: secs, At: completed}, t.config.MaxSamples) {
+			changed[key] = l
+			metrics.TransferLatency.WithLabelValues(l.Asset, l.Chain, string(from), string(to)).Observe(secs)
+			log.Info().
+				Str("asset", l.Asset).
+				Str("chain", l.Chain).
+				Str("from", string(from)).
+				Str("to", string(to)).
+				Dur("latency", completed.Sub(started)).
+				Msg("Recorded transfer latency")
+		}
+	}
+
+	// Forget what is past the lookback; it won't be read again
+	cutoff := now.Add(-2 * t.config.Lookback)
+	for id, at := range t.pending {
+		if at.Before(cutoff) {
+			delete(t.pending, id)
+		}
+	}
+	for id, at := range t.seen {
+		if at.Before(cutoff) {
+			delete(t.seen, id)
+		}
+	}
+	for key, w := range t.withdrawals {
+		if w.CreatedAt.Before(cutoff) {
+			delete(t.withdrawals, key)
+		}
+	}
+
+	out := make([]TransferLatency, 0, len(changed))
+	for _, l := range changed {
+		out = append(out, l.copy())
+	}
+	return out
+}
+
+// copy returns the route with its own samples slice
+func (l *TransferLatency) copy() TransferLatency {
+	c := *l
+	c.Samples = append([]TransferSample(nil), l.Samples...)
+	return c
+}
+
+// Routes returns every recorded route, ordered by asset, chain, then
+// venues
+func (t *TransferLatencies) Routes() []TransferLatency {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	routes := make([]TransferLatency, 0, len(t.routes))
+	for _, l := range t.routes {
+		routes = append(routes, l.copy())
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].key() < routes[j].key() })
+	return routes
+}
+
+// estimate returns a route's latency, if it has enough samples
+func (t *TransferLatencies) estimate(asset, chain string, from, to connector.ExchangeID) (TransferEstimate, bool) {
+	l, ok := t.routes[transferRouteKey(asset, chain, from, to)]
+	if !ok || len(l.Samples) < t.config.MinSamples || len(l.Samples) == 0 {
+		return TransferEstimate{}, false
+	}
+	return TransferEstimate{
+		Asset:   l.Asset,
+		Chain:   l.Chain,
+		From:    from,
+		To:      to,
+		P50:     time.Duration(l.P50Seconds * float64(time.Second)),
+		P90:     time.Duration(l.P90Seconds * float64(time.Second)),
+		Samples: len(l.Samples),
+	}, true
+}
+
+// Expected returns how long moving an asset over a chain from one venue to
+// another is expected to take: the transfers seen end to end on that
+// route, or else the source's withdrawals plus the destination's deposits.
+// A nil *TransferLatencies expects nothing.
+func (t *TransferLatencies) Expected(asset, chain string, from, to connector.ExchangeID) (TransferEstimate, bool) {
+	if t == nil {
+		return TransferEstimate{}, false
+	}
+	chain = connector.NormalizeChain(chain)
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if e, ok := t.estimate(asset, chain, from, to); ok {
+		return e, true
+	}
+	out, ok := t.estimate(asset, chain, from, "")
+	if !ok {
+		return TransferEstimate{}, false
+	}
+	in, ok := t.estimate(asset, chain, "", to)
+	if !ok {
+		return TransferEstimate{}, false
+	}
+	samples := out.Samples
+	if in.Samples < samples {
+		samples = in.Samples
+	}
+	return TransferEstimate{
+		Asset:    out.Asset,
+		Chain:    chain,
+		From:     from,
+		To:       to,
+		P50:      out.P50 + in.P50,
+		P90:      out.P90 + in.P90,
+		Samples:  samples,
+		Composed: true,
+	}, true
+}
+
+// Fastest returns the chain an asset is expected to move over quickest
+// from one venue to another, by p90 latency
+func (t *TransferLatencies) Fastest(asset string, from, to connector.ExchangeID) (TransferEstimate, error) {
+	if t == nil {
+		return TransferEstimate{}, fmt.Errorf("transfer latencies not tracked")
+	}
+	t.mu.RLock()
+	chains := make(map[string]bool)
+	for _, l := range t.routes {
+		if strings.EqualFold(l.Asset, asset) {
+			chains[l.Chain] = true
+		}
+	}
+	t.mu.RUnlock()
+
+	var best TransferEstimate
+	found := false
+	for chain := range chains {
+		e, ok := t.Expected(asset, chain, from, to)
+		if !ok {
+			continue
+		}
+		if !found || e.P90 < best.P90 || (e.P90 == best.P90 && e.Chain < best.Chain) {
+			best, found = e, true
+		}
+	}
+	if !found {
+		return TransferEstimate{}, fmt.Errorf("too few %s transfers seen from %s to %s to expect a latency", strings.ToUpper(asset), from, to)
+	}
+	return best, nil
+}
+
+// TransferP90 returns the p90 time to move an asset from one venue to
+// another over its fastest chain, for spread discovery's rebalance estimate
+func (t *TransferLatencies) TransferP90(asset string, from, to connector.ExchangeID) (time.Duration, bool) {
+	e, err := t.Fastest(asset, from, to)
+	if err != nil {
+		return 0, false
+	}
+	return e.P90, true
+}
+
+// Start reads transfers until the context is cancelled or Stop is called
+func (t *TransferLatencies) Start(ctx context.Context) {
+	ticker := time.NewTicker(t.config.PollInterval)
+	defer ticker.Stop()
+
+	t.Poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.done:
+			return
+		case <-ticker.C:
+			t.Poll(ctx)
+		}
+	}
+}
+
+// Stop stops the poll loop
+func (t *TransferLatencies) Stop() {
+	close(t.done)
+}
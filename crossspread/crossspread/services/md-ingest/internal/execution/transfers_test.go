@@ -0,0 +1,98 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+var transferEpoch = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// transfer returns a withdrawal from one venue and the deposit it funded on
+// another, completing after the given time
+func transfer(n int, chain string, from, to connector.ExchangeID, took time.Duration) []connector.TransferRecord {
+	start := transferEpoch.Add(time.Duration(n) * time.Hour)
+	tx := "0x" + string(rune('a'+n))
+	return []connector.TransferRecord{
+		{ExchangeID: from, Direction: connector.TransferWithdrawal, ID: "w" + tx, TxID: tx, Asset: "usdt", Chain: chain,
+			Completed: true, CreatedAt: start, CompletedAt: start.Add(took / 2)},
+		{ExchangeID: to, Direction: connector.TransferDeposit, ID: "d" + tx, TxID: tx, Asset: "USDT", Chain: chain,
+			Completed: true, CreatedAt: start.Add(took / 2), CompletedAt: start.Add(took)},
+	}
+}
+
+func newTestLatencies() *TransferLatencies {
+	cfg := DefaultTransferConfig()
+	cfg.MinSamples = 2
+	cfg.Lookback = 365 * 24 * time.Hour
+	return NewTransferLatencies(nil, nil, cfg)
+}
+
+// Routes recorded under a venue's chain alias are found by the network name
+func TestTransferLatenciesMatchesWithdrawalToDeposit(t *testing.T) {
+	l := newTestLatencies()
+	now := transferEpoch.Add(24 * time.Hour)
+	l.record(now, transfer(0, "TRC20", connector.Bybit, connector.CoinEx, 10*time.Minute))
+	if _, ok := l.Expected("USDT", "TRX", connector.Bybit, connector.CoinEx); ok {
+		t.Fatal("expected a latency from one transfer with MinSamples 2")
+	}
+	// The withdrawal leg is kept on its own too
+	changed := l.record(now, transfer(1, "TRX", connector.Bybit, connector.CoinEx, 20*time.Minute))
+	if len(changed) != 2 {
+		t.Fatalf("second transfer changed %d routes, want the withdrawal's and the end to end one", len(changed))
+	}
+
+	e, ok := l.Expected("USDT", "trc20", connector.Bybit, connector.CoinEx)
+	if !ok {
+		t.Fatalf("no latency for TRC20 after two transfers; routes %+v", l.Routes())
+	}
+	if e.Composed || e.Samples != 2 || e.P50 != 10*time.Minute || e.P90 != 20*time.Minute {
+		t.Errorf("estimate = %+v, want end to end p50 10m, p90 20m over 2 samples", e)
+	}
+
+	// Records already seen are not counted again
+	if changed := l.record(now, transfer(1, "TRX", connector.Bybit, connector.CoinEx, 20*time.Minute)); len(changed) != 0 {
+		t.Errorf("repeated records changed %d routes", len(changed))
+	}
+}
+
+// Without end to end transfers, the source's withdrawals and destination's
+// deposits are added
+func TestTransferLatenciesComposesLegs(t *testing.T) {
+	l := newTestLatencies()
+	now := transferEpoch.Add(24 * time.Hour)
+	for n := 0; n < 2; n++ {
+		records := transfer(n, "ERC20", connector.Bybit, connector.CoinEx, 30*time.Minute)
+		records[1].TxID = "" // Deposit not matched to its withdrawal
+		l.record(now, records)
+	}
+
+	e, ok := l.Expected("USDT", "ETH", connector.Bybit, connector.CoinEx)
+	if !ok || !e.Composed {
+		t.Fatalf("estimate = %+v, %v, want one composed from both legs", e, ok)
+	}
+	if e.P90 != 30*time.Minute {
+		t.Errorf("composed p90 %v, want 15m withdrawal + 15m deposit", e.P90)
+	}
+}
+
+func TestTransferLatenciesFastest(t *testing.T) {
+	l := newTestLatencies()
+	now := transferEpoch.Add(24 * time.Hour)
+	for n := 0; n < 2; n++ {
+		l.record(now, transfer(n, "ETH", connector.Bybit, connector.CoinEx, time.Hour))
+		l.record(now, transfer(n+10, "SOL", connector.Bybit, connector.CoinEx, 2*time.Minute))
+	}
+
+	e, err := l.Fastest("usdt", connector.Bybit, connector.CoinEx)
+	if err != nil || e.Chain != "SOL" {
+		t.Fatalf("fastest = %+v, %v, want SOL", e, err)
+	}
+	if d, ok := l.TransferP90("USDT", connector.Bybit, connector.CoinEx); !ok || d != 2*time.Minute {
+		t.Errorf("TransferP90 = %v, %v, want 2m", d, ok)
+	}
+	if _, err := l.Fastest("USDT", connector.CoinEx, connector.Bybit); err == nil {
+		t.Error("fastest route found in a direction never seen")
+	}
+}
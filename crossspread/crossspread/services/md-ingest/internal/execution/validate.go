@@ -0,0 +1,315 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+)
+
+// Quote sources
+const (
+	SourceWS         = "ws"          // Streamed book cached by spread discovery
+	SourceBookTicker = "book_ticker" // REST best bid/ask
+	SourceSnapshot   = "snapshot"    // REST depth snapshot
+)
+
+// BookCache returns the latest streamed books of a canonical symbol;
+// *spread.SpreadDiscovery implements it
+type BookCache interface {
+	Orderbook(canonical string, exchange connector.ExchangeID) *connector.Orderbook
+	Orderbooks(canonical string) map[connector.ExchangeID]*connector.Orderbook
+}
+
+// ValidationConfig controls pre-execution revalidation
+type ValidationConfig struct {
+	// MinEdgeFraction is the share of the advertised net edge that must
+	// survive revalidation, e.g. 0.6 sends only if 60% of the edge is left
+	MinEdgeFraction float64
+	// MaxCacheAge is the oldest streamed quote trusted without going to
+	// REST. Older quotes may already be gone by the time legs land.
+	MaxCacheAge  time.Duration
+	RESTTimeout  time.Duration
+	SnapshotSize int // Levels requested from venues without a book ticker
+}
+
+// DefaultValidationConfig trusts quotes younger than 250ms and requires
+// half of the advertised edge
+func DefaultValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		MinEdgeFraction: 0.5,
+		MaxCacheAge:     250 * time.Millisecond,
+		RESTTimeout:     500 * time.Millisecond,
+		SnapshotSize:    5,
+	}
+}
+
+// LegQuote is the quote a leg was revalidated against
+type LegQuote struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Symbol   string               `json:"symbol"`
+	Price    float64              `json:"price"` // Ask for the long leg, bid for the short leg
+	Quantity float64              `json:"quantity"`
+	Source   string               `json:"source"` // ws, book_ticker, snapshot
+	AgeMs    float64              `json:"age_ms"`
+}
+
+// Validation is the verdict of revalidating a spread just before execution
+type Validation struct {
+	OpportunityID string    `json:"opportunity_id"`
+	Long          LegQuote  `json:"long"`
+	Short         LegQuote  `json:"short"`
+	AdvertisedBps float64   `json:"advertised_bps"` // Net edge when the spread was published
+	CurrentBps    float64   `json:"current_bps"`    // Net edge now, same breakeven
+	RequiredBps   float64   `json:"required_bps"`
+	Proceed       bool      `json:"proceed"`
+	Reason        string    `json:"reason,omitempty"`
+	ElapsedMs     float64   `json:"elapsed_ms"`
+	At            time.Time `json:"at"`
+
+	// Estimated liquidation of each leg at its quote, if a guard is set
+	Liquidation []LiquidationCheck `json:"liquidation,omitempty"`
+	// Underlying's notional with the entry added, if limits are set
+	Notional *NotionalCheck `json:"notional,omitempty"`
+	// Scale executors apply to the entry size; below 1 after a drawdown or
+	// with a leg on a venue at its exposure cap
+	SizeFactor float64 `json:"size_factor"`
+	// Each leg's venue against its share of total equity, if caps are set
+	Venues []VenueExposure `json:"venues,omitempty"`
+}
+
+// Validator rechecks a spread against the freshest quotes available before
+// an executor sends legs, so edges that decayed while the signal travelled
+// are skipped
+type Validator struct {
+	config      ValidationConfig
+	cache       BookCache
+	connectors  map[connector.ExchangeID]connector.Connector
+	status      StatusGate        // Optional; legs that cannot open are rejected
+	modes       *Modes            // Optional; legs on md-only venues are rejected
+	liquidation *LiquidationGuard // Optional; legs liquidated too close to mark are rejected
+	limits      *NotionalLimits   // Optional; entries past the underlying's notional cap are rejected
+	drawdown    *DrawdownGuard    // Optional; entries are scaled down or halted after a drawdown
+	credit      *CreditLimits     // Optional; entries with a leg on a venue at its exposure cap are scaled down
+	preflight   *Preflight        // Optional; entries are refused until account setup is verified
+}
+
+// NewValidator creates a new pre-execution validator
+func NewValidator(cache BookCache, connectors []connector.Connector, config ValidationConfig) *Validator {
+	byID := make(map[connector.ExchangeID]connector.Connector, len(connectors))
+	for _, conn := range connectors {
+		byID[conn.ID()] = conn
+	}
+	return &Validator{config: config, cache: cache, connectors: byID}
+}
+
+// SetStatusGate rejects spreads with a leg on a symbol that cannot open
+// positions before any quote is fetched
+func (v *Validator) SetStatusGate(gate StatusGate) {
+	v.status = gate
+}
+
+// SetModes rejects spreads with a leg on a venue in md-only mode
+func (v *Validator) SetModes(modes *Modes) {
+	v.modes = modes
+}
+
+// SetLiquidationGuard rejects spreads with a leg whose estimated liquidation
+// price at its quote sits too close to mark
+func (v *Validator) SetLiquidationGuard(guard *LiquidationGuard) {
+	v.liquidation = guard
+}
+
+// SetNotionalLimits rejects spreads whose entry would take the underlying
+// past its gross or net notional cap across venues
+func (v *Validator) SetNotionalLimits(limits *NotionalLimits) {
+	v.limits = limits
+}
+
+// SetDrawdownGuard scales entries down, or rejects them, while de-risking
+// after a drawdown is in force
+func (v *Validator) SetDrawdownGuard(guard *DrawdownGuard) {
+	v.drawdown = guard
+}
+
+// SetCreditLimits scales entries down with a leg on a venue holding its
+// capped share of total equity
+func (v *Validator) SetCreditLimits(limits *CreditLimits) {
+	v.credit = limits
+}
+
+// SetPreflight refuses every entry until the account preflight has passed
+func (v *Validator) SetPreflight(p *Preflight) {
+	v.preflight = p
+}
+
+// Validate fetches both legs' quotes in parallel and reports whether the
+// spread still clears the required fraction of its advertised net edge.
+// An error means a leg could not be quoted; the executor must not proceed.
+func (v *Validator) Validate(ctx context.Context, opp *spread.SpreadOpportunity) (*Validation, error) {
+	start := time.Now()
+	result := &Validation{
+		OpportunityID: opp.ID,
+		AdvertisedBps: opp.NetEdgeBps,
+		RequiredBps:   opp.NetEdgeBps * v.config.MinEdgeFraction,
+		SizeFactor:    v.drawdown.SizeFactor() * v.credit.SizeFactor(opp.LongExchange, opp.ShortExchange),
+	}
+	for _, exchange := range []connector.ExchangeID{opp.LongExchange, opp.ShortExchange} {
+		if x, ok := v.credit.Exposure(exchange); ok {
+			result.Venues = append(result.Venues, x)
+		}
+	}
+
+	labels := []string{string(opp.LongExchange), string(opp.ShortExchange)}
+	for _, err := range []error{
+		v.preflight.CheckLive(),
+		v.drawdown.CheckEntry(),
+		v.credit.CheckEntry(opp.LongExchange, opp.ShortExchange),
+		checkTrade(v.modes, opp.LongExchange),
+		checkTrade(v.modes, opp.ShortExchange),
+		checkOpen(v.status, opp.LongExchange, opp.LongSymbol),
+		checkOpen(v.status, opp.ShortExchange, opp.ShortSymbol),
+	} {
+		if err != nil {
+			result.Reason = err.Error()
+			result.At = time.Now()
+			metrics.ExecutionValidations.WithLabelValues(append(labels, "rejected")...).Inc()
+			return result, nil
+		}
+	}
+
+	var (
+		wg                sync.WaitGroup
+		longErr, shortErr error
+		longQ, shortQ     LegQuote
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		longQ, longErr = v.quote(ctx, opp.Canonical, opp.LongExchange, opp.LongSymbol, false)
+	}()
+	go func() {
+		defer wg.Done()
+		shortQ, shortErr = v.quote(ctx, opp.Canonical, opp.ShortExchange, opp.ShortSymbol, true)
+	}()
+	wg.Wait()
+
+	if longErr != nil || shortErr != nil {
+		metrics.ExecutionValidations.WithLabelValues(append(labels, "error")...).Inc()
+		if longErr != nil {
+			return nil, fmt.Errorf("long leg %s: %w", opp.LongExchange, longErr)
+		}
+		return nil, fmt.Errorf("short leg %s: %w", opp.ShortExchange, shortErr)
+	}
+
+	result.Long, result.Short = longQ, shortQ
+	spreadBps := (shortQ.Price - longQ.Price) / longQ.Price * 10000
+	result.CurrentBps = spreadBps - opp.BreakevenBps
+	result.At = time.Now()
+	result.ElapsedMs = float64(result.At.Sub(start)) / float64(time.Millisecond)
+
+	var blocked string
+	if v.liquidation != nil {
+		result.Liquidation = []LiquidationCheck{
+			v.liquidation.Check(opp.Canonical, opp.LongExchange, opp.LongSymbol, SideBuy, longQ.Price),
+			v.liquidation.Check(opp.Canonical, opp.ShortExchange, opp.ShortSymbol, SideSell, shortQ.Price),
+		}
+		for _, c := range result.Liquidation {
+			if !c.Allowed && blocked == "" {
+				blocked = c.Reason
+			}
+		}
+	}
+	if v.limits != nil && longQ.Price > 0 {
+		quantity := v.limits.EntryNotionalUSD() * result.SizeFactor / longQ.Price
+		check := v.limits.CheckSpread(opp.Canonical, opp.LongExchange, opp.ShortExchange, quantity, longQ.Price)
+		result.Notional = &check
+		if !check.Allowed && blocked == "" {
+			blocked = check.Reason
+		}
+	}
+
+	switch {
+	case blocked != "":
+		result.Reason = blocked
+	case opp.NetEdgeBps <= 0:
+		result.Reason = "no advertised edge"
+	case result.CurrentBps < result.RequiredBps:
+		result.Reason = fmt.Sprintf("edge decayed to %.2f bps, need %.2f", result.CurrentBps, result.RequiredBps)
+	default:
+		result.Proceed = true
+	}
+
+	outcome := "proceed"
+	if !result.Proceed {
+		outcome = "rejected"
+	}
+	metrics.ExecutionValidations.WithLabelValues(append(labels, outcome)...).Inc()
+	return result, nil
+}
+
+// quote returns a leg's executable price: the ask for a buy, the bid for a
+// sell. The streamed book is used while fresh; otherwise the venue's book
+// ticker, falling back to a small depth snapshot.
+func (v *Validator) quote(ctx context.Context, canonical string, exchange connector.ExchangeID, symbol string, sell bool) (LegQuote, error) {
+	start := time.Now()
+	q := LegQuote{Exchange: exchange, Symbol: symbol}
+
+	if ob := v.cache.Orderbook(canonical, exchange); ob != nil {
+		age := time.Since(ob.ReceivedAt)
+		if !ob.ReceivedAt.IsZero() && age <= v.config.MaxCacheAge {
+			if err := fillQuote(&q, ob, sell); err == nil {
+				q.Source = SourceWS
+				q.AgeMs = float64(age) / float64(time.Millisecond)
+				metrics.ExecutionQuoteLatency.WithLabelValues(string(exchange), SourceWS).Observe(time.Since(start).Seconds())
+				return q, nil
+			}
+		}
+	}
+
+	conn, ok := v.connectors[exchange]
+	if !ok {
+		return q, fmt.Errorf("no connector and no fresh streamed quote")
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, v.config.RESTTimeout)
+	defer cancel()
+
+	var (
+		ob  *connector.Orderbook
+		err error
+	)
+	if fetcher, ok := conn.(connector.BookTickerFetcher); ok {
+		q.Source = SourceBookTicker
+		ob, err = fetcher.FetchBookTicker(reqCtx, symbol)
+	} else {
+		q.Source = SourceSnapshot
+		ob, err = conn.FetchOrderbookSnapshot(reqCtx, symbol, v.config.SnapshotSize)
+	}
+	if err != nil {
+		return q, err
+	}
+	if err := fillQuote(&q, ob, sell); err != nil {
+		return q, err
+	}
+	q.AgeMs = float64(time.Since(start)) / float64(time.Millisecond)
+	metrics.ExecutionQuoteLatency.WithLabelValues(string(exchange), q.Source).Observe(time.Since(start).Seconds())
+	return q, nil
+}
+
+func fillQuote(q *LegQuote, ob *connector.Orderbook, sell bool) error {
+	levels := ob.Asks
+	if sell {
+		levels = ob.Bids
+	}
+	if len(levels) == 0 || levels[0].Price <= 0 {
+		return fmt.Errorf("empty book")
+	}
+	q.Price = levels[0].Price
+	q.Quantity = levels[0].Quantity
+	return nil
+}
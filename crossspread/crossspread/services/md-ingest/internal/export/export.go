@@ -0,0 +1,432 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/bars"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Datasets that can be exported
+const (
+	DatasetTrades    = "trades"    // trades:{exchange}:{symbol} stream
+	DatasetOrderbook = "orderbook" // orderbook:{exchange}:{symbol} stream
+	DatasetFunding   = "funding"   // funding:{exchange}:{symbol} stream
+	DatasetBars      = "bars"      // Bar history of an interval
+)
+
+// Output formats
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// ErrInvalidRequest is returned for requests naming an unknown dataset,
+// format or field, or missing the symbol
+var ErrInvalidRequest = errors.New("invalid export request")
+
+// Column types
+const (
+	TypeTime   = "time"
+	TypeFloat  = "float"
+	TypeInt    = "int"
+	TypeString = "string"
+	TypeBool   = "bool"
+)
+
+// Column is a field of a dataset
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // time, float, int, string, bool
+}
+
+// Request selects what to export
+type Request struct {
+	Dataset  string
+	Exchange connector.ExchangeID
+	Symbol   string // Exchange-native symbol
+	Interval string // Bar interval, for the bars dataset
+	From, To time.Time
+	Fields   []string // Columns in output order; empty exports every column
+	Format   string
+	Limit    int // Rows; zero or above the configured maximum uses the maximum
+}
+
+// Config bounds exports
+type Config struct {
+	MaxRows   int // Rows exported at most per request
+	BatchSize int // Stream entries read per Redis round trip
+}
+
+// DefaultConfig exports up to a million rows, reading streams a thousand
+// entries at a time
+func DefaultConfig() Config {
+	return Config{
+		MaxRows:   1000000,
+		BatchSize: 1000,
+	}
+}
+
+// BarSource returns a venue symbol's recorded bars; *history.Store
+// implements it
+type BarSource interface {
+	Bars(ctx context.Context, exchange, symbol, interval string, from, to time.Time) ([]*bars.Bar, error)
+}
+
+type dataset struct {
+	columns []Column
+	row     func(data []byte) ([]interface{}, error) // Values in column order
+}
+
+var datasets = map[string]dataset{
+	DatasetTrades: {
+		columns: []Column{
+			{"timestamp", TypeTime}, {"exchange", TypeString}, {"symbol", TypeString}, {"canonical", TypeString},
+			{"trade_id", TypeString}, {"price", TypeFloat}, {"quantity", TypeFloat}, {"side", TypeString},
+			{"notional_usd", TypeFloat}, {"side_inferred", TypeBool}, {"received_at", TypeTime},
+		},
+		row: func(data []byte) ([]interface{}, error) {
+			var t connector.Trade
+			if err := json.Unmarshal(data, &t); err != nil {
+				return nil, err
+			}
+			return []interface{}{
+				t.Timestamp, string(t.ExchangeID), t.Symbol, t.Canonical,
+				t.TradeID, t.Price, t.Quantity, t.Side,
+				t.NotionalUSD, t.SideInferred, t.ReceivedAt,
+			}, nil
+		},
+	},
+	DatasetOrderbook: {
+		columns: []Column{
+			{"timestamp", TypeTime}, {"exchange", TypeString}, {"symbol", TypeString}, {"canonical", TypeString},
+			{"sequence_id", TypeInt}, {"is_snapshot", TypeBool}, {"best_bid", TypeFloat}, {"best_ask", TypeFloat},
+			{"bid_size", TypeFloat}, {"ask_size", TypeFloat}, {"spread_bps", TypeFloat},
+			{"bids", TypeString}, {"asks", TypeString}, {"received_at", TypeTime},
+		},
+		row: func(data []byte) ([]interface{}, error) {
+			var ob connector.Orderbook
+			if err := json.Unmarshal(data, &ob); err != nil {
+				return nil, err
+			}
+			var bidSize, askSize float64
+			if len(ob.Bids) > 0 {
+				bidSize = ob.Bids[0].Quantity
+			}
+			if len(ob.Asks) > 0 {
+				askSize = ob.Asks[0].Quantity
+			}
+			return []interface{}{
+				ob.Timestamp, string(ob.ExchangeID), ob.Symbol, ob.Canonical,
+				ob.SequenceID, ob.IsSnapshot, ob.BestBid, ob.BestAsk,
+				bidSize, askSize, ob.SpreadBps,
+				levels(ob.Bids), levels(ob.Asks), ob.ReceivedAt,
+			}, nil
+		},
+	},
+	DatasetFunding: {
+		columns: []Column{
+			{"timestamp", TypeTime}, {"exchange", TypeString}, {"symbol", TypeString}, {"canonical", TypeString},
+			{"funding_rate", TypeFloat}, {"funding_rate_8h", TypeFloat}, {"funding_apr", TypeFloat},
+			{"funding_interval_hours", TypeInt}, {"next_funding_time", TypeTime}, {"mark_price", TypeFloat},
+		},
+		row: func(data []byte) ([]interface{}, error) {
+			var fr connector.FundingRate
+			if err := json.Unmarshal(data, &fr); err != nil {
+				return nil, err
+			}
+			return []interface{}{
+				fr.Timestamp, string(fr.ExchangeID), fr.Symbol, fr.Canonical,
+				fr.FundingRate, fr.FundingRate8h, fr.FundingAPR,
+				int64(fr.FundingIntervalHours), fr.NextFundingTime, fr.MarkPrice,
+			}, nil
+		},
+	},
+	DatasetBars: {
+		columns: []Column{
+			{"start", TypeTime}, {"end", TypeTime}, {"exchange", TypeString}, {"symbol", TypeString},
+			{"canonical", TypeString}, {"interval", TypeString}, {"open", TypeFloat}, {"high", TypeFloat},
+			{"low", TypeFloat}, {"close", TypeFloat}, {"volume", TypeFloat}, {"quote_volume", TypeFloat},
+			{"buy_volume", TypeFloat}, {"vwap", TypeFloat}, {"trades", TypeInt},
+		},
+		row: func(data []byte) ([]interface{}, error) {
+			var b bars.Bar
+			if err := json.Unmarshal(data, &b); err != nil {
+				return nil, err
+			}
+			return barRow(&b), nil
+		},
+	},
+}
+
+func barRow(b *bars.Bar) []interface{} {
+	return []interface{}{
+		b.Start, b.End, string(b.Exchange), b.Symbol,
+		b.Canonical, b.Interval, b.Open, b.High,
+		b.Low, b.Close, b.Volume, b.QuoteVolume,
+		b.BuyVolume, b.VWAP, int64(b.Trades),
+	}
+}
+
+// levels renders book levels compactly as [[price,quantity],...]
+func levels(ls []connector.PriceLevel) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, l := range ls {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('[')
+		sb.WriteString(strconv.FormatFloat(l.Price, 'f', -1, 64))
+		sb.WriteByte(',')
+		sb.WriteString(strconv.FormatFloat(l.Quantity, 'f', -1, 64))
+		sb.WriteByte(']')
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// Columns returns every column of a dataset
+func Columns(name string) ([]Column, error) {
+	ds, err := lookup(name)
+	return ds.columns, err
+}
+
+func lookup(name string) (dataset, error) {
+	ds, ok := datasets[name]
+	if !ok {
+		return ds, fmt.Errorf("%w: unknown dataset %q, want trades, orderbook, funding or bars", ErrInvalidRequest, name)
+	}
+	return ds, nil
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// Exporter extracts recorded market data for researchers without access
+// to the raw store. Streams are read by entry ID, i.e. by publish time;
+// they hold only the most recent entries their MaxLen keeps.
+type Exporter struct {
+	config Config
+	client *redis.Client
+	bars   BarSource // Optional; the bars dataset is unavailable without it
+}
+
+// New creates an exporter reading from Redis
+func New(client *redis.Client, config Config) *Exporter {
+	return &Exporter{config: config, client: client}
+}
+
+// SetBarSource enables the bars dataset
+func (e *Exporter) SetBarSource(source BarSource) {
+	e.bars = source
+}
+
+// plan validates a request and returns the dataset, the index of each
+// selected column and the selected columns
+func (e *Exporter) plan(req *Request) (dataset, []int, []Column, error) {
+	ds, err := lookup(req.Dataset)
+	if err != nil {
+		return ds, nil, nil, err
+	}
+	switch req.Format {
+	case FormatCSV, FormatJSONL, FormatParquet:
+	default:
+		return ds, nil, nil, fmt.Errorf("%w: unknown format %q, want csv, jsonl or parquet", ErrInvalidRequest, req.Format)
+	}
+	if req.Exchange == "" || req.Symbol == "" {
+		return ds, nil, nil, fmt.Errorf("%w: exchange and symbol are required", ErrInvalidRequest)
+	}
+	if req.Dataset == DatasetBars {
+		if e.bars == nil {
+			return ds, nil, nil, fmt.Errorf("%w: bar history is not available", ErrInvalidRequest)
+		}
+		if req.Interval == "" {
+			return ds, nil, nil, fmt.Errorf("%w: interval is required for bars", ErrInvalidRequest)
+		}
+	}
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if !req.From.Before(req.To) {
+		return ds, nil, nil, fmt.Errorf("%w: from must be before to", ErrInvalidRequest)
+	}
+	if req.Limit <= 0 || req.Limit > e.config.MaxRows {
+		req.Limit = e.config.MaxRows
+	}
+
+	if len(req.Fields) == 0 {
+		idx := make([]int, len(ds.columns))
+		for i := range idx {
+			idx[i] = i
+		}
+		return ds, idx, ds.columns, nil
+	}
+	idx := make([]int, 0, len(req.Fields))
+	cols := make([]Column, 0, len(req.Fields))
+	for _, name := range req.Fields {
+		found := -1
+		for i, c := range ds.columns {
+			if c.Name == name {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return ds, nil, nil, fmt.Errorf("%w: %s has no field %q", ErrInvalidRequest, req.Dataset, name)
+		}
+		idx = append(idx, found)
+		cols = append(cols, ds.columns[found])
+	}
+	return ds, idx, cols, nil
+}
+
+// Validate checks a request without reading any data
+func (e *Exporter) Validate(req Request) error {
+	_, _, _, err := e.plan(&req)
+	return err
+}
+
+// Export writes the rows of a request to w in its format and returns how
+// many were written. Rows past the limit are not exported.
+func (e *Exporter) Export(ctx context.Context, req Request, w io.Writer) (int, error) {
+	ds, idx, cols, err := e.plan(&req)
+	if err != nil {
+		return 0, err
+	}
+	out := newWriter(req.Format, w, cols)
+
+	rows := 0
+	emit := func(values []interface{}) (bool, error) {
+		selected := make([]interface{}, len(idx))
+		for i, j := range idx {
+			selected[i] = values[j]
+		}
+		if err := out.Write(selected); err != nil {
+			return false, err
+		}
+		rows++
+		return rows < req.Limit, nil
+	}
+
+	if req.Dataset == DatasetBars {
+		err = e.readBars(ctx, req, emit)
+	} else {
+		err = e.readStream(ctx, req, func(data []byte) (bool, error) {
+			values, err := ds.row(data)
+			if err != nil {
+				return true, nil // Skip entries that don't decode
+			}
+			return emit(values)
+		})
+	}
+	if err != nil {
+		return rows, err
+	}
+	return rows, out.Close()
+}
+
+// streamKey returns the stream a dataset is recorded in
+func streamKey(req Request) string {
+	switch req.Dataset {
+	case DatasetTrades:
+		return keyspace.TradesKey(string(req.Exchange), req.Symbol)
+	case DatasetOrderbook:
+		return keyspace.OrderbookKey(string(req.Exchange), req.Symbol)
+	default:
+		return keyspace.FundingKey(string(req.Exchange), req.Symbol)
+	}
+}
+
+// readStream pages through a stream's entries in [from, to]
+func (e *Exporter) readStream(ctx context.Context, req Request, emit func([]byte) (bool, error)) error {
+	key := streamKey(req)
+	start := strconv.FormatInt(req.From.UnixMilli(), 10)
+	end := strconv.FormatInt(req.To.UnixMilli(), 10)
+	for {
+		entries, err := e.client.XRangeN(ctx, key, start, end, int64(e.config.BatchSize)).Result()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			data, _ := entry.Values["data"].(string)
+			more, err := emit([]byte(data))
+			if err != nil || !more {
+				return err
+			}
+		}
+		if len(entries) < e.config.BatchSize {
+			return nil
+		}
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+// readBars reads the bar history in [from, to]
+func (e *Exporter) readBars(ctx context.Context, req Request, emit func([]interface{}) (bool, error)) error {
+	bs, err := e.bars.Bars(ctx, string(req.Exchange), req.Symbol, req.Interval, req.From, req.To)
+	if err != nil {
+		return err
+	}
+	for _, b := range bs {
+		more, err := emit(barRow(b))
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseTime parses an RFC 3339 time or Unix milliseconds
+func ParseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is neither an RFC 3339 time nor Unix milliseconds", ErrInvalidRequest, s)
+	}
+	return t, nil
+}
+
+// ParseFields splits a comma-separated field list
+func ParseFields(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Filename names an export's file, e.g. trades_binance_BTCUSDT_20240131T0000_20240131T0100.csv
+func Filename(req Request) string {
+	const layout = "20060102T1504"
+	name := req.Dataset
+	if req.Dataset == DatasetBars {
+		name += "_" + req.Interval
+	}
+	return fmt.Sprintf("%s_%s_%s_%s_%s.%s", name, req.Exchange, req.Symbol,
+		req.From.UTC().Format(layout), req.To.UTC().Format(layout), req.Format)
+}
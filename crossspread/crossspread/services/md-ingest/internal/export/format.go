@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// rowWriter writes rows of values in the selected columns' order
+type rowWriter interface {
+	Write(values []interface{}) error
+	Close() error // Flushes; does not close the underlying writer
+}
+
+func newWriter(format string, w io.Writer, cols []Column) rowWriter {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, cols)
+	case FormatJSONL:
+		return newJSONLWriter(w, cols)
+	default:
+		return newParquetWriter(w, cols)
+	}
+}
+
+// formatValue renders a value as text; zero times are empty
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return ""
+}
+
+// csvWriter writes a header line and one line per row
+type csvWriter struct {
+	w      *csv.Writer
+	cols   []Column
+	header bool
+	record []string
+}
+
+func newCSVWriter(w io.Writer, cols []Column) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), cols: cols, record: make([]string, len(cols))}
+}
+
+func (c *csvWriter) writeHeader() error {
+	c.header = true
+	for i, col := range c.cols {
+		c.record[i] = col.Name
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Write(values []interface{}) error {
+	if !c.header {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	for i, v := range values {
+		c.record[i] = formatValue(v)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	if !c.header {
+		// An empty export still names its columns
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter writes one JSON object per line, keys in column order. Zero
+// times are null.
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte // Quoted names followed by a colon
+}
+
+func newJSONLWriter(w io.Writer, cols []Column) *jsonlWriter {
+	keys := make([][]byte, len(cols))
+	for i, col := range cols {
+		name, _ := json.Marshal(col.Name)
+		keys[i] = append(name, ':')
+	}
+	return &jsonlWriter{w: bufio.NewWriter(w), keys: keys}
+}
+
+func (j *jsonlWriter) Write(values []interface{}) error {
+	j.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.keys[i])
+		if t, ok := v.(time.Time); ok && t.IsZero() {
+			j.w.WriteString("null")
+			continue
+		}
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC()
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		j.w.Write(data)
+	}
+	j.w.WriteByte('}')
+	_, err := j.w.WriteString("\n")
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
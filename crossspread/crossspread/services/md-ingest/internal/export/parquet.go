@@ -0,0 +1,352 @@
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// The parquet writer covers what exports need and no more: flat schemas of
+// OPTIONAL columns, PLAIN encoding, one uncompressed data page per column
+// chunk. Readers such as pandas, DuckDB and Spark load it directly.
+
+const parquetMagic = "PAR1"
+
+// Row groups are cut at this many rows or buffered bytes, whichever first
+const (
+	rowGroupRows  = 65536
+	rowGroupBytes = 32 << 20
+)
+
+// Parquet enums (parquet.thrift)
+const (
+	pqBoolean   = 0
+	pqInt64     = 2
+	pqDouble    = 5
+	pqByteArray = 6
+
+	pqOptional = 1
+
+	pqUTF8            = 0
+	pqTimestampMicros = 10
+
+	pqPlain = 0
+	pqRLE   = 3
+
+	pqDataPage = 0
+)
+
+// parquetColumn buffers a row group's values of one column
+type parquetColumn struct {
+	col     Column
+	defined []bool // False for nulls
+	values  []byte // PLAIN-encoded non-null values
+	bools   []bool // Boolean values; bit-packed at flush
+}
+
+func (c *parquetColumn) physicalType() int32 {
+	switch c.col.Type {
+	case TypeBool:
+		return pqBoolean
+	case TypeFloat:
+		return pqDouble
+	case TypeString:
+		return pqByteArray
+	default:
+		return pqInt64
+	}
+}
+
+func (c *parquetColumn) add(v interface{}) {
+	switch v := v.(type) {
+	case time.Time:
+		if v.IsZero() {
+			c.defined = append(c.defined, false)
+			return
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, uint64(v.UnixMicro()))
+	case int64:
+		c.values = binary.LittleEndian.AppendUint64(c.values, uint64(v))
+	case float64:
+		c.values = binary.LittleEndian.AppendUint64(c.values, math.Float64bits(v))
+	case string:
+		c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(v)))
+		c.values = append(c.values, v...)
+	case bool:
+		c.bools = append(c.bools, v)
+	}
+	c.defined = append(c.defined, true)
+}
+
+// page returns the column's data page body: definition levels, then values
+func (c *parquetColumn) page() []byte {
+	// Definition levels as bit-packed runs of width 1, length-prefixed
+	levels := binary.AppendUvarint(nil, uint64((len(c.defined)+7)/8)<<1|1)
+	levels = append(levels, packBits(c.defined)...)
+
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	body = append(body, levels...)
+	if c.col.Type == TypeBool {
+		return append(body, packBits(c.bools)...)
+	}
+	return append(body, c.values...)
+}
+
+func (c *parquetColumn) reset() {
+	c.defined = c.defined[:0]
+	c.values = c.values[:0]
+	c.bools = c.bools[:0]
+}
+
+// packBits packs booleans LSB first, padding the last byte
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+type chunkMeta struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type rowGroupMeta struct {
+	rows   int64
+	size   int64
+	chunks []chunkMeta
+}
+
+// parquetWriter buffers rows into row groups and writes the footer on Close
+type parquetWriter struct {
+	w       *bufio.Writer
+	offset  int64
+	err     error
+	cols    []*parquetColumn
+	rows    int64 // Rows buffered in the current row group
+	total   int64
+	buffer  int
+	written []rowGroupMeta
+}
+
+func newParquetWriter(w io.Writer, cols []Column) *parquetWriter {
+	p := &parquetWriter{w: bufio.NewWriter(w)}
+	for _, col := range cols {
+		p.cols = append(p.cols, &parquetColumn{col: col})
+	}
+	p.write([]byte(parquetMagic))
+	return p
+}
+
+func (p *parquetWriter) write(b []byte) {
+	if p.err != nil {
+		return
+	}
+	var n int
+	n, p.err = p.w.Write(b)
+	p.offset += int64(n)
+}
+
+func (p *parquetWriter) Write(values []interface{}) error {
+	for i, v := range values {
+		c := p.cols[i]
+		before := len(c.values)
+		c.add(v)
+		p.buffer += len(c.values) - before
+	}
+	p.rows++
+	p.total++
+	if p.rows >= rowGroupRows || p.buffer >= rowGroupBytes {
+		p.flush()
+	}
+	return p.err
+}
+
+// flush writes the buffered rows as a row group
+func (p *parquetWriter) flush() {
+	if p.rows == 0 {
+		return
+	}
+	rg := rowGroupMeta{rows: p.rows}
+	for _, c := range p.cols {
+		body := c.page()
+		var header thriftWriter
+		header.i32(1, pqDataPage)
+		header.i32(2, int32(len(body)))
+		header.i32(3, int32(len(body)))
+		header.begin(5)
+		header.i32(1, int32(len(c.defined)))
+		header.i32(2, pqPlain)
+		header.i32(3, pqRLE)
+		header.i32(4, pqRLE)
+		header.end()
+		header.stop()
+
+		chunk := chunkMeta{offset: p.offset, values: int64(len(c.defined))}
+		p.write(header.buf)
+		p.write(body)
+		chunk.size = p.offset - chunk.offset
+		rg.size += chunk.size
+		rg.chunks = append(rg.chunks, chunk)
+		c.reset()
+	}
+	p.written = append(p.written, rg)
+	p.rows, p.buffer = 0, 0
+}
+
+func (p *parquetWriter) Close() error {
+	p.flush()
+
+	var t thriftWriter
+	t.i32(1, 1) // version
+	t.list(2, thriftStruct, len(p.cols)+1)
+	t.listStruct()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(p.cols)))
+	t.endStruct()
+	for _, c := range p.cols {
+		t.listStruct()
+		t.i32(1, c.physicalType())
+		t.i32(3, pqOptional)
+		t.binary(4, c.col.Name)
+		switch c.col.Type {
+		case TypeString:
+			t.i32(6, pqUTF8)
+		case TypeTime:
+			t.i32(6, pqTimestampMicros)
+		}
+		t.endStruct()
+	}
+	t.i64(3, p.total)
+	t.list(4, thriftStruct, len(p.written))
+	for _, rg := range p.written {
+		t.listStruct()
+		t.list(1, thriftStruct, len(rg.chunks))
+		for i, chunk := range rg.chunks {
+			c := p.cols[i]
+			t.listStruct()
+			t.i64(2, chunk.offset)
+			t.begin(3)
+			t.i32(1, c.physicalType())
+			t.list(2, thriftI32, 2)
+			t.listI32(pqPlain)
+			t.listI32(pqRLE)
+			t.list(3, thriftBinary, 1)
+			t.listBinary(c.col.Name)
+			t.i32(4, 0) // Uncompressed
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.endStruct()
+		}
+		t.i64(2, rg.size)
+		t.i64(3, rg.rows)
+		t.endStruct()
+	}
+	t.binary(6, "crossspread md-ingest")
+	t.stop()
+
+	p.write(t.buf)
+	p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(t.buf))))
+	p.write([]byte(parquetMagic))
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+// Thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the structs of the parquet footer and page headers
+// in the Thrift compact protocol
+type thriftWriter struct {
+	buf   []byte
+	last  int16   // Last field ID written in the current struct
+	stack []int16 // Last field IDs of enclosing structs
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.listBinary(s)
+}
+
+// begin opens a struct field; end closes it
+func (t *thriftWriter) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.listStruct()
+}
+
+func (t *thriftWriter) end() {
+	t.endStruct()
+}
+
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+// listStruct opens a struct element of a list; endStruct closes it
+func (t *thriftWriter) listStruct() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) listBinary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// stop ends the current struct's fields
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
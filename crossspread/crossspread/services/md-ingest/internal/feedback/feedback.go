@@ -0,0 +1,342 @@
+package feedback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidTag is returned for tags that cannot be attributed or lack a
+// known reason
+var ErrInvalidTag = errors.New("feedback: invalid tag")
+
+// Reasons an opportunity was a false positive
+const (
+	ReasonStale        = "stale"         // A leg's quote was gone by the time it was hit
+	ReasonUnexecutable = "unexecutable"  // The quoted price or size could not be traded
+	ReasonWrongMapping = "wrong_mapping" // The legs are different instruments
+	ReasonOther        = "other"
+)
+
+// Reasons returns every reason, in the order the quality summary lists them
+func Reasons() []string {
+	return []string{ReasonStale, ReasonUnexecutable, ReasonWrongMapping, ReasonOther}
+}
+
+// Config controls how tags weigh on data quality
+type Config struct {
+	Window time.Duration // Tags older than this no longer count
+	// HalfAt is the number of tags in the window at which a venue symbol's
+	// quality falls to one half
+	HalfAt float64
+	// MinQuality bounds the penalty: a venue symbol's spreads need at most
+	// 1/MinQuality times the usual spread
+	MinQuality float64
+}
+
+// DefaultConfig halves a venue symbol's quality at five tags in a week and
+// never more than quadruples its threshold
+func DefaultConfig() Config {
+	return Config{
+		Window:     7 * 24 * time.Hour,
+		HalfAt:     5,
+		MinQuality: 0.25,
+	}
+}
+
+// Tag marks a published opportunity as a false positive
+type Tag struct {
+	OpportunityID string                 `json:"opportunity_id"` // Spread or basis ID as published
+	Canonical     string                 `json:"canonical"`
+	Exchanges     []connector.ExchangeID `json:"exchanges"` // Venues of the legs; taken from the ID when empty
+	Reason        string                 `json:"reason"`    // stale, unexecutable, wrong_mapping, other
+	Source        string                 `json:"source"`    // Executor or operator that tagged it
+	Note          string                 `json:"note,omitempty"`
+	At            time.Time              `json:"at"`
+}
+
+// VenueSymbol is the tag count and quality of one venue's symbol
+type VenueSymbol struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Canonical string               `json:"canonical"`
+	Tags      int                  `json:"tags"`
+	ByReason  map[string]int       `json:"by_reason"`
+	Quality   float64              `json:"quality"` // 1 untagged, falling toward min_quality with tags
+	LastAt    time.Time            `json:"last_at"`
+}
+
+type venueSymbolKey struct {
+	exchange  connector.ExchangeID
+	canonical string
+}
+
+// Store records false positive tags from executors and operators and turns
+// them into a data-quality score per venue symbol. Discovery asks for a
+// wider spread on low-quality symbols and ranks their spreads lower, so
+// venues that keep producing phantom opportunities stop crowding out real
+// ones. Tags are kept in a Redis stream and reloaded on restart.
+type Store struct {
+	client *redis.Client
+	config Config
+
+	mu      sync.RWMutex
+	tags    []Tag // Oldest first, within the window
+	quality map[venueSymbolKey]float64
+}
+
+// New creates a store
+func New(client *redis.Client, config Config) *Store {
+	return &Store{
+		client:  client,
+		config:  config,
+		quality: make(map[venueSymbolKey]float64),
+	}
+}
+
+// Load reads the tags within the window back from Redis
+func (s *Store) Load(ctx context.Context) error {
+	tags, err := s.Tags(ctx, time.Now().Add(-s.config.Window))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.tags = tags
+	s.recompute(time.Now())
+	s.mu.Unlock()
+	return nil
+}
+
+// Tags reads the tags recorded since a time from Redis, oldest first
+func (s *Store) Tags(ctx context.Context, since time.Time) ([]Tag, error) {
+	start := strconv.FormatInt(since.UnixMilli(), 10)
+	entries, err := s.client.XRange(ctx, keyspace.Key(keyspace.FalsePositivesKey), start, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, 0, len(entries))
+	for _, e := range entries {
+		data, _ := e.Values["data"].(string)
+		var tag Tag
+		if err := json.Unmarshal([]byte(data), &tag); err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// Start prunes tags past the window every minute until ctx is done
+func (s *Store) Start(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			s.recompute(now)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Add validates and records a tag. The canonical symbol and venues are
+// filled in from a spread ID (canonical:long:short) or basis ID
+// (canonical:exchange:direction) when not given.
+func (s *Store) Add(ctx context.Context, tag Tag) (Tag, error) {
+	tag, err := normalize(tag)
+	if err != nil {
+		return tag, err
+	}
+	if tag.At.IsZero() {
+		tag.At = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return tag, err
+	}
+	if err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: keyspace.Key(keyspace.FalsePositivesKey),
+		MaxLen: keyspace.FalsePositivesMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": string(data)},
+	}).Err(); err != nil {
+		return tag, err
+	}
+
+	for _, exchange := range tag.Exchanges {
+		metrics.FalsePositiveTags.WithLabelValues(string(exchange), tag.Reason).Inc()
+	}
+	log.Info().
+		Str("opportunity", tag.OpportunityID).
+		Str("reason", tag.Reason).
+		Str("source", tag.Source).
+		Msg("Opportunity tagged as false positive")
+
+	s.mu.Lock()
+	s.tags = append(s.tags, tag)
+	s.recompute(time.Now())
+	s.mu.Unlock()
+	return tag, nil
+}
+
+func normalize(tag Tag) (Tag, error) {
+	valid := false
+	for _, r := range Reasons() {
+		valid = valid || tag.Reason == r
+	}
+	if !valid {
+		return tag, fmt.Errorf("%w: reason must be one of %s", ErrInvalidTag, strings.Join(Reasons(), ", "))
+	}
+	if tag.OpportunityID == "" {
+		return tag, fmt.Errorf("%w: opportunity_id is required", ErrInvalidTag)
+	}
+
+	parts := strings.Split(tag.OpportunityID, ":")
+	if tag.Canonical == "" {
+		tag.Canonical = parts[0]
+	}
+	tag.Canonical = strings.ToUpper(tag.Canonical)
+	if len(tag.Exchanges) == 0 {
+		for _, part := range parts[1:] {
+			if id := connector.ExchangeID(part); known(id) {
+				tag.Exchanges = append(tag.Exchanges, id)
+			}
+		}
+	}
+	if len(tag.Exchanges) == 0 {
+		return tag, fmt.Errorf("%w: no venue in %q; give exchanges", ErrInvalidTag, tag.OpportunityID)
+	}
+	return tag, nil
+}
+
+func known(id connector.ExchangeID) bool {
+	for _, e := range connector.Exchanges() {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+// recompute drops tags past the window and rebuilds the quality of every
+// tagged venue symbol. Caller holds s.mu.
+func (s *Store) recompute(now time.Time) {
+	cutoff := now.Add(-s.config.Window)
+	i := sort.Search(len(s.tags), func(i int) bool { return !s.tags[i].At.Before(cutoff) })
+	s.tags = s.tags[i:]
+
+	counts := make(map[venueSymbolKey]int)
+	for _, tag := range s.tags {
+		for _, exchange := range tag.Exchanges {
+			counts[venueSymbolKey{exchange, tag.Canonical}]++
+		}
+	}
+	s.quality = make(map[venueSymbolKey]float64, len(counts))
+	for k, n := range counts {
+		s.quality[k] = s.score(n)
+	}
+}
+
+// score maps a tag count to quality: 1 at none, one half at HalfAt,
+// never below MinQuality
+func (s *Store) score(n int) float64 {
+	q := 1 / (1 + float64(n)/s.config.HalfAt)
+	if q < s.config.MinQuality {
+		return s.config.MinQuality
+	}
+	return q
+}
+
+// Quality returns a venue symbol's data-quality score in [MinQuality, 1]
+func (s *Store) Quality(exchange connector.ExchangeID, canonical string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if q, ok := s.quality[venueSymbolKey{exchange, canonical}]; ok {
+		return q
+	}
+	return 1
+}
+
+// Recent returns up to limit tags within the window, newest first,
+// optionally for one venue or canonical symbol
+func (s *Store) Recent(exchange connector.ExchangeID, canonical string, limit int) []Tag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]Tag, 0)
+	for i := len(s.tags) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		tag := s.tags[i]
+		if canonical != "" && tag.Canonical != strings.ToUpper(canonical) {
+			continue
+		}
+		if exchange != "" && !hasExchange(tag, exchange) {
+			continue
+		}
+		result = append(result, tag)
+	}
+	return result
+}
+
+func hasExchange(tag Tag, exchange connector.ExchangeID) bool {
+	for _, e := range tag.Exchanges {
+		if e == exchange {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary aggregates the tags within the window per venue symbol, most
+// tagged first
+func (s *Store) Summary() []VenueSymbol {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byKey := make(map[venueSymbolKey]*VenueSymbol)
+	for _, tag := range s.tags {
+		for _, exchange := range tag.Exchanges {
+			k := venueSymbolKey{exchange, tag.Canonical}
+			vs := byKey[k]
+			if vs == nil {
+				vs = &VenueSymbol{Exchange: exchange, Canonical: tag.Canonical, ByReason: make(map[string]int)}
+				byKey[k] = vs
+			}
+			vs.Tags++
+			vs.ByReason[tag.Reason]++
+			if tag.At.After(vs.LastAt) {
+				vs.LastAt = tag.At
+			}
+		}
+	}
+
+	result := make([]VenueSymbol, 0, len(byKey))
+	for k, vs := range byKey {
+		vs.Quality = s.quality[k]
+		result = append(result, *vs)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tags != result[j].Tags {
+			return result[i].Tags > result[j].Tags
+		}
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Canonical < result[j].Canonical
+	})
+	return result
+}
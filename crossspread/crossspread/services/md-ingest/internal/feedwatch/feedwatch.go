@@ -0,0 +1,341 @@
+package feedwatch
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/publisher"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Channels watched per exchange
+const (
+	ChannelOrderbook = "orderbook"
+	ChannelTrade     = "trade"
+)
+
+// Alert kinds
+const (
+	KindSilent    = "silent"    // Rate fell far below its baseline while connected
+	KindRecovered = "recovered" // Rate came back after a silent alert
+)
+
+// maxAlerts is the number of alerts kept for the admin API
+const maxAlerts = 500
+
+// Config controls the baselines and when a drop is anomalous
+type Config struct {
+	Interval     time.Duration // Length of each rate sample
+	HalfLife     time.Duration // Half-life of the baseline's moving average
+	Warmup       time.Duration // Sampling before a baseline is trusted
+	DropRatio    float64       // A rate below this share of the baseline is anomalous
+	RecoverRatio float64       // A silent feed recovers at this share of the baseline
+	Consecutive  int           // Anomalous samples in a row before alerting
+	MinRate      float64       // Feeds with a baseline under this many messages per second are not judged
+}
+
+// DefaultConfig alerts when a feed runs below a fifth of its baseline for
+// 30 seconds, after five minutes of learning
+func DefaultConfig() Config {
+	return Config{
+		Interval:     10 * time.Second,
+		HalfLife:     30 * time.Minute,
+		Warmup:       5 * time.Minute,
+		DropRatio:    0.2,
+		RecoverRatio: 0.5,
+		Consecutive:  3,
+		MinRate:      0.5,
+	}
+}
+
+// Alert is a silent feed or its recovery
+type Alert struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Channel  string               `json:"channel"` // orderbook, trade
+	Kind     string               `json:"kind"`    // silent, recovered
+	Rate     float64              `json:"rate"`    // Messages per second over the last sample
+	Baseline float64              `json:"baseline"`
+	Symbols  int                  `json:"symbols,omitempty"` // Subscribed symbols the rates are for
+	Since    time.Time            `json:"since"`             // When the feed went silent
+	At       time.Time            `json:"at"`
+}
+
+// Status is a feed's rate against its baseline
+type Status struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Channel   string               `json:"channel"`
+	Rate      float64              `json:"rate"`
+	Baseline  float64              `json:"baseline"`
+	Learning  bool                 `json:"learning"` // Still warming up; not judged
+	Connected bool                 `json:"connected"`
+	Silent    bool                 `json:"silent"`
+	Since     time.Time            `json:"since"` // Start of the last silence
+}
+
+type feedKey struct {
+	exchange connector.ExchangeID
+	channel  string
+}
+
+type feed struct {
+	count atomic.Int64 // Messages since the last sample
+
+	rate      float64 // Per symbol when a symbol source is set
+	baseline  float64
+	learned   time.Duration
+	low       int // Anomalous samples in a row
+	connected bool
+	silent    bool
+	since     time.Time
+}
+
+// Watchdog learns a baseline message rate per exchange and channel and
+// alerts when a feed drops far below it while its connection still reports
+// connected: the silent WebSocket death that a staleness check only
+// catches once every message has stopped. With a symbol source, rates are
+// per subscribed symbol so subscription changes don't look like drops.
+type Watchdog struct {
+	config    Config
+	publisher *publisher.RedisPublisher
+	connected map[connector.ExchangeID]func() bool
+	symbols   func() map[connector.ExchangeID][]string
+
+	mu     sync.RWMutex
+	feeds  map[feedKey]*feed
+	alerts []Alert
+	done   chan struct{}
+}
+
+// New creates a watchdog over the connectors' feeds
+func New(connectors []connector.Connector, config Config, pub *publisher.RedisPublisher) *Watchdog {
+	connected := make(map[connector.ExchangeID]func() bool, len(connectors))
+	for _, conn := range connectors {
+		connected[conn.ID()] = conn.IsConnected
+	}
+	return &Watchdog{
+		config:    config,
+		publisher: pub,
+		connected: connected,
+		feeds:     make(map[feedKey]*feed),
+		done:      make(chan struct{}),
+	}
+}
+
+// SetSymbolSource sets the subscribed symbols per exchange
+func (w *Watchdog) SetSymbolSource(fn func() map[connector.ExchangeID][]string) {
+	w.symbols = fn
+}
+
+// HandleOrderbook counts an orderbook update
+func (w *Watchdog) HandleOrderbook(ob *connector.Orderbook) {
+	w.count(ob.ExchangeID, ChannelOrderbook)
+}
+
+// HandleTrade counts a trade
+func (w *Watchdog) HandleTrade(trade *connector.Trade) {
+	w.count(trade.ExchangeID, ChannelTrade)
+}
+
+func (w *Watchdog) count(exchange connector.ExchangeID, channel string) {
+	k := feedKey{exchange, channel}
+	w.mu.RLock()
+	f := w.feeds[k]
+	w.mu.RUnlock()
+	if f == nil {
+		w.mu.Lock()
+		if f = w.feeds[k]; f == nil {
+			f = &feed{}
+			w.feeds[k] = f
+		}
+		w.mu.Unlock()
+	}
+	f.count.Add(1)
+}
+
+// Start samples the feeds until the context is cancelled or Stop is called
+func (w *Watchdog) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case now := <-ticker.C:
+			for _, alert := range w.Check(now, now.Sub(last)) {
+				w.publish(alert)
+			}
+			last = now
+		}
+	}
+}
+
+// Stop stops the watchdog
+func (w *Watchdog) Stop() {
+	close(w.done)
+}
+
+// Check takes a rate sample covering elapsed from every feed and returns
+// the alerts it raises
+func (w *Watchdog) Check(now time.Time, elapsed time.Duration) []Alert {
+	if elapsed <= 0 {
+		return nil
+	}
+	var symbols map[connector.ExchangeID][]string
+	if w.symbols != nil {
+		symbols = w.symbols()
+	}
+	alpha := 1 - math.Pow(0.5, float64(elapsed)/float64(w.config.HalfLife))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var raised []Alert
+	for k, f := range w.feeds {
+		rate := float64(f.count.Swap(0)) / elapsed.Seconds()
+		n := 0
+		if symbols != nil {
+			if n = len(symbols[k.exchange]); n == 0 {
+				// Nothing subscribed; the feed is expected to be quiet
+				f.low, f.silent = 0, false
+				continue
+			}
+			rate /= float64(n)
+		}
+		f.rate = rate
+		connected := w.connected[k.exchange]
+		f.connected = connected == nil || connected()
+		metrics.FeedRate.WithLabelValues(string(k.exchange), k.channel).Set(rate)
+
+		if !f.connected {
+			// Disconnects are handled by reconnects, not this watchdog
+			f.low = 0
+			continue
+		}
+
+		switch {
+		case f.silent && rate >= w.config.RecoverRatio*f.baseline:
+			f.silent, f.low = false, 0
+			raised = append(raised, w.alert(k, f, KindRecovered, n, now))
+		case f.silent:
+		case f.learned >= w.config.Warmup && f.baseline*max(float64(n), 1) >= w.config.MinRate && rate < w.config.DropRatio*f.baseline:
+			// The baseline is frozen while the feed looks anomalous
+			if f.low++; f.low >= w.config.Consecutive {
+				f.silent, f.since = true, now.Add(-time.Duration(f.low)*elapsed)
+				raised = append(raised, w.alert(k, f, KindSilent, n, now))
+			}
+		default:
+			f.low = 0
+			if f.learned == 0 {
+				f.baseline = rate
+			} else {
+				f.baseline += alpha * (rate - f.baseline)
+			}
+			f.learned += elapsed
+		}
+
+		silent := 0.0
+		if f.silent {
+			silent = 1
+		}
+		metrics.FeedBaseline.WithLabelValues(string(k.exchange), k.channel).Set(f.baseline)
+		metrics.FeedSilent.WithLabelValues(string(k.exchange), k.channel).Set(silent)
+	}
+
+	w.alerts = append(w.alerts, raised...)
+	if len(w.alerts) > maxAlerts {
+		w.alerts = w.alerts[len(w.alerts)-maxAlerts:]
+	}
+	return raised
+}
+
+func (w *Watchdog) alert(k feedKey, f *feed, kind string, symbols int, now time.Time) Alert {
+	return Alert{
+		Exchange: k.exchange,
+		Channel:  k.channel,
+		Kind:     kind,
+		Rate:     math.Round(f.rate*1000) / 1000,
+		Baseline: math.Round(f.baseline*1000) / 1000,
+		Symbols:  symbols,
+		Since:    f.since,
+		At:       now,
+	}
+}
+
+func (w *Watchdog) publish(alert Alert) {
+	metrics.FeedAlerts.WithLabelValues(string(alert.Exchange), alert.Channel, alert.Kind).Inc()
+	event := log.Warn()
+	msg := "Feed rate collapsed while connected, feed may be silently dead"
+	if alert.Kind == KindRecovered {
+		event = log.Info()
+		msg = "Feed rate recovered"
+	}
+	event.
+		Str("exchange", string(alert.Exchange)).
+		Str("channel", alert.Channel).
+		Float64("rate", alert.Rate).
+		Float64("baseline", alert.Baseline).
+		Time("since", alert.Since).
+		Msg(msg)
+
+	if w.publisher == nil {
+		return
+	}
+	data, err := json.Marshal(alert)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal feed alert")
+		return
+	}
+	if err := w.publisher.PublishFeedAlert(data); err != nil {
+		log.Error().Err(err).Msg("Failed to publish feed alert")
+		metrics.RedisPublishErrors.WithLabelValues("feed_alert").Inc()
+	}
+}
+
+// Feeds returns every feed's rate against its baseline, sorted by exchange
+// and channel
+func (w *Watchdog) Feeds() []Status {
+	w.mu.RLock()
+	result := make([]Status, 0, len(w.feeds))
+	for k, f := range w.feeds {
+		result = append(result, Status{
+			Exchange:  k.exchange,
+			Channel:   k.channel,
+			Rate:      f.rate,
+			Baseline:  f.baseline,
+			Learning:  f.learned < w.config.Warmup,
+			Connected: f.connected,
+			Silent:    f.silent,
+			Since:     f.since,
+		})
+	}
+	w.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Channel < result[j].Channel
+	})
+	return result
+}
+
+// Alerts returns recent alerts, newest first
+func (w *Watchdog) Alerts() []Alert {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	result := make([]Alert, len(w.alerts))
+	for i, a := range w.alerts {
+		result[len(w.alerts)-1-i] = a
+	}
+	return result
+}
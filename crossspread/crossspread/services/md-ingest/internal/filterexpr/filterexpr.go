@@ -0,0 +1,488 @@
+// Package filterexpr compiles small boolean expressions over named fields,
+// e.g. spread_bps > 8 && min_liquidity_usd > 50000 && long_exchange != "lbank".
+//
+// Expressions support numbers, strings, true and false, lists of strings
+// ["okx", "bybit"], arithmetic (+ - * /), comparisons (== != < <= > >=),
+// membership (in), negation (!) and the logical operators && and ||, with
+// parentheses for grouping. Fields and operators are type-checked when the
+// expression is compiled, so evaluation cannot fail.
+package filterexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxLength bounds an expression's source
+const MaxLength = 4096
+
+// Type is the type of a field or subexpression
+type Type int
+
+// Types
+const (
+	Number Type = iota + 1
+	String
+	Bool
+	List // List of strings
+)
+
+func (t Type) String() string {
+	switch t {
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Bool:
+		return "bool"
+	case List:
+		return "list"
+	}
+	return "unknown"
+}
+
+// Value is a field's value. Only the member matching its type is read.
+type Value struct {
+	Num  float64
+	Str  string
+	Bool bool
+	List []string
+}
+
+// Env resolves fields while an expression is evaluated
+type Env interface {
+	Lookup(name string) Value
+}
+
+// Schema declares the fields an expression may use and their types
+type Schema map[string]Type
+
+// Expr is a compiled expression
+type Expr struct {
+	src  string
+	root node
+}
+
+type node struct {
+	typ  Type
+	eval func(Env) Value
+}
+
+// Compile parses and type-checks src against the schema. The expression
+// must be boolean.
+func Compile(src string, schema Schema) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("filterexpr: expression longer than %d bytes", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, schema: schema}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	if root.typ != Bool {
+		return nil, fmt.Errorf("filterexpr: expression is %s, want bool", root.typ)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Match evaluates the expression
+func (e *Expr) Match(env Env) bool {
+	return e.root.eval(env).Bool
+}
+
+// String returns the expression's source
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Lexer
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	num  float64
+	pos  int // Byte offset in the source
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == '_' ||
+				src[i] == 'e' || src[i] == 'E' || (src[i] == '-' || src[i] == '+') && (src[i-1] == 'e' || src[i-1] == 'E')) {
+				i++
+			}
+			v, err := strconv.ParseFloat(strings.ReplaceAll(src[start:i], "_", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("filterexpr: at %d: invalid number %q", start, src[start:i])
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: v, pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return nil, fmt.Errorf("filterexpr: at %d: unterminated string", start)
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokString, text: b.String(), pos: start})
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(rune(src[i])) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("filterexpr: at %d: unexpected character %q", i, c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// Parser, by precedence: || then && then comparisons then + - then * /
+// then unary ! and -
+
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+	depth  int
+}
+
+// maxDepth bounds nesting so a hostile expression cannot exhaust the stack
+const maxDepth = 64
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind == tokOp {
+		for _, op := range ops {
+			if t.text == op {
+				return true
+			}
+		}
+	}
+	return t.kind == tokIdent && len(ops) == 1 && ops[0] == "in" && t.text == "in"
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("filterexpr: at %d: %s", t.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for p.isOp("||") {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != Bool || right.typ != Bool {
+			return node{}, p.errorf(op, "|| needs bool operands, have %s and %s", left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		left = node{typ: Bool, eval: func(env Env) Value { return Value{Bool: l(env).Bool || r(env).Bool} }}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return left, err
+	}
+	for p.isOp("&&") {
+		op := p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != Bool || right.typ != Bool {
+			return node{}, p.errorf(op, "&& needs bool operands, have %s and %s", left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		left = node{typ: Bool, eval: func(env Env) Value { return Value{Bool: l(env).Bool && r(env).Bool} }}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return left, err
+	}
+	if !p.isOp("==", "!=", "<", "<=", ">", ">=") && !p.isOp("in") {
+		return left, nil
+	}
+	op := p.next()
+	right, err := p.parseAdditive()
+	if err != nil {
+		return right, err
+	}
+	l, r := left.eval, right.eval
+
+	if op.text == "in" {
+		if left.typ != String || right.typ != List {
+			return node{}, p.errorf(op, "in needs a string and a list, have %s and %s", left.typ, right.typ)
+		}
+		return node{typ: Bool, eval: func(env Env) Value {
+			s := l(env).Str
+			for _, item := range r(env).List {
+				if item == s {
+					return Value{Bool: true}
+				}
+			}
+			return Value{}
+		}}, nil
+	}
+
+	if left.typ != right.typ {
+		return node{}, p.errorf(op, "cannot compare %s with %s", left.typ, right.typ)
+	}
+	switch left.typ {
+	case Number:
+		cmp := map[string]func(a, b float64) bool{
+			"==": func(a, b float64) bool { return a == b },
+			"!=": func(a, b float64) bool { return a != b },
+			"<":  func(a, b float64) bool { return a < b },
+			"<=": func(a, b float64) bool { return a <= b },
+			">":  func(a, b float64) bool { return a > b },
+			">=": func(a, b float64) bool { return a >= b },
+		}[op.text]
+		return node{typ: Bool, eval: func(env Env) Value { return Value{Bool: cmp(l(env).Num, r(env).Num)} }}, nil
+	case String:
+		cmp := map[string]func(a, b string) bool{
+			"==": func(a, b string) bool { return a == b },
+			"!=": func(a, b string) bool { return a != b },
+			"<":  func(a, b string) bool { return a < b },
+			"<=": func(a, b string) bool { return a <= b },
+			">":  func(a, b string) bool { return a > b },
+			">=": func(a, b string) bool { return a >= b },
+		}[op.text]
+		return node{typ: Bool, eval: func(env Env) Value { return Value{Bool: cmp(l(env).Str, r(env).Str)} }}, nil
+	case Bool:
+		switch op.text {
+		case "==":
+			return node{typ: Bool, eval: func(env Env) Value { return Value{Bool: l(env).Bool == r(env).Bool} }}, nil
+		case "!=":
+			return node{typ: Bool, eval: func(env Env) Value { return Value{Bool: l(env).Bool != r(env).Bool} }}, nil
+		}
+	}
+	return node{}, p.errorf(op, "cannot apply %s to %s", op.text, left.typ)
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return left, err
+	}
+	for p.isOp("+", "-") {
+		op := p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != Number || right.typ != Number {
+			return node{}, p.errorf(op, "%s needs numbers, have %s and %s", op.text, left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		if op.text == "+" {
+			left = node{typ: Number, eval: func(env Env) Value { return Value{Num: l(env).Num + r(env).Num} }}
+		} else {
+			left = node{typ: Number, eval: func(env Env) Value { return Value{Num: l(env).Num - r(env).Num} }}
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+	for p.isOp("*", "/") {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != Number || right.typ != Number {
+			return node{}, p.errorf(op, "%s needs numbers, have %s and %s", op.text, left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		if op.text == "*" {
+			left = node{typ: Number, eval: func(env Env) Value { return Value{Num: l(env).Num * r(env).Num} }}
+		} else {
+			// Division by zero yields ±Inf or NaN; NaN compares false
+			left = node{typ: Number, eval: func(env Env) Value { return Value{Num: l(env).Num / r(env).Num} }}
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if !p.isOp("!", "-") {
+		return p.parsePrimary()
+	}
+	op := p.next()
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return node{}, p.errorf(op, "expression nested too deeply")
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return operand, err
+	}
+	f := operand.eval
+	if op.text == "!" {
+		if operand.typ != Bool {
+			return node{}, p.errorf(op, "! needs a bool, have %s", operand.typ)
+		}
+		return node{typ: Bool, eval: func(env Env) Value { return Value{Bool: !f(env).Bool} }}, nil
+	}
+	if operand.typ != Number {
+		return node{}, p.errorf(op, "- needs a number, have %s", operand.typ)
+	}
+	return node{typ: Number, eval: func(env Env) Value { return Value{Num: -f(env).Num} }}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		v := Value{Num: t.num}
+		return node{typ: Number, eval: func(Env) Value { return v }}, nil
+
+	case tokString:
+		v := Value{Str: t.text}
+		return node{typ: String, eval: func(Env) Value { return v }}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			v := Value{Bool: t.text == "true"}
+			return node{typ: Bool, eval: func(Env) Value { return v }}, nil
+		}
+		typ, ok := p.schema[t.text]
+		if !ok {
+			return node{}, p.errorf(t, "unknown field %q", t.text)
+		}
+		name := t.text
+		return node{typ: typ, eval: func(env Env) Value { return env.Lookup(name) }}, nil
+
+	case tokOp:
+		switch t.text {
+		case "(":
+			p.depth++
+			defer func() { p.depth-- }()
+			if p.depth > maxDepth {
+				return node{}, p.errorf(t, "expression nested too deeply")
+			}
+			inner, err := p.parseOr()
+			if err != nil {
+				return inner, err
+			}
+			if closing := p.next(); closing.kind != tokOp || closing.text != ")" {
+				return node{}, p.errorf(closing, "expected \")\", found %s", closing)
+			}
+			return inner, nil
+
+		case "[":
+			var items []string
+			for !p.isOp("]") {
+				item := p.next()
+				if item.kind != tokString {
+					return node{}, p.errorf(item, "list items must be strings, found %s", item)
+				}
+				items = append(items, item.text)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+			if closing := p.next(); closing.kind != tokOp || closing.text != "]" {
+				return node{}, p.errorf(closing, "expected \"]\", found %s", closing)
+			}
+			v := Value{List: items}
+			return node{typ: List, eval: func(Env) Value { return v }}, nil
+		}
+	}
+	return node{}, p.errorf(t, "unexpected %s", t)
+}
@@ -0,0 +1,92 @@
+package filterexpr
+
+import (
+	"testing"
+)
+
+type mapEnv map[string]Value
+
+func (m mapEnv) Lookup(name string) Value {
+	return m[name]
+}
+
+var testSchema = Schema{
+	"spread_bps":        Number,
+	"min_liquidity_usd": Number,
+	"long_exchange":     String,
+	"quote_only":        Bool,
+	"tags":              List,
+}
+
+var testEnv = mapEnv{
+	"spread_bps":        {Num: 12},
+	"min_liquidity_usd": {Num: 80000},
+	"long_exchange":     {Str: "okx"},
+	"quote_only":        {Bool: false},
+	"tags":              {List: []string{"new_listing"}},
+}
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want bool
+	}{
+		{`spread_bps > 8 && min_liquidity_usd > 50000 && long_exchange != "lbank"`, true},
+		{`spread_bps > 8 && min_liquidity_usd > 100_000`, false},
+		{`spread_bps >= 12 || long_exchange == "lbank"`, true},
+		{`!quote_only && long_exchange in ["okx", 'bybit']`, true},
+		{`"new_listing" in tags`, true},
+		{`"reduces_inventory" in tags`, false},
+		{`(spread_bps - 2) * 2 == 20`, true},
+		{`-spread_bps < 0 && spread_bps / 0 > 1e9`, true},
+		{`true && !(false || quote_only)`, true},
+	} {
+		expr, err := Compile(tc.src, testSchema)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if got := expr.Match(testEnv); got != tc.want {
+			t.Errorf("%s = %v, want %v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`spread_bps`,
+		`spread_bps > "8"`,
+		`unknown > 1`,
+		`spread_bps > 8 &&`,
+		`(spread_bps > 8`,
+		`long_exchange in "okx"`,
+		`long_exchange in [1]`,
+		`quote_only < true`,
+		`spread_bps > 8 extra`,
+		`"unterminated`,
+		`spread_bps # 8`,
+	} {
+		if _, err := Compile(src, testSchema); err == nil {
+			t.Errorf("%q compiled, want an error", src)
+		}
+	}
+}
+
+// FuzzCompile feeds arbitrary source through the compiler and evaluates
+// whatever compiles. Any panic is a bug: expressions come from operators.
+func FuzzCompile(f *testing.F) {
+	f.Add(`spread_bps > 8 && min_liquidity_usd > 50000 && long_exchange != "lbank"`)
+	f.Add(`!quote_only && long_exchange in ["okx", "bybit"]`)
+	f.Add(`((((spread_bps))))`)
+	f.Add(`- - - spread_bps < 1e-3`)
+	f.Add(`"a\"b" == 'c'`)
+
+	f.Fuzz(func(t *testing.T, src string) {
+		expr, err := Compile(src, testSchema)
+		if err != nil {
+			return
+		}
+		expr.Match(testEnv)
+	})
+}
@@ -0,0 +1,252 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Flags shared by every service reading the environment's flag hash
+const (
+	WSOrderEntry  = "ws_order_entry" // Send orders over WebSocket where the venue supports it
+	AutoRebalance = "auto_rebalance" // Move margin between venues without operator approval
+	ListingWatch  = "listing_watch"  // Subscribe announced listings ahead of the spread threshold
+)
+
+// Connector returns the flag gating an exchange connector
+func Connector(id connector.ExchangeID) string {
+	return "connector." + string(id)
+}
+
+// Config controls the flag store
+type Config struct {
+	Env             string          // Environment whose overrides are read, e.g. dev, staging, prod
+	RefreshInterval time.Duration   // How often overrides are re-read from Redis
+	Defaults        map[string]bool // State of flags without an override; unknown flags are off
+}
+
+// DefaultConfig returns defaults that keep risky features off until they
+// are switched on per environment. Connectors are on: ENABLED_EXCHANGES
+// already opts them in, the flag is a kill switch.
+func DefaultConfig() Config {
+	defaults := map[string]bool{
+		WSOrderEntry:  false,
+		AutoRebalance: false,
+		ListingWatch:  true,
+	}
+	for _, id := range connector.Exchanges() {
+		defaults[Connector(id)] = true
+	}
+
+	return Config{
+		Env:             "dev",
+		RefreshInterval: 5 * time.Second,
+		Defaults:        defaults,
+	}
+}
+
+// State is a flag's effective value
+type State struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Default    bool   `json:"default"`
+	Overridden bool   `json:"overridden"`
+}
+
+// Store serves feature flags from an in-memory copy of the environment's
+// Redis hash, so checks on hot paths never touch the network. Overrides
+// written by other services or the admin API are picked up on the next refresh.
+type Store struct {
+	client *redis.Client
+	config Config
+
+	mu        sync.RWMutex
+	overrides map[string]bool
+	done      chan struct{}
+}
+
+// New creates a new flag store
+func New(client *redis.Client, config Config) *Store {
+	return &Store{
+		client:    client,
+		config:    config,
+		overrides: make(map[string]bool),
+		done:      make(chan struct{}),
+	}
+}
+
+// Env returns the environment the store reads
+func (s *Store) Env() string {
+	return s.config.Env
+}
+
+// Enabled returns whether a flag is on
+func (s *Store) Enabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if v, ok := s.overrides[name]; ok {
+		return v
+	}
+	return s.config.Defaults[name]
+}
+
+// ConnectorEnabled returns whether an exchange connector may run
+func (s *Store) ConnectorEnabled(id connector.ExchangeID) bool {
+	return s.Enabled(Connector(id))
+}
+
+// WSOrderEntryEnabled returns whether orders may be sent over WebSocket on
+// an exchange. Venues without WebSocket order entry always use REST.
+func (s *Store) WSOrderEntryEnabled(id connector.ExchangeID) bool {
+	return connector.GetCapabilities(id).WSOrderEntry && s.Enabled(WSOrderEntry)
+}
+
+// Filter drops every symbol of a disabled connector. It has the signature
+// of a WebSocket symbol filter.
+func (s *Store) Filter(exchange connector.ExchangeID, symbols []string) []string {
+	if !s.ConnectorEnabled(exchange) {
+		return nil
+	}
+	return symbols
+}
+
+// List returns the state of every known or overridden flag, sorted by name
+func (s *Store) List() []State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make(map[string]bool, len(s.config.Defaults)+len(s.overrides))
+	for name := range s.config.Defaults {
+		names[name] = true
+	}
+	for name := range s.overrides {
+		names[name] = true
+	}
+
+	result := make([]State, 0, len(names))
+	for name := range names {
+		st := State{Name: name, Default: s.config.Defaults[name]}
+		st.Enabled, st.Overridden = s.overrides[name]
+		if !st.Overridden {
+			st.Enabled = st.Default
+		}
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Set overrides a flag for the environment
+func (s *Store) Set(ctx context.Context, name string, enabled bool) error {
+	if name == "" {
+		return fmt.Errorf("flags: empty flag name")
+	}
+	if err := s.client.HSet(ctx, keyspace.FlagsKey(s.config.Env), name, strconv.FormatBool(enabled)).Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.overrides[name] = enabled
+	s.mu.Unlock()
+	s.updateMetrics()
+
+	log.Info().Str("env", s.config.Env).Str("flag", name).Bool("enabled", enabled).Msg("Feature flag set")
+	return nil
+}
+
+// Clear removes a flag's override so it reverts to its default
+func (s *Store) Clear(ctx context.Context, name string) error {
+	if err := s.client.HDel(ctx, keyspace.FlagsKey(s.config.Env), name).Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.overrides, name)
+	s.mu.Unlock()
+	s.updateMetrics()
+
+	log.Info().Str("env", s.config.Env).Str("flag", name).Msg("Feature flag override cleared")
+	return nil
+}
+
+// Refresh re-reads the environment's overrides. Values that don't parse as
+// a bool are ignored so a typo can't flip a flag.
+func (s *Store) Refresh(ctx context.Context) error {
+	raw, err := s.client.HGetAll(ctx, keyspace.FlagsKey(s.config.Env)).Result()
+	if err != nil {
+		return err
+	}
+
+	overrides := make(map[string]bool, len(raw))
+	for name, v := range raw {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Warn().Str("flag", name).Str("value", v).Msg("Ignoring invalid feature flag value")
+			continue
+		}
+		overrides[name] = enabled
+	}
+
+	s.mu.Lock()
+	previous := s.overrides
+	s.overrides = overrides
+	s.mu.Unlock()
+
+	for name, enabled := range overrides {
+		if old, ok := previous[name]; !ok || old != enabled {
+			log.Info().Str("env", s.config.Env).Str("flag", name).Bool("enabled", enabled).Msg("Feature flag override changed")
+		}
+	}
+	for name := range previous {
+		if _, ok := overrides[name]; !ok {
+			log.Info().Str("env", s.config.Env).Str("flag", name).Msg("Feature flag override removed")
+		}
+	}
+	s.updateMetrics()
+	return nil
+}
+
+// Start refreshes overrides until the context is cancelled or Stop is
+// called. Flags keep their last known state while Redis is unreachable.
+func (s *Store) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh feature flags")
+			}
+		}
+	}
+}
+
+// Stop stops the refresh loop
+func (s *Store) Stop() {
+	close(s.done)
+}
+
+func (s *Store) updateMetrics() {
+	for _, st := range s.List() {
+		v := 0.0
+		if st.Enabled {
+			v = 1
+		}
+		metrics.FeatureFlag.WithLabelValues(st.Name).Set(v)
+	}
+}
@@ -0,0 +1,198 @@
+package funding
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Interval sources, in order of precedence
+const (
+	IntervalRule     = "rule"     // Configured for the symbol
+	IntervalMetadata = "metadata" // Listed with the venue's contracts
+	IntervalObserved = "observed" // Step between consecutive next funding times
+	IntervalVenue    = "venue"    // Configured for the venue
+	IntervalReported = "reported" // Sent with the rate by the connector
+	IntervalDefault  = "default"
+)
+
+// maxIntervalHours bounds observed steps; longer ones mean updates were missed
+const maxIntervalHours = 24
+
+// IntervalConfig holds the rules funding intervals are resolved with
+type IntervalConfig struct {
+	DefaultHours int                          // Used when nothing else is known
+	Venues       map[connector.ExchangeID]int // Hours per venue, below metadata and observed steps
+	Symbols      map[string]int               // Hours per "exchange:symbol", above everything
+}
+
+// DefaultIntervalConfig assumes 8h funding where nothing else is known
+func DefaultIntervalConfig() IntervalConfig {
+	return IntervalConfig{
+		DefaultHours: 8,
+		Venues:       map[connector.ExchangeID]int{},
+		Symbols:      map[string]int{},
+	}
+}
+
+// ParseIntervalRules returns the default config with rules such as
+// "kucoin=4,binance:XYZUSDT=1" added: exchange=hours or exchange:symbol=hours
+func ParseIntervalRules(s string) (IntervalConfig, error) {
+	config := DefaultIntervalConfig()
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return config, fmt.Errorf("invalid funding interval rule %q: want exchange[:symbol]=hours", part)
+		}
+		hours, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || hours <= 0 || hours > maxIntervalHours {
+			return config, fmt.Errorf("invalid funding interval rule %q: hours must be 1-%d", part, maxIntervalHours)
+		}
+		exchange, symbol, bySymbol := strings.Cut(strings.TrimSpace(target), ":")
+		id := connector.ExchangeID(strings.ToLower(exchange))
+		if bySymbol {
+			config.Symbols[intervalKey(id, symbol)] = hours
+		} else {
+			config.Venues[id] = hours
+		}
+	}
+	return config, nil
+}
+
+func intervalKey(exchange connector.ExchangeID, symbol string) string {
+	return string(exchange) + ":" + symbol
+}
+
+// Interval is the funding interval resolved for a contract
+type Interval struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Symbol   string               `json:"symbol"`
+	Hours    int                  `json:"hours"`
+	Source   string               `json:"source"` // rule, metadata, observed, venue, reported, default
+}
+
+type contractInterval struct {
+	metadata int       // From the venue's instrument list
+	observed int       // From the last step of the next funding time
+	reported int       // From the last rate
+	lastNext time.Time // Next funding time of the last rate
+}
+
+// Intervals resolves the funding interval of every contract and normalizes
+// rates to an 8h equivalent and an APR, so venues funding every 1h, 4h or
+// 8h compare directly. Venues changing a symbol's interval are followed
+// through their instrument lists and the step of the next funding time.
+type Intervals struct {
+	config IntervalConfig
+
+	mu        sync.RWMutex
+	contracts map[string]*contractInterval // key: "exchange:symbol"
+}
+
+// NewIntervals creates an interval resolver
+func NewIntervals(config IntervalConfig) *Intervals {
+	if config.DefaultHours <= 0 {
+		config.DefaultHours = DefaultIntervalConfig().DefaultHours
+	}
+	return &Intervals{
+		config:    config,
+		contracts: make(map[string]*contractInterval),
+	}
+}
+
+func (iv *Intervals) contract(exchange connector.ExchangeID, symbol string) *contractInterval {
+	key := intervalKey(exchange, symbol)
+	c := iv.contracts[key]
+	if c == nil {
+		c = &contractInterval{}
+		iv.contracts[key] = c
+	}
+	return c
+}
+
+// Learn records the intervals a venue lists with its contracts
+func (iv *Intervals) Learn(exchange connector.ExchangeID, instruments []connector.Instrument) {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	for _, inst := range instruments {
+		if inst.FundingIntervalHours > 0 {
+			iv.contract(exchange, inst.Symbol).metadata = inst.FundingIntervalHours
+		}
+	}
+}
+
+// Normalize sets a rate's interval to the resolved one and stamps its 8h
+// equivalent and APR
+func (iv *Intervals) Normalize(fr *connector.FundingRate) {
+	iv.mu.Lock()
+	c := iv.contract(fr.ExchangeID, fr.Symbol)
+	if fr.FundingIntervalHours > 0 {
+		c.reported = fr.FundingIntervalHours
+	}
+	if !fr.NextFundingTime.IsZero() {
+		if !c.lastNext.IsZero() && fr.NextFundingTime.After(c.lastNext) {
+			step := fr.NextFundingTime.Sub(c.lastNext)
+			hours := int(step.Round(time.Hour) / time.Hour)
+			if hours >= 1 && hours <= maxIntervalHours && (step-time.Duration(hours)*time.Hour).Abs() < time.Minute {
+				c.observed = hours
+			}
+		}
+		c.lastNext = fr.NextFundingTime
+	}
+	hours, source := iv.resolve(fr.ExchangeID, fr.Symbol, c)
+	iv.mu.Unlock()
+
+	fr.FundingIntervalHours = hours
+	fr.IntervalSource = source
+	fr.FundingRate8h = fr.FundingRate * 8 / float64(hours)
+	fr.FundingAPR = fr.FundingRate * 24 * 365 / float64(hours)
+}
+
+// resolve picks a contract's interval by precedence. Caller holds iv.mu.
+func (iv *Intervals) resolve(exchange connector.ExchangeID, symbol string, c *contractInterval) (int, string) {
+	if h, ok := iv.config.Symbols[intervalKey(exchange, symbol)]; ok {
+		return h, IntervalRule
+	}
+	switch {
+	case c.metadata > 0:
+		return c.metadata, IntervalMetadata
+	case c.observed > 0:
+		return c.observed, IntervalObserved
+	}
+	if h, ok := iv.config.Venues[exchange]; ok {
+		return h, IntervalVenue
+	}
+	if c.reported > 0 {
+		return c.reported, IntervalReported
+	}
+	return iv.config.DefaultHours, IntervalDefault
+}
+
+// Intervals returns the resolved interval of every contract seen, sorted
+// by exchange and symbol
+func (iv *Intervals) Intervals() []Interval {
+	iv.mu.RLock()
+	result := make([]Interval, 0, len(iv.contracts))
+	for key, c := range iv.contracts {
+		exchange, symbol, _ := strings.Cut(key, ":")
+		hours, source := iv.resolve(connector.ExchangeID(exchange), symbol, c)
+		result = append(result, Interval{Exchange: connector.ExchangeID(exchange), Symbol: symbol, Hours: hours, Source: source})
+	}
+	iv.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
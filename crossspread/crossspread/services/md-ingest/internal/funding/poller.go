@@ -0,0 +1,289 @@
+package funding
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Config controls REST funding polling
+type Config struct {
+	Intervals       map[connector.ExchangeID]time.Duration // Per-venue poll interval overrides
+	DefaultInterval time.Duration                          // Time between polls of a venue's funding
+	// SymbolRate caps requests per second on venues polled one symbol at a
+	// time. A cycle over many symbols takes longer than the interval.
+	SymbolRate        map[connector.ExchangeID]float64
+	DefaultSymbolRate float64
+	Jitter            float64       // Fraction of each wait added at random so venues don't align
+	Timeout           time.Duration // Per-request timeout
+	AgeInterval       time.Duration // How often the poll-age gauge is refreshed
+}
+
+// DefaultConfig returns polling rates well inside every venue's public REST limits.
+// Funding rates move slowly; a minute is fresh enough for spread scoring.
+func DefaultConfig() Config {
+	return Config{
+		Intervals:         map[connector.ExchangeID]time.Duration{},
+		DefaultInterval:   time.Minute,
+		SymbolRate:        map[connector.ExchangeID]float64{},
+		DefaultSymbolRate: 2,
+		Jitter:            0.2,
+		Timeout:           10 * time.Second,
+		AgeInterval:       5 * time.Second,
+	}
+}
+
+// Poller polls funding rates over REST for venues that don't push them over
+// WebSocket and hands them to the same FundingHandler the connectors use
+type Poller struct {
+	config     Config
+	connectors []connector.Connector
+	handler    connector.FundingHandler
+	symbols    func() map[connector.ExchangeID][]string
+
+	mu       sync.Mutex
+	lastPoll map[connector.ExchangeID]time.Time
+	done     chan struct{}
+}
+
+// NewPoller creates a funding poller. Connectors whose venue streams
+// funding over WebSocket are skipped.
+func NewPoller(connectors []connector.Connector, config Config) *Poller {
+	polled := make([]connector.Connector, 0, len(connectors))
+	for _, conn := range connectors {
+		if !connector.HasFundingWS(conn.ID()) {
+			polled = append(polled, conn)
+		}
+	}
+
+	return &Poller{
+		config:     config,
+		connectors: polled,
+		lastPoll:   make(map[connector.ExchangeID]time.Time),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetHandler sets the callback for polled funding rates
+func (p *Poller) SetHandler(handler connector.FundingHandler) {
+	p.handler = handler
+}
+
+// SetSymbolSource limits polling to the symbols returned by fn, keyed by
+// exchange. Without a source every symbol of a venue is polled.
+func (p *Poller) SetSymbolSource(fn func() map[connector.ExchangeID][]string) {
+	p.symbols = fn
+}
+
+// Start polls every venue until the context is cancelled or Stop is called
+func (p *Poller) Start(ctx context.Context) {
+	// Venues that never succeed report their age from startup
+	now := time.Now()
+	p.mu.Lock()
+	for _, conn := range p.connectors {
+		p.lastPoll[conn.ID()] = now
+	}
+	p.mu.Unlock()
+
+	for _, conn := range p.connectors {
+		go p.run(ctx, conn)
+	}
+
+	ticker := time.NewTicker(p.config.AgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.updateAge()
+		}
+	}
+}
+
+// Stop stops the poller
+func (p *Poller) Stop() {
+	close(p.done)
+}
+
+// LastPoll returns the time of the last successful poll of an exchange
+func (p *Poller) LastPoll(id connector.ExchangeID) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastPoll[id]
+}
+
+// run polls one venue. The first poll is delayed by a random fraction of
+// the jitter so venues sharing an egress IP don't fire together.
+func (p *Poller) run(ctx context.Context, conn connector.Connector) {
+	interval := p.interval(conn.ID())
+	if !p.wait(ctx, time.Duration(rand.Float64()*p.config.Jitter*float64(interval))) {
+		return
+	}
+
+	fetcher, perSymbol := conn.(connector.SymbolFundingFetcher)
+	for {
+		start := time.Now()
+		symbols, tracked := p.trackedSymbols(conn.ID())
+		if perSymbol && tracked {
+			if !p.pollSymbols(ctx, conn.ID(), fetcher, symbols) {
+				return
+			}
+		} else {
+			p.pollBulk(ctx, conn, symbols, tracked)
+		}
+
+		if !p.wait(ctx, p.jittered(interval-time.Since(start))) {
+			return
+		}
+	}
+}
+
+// pollBulk fetches all of a venue's funding rates in one request
+func (p *Poller) pollBulk(ctx context.Context, conn connector.Connector, symbols []string, tracked bool) {
+	id := conn.ID()
+	reqCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	rates, err := conn.FetchFundingRates(reqCtx)
+	cancel()
+	if err != nil {
+		metrics.FundingPolls.WithLabelValues(string(id), "error").Inc()
+		log.Warn().Err(err).Str("exchange", string(id)).Msg("Funding poll failed")
+		return
+	}
+	metrics.FundingPolls.WithLabelValues(string(id), "ok").Inc()
+
+	var want map[string]bool
+	if tracked {
+		want = make(map[string]bool, len(symbols))
+		for _, s := range symbols {
+			want[s] = true
+		}
+	}
+	for i := range rates {
+		if want != nil && !want[rates[i].Symbol] {
+			continue
+		}
+		p.emit(&rates[i])
+	}
+	p.markPolled(id)
+}
+
+// pollSymbols fetches funding one symbol at a time at the venue's symbol
+// rate. Returns false if polling should stop.
+func (p *Poller) pollSymbols(ctx context.Context, id connector.ExchangeID, fetcher connector.SymbolFundingFetcher, symbols []string) bool {
+	gap := time.Duration(float64(time.Second) / p.symbolRate(id))
+	failed := 0
+	for i, symbol := range symbols {
+		if i > 0 && !p.wait(ctx, p.jittered(gap)) {
+			return false
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+		fr, err := fetcher.FetchFundingRate(reqCtx, symbol)
+		cancel()
+		if err != nil {
+			failed++
+			metrics.FundingPolls.WithLabelValues(string(id), "error").Inc()
+			log.Debug().Err(err).Str("exchange", string(id)).Str("symbol", symbol).Msg("Funding poll failed")
+			continue
+		}
+		metrics.FundingPolls.WithLabelValues(string(id), "ok").Inc()
+		p.emit(fr)
+	}
+
+	if failed > 0 {
+		log.Warn().
+			Str("exchange", string(id)).
+			Int("failed", failed).
+			Int("symbols", len(symbols)).
+			Msg("Some funding polls failed")
+	}
+	if failed < len(symbols) {
+		p.markPolled(id)
+	}
+	return true
+}
+
+// emit fills in the canonical symbol if the connector left it empty and
+// hands the rate to the handler
+func (p *Poller) emit(fr *connector.FundingRate) {
+	if fr.Canonical == "" {
+		fr.Canonical = connector.ParsePair(fr.Symbol).Canonical()
+	}
+	if p.handler != nil {
+		p.handler(fr)
+	}
+}
+
+// trackedSymbols returns the symbols to poll for an exchange. The second
+// result is false when polling is unrestricted.
+func (p *Poller) trackedSymbols(id connector.ExchangeID) ([]string, bool) {
+	if p.symbols == nil {
+		return nil, false
+	}
+	return p.symbols()[id], true
+}
+
+func (p *Poller) markPolled(id connector.ExchangeID) {
+	p.mu.Lock()
+	p.lastPoll[id] = time.Now()
+	p.mu.Unlock()
+	metrics.FundingPollAge.WithLabelValues(string(id)).Set(0)
+}
+
+func (p *Poller) updateAge() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, at := range p.lastPoll {
+		metrics.FundingPollAge.WithLabelValues(string(id)).Set(time.Since(at).Seconds())
+	}
+}
+
+func (p *Poller) interval(id connector.ExchangeID) time.Duration {
+	if d, ok := p.config.Intervals[id]; ok {
+		return d
+	}
+	return p.config.DefaultInterval
+}
+
+func (p *Poller) symbolRate(id connector.ExchangeID) float64 {
+	if r, ok := p.config.SymbolRate[id]; ok && r > 0 {
+		return r
+	}
+	return p.config.DefaultSymbolRate
+}
+
+// jittered adds up to Jitter of d at random
+func (p *Poller) jittered(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d + time.Duration(rand.Float64()*p.config.Jitter*float64(d))
+}
+
+// wait sleeps for d, returning false if the poller was stopped meanwhile
+func (p *Poller) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-p.done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
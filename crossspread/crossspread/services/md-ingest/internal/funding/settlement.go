@@ -0,0 +1,374 @@
+package funding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Settlement stages, published in order for each funding timestamp
+const (
+	StageUpcoming = "upcoming" // Settlement is within the horizon
+	StageImminent = "imminent" // Settlement is within the action lead; actions fire
+	StageSettled  = "settled"  // The funding timestamp has passed
+)
+
+// Pre-settlement action kinds
+const (
+	ActionReduce = "reduce" // Cut the paying leg by a fraction
+	ActionFlip   = "flip"   // Reverse the paying leg so it receives funding
+)
+
+// maxActions is the number of actions kept for the admin API
+const maxActions = 500
+
+// Settlement and action payloads are defined in pkg/mdtypes for clients
+type (
+	Settlement = mdtypes.Settlement
+	Action     = mdtypes.Action
+)
+
+// ActionRule fires an action when the predicted rate is at least MinRate in
+// absolute value
+type ActionRule struct {
+	Kind     string  `json:"kind"` // reduce, flip
+	MinRate  float64 `json:"min_rate"`
+	Fraction float64 `json:"fraction,omitempty"` // Share of the leg to cut, for reduce
+}
+
+// PositionSource returns the signed position held on a venue;
+// *inventory.Store implements it
+type PositionSource interface {
+	Position(exchange connector.ExchangeID, canonical string) float64
+}
+
+// SettlementConfig controls the settlement scheduler
+type SettlementConfig struct {
+	Horizon    time.Duration // Settlements closer than this are announced as upcoming
+	ActionLead time.Duration // Actions fire this long before settlement
+	Interval   time.Duration // Time between schedule checks
+	Rules      []ActionRule  // No rules publishes events only
+}
+
+// DefaultSettlementConfig announces settlements an hour ahead and fires
+// actions five minutes before them. No actions are configured.
+func DefaultSettlementConfig() SettlementConfig {
+	return SettlementConfig{
+		Horizon:    time.Hour,
+		ActionLead: 5 * time.Minute,
+		Interval:   5 * time.Second,
+	}
+}
+
+// ParseRules parses action rules such as "reduce:0.0005:0.5,flip:0.002",
+// i.e. kind:min_rate[:fraction]
+func ParseRules(s string) ([]ActionRule, error) {
+	var rules []ActionRule
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid funding action %q", part)
+		}
+		rule := ActionRule{Kind: fields[0]}
+		if rule.Kind != ActionReduce && rule.Kind != ActionFlip {
+			return nil, fmt.Errorf("unknown funding action %q", rule.Kind)
+		}
+		rate, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid min rate in funding action %q", part)
+		}
+		rule.MinRate = rate
+		if rule.Kind == ActionReduce {
+			rule.Fraction = 1
+			if len(fields) == 3 {
+				f, err := strconv.ParseFloat(fields[2], 64)
+				if err != nil || f <= 0 || f > 1 {
+					return nil, fmt.Errorf("invalid fraction in funding action %q", part)
+				}
+				rule.Fraction = f
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+type settlementKey struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+type settlementState struct {
+	rate      connector.FundingRate
+	settlesAt time.Time // Funding timestamp the stage refers to
+	stage     string    // Last stage published for settlesAt
+}
+
+// Scheduler tracks each venue's next funding timestamp from incoming
+// funding rates, publishes settlement events as they approach and fires
+// the configured pre-settlement actions
+type Scheduler struct {
+	config    SettlementConfig
+	publisher *publisher.RedisPublisher
+	positions PositionSource
+	handler   func(Action)
+
+	mu      sync.Mutex
+	state   map[settlementKey]*settlementState
+	actions []Action
+
+	done chan struct{}
+}
+
+// NewScheduler creates a funding settlement scheduler
+func NewScheduler(config SettlementConfig, pub *publisher.RedisPublisher) *Scheduler {
+	rules := append([]ActionRule(nil), config.Rules...)
+	// Evaluate the strongest rule first
+	sort.Slice(rules, func(i, j int) bool { return rules[i].MinRate > rules[j].MinRate })
+	config.Rules = rules
+
+	return &Scheduler{
+		config:    config,
+		publisher: pub,
+		state:     make(map[settlementKey]*settlementState),
+		done:      make(chan struct{}),
+	}
+}
+
+// SetPositions limits actions to venues where a position pays the
+// settlement. Without a source actions fire for every paying side.
+func (s *Scheduler) SetPositions(positions PositionSource) {
+	s.positions = positions
+}
+
+// SetActionHandler sets an in-process callback for fired actions
+func (s *Scheduler) SetActionHandler(handler func(Action)) {
+	s.handler = handler
+}
+
+// HandleFundingRate records a venue's latest rate and next funding timestamp
+func (s *Scheduler) HandleFundingRate(fr *connector.FundingRate) {
+	if fr.NextFundingTime.IsZero() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := settlementKey{fr.ExchangeID, fr.Symbol}
+	st, ok := s.state[key]
+	if !ok {
+		st = &settlementState{}
+		s.state[key] = st
+	}
+	st.rate = *fr
+	if st.rate.Canonical == "" {
+		st.rate.Canonical = connector.ParsePair(fr.Symbol).Canonical()
+	}
+}
+
+// Start checks the schedule until the context is cancelled or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.check(now)
+		}
+	}
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	close(s.done)
+}
+
+// Upcoming returns settlements within the horizon, soonest first
+func (s *Scheduler) Upcoming() []Settlement {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Settlement, 0)
+	for _, st := range s.state {
+		until := st.rate.NextFundingTime.Sub(now)
+		if until <= 0 || until > s.config.Horizon {
+			continue
+		}
+		stage := StageUpcoming
+		if until <= s.config.ActionLead {
+			stage = StageImminent
+		}
+		result = append(result, settlementOf(&st.rate, st.rate.NextFundingTime, stage, now))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].SettlesAt.Equal(result[j].SettlesAt) {
+			return result[i].SettlesAt.Before(result[j].SettlesAt)
+		}
+		return result[i].Exchange < result[j].Exchange
+	})
+	return result
+}
+
+// Actions returns recently fired actions, newest first
+func (s *Scheduler) Actions() []Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Action, len(s.actions))
+	for i, a := range s.actions {
+		result[len(s.actions)-1-i] = a
+	}
+	return result
+}
+
+// check advances every tracked settlement to its current stage. Each stage
+// is published once per funding timestamp.
+func (s *Scheduler) check(now time.Time) {
+	var settlements []Settlement
+	var actions []Action
+
+	s.mu.Lock()
+	for _, st := range s.state {
+		// A settlement announced earlier settles once its timestamp passes,
+		// even if the venue already reports the following one
+		if st.stage != "" && st.stage != StageSettled && !now.Before(st.settlesAt) {
+			settlements = append(settlements, settlementOf(&st.rate, st.settlesAt, StageSettled, now))
+			st.stage = StageSettled
+		}
+
+		next := st.rate.NextFundingTime
+		until := next.Sub(now)
+		if until <= 0 || until > s.config.Horizon {
+			continue
+		}
+		if !next.Equal(st.settlesAt) {
+			st.settlesAt = next
+			st.stage = ""
+		}
+
+		if st.stage == "" {
+			st.stage = StageUpcoming
+			settlements = append(settlements, settlementOf(&st.rate, next, StageUpcoming, now))
+		}
+		if st.stage == StageUpcoming && until <= s.config.ActionLead {
+			st.stage = StageImminent
+			settlements = append(settlements, settlementOf(&st.rate, next, StageImminent, now))
+			if a, ok := s.action(&st.rate, now); ok {
+				actions = append(actions, a)
+			}
+		}
+	}
+	s.actions = append(s.actions, actions...)
+	if len(s.actions) > maxActions {
+		s.actions = s.actions[len(s.actions)-maxActions:]
+	}
+	s.mu.Unlock()
+
+	for _, e := range settlements {
+		metrics.FundingSettlements.WithLabelValues(string(e.Exchange), e.Stage).Inc()
+		s.publish(e, s.publisher.PublishFundingSettlement)
+	}
+	for _, a := range actions {
+		metrics.FundingActions.WithLabelValues(string(a.Exchange), a.Kind).Inc()
+		log.Info().
+			Str("exchange", string(a.Exchange)).
+			Str("symbol", a.Symbol).
+			Str("kind", a.Kind).
+			Str("paying_side", a.PayingSide).
+			Float64("predicted_rate", a.PredictedRate).
+			Time("settles_at", a.SettlesAt).
+			Msg("Pre-settlement funding action")
+		s.publish(a, s.publisher.PublishFundingAction)
+		if s.handler != nil {
+			s.handler(a)
+		}
+	}
+}
+
+// action returns the strongest rule the predicted rate triggers. With a
+// position source it only fires if the venue's position pays the rate.
+func (s *Scheduler) action(fr *connector.FundingRate, now time.Time) (Action, bool) {
+	if fr.FundingRate == 0 {
+		return Action{}, false
+	}
+	payingSide := "long"
+	if fr.FundingRate < 0 {
+		payingSide = "short"
+	}
+
+	var position float64
+	if s.positions != nil {
+		position = s.positions.Position(fr.ExchangeID, fr.Canonical)
+		if position*fr.FundingRate <= 0 {
+			return Action{}, false
+		}
+	}
+
+	for _, rule := range s.config.Rules {
+		if math.Abs(fr.FundingRate) < rule.MinRate {
+			continue
+		}
+		return Action{
+			Exchange:      fr.ExchangeID,
+			Symbol:        fr.Symbol,
+			Canonical:     fr.Canonical,
+			Kind:          rule.Kind,
+			Fraction:      rule.Fraction,
+			PayingSide:    payingSide,
+			Position:      position,
+			PredictedRate: fr.FundingRate,
+			SettlesAt:     fr.NextFundingTime,
+			At:            now,
+		}, true
+	}
+	return Action{}, false
+}
+
+func (s *Scheduler) publish(v interface{}, fn func([]byte) error) {
+	if s.publisher == nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := fn(data); err != nil {
+		log.Error().Err(err).Msg("Failed to publish funding settlement event")
+	}
+}
+
+func settlementOf(fr *connector.FundingRate, settlesAt time.Time, stage string, now time.Time) Settlement {
+	return Settlement{
+		Exchange:      fr.ExchangeID,
+		Symbol:        fr.Symbol,
+		Canonical:     fr.Canonical,
+		Stage:         stage,
+		PredictedRate: fr.FundingRate,
+		SettlesAt:     settlesAt,
+		IntervalHours: fr.FundingIntervalHours,
+		At:            now,
+	}
+}
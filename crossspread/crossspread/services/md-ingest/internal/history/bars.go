@@ -0,0 +1,85 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"crossspread-md-ingest/internal/bars"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RecordBars stores venue bars in the bar history, replacing any bar of the
+// same symbol and interval with the same start. Bars older than the
+// retention are trimmed. Consolidated bars are ignored.
+func (s *Store) RecordBars(ctx context.Context, bs []*bars.Bar) error {
+	cutoff := strconv.FormatInt(time.Now().Add(-s.config.Retention).UnixMilli(), 10)
+	touched := make(map[string]bool)
+
+	pipe := s.client.Pipeline()
+	for _, bar := range bs {
+		if bar.Exchange == "" {
+			continue
+		}
+		data, err := json.Marshal(bar)
+		if err != nil {
+			continue
+		}
+
+		key := keyspace.HistoryBarsKey(string(bar.Exchange), bar.Symbol, bar.Interval)
+		score := strconv.FormatInt(bar.Start.UnixMilli(), 10)
+		pipe.ZRemRangeByScore(ctx, key, score, score)
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(bar.Start.UnixMilli()), Member: data})
+		touched[key] = true
+	}
+	for key := range touched {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+		pipe.Expire(ctx, key, s.config.Retention)
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Bars returns a venue symbol's bars starting in [from, to], oldest first.
+// Bars the venue recorded under the symbol's former names are included.
+func (s *Store) Bars(ctx context.Context, exchange, symbol, interval string, from, to time.Time) ([]*bars.Bar, error) {
+	symbols := []string{symbol}
+	s.mu.Lock()
+	r := s.renames
+	s.mu.Unlock()
+	if r != nil {
+		symbols = append(symbols, r.FormerSymbols(connector.ExchangeID(exchange), symbol, from)...)
+	}
+
+	var result []*bars.Bar
+	for _, sym := range symbols {
+		values, err := s.client.ZRangeByScore(ctx, keyspace.HistoryBarsKey(exchange, sym, interval), &redis.ZRangeBy{
+			Min: strconv.FormatInt(from.UnixMilli(), 10),
+			Max: strconv.FormatInt(to.UnixMilli(), 10),
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range values {
+			var bar bars.Bar
+			if err := json.Unmarshal([]byte(v), &bar); err != nil {
+				continue
+			}
+			result = append(result, &bar)
+		}
+	}
+	if len(symbols) > 1 {
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// Capture groupings
+const (
+	CaptureByPair   = "pair"   // Per long:short exchange pair
+	CaptureBySymbol = "symbol" // Per canonical symbol
+)
+
+// ErrInvalidExecution is returned for execution reports missing legs or prices
+var ErrInvalidExecution = errors.New("invalid execution")
+
+// Execution is an executed opportunity as reported by an executor: the
+// spread it traded on and the average fill price of each leg
+type Execution struct {
+	OpportunityID string    `json:"opportunity_id"`
+	Canonical     string    `json:"canonical"`
+	LongExchange  string    `json:"long_exchange"`
+	ShortExchange string    `json:"short_exchange"`
+	SignalBps     float64   `json:"signal_bps"` // spread_bps of the opportunity when acted on
+	LongFill      float64   `json:"long_fill"`  // Average buy price
+	ShortFill     float64   `json:"short_fill"` // Average sell price
+	FilledAt      time.Time `json:"filled_at"`  // Defaults to now
+}
+
+// CapturedBps returns the spread actually captured, measured like spread_bps
+func (e *Execution) CapturedBps() float64 {
+	if e.LongFill <= 0 {
+		return 0
+	}
+	return (e.ShortFill - e.LongFill) / e.LongFill * 10000
+}
+
+// CaptureStats aggregates executions of one exchange pair or symbol
+type CaptureStats struct {
+	Group         string  `json:"group"` // "okx:bybit" by pair, "BTC" by symbol
+	Executions    int64   `json:"executions"`
+	AvgSignalBps  float64 `json:"avg_signal_bps"`
+	AvgCaptureBps float64 `json:"avg_captured_bps"`
+	AvgSlipBps    float64 `json:"avg_slip_bps"`  // Signal minus captured
+	CaptureRatio  float64 `json:"capture_ratio"` // Total captured over total signal
+}
+
+// RecordExecution adds an execution to the day's capture aggregates
+func (s *Store) RecordExecution(ctx context.Context, e Execution) error {
+	if e.Canonical == "" || e.LongExchange == "" || e.ShortExchange == "" {
+		return fmt.Errorf("%w: canonical, long_exchange and short_exchange are required", ErrInvalidExecution)
+	}
+	if e.LongFill <= 0 || e.ShortFill <= 0 {
+		return fmt.Errorf("%w: long_fill and short_fill must be positive", ErrInvalidExecution)
+	}
+	if e.FilledAt.IsZero() {
+		e.FilledAt = time.Now()
+	}
+	captured := e.CapturedBps()
+
+	key := keyspace.HistoryCaptureKey(e.FilledAt.UTC().Format(DateLayout))
+	pipe := s.client.Pipeline()
+	for _, group := range []string{
+		CaptureByPair + ":" + e.LongExchange + ":" + e.ShortExchange,
+		CaptureBySymbol + ":" + e.Canonical,
+	} {
+		pipe.HIncrBy(ctx, key, group+"|count", 1)
+		pipe.HIncrByFloat(ctx, key, group+"|signal_bps", e.SignalBps)
+		pipe.HIncrByFloat(ctx, key, group+"|captured_bps", captured)
+	}
+	pipe.Expire(ctx, key, s.config.Retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if e.SignalBps > 0 {
+		metrics.SpreadCaptureRatio.WithLabelValues(e.LongExchange, e.ShortExchange).Observe(captured / e.SignalBps)
+	}
+	return nil
+}
+
+// Capture returns the capture stats on a date grouped by pair or symbol,
+// most executions first
+func (s *Store) Capture(ctx context.Context, date, by string) ([]CaptureStats, error) {
+	if by != CaptureByPair && by != CaptureBySymbol {
+		return nil, fmt.Errorf("by must be %s or %s", CaptureByPair, CaptureBySymbol)
+	}
+	fields, err := s.client.HGetAll(ctx, keyspace.HistoryCaptureKey(date)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	type sums struct {
+		count            int64
+		signal, captured float64
+	}
+	groups := make(map[string]*sums)
+	prefix := by + ":"
+	for field, value := range fields {
+		name, stat, ok := strings.Cut(field, "|")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		group := strings.TrimPrefix(name, prefix)
+		g, ok := groups[group]
+		if !ok {
+			g = &sums{}
+			groups[group] = g
+		}
+		switch stat {
+		case "count":
+			g.count, _ = strconv.ParseInt(value, 10, 64)
+		case "signal_bps":
+			g.signal, _ = strconv.ParseFloat(value, 64)
+		case "captured_bps":
+			g.captured, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	result := make([]CaptureStats, 0, len(groups))
+	for group, g := range groups {
+		if g.count == 0 {
+			continue
+		}
+		n := float64(g.count)
+		stats := CaptureStats{
+			Group:         group,
+			Executions:    g.count,
+			AvgSignalBps:  g.signal / n,
+			AvgCaptureBps: g.captured / n,
+			AvgSlipBps:    (g.signal - g.captured) / n,
+		}
+		if g.signal != 0 {
+			stats.CaptureRatio = g.captured / g.signal
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Executions != result[j].Executions {
+			return result[i].Executions > result[j].Executions
+		}
+		return result[i].Group < result[j].Group
+	})
+	return result, nil
+}
@@ -0,0 +1,355 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CorrelationConfig controls spread correlation analysis
+type CorrelationConfig struct {
+	Bucket          time.Duration // Resolution of each exchange pair's activity series
+	MinCorrelation  float64       // Pairs correlated at least this much are clustered together
+	MinActive       int           // Buckets a pair must have an opportunity in to be analysed
+	Days            int           // Days of episodes the published clusters cover, today included
+	PublishInterval time.Duration // How often clusters are recomputed and published
+}
+
+// DefaultCorrelationConfig correlates 1m buckets of the last day and
+// clusters pairs correlated at 0.6 or more, every 15m
+func DefaultCorrelationConfig() CorrelationConfig {
+	return CorrelationConfig{
+		Bucket:          time.Minute,
+		MinCorrelation:  0.6,
+		MinActive:       10,
+		Days:            1,
+		PublishInterval: 15 * time.Minute,
+	}
+}
+
+// The correlation report is defined in pkg/mdtypes for clients
+type (
+	SpreadCluster     = mdtypes.SpreadCluster
+	PairCorrelation   = mdtypes.PairCorrelation
+	SpreadCorrelation = mdtypes.SpreadCorrelation
+)
+
+// maxClusterSymbols is the number of symbols listed per cluster
+const maxClusterSymbols = 5
+
+// pairActivity is one exchange pair's spread series and episode totals
+type pairActivity struct {
+	series   []float64 // Highest peak bps of the pair's opportunities open in each bucket
+	active   int
+	episodes int64
+	peakSum  float64
+	symbols  map[string]int
+}
+
+// Correlate correlates the spread activity of every exchange pair between
+// from and to. Each pair's series holds, per bucket, the highest peak of the
+// pair's opportunities open during it across all symbols, so a venue that
+// lags on every symbol at once moves all of its pairs together. Pairs with
+// fewer than MinActive active buckets are left out. Clusters link pairs
+// correlated at MinCorrelation or more (single linkage) and are sorted by
+// size, then average correlation.
+func Correlate(episodes []Episode, from, to time.Time, cfg CorrelationConfig) *SpreadCorrelation {
+	result := &SpreadCorrelation{
+		Start:        from,
+		End:          to,
+		Bucket:       cfg.Bucket.String(),
+		Pairs:        []string{},
+		Correlations: []PairCorrelation{},
+		Clusters:     []SpreadCluster{},
+		GeneratedAt:  time.Now().UTC(),
+	}
+	if cfg.Bucket <= 0 || to.Sub(from) < 2*cfg.Bucket {
+		return result
+	}
+	buckets := int(to.Sub(from) / cfg.Bucket)
+
+	pairs := make(map[string]*pairActivity)
+	for i := range episodes {
+		ep := &episodes[i]
+		end := ep.Start.Add(time.Duration(ep.DurationMs) * time.Millisecond)
+		if !end.After(from) || !ep.Start.Before(to) {
+			continue
+		}
+		key := ep.LongExchange + ":" + ep.ShortExchange
+		p, ok := pairs[key]
+		if !ok {
+			p = &pairActivity{series: make([]float64, buckets), symbols: make(map[string]int)}
+			pairs[key] = p
+		}
+		p.episodes++
+		p.peakSum += ep.PeakBps
+		p.symbols[ep.Canonical]++
+
+		first := max(int(ep.Start.Sub(from)/cfg.Bucket), 0)
+		last := min(int(end.Sub(from)/cfg.Bucket), buckets-1)
+		for b := first; b <= last; b++ {
+			if p.series[b] == 0 {
+				p.active++
+			}
+			p.series[b] = max(p.series[b], ep.PeakBps)
+		}
+	}
+
+	for key, p := range pairs {
+		if p.active >= cfg.MinActive {
+			result.Pairs = append(result.Pairs, key)
+		}
+	}
+	sort.Strings(result.Pairs)
+
+	n := len(result.Pairs)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		matrix[i][i] = 1
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			c := round2(pearson(pairs[result.Pairs[i]].series, pairs[result.Pairs[j]].series))
+			matrix[i][j], matrix[j][i] = c, c
+			result.Correlations = append(result.Correlations, PairCorrelation{A: result.Pairs[i], B: result.Pairs[j], Correlation: c})
+		}
+	}
+	sort.SliceStable(result.Correlations, func(i, j int) bool {
+		return result.Correlations[i].Correlation > result.Correlations[j].Correlation
+	})
+
+	result.Clusters = clusters(result.Pairs, matrix, pairs, cfg.MinCorrelation)
+	return result
+}
+
+// clusters groups pairs linked by a correlation of at least threshold and
+// summarises each group of two or more
+func clusters(keys []string, matrix [][]float64, pairs map[string]*pairActivity, threshold float64) []SpreadCluster {
+	parent := make([]int, len(keys))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			if matrix[i][j] >= threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range keys {
+		groups[find(i)] = append(groups[find(i)], i)
+	}
+
+	result := []SpreadCluster{}
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		result = append(result, summarise(keys, matrix, pairs, members))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].Pairs) != len(result[j].Pairs) {
+			return len(result[i].Pairs) > len(result[j].Pairs)
+		}
+		return result[i].AvgCorrelation > result[j].AvgCorrelation
+	})
+	return result
+}
+
+// summarise describes one cluster of pair indices
+func summarise(keys []string, matrix [][]float64, pairs map[string]*pairActivity, members []int) SpreadCluster {
+	c := SpreadCluster{MinCorrelation: 1}
+	strength := make(map[int]float64, len(members))
+	var sum float64
+	var links int
+	for a, i := range members {
+		for _, j := range members[a+1:] {
+			sum += matrix[i][j]
+			links++
+			c.MinCorrelation = min(c.MinCorrelation, matrix[i][j])
+			strength[i] += matrix[i][j]
+			strength[j] += matrix[i][j]
+		}
+	}
+	c.AvgCorrelation = round2(sum / float64(links))
+	sort.Slice(members, func(a, b int) bool {
+		if strength[members[a]] != strength[members[b]] {
+			return strength[members[a]] > strength[members[b]]
+		}
+		return keys[members[a]] < keys[members[b]]
+	})
+
+	venues := make(map[string]int)
+	symbols := make(map[string]int)
+	var peakSum float64
+	for _, i := range members {
+		key := keys[i]
+		c.Pairs = append(c.Pairs, key)
+		long, short, _ := strings.Cut(key, ":")
+		venues[long]++
+		if short != long {
+			venues[short]++
+		}
+		p := pairs[key]
+		c.Episodes += p.episodes
+		peakSum += p.peakSum
+		for sym, count := range p.symbols {
+			symbols[sym] += count
+		}
+	}
+	if c.Episodes > 0 {
+		c.AvgPeakBps = round2(peakSum / float64(c.Episodes))
+	}
+	for venue, count := range venues {
+		if count == len(members) {
+			c.Common = append(c.Common, venue)
+		}
+	}
+	sort.Strings(c.Common)
+
+	for sym := range symbols {
+		c.Symbols = append(c.Symbols, sym)
+	}
+	sort.Slice(c.Symbols, func(a, b int) bool {
+		if symbols[c.Symbols[a]] != symbols[c.Symbols[b]] {
+			return symbols[c.Symbols[a]] > symbols[c.Symbols[b]]
+		}
+		return c.Symbols[a] < c.Symbols[b]
+	})
+	if len(c.Symbols) > maxClusterSymbols {
+		c.Symbols = c.Symbols[:maxClusterSymbols]
+	}
+	return c
+}
+
+// pearson returns the correlation of two equal-length series, 0 if either
+// is constant
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	var sx, sy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+	}
+	mx, my := sx/n, sy/n
+	var cov, vx, vy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Correlation correlates the spread activity of exchange pairs over the
+// episodes that ended between two dates (inclusive)
+func (s *Store) Correlation(ctx context.Context, from, to string, cfg CorrelationConfig) (*SpreadCorrelation, error) {
+	start, end, err := dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var episodes []Episode
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		eps, err := s.Episodes(ctx, day.Format(DateLayout))
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, eps...)
+	}
+
+	until := end.AddDate(0, 0, 1)
+	if now := time.Now().UTC(); until.After(now) {
+		until = now.Truncate(cfg.Bucket)
+	}
+	return Correlate(episodes, start, until, cfg), nil
+}
+
+// dateRange parses an inclusive range of UTC dates no longer than
+// MaxSessionDays
+func dateRange(from, to string) (time.Time, time.Time, error) {
+	start, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from date %q", from)
+	}
+	end, err := time.Parse(DateLayout, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to date %q", to)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to is before from")
+	}
+	if end.Sub(start) >= MaxSessionDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range is limited to %d days", MaxSessionDays)
+	}
+	return start, end, nil
+}
+
+// PublishClusters recomputes spread clusters over the configured days every
+// publish interval and publishes them, so risk can avoid stacking
+// opportunities on correlated pairs. Runs until ctx is done.
+func (s *Store) PublishClusters(ctx context.Context, cfg CorrelationConfig) {
+	ticker := time.NewTicker(cfg.PublishInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.publishClusters(ctx, cfg); err != nil {
+				log.Warn().Err(err).Msg("Failed to publish spread clusters")
+			}
+		}
+	}
+}
+
+func (s *Store) publishClusters(ctx context.Context, cfg CorrelationConfig) error {
+	now := time.Now().UTC()
+	from := now.AddDate(0, 0, 1-max(cfg.Days, 1)).Format(DateLayout)
+	corr, err := s.Correlation(ctx, from, now.Format(DateLayout), cfg)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(corr)
+	if err != nil {
+		return err
+	}
+
+	key := keyspace.Key(keyspace.HistoryClustersKey)
+	pipe := s.client.Pipeline()
+	pipe.Set(ctx, key, data, keyspace.HistoryClustersTTL)
+	pipe.Publish(ctx, key, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	log.Info().Int("pairs", len(corr.Pairs)).Int("clusters", len(corr.Clusters)).Msg("Published spread clusters")
+	return nil
+}
@@ -0,0 +1,536 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// DateLayout is the layout of the UTC date in history keys and queries
+const DateLayout = "2006-01-02"
+
+// Config controls how spread history is recorded
+type Config struct {
+	SampleInterval time.Duration // How often peaks and distributions are sampled
+	Retention      time.Duration // How long daily keys are kept
+}
+
+// DefaultConfig returns the default history configuration
+func DefaultConfig() Config {
+	return Config{
+		SampleInterval: 10 * time.Second,
+		Retention:      keyspace.HistoryTTL,
+	}
+}
+
+// Bucket is one bin of a spread bps histogram
+type Bucket struct {
+	Label string  `json:"label"`
+	Min   float64 `json:"min_bps"`
+	Max   float64 `json:"max_bps"` // 0 for the open-ended last bucket
+	Count int64   `json:"count"`
+}
+
+// bucketEdges are the lower bounds of the histogram bins in bps
+var bucketEdges = []float64{0, 5, 10, 20, 50, 100}
+
+// PairDistribution is the sampled spread distribution of an exchange pair
+type PairDistribution struct {
+	Long    string   `json:"long_exchange"`
+	Short   string   `json:"short_exchange"`
+	Samples int64    `json:"samples"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// PersistenceStats describes how long opportunities on a symbol lasted
+type PersistenceStats struct {
+	Canonical string  `json:"canonical"`
+	Episodes  int64   `json:"episodes"`
+	TotalMs   int64   `json:"total_ms"`
+	MaxMs     int64   `json:"max_ms"`
+	AvgMs     float64 `json:"avg_ms"`
+}
+
+// EpisodeThresholds are the spread levels, in bps, for which each episode
+// records how long the spread stayed at or above the level
+var EpisodeThresholds = []float64{2, 5, 10, 15, 20, 30, 50, 75, 100}
+
+// ThresholdTime is the time an episode spent at or above a spread level
+type ThresholdTime struct {
+	Bps float64 `json:"bps"`
+	Ms  int64   `json:"ms"`
+}
+
+// Episode is one opportunity from first to last publication
+type Episode struct {
+	ID              string          `json:"id"`
+	Canonical       string          `json:"canonical"`
+	LongExchange    string          `json:"long_exchange"`
+	ShortExchange   string          `json:"short_exchange"`
+	Start           time.Time       `json:"start"`
+	DurationMs      int64           `json:"duration_ms"`
+	PeakBps         float64         `json:"peak_bps"`
+	MinAfterPeakBps float64         `json:"min_after_peak_bps"` // Lowest spread seen after the peak
+	AboveMs         []ThresholdTime `json:"above_ms"`           // Only levels that were reached
+}
+
+// AboveMsAt returns the time the episode spent at or above a level
+func (e *Episode) AboveMsAt(bps float64) int64 {
+	for _, t := range e.AboveMs {
+		if t.Bps == bps {
+			return t.Ms
+		}
+	}
+	return 0
+}
+
+// episode is an opportunity currently being tracked
+type episode struct {
+	Episode
+	lastSeen time.Time
+	lastBps  float64
+	above    []int64 // Per EpisodeThresholds, in ms
+}
+
+// closeEpisodeScript records an ended opportunity, keeping the max duration
+var closeEpisodeScript = redis.NewScript(`
+local cur = tonumber(redis.call('HGET', KEYS[1], 'max_ms') or '0')
+if tonumber(ARGV[1]) > cur then
+	redis.call('HSET', KEYS[1], 'max_ms', ARGV[1])
+end
+redis.call('HINCRBY', KEYS[1], 'episodes', 1)
+redis.call('HINCRBY', KEYS[1], 'total_ms', ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// Store records published spreads into daily Redis aggregates and answers
+// research queries over them
+type Store struct {
+	mu     sync.Mutex
+	client *redis.Client
+	config Config
+
+	lastSample time.Time
+	peakDate   string
+	peaks      map[string]float64  // Spread ID -> peak bps recorded today
+	open       map[string]*episode // Spread ID -> opportunity still being published
+
+	renames Renames
+}
+
+// Renames gives the names a symbol was recorded under before it was
+// renamed; *normalizer.InstrumentNormalizer implements it
+type Renames interface {
+	FormerCanonicals(canonical string, since time.Time) []string
+	FormerSymbols(exchange connector.ExchangeID, symbol string, since time.Time) []string
+}
+
+// SetRenames makes queries for a renamed symbol include what was recorded
+// under its former names, so history carries across the rename
+func (s *Store) SetRenames(r Renames) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renames = r
+}
+
+// formerCanonicals returns the names a canonical was recorded under since a time
+func (s *Store) formerCanonicals(canonical string, since time.Time) []string {
+	s.mu.Lock()
+	r := s.renames
+	s.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+	return r.FormerCanonicals(canonical, since)
+}
+
+// NewStore creates a new history store
+func NewStore(client *redis.Client, config Config) *Store {
+	return &Store{
+		client: client,
+		config: config,
+		peaks:  make(map[string]float64),
+		open:   make(map[string]*episode),
+	}
+}
+
+// Record ingests the spreads published in one cycle. Opportunity lifetimes
+// are tracked on every call; peaks and distributions are sampled. Times,
+// days and sampling all follow the spreads' exchange event time, not the
+// time they were recorded, so a replay aggregates like the live run.
+func (s *Store) Record(spreads []*spread.SpreadOpportunity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	s.trackEpisodes(ctx, spreads)
+	if len(spreads) == 0 {
+		return
+	}
+
+	var now time.Time
+	for _, opp := range spreads {
+		if at := spreadTime(opp); at.After(now) {
+			now = at
+		}
+	}
+	if now.Sub(s.lastSample) < s.config.SampleInterval {
+		return
+	}
+	s.lastSample = now
+	date := now.Format(DateLayout)
+
+	if date != s.peakDate {
+		s.loadPeaks(ctx, date)
+	}
+
+	pipe := s.client.Pipeline()
+	pairs := make(map[string]bool)
+	for _, opp := range spreads {
+		long, short := string(opp.LongExchange), string(opp.ShortExchange)
+
+		distKey := keyspace.HistoryDistKey(date, long, short)
+		pipe.HIncrBy(ctx, distKey, bucketLabel(bucketIndex(opp.SpreadBps)), 1)
+		pipe.Expire(ctx, distKey, s.config.Retention)
+		pairs[long+":"+short] = true
+
+		if opp.SpreadBps <= s.peaks[opp.ID] {
+			continue
+		}
+		data, err := json.Marshal(opp)
+		if err != nil {
+			continue
+		}
+		s.peaks[opp.ID] = opp.SpreadBps
+		pipe.ZAdd(ctx, keyspace.HistoryTopKey(date), redis.Z{Score: opp.SpreadBps, Member: opp.ID})
+		pipe.HSet(ctx, keyspace.HistoryPeakKey(date), opp.ID, data)
+	}
+
+	if len(pairs) > 0 {
+		members := make([]interface{}, 0, len(pairs))
+		for p := range pairs {
+			members = append(members, p)
+		}
+		pipe.SAdd(ctx, keyspace.HistoryPairsKey(date), members...)
+	}
+	for _, key := range []string{keyspace.HistoryTopKey(date), keyspace.HistoryPeakKey(date), keyspace.HistoryPairsKey(date)} {
+		pipe.Expire(ctx, key, s.config.Retention)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to record spread history")
+	}
+}
+
+// spreadTime returns a spread's event time in UTC, falling back to its
+// computation time for spreads recorded before event times were stamped
+func spreadTime(opp *spread.SpreadOpportunity) time.Time {
+	if !opp.EventTime.IsZero() {
+		return opp.EventTime.UTC()
+	}
+	if !opp.UpdatedAt.IsZero() {
+		return opp.UpdatedAt.UTC()
+	}
+	return time.Now().UTC()
+}
+
+// trackEpisodes opens episodes for new spreads, updates running ones and
+// closes those no longer published. A spread stamped before the episode's
+// last update arrived late; it keeps the episode open but its time is not
+// attributed, so out-of-order cycles can't shorten or double-count it.
+func (s *Store) trackEpisodes(ctx context.Context, spreads []*spread.SpreadOpportunity) {
+	seen := make(map[string]bool, len(spreads))
+	for _, opp := range spreads {
+		seen[opp.ID] = true
+		now := spreadTime(opp)
+		ep, ok := s.open[opp.ID]
+		if !ok {
+			s.open[opp.ID] = &episode{
+				Episode: Episode{
+					ID:              opp.ID,
+					Canonical:       opp.Canonical,
+					LongExchange:    string(opp.LongExchange),
+					ShortExchange:   string(opp.ShortExchange),
+					Start:           now,
+					PeakBps:         opp.SpreadBps,
+					MinAfterPeakBps: opp.SpreadBps,
+				},
+				lastSeen: now,
+				lastBps:  opp.SpreadBps,
+				above:    make([]int64, len(EpisodeThresholds)),
+			}
+			continue
+		}
+
+		if now.Before(ep.lastSeen) {
+			metrics.HistoryLateSpreads.Inc()
+			continue
+		}
+
+		// Attribute the time since the last cycle to the previous level
+		dt := now.Sub(ep.lastSeen).Milliseconds()
+		for i, level := range EpisodeThresholds {
+			if ep.lastBps >= level {
+				ep.above[i] += dt
+			}
+		}
+		ep.lastSeen = now
+		ep.lastBps = opp.SpreadBps
+
+		if opp.SpreadBps > ep.PeakBps {
+			ep.PeakBps = opp.SpreadBps
+			ep.MinAfterPeakBps = opp.SpreadBps
+		} else if opp.SpreadBps < ep.MinAfterPeakBps {
+			ep.MinAfterPeakBps = opp.SpreadBps
+		}
+	}
+
+	retentionMs := strconv.FormatInt(s.config.Retention.Milliseconds(), 10)
+	for id, ep := range s.open {
+		if seen[id] {
+			continue
+		}
+		delete(s.open, id)
+		s.closeEpisode(ctx, ep, ep.lastSeen.Format(DateLayout), retentionMs)
+	}
+}
+
+// closeEpisode updates the symbol's persistence counters and appends the
+// episode to the day's episode stream
+func (s *Store) closeEpisode(ctx context.Context, ep *episode, date, retentionMs string) {
+	ep.DurationMs = ep.lastSeen.Sub(ep.Start).Milliseconds()
+	for i, ms := range ep.above {
+		if ms > 0 {
+			ep.AboveMs = append(ep.AboveMs, ThresholdTime{Bps: EpisodeThresholds[i], Ms: ms})
+		}
+	}
+
+	key := keyspace.HistoryPersistKey(date, ep.Canonical)
+	durationMs := strconv.FormatInt(ep.DurationMs, 10)
+	if err := closeEpisodeScript.Run(ctx, s.client, []string{key}, durationMs, retentionMs).Err(); err != nil {
+		log.Error().Err(err).Str("spread", ep.ID).Msg("Failed to record spread persistence")
+		return
+	}
+
+	data, err := json.Marshal(&ep.Episode)
+	if err != nil {
+		return
+	}
+
+	symbolsKey := keyspace.HistorySymbolsKey(date)
+	episodesKey := keyspace.HistoryEpisodesKey(date)
+	pipe := s.client.Pipeline()
+	pipe.SAdd(ctx, symbolsKey, ep.Canonical)
+	pipe.Expire(ctx, symbolsKey, s.config.Retention)
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: episodesKey,
+		MaxLen: keyspace.HistoryEpisodesMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	})
+	pipe.Expire(ctx, episodesKey, s.config.Retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Str("spread", ep.ID).Msg("Failed to record spread episode")
+	}
+}
+
+// loadPeaks reloads today's recorded peaks so a restart doesn't overwrite
+// higher snapshots with lower ones
+func (s *Store) loadPeaks(ctx context.Context, date string) {
+	s.peakDate = date
+	s.peaks = make(map[string]float64)
+
+	scores, err := s.client.ZRangeWithScores(ctx, keyspace.HistoryTopKey(date), 0, -1).Result()
+	if err != nil {
+		log.Warn().Err(err).Str("date", date).Msg("Failed to load spread history peaks")
+		return
+	}
+	for _, z := range scores {
+		if id, ok := z.Member.(string); ok {
+			s.peaks[id] = z.Score
+		}
+	}
+}
+
+// TopByDay returns the spreads with the highest peak bps on a date, each as
+// its snapshot at the peak
+func (s *Store) TopByDay(ctx context.Context, date string, limit int) ([]*spread.SpreadOpportunity, error) {
+	ids, err := s.client.ZRevRange(ctx, keyspace.HistoryTopKey(date), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*spread.SpreadOpportunity{}, nil
+	}
+
+	values, err := s.client.HMGet(ctx, keyspace.HistoryPeakKey(date), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*spread.SpreadOpportunity, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var opp spread.SpreadOpportunity
+		if err := json.Unmarshal([]byte(data), &opp); err != nil {
+			continue
+		}
+		result = append(result, &opp)
+	}
+	return result, nil
+}
+
+// Distribution returns the spread distribution on a date for one exchange
+// pair, or for every pair seen that day if long and short are empty
+func (s *Store) Distribution(ctx context.Context, date, long, short string) ([]PairDistribution, error) {
+	var pairs []string
+	if long != "" && short != "" {
+		pairs = []string{long + ":" + short}
+	} else {
+		members, err := s.client.SMembers(ctx, keyspace.HistoryPairsKey(date)).Result()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(members)
+		pairs = members
+	}
+
+	result := make([]PairDistribution, 0, len(pairs))
+	for _, pair := range pairs {
+		l, sh, ok := strings.Cut(pair, ":")
+		if !ok {
+			continue
+		}
+		counts, err := s.client.HGetAll(ctx, keyspace.HistoryDistKey(date, l, sh)).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		dist := PairDistribution{Long: l, Short: sh, Buckets: make([]Bucket, len(bucketEdges))}
+		for i := range bucketEdges {
+			b := Bucket{Label: bucketLabel(i), Min: bucketEdges[i]}
+			if i+1 < len(bucketEdges) {
+				b.Max = bucketEdges[i+1]
+			}
+			b.Count, _ = strconv.ParseInt(counts[b.Label], 10, 64)
+			dist.Samples += b.Count
+			dist.Buckets[i] = b
+		}
+		result = append(result, dist)
+	}
+	return result, nil
+}
+
+// Persistence returns opportunity lifetime stats on a date for one symbol,
+// or for every symbol seen that day if canonical is empty. One symbol's
+// stats include those recorded under its former names.
+func (s *Store) Persistence(ctx context.Context, date, canonical string) ([]PersistenceStats, error) {
+	var symbols []string
+	if canonical != "" {
+		symbols = []string{canonical}
+	} else {
+		members, err := s.client.SMembers(ctx, keyspace.HistorySymbolsKey(date)).Result()
+		if err != nil {
+			return nil, err
+		}
+		symbols = members
+	}
+
+	var names []string
+	if canonical != "" {
+		if day, err := time.Parse(DateLayout, date); err == nil {
+			names = s.formerCanonicals(canonical, day)
+		}
+	}
+
+	result := make([]PersistenceStats, 0, len(symbols))
+	for _, sym := range symbols {
+		stats := PersistenceStats{Canonical: sym}
+		for _, name := range append([]string{sym}, names...) {
+			fields, err := s.client.HGetAll(ctx, keyspace.HistoryPersistKey(date, name)).Result()
+			if err != nil {
+				return nil, err
+			}
+			episodes, _ := strconv.ParseInt(fields["episodes"], 10, 64)
+			totalMs, _ := strconv.ParseInt(fields["total_ms"], 10, 64)
+			maxMs, _ := strconv.ParseInt(fields["max_ms"], 10, 64)
+			stats.Episodes += episodes
+			stats.TotalMs += totalMs
+			stats.MaxMs = max(stats.MaxMs, maxMs)
+		}
+		if stats.Episodes > 0 {
+			stats.AvgMs = float64(stats.TotalMs) / float64(stats.Episodes)
+		}
+		result = append(result, stats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TotalMs > result[j].TotalMs
+	})
+	return result, nil
+}
+
+// Episodes returns every opportunity episode that ended on a date
+func (s *Store) Episodes(ctx context.Context, date string) ([]Episode, error) {
+	const pageSize = 1000
+	key := keyspace.HistoryEpisodesKey(date)
+
+	var episodes []Episode
+	start := "-"
+	for {
+		msgs, err := s.client.XRangeN(ctx, key, start, "+", pageSize).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			data, ok := msg.Values["data"].(string)
+			if !ok {
+				continue
+			}
+			var ep Episode
+			if err := json.Unmarshal([]byte(data), &ep); err != nil {
+				continue
+			}
+			episodes = append(episodes, ep)
+		}
+		if len(msgs) < pageSize {
+			return episodes, nil
+		}
+		start = "(" + msgs[len(msgs)-1].ID
+	}
+}
+
+// bucketIndex returns the histogram bin for a spread
+func bucketIndex(bps float64) int {
+	for i := len(bucketEdges) - 1; i > 0; i-- {
+		if bps >= bucketEdges[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+// bucketLabel returns the hash field name of a histogram bin, e.g. "5-10" or "100+"
+func bucketLabel(i int) string {
+	if i+1 >= len(bucketEdges) {
+		return fmt.Sprintf("%g+", bucketEdges[i])
+	}
+	return fmt.Sprintf("%g-%g", bucketEdges[i], bucketEdges[i+1])
+}
@@ -0,0 +1,135 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// MaxSessionDays is the longest date range a session query may span; older
+// episodes have expired anyway
+const MaxSessionDays = 31
+
+// SessionBucket aggregates the episodes that started in one hour-of-day,
+// day-of-week or both (UTC)
+type SessionBucket struct {
+	Weekday    *time.Weekday `json:"weekday,omitempty"` // 0 is Sunday; unset in hour-only buckets
+	Hour       *int          `json:"hour,omitempty"`    // Unset in weekday-only buckets
+	Episodes   int64         `json:"episodes"`
+	TotalMs    int64         `json:"total_ms"`
+	AvgPeakBps float64       `json:"avg_peak_bps"`
+	MaxPeakBps float64       `json:"max_peak_bps"`
+}
+
+// SessionStats is the time-of-day and day-of-week profile of one exchange
+// pair's opportunities
+type SessionStats struct {
+	Long      string          `json:"long_exchange"`
+	Short     string          `json:"short_exchange"`
+	Episodes  int64           `json:"episodes"`
+	ByHour    []SessionBucket `json:"by_hour"`         // 24 buckets
+	ByWeekday []SessionBucket `json:"by_weekday"`      // 7 buckets, Sunday first
+	ByHourDay []SessionBucket `json:"by_weekday_hour"` // Non-empty weekday-hour cells
+}
+
+type sessionAcc struct {
+	episodes int64
+	totalMs  int64
+	peakSum  float64
+	peakMax  float64
+}
+
+func (a *sessionAcc) add(ep *Episode) {
+	a.episodes++
+	a.totalMs += ep.DurationMs
+	a.peakSum += ep.PeakBps
+	if ep.PeakBps > a.peakMax {
+		a.peakMax = ep.PeakBps
+	}
+}
+
+func (a *sessionAcc) bucket(weekday *time.Weekday, hour *int) SessionBucket {
+	b := SessionBucket{Weekday: weekday, Hour: hour, Episodes: a.episodes, TotalMs: a.totalMs, MaxPeakBps: a.peakMax}
+	if a.episodes > 0 {
+		b.AvgPeakBps = a.peakSum / float64(a.episodes)
+	}
+	return b
+}
+
+type pairSessions struct {
+	episodes  int64
+	byHour    [24]sessionAcc
+	byWeekday [7]sessionAcc
+	cells     [7][24]sessionAcc
+}
+
+// Sessions buckets the opportunity episodes that ended between two dates
+// (inclusive) by the UTC hour and weekday they started in, per exchange
+// pair. long and short filter to one pair when both are set. Pairs are
+// sorted by episode count.
+func (s *Store) Sessions(ctx context.Context, from, to, long, short string) ([]SessionStats, error) {
+	start, end, err := dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make(map[[2]string]*pairSessions)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		episodes, err := s.Episodes(ctx, day.Format(DateLayout))
+		if err != nil {
+			return nil, err
+		}
+		for i := range episodes {
+			ep := &episodes[i]
+			if long != "" && (ep.LongExchange != long || ep.ShortExchange != short) {
+				continue
+			}
+			key := [2]string{ep.LongExchange, ep.ShortExchange}
+			p, ok := pairs[key]
+			if !ok {
+				p = &pairSessions{}
+				pairs[key] = p
+			}
+			started := ep.Start.UTC()
+			wd, hour := started.Weekday(), started.Hour()
+			p.episodes++
+			p.byHour[hour].add(ep)
+			p.byWeekday[wd].add(ep)
+			p.cells[wd][hour].add(ep)
+		}
+	}
+
+	result := make([]SessionStats, 0, len(pairs))
+	for key, p := range pairs {
+		stats := SessionStats{
+			Long:      key[0],
+			Short:     key[1],
+			Episodes:  p.episodes,
+			ByHour:    make([]SessionBucket, 24),
+			ByWeekday: make([]SessionBucket, 7),
+		}
+		for h := range p.byHour {
+			hour := h
+			stats.ByHour[h] = p.byHour[h].bucket(nil, &hour)
+		}
+		for d := range p.byWeekday {
+			wd := time.Weekday(d)
+			stats.ByWeekday[d] = p.byWeekday[d].bucket(&wd, nil)
+			for h := range p.cells[d] {
+				if p.cells[d][h].episodes == 0 {
+					continue
+				}
+				hour := h
+				stats.ByHourDay = append(stats.ByHourDay, p.cells[d][h].bucket(&wd, &hour))
+			}
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Episodes != result[j].Episodes {
+			return result[i].Episodes > result[j].Episodes
+		}
+		return result[i].Long+":"+result[i].Short < result[j].Long+":"+result[j].Short
+	})
+	return result, nil
+}
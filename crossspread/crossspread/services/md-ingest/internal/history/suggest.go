@@ -0,0 +1,109 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// SuggestConfig controls threshold suggestion
+type SuggestConfig struct {
+	FillTime       time.Duration // Time a spread must stay above entry for both legs to fill
+	MinProbability float64       // Required fill-before-close and exit probability
+	MinEpisodes    int           // Episodes reaching a level needed to trust its probability
+}
+
+// DefaultSuggestConfig returns the default suggestion configuration
+func DefaultSuggestConfig() SuggestConfig {
+	return SuggestConfig{
+		FillTime:       2 * time.Second,
+		MinProbability: 0.7,
+		MinEpisodes:    20,
+	}
+}
+
+// Suggestion is a suggested entry/exit threshold for one spread
+type Suggestion struct {
+	EntryBps        float64 `json:"entry_bps"`
+	ExitBps         float64 `json:"exit_bps"`
+	FillProbability float64 `json:"fill_probability"` // Share of episodes reaching entry that stayed above it for FillTime
+	ExitProbability float64 `json:"exit_probability"` // Share of filled episodes that later fell to exit
+	Episodes        int     `json:"episodes"`         // Episodes reaching entry
+}
+
+// Suggest computes per-spread thresholds from opportunity episodes, keyed by
+// spread ID (canonical:long:short). Spreads without a level meeting
+// MinProbability on MinEpisodes episodes are omitted.
+//
+// Entry is the lowest level whose fill-before-close probability meets
+// MinProbability. Exit is the highest lower level that filled episodes fell
+// back to with at least MinProbability before the opportunity closed.
+func Suggest(episodes []Episode, cfg SuggestConfig) map[string]Suggestion {
+	byID := make(map[string][]Episode)
+	for _, ep := range episodes {
+		byID[ep.ID] = append(byID[ep.ID], ep)
+	}
+
+	fillMs := cfg.FillTime.Milliseconds()
+	result := make(map[string]Suggestion)
+	for id, eps := range byID {
+		for i, entry := range EpisodeThresholds {
+			var reached, filled []Episode
+			for _, ep := range eps {
+				if ep.PeakBps < entry {
+					continue
+				}
+				reached = append(reached, ep)
+				if ep.AboveMsAt(entry) >= fillMs {
+					filled = append(filled, ep)
+				}
+			}
+			if len(reached) == 0 || len(reached) < cfg.MinEpisodes {
+				break // Higher levels are reached by even fewer episodes
+			}
+			fillProb := float64(len(filled)) / float64(len(reached))
+			if fillProb < cfg.MinProbability {
+				continue
+			}
+
+			exit, exitProb, ok := suggestExit(filled, EpisodeThresholds[:i], cfg.MinProbability)
+			if !ok {
+				continue
+			}
+			result[id] = Suggestion{
+				EntryBps:        entry,
+				ExitBps:         exit,
+				FillProbability: fillProb,
+				ExitProbability: exitProb,
+				Episodes:        len(reached),
+			}
+			break
+		}
+	}
+	return result
+}
+
+// suggestExit returns the highest level in levels that at least minProb of
+// the episodes fell back to after their peak
+func suggestExit(episodes []Episode, levels []float64, minProb float64) (float64, float64, bool) {
+	if len(episodes) == 0 {
+		return 0, 0, false
+	}
+
+	lows := make([]float64, len(episodes))
+	for i, ep := range episodes {
+		lows[i] = ep.MinAfterPeakBps
+	}
+	sort.Float64s(lows)
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		n := sort.Search(len(lows), func(j int) bool { return lows[j] > levels[i] })
+		prob := float64(n) / float64(len(lows))
+		if prob >= minProb {
+			return levels[i], prob, true
+		}
+	}
+
+	// Spreads are only published above the discovery minimum, so the
+	// lowest observed exit is the opportunity closing
+	return 0, 1, true
+}
@@ -0,0 +1,339 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Index payloads are defined in pkg/mdtypes for clients
+type (
+	IndexConstituent = mdtypes.IndexConstituent
+	IndexPrice       = mdtypes.IndexPrice
+)
+
+// Config controls index construction
+type Config struct {
+	// Constituents limits which venues contribute to the index. Empty means all.
+	// Deviation is still reported for venues outside the set.
+	Constituents    []connector.ExchangeID
+	MinConstituents int           // Minimum fresh venues required to publish
+	StaleAfter      time.Duration // Quotes older than this are excluded
+	OutlierBps      float64       // Deviation above this is flagged as an outlier
+	PublishInterval time.Duration
+}
+
+// DefaultConfig returns the default index configuration
+func DefaultConfig() Config {
+	return Config{
+		MinConstituents: 2,
+		StaleAfter:      10 * time.Second,
+		OutlierBps:      100,
+		PublishInterval: time.Second,
+	}
+}
+
+type quote struct {
+	mid       float64
+	updatedAt time.Time
+}
+
+// Builder maintains per-venue quotes and publishes index prices
+type Builder struct {
+	mu sync.RWMutex
+
+	config       Config
+	constituents map[connector.ExchangeID]bool
+	publisher    *publisher.RedisPublisher
+
+	// Latest mid per canonical symbol per exchange
+	quotes map[string]map[connector.ExchangeID]quote
+
+	// 24h volume per canonical symbol per exchange (from REST tickers)
+	volumes map[string]map[connector.ExchangeID]float64
+
+	// Last computed index per canonical symbol
+	latest map[string]*IndexPrice
+
+	done chan struct{}
+}
+
+// NewBuilder creates a new index builder
+func NewBuilder(config Config, pub *publisher.RedisPublisher) *Builder {
+	constituents := make(map[connector.ExchangeID]bool, len(config.Constituents))
+	for _, ex := range config.Constituents {
+		constituents[ex] = true
+	}
+
+	return &Builder{
+		config:       config,
+		constituents: constituents,
+		publisher:    pub,
+		quotes:       make(map[string]map[connector.ExchangeID]quote),
+		volumes:      make(map[string]map[connector.ExchangeID]float64),
+		latest:       make(map[string]*IndexPrice),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start periodically computes and publishes index prices
+func (b *Builder) Start(ctx context.Context) {
+	ticker := time.NewTicker(b.config.PublishInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.publishAll()
+		}
+	}
+}
+
+// Stop stops the builder
+func (b *Builder) Stop() {
+	close(b.done)
+}
+
+// HandleOrderbook updates the venue mid from an orderbook
+func (b *Builder) HandleOrderbook(ob *connector.Orderbook) {
+	bid, ask := ob.BestBid, ob.BestAsk
+	if bid == 0 && len(ob.Bids) > 0 {
+		bid = ob.Bids[0].Price
+	}
+	if ask == 0 && len(ob.Asks) > 0 {
+		ask = ob.Asks[0].Price
+	}
+	if bid <= 0 || ask <= 0 || ob.Canonical == "" {
+		return
+	}
+
+	b.setQuote(ob.Canonical, ob.ExchangeID, (bid+ask)/2, time.Now())
+}
+
+// HandleTicker updates volume, and the mid if the ticker carries a BBO
+func (b *Builder) HandleTicker(ticker connector.PriceTicker) {
+	if ticker.Canonical == "" {
+		return
+	}
+
+	b.mu.Lock()
+	if b.volumes[ticker.Canonical] == nil {
+		b.volumes[ticker.Canonical] = make(map[connector.ExchangeID]float64)
+	}
+	b.volumes[ticker.Canonical][ticker.ExchangeID] = ticker.Volume24h
+	b.mu.Unlock()
+
+	if ticker.BidPrice > 0 && ticker.AskPrice > 0 {
+		// Don't let a REST ticker overwrite a fresher WebSocket quote
+		b.mu.RLock()
+		existing, ok := b.quotes[ticker.Canonical][ticker.ExchangeID]
+		b.mu.RUnlock()
+		if ok && existing.updatedAt.After(ticker.Timestamp) {
+			return
+		}
+		b.setQuote(ticker.Canonical, ticker.ExchangeID, (ticker.BidPrice+ticker.AskPrice)/2, ticker.Timestamp)
+	}
+}
+
+func (b *Builder) setQuote(canonical string, exchange connector.ExchangeID, mid float64, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.quotes[canonical] == nil {
+		b.quotes[canonical] = make(map[connector.ExchangeID]quote)
+	}
+	b.quotes[canonical][exchange] = quote{mid: mid, updatedAt: at}
+}
+
+// MemoryUsage estimates the bytes held by cached quotes and index prices
+func (b *Builder) MemoryUsage() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	const (
+		quoteSize       = 64  // map entry with quote
+		constituentSize = 120 // IndexConstituent
+	)
+
+	var total int64
+	for _, quotes := range b.quotes {
+		total += int64(len(quotes)) * quoteSize
+	}
+	for _, idx := range b.latest {
+		total += int64(len(idx.Constituents)) * constituentSize
+	}
+	return total
+}
+
+// Get returns the last computed index for a canonical symbol
+func (b *Builder) Get(canonical string) (*IndexPrice, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	idx, ok := b.latest[canonical]
+	return idx, ok
+}
+
+// IndexPrice returns the last computed index price for a canonical symbol
+func (b *Builder) IndexPrice(canonical string) (float64, bool) {
+	idx, ok := b.Get(canonical)
+	if !ok {
+		return 0, false
+	}
+	return idx.Price, true
+}
+
+// Compute builds the index for a canonical symbol from the current quotes.
+// Returns nil if fewer than MinConstituents fresh venues are available.
+func (b *Builder) Compute(canonical string, now time.Time) *IndexPrice {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.compute(canonical, now)
+}
+
+func (b *Builder) compute(canonical string, now time.Time) *IndexPrice {
+	quotes := b.quotes[canonical]
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	constituents := make([]IndexConstituent, 0, len(quotes))
+	for exchange, q := range quotes {
+		c := IndexConstituent{
+			Exchange:  exchange,
+			Price:     q.mid,
+			Volume24h: b.volumes[canonical][exchange],
+			Stale:     now.Sub(q.updatedAt) > b.config.StaleAfter,
+			UpdatedAt: q.updatedAt,
+		}
+		c.Included = !c.Stale && (len(b.constituents) == 0 || b.constituents[exchange])
+		constituents = append(constituents, c)
+	}
+
+	sort.Slice(constituents, func(i, j int) bool {
+		return constituents[i].Price < constituents[j].Price
+	})
+
+	price, included := weightedMedian(constituents)
+	if included < b.config.MinConstituents || price <= 0 {
+		return nil
+	}
+
+	for i := range constituents {
+		c := &constituents[i]
+		c.DeviationBps = (c.Price - price) / price * 10000
+		c.Outlier = math.Abs(c.DeviationBps) > b.config.OutlierBps
+	}
+
+	return &IndexPrice{
+		Canonical:    canonical,
+		Price:        price,
+		Included:     included,
+		Constituents: constituents,
+		Timestamp:    now,
+	}
+}
+
+// weightedMedian returns the volume-weighted median of included constituents
+// (which must be sorted by price) and fills in their normalized weights.
+// Venues without volume data get equal weight when none have volume.
+func weightedMedian(constituents []IndexConstituent) (float64, int) {
+	var total float64
+	included := 0
+	for _, c := range constituents {
+		if c.Included {
+			total += c.Volume24h
+			included++
+		}
+	}
+	if included == 0 {
+		return 0, 0
+	}
+
+	equalWeight := total <= 0
+	for i := range constituents {
+		c := &constituents[i]
+		if !c.Included {
+			continue
+		}
+		if equalWeight {
+			c.Weight = 1 / float64(included)
+		} else {
+			c.Weight = c.Volume24h / total
+		}
+	}
+
+	var cumulative float64
+	for i, c := range constituents {
+		if !c.Included {
+			continue
+		}
+		cumulative += c.Weight
+		if cumulative > 0.5 {
+			return c.Price, included
+		}
+		if cumulative == 0.5 {
+			// Exactly on the boundary: average with the next included price
+			for _, next := range constituents[i+1:] {
+				if next.Included {
+					return (c.Price + next.Price) / 2, included
+				}
+			}
+			return c.Price, included
+		}
+	}
+
+	return 0, included
+}
+
+func (b *Builder) publishAll() {
+	now := time.Now()
+
+	b.mu.Lock()
+	indexes := make([]*IndexPrice, 0, len(b.quotes))
+	for canonical := range b.quotes {
+		idx := b.compute(canonical, now)
+		if idx == nil {
+			delete(b.latest, canonical)
+			continue
+		}
+		b.latest[canonical] = idx
+		indexes = append(indexes, idx)
+	}
+	b.mu.Unlock()
+
+	for _, idx := range indexes {
+		for _, c := range idx.Constituents {
+			metrics.RecordIndexDeviation(string(c.Exchange), c.DeviationBps, c.Stale, c.Outlier)
+		}
+
+		if b.publisher == nil {
+			continue
+		}
+
+		data, err := json.Marshal(idx)
+		if err != nil {
+			log.Error().Err(err).Str("canonical", idx.Canonical).Msg("Failed to marshal index price")
+			continue
+		}
+		if err := b.publisher.SetIndex(idx.Canonical, data); err != nil {
+			log.Error().Err(err).Str("canonical", idx.Canonical).Msg("Failed to publish index price")
+			metrics.RedisPublishErrors.WithLabelValues("index").Inc()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package inventory
+
+import (
+	"math"
+	"sort"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// OptionLeg is an option position with its greeks per unit of underlying.
+// md-ingest does not ingest options yet; an OptionSource supplies legs
+// once a venue's option positions are tracked.
+type OptionLeg struct {
+	Exchange   connector.ExchangeID `json:"exchange"`
+	Instrument string               `json:"instrument"` // Exchange-native option symbol, e.g. BTC-27DEC24-60000-C
+	Underlying string               `json:"underlying"` // Base asset, e.g. BTC
+	Quantity   float64              `json:"quantity"`   // Contracts; negative is short
+	Multiplier float64              `json:"multiplier"` // Underlying units per contract
+	Delta      float64              `json:"delta"`
+	Gamma      float64              `json:"gamma"` // Delta change per unit move of the underlying
+}
+
+// OptionSource returns current option legs
+type OptionSource interface {
+	OptionLegs() []OptionLeg
+}
+
+// PriceSource returns the underlying price used to express exposure in USD
+type PriceSource func(underlying string) (float64, bool)
+
+// Exposure is the aggregate delta and gamma across perp and option legs
+// of one underlying, in underlying units unless suffixed USD
+type Exposure struct {
+	Underlying  string  `json:"underlying"`
+	PerpDelta   float64 `json:"perp_delta"`
+	OptionDelta float64 `json:"option_delta"`
+	Delta       float64 `json:"delta"`
+	Gamma       float64 `json:"gamma"`
+	Price       float64 `json:"price,omitempty"`
+	DeltaUSD    float64 `json:"delta_usd,omitempty"`
+	GammaUSD    float64 `json:"gamma_usd,omitempty"` // Change in DeltaUSD for a 1% move
+	PerpLegs    int     `json:"perp_legs"`
+	OptionLegs  int     `json:"option_legs"`
+}
+
+// SetOptionSource adds option legs to exposure aggregation
+func (s *Store) SetOptionSource(source OptionSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options = source
+}
+
+// Exposures aggregates delta and gamma per underlying, sorted by absolute
+// USD delta when prices are known. Perps contribute delta one per base
+// unit and no gamma.
+func (s *Store) Exposures(prices PriceSource) []Exposure {
+	s.mu.RLock()
+	options := s.options
+	s.mu.RUnlock()
+
+	byUnderlying := make(map[string]*Exposure)
+	get := func(underlying string) *Exposure {
+		e, ok := byUnderlying[underlying]
+		if !ok {
+			e = &Exposure{Underlying: underlying}
+			byUnderlying[underlying] = e
+		}
+		return e
+	}
+
+	for _, p := range s.List() {
+		e := get(connector.ParseCanonical(p.Canonical).Base)
+		e.PerpDelta += p.Quantity
+		e.PerpLegs++
+	}
+	if options != nil {
+		for _, leg := range options.OptionLegs() {
+			if leg.Quantity == 0 {
+				continue
+			}
+			mult := leg.Multiplier
+			if mult == 0 {
+				mult = 1
+			}
+			e := get(leg.Underlying)
+			e.OptionDelta += leg.Quantity * mult * leg.Delta
+			e.Gamma += leg.Quantity * mult * leg.Gamma
+			e.OptionLegs++
+		}
+	}
+
+	result := make([]Exposure, 0, len(byUnderlying))
+	for _, e := range byUnderlying {
+		e.Delta = e.PerpDelta + e.OptionDelta
+		if prices != nil {
+			if price, ok := prices(e.Underlying); ok && price > 0 {
+				e.Price = price
+				e.DeltaUSD = e.Delta * price
+				e.GammaUSD = e.Gamma * price * price / 100
+			}
+		}
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ai, aj := math.Abs(result[i].DeltaUSD), math.Abs(result[j].DeltaUSD)
+		if ai != aj {
+			return ai > aj
+		}
+		return result[i].Underlying < result[j].Underlying
+	})
+	return result
+}
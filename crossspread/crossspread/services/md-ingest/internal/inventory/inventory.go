@@ -0,0 +1,176 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Config controls the inventory store
+type Config struct {
+	RefreshInterval time.Duration
+}
+
+// DefaultConfig refreshes every second; executors update positions on
+// every fill and scoring should follow within a few spread cycles
+func DefaultConfig() Config {
+	return Config{RefreshInterval: time.Second}
+}
+
+// Position is the net perp position on a venue, in base units; negative is short
+type Position struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Canonical string               `json:"canonical"`
+	Quantity  float64              `json:"quantity"`
+}
+
+type positionKey struct {
+	exchange  connector.ExchangeID
+	canonical string
+}
+
+// Store serves net positions per venue and canonical symbol from an
+// in-memory copy of the Redis positions hash. Executors adjust it on fills;
+// spread scoring reads it without touching the network.
+type Store struct {
+	client *redis.Client
+	config Config
+
+	mu        sync.RWMutex
+	positions map[positionKey]float64
+	options   OptionSource // Optional option legs for exposure aggregation
+	done      chan struct{}
+}
+
+// New creates a new inventory store
+func New(client *redis.Client, config Config) *Store {
+	return &Store{
+		client:    client,
+		config:    config,
+		positions: make(map[positionKey]float64),
+		done:      make(chan struct{}),
+	}
+}
+
+// Position returns the net position on a venue in base units
+func (s *Store) Position(exchange connector.ExchangeID, canonical string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.positions[positionKey{exchange, canonical}]
+}
+
+// List returns every non-flat position sorted by canonical, then exchange
+func (s *Store) List() []Position {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Position, 0, len(s.positions))
+	for k, qty := range s.positions {
+		if qty != 0 {
+			result = append(result, Position{Exchange: k.exchange, Canonical: k.canonical, Quantity: qty})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Canonical != result[j].Canonical {
+			return result[i].Canonical < result[j].Canonical
+		}
+		return result[i].Exchange < result[j].Exchange
+	})
+	return result
+}
+
+// Set overwrites a position, e.g. after reconciling with the venue
+func (s *Store) Set(ctx context.Context, exchange connector.ExchangeID, canonical string, quantity float64) error {
+	field := positionField(exchange, canonical)
+	var err error
+	if quantity == 0 {
+		err = s.client.HDel(ctx, keyspace.Key(keyspace.PositionsKey), field).Err()
+	} else {
+		err = s.client.HSet(ctx, keyspace.Key(keyspace.PositionsKey), field, strconv.FormatFloat(quantity, 'f', -1, 64)).Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.positions[positionKey{exchange, canonical}] = quantity
+	s.mu.Unlock()
+	return nil
+}
+
+// Adjust adds a fill to a position: positive for buys, negative for sells.
+// Concurrent executors may adjust the same position.
+func (s *Store) Adjust(ctx context.Context, exchange connector.ExchangeID, canonical string, delta float64) (float64, error) {
+	qty, err := s.client.HIncrByFloat(ctx, keyspace.Key(keyspace.PositionsKey), positionField(exchange, canonical), delta).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.positions[positionKey{exchange, canonical}] = qty
+	s.mu.Unlock()
+	return qty, nil
+}
+
+// Refresh re-reads every position. Fields that don't parse are ignored.
+func (s *Store) Refresh(ctx context.Context) error {
+	raw, err := s.client.HGetAll(ctx, keyspace.Key(keyspace.PositionsKey)).Result()
+	if err != nil {
+		return err
+	}
+
+	positions := make(map[positionKey]float64, len(raw))
+	for field, v := range raw {
+		exchange, canonical, ok := strings.Cut(field, ":")
+		qty, err := strconv.ParseFloat(v, 64)
+		if !ok || err != nil {
+			log.Warn().Str("field", field).Str("value", v).Msg("Ignoring invalid position")
+			continue
+		}
+		positions[positionKey{connector.ExchangeID(exchange), canonical}] = qty
+	}
+
+	s.mu.Lock()
+	s.positions = positions
+	s.mu.Unlock()
+	return nil
+}
+
+// Start refreshes positions until the context is cancelled or Stop is
+// called. The last known positions are kept while Redis is unreachable.
+func (s *Store) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh positions")
+			}
+		}
+	}
+}
+
+// Stop stops the refresh loop
+func (s *Store) Stop() {
+	close(s.done)
+}
+
+func positionField(exchange connector.ExchangeID, canonical string) string {
+	return fmt.Sprintf("%s:%s", exchange, canonical)
+}
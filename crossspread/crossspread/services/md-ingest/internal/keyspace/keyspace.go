@@ -0,0 +1,858 @@
+package keyspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// prefix namespaces every key and channel so several environments or
+// instances can share one Redis, e.g. "md:prod-a:"
+var prefix string
+
+// SetPrefix sets the namespace prepended to every key and channel. It is
+// process-wide and must be set before any Redis access. A missing trailing
+// colon is added.
+func SetPrefix(p string) {
+	if p != "" && !strings.HasSuffix(p, ":") {
+		p += ":"
+	}
+	prefix = p
+}
+
+// Prefix returns the current key namespace, empty by default
+func Prefix() string {
+	return prefix
+}
+
+// Key returns a key or channel name within the namespace. The patterns and
+// constants below are unprefixed; every Redis access goes through Key or one
+// of the key functions.
+func Key(name string) string {
+	return prefix + name
+}
+
+// Kind describes how a Redis key or channel is used
+type Kind string
+
+const (
+	KindStream Kind = "stream" // Redis Stream (XADD)
+	KindPubSub Kind = "pubsub" // Pub/Sub channel (PUBLISH)
+	KindString Kind = "string" // Plain key (SET)
+	KindSet    Kind = "set"    // Set (SADD)
+	KindHash   Kind = "hash"   // Hash (HSET/HINCRBY)
+	KindZSet   Kind = "zset"   // Sorted set (ZADD)
+)
+
+// Payload names used in the registry. The schema generator maps these to Go types.
+const (
+	PayloadOrderbook     = "Orderbook"
+	PayloadTrade         = "Trade"
+	PayloadFundingRate   = "FundingRate"
+	PayloadSpread        = "SpreadOpportunity"
+	PayloadSpreadSummary = "SpreadSummary"
+	PayloadSpreadID      = "SpreadID"
+	PayloadIndexPrice    = "IndexPrice"
+	PayloadCounter       = "Counter"
+	PayloadExchangePair  = "ExchangePair"
+	PayloadCanonical     = "Canonical"
+	PayloadEpisode       = "Episode"
+	PayloadRateBudget    = "RateBudget"
+	PayloadFlags         = "Flags"
+	PayloadBar           = "Bar"
+	PayloadOIEvent       = "OpenInterestEvent"
+	PayloadClaim         = "Claim"
+	PayloadMigration     = "MigrationFlag"
+	PayloadPositions     = "Positions"
+	PayloadSettlement    = "FundingSettlement"
+	PayloadFundingAction = "FundingAction"
+	PayloadSymbolStatus  = "SymbolStatusTransition"
+	PayloadTenantSpreads = "TenantSpreadSummary"
+	PayloadSettings      = "Settings"
+	PayloadPairMute      = "PairMute"
+	PayloadSymbolTiers   = "SymbolTiers"
+	PayloadSettingsEvent = "SettingsSection"
+	PayloadCorrelation   = "SpreadCorrelation"
+	PayloadBasisSummary  = "BasisSummary"
+	PayloadStrategyPnL   = "StrategyPnL"
+	PayloadDeRisk        = "DeRiskState"
+	PayloadVenueEquity   = "VenueEquity"
+	PayloadTransfers     = "TransferLatency"
+	PayloadHeartbeat     = "Heartbeat"
+	PayloadVenueHealth   = "VenueHealth"
+	PayloadWebhook       = "WebhookEndpoint"
+	PayloadFeedAlert     = "FeedAlert"
+	PayloadSubscriptions = "VenueSubscriptions"
+	PayloadBBO           = "BBO"
+	PayloadFalsePositive = "FalsePositiveTag"
+	PayloadFilter        = "FilterExpression"
+)
+
+// Key patterns written by md-ingest
+const (
+	OrderbookPattern     = "orderbook:{exchange}:{symbol}"
+	TradesPattern        = "trades:{exchange}:{symbol}"
+	FundingPattern       = "funding:{exchange}:{symbol}"
+	BBOPattern           = "bbo:{exchange}:{symbol}"
+	SpreadsStreamKey     = "spreads"
+	SpreadDataPattern    = "spread:data:{spread_id}"
+	SpreadChannelPattern = "spread:{spread_id}"
+	SpreadCanonicalChan  = "spread:{canonical}"
+	SpreadsActiveKey     = "spreads:active"
+	SpreadsListKey       = "spreads:list"
+	SpreadsSummaryChan   = "spreads:summary"
+	TenantSpreadsPattern = "tenant:{tenant}:spreads"
+	BasisKey             = "spreads:basis"
+	IndexPattern         = "index:{canonical}"
+
+	BarsPattern             = "bars:{exchange}:{symbol}:{interval}"
+	ConsolidatedBarsPattern = "bars:consolidated:{canonical}:{interval}"
+
+	OpenInterestEventsKey = "oi:events"
+
+	FundingSettlementsKey = "funding:settlements"
+	FundingActionsKey     = "funding:actions"
+
+	SymbolStatusEventsKey = "symbols:status"
+
+	FeedAlertsKey = "feeds:alerts"
+
+	HeartbeatPattern = "heartbeat:{channel}"
+
+	FalsePositivesKey = "feedback:false_positives"
+
+	SubscriptionStateKey = "subscriptions:state"
+
+	HistoryTopPattern      = "history:top:{date}"
+	HistoryPeakPattern     = "history:peak:{date}"
+	HistoryDistPattern     = "history:dist:{date}:{long}:{short}"
+	HistoryPairsPattern    = "history:pairs:{date}"
+	HistoryPersistPattern  = "history:persist:{date}:{canonical}"
+	HistorySymbolsPattern  = "history:symbols:{date}"
+	HistoryEpisodesPattern = "history:episodes:{date}"
+	HistoryBarsPattern     = "history:bars:{exchange}:{symbol}:{interval}"
+	HistoryCapturePattern  = "history:capture:{date}"
+	HistoryClustersKey     = "history:clusters"
+
+	RateBudgetPattern = "ratebudget:{exchange}"
+	VenueHealthKey    = "venues:health"
+	WebhooksKey       = "webhooks"
+
+	FlagsPattern = "flags:{env}"
+
+	SettingsPattern        = "settings:{env}"
+	SettingsMutesPattern   = "settings:{env}:mutes"
+	SettingsTiersPattern   = "settings:{env}:tiers"
+	SettingsFilterPattern  = "settings:{env}:filter"
+	SettingsChangedPattern = "settings:{env}:changed"
+
+	ClaimPattern       = "claim:{opportunity_id}"
+	TenantClaimPattern = "claim:tenant:{tenant}:{opportunity_id}"
+	MigrationsKey      = "execution:migrations"
+	PositionsKey       = "positions"
+	PnLKey             = "risk:pnl"
+	DeRiskKey          = "risk:derisk"
+	EquityKey          = "risk:equity"
+	TransfersKey       = "transfers:latency"
+)
+
+// Retention settings shared between the publisher and the registry
+const (
+	OrderbookStreamMaxLen = 1000
+	TradesStreamMaxLen    = 10000
+	FundingStreamMaxLen   = 1000
+	SpreadsStreamMaxLen   = 10000
+	SpreadDataTTL         = 5 * time.Minute
+	SpreadsListTTL        = 30 * time.Second
+	IndexTTL              = time.Minute
+	HistoryTTL            = 30 * 24 * time.Hour
+	HistoryEpisodesMaxLen = 200000
+	HistoryClustersTTL    = 24 * time.Hour
+	VenueHealthTTL        = time.Minute
+	SubscriptionStateTTL  = time.Hour
+	BarsStreamMaxLen      = 3600 // An hour of 1s bars, 2.5 days of 1m bars
+
+	OpenInterestEventsMaxLen = 10000
+	FundingEventsMaxLen      = 10000
+	SymbolStatusMaxLen       = 10000
+	FeedAlertsMaxLen         = 10000
+	FalsePositivesMaxLen     = 100000
+)
+
+// OrderbookKey returns the stream/channel name for an orderbook
+func OrderbookKey(exchange, symbol string) string {
+	return Key(fmt.Sprintf("orderbook:%s:%s", exchange, symbol))
+}
+
+// TradesKey returns the stream name for trades
+func TradesKey(exchange, symbol string) string {
+	return Key(fmt.Sprintf("trades:%s:%s", exchange, symbol))
+}
+
+// BBOKey returns the channel name for a symbol's best bid and offer
+func BBOKey(exchange, symbol string) string {
+	return Key(fmt.Sprintf("bbo:%s:%s", exchange, symbol))
+}
+
+// FundingKey returns the stream/channel name for funding rates
+func FundingKey(exchange, symbol string) string {
+	return Key(fmt.Sprintf("funding:%s:%s", exchange, symbol))
+}
+
+// SpreadDataKey returns the key holding the latest state of a spread
+func SpreadDataKey(spreadID string) string {
+	return Key(fmt.Sprintf("spread:data:%s", spreadID))
+}
+
+// SpreadChannel returns the Pub/Sub channel for a spread ID or canonical symbol
+func SpreadChannel(idOrCanonical string) string {
+	return Key(fmt.Sprintf("spread:%s", idOrCanonical))
+}
+
+// TenantSpreadsKey returns the key and channel holding the spreads a tenant
+// has credentials to trade on both legs
+func TenantSpreadsKey(tenant string) string {
+	return Key(fmt.Sprintf("tenant:%s:spreads", tenant))
+}
+
+// IndexKey returns the key and channel for a canonical symbol's index price
+func IndexKey(canonical string) string {
+	return Key(fmt.Sprintf("index:%s", canonical))
+}
+
+// BarsKey returns the stream/channel name for a venue's OHLCV bars of an
+// interval (1s, 1m)
+func BarsKey(exchange, symbol, interval string) string {
+	return Key(fmt.Sprintf("bars:%s:%s:%s", exchange, symbol, interval))
+}
+
+// ConsolidatedBarsKey returns the stream/channel name for a canonical
+// symbol's OHLCV bars built from every venue's trades
+func ConsolidatedBarsKey(canonical, interval string) string {
+	return Key(fmt.Sprintf("bars:consolidated:%s:%s", canonical, interval))
+}
+
+// HistoryTopKey returns the sorted set of spread IDs by peak bps for a UTC date (2006-01-02)
+func HistoryTopKey(date string) string {
+	return Key(fmt.Sprintf("history:top:%s", date))
+}
+
+// HistoryPeakKey returns the hash of peak spread snapshots for a date
+func HistoryPeakKey(date string) string {
+	return Key(fmt.Sprintf("history:peak:%s", date))
+}
+
+// HistoryDistKey returns the spread bps histogram for an exchange pair on a date
+func HistoryDistKey(date, long, short string) string {
+	return Key(fmt.Sprintf("history:dist:%s:%s:%s", date, long, short))
+}
+
+// HistoryPairsKey returns the set of "long:short" exchange pairs seen on a date
+func HistoryPairsKey(date string) string {
+	return Key(fmt.Sprintf("history:pairs:%s", date))
+}
+
+// HistoryPersistKey returns the persistence counters for a canonical symbol on a date
+func HistoryPersistKey(date, canonical string) string {
+	return Key(fmt.Sprintf("history:persist:%s:%s", date, canonical))
+}
+
+// HistorySymbolsKey returns the set of canonical symbols with persistence data on a date
+func HistorySymbolsKey(date string) string {
+	return Key(fmt.Sprintf("history:symbols:%s", date))
+}
+
+// HistoryEpisodesKey returns the stream of opportunity episodes that ended on a date
+func HistoryEpisodesKey(date string) string {
+	return Key(fmt.Sprintf("history:episodes:%s", date))
+}
+
+// HistoryBarsKey returns the sorted set of a venue symbol's bars scored by start time in ms
+func HistoryBarsKey(exchange, symbol, interval string) string {
+	return Key(fmt.Sprintf("history:bars:%s:%s:%s", exchange, symbol, interval))
+}
+
+// HistoryCaptureKey returns the spread capture counters of executions filled on a date
+func HistoryCaptureKey(date string) string {
+	return Key(fmt.Sprintf("history:capture:%s", date))
+}
+
+// HeartbeatKey returns the channel carrying heartbeats for a published
+// channel, given by its full (prefixed) name
+func HeartbeatKey(channel string) string {
+	return Key("heartbeat:" + strings.TrimPrefix(channel, prefix))
+}
+
+// RateBudgetKey returns the shared order rate bucket for an exchange account
+func RateBudgetKey(exchange string) string {
+	return Key(fmt.Sprintf("ratebudget:%s", exchange))
+}
+
+// FlagsKey returns the feature flag overrides of an environment
+func FlagsKey(env string) string {
+	return Key(fmt.Sprintf("flags:%s", env))
+}
+
+// SettingsKey returns the runtime parameter overrides of an environment
+func SettingsKey(env string) string {
+	return Key(fmt.Sprintf("settings:%s", env))
+}
+
+// SettingsMutesKey returns the exchange pair mutes shared by an environment
+func SettingsMutesKey(env string) string {
+	return Key(fmt.Sprintf("settings:%s:mutes", env))
+}
+
+// SettingsTiersKey returns the symbol tier assignments of an environment
+func SettingsTiersKey(env string) string {
+	return Key(fmt.Sprintf("settings:%s:tiers", env))
+}
+
+// SettingsFilterKey returns the opportunity filter expression of an environment
+func SettingsFilterKey(env string) string {
+	return Key(fmt.Sprintf("settings:%s:filter", env))
+}
+
+// SettingsChangedKey returns the channel announcing settings writes so
+// watchers reload at once instead of on their next poll
+func SettingsChangedKey(env string) string {
+	return Key(fmt.Sprintf("settings:%s:changed", env))
+}
+
+// ClaimKey returns the executor lease on a spread opportunity
+func ClaimKey(opportunityID string) string {
+	return Key(fmt.Sprintf("claim:%s", opportunityID))
+}
+
+// TenantClaimKey returns a tenant's executor lease on a spread opportunity.
+// Tenants trade separate accounts, so each may claim the same opportunity.
+func TenantClaimKey(tenant, opportunityID string) string {
+	return Key(fmt.Sprintf("claim:tenant:%s:%s", tenant, opportunityID))
+}
+
+// Entry describes a single key or channel family written by md-ingest
+type Entry struct {
+	Name        string        `json:"name"`
+	Pattern     string        `json:"pattern"`
+	Kind        Kind          `json:"kind"`
+	Payload     string        `json:"payload"`
+	Field       string        `json:"field,omitempty"` // Stream field holding the JSON payload
+	MaxLen      int64         `json:"max_len,omitempty"`
+	TTL         time.Duration `json:"-"`
+	TTLSeconds  int64         `json:"ttl_seconds,omitempty"`
+	Description string        `json:"description"`
+}
+
+// Entries returns every key and channel family md-ingest writes
+func Entries() []Entry {
+	return []Entry{
+		{
+			Name:        "orderbook_stream",
+			Pattern:     OrderbookPattern,
+			Kind:        KindStream,
+			Payload:     PayloadOrderbook,
+			Field:       "data",
+			MaxLen:      OrderbookStreamMaxLen,
+			Description: "Orderbook snapshots/updates per exchange-native symbol (approximate trim); snapshots carry at most the configured publish depth (1/5/20/full)",
+		},
+		{
+			Name:        "orderbook_channel",
+			Pattern:     OrderbookPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadOrderbook,
+			Description: "Real-time orderbook updates, same payload as the stream",
+		},
+		{
+			Name:        "bbo_channel",
+			Pattern:     BBOPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadBBO,
+			Description: "Best bid and offer on every touch change, published only in partial book mode; books then follow at most every depth interval",
+		},
+		{
+			Name:        "heartbeat_channel",
+			Pattern:     HeartbeatPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadHeartbeat,
+			Description: "Every second per published channel ({channel} unprefixed, e.g. heartbeat:orderbook:binance:BTCUSDT): messages published on it so far and the producer's clock, so a quiet market is told apart from a dead or partitioned producer",
+		},
+		{
+			Name:        "trades_stream",
+			Pattern:     TradesPattern,
+			Kind:        KindStream,
+			Payload:     PayloadTrade,
+			Field:       "data",
+			MaxLen:      TradesStreamMaxLen,
+			Description: "Public trades per exchange-native symbol",
+		},
+		{
+			Name:        "funding_stream",
+			Pattern:     FundingPattern,
+			Kind:        KindStream,
+			Payload:     PayloadFundingRate,
+			Field:       "data",
+			MaxLen:      FundingStreamMaxLen,
+			Description: "Funding rate, next funding time and mark price (where the venue returns it) per exchange-native symbol, from WebSocket and REST polling",
+		},
+		{
+			Name:        "funding_channel",
+			Pattern:     FundingPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadFundingRate,
+			Description: "Real-time funding rates, same payload as the stream",
+		},
+		{
+			Name:        "spreads_stream",
+			Pattern:     SpreadsStreamKey,
+			Kind:        KindStream,
+			Payload:     PayloadSpread,
+			Field:       "data",
+			MaxLen:      SpreadsStreamMaxLen,
+			Description: "Historical spread opportunities",
+		},
+		{
+			Name:        "spread_data",
+			Pattern:     SpreadDataPattern,
+			Kind:        KindString,
+			Payload:     PayloadSpread,
+			TTL:         SpreadDataTTL,
+			TTLSeconds:  int64(SpreadDataTTL.Seconds()),
+			Description: "Latest state of a spread, keyed by canonical:long:short; the TTL follows the quote-age decay (SPREAD_TTL) less the older leg's quote age, this is the fallback",
+		},
+		{
+			Name:        "spread_channel",
+			Pattern:     SpreadChannelPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadSpread,
+			Description: "Real-time updates for a single spread ID",
+		},
+		{
+			Name:        "spread_canonical_channel",
+			Pattern:     SpreadCanonicalChan,
+			Kind:        KindPubSub,
+			Payload:     PayloadSpread,
+			Description: "Real-time updates for every spread of a canonical symbol",
+		},
+		{
+			Name:        "spreads_active",
+			Pattern:     SpreadsActiveKey,
+			Kind:        KindSet,
+			Payload:     PayloadSpreadID,
+			TTL:         SpreadDataTTL,
+			TTLSeconds:  int64(SpreadDataTTL.Seconds()),
+			Description: "Set of spread IDs whose data key is live; IDs are removed as their data expires and the set expires once nothing is published",
+		},
+		{
+			Name:        "spreads_list",
+			Pattern:     SpreadsListKey,
+			Kind:        KindString,
+			Payload:     PayloadSpreadSummary,
+			TTL:         SpreadsListTTL,
+			TTLSeconds:  int64(SpreadsListTTL.Seconds()),
+			Description: "Summary of the current top spreads",
+		},
+		{
+			Name:        "spreads_summary_channel",
+			Pattern:     SpreadsSummaryChan,
+			Kind:        KindPubSub,
+			Payload:     PayloadSpreadSummary,
+			Description: "Real-time summary of the current top spreads",
+		},
+		{
+			Name:        "tenant_spreads",
+			Pattern:     TenantSpreadsPattern,
+			Kind:        KindString,
+			Payload:     PayloadTenantSpreads,
+			TTL:         SpreadsListTTL,
+			TTLSeconds:  int64(SpreadsListTTL.Seconds()),
+			Description: "Summary of the current top spreads whose legs are both on venues the tenant has credentials for",
+		},
+		{
+			Name:        "tenant_spreads_channel",
+			Pattern:     TenantSpreadsPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadTenantSpreads,
+			Description: "Real-time per-tenant spread summary, same payload as the key",
+		},
+		{
+			Name:        "basis",
+			Pattern:     BasisKey,
+			Kind:        KindString,
+			Payload:     PayloadBasisSummary,
+			TTL:         SpreadsListTTL,
+			TTLSeconds:  int64(SpreadsListTTL.Seconds()),
+			Description: "Current spot-vs-perp basis opportunities within single venues, by net edge; written once spot books are ingested",
+		},
+		{
+			Name:        "basis_channel",
+			Pattern:     BasisKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadBasisSummary,
+			Description: "Real-time basis summary, same payload as the key",
+		},
+		{
+			Name:        "index_price",
+			Pattern:     IndexPattern,
+			Kind:        KindString,
+			Payload:     PayloadIndexPrice,
+			TTL:         IndexTTL,
+			TTLSeconds:  int64(IndexTTL.Seconds()),
+			Description: "Volume-weighted median reference price with per-venue deviation",
+		},
+		{
+			Name:        "index_channel",
+			Pattern:     IndexPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadIndexPrice,
+			Description: "Real-time index price updates, same payload as the key",
+		},
+		{
+			Name:        "bars_stream",
+			Pattern:     BarsPattern,
+			Kind:        KindStream,
+			Payload:     PayloadBar,
+			Field:       "data",
+			MaxLen:      BarsStreamMaxLen,
+			Description: "OHLCV bars per exchange-native symbol built from the trade stream, one entry per closed bar",
+		},
+		{
+			Name:        "bars_channel",
+			Pattern:     BarsPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadBar,
+			Description: "Real-time closed bars, same payload as the stream",
+		},
+		{
+			Name:        "consolidated_bars_stream",
+			Pattern:     ConsolidatedBarsPattern,
+			Kind:        KindStream,
+			Payload:     PayloadBar,
+			Field:       "data",
+			MaxLen:      BarsStreamMaxLen,
+			Description: "OHLCV bars per canonical symbol across every venue's trades; volume in base units",
+		},
+		{
+			Name:        "consolidated_bars_channel",
+			Pattern:     ConsolidatedBarsPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadBar,
+			Description: "Real-time closed consolidated bars, same payload as the stream",
+		},
+		{
+			Name:        "oi_events_stream",
+			Pattern:     OpenInterestEventsKey,
+			Kind:        KindStream,
+			Payload:     PayloadOIEvent,
+			Field:       "data",
+			MaxLen:      OpenInterestEventsMaxLen,
+			Description: "Abnormal open interest builds and drops per exchange-native symbol",
+		},
+		{
+			Name:        "oi_events_channel",
+			Pattern:     OpenInterestEventsKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadOIEvent,
+			Description: "Real-time open interest events, same payload as the stream",
+		},
+		{
+			Name:        "funding_settlements_stream",
+			Pattern:     FundingSettlementsKey,
+			Kind:        KindStream,
+			Payload:     PayloadSettlement,
+			Field:       "data",
+			MaxLen:      FundingEventsMaxLen,
+			Description: "Funding settlements per exchange-native symbol as they become upcoming, imminent and settled",
+		},
+		{
+			Name:        "funding_settlements_channel",
+			Pattern:     FundingSettlementsKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadSettlement,
+			Description: "Real-time funding settlement events, same payload as the stream",
+		},
+		{
+			Name:        "funding_actions_stream",
+			Pattern:     FundingActionsKey,
+			Kind:        KindStream,
+			Payload:     PayloadFundingAction,
+			Field:       "data",
+			MaxLen:      FundingEventsMaxLen,
+			Description: "Pre-settlement reduce or flip actions for legs paying an imminent funding settlement",
+		},
+		{
+			Name:        "funding_actions_channel",
+			Pattern:     FundingActionsKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadFundingAction,
+			Description: "Real-time pre-settlement actions, same payload as the stream",
+		},
+		{
+			Name:        "symbol_status_stream",
+			Pattern:     SymbolStatusEventsKey,
+			Kind:        KindStream,
+			Payload:     PayloadSymbolStatus,
+			Field:       "data",
+			MaxLen:      SymbolStatusMaxLen,
+			Description: "Symbol status transitions (trading, limit_open, delisting, settling, maintenance) from venue instrument lists and scheduled windows; an empty symbol covers the whole exchange",
+		},
+		{
+			Name:        "symbol_status_channel",
+			Pattern:     SymbolStatusEventsKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadSymbolStatus,
+			Description: "Real-time symbol status transitions, same payload as the stream",
+		},
+		{
+			Name:        "feed_alerts_stream",
+			Pattern:     FeedAlertsKey,
+			Kind:        KindStream,
+			Payload:     PayloadFeedAlert,
+			Field:       "data",
+			MaxLen:      FeedAlertsMaxLen,
+			Description: "Feeds (exchange and channel) whose message rate collapsed against their learned baseline while still connected, and their recoveries",
+		},
+		{
+			Name:        "feed_alerts_channel",
+			Pattern:     FeedAlertsKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadFeedAlert,
+			Description: "Real-time feed alerts, same payload as the stream",
+		},
+		{
+			Name:        "false_positives_stream",
+			Pattern:     FalsePositivesKey,
+			Kind:        KindStream,
+			Payload:     PayloadFalsePositive,
+			Field:       "data",
+			MaxLen:      FalsePositivesMaxLen,
+			Description: "Published opportunities tagged as false positives by executors or operators; the tags within a week lower the data-quality score of the venue symbols involved",
+		},
+		{
+			Name:        "history_top",
+			Pattern:     HistoryTopPattern,
+			Kind:        KindZSet,
+			Payload:     PayloadSpreadID,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Spread IDs scored by peak spread bps for a UTC date",
+		},
+		{
+			Name:        "history_peak",
+			Pattern:     HistoryPeakPattern,
+			Kind:        KindHash,
+			Payload:     PayloadSpread,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Spread snapshot at its daily peak, field per spread ID",
+		},
+		{
+			Name:        "history_distribution",
+			Pattern:     HistoryDistPattern,
+			Kind:        KindHash,
+			Payload:     PayloadCounter,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Sampled spread bps histogram per exchange pair, field per bucket",
+		},
+		{
+			Name:        "history_pairs",
+			Pattern:     HistoryPairsPattern,
+			Kind:        KindSet,
+			Payload:     PayloadExchangePair,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Exchange pairs (long:short) with distribution data for a date",
+		},
+		{
+			Name:        "history_persistence",
+			Pattern:     HistoryPersistPattern,
+			Kind:        KindHash,
+			Payload:     PayloadCounter,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Opportunity lifetime counters (episodes, total_ms, max_ms) per symbol",
+		},
+		{
+			Name:        "history_symbols",
+			Pattern:     HistorySymbolsPattern,
+			Kind:        KindSet,
+			Payload:     PayloadCanonical,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Canonical symbols with persistence data for a date",
+		},
+		{
+			Name:        "history_episodes",
+			Pattern:     HistoryEpisodesPattern,
+			Kind:        KindStream,
+			Payload:     PayloadEpisode,
+			Field:       "data",
+			MaxLen:      HistoryEpisodesMaxLen,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Opportunity episodes (lifetime, peak, time above levels) that ended on a date",
+		},
+		{
+			Name:        "history_bars",
+			Pattern:     HistoryBarsPattern,
+			Kind:        KindZSet,
+			Payload:     PayloadBar,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "OHLCV bars scored by start time in ms, backfilled from venue klines at startup and extended by live bars",
+		},
+		{
+			Name:        "history_capture",
+			Pattern:     HistoryCapturePattern,
+			Kind:        KindHash,
+			Payload:     PayloadCounter,
+			TTL:         HistoryTTL,
+			TTLSeconds:  int64(HistoryTTL.Seconds()),
+			Description: "Signal vs captured spread counters of executed opportunities, fields {pair:long:short|symbol:canonical}|{count,signal_bps,captured_bps}",
+		},
+		{
+			Name:        "history_clusters",
+			Pattern:     HistoryClustersKey,
+			Kind:        KindString,
+			Payload:     PayloadCorrelation,
+			TTL:         HistoryClustersTTL,
+			TTLSeconds:  int64(HistoryClustersTTL.Seconds()),
+			Description: "Correlation matrix of exchange pairs' spread activity and the clusters of pairs that move together, recomputed periodically",
+		},
+		{
+			Name:        "history_clusters_channel",
+			Pattern:     HistoryClustersKey,
+			Kind:        KindPubSub,
+			Payload:     PayloadCorrelation,
+			Description: "Spread clusters as they are recomputed, same payload as the key",
+		},
+		{
+			Name:        "rate_budget",
+			Pattern:     RateBudgetPattern,
+			Kind:        KindHash,
+			Payload:     PayloadRateBudget,
+			Description: "Order rate token bucket (tokens, ts_ms) shared by every strategy trading the account; factor, set while the venue is degraded, scales its capacity and rate",
+		},
+		{
+			Name:        "venue_health",
+			Pattern:     VenueHealthKey,
+			Kind:        KindHash,
+			Payload:     PayloadVenueHealth,
+			TTL:         VenueHealthTTL,
+			Description: "REST health per venue ({exchange} -> JSON); executors stop resting passive quotes on venues with quoting_allowed false",
+		},
+		{
+			Name:        "subscription_state",
+			Pattern:     SubscriptionStateKey,
+			Kind:        KindHash,
+			Payload:     PayloadSubscriptions,
+			TTL:         SubscriptionStateTTL,
+			Description: "WebSocket subscription set per venue ({exchange} -> JSON), saved periodically; a restarted or failed-over instance resubscribes from it before the REST load",
+		},
+		{
+			Name:        "webhooks",
+			Pattern:     WebhooksKey,
+			Kind:        KindHash,
+			Payload:     PayloadWebhook,
+			Description: "Registered spread event webhooks ({id} -> JSON), including the secret each delivery is signed with",
+		},
+		{
+			Name:        "flags",
+			Pattern:     FlagsPattern,
+			Kind:        KindHash,
+			Payload:     PayloadFlags,
+			Description: "Feature flag overrides (flag name -> true/false) for an environment; unset flags use each service's default",
+		},
+		{
+			Name:        "settings",
+			Pattern:     SettingsPattern,
+			Kind:        KindHash,
+			Payload:     PayloadSettings,
+			Description: "Runtime parameter overrides (name -> number), e.g. spread.min_spread_bps or tier.major.min_depth_usd; unset parameters use the service default",
+		},
+		{
+			Name:        "settings_mutes",
+			Pattern:     SettingsMutesPattern,
+			Kind:        KindHash,
+			Payload:     PayloadPairMute,
+			Description: "Exchange pair mutes applied by every instance (mute ID -> JSON); expired mutes are removed by the watchers",
+		},
+		{
+			Name:        "settings_tiers",
+			Pattern:     SettingsTiersPattern,
+			Kind:        KindHash,
+			Payload:     PayloadSymbolTiers,
+			Description: "Tier of each canonical symbol (canonical -> tier name); a tier's thresholds are the tier.{name}.* settings",
+		},
+		{
+			Name:        "settings_filter",
+			Pattern:     SettingsFilterPattern,
+			Kind:        KindString,
+			Payload:     PayloadFilter,
+			Description: "Filter expression every opportunity must match to be published, e.g. spread_bps > 8 && long_exchange != \"lbank\"; unset publishes all",
+		},
+		{
+			Name:        "settings_changed_channel",
+			Pattern:     SettingsChangedPattern,
+			Kind:        KindPubSub,
+			Payload:     PayloadSettingsEvent,
+			Description: "Name of the settings section just written (params, mutes, tiers, filter); watchers reload within seconds without it",
+		},
+		{
+			Name:        "claim",
+			Pattern:     ClaimPattern,
+			Kind:        KindHash,
+			Payload:     PayloadClaim,
+			Description: "Executor lease on a spread opportunity (token, owner, state, claimed_ms); expires unless renewed, held briefly once done",
+		},
+		{
+			Name:        "tenant_claim",
+			Pattern:     TenantClaimPattern,
+			Kind:        KindHash,
+			Payload:     PayloadClaim,
+			Description: "Executor lease on a spread opportunity for one tenant's account; same fields as claim",
+		},
+		{
+			Name:        "migrations",
+			Pattern:     MigrationsKey,
+			Kind:        KindHash,
+			Payload:     PayloadMigration,
+			Description: "Positions hedged on a fallback venue (flag ID -> JSON), pending migration to the intended venue pair",
+		},
+		{
+			Name:        "positions",
+			Pattern:     PositionsKey,
+			Kind:        KindHash,
+			Payload:     PayloadPositions,
+			Description: "Net perp position per venue ({exchange}:{canonical} -> signed base quantity), adjusted by executors on fills",
+		},
+		{
+			Name:        "strategy_pnl",
+			Pattern:     PnLKey,
+			Kind:        KindHash,
+			Payload:     PayloadStrategyPnL,
+			Description: "Cumulative realized plus unrealized PnL per strategy ({strategy} -> USD), written by executors; drives drawdown de-risking",
+		},
+		{
+			Name:        "derisk",
+			Pattern:     DeRiskKey,
+			Kind:        KindString,
+			Payload:     PayloadDeRisk,
+			Description: "De-risking in force after a drawdown (halve sizes or halt entries); executors scale entries by size_factor until it is acknowledged",
+		},
+		{
+			Name:        "venue_equity",
+			Pattern:     EquityKey,
+			Kind:        KindHash,
+			Payload:     PayloadVenueEquity,
+			Description: "Balance and unrealized PnL per venue ({exchange} -> JSON), written by executors; drives per-venue exposure caps",
+		},
+		{
+			Name:        "transfer_latency",
+			Pattern:     TransfersKey,
+			Kind:        KindHash,
+			Payload:     PayloadTransfers,
+			Description: "Observed deposit and withdrawal completion times per route ({asset}:{chain}:{from}:{to} -> JSON, empty from/to for untracked venues); the expected latency for rebalancing transfers",
+		},
+	}
+}
@@ -0,0 +1,266 @@
+package listings
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// httpClient is shared by all sources; announcement hosts are slow but
+// requests are bounded by the watcher's per-poll timeout
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// getJSON fetches a URL and decodes the JSON response into v
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "crossspread-md-ingest")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// DefaultSources returns the announcement sources of the given exchanges
+// that have a public announcement API
+func DefaultSources(exchanges []connector.ExchangeID) []Source {
+	var sources []Source
+	for _, id := range exchanges {
+		switch id {
+		case connector.Binance:
+			sources = append(sources, &BinanceSource{})
+		case connector.Bybit:
+			sources = append(sources, &BybitSource{})
+		case connector.OKX:
+			sources = append(sources, &OKXSource{})
+		}
+	}
+	return sources
+}
+
+// BinanceSource reads the Binance support CMS listing and delisting catalogs
+type BinanceSource struct{}
+
+const (
+	binanceCMSURL           = "https://www.binance.com/bapi/composite/v1/public/cms/article/list/query?type=1&pageNo=1&pageSize=20&catalogId=%d"
+	binanceArticleURL       = "https://www.binance.com/en/support/announcement/%s"
+	binanceListingCatalog   = 48
+	binanceDelistingCatalog = 161
+)
+
+// Exchange returns the exchange ID
+func (s *BinanceSource) Exchange() connector.ExchangeID {
+	return connector.Binance
+}
+
+// Fetch fetches the latest listing and delisting articles
+func (s *BinanceSource) Fetch(ctx context.Context) ([]Announcement, error) {
+	var result []Announcement
+	for _, catalog := range []int{binanceListingCatalog, binanceDelistingCatalog} {
+		var resp struct {
+			Code string `json:"code"`
+			Data struct {
+				Catalogs []struct {
+					Articles []struct {
+						ID          int64  `json:"id"`
+						Code        string `json:"code"`
+						Title       string `json:"title"`
+						ReleaseDate int64  `json:"releaseDate"`
+					} `json:"articles"`
+				} `json:"catalogs"`
+			} `json:"data"`
+		}
+		if err := getJSON(ctx, fmt.Sprintf(binanceCMSURL, catalog), &resp); err != nil {
+			return nil, err
+		}
+		if resp.Code != "000000" {
+			return nil, fmt.Errorf("binance cms error: %s", resp.Code)
+		}
+
+		for _, c := range resp.Data.Catalogs {
+			for _, a := range c.Articles {
+				result = append(result, Announcement{
+					ID:          strconv.FormatInt(a.ID, 10),
+					Title:       a.Title,
+					URL:         fmt.Sprintf(binanceArticleURL, a.Code),
+					PublishedAt: time.UnixMilli(a.ReleaseDate),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// BybitSource reads the Bybit v5 announcements API
+type BybitSource struct{}
+
+const bybitAnnouncementsURL = "https://api.bybit.com/v5/announcements/index?locale=en-US&limit=20&type=%s"
+
+// Exchange returns the exchange ID
+func (s *BybitSource) Exchange() connector.ExchangeID {
+	return connector.Bybit
+}
+
+// Fetch fetches the latest listing and delisting announcements
+func (s *BybitSource) Fetch(ctx context.Context) ([]Announcement, error) {
+	var result []Announcement
+	for _, annType := range []string{"new_crypto", "delistings"} {
+		var resp struct {
+			RetCode int    `json:"retCode"`
+			RetMsg  string `json:"retMsg"`
+			Result  struct {
+				List []struct {
+					Title         string `json:"title"`
+					URL           string `json:"url"`
+					DateTimestamp int64  `json:"dateTimestamp"`
+				} `json:"list"`
+			} `json:"result"`
+		}
+		if err := getJSON(ctx, fmt.Sprintf(bybitAnnouncementsURL, annType), &resp); err != nil {
+			return nil, err
+		}
+		if resp.RetCode != 0 {
+			return nil, fmt.Errorf("bybit announcements error: %s", resp.RetMsg)
+		}
+
+		for _, a := range resp.Result.List {
+			result = append(result, Announcement{
+				ID:          a.URL, // Bybit announcements have no ID; the URL is stable
+				Title:       a.Title,
+				URL:         a.URL,
+				PublishedAt: time.UnixMilli(a.DateTimestamp),
+			})
+		}
+	}
+	return result, nil
+}
+
+// OKXSource reads the OKX support announcements API
+type OKXSource struct{}
+
+const okxAnnouncementsURL = "https://www.okx.com/api/v5/support/announcements?annType=%s"
+
+// Exchange returns the exchange ID
+func (s *OKXSource) Exchange() connector.ExchangeID {
+	return connector.OKX
+}
+
+// Fetch fetches the latest listing and delisting announcements
+func (s *OKXSource) Fetch(ctx context.Context) ([]Announcement, error) {
+	var result []Announcement
+	for _, annType := range []string{"announcements-new-listings", "announcements-delistings"} {
+		var resp struct {
+			Code string `json:"code"`
+			Msg  string `json:"msg"`
+			Data []struct {
+				Details []struct {
+					Title string `json:"title"`
+					URL   string `json:"url"`
+					PTime string `json:"pTime"`
+				} `json:"details"`
+			} `json:"data"`
+		}
+		if err := getJSON(ctx, fmt.Sprintf(okxAnnouncementsURL, annType), &resp); err != nil {
+			return nil, err
+		}
+		if resp.Code != "0" {
+			return nil, fmt.Errorf("okx announcements error: %s", resp.Msg)
+		}
+
+		for _, d := range resp.Data {
+			for _, a := range d.Details {
+				ms, _ := strconv.ParseInt(a.PTime, 10, 64)
+				result = append(result, Announcement{
+					ID:          a.URL,
+					Title:       a.Title,
+					URL:         a.URL,
+					PublishedAt: time.UnixMilli(ms),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// RSSSource reads an RSS 2.0 feed, for exchanges that publish announcements
+// only as RSS
+type RSSSource struct {
+	ExchangeID connector.ExchangeID
+	FeedURL    string
+}
+
+// Exchange returns the exchange ID
+func (s *RSSSource) Exchange() connector.ExchangeID {
+	return s.ExchangeID
+}
+
+// Fetch fetches the feed's items
+func (s *RSSSource) Fetch(ctx context.Context) ([]Announcement, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.FeedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "crossspread-md-ingest")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var feed struct {
+		Channel struct {
+			Items []struct {
+				GUID    string `xml:"guid"`
+				Title   string `xml:"title"`
+				Link    string `xml:"link"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	result := make([]Announcement, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		published, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			published, err = time.Parse(time.RFC1123, item.PubDate)
+		}
+		if err != nil {
+			continue
+		}
+
+		id := item.GUID
+		if id == "" {
+			id = item.Link
+		}
+		result = append(result, Announcement{
+			ID:          id,
+			Title:       item.Title,
+			URL:         item.Link,
+			PublishedAt: published,
+		})
+	}
+	return result, nil
+}
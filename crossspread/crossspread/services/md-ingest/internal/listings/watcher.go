@@ -0,0 +1,287 @@
+package listings
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Kind classifies an announcement
+type Kind string
+
+const (
+	KindListing   Kind = "listing"
+	KindDelisting Kind = "delisting"
+)
+
+// Announcement is a raw entry from an exchange announcement feed
+type Announcement struct {
+	ID          string
+	Title       string
+	URL         string
+	PublishedAt time.Time
+}
+
+// Source fetches the latest announcements of one exchange
+type Source interface {
+	Exchange() connector.ExchangeID
+	Fetch(ctx context.Context) ([]Announcement, error)
+}
+
+// Event is a perpetual listing or delisting detected in an announcement.
+// An announcement naming several contracts yields one event per canonical.
+type Event struct {
+	Exchange    connector.ExchangeID `json:"exchange"`
+	Kind        Kind                 `json:"kind"`
+	Canonical   string               `json:"canonical"`
+	Title       string               `json:"title"`
+	URL         string               `json:"url,omitempty"`
+	PublishedAt time.Time            `json:"published_at"`
+	DetectedAt  time.Time            `json:"detected_at"`
+}
+
+// Handler is called for every detected event
+type Handler func(ev Event)
+
+// Config controls the announcement watcher
+type Config struct {
+	PollInterval time.Duration // Time between polls of each feed
+	MaxAge       time.Duration // Announcements published earlier are ignored
+	Timeout      time.Duration // Per-request timeout
+	KeepRecent   int           // Events kept for the admin API
+}
+
+// DefaultConfig returns the default watcher configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 2 * time.Minute,
+		MaxAge:       72 * time.Hour,
+		Timeout:      15 * time.Second,
+		KeepRecent:   200,
+	}
+}
+
+// Watcher polls exchange announcement feeds for new perpetual listings and
+// delistings. Listings are announced hours to days before trading opens,
+// which is when cross-venue mispricings are widest.
+type Watcher struct {
+	config  Config
+	sources []Source
+	handler Handler
+
+	mu     sync.Mutex
+	seen   map[string]time.Time // source:announcement ID -> published
+	recent []Event
+	done   chan struct{}
+}
+
+// NewWatcher creates a new announcement watcher
+func NewWatcher(sources []Source, config Config) *Watcher {
+	return &Watcher{
+		config:  config,
+		sources: sources,
+		seen:    make(map[string]time.Time),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetHandler sets the callback for detected events
+func (w *Watcher) SetHandler(handler Handler) {
+	w.handler = handler
+}
+
+// Start polls every source until the context is cancelled or Stop is called
+func (w *Watcher) Start(ctx context.Context) {
+	w.pollAll(ctx)
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.pollAll(ctx)
+		}
+	}
+}
+
+// Stop stops the watcher
+func (w *Watcher) Stop() {
+	close(w.done)
+}
+
+// Recent returns the most recent events, newest first
+func (w *Watcher) Recent() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := make([]Event, len(w.recent))
+	for i, ev := range w.recent {
+		result[len(w.recent)-1-i] = ev
+	}
+	return result
+}
+
+// pollAll polls the sources concurrently; feeds are on different hosts
+func (w *Watcher) pollAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, src := range w.sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			w.poll(ctx, src)
+		}(src)
+	}
+	wg.Wait()
+	w.prune()
+}
+
+func (w *Watcher) poll(ctx context.Context, src Source) {
+	id := src.Exchange()
+	reqCtx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	announcements, err := src.Fetch(reqCtx)
+	if err != nil {
+		metrics.ListingPollErrors.WithLabelValues(string(id)).Inc()
+		log.Warn().Err(err).Str("exchange", string(id)).Msg("Announcement poll failed")
+		return
+	}
+
+	now := time.Now()
+	for _, a := range announcements {
+		if now.Sub(a.PublishedAt) > w.config.MaxAge {
+			continue
+		}
+
+		key := string(id) + ":" + a.ID
+		w.mu.Lock()
+		_, seen := w.seen[key]
+		w.seen[key] = a.PublishedAt
+		w.mu.Unlock()
+		if seen {
+			continue
+		}
+
+		kind, ok := Classify(a.Title)
+		if !ok {
+			continue
+		}
+		for _, canonical := range ExtractCanonicals(a.Title) {
+			w.emit(Event{
+				Exchange:    id,
+				Kind:        kind,
+				Canonical:   canonical,
+				Title:       a.Title,
+				URL:         a.URL,
+				PublishedAt: a.PublishedAt,
+				DetectedAt:  now,
+			})
+		}
+	}
+}
+
+func (w *Watcher) emit(ev Event) {
+	metrics.ListingEvents.WithLabelValues(string(ev.Exchange), string(ev.Kind)).Inc()
+	log.Info().
+		Str("exchange", string(ev.Exchange)).
+		Str("kind", string(ev.Kind)).
+		Str("canonical", ev.Canonical).
+		Str("title", ev.Title).
+		Msg("Perpetual listing announcement detected")
+
+	w.mu.Lock()
+	w.recent = append(w.recent, ev)
+	if len(w.recent) > w.config.KeepRecent {
+		w.recent = w.recent[len(w.recent)-w.config.KeepRecent:]
+	}
+	w.mu.Unlock()
+
+	if w.handler != nil {
+		w.handler(ev)
+	}
+}
+
+// prune forgets announcements that have aged out of every feed window
+func (w *Watcher) prune() {
+	cutoff := time.Now().Add(-2 * w.config.MaxAge)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, published := range w.seen {
+		if published.Before(cutoff) {
+			delete(w.seen, key)
+		}
+	}
+}
+
+var (
+	// Contract words distinguish perpetual announcements from spot ones
+	perpetualWords = []string{"perpetual", "futures", "swap", "contract"}
+	delistingWords = []string{"delist", "removal of", "offboard", "will cease"}
+	listingWords   = []string{"will launch", "new listing", "will list", "to list", "lists", "launches", "introduces", "adds"}
+
+	// XYZUSDT, 1000PEPEUSDT, XYZUSDC
+	symbolPattern = regexp.MustCompile(`\b([A-Z0-9]{2,20}(?:USDT|USDC))\b`)
+	// "Foo Token (XYZ)" when the title names the asset rather than the contract
+	tickerPattern = regexp.MustCompile(`\(([A-Z0-9]{2,15})\)`)
+)
+
+// Classify returns whether an announcement title is a perpetual listing or
+// delisting. Titles not mentioning a derivative contract are ignored.
+func Classify(title string) (Kind, bool) {
+	t := strings.ToLower(title)
+	if !containsAny(t, perpetualWords) {
+		return "", false
+	}
+	if containsAny(t, delistingWords) {
+		return KindDelisting, true
+	}
+	if containsAny(t, listingWords) {
+		return KindListing, true
+	}
+	return "", false
+}
+
+// ExtractCanonicals returns the canonical symbols named in a title
+func ExtractCanonicals(title string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	add := func(symbol string) {
+		canonical := connector.ParsePair(symbol).Canonical()
+		if canonical != "" && !seen[canonical] {
+			seen[canonical] = true
+			result = append(result, canonical)
+		}
+	}
+
+	for _, m := range symbolPattern.FindAllStringSubmatch(title, -1) {
+		add(m[1])
+	}
+	if len(result) == 0 {
+		for _, m := range tickerPattern.FindAllStringSubmatch(title, -1) {
+			add(m[1])
+		}
+	}
+	return result
+}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
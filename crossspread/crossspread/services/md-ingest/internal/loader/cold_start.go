@@ -0,0 +1,155 @@
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ColdStartConfig controls the ticker fast path run right after startup
+type ColdStartConfig struct {
+	// Venues whose all-tickers endpoint carries best bid/ask with sizes
+	Exchanges []connector.ExchangeID
+	Window    time.Duration // How long after startup approximate books are produced
+	Interval  time.Duration // Ticker poll interval within the window
+}
+
+// DefaultColdStartConfig polls Bybit, Bitget and KuCoin tickers every 5s for
+// the first 30s
+func DefaultColdStartConfig() ColdStartConfig {
+	return ColdStartConfig{
+		Exchanges: []connector.ExchangeID{connector.Bybit, connector.Bitget, connector.KuCoin},
+		Window:    30 * time.Second,
+		Interval:  5 * time.Second,
+	}
+}
+
+// ColdStart produces approximate one-level books from all-tickers endpoints
+// while the full REST load and WebSocket books are still coming in. One
+// request per venue covers every symbol, so spreads can be published within
+// seconds of startup instead of after Phase 1. The books are flagged
+// Approximate; real books replace them as they arrive.
+type ColdStart struct {
+	connectors []connector.Connector
+	config     ColdStartConfig
+	handler    func(*connector.Orderbook)
+}
+
+// NewColdStart creates the fast path over the configured venues among
+// connectors
+func NewColdStart(connectors []connector.Connector, config ColdStartConfig) *ColdStart {
+	enabled := make(map[connector.ExchangeID]bool, len(config.Exchanges))
+	for _, id := range config.Exchanges {
+		enabled[id] = true
+	}
+	var selected []connector.Connector
+	for _, conn := range connectors {
+		if enabled[conn.ID()] {
+			selected = append(selected, conn)
+		}
+	}
+	return &ColdStart{connectors: selected, config: config}
+}
+
+// SetOrderbookHandler sets the callback receiving approximate books
+func (c *ColdStart) SetOrderbookHandler(handler func(*connector.Orderbook)) {
+	c.handler = handler
+}
+
+// Run polls tickers until the window has elapsed or ctx is done
+func (c *ColdStart) Run(ctx context.Context) {
+	if len(c.connectors) == 0 || c.handler == nil {
+		return
+	}
+
+	deadline := time.Now().Add(c.config.Window)
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for round := 1; ; round++ {
+		books := c.pollAll(ctx)
+		log.Info().Int("round", round).Int("books", books).Msg("Cold start: approximate books seeded from tickers")
+
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if now.After(deadline) {
+				return
+			}
+		}
+	}
+}
+
+// pollAll fetches every venue's tickers concurrently and returns the number
+// of books handed on
+func (c *ColdStart) pollAll(ctx context.Context) int {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for _, conn := range c.connectors {
+		wg.Add(1)
+		go func(conn connector.Connector) {
+			defer wg.Done()
+			exchange := string(conn.ID())
+
+			reqCtx, cancel := context.WithTimeout(ctx, c.config.Interval)
+			defer cancel()
+			tickers, err := conn.FetchPriceTickers(reqCtx)
+			if err != nil {
+				metrics.ColdStartFetches.WithLabelValues(exchange, "error").Inc()
+				log.Warn().Err(err).Str("exchange", exchange).Msg("Cold start ticker fetch failed")
+				return
+			}
+			metrics.ColdStartFetches.WithLabelValues(exchange, "ok").Inc()
+
+			count := 0
+			for _, t := range tickers {
+				ob := tickerOrderbook(t)
+				if ob == nil {
+					continue
+				}
+				c.handler(ob)
+				count++
+			}
+			metrics.ColdStartBooks.WithLabelValues(exchange).Add(float64(count))
+
+			mu.Lock()
+			total += count
+			mu.Unlock()
+		}(conn)
+	}
+	wg.Wait()
+	return total
+}
+
+// tickerOrderbook builds a one-level approximate book from a ticker's best
+// bid and ask, or nil if the ticker lacks a two-sided quote
+func tickerOrderbook(t connector.PriceTicker) *connector.Orderbook {
+	if t.Canonical == "" || t.BidPrice <= 0 || t.AskPrice <= 0 || t.BidPrice >= t.AskPrice {
+		return nil
+	}
+	now := time.Now()
+	return &connector.Orderbook{
+		ExchangeID:   t.ExchangeID,
+		Symbol:       t.Symbol,
+		Canonical:    t.Canonical,
+		Bids:         []connector.PriceLevel{{Price: t.BidPrice, Quantity: t.BidSize}},
+		Asks:         []connector.PriceLevel{{Price: t.AskPrice, Quantity: t.AskSize}},
+		BestBid:      t.BidPrice,
+		BestAsk:      t.AskPrice,
+		SpreadBps:    (t.AskPrice - t.BidPrice) / t.BidPrice * 10000,
+		Timestamp:    t.Timestamp,
+		IsSnapshot:   true,
+		Approximate:  true,
+		ReceivedAt:   now,
+		NormalizedAt: now,
+	}
+}
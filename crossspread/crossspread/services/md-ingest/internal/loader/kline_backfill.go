@@ -0,0 +1,154 @@
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/bars"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// KlineBackfillConfig controls the startup candle backfill
+type KlineBackfillConfig struct {
+	Interval    time.Duration // Candle interval, e.g. 1m
+	Lookback    time.Duration // History fetched per symbol
+	MaxCandles  int           // Cap per request; venues allow 1000
+	Concurrency int           // Symbols fetched at once per venue
+	Timeout     time.Duration // Per-request timeout
+}
+
+// DefaultKlineBackfillConfig returns a day of 1m candles, two requests at a
+// time per venue to stay clear of REST limits during startup
+func DefaultKlineBackfillConfig() KlineBackfillConfig {
+	return KlineBackfillConfig{
+		Interval:    time.Minute,
+		Lookback:    24 * time.Hour,
+		MaxCandles:  1000,
+		Concurrency: 2,
+		Timeout:     10 * time.Second,
+	}
+}
+
+// BackfillKlines fetches recent candles for the symbols selected for
+// WebSocket subscription on every venue with a kline endpoint and hands them
+// to handler as bars, one call per symbol. Returns the number of bars.
+func (l *RestDataLoader) BackfillKlines(ctx context.Context, cfg KlineBackfillConfig, handler func([]*bars.Bar)) int {
+	limit := int(cfg.Lookback / cfg.Interval)
+	if limit > cfg.MaxCandles {
+		limit = cfg.MaxCandles
+	}
+	if limit <= 0 {
+		return 0
+	}
+
+	symbolsByExchange := l.GetSymbolsForWebSocket()
+	exchangeData := l.GetExchangeData()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for _, conn := range l.connectors {
+		fetcher, ok := conn.(connector.KlineFetcher)
+		if !ok {
+			continue
+		}
+		symbols := symbolsByExchange[conn.ID()]
+		if len(symbols) == 0 {
+			continue
+		}
+
+		sizes := make(map[string]float64)
+		if data := exchangeData[conn.ID()]; data != nil {
+			for _, inst := range data.Instruments {
+				sizes[inst.Symbol] = inst.ContractSize
+			}
+		}
+
+		wg.Add(1)
+		go func(id connector.ExchangeID, fetcher connector.KlineFetcher, symbols []string) {
+			defer wg.Done()
+
+			sem := make(chan struct{}, cfg.Concurrency)
+			var symWg sync.WaitGroup
+			for _, symbol := range symbols {
+				symWg.Add(1)
+				sem <- struct{}{}
+				go func(symbol string) {
+					defer symWg.Done()
+					defer func() { <-sem }()
+
+					reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+					klines, err := fetcher.FetchKlines(reqCtx, symbol, cfg.Interval, limit)
+					cancel()
+					if err != nil {
+						metrics.KlineBackfillErrors.WithLabelValues(string(id)).Inc()
+						log.Debug().Err(err).Str("exchange", string(id)).Str("symbol", symbol).Msg("Kline backfill failed")
+						return
+					}
+
+					result := klinesToBars(klines, sizes[symbol])
+					if len(result) == 0 {
+						return
+					}
+					metrics.KlineBackfillBars.WithLabelValues(string(id)).Add(float64(len(result)))
+					handler(result)
+
+					mu.Lock()
+					total += len(result)
+					mu.Unlock()
+				}(symbol)
+			}
+			symWg.Wait()
+		}(conn.ID(), fetcher, symbols)
+	}
+	wg.Wait()
+
+	log.Info().
+		Int("bars", total).
+		Str("interval", bars.IntervalName(cfg.Interval)).
+		Dur("lookback", cfg.Lookback).
+		Msg("Kline backfill complete")
+	return total
+}
+
+// klinesToBars converts candles to venue bars, converting contract volume
+// to base units. The last candle is still forming and is dropped.
+func klinesToBars(klines []connector.Kline, contractSize float64) []*bars.Bar {
+	now := time.Now()
+	result := make([]*bars.Bar, 0, len(klines))
+	for _, k := range klines {
+		end := k.Start.Add(k.Interval)
+		if end.After(now) {
+			continue
+		}
+		volume := k.Volume
+		if k.Contracts && contractSize > 0 {
+			volume *= contractSize
+		}
+		bar := &bars.Bar{
+			Exchange:    k.ExchangeID,
+			Symbol:      k.Symbol,
+			Canonical:   connector.ParsePair(k.Symbol).Canonical(),
+			Interval:    bars.IntervalName(k.Interval),
+			Open:        k.Open,
+			High:        k.High,
+			Low:         k.Low,
+			Close:       k.Close,
+			Volume:      volume,
+			QuoteVolume: k.QuoteVolume,
+			Start:       k.Start.UTC(),
+			End:         end.UTC(),
+		}
+		if bar.Volume > 0 && bar.QuoteVolume > 0 {
+			bar.VWAP = bar.QuoteVolume / bar.Volume
+		}
+		result = append(result, bar)
+	}
+	return result
+}
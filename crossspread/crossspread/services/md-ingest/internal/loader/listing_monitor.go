@@ -0,0 +1,148 @@
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/restcache"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NewListing is a canonical that just became tradeable on a second venue,
+// or on another venue while already listed on two or more
+type NewListing struct {
+	Canonical  string                          `json:"canonical"`
+	Symbols    map[connector.ExchangeID]string `json:"symbols"`  // Every venue listing it
+	Exchange   connector.ExchangeID            `json:"exchange"` // Venue where it just appeared
+	DetectedAt time.Time                       `json:"detected_at"`
+}
+
+// ListingMonitor polls venue instrument lists far more often than the full
+// REST refresh, so a contract listed on a second venue is subscribed within
+// seconds instead of on the next refresh
+type ListingMonitor struct {
+	connectors   []connector.Connector
+	pollInterval time.Duration
+	handler      func(NewListing)
+
+	mu       sync.Mutex
+	listed   map[string]map[connector.ExchangeID]string // canonical -> exchange -> symbol
+	baseline map[connector.ExchangeID]bool              // Venues whose first poll has been recorded
+}
+
+// NewListingMonitor creates a listing monitor
+func NewListingMonitor(connectors []connector.Connector, pollInterval time.Duration) *ListingMonitor {
+	return &ListingMonitor{
+		connectors:   connectors,
+		pollInterval: pollInterval,
+		listed:       make(map[string]map[connector.ExchangeID]string),
+		baseline:     make(map[connector.ExchangeID]bool),
+	}
+}
+
+// SetHandler sets the callback for new listings
+func (m *ListingMonitor) SetHandler(handler func(NewListing)) {
+	m.handler = handler
+}
+
+// Start polls every venue until the context is cancelled. The first poll of
+// a venue only records its instruments.
+func (m *ListingMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		m.pollAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *ListingMonitor) pollAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, conn := range m.connectors {
+		wg.Add(1)
+		go func(c connector.Connector) {
+			defer wg.Done()
+
+			// Listings must show up within a poll, not a cache TTL
+			reqCtx, cancel := context.WithTimeout(restcache.Fresh(ctx), m.pollInterval)
+			defer cancel()
+			instruments, err := c.FetchInstruments(reqCtx)
+			if err != nil {
+				log.Debug().Err(err).Str("exchange", string(c.ID())).Msg("Listing poll failed")
+				return
+			}
+			m.record(c.ID(), instruments)
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// record updates a venue's listed instruments and reports canonicals that
+// newly reach two or more venues
+func (m *ListingMonitor) record(exchID connector.ExchangeID, instruments []connector.Instrument) {
+	m.mu.Lock()
+	first := !m.baseline[exchID]
+	m.baseline[exchID] = true
+
+	var listings []NewListing
+	now := time.Now()
+	current := make(map[string]bool, len(instruments))
+	for _, inst := range instruments {
+		canonical := connector.ParsePair(inst.Symbol).Canonical()
+		current[canonical] = true
+		venues := m.listed[canonical]
+		if venues == nil {
+			venues = make(map[connector.ExchangeID]string)
+			m.listed[canonical] = venues
+		}
+		if _, ok := venues[exchID]; ok {
+			continue
+		}
+		venues[exchID] = inst.Symbol
+
+		if first || len(venues) < 2 {
+			continue
+		}
+		symbols := make(map[connector.ExchangeID]string, len(venues))
+		for id, s := range venues {
+			symbols[id] = s
+		}
+		listings = append(listings, NewListing{
+			Canonical:  canonical,
+			Symbols:    symbols,
+			Exchange:   exchID,
+			DetectedAt: now,
+		})
+	}
+
+	// Forget delisted contracts so a relisting is reported again. An empty
+	// response is more likely a venue hiccup than a full delisting.
+	if len(instruments) > 0 {
+		for canonical, venues := range m.listed {
+			if _, ok := venues[exchID]; ok && !current[canonical] {
+				delete(venues, exchID)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	for _, nl := range listings {
+		log.Info().
+			Str("canonical", nl.Canonical).
+			Str("exchange", string(exchID)).
+			Int("venues", len(nl.Symbols)).
+			Msg("New listing on multiple venues")
+		if m.handler != nil {
+			m.handler(nl)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"crossspread-md-ingest/internal/connector"
 	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/symbolmap"
 
 	"github.com/rs/zerolog/log"
 )
@@ -76,8 +77,28 @@ type RestDataLoader struct {
 	tokenData    map[string]*TokenData // canonical -> data
 	spreads      []*RestPreliminarySpread
 
+	// Spreads below minSpreadBps but within preemptRatio of it. Their symbols
+	// are subscribed ahead of time so live books exist when they cross.
+	nearSpreads []*RestPreliminarySpread
+
+	// Canonicals from listing announcements: watched ones stay subscribed
+	// without a spread, excluded ones (delisting) are never candidates
+	watched  map[string]time.Time // canonical -> until
+	excluded map[string]time.Time // canonical -> until
+
+	// Canonicals each venue may pair spreads on, from the universe config;
+	// nil allows every canonical everywhere
+	universe map[connector.ExchangeID]map[string]bool
+
+	// Maps canonicals still quoted under an asset's old name to the new one
+	renamer Renamer
+
+	// Venue symbols to canonicals, filled from fetched instruments
+	symbols *symbolmap.Map
+
 	// Config
 	minSpreadBps    float64
+	preemptRatio    float64
 	refreshInterval time.Duration
 	parallelFetch   bool
 }
@@ -89,12 +110,40 @@ func NewRestDataLoader(connectors []connector.Connector) *RestDataLoader {
 		exchangeData:    make(map[connector.ExchangeID]*ExchangeData),
 		tokenData:       make(map[string]*TokenData),
 		spreads:         make([]*RestPreliminarySpread, 0),
+		nearSpreads:     make([]*RestPreliminarySpread, 0),
+		watched:         make(map[string]time.Time),
+		excluded:        make(map[string]time.Time),
 		minSpreadBps:    1.0, // Minimum 0.01% spread to consider (lowered from 5.0)
+		preemptRatio:    0.7, // Pre-subscribe at 70% of the threshold
 		refreshInterval: 30 * time.Second,
 		parallelFetch:   true,
 	}
 }
 
+// Renamer maps a canonical to the one in effect after symbol renames;
+// *normalizer.InstrumentNormalizer implements it
+type Renamer interface {
+	Rename(exchange connector.ExchangeID, canonical string) string
+}
+
+// SetRenamer renames the canonicals of fetched instruments, tickers and
+// funding rates, so venues that have not migrated a renamed asset yet are
+// still paired with those that have
+func (l *RestDataLoader) SetRenamer(r Renamer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.renamer = r
+}
+
+// SetSymbolMap registers every fetched instrument list in m and takes
+// canonicals from it, so 1000PEPEUSDT on one venue pairs with PEPE_USDT on
+// another at unit prices
+func (l *RestDataLoader) SetSymbolMap(m *symbolmap.Map) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.symbols = m
+}
+
 // SetMinSpreadBps sets the minimum spread in basis points
 func (l *RestDataLoader) SetMinSpreadBps(bps float64) {
 	l.mu.Lock()
@@ -102,6 +151,110 @@ func (l *RestDataLoader) SetMinSpreadBps(bps float64) {
 	l.minSpreadBps = bps
 }
 
+// SetPreemptRatio sets the fraction of minSpreadBps at which a spread is
+// considered near the threshold and its symbols are pre-subscribed.
+// A ratio of 1 or more disables pre-emptive subscription.
+func (l *RestDataLoader) SetPreemptRatio(ratio float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.preemptRatio = ratio
+}
+
+// WatchCanonical keeps a canonical's symbols subscribed on every venue
+// listing it until the given time, whether or not a spread exists
+func (l *RestDataLoader) WatchCanonical(canonical string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.watched[canonical]) {
+		l.watched[canonical] = until
+	}
+}
+
+// ExcludeCanonical drops a canonical from spread discovery until the given
+// time, e.g. while a venue is delisting it
+func (l *RestDataLoader) ExcludeCanonical(canonical string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.excluded[canonical]) {
+		l.excluded[canonical] = until
+	}
+}
+
+// SetUniverse restricts spread candidates to the canonicals each venue is
+// configured for: a spread is only considered if both legs' venues allow its
+// canonical. Venues missing from the map are unrestricted and nil lifts the
+// restriction. Data already loaded is rescanned at once.
+func (l *RestDataLoader) SetUniverse(canonicals map[connector.ExchangeID][]string) {
+	var allowed map[connector.ExchangeID]map[string]bool
+	if canonicals != nil {
+		allowed = make(map[connector.ExchangeID]map[string]bool, len(canonicals))
+		for exchID, list := range canonicals {
+			set := make(map[string]bool, len(list))
+			for _, canonical := range list {
+				set[canonical] = true
+			}
+			allowed[exchID] = set
+		}
+	}
+
+	l.mu.Lock()
+	l.universe = allowed
+	loaded := len(l.tokenData) > 0
+	l.mu.Unlock()
+	if loaded {
+		l.discoverSpreads()
+	}
+}
+
+// InUniverse reports whether a venue may pair spreads on a canonical
+func (l *RestDataLoader) InUniverse(exchID connector.ExchangeID, canonical string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.inUniverse(exchID, canonical)
+}
+
+// inUniverse is InUniverse for callers holding l.mu
+func (l *RestDataLoader) inUniverse(exchID connector.ExchangeID, canonical string) bool {
+	allowed, ok := l.universe[exchID]
+	return !ok || allowed[canonical]
+}
+
+// OutsideUniverse returns the symbols in active whose canonical the universe
+// doesn't allow on their venue, e.g. after it was reloaded
+func (l *RestDataLoader) OutsideUniverse(active map[connector.ExchangeID][]string) map[connector.ExchangeID][]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	outside := make(map[connector.ExchangeID]map[string]bool)
+	for canonical, td := range l.tokenData {
+		for exchID, etd := range td.Exchanges {
+			if l.inUniverse(exchID, canonical) {
+				continue
+			}
+			if outside[exchID] == nil {
+				outside[exchID] = make(map[string]bool)
+			}
+			outside[exchID][etd.Symbol] = true
+		}
+	}
+
+	result := make(map[connector.ExchangeID][]string)
+	for exchID, symbols := range active {
+		for _, s := range symbols {
+			if outside[exchID][s] {
+				result[exchID] = append(result[exchID], s)
+			}
+		}
+	}
+	return result
+}
+
+// isExcluded reports whether a canonical is excluded. Caller holds l.mu.
+func (l *RestDataLoader) isExcluded(canonical string, now time.Time) bool {
+	until, ok := l.excluded[canonical]
+	return ok && now.Before(until)
+}
+
 // LoadAll fetches data from all exchanges via REST APIs
 // This is Phase 1 of the two-phase approach
 func (l *RestDataLoader) LoadAll(ctx context.Context) error {
@@ -248,12 +401,69 @@ func (l *RestDataLoader) fetchExchangeData(ctx context.Context, conn connector.C
 		data.AssetInfo = assetInfo
 	}
 
+	l.mapData(data)
+	l.renameData(data)
+
 	// Record REST fetch duration
 	timer.ObserveDuration(metrics.RestFetchDuration, string(exchangeID), "all")
 
 	return data, nil
 }
 
+// mapData registers freshly fetched instruments in the symbol map and
+// rewrites canonicals from it, ticker and mark prices in base units
+func (l *RestDataLoader) mapData(data *ExchangeData) {
+	l.mu.RLock()
+	m := l.symbols
+	l.mu.RUnlock()
+	if m == nil {
+		return
+	}
+
+	m.Register(data.Instruments)
+	for i := range data.Instruments {
+		if e, ok := m.Unit(data.ExchangeID, data.Instruments[i].Symbol); ok {
+			data.Instruments[i].Canonical = e.Canonical
+		}
+	}
+	for i := range data.Tickers {
+		t := &data.Tickers[i]
+		if e, ok := m.Unit(data.ExchangeID, t.Symbol); ok {
+			t.Canonical = e.Canonical
+			t.Price = e.UnitPrice(t.Price)
+			t.BidPrice = e.UnitPrice(t.BidPrice)
+			t.AskPrice = e.UnitPrice(t.AskPrice)
+		}
+	}
+	for i := range data.FundingRates {
+		fr := &data.FundingRates[i]
+		if e, ok := m.Unit(data.ExchangeID, fr.Symbol); ok {
+			fr.Canonical = e.Canonical
+			fr.MarkPrice = e.UnitPrice(fr.MarkPrice)
+		}
+	}
+}
+
+// renameData applies symbol renames to freshly fetched data
+func (l *RestDataLoader) renameData(data *ExchangeData) {
+	l.mu.RLock()
+	r := l.renamer
+	l.mu.RUnlock()
+	if r == nil {
+		return
+	}
+
+	for i := range data.Instruments {
+		data.Instruments[i].Canonical = r.Rename(data.ExchangeID, data.Instruments[i].Canonical)
+	}
+	for i := range data.Tickers {
+		data.Tickers[i].Canonical = r.Rename(data.ExchangeID, data.Tickers[i].Canonical)
+	}
+	for i := range data.FundingRates {
+		data.FundingRates[i].Canonical = r.Rename(data.ExchangeID, data.FundingRates[i].Canonical)
+	}
+}
+
 // aggregateByToken aggregates exchange data by canonical token
 func (l *RestDataLoader) aggregateByToken() {
 	l.mu.Lock()
@@ -349,12 +559,29 @@ func (l *RestDataLoader) discoverSpreads() {
 	defer l.mu.Unlock()
 
 	l.spreads = make([]*RestPreliminarySpread, 0)
+	l.nearSpreads = make([]*RestPreliminarySpread, 0)
+	nearThreshold := l.minSpreadBps * l.preemptRatio
+	now := time.Now()
+
+	for canonical, until := range l.excluded {
+		if !now.Before(until) {
+			delete(l.excluded, canonical)
+		}
+	}
+	for canonical, until := range l.watched {
+		if !now.Before(until) {
+			delete(l.watched, canonical)
+		}
+	}
 
 	for canonical, td := range l.tokenData {
 		// Need at least 2 exchanges
 		if len(td.Exchanges) < 2 {
 			continue
 		}
+		if l.isExcluded(canonical, now) {
+			continue
+		}
 
 		// Get list of exchanges
 		exchanges := make([]connector.ExchangeID, 0, len(td.Exchanges))
@@ -371,6 +598,9 @@ func (l *RestDataLoader) discoverSpreads() {
 
 				longExch := exchanges[i]
 				shortExch := exchanges[j]
+				if !l.inUniverse(longExch, canonical) || !l.inUniverse(shortExch, canonical) {
+					continue
+				}
 				longData := td.Exchanges[longExch]
 				shortData := td.Exchanges[shortExch]
 
@@ -393,9 +623,13 @@ func (l *RestDataLoader) discoverSpreads() {
 				spreadPercent := (shortPrice - longPrice) / longPrice * 100
 				spreadBps := spreadPercent * 100
 
-				// Skip negative or too small spreads
+				// Skip negative or too small spreads, keeping those close to the threshold
+				near := false
 				if spreadBps < l.minSpreadBps {
-					continue
+					if l.preemptRatio >= 1 || spreadBps <= 0 || spreadBps < nearThreshold {
+						continue
+					}
+					near = true
 				}
 
 				// Calculate fees impact
@@ -421,13 +655,21 @@ func (l *RestDataLoader) discoverSpreads() {
 					DiscoveredAt:  time.Now(),
 				}
 
-				l.spreads = append(l.spreads, spread)
+				if near {
+					l.nearSpreads = append(l.nearSpreads, spread)
+				} else {
+					l.spreads = append(l.spreads, spread)
+				}
 			}
 		}
 	}
 
+	metrics.PreliminarySpreadsFound.Set(float64(len(l.spreads)))
+	metrics.NearThresholdSpreads.Set(float64(len(l.nearSpreads)))
+
 	log.Info().
 		Int("spreads", len(l.spreads)).
+		Int("near_threshold", len(l.nearSpreads)).
 		Float64("min_bps", l.minSpreadBps).
 		Msg("Discovered preliminary spreads from REST data")
 }
@@ -442,8 +684,19 @@ func (l *RestDataLoader) GetDiscoveredSpreads() []*RestPreliminarySpread {
 	return result
 }
 
+// GetNearThresholdSpreads returns spreads that are close to, but below, the threshold
+func (l *RestDataLoader) GetNearThresholdSpreads() []*RestPreliminarySpread {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]*RestPreliminarySpread, len(l.nearSpreads))
+	copy(result, l.nearSpreads)
+	return result
+}
+
 // GetSymbolsForWebSocket returns the unique symbols that need WebSocket subscription
-// This is used for Phase 2: selective WebSocket connection
+// This is used for Phase 2: selective WebSocket connection. Symbols of
+// near-threshold spreads are included so their books are live before they cross.
 func (l *RestDataLoader) GetSymbolsForWebSocket() map[connector.ExchangeID][]string {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -451,7 +704,11 @@ func (l *RestDataLoader) GetSymbolsForWebSocket() map[connector.ExchangeID][]str
 	result := make(map[connector.ExchangeID][]string)
 	symbolSets := make(map[connector.ExchangeID]map[string]bool)
 
-	for _, spread := range l.spreads {
+	candidates := make([]*RestPreliminarySpread, 0, len(l.spreads)+len(l.nearSpreads))
+	candidates = append(candidates, l.spreads...)
+	candidates = append(candidates, l.nearSpreads...)
+
+	for _, spread := range candidates {
 		// Long exchange symbol
 		if symbolSets[spread.LongExchange] == nil {
 			symbolSets[spread.LongExchange] = make(map[string]bool)
@@ -465,6 +722,24 @@ func (l *RestDataLoader) GetSymbolsForWebSocket() map[connector.ExchangeID][]str
 		symbolSets[spread.ShortExchange][spread.ShortSymbol] = true
 	}
 
+	// Announced listings, on whichever venues already list them
+	now := time.Now()
+	for canonical, until := range l.watched {
+		td, ok := l.tokenData[canonical]
+		if !ok || now.After(until) || l.isExcluded(canonical, now) {
+			continue
+		}
+		for exchID, etd := range td.Exchanges {
+			if !l.inUniverse(exchID, canonical) {
+				continue
+			}
+			if symbolSets[exchID] == nil {
+				symbolSets[exchID] = make(map[string]bool)
+			}
+			symbolSets[exchID][etd.Symbol] = true
+		}
+	}
+
 	// Convert sets to slices
 	for exchID, symbols := range symbolSets {
 		symbolList := make([]string, 0, len(symbols))
@@ -524,3 +799,44 @@ func (l *RestDataLoader) StartPeriodicRefresh(ctx context.Context) {
 		}
 	}()
 }
+
+// GetVolumeData returns the latest tickers from all exchanges that carry 24h volume
+func (l *RestDataLoader) GetVolumeData() []connector.PriceTicker {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var result []connector.PriceTicker
+	for _, exchData := range l.exchangeData {
+		for _, ticker := range exchData.Tickers {
+			if ticker.Canonical == "" || ticker.Volume24h <= 0 {
+				continue
+			}
+			result = append(result, ticker)
+		}
+	}
+	return result
+}
+
+// StartPeriodicRefreshWithCallback refreshes data periodically and invokes
+// the callback after every successful refresh
+func (l *RestDataLoader) StartPeriodicRefreshWithCallback(ctx context.Context, callback func(*RestDataLoader)) {
+	go func() {
+		ticker := time.NewTicker(l.refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Refresh(ctx); err != nil {
+					log.Error().Err(err).Msg("Periodic refresh failed")
+					continue
+				}
+				if callback != nil {
+					callback(l)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,166 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Channels a symbol is subscribed to
+const (
+	ChannelOrderbook = "orderbook"
+	ChannelTrade     = "trade"
+	ChannelFunding   = "funding"
+)
+
+// VenueSubscriptions is a venue's saved subscription set
+type VenueSubscriptions struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Symbols   []string             `json:"symbols"`
+	Channels  []string             `json:"channels"` // Subscribed for every symbol
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// channels returns what a connector subscribes per symbol
+func channels(id connector.ExchangeID) []string {
+	if connector.HasFundingWS(id) {
+		return []string{ChannelOrderbook, ChannelTrade, ChannelFunding}
+	}
+	return []string{ChannelOrderbook, ChannelTrade}
+}
+
+// SubscriptionStateConfig controls how the subscription set is saved
+type SubscriptionStateConfig struct {
+	SaveInterval time.Duration
+	// MaxAge is how long a saved set outlives its last save; an instance
+	// starting later rebuilds from REST alone
+	MaxAge time.Duration
+}
+
+// DefaultSubscriptionStateConfig saves every 30s and keeps the set an hour
+func DefaultSubscriptionStateConfig() SubscriptionStateConfig {
+	return SubscriptionStateConfig{
+		SaveInterval: 30 * time.Second,
+		MaxAge:       keyspace.SubscriptionStateTTL,
+	}
+}
+
+// SubscriptionState saves the WebSocket subscription set to Redis so a
+// restarted or failed-over instance can resubscribe at once from the saved
+// set, instead of waiting for Phase 1 to rebuild it from REST
+type SubscriptionState struct {
+	client *redis.Client
+	config SubscriptionStateConfig
+}
+
+// NewSubscriptionState creates the store
+func NewSubscriptionState(client *redis.Client, config SubscriptionStateConfig) *SubscriptionState {
+	return &SubscriptionState{client: client, config: config}
+}
+
+// Load returns the saved symbols per exchange. Venues saved with other
+// channels than their connector now subscribes are left out, so a changed
+// connector starts from REST like any other.
+func (s *SubscriptionState) Load(ctx context.Context) (map[connector.ExchangeID][]string, error) {
+	raw, err := s.client.HGetAll(ctx, keyspace.Key(keyspace.SubscriptionStateKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[connector.ExchangeID][]string, len(raw))
+	for field, data := range raw {
+		var v VenueSubscriptions
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			log.Warn().Err(err).Str("exchange", field).Msg("Ignoring unreadable saved subscriptions")
+			continue
+		}
+		if !sameChannels(v.Channels, channels(v.Exchange)) {
+			log.Info().Str("exchange", field).Strs("channels", v.Channels).
+				Msg("Saved subscriptions use other channels, ignoring")
+			continue
+		}
+		if len(v.Symbols) > 0 {
+			result[v.Exchange] = v.Symbols
+		}
+	}
+	return result, nil
+}
+
+func sameChannels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	sort.Strings(a)
+	b = append([]string(nil), b...)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Save replaces the saved set. Every save restarts its MaxAge.
+func (s *SubscriptionState) Save(ctx context.Context, symbolsByExchange map[connector.ExchangeID][]string) error {
+	now := time.Now()
+	fields := make(map[string]interface{}, len(symbolsByExchange))
+	for exchID, symbols := range symbolsByExchange {
+		if len(symbols) == 0 {
+			continue
+		}
+		sorted := append([]string(nil), symbols...)
+		sort.Strings(sorted)
+		data, err := json.Marshal(VenueSubscriptions{
+			Exchange:  exchID,
+			Symbols:   sorted,
+			Channels:  channels(exchID),
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		fields[string(exchID)] = data
+	}
+
+	key := keyspace.Key(keyspace.SubscriptionStateKey)
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(fields) > 0 {
+		pipe.HSet(ctx, key, fields)
+		pipe.Expire(ctx, key, s.config.MaxAge)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Run saves source's symbols every SaveInterval until ctx is done. An empty
+// set is skipped: shutdown empties it, and the last save before that is
+// what a successor should resume from.
+func (s *SubscriptionState) Run(ctx context.Context, source func() map[connector.ExchangeID][]string) {
+	ticker := time.NewTicker(s.config.SaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			symbols := source()
+			if len(symbols) == 0 {
+				continue
+			}
+			if err := s.Save(ctx, symbols); err != nil {
+				log.Warn().Err(err).Msg("Failed to save subscription state")
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
 
 	"github.com/rs/zerolog/log"
 )
@@ -25,6 +26,18 @@ type WebSocketManager struct {
 	fundingHandler   connector.FundingHandler
 	errorHandler     connector.ErrorHandler
 
+	// Optional filter dropping symbols that must not be subscribed (e.g. blacklisted)
+	symbolFilter func(connector.ExchangeID, []string) []string
+
+	// Optional check deferring reconnects to a venue, e.g. during its
+	// scheduled maintenance
+	reconnectHold func(connector.ExchangeID) bool
+
+	// REST snapshots fetched after a reconnect go to backfillHandler so
+	// consumers aren't blind until the first WebSocket updates arrive
+	backfillHandler connector.OrderbookHandler
+	backfillDepth   int
+
 	done chan struct{}
 }
 
@@ -62,12 +75,57 @@ func (m *WebSocketManager) SetErrorHandler(handler connector.ErrorHandler) {
 	m.errorHandler = handler
 }
 
-// ConnectForSpreads establishes WebSocket connections only for the symbols in discovered spreads
-// symbolsByExchange: map of exchange ID to list of symbols to subscribe
-func (m *WebSocketManager) ConnectForSpreads(ctx context.Context, symbolsByExchange map[connector.ExchangeID][]string) error {
+// SetBackfillHandler sets the callback receiving REST orderbook snapshots of
+// a venue's symbols right after it reconnects. A depth of 0 disables backfill.
+func (m *WebSocketManager) SetBackfillHandler(depth int, handler connector.OrderbookHandler) {
+	m.backfillDepth = depth
+	m.backfillHandler = handler
+}
+
+// SetSymbolFilter sets a filter applied to every symbol list before subscribing
+func (m *WebSocketManager) SetSymbolFilter(filter func(connector.ExchangeID, []string) []string) {
+	m.symbolFilter = filter
+}
+
+// SetReconnectHold sets a check consulted before reconnecting to a venue;
+// while it returns true the venue is left disconnected
+func (m *WebSocketManager) SetReconnectHold(hold func(connector.ExchangeID) bool) {
+	m.reconnectHold = hold
+}
+
+func (m *WebSocketManager) held(exchID connector.ExchangeID) bool {
+	return m.reconnectHold != nil && m.reconnectHold(exchID)
+}
+
+func (m *WebSocketManager) filterSymbols(exchID connector.ExchangeID, symbols []string) []string {
+	if m.symbolFilter == nil {
+		return symbols
+	}
+	return m.symbolFilter(exchID, symbols)
+}
+
+// RemoveSymbols unsubscribes symbols and forgets them so reconnects don't resubscribe
+func (m *WebSocketManager) RemoveSymbols(exchID connector.ExchangeID, symbols []string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	conn, ok := m.connectors[exchID]
+	if !ok {
+		return nil
+	}
+	for _, s := range symbols {
+		delete(m.activeSymbols[exchID], s)
+	}
+	if !conn.IsConnected() {
+		return nil
+	}
+	return conn.Unsubscribe(symbols)
+}
+
+// ConnectForSpreads establishes WebSocket connections only for the symbols in discovered spreads
+// symbolsByExchange: map of exchange ID to list of symbols to subscribe
+func (m *WebSocketManager) ConnectForSpreads(ctx context.Context, symbolsByExchange map[connector.ExchangeID][]string) error {
+	// Not holding m.mu here: the per-exchange goroutines take it to record active symbols
 	log.Info().
 		Int("exchanges", len(symbolsByExchange)).
 		Msg("Phase 2: Connecting WebSockets for discovered spreads")
@@ -84,6 +142,7 @@ func (m *WebSocketManager) ConnectForSpreads(ctx context.Context, symbolsByExcha
 			continue
 		}
 
+		symbols = m.filterSymbols(exchID, symbols)
 		if len(symbols) == 0 {
 			continue
 		}
@@ -194,6 +253,7 @@ func (m *WebSocketManager) UpdateSubscriptions(ctx context.Context, symbolsByExc
 		currentSymbols := m.activeSymbols[exchID]
 		if currentSymbols == nil {
 			currentSymbols = make(map[string]bool)
+			m.activeSymbols[exchID] = currentSymbols
 		}
 
 		// Find symbols to add
@@ -258,6 +318,112 @@ func (m *WebSocketManager) UpdateSubscriptions(ctx context.Context, symbolsByExc
 	return nil
 }
 
+// AddSubscriptions subscribes to any symbols not yet active without removing existing ones.
+// Exchanges with no live connection are connected for their full symbol set.
+// Returns the number of newly subscribed symbols per exchange.
+func (m *WebSocketManager) AddSubscriptions(ctx context.Context, symbolsByExchange map[connector.ExchangeID][]string) map[connector.ExchangeID]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added := make(map[connector.ExchangeID]int)
+
+	for exchID, symbols := range symbolsByExchange {
+		conn, ok := m.connectors[exchID]
+		if !ok {
+			continue
+		}
+
+		currentSymbols := m.activeSymbols[exchID]
+		if currentSymbols == nil {
+			currentSymbols = make(map[string]bool)
+			m.activeSymbols[exchID] = currentSymbols
+		}
+
+		var toAdd []string
+		for _, s := range m.filterSymbols(exchID, symbols) {
+			if !currentSymbols[s] {
+				toAdd = append(toAdd, s)
+			}
+		}
+		if len(toAdd) == 0 {
+			continue
+		}
+
+		var err error
+		if conn.IsConnected() {
+			err = conn.Subscribe(toAdd)
+		} else if m.held(exchID) {
+			continue
+		} else {
+			// First subscription for this exchange (or it dropped): connect with everything
+			all := make([]string, 0, len(currentSymbols)+len(toAdd))
+			for s := range currentSymbols {
+				all = append(all, s)
+			}
+			all = append(all, toAdd...)
+			m.setupHandlers(conn)
+			if err = conn.ConnectForSymbols(ctx, all); err == nil && len(currentSymbols) > 0 {
+				go m.backfill(ctx, conn, all)
+			}
+		}
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("exchange", string(exchID)).
+				Int("count", len(toAdd)).
+				Msg("Failed to add symbol subscriptions")
+			continue
+		}
+
+		for _, s := range toAdd {
+			currentSymbols[s] = true
+		}
+		added[exchID] = len(toAdd)
+		metrics.WebsocketSymbolsSubscribed.WithLabelValues(string(exchID)).Set(float64(len(currentSymbols)))
+
+		log.Info().
+			Str("exchange", string(exchID)).
+			Int("count", len(toAdd)).
+			Msg("Added symbol subscriptions")
+	}
+
+	return added
+}
+
+// Reconcile converges the subscriptions on symbolsByExchange: symbols no
+// longer in it are unsubscribed and missing ones are added. It takes over a
+// set restored from saved state once Phase 1 has rebuilt the real one.
+func (m *WebSocketManager) Reconcile(ctx context.Context, symbolsByExchange map[connector.ExchangeID][]string) map[connector.ExchangeID]int {
+	for exchID, active := range m.GetActiveSymbols() {
+		wanted := make(map[string]bool, len(symbolsByExchange[exchID]))
+		for _, s := range symbolsByExchange[exchID] {
+			wanted[s] = true
+		}
+		var stale []string
+		for _, s := range active {
+			if !wanted[s] {
+				stale = append(stale, s)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		if err := m.RemoveSymbols(exchID, stale); err != nil {
+			log.Warn().
+				Err(err).
+				Str("exchange", string(exchID)).
+				Int("count", len(stale)).
+				Msg("Failed to unsubscribe stale symbols")
+		}
+		log.Info().
+			Str("exchange", string(exchID)).
+			Int("count", len(stale)).
+			Msg("Dropped restored symbols no longer needed")
+	}
+
+	return m.AddSubscriptions(ctx, symbolsByExchange)
+}
+
 // GetActiveSymbols returns currently subscribed symbols per exchange
 func (m *WebSocketManager) GetActiveSymbols() map[connector.ExchangeID][]string {
 	m.mu.RLock()
@@ -353,15 +519,19 @@ func (m *WebSocketManager) checkAndReconnect(ctx context.Context) {
 		}
 
 		if !conn.IsConnected() {
+			if m.held(exchID) {
+				continue // Reconnect once the hold lifts, e.g. maintenance is over
+			}
 			log.Warn().
 				Str("exchange", string(exchID)).
 				Msg("WebSocket disconnected, attempting reconnect")
 
-			// Convert map to slice
+			// Convert map to slice, skipping symbols filtered out since they were added
 			symbolList := make([]string, 0, len(symbols))
 			for s := range symbols {
 				symbolList = append(symbolList, s)
 			}
+			symbolList = m.filterSymbols(exchID, symbolList)
 
 			if err := conn.ConnectForSymbols(ctx, symbolList); err != nil {
 				log.Error().
@@ -373,6 +543,7 @@ func (m *WebSocketManager) checkAndReconnect(ctx context.Context) {
 					Str("exchange", string(exchID)).
 					Int("symbols", len(symbolList)).
 					Msg("Reconnected to exchange")
+				go m.backfill(ctx, conn, symbolList)
 			}
 		}
 
@@ -386,3 +557,68 @@ func (m *WebSocketManager) checkAndReconnect(ctx context.Context) {
 		}
 	}
 }
+
+// backfillConcurrency bounds snapshot requests per venue during backfill
+const backfillConcurrency = 4
+
+// backfill fetches REST orderbook snapshots for a reconnected venue's
+// symbols and hands them to the backfill handler
+func (m *WebSocketManager) backfill(ctx context.Context, conn connector.Connector, symbols []string) {
+	if m.backfillHandler == nil || m.backfillDepth <= 0 || len(symbols) == 0 {
+		return
+	}
+
+	exchID := conn.ID()
+	start := time.Now()
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		seeded int
+		sem    = make(chan struct{}, backfillConcurrency)
+	)
+
+	for _, symbol := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+
+			// Stamp before the request so any WebSocket book received while
+			// it was in flight counts as fresher
+			requestedAt := time.Now()
+			ob, err := conn.FetchOrderbookSnapshot(reqCtx, symbol, m.backfillDepth)
+			if err != nil || ob == nil {
+				log.Debug().Err(err).Str("exchange", string(exchID)).Str("symbol", symbol).Msg("Backfill snapshot failed")
+				return
+			}
+			ob.ExchangeID = exchID
+			if ob.Symbol == "" {
+				ob.Symbol = symbol
+			}
+			if ob.Canonical == "" {
+				ob.Canonical = connector.ParsePair(symbol).Canonical()
+			}
+			ob.IsSnapshot = true
+			ob.ReceivedAt = requestedAt
+			ob.NormalizedAt = time.Now()
+
+			m.backfillHandler(ob)
+			mu.Lock()
+			seeded++
+			mu.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+
+	metrics.ReconnectBackfillBooks.WithLabelValues(string(exchID)).Add(float64(seeded))
+	log.Info().
+		Str("exchange", string(exchID)).
+		Int("seeded", seeded).
+		Int("symbols", len(symbols)).
+		Dur("duration", time.Since(start)).
+		Msg("Backfilled orderbooks after reconnect")
+}
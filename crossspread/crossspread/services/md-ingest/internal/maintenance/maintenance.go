@@ -0,0 +1,424 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Window schedules
+const (
+	Daily = "daily"
+	Once  = "once"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Window is a known maintenance window of a venue: recurring daily or on a
+// weekday at a UTC time of day, e.g. a weekly settlement pause, or once
+type Window struct {
+	ID       string               `json:"id"`
+	Exchange connector.ExchangeID `json:"exchange"`
+	Schedule string               `json:"schedule"`        // daily, a weekday such as sun, or once
+	At       string               `json:"at,omitempty"`    // UTC time of day of a recurring window, HH:MM
+	Start    time.Time            `json:"start,omitempty"` // Start of a one-off window
+	Duration time.Duration        `json:"-"`
+	Reason   string               `json:"reason,omitempty"`
+
+	offset time.Duration // At as an offset from UTC midnight
+}
+
+// ParseWindow parses a recurring window spec, exchange:schedule@HH:MM/duration,
+// e.g. bybit:sun@04:00/30m or okx:daily@08:00/5m
+func ParseWindow(spec string) (Window, error) {
+	exchange, rest, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	schedule, rest, ok2 := strings.Cut(rest, "@")
+	at, length, ok3 := strings.Cut(rest, "/")
+	if !ok || !ok2 || !ok3 || exchange == "" {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: want exchange:schedule@HH:MM/duration", spec)
+	}
+	d, err := time.ParseDuration(length)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+	w := Window{
+		Exchange: connector.ExchangeID(strings.ToLower(exchange)),
+		Schedule: strings.ToLower(schedule),
+		At:       at,
+		Duration: d,
+	}
+	if err := w.validate(); err != nil {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+	return w, nil
+}
+
+// ParseWindows parses a comma-separated list of window specs
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	for _, spec := range strings.Split(s, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		w, err := ParseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// validate checks the schedule and duration and sets offset
+func (w *Window) validate() error {
+	if w.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	switch _, weekly := weekdays[w.Schedule]; {
+	case w.Schedule == Once:
+		if w.Start.IsZero() {
+			return fmt.Errorf("one-off window needs a start")
+		}
+		return nil
+	case w.Schedule == Daily:
+		if w.Duration >= 24*time.Hour {
+			return fmt.Errorf("daily window must be shorter than a day")
+		}
+	case weekly:
+		if w.Duration >= 7*24*time.Hour {
+			return fmt.Errorf("weekly window must be shorter than a week")
+		}
+	default:
+		return fmt.Errorf("schedule must be daily, once or a weekday (sun..sat), got %q", w.Schedule)
+	}
+	t, err := time.Parse("15:04", w.At)
+	if err != nil {
+		return fmt.Errorf("at must be a UTC time of day, HH:MM")
+	}
+	w.offset = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return nil
+}
+
+// occurs returns true if an occurrence of the window starts on day (UTC
+// midnight)
+func (w Window) occurs(day time.Time) bool {
+	if w.Schedule == Daily {
+		return true
+	}
+	return weekdays[w.Schedule] == day.Weekday()
+}
+
+// Active returns whether now falls in the window and, if so, when the
+// current occurrence ends
+func (w Window) Active(now time.Time) (time.Time, bool) {
+	if w.Schedule == Once {
+		end := w.Start.Add(w.Duration)
+		return end, !now.Before(w.Start) && now.Before(end)
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for back := 0; back <= 7; back++ {
+		day := today.AddDate(0, 0, -back)
+		if !w.occurs(day) {
+			continue
+		}
+		start := day.Add(w.offset)
+		if end := start.Add(w.Duration); !now.Before(start) && now.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Next returns the start of the next occurrence after now, or zero for a
+// one-off window that has started
+func (w Window) Next(now time.Time) time.Time {
+	if w.Schedule == Once {
+		if now.Before(w.Start) {
+			return w.Start
+		}
+		return time.Time{}
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for ahead := 0; ahead <= 7; ahead++ {
+		day := today.AddDate(0, 0, ahead)
+		if start := day.Add(w.offset); w.occurs(day) && start.After(now) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// Muter mutes and resumes exchange pairs; *spread.SpreadDiscovery implements it
+type Muter interface {
+	Mutes() []spread.PairMute
+	MutePair(m spread.PairMute) spread.PairMute
+	ResumePair(id, by string) (spread.PairMute, bool)
+}
+
+// Config controls the scheduler
+type Config struct {
+	CheckInterval time.Duration // How often windows are evaluated
+	Lead          time.Duration // Venues are muted this long before a window starts
+}
+
+// DefaultConfig checks every 15s and mutes 1m ahead of each window
+func DefaultConfig() Config {
+	return Config{
+		CheckInterval: 15 * time.Second,
+		Lead:          time.Minute,
+	}
+}
+
+// Status is a window and whether it is in effect
+type Status struct {
+	Window
+	Length    string    `json:"duration"`
+	Active    bool      `json:"active"`
+	Until     time.Time `json:"until,omitempty"`      // End of the current occurrence
+	NextStart time.Time `json:"next_start,omitempty"` // Start of the next occurrence
+}
+
+// Status returns the window's state at now
+func (w Window) Status(now time.Time) Status {
+	st := Status{Window: w, Length: w.Duration.String(), NextStart: w.Next(now)}
+	st.Until, st.Active = w.Active(now)
+	if !st.Active {
+		st.Until = time.Time{}
+	}
+	return st
+}
+
+// Scheduler mutes a venue in spread discovery for the length of its known
+// maintenance windows, starting a little ahead so no spread is published
+// on a book about to freeze, and tells the WebSocket manager to hold off
+// reconnecting so the outage doesn't turn into a reconnect storm. Both
+// lift on their own when the window ends.
+type Scheduler struct {
+	config Config
+	muter  Muter
+
+	mu      sync.RWMutex
+	windows []Window
+	nextID  int
+	active  map[connector.ExchangeID]time.Time // Venue -> end of its current window
+	done    chan struct{}
+}
+
+// NewScheduler creates a scheduler over the given windows
+func NewScheduler(config Config, muter Muter, windows []Window) *Scheduler {
+	s := &Scheduler{
+		config: config,
+		muter:  muter,
+		active: make(map[connector.ExchangeID]time.Time),
+		done:   make(chan struct{}),
+	}
+	for _, w := range windows {
+		s.add(w)
+	}
+	return s
+}
+
+// add assigns a window an ID and stores it. Caller holds s.mu or owns s.
+func (s *Scheduler) add(w Window) Window {
+	s.nextID++
+	w.ID = fmt.Sprintf("w%d", s.nextID)
+	s.windows = append(s.windows, w)
+	return w
+}
+
+// Add adds a window, e.g. a one-off maintenance a venue has announced
+func (s *Scheduler) Add(w Window) (Window, error) {
+	w.Exchange = connector.ExchangeID(strings.ToLower(string(w.Exchange)))
+	w.Schedule = strings.ToLower(w.Schedule)
+	if err := w.validate(); err != nil {
+		return Window{}, err
+	}
+
+	s.mu.Lock()
+	w = s.add(w)
+	s.mu.Unlock()
+
+	log.Info().Str("id", w.ID).Str("exchange", string(w.Exchange)).Str("schedule", w.Schedule).Dur("duration", w.Duration).Msg("Maintenance window added")
+	s.check(time.Now())
+	return w, nil
+}
+
+// Remove deletes a window; a venue it was muting resumes at the next check
+func (s *Scheduler) Remove(id string) bool {
+	s.mu.Lock()
+	found := false
+	for i, w := range s.windows {
+		if w.ID == id {
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if found {
+		s.check(time.Now())
+	}
+	return found
+}
+
+// Windows returns every window with its current state
+func (s *Scheduler) Windows() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]Status, 0, len(s.windows))
+	for _, w := range s.windows {
+		result = append(result, w.Status(now))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// InMaintenance returns true while a venue is muted for maintenance
+func (s *Scheduler) InMaintenance(exchange connector.ExchangeID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.active[exchange]
+	return ok
+}
+
+// HoldReconnect reports whether reconnecting to a venue should wait for its
+// maintenance to end; counted so held reconnects show on dashboards
+func (s *Scheduler) HoldReconnect(exchange connector.ExchangeID) bool {
+	if !s.InMaintenance(exchange) {
+		return false
+	}
+	metrics.MaintenanceReconnectsHeld.WithLabelValues(string(exchange)).Inc()
+	return true
+}
+
+// Start evaluates the windows now and then every check interval until ctx
+// is done or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	s.check(time.Now())
+
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.check(now)
+		}
+	}
+}
+
+// Stop stops the scheduler. Venues muted for maintenance stay muted until
+// their mutes expire.
+func (s *Scheduler) Stop() {
+	close(s.done)
+}
+
+// check mutes venues entering a window and resumes venues leaving one
+func (s *Scheduler) check(now time.Time) {
+	s.mu.Lock()
+	due := make(map[connector.ExchangeID]time.Time)
+	reasons := make(map[connector.ExchangeID]string)
+	for _, w := range s.windows {
+		// Looking ahead by the lead time starts the mute early; the
+		// occurrence's end still bounds it
+		end, ok := w.Active(now.Add(s.config.Lead))
+		if !ok {
+			end, ok = w.Active(now)
+		}
+		if ok && end.After(due[w.Exchange]) {
+			due[w.Exchange] = end
+			reasons[w.Exchange] = w.Reason
+		}
+	}
+	var entering, leaving []connector.ExchangeID
+	for ex, end := range due {
+		if current, ok := s.active[ex]; !ok || !current.Equal(end) {
+			entering = append(entering, ex)
+		}
+		s.active[ex] = end
+	}
+	for ex := range s.active {
+		if _, ok := due[ex]; !ok {
+			leaving = append(leaving, ex)
+			delete(s.active, ex)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, ex := range entering {
+		s.mute(ex, due[ex], reasons[ex], now)
+	}
+	for _, ex := range leaving {
+		s.resume(ex)
+	}
+}
+
+// muteIDs returns the mutes covering a venue on either leg
+func muteIDs(ex connector.ExchangeID) [2]spread.PairMute {
+	return [2]spread.PairMute{{Long: ex}, {Short: ex}}
+}
+
+// mute mutes both legs of a venue until end, leaving alone mutes an
+// operator already made
+func (s *Scheduler) mute(ex connector.ExchangeID, end time.Time, reason string, now time.Time) {
+	existing := make(map[string]spread.PairMute)
+	for _, m := range s.muter.Mutes() {
+		existing[m.ID] = m
+	}
+	if reason == "" {
+		reason = "scheduled maintenance"
+	}
+	for _, m := range muteIDs(ex) {
+		id := spread.MuteID(m.Long, m.Short)
+		if current, ok := existing[id]; ok && current.By != spread.MaintenanceBy {
+			continue
+		}
+		m.Reason = reason
+		m.By = spread.MaintenanceBy
+		m.At = now
+		m.Until = end
+		s.muter.MutePair(m)
+	}
+	metrics.MaintenanceActive.WithLabelValues(string(ex)).Set(1)
+	log.Info().Str("exchange", string(ex)).Time("until", end).Str("reason", reason).Msg("Venue entering maintenance window")
+}
+
+// resume lifts the scheduler's own mutes of a venue
+func (s *Scheduler) resume(ex connector.ExchangeID) {
+	existing := make(map[string]spread.PairMute)
+	for _, m := range s.muter.Mutes() {
+		existing[m.ID] = m
+	}
+	for _, m := range muteIDs(ex) {
+		id := spread.MuteID(m.Long, m.Short)
+		if current, ok := existing[id]; ok && current.By == spread.MaintenanceBy {
+			s.muter.ResumePair(id, spread.MaintenanceBy)
+		}
+	}
+	metrics.MaintenanceActive.WithLabelValues(string(ex)).Set(0)
+	log.Info().Str("exchange", string(ex)).Msg("Venue maintenance window over")
+}
@@ -0,0 +1,211 @@
+// Package mdschema describes the md-ingest keyspace by reflecting over the
+// payload types the service marshals. The machine-readable schema and
+// Markdown reference in docs/, and the Python/TypeScript bindings used by
+// sim-backtest and the web packages, are generated from it:
+//
+//go:generate go run ../../cmd/mdschema -format json -out ../../docs/keyspace.schema.json
+//go:generate go run ../../cmd/mdschema -format markdown -out ../../docs/KEYSPACE.md
+//go:generate go run ../../cmd/mdschema -format python -out ../../../sim-backtest/md_types.py
+//go:generate go run ../../cmd/mdschema -format typescript -out ../../../../packages/shared-types/src/mdIngest.ts
+package mdschema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/bars"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/execution"
+	"crossspread-md-ingest/internal/feedback"
+	"crossspread-md-ingest/internal/feedwatch"
+	"crossspread-md-ingest/internal/funding"
+	"crossspread-md-ingest/internal/history"
+	"crossspread-md-ingest/internal/index"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/loader"
+	"crossspread-md-ingest/internal/openinterest"
+	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/internal/spread"
+	"crossspread-md-ingest/internal/symbolstatus"
+	"crossspread-md-ingest/internal/venuehealth"
+	"crossspread-md-ingest/internal/webhook"
+	"crossspread-md-ingest/pkg/mdtypes"
+)
+
+// SchemaVersion is bumped whenever a published payload changes incompatibly
+const SchemaVersion = 1
+
+// Field describes a single JSON field of a payload type
+type Field struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`            // string, number, integer, boolean, timestamp, array, object
+	Items    string `json:"items,omitempty"` // Element type for arrays
+	Ref      string `json:"ref,omitempty"`   // Referenced type for objects
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// TypeSchema describes a payload type
+type TypeSchema struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// Schema is the machine-readable description of the md-ingest keyspace
+type Schema struct {
+	Version int              `json:"version"`
+	Keys    []keyspace.Entry `json:"keys"`
+	Types   []TypeSchema     `json:"types"`
+}
+
+// payloadTypes maps registry payload names to the Go types md-ingest marshals
+var payloadTypes = map[string]reflect.Type{
+	keyspace.PayloadOrderbook:     reflect.TypeOf(connector.Orderbook{}),
+	keyspace.PayloadTrade:         reflect.TypeOf(connector.Trade{}),
+	keyspace.PayloadFundingRate:   reflect.TypeOf(connector.FundingRate{}),
+	keyspace.PayloadSpread:        reflect.TypeOf(spread.SpreadOpportunity{}),
+	keyspace.PayloadSpreadSummary: reflect.TypeOf(spread.SpreadSummary{}),
+	keyspace.PayloadIndexPrice:    reflect.TypeOf(index.IndexPrice{}),
+	keyspace.PayloadEpisode:       reflect.TypeOf(history.Episode{}),
+	keyspace.PayloadCorrelation:   reflect.TypeOf(history.SpreadCorrelation{}),
+	keyspace.PayloadBar:           reflect.TypeOf(bars.Bar{}),
+	keyspace.PayloadOIEvent:       reflect.TypeOf(openinterest.Event{}),
+	keyspace.PayloadMigration:     reflect.TypeOf(execution.MigrationFlag{}),
+	keyspace.PayloadDeRisk:        reflect.TypeOf(execution.DeRiskState{}),
+	keyspace.PayloadVenueEquity:   reflect.TypeOf(execution.VenueEquity{}),
+	keyspace.PayloadTransfers:     reflect.TypeOf(execution.TransferLatency{}),
+	keyspace.PayloadSettlement:    reflect.TypeOf(funding.Settlement{}),
+	keyspace.PayloadFundingAction: reflect.TypeOf(funding.Action{}),
+	keyspace.PayloadSymbolStatus:  reflect.TypeOf(symbolstatus.Transition{}),
+	keyspace.PayloadTenantSpreads: reflect.TypeOf(spread.TenantSpreadSummary{}),
+	keyspace.PayloadBasisSummary:  reflect.TypeOf(spread.BasisSummary{}),
+	keyspace.PayloadPairMute:      reflect.TypeOf(spread.PairMute{}),
+	keyspace.PayloadVenueHealth:   reflect.TypeOf(venuehealth.Health{}),
+	keyspace.PayloadWebhook:       reflect.TypeOf(webhook.Endpoint{}),
+	keyspace.PayloadFeedAlert:     reflect.TypeOf(feedwatch.Alert{}),
+	keyspace.PayloadSubscriptions: reflect.TypeOf(loader.VenueSubscriptions{}),
+	keyspace.PayloadBBO:           reflect.TypeOf(publisher.BBO{}),
+	keyspace.PayloadHeartbeat:     reflect.TypeOf(mdtypes.Heartbeat{}),
+	keyspace.PayloadFalsePositive: reflect.TypeOf(feedback.Tag{}),
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// BuildSchema generates the schema by reflecting over the published Go types
+func BuildSchema() *Schema {
+	types := make(map[string]TypeSchema)
+	for _, t := range payloadTypes {
+		collectType(t, types)
+	}
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schema := &Schema{
+		Version: SchemaVersion,
+		Keys:    keyspace.Entries(),
+		Types:   make([]TypeSchema, 0, len(names)),
+	}
+	for _, name := range names {
+		schema.Types = append(schema.Types, types[name])
+	}
+	return schema
+}
+
+// collectType adds a struct type and all struct types it references
+func collectType(t reflect.Type, types map[string]TypeSchema) {
+	if _, ok := types[t.Name()]; ok {
+		return
+	}
+
+	ts := TypeSchema{Name: t.Name()}
+	types[t.Name()] = ts // Reserve name to stop recursion
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, optional := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+
+		field := Field{Name: name, Optional: optional}
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+			field.Optional = true
+		}
+
+		switch {
+		case ft == timeType:
+			field.Type = "timestamp"
+		case ft.Kind() == reflect.Slice:
+			field.Type = "array"
+			elem := ft.Elem()
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			field.Items = scalarType(elem)
+			if elem.Kind() == reflect.Struct && elem != timeType {
+				field.Items = elem.Name()
+				collectType(elem, types)
+			}
+		case ft.Kind() == reflect.Struct:
+			field.Type = "object"
+			field.Ref = ft.Name()
+			collectType(ft, types)
+		default:
+			field.Type = scalarType(ft)
+		}
+
+		ts.Fields = append(ts.Fields, field)
+	}
+
+	types[t.Name()] = ts
+}
+
+// jsonName returns the JSON field name and whether it is omitempty
+func jsonName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "" {
+		return sf.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = sf.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+// scalarType maps a Go kind to a schema type name
+func scalarType(t reflect.Type) string {
+	if t == timeType {
+		return "timestamp"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Config controls the process memory budget
+type Config struct {
+	LimitBytes    int64         // Soft memory limit passed to the GC (0 = leave unset)
+	BallastBytes  int64         // Heap ballast to reduce GC frequency at low heap sizes
+	ShedRatio     float64       // Fraction of LimitBytes at which subsystems start shedding
+	CheckInterval time.Duration // How often to sample memory usage
+}
+
+// DefaultConfig returns defaults sized for a 512MB container
+func DefaultConfig() Config {
+	return Config{
+		LimitBytes:    400 << 20,
+		ShedRatio:     0.85,
+		CheckInterval: 5 * time.Second,
+	}
+}
+
+// Reporter returns the estimated bytes held by a subsystem
+type Reporter func() int64
+
+// Shedder is notified when memory pressure starts (true) or ends (false)
+type Shedder func(shedding bool)
+
+// Manager applies the memory limit, tracks per-subsystem usage and
+// asks subsystems to shed when the heap approaches the limit
+type Manager struct {
+	mu sync.Mutex
+
+	config    Config
+	reporters map[string]Reporter
+	shedders  map[string]Shedder
+	shedding  bool
+
+	ballast []byte
+}
+
+// NewManager creates a memory manager. Call Apply to set the GC limit.
+func NewManager(config Config) *Manager {
+	return &Manager{
+		config:    config,
+		reporters: make(map[string]Reporter),
+		shedders:  make(map[string]Shedder),
+	}
+}
+
+// Apply sets the GC memory limit and allocates the ballast.
+// An explicit GOMEMLIMIT in the environment takes precedence over LimitBytes.
+func (m *Manager) Apply() {
+	if os.Getenv("GOMEMLIMIT") != "" {
+		m.config.LimitBytes = debug.SetMemoryLimit(-1)
+		log.Info().Int64("limit_bytes", m.config.LimitBytes).Msg("Using GOMEMLIMIT from environment")
+	} else if m.config.LimitBytes > 0 {
+		debug.SetMemoryLimit(m.config.LimitBytes)
+		log.Info().Int64("limit_bytes", m.config.LimitBytes).Msg("Memory limit set")
+	}
+
+	if m.config.BallastBytes > 0 {
+		// Never written, so the pages are not resident; it only raises the GC target
+		m.ballast = make([]byte, m.config.BallastBytes)
+		log.Info().Int64("ballast_bytes", m.config.BallastBytes).Msg("Heap ballast allocated")
+	}
+
+	metrics.MemoryLimit.Set(float64(m.config.LimitBytes))
+}
+
+// Register adds a subsystem to memory accounting. shed may be nil.
+func (m *Manager) Register(name string, report Reporter, shed Shedder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reporters[name] = report
+	if shed != nil {
+		m.shedders[name] = shed
+	}
+}
+
+// Shedding returns true while the process is over the shed threshold
+func (m *Manager) Shedding() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shedding
+}
+
+// Start samples memory usage until ctx is cancelled
+func (m *Manager) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *Manager) check() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	heap := int64(ms.HeapAlloc) - int64(len(m.ballast))
+	metrics.MemoryHeap.Set(float64(heap))
+	metrics.MemoryGCCycles.Set(float64(ms.NumGC))
+
+	m.mu.Lock()
+	names := make([]string, 0, len(m.reporters))
+	for name := range m.reporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metrics.MemorySubsystem.WithLabelValues(name).Set(float64(m.reporters[name]()))
+	}
+
+	if m.config.LimitBytes <= 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	threshold := int64(float64(m.config.LimitBytes) * m.config.ShedRatio)
+	// Hysteresis: stop shedding only once we're 10% below the threshold
+	shedding := m.shedding
+	if !shedding && heap > threshold {
+		shedding = true
+	} else if shedding && heap < threshold*9/10 {
+		shedding = false
+	}
+
+	changed := shedding != m.shedding
+	m.shedding = shedding
+	shedders := make([]Shedder, 0, len(m.shedders))
+	for _, shed := range m.shedders {
+		shedders = append(shedders, shed)
+	}
+	m.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if shedding {
+		metrics.MemoryShedding.Set(1)
+		log.Warn().
+			Int64("heap_bytes", heap).
+			Int64("threshold_bytes", threshold).
+			Msg("Memory soft limit reached, shedding")
+	} else {
+		metrics.MemoryShedding.Set(0)
+		log.Info().Int64("heap_bytes", heap).Msg("Memory back under soft limit")
+	}
+
+	for _, shed := range shedders {
+		shed(shedding)
+	}
+}
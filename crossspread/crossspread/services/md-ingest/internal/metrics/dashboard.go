@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dashboard rows, in the order they appear
+const (
+	RowExchanges = "Exchanges"
+	RowSpreads   = "Spreads"
+	RowLatency   = "Latency"
+	RowService   = "Service"
+)
+
+// DashboardUID is the UID of the generated dashboard, stable across
+// regenerations so re-imports replace it
+const DashboardUID = "md-ingest"
+
+// Dashboard is a Grafana dashboard, as accepted by its import API
+type Dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Tags          []string   `json:"tags"`
+	Timezone      string     `json:"timezone"`
+	Editable      bool       `json:"editable"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+// TimeRange is the dashboard's default time range
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Templating holds the dashboard variables
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+// Variable is a dashboard variable
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Query      string      `json:"query"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	AllValue   string      `json:"allValue,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+	Sort       int         `json:"sort,omitempty"`
+}
+
+// Datasource references the Prometheus datasource picked in the dashboard
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// GridPos places a panel on the dashboard's 24-column grid
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Panel is a row header or a time series panel
+type Panel struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	GridPos     GridPos      `json:"gridPos"`
+	Collapsed   bool         `json:"collapsed,omitempty"`
+	Datasource  *Datasource  `json:"datasource,omitempty"`
+	Targets     []Target     `json:"targets,omitempty"`
+	FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+}
+
+// Target is a panel's PromQL query
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+// FieldConfig sets a panel's unit
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+// FieldDefaults holds the field options shared by a panel's series
+type FieldDefaults struct {
+	Unit string `json:"unit"`
+}
+
+const (
+	panelWidth  = 12
+	panelHeight = 8
+	// Gauges labelled by symbol show only their largest series
+	symbolTopK = 20
+)
+
+var datasource = &Datasource{Type: "prometheus", UID: "${datasource}"}
+
+// BuildDashboard generates the service's Grafana dashboard from the
+// registered metrics: one panel per metric, in rows for per-exchange,
+// spread, latency and service-wide metrics
+func BuildDashboard() *Dashboard {
+	rows := map[string][]Metric{}
+	for _, m := range Registered() {
+		row := rowOf(m)
+		rows[row] = append(rows[row], m)
+	}
+
+	d := &Dashboard{
+		UID:           DashboardUID,
+		Title:         "md-ingest",
+		Description:   "Generated from the md-ingest metrics registry by go run ./cmd/mddashboard. Do not edit by hand.",
+		Tags:          []string{"md-ingest", "generated"},
+		Timezone:      "utc",
+		Editable:      false,
+		SchemaVersion: 39,
+		Refresh:       "30s",
+		Time:          TimeRange{From: "now-1h", To: "now"},
+		Templating: Templating{List: []Variable{
+			{Name: "datasource", Label: "Datasource", Type: "datasource", Query: "prometheus"},
+			{
+				Name:       "exchange",
+				Label:      "Exchange",
+				Type:       "query",
+				Query:      "label_values(md_connection_status, exchange)",
+				Datasource: datasource,
+				Multi:      true,
+				IncludeAll: true,
+				AllValue:   ".*",
+				Refresh:    2,
+				Sort:       1,
+			},
+		}},
+	}
+
+	id, y := 1, 0
+	for _, row := range []string{RowExchanges, RowSpreads, RowLatency, RowService} {
+		if len(rows[row]) == 0 {
+			continue
+		}
+		d.Panels = append(d.Panels, Panel{ID: id, Type: "row", Title: row, GridPos: GridPos{H: 1, W: 24, Y: y}})
+		id++
+		y++
+		for i, m := range rows[row] {
+			p := metricPanel(m)
+			p.ID = id
+			p.GridPos = GridPos{H: panelHeight, W: panelWidth, X: (i % 2) * panelWidth, Y: y + (i/2)*panelHeight}
+			d.Panels = append(d.Panels, p)
+			id++
+		}
+		y += (len(rows[row]) + 1) / 2 * panelHeight
+	}
+	return d
+}
+
+// rowOf places a metric: timings go to latency, spread metrics to spreads,
+// anything else labelled by exchange to exchanges
+func rowOf(m Metric) string {
+	switch {
+	case m.Type == TypeHistogram && strings.HasSuffix(m.Name, "_seconds"):
+		return RowLatency
+	case strings.Contains(m.Name, "spread"):
+		return RowSpreads
+	case hasLabel(m, "exchange"):
+		return RowExchanges
+	default:
+		return RowService
+	}
+}
+
+// metricPanel returns a metric's panel without its ID and position
+func metricPanel(m Metric) Panel {
+	sel := ""
+	if hasLabel(m, "exchange") {
+		sel = `{exchange=~"$exchange"}`
+	}
+	by := groupLabels(m)
+	legend := legendOf(by)
+
+	var targets []Target
+	switch m.Type {
+	case TypeCounter:
+		targets = []Target{{
+			RefID:        "A",
+			Expr:         fmt.Sprintf("sum%s (rate(%s%s[$__rate_interval]))", byClause(by), m.Name, sel),
+			LegendFormat: legend,
+		}}
+	case TypeHistogram:
+		bucketBy := byClause(append([]string{"le"}, by...))
+		for i, q := range []string{"0.5", "0.99"} {
+			targets = append(targets, Target{
+				RefID:        string(rune('A' + i)),
+				Expr:         fmt.Sprintf("histogram_quantile(%s, sum%s (rate(%s_bucket%s[$__rate_interval])))", q, bucketBy, m.Name, sel),
+				LegendFormat: strings.TrimSpace(fmt.Sprintf("p%s %s", strings.TrimPrefix(q, "0."), legend)),
+			})
+		}
+	default:
+		expr := m.Name + sel
+		if hasLabel(m, "symbol") {
+			expr = fmt.Sprintf("topk(%d, %s)", symbolTopK, expr)
+		}
+		targets = []Target{{RefID: "A", Expr: expr, LegendFormat: legendOf(m.Labels)}}
+	}
+
+	return Panel{
+		Type:        "timeseries",
+		Title:       m.Name,
+		Description: m.Help,
+		Datasource:  datasource,
+		Targets:     targets,
+		FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: unitOf(m)}},
+	}
+}
+
+// groupLabels returns the labels a counter or histogram is summed by: all
+// but symbol, which would give a series per instrument
+func groupLabels(m Metric) []string {
+	var by []string
+	for _, l := range m.Labels {
+		if l != "symbol" {
+			by = append(by, l)
+		}
+	}
+	return by
+}
+
+func byClause(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return " by (" + strings.Join(labels, ", ") + ")"
+}
+
+func legendOf(labels []string) string {
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = "{{" + l + "}}"
+	}
+	return strings.Join(parts, " ")
+}
+
+// unitOf picks a Grafana unit from the metric's name and type
+func unitOf(m Metric) string {
+	name := strings.TrimSuffix(m.Name, "_total")
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes"):
+		if m.Type == TypeCounter {
+			return "Bps"
+		}
+		return "bytes"
+	case strings.HasSuffix(name, "_pct"):
+		return "percent"
+	case m.Type == TypeCounter:
+		return "ops"
+	default:
+		return "short"
+	}
+}
+
+func hasLabel(m Metric, label string) bool {
+	for _, l := range m.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
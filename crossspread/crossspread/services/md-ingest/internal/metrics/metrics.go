@@ -1,11 +1,11 @@
 package metrics
 
 import (
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 )
@@ -13,7 +13,7 @@ import (
 // Metrics for the market data ingestion service
 var (
 	// Orderbook metrics
-	OrderbookUpdates = promauto.NewCounterVec(
+	OrderbookUpdates = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "md_orderbook_updates_total",
 			Help: "Total number of orderbook updates received",
@@ -21,7 +21,7 @@ var (
 		[]string{"exchange", "symbol"},
 	)
 
-	OrderbookDepth = promauto.NewGaugeVec(
+	OrderbookDepth = newGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "md_orderbook_depth",
 			Help: "Current orderbook depth (number of levels)",
@@ -29,7 +29,7 @@ var (
 		[]string{"exchange", "symbol", "side"},
 	)
 
-	OrderbookBestBid = promauto.NewGaugeVec(
+	OrderbookBestBid = newGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "md_orderbook_best_bid",
 			Help: "Current best bid price",
@@ -37,7 +37,7 @@ var (
 		[]string{"exchange", "symbol"},
 	)
 
-	OrderbookBestAsk = promauto.NewGaugeVec(
+	OrderbookBestAsk = newGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "md_orderbook_best_ask",
 			Help: "Current best ask price",
@@ -45,7 +45,7 @@ var (
 		[]string{"exchange", "symbol"},
 	)
 
-	OrderbookSpread = promauto.NewGaugeVec(
+	OrderbookSpread = newGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "md_orderbook_spread_bps",
 			Help: "Current bid-ask spread in basis points",
@@ -54,7 +54,7 @@ var (
 	)
 
 	// Trade metrics
-	TradeCount = promauto.NewCounterVec(
+	TradeCount = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "md_trades_total",
 			Help: "Total number of trades received",
@@ -62,7 +62,7 @@ var (
 		[]string{"exchange", "symbol", "side"},
 	)
 
-	TradeVolume = promauto.NewCounterVec(
+	TradeVolume = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "md_trade_volume_total",
 			Help: "Total trade volume",
@@ -71,7 +71,7 @@ var (
 	)
 
 	// Latency metrics
-	MessageLatency = promauto.NewHistogramVec(
+	MessageLatency = newHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "md_message_latency_seconds",
 			Help:    "Latency from exchange timestamp to processing",
@@ -80,157 +80,1163 @@ var (
 		[]string{"exchange", "message_type"},
 	)
 
-	ProcessingDuration = promauto.NewHistogramVec(
+	PipelineLatency = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_pipeline_latency_seconds",
+			Help:    "Per-stage latency from exchange event to Redis delivery (exchange_to_receive, receive_to_normalize, normalize_to_publish, total)",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"exchange", "message_type", "stage"},
+	)
+
+	// WebSocket bandwidth metrics (compare payload vs wire bytes for compression savings)
+	WSWireBytes = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_ws_wire_bytes_total",
+			Help: "Bytes read from the WebSocket TCP connection (compressed, including TLS)",
+		},
+		[]string{"exchange"},
+	)
+
+	WSPayloadBytes = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_ws_payload_bytes_total",
+			Help: "Bytes of WebSocket message payload after inflate",
+		},
+		[]string{"exchange"},
+	)
+
+	WSFrameCPU = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_ws_frame_cpu_seconds_total",
+			Help: "Time spent in TLS, framing and inflate while reading frames, excluding network wait",
+		},
+		[]string{"exchange"},
+	)
+
+	WSCompressionNegotiated = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_ws_compression_negotiated",
+			Help: "permessage-deflate negotiated on the current connection (1=yes, 0=no)",
+		},
+		[]string{"exchange"},
+	)
+
+	WSConnections = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_ws_connections",
+			Help: "Open WebSocket connections by exchange",
+		},
+		[]string{"exchange"},
+	)
+
+	ExchangeSockets = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_exchange_sockets",
+			Help: "Open sockets (file descriptors) dialed for an exchange's WebSockets, including handshakes in flight",
+		},
+		[]string{"exchange"},
+	)
+
+	ExchangeGoroutines = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_exchange_goroutines",
+			Help: "Running connector goroutines (read, ping and resync loops) by exchange",
+		},
+		[]string{"exchange"},
+	)
+
+	ExchangeBudgetExceeded = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_exchange_budget_exceeded_total",
+			Help: "Times an exchange hit its soft cap: refused dials for sockets, alerts for goroutines",
+		},
+		[]string{"exchange", "resource"},
+	)
+
+	ProcessingDuration = newHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "md_processing_duration_seconds",
 			Help:    "Time to process and publish a message",
 			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1},
 		},
-		[]string{"exchange", "message_type"},
+		[]string{"exchange", "message_type"},
+	)
+
+	// Connection metrics
+	ConnectionStatus = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_connection_status",
+			Help: "WebSocket connection status (1=connected, 0=disconnected)",
+		},
+		[]string{"exchange"},
+	)
+
+	TradeSidesInferred = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_trade_sides_inferred_total",
+			Help: "Total number of trades without a venue side, by inference rule (quote, tick, none)",
+		},
+		[]string{"exchange", "rule"},
+	)
+
+	BusQueueDepth = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_bus_queue_depth",
+			Help: "Events queued for an event bus subscriber",
+		},
+		[]string{"topic", "subscriber"},
+	)
+
+	BusDropped = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_bus_dropped_total",
+			Help: "Total number of events dropped by an event bus subscriber's backpressure policy",
+		},
+		[]string{"topic", "subscriber"},
+	)
+
+	BusPanics = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_bus_panics_total",
+			Help: "Total number of recovered panics in event bus subscribers",
+		},
+		[]string{"topic", "subscriber"},
+	)
+
+	SymbolsGated = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_symbols_gated",
+			Help: "Symbols a venue reports as not open for trading, by status",
+		},
+		[]string{"exchange", "status"},
+	)
+
+	SymbolStatusTransitions = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_symbol_status_transitions_total",
+			Help: "Total number of symbol status transitions by new status and source (venue or window)",
+		},
+		[]string{"exchange", "status", "source"},
+	)
+
+	SettingsReloads = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_settings_reloads_total",
+			Help: "Total number of runtime settings reloads from Redis by trigger (watch, poll) and result",
+		},
+		[]string{"trigger", "result"},
+	)
+
+	UniverseReloads = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_universe_reloads_total",
+			Help: "Total number of symbol universe config reloads on SIGHUP by result",
+		},
+		[]string{"result"},
+	)
+
+	SettingsChanges = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_settings_changes_total",
+			Help: "Total number of runtime settings changes applied, by section (params, mutes, tiers)",
+		},
+		[]string{"section"},
+	)
+
+	SpreadTrackerSpreads = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_spread_tracker_spreads",
+			Help: "Spreads currently kept by the per-symbol top-N tracker",
+		},
+	)
+
+	SpreadTrackerEvictions = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_spread_tracker_evictions_total",
+			Help: "Total number of spreads dropped by the top-N tracker, by the ranking that pushed them out (bps, net_edge) or rejected when they made neither",
+		},
+		[]string{"reason"},
+	)
+
+	ColdStartFetches = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_cold_start_ticker_fetches_total",
+			Help: "Total number of all-tickers requests made by the cold-start fast path, by exchange and result",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	ColdStartBooks = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_cold_start_books_total",
+			Help: "Total number of approximate ticker books seeded into spread discovery during cold start",
+		},
+		[]string{"exchange"},
+	)
+
+	ColdStartApproximateDropped = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_cold_start_approximate_dropped_total",
+			Help: "Total number of approximate books never replaced by a real book and dropped when the cold-start window ended",
+		},
+	)
+
+	ExchangeAPIRequests = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_exchange_api_requests_total",
+			Help: "Total number of venue API calls and WebSocket handshakes by exchange and API version (path prefix through its vN segment)",
+		},
+		[]string{"exchange", "version"},
+	)
+
+	ExchangeAPIDeprecationSignals = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_exchange_api_deprecation_signals_total",
+			Help: "Total number of deprecation signals seen by exchange and source (header, ws_header, body, config)",
+		},
+		[]string{"exchange", "source"},
+	)
+
+	ExchangeAPIDeprecated = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_exchange_api_deprecated",
+			Help: "1 for exchange API versions a venue has flagged as deprecated or sunsetting",
+		},
+		[]string{"exchange", "version"},
+	)
+
+	ExchangeAPISunsetTimestamp = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_exchange_api_sunset_timestamp_seconds",
+			Help: "Unix time an exchange API version is announced to be switched off",
+		},
+		[]string{"exchange", "version"},
+	)
+
+	ExchangeAPISunsetDays = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_exchange_api_sunset_days",
+			Help: "Days until an exchange API version is switched off, negative once past; alert on this before feeds break",
+		},
+		[]string{"exchange", "version"},
+	)
+
+	MaintenanceActive = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_maintenance_active",
+			Help: "1 while an exchange is muted for a scheduled maintenance window",
+		},
+		[]string{"exchange"},
+	)
+
+	MaintenanceReconnectsHeld = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_maintenance_reconnects_held_total",
+			Help: "Reconnects to an exchange deferred until its maintenance window ends",
+		},
+		[]string{"exchange"},
+	)
+
+	ConnectorPanics = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_connector_panics_total",
+			Help: "Total number of recovered panics in connector goroutines and handler callbacks",
+		},
+		[]string{"exchange", "where"},
+	)
+
+	ConnectionReconnects = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_reconnects_total",
+			Help: "Total number of reconnection attempts",
+		},
+		[]string{"exchange"},
+	)
+
+	OrderbookResyncs = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_orderbook_resyncs_total",
+			Help: "Total number of local orderbooks rebuilt from a snapshot after a sequence gap",
+		},
+		[]string{"exchange"},
+	)
+
+	OrderbookChecksumFailures = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_orderbook_checksum_failures_total",
+			Help: "Total number of book pushes whose CRC32 checksum did not match the book, each followed by a resubscribe",
+		},
+		[]string{"exchange"},
+	)
+
+	ReconnectBackfillBooks = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_reconnect_backfill_books_total",
+			Help: "Total number of REST orderbook snapshots used to re-seed spread discovery after a reconnect",
+		},
+		[]string{"exchange"},
+	)
+
+	SymbolErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_symbol_errors_total",
+			Help: "Total number of errors attributed to a single symbol",
+		},
+		[]string{"exchange"},
+	)
+
+	SymbolsBlacklisted = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_symbols_blacklisted_total",
+			Help: "Total number of times a symbol was blacklisted after repeated errors",
+		},
+		[]string{"exchange"},
+	)
+
+	ConnectionErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_connection_errors_total",
+			Help: "Total number of connection errors",
+		},
+		[]string{"exchange", "error_type"},
+	)
+
+	// Spread discovery metrics
+	SpreadsDiscovered = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_spreads_discovered_total",
+			Help: "Total number of spreads discovered",
+		},
+		[]string{"symbol"},
+	)
+
+	// SpreadsFiltered counts opportunities the operator filter expression rejected
+	SpreadsFiltered = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_spreads_filtered_total",
+			Help: "Total number of spread evaluations rejected by the runtime filter expression",
+		},
+	)
+
+	// SpreadsExpiredUnwritten counts spreads whose older quote was past the
+	// data TTL when published, so their key wasn't written
+	SpreadsExpiredUnwritten = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_spreads_expired_unwritten_total",
+			Help: "Total number of spreads not stored because their older leg's quote outlived the data TTL",
+		},
+	)
+
+	// SpreadsActivePruned counts IDs removed from spreads:active once their data key was gone
+	SpreadsActivePruned = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_spreads_active_pruned_total",
+			Help: "Total number of spread IDs removed from spreads:active after their data expired",
+		},
+	)
+
+	SpreadValue = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_spread_value_bps",
+			Help: "Current spread value in basis points",
+		},
+		[]string{"symbol", "long_exchange", "short_exchange"},
+	)
+
+	SpreadSlippage = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_spread_slippage_bps",
+			Help: "Estimated slippage for spread entry",
+		},
+		[]string{"symbol", "long_exchange", "short_exchange"},
+	)
+
+	// Redis metrics
+	RedisPublishDuration = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_redis_publish_duration_seconds",
+			Help:    "Time to publish message to Redis",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05},
+		},
+		[]string{"channel"},
+	)
+
+	HeartbeatChannels = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_heartbeat_channels",
+			Help: "Published channels given a heartbeat on the last beat",
+		},
+	)
+
+	RedisPublishErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_redis_publish_errors_total",
+			Help: "Total number of Redis publish errors",
+		},
+		[]string{"channel"},
+	)
+
+	// JetStream backend metrics, when PUBLISHER_BACKEND=jetstream
+	JetStreamPublishErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_jetstream_publish_errors_total",
+			Help: "JetStream publishes not acknowledged after retries, by message type",
+		},
+		[]string{"type"},
+	)
+
+	JetStreamRetries = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_jetstream_retries_total",
+			Help: "JetStream publishes retried after a failed acknowledgement, by message type",
+		},
+		[]string{"type"},
+	)
+
+	JetStreamDropped = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_jetstream_dropped_total",
+			Help: "JetStream publishes dropped after a failed acknowledgement found the retry queue full, by message type",
+		},
+		[]string{"type"},
+	)
+
+	JetStreamPending = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_jetstream_pending",
+			Help: "JetStream publishes awaiting acknowledgement",
+		},
+	)
+
+	// Publish class metrics, while publishes are prioritized by class
+	PublishClassLatency = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_publish_class_latency_seconds",
+			Help:    "Time to publish to Redis by traffic class (spread, trade, orderbook, analytics), including queueing for a slot",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5},
+		},
+		[]string{"class"},
+	)
+
+	PublishQueueWait = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_publish_queue_wait_seconds",
+			Help:    "Time a publish waited for a Redis slot while the publisher was backlogged, by class",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5},
+		},
+		[]string{"class"},
+	)
+
+	PublishQueued = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_publish_queued",
+			Help: "Publishes waiting for a Redis slot by class",
+		},
+		[]string{"class"},
+	)
+
+	PublishDrops = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_publish_dropped_total",
+			Help: "Total number of publishes dropped because their class's queue was full",
+		},
+		[]string{"class"},
+	)
+
+	// Partial book publication metrics: BBO on every touch change, books
+	// conflated to the depth interval
+	BBOPublishLatency = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_bbo_publish_latency_seconds",
+			Help:    "Time from receiving a book update to publishing its BBO",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1},
+		},
+		[]string{"exchange"},
+	)
+
+	BBOLatencyP99 = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_bbo_publish_latency_p99_seconds",
+			Help: "p99 BBO publish latency over the SLO window",
+		},
+	)
+
+	BBOSLOBreached = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_bbo_slo_breached",
+			Help: "1 while the p99 BBO publish latency is above its SLO",
+		},
+	)
+
+	BBOSLOBreaches = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_bbo_slo_breaches_total",
+			Help: "Times the p99 BBO publish latency crossed above its SLO",
+		},
+	)
+
+	BookPublishesDeferred = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_book_publishes_deferred_total",
+			Help: "Book updates held back to the depth interval in partial book mode; the newest is published when due",
+		},
+		[]string{"exchange"},
+	)
+
+	// REST API metrics (for two-phase approach)
+	RestFetchDuration = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_rest_fetch_duration_seconds",
+			Help:    "Time to fetch data from exchange REST API",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"exchange", "endpoint"},
+	)
+
+	RestFetchErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_rest_fetch_errors_total",
+			Help: "Total number of REST API fetch errors",
+		},
+		[]string{"exchange", "endpoint"},
+	)
+
+	SpreadDiscoveryDuration = newHistogram(
+		prometheus.HistogramOpts{
+			Name:    "md_spread_discovery_duration_seconds",
+			Help:    "Time to discover spreads from REST data",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1},
+		},
+	)
+
+	PreliminarySpreadsFound = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_preliminary_spreads_found",
+			Help: "Number of preliminary spreads found from REST data",
+		},
+	)
+
+	NearThresholdSpreads = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_near_threshold_spreads",
+			Help: "Number of REST spreads below but near the threshold whose symbols are pre-subscribed",
+		},
+	)
+
+	PreemptiveSubscriptions = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_preemptive_subscriptions_total",
+			Help: "Total number of symbols subscribed via WebSocket after a REST refresh",
+		},
+		[]string{"exchange"},
+	)
+
+	WebsocketSymbolsSubscribed = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_websocket_symbols_subscribed",
+			Help: "Number of symbols subscribed via WebSocket (selective mode)",
+		},
+		[]string{"exchange"},
+	)
+
+	// Instrument metrics
+	InstrumentsLoaded = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_instruments_loaded",
+			Help: "Number of instruments loaded per exchange",
+		},
+		[]string{"exchange"},
+	)
+
+	InstrumentsSubscribed = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_instruments_subscribed",
+			Help: "Number of instruments subscribed per exchange",
+		},
+		[]string{"exchange"},
+	)
+
+	// Funding rate metrics
+	FundingRate = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_funding_rate",
+			Help: "Current funding rate",
+		},
+		[]string{"exchange", "symbol"},
+	)
+
+	FundingRateUpdates = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_funding_rate_updates_total",
+			Help: "Total number of funding rate updates",
+		},
+		[]string{"exchange"},
+	)
+
+	FundingRatesPublished = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_funding_rates_published_total",
+			Help: "Total number of funding rates published to their Redis stream and channel",
+		},
+		[]string{"exchange"},
+	)
+
+	MarkPrice = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_mark_price",
+			Help: "Latest mark price published with a funding rate, for venues returning it",
+		},
+		[]string{"exchange", "symbol"},
+	)
+
+	FundingPollAge = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_funding_poll_age_seconds",
+			Help: "Seconds since the last successful REST funding poll",
+		},
+		[]string{"exchange"},
+	)
+
+	FundingPolls = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_funding_polls_total",
+			Help: "Total number of REST funding requests by result",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	// Listing announcement metrics
+	ListingEvents = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_listing_events_total",
+			Help: "Total number of perpetual listing and delisting announcements detected",
+		},
+		[]string{"exchange", "kind"},
+	)
+
+	NewListingSubscriptions = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_new_listing_subscriptions_total",
+			Help: "Total number of symbols subscribed on the fast path after a new multi-venue listing",
+		},
+		[]string{"exchange"},
+	)
+
+	ListingPollErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_listing_poll_errors_total",
+			Help: "Total number of failed announcement feed polls",
+		},
+		[]string{"exchange"},
+	)
+
+	// Memory metrics
+	MemoryLimit = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_memory_limit_bytes",
+			Help: "Configured soft memory limit",
+		},
 	)
 
-	// Connection metrics
-	ConnectionStatus = promauto.NewGaugeVec(
+	MemoryHeap = newGauge(
 		prometheus.GaugeOpts{
-			Name: "md_connection_status",
-			Help: "WebSocket connection status (1=connected, 0=disconnected)",
+			Name: "md_memory_heap_bytes",
+			Help: "Live heap size excluding ballast",
+		},
+	)
+
+	MemoryGCCycles = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_memory_gc_cycles",
+			Help: "Number of completed GC cycles",
+		},
+	)
+
+	MemorySubsystem = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_memory_subsystem_bytes",
+			Help: "Estimated bytes held per subsystem",
+		},
+		[]string{"subsystem"},
+	)
+
+	MemoryShedding = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_memory_shedding",
+			Help: "Memory shedding active (1=shedding, 0=normal)",
+		},
+	)
+
+	// Index price metrics
+	IndexDeviation = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_index_deviation_bps",
+			Help:    "Absolute deviation of a venue's mid from the index price in basis points",
+			Buckets: []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500},
 		},
 		[]string{"exchange"},
 	)
 
-	ConnectionReconnects = promauto.NewCounterVec(
+	IndexStaleQuotes = newCounterVec(
 		prometheus.CounterOpts{
-			Name: "md_reconnects_total",
-			Help: "Total number of reconnection attempts",
+			Name: "md_index_stale_quotes_total",
+			Help: "Total number of stale venue quotes seen while building the index",
 		},
 		[]string{"exchange"},
 	)
 
-	ConnectionErrors = promauto.NewCounterVec(
+	IndexOutliers = newCounterVec(
 		prometheus.CounterOpts{
-			Name: "md_connection_errors_total",
-			Help: "Total number of connection errors",
+			Name: "md_index_outliers_total",
+			Help: "Total number of venue quotes deviating from the index beyond the outlier threshold",
 		},
-		[]string{"exchange", "error_type"},
+		[]string{"exchange"},
 	)
 
-	// Spread discovery metrics
-	SpreadsDiscovered = promauto.NewCounterVec(
+	// Order rate budget metrics
+	OrderBudgetAcquired = newCounterVec(
 		prometheus.CounterOpts{
-			Name: "md_spreads_discovered_total",
-			Help: "Total number of spreads discovered",
+			Name: "md_order_budget_acquired_total",
+			Help: "Total number of order rate tokens granted",
 		},
-		[]string{"symbol"},
+		[]string{"exchange", "strategy", "priority"},
 	)
 
-	SpreadValue = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "md_spread_value_bps",
-			Help: "Current spread value in basis points",
+	OrderBudgetDenied = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_order_budget_denied_total",
+			Help: "Total number of order submissions denied or timed out waiting for the rate budget",
 		},
-		[]string{"symbol", "long_exchange", "short_exchange"},
+		[]string{"exchange", "strategy", "priority"},
 	)
 
-	SpreadSlippage = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "md_spread_slippage_bps",
-			Help: "Estimated slippage for spread entry",
+	ExecutionValidations = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_execution_validations_total",
+			Help: "Total number of pre-execution spread revalidations by result",
 		},
-		[]string{"symbol", "long_exchange", "short_exchange"},
+		[]string{"long_exchange", "short_exchange", "result"},
 	)
 
-	// Redis metrics
-	RedisPublishDuration = promauto.NewHistogramVec(
+	ExecutionQuoteLatency = newHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "md_redis_publish_duration_seconds",
-			Help:    "Time to publish message to Redis",
-			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05},
+			Name:    "md_execution_quote_seconds",
+			Help:    "Time to obtain a leg quote for pre-execution validation by source",
+			Buckets: []float64{0.0001, 0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1},
 		},
-		[]string{"channel"},
+		[]string{"exchange", "source"},
 	)
 
-	RedisPublishErrors = promauto.NewCounterVec(
+	ExecutionLiquidationBlocks = newCounterVec(
 		prometheus.CounterOpts{
-			Name: "md_redis_publish_errors_total",
-			Help: "Total number of Redis publish errors",
+			Name: "md_execution_liquidation_blocks_total",
+			Help: "Total number of entries blocked because the leg's estimated liquidation price was too close to mark",
 		},
-		[]string{"channel"},
+		[]string{"exchange", "side"},
 	)
 
-	// REST API metrics (for two-phase approach)
-	RestFetchDuration = promauto.NewHistogramVec(
+	ExecutionLadderAttempts = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_execution_ladder_attempts_total",
+			Help: "Total number of IOC attempts on the retry ladder by result",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	ExecutionFallbackHedges = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_execution_fallback_hedges_total",
+			Help: "Total number of legs hedged on an alternate venue after the ladder ran out, by result",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	// FundingSettlements tracks settlement events by stage
+	FundingSettlements = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_funding_settlements_total",
+			Help: "Total number of funding settlement events published, by stage",
+		},
+		[]string{"exchange", "stage"},
+	)
+
+	// FundingActions tracks pre-settlement actions fired
+	FundingActions = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_funding_actions_total",
+			Help: "Total number of pre-settlement funding actions fired, by kind",
+		},
+		[]string{"exchange", "kind"},
+	)
+
+	// SpreadCaptureRatio tracks captured over signalled spread per execution
+	SpreadCaptureRatio = newHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "md_rest_fetch_duration_seconds",
-			Help:    "Time to fetch data from exchange REST API",
-			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			Name:    "md_spread_capture_ratio",
+			Help:    "Spread captured at fill over spread at signal time, per executed opportunity",
+			Buckets: []float64{-0.5, 0, 0.25, 0.5, 0.75, 0.9, 1, 1.1, 1.5},
 		},
-		[]string{"exchange", "endpoint"},
+		[]string{"long_exchange", "short_exchange"},
 	)
 
-	RestFetchErrors = promauto.NewCounterVec(
+	// Notional limits per underlying across venues
+	NotionalUtilization = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_execution_notional_utilization",
+			Help: "Fraction of an underlying's gross or net notional cap in use, zero when uncapped",
+		},
+		[]string{"underlying", "measure"},
+	)
+
+	NotionalBlocks = newCounterVec(
 		prometheus.CounterOpts{
-			Name: "md_rest_fetch_errors_total",
-			Help: "Total number of REST API fetch errors",
+			Name: "md_execution_notional_blocks_total",
+			Help: "Total number of entries rejected for taking an underlying past its notional cap",
 		},
-		[]string{"exchange", "endpoint"},
+		[]string{"underlying", "measure"},
+	)
+
+	NotionalAlerts = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_execution_notional_alerts_total",
+			Help: "Times an underlying's notional crossed the warn fraction of its cap",
+		},
+		[]string{"underlying", "measure"},
+	)
+
+	// Drawdown de-risking
+	DrawdownUSD = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_risk_drawdown_usd",
+			Help: "Drop of total strategy PnL from its peak over the drawdown window",
+		},
+	)
+
+	DeRiskActive = newGauge(
+		prometheus.GaugeOpts{
+			Name: "md_risk_derisk_active",
+			Help: "1 while de-risking after a drawdown is in force, until acknowledged",
+		},
+	)
+
+	DeRiskTrips = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_risk_derisk_trips_total",
+			Help: "Times the drawdown limit tripped de-risking, by action",
+		},
+		[]string{"action"},
+	)
+
+	// Exposure per venue against its share of total equity
+	VenueEquityShare = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_risk_venue_equity_share",
+			Help: "Fraction of total equity across venues held on a venue, balances plus unrealized PnL",
+		},
+		[]string{"exchange"},
+	)
+
+	VenueExposureUtilization = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_risk_venue_exposure_utilization",
+			Help: "Fraction of a venue's equity share cap in use, zero when uncapped",
+		},
+		[]string{"exchange"},
+	)
+
+	VenueExposureAlerts = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_risk_venue_exposure_alerts_total",
+			Help: "Times a venue's equity share crossed the warn fraction of its cap, or the cap",
+		},
+		[]string{"exchange", "level"},
 	)
 
-	SpreadDiscoveryDuration = promauto.NewHistogram(
+	// Deposit and withdrawal completion times per route
+	TransferLatency = newHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "md_spread_discovery_duration_seconds",
-			Help:    "Time to discover spreads from REST data",
-			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1},
+			Name:    "md_transfer_latency_seconds",
+			Help:    "Time from a withdrawal request to the deposit being credited (or one leg when the other venue isn't tracked), by asset, chain and route",
+			Buckets: []float64{60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400, 43200},
 		},
+		[]string{"asset", "chain", "from", "to"},
 	)
 
-	PreliminarySpreadsFound = promauto.NewGauge(
+	TransferFetchFailures = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_transfer_fetch_failures_total",
+			Help: "Failed reads of a venue's deposit and withdrawal records",
+		},
+		[]string{"exchange"},
+	)
+
+	PreflightLive = newGauge(
 		prometheus.GaugeOpts{
-			Name: "md_preliminary_spreads_found",
-			Help: "Number of preliminary spreads found from REST data",
+			Name: "md_execution_preflight_live",
+			Help: "1 while the last account preflight found every venue symbol set up as configured",
 		},
 	)
 
-	WebsocketSymbolsSubscribed = promauto.NewGaugeVec(
+	PreflightMismatches = newGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "md_websocket_symbols_subscribed",
-			Help: "Number of symbols subscribed via WebSocket (selective mode)",
+			Name: "md_execution_preflight_mismatches",
+			Help: "Position mode, margin mode and leverage settings differing from config in the last account preflight",
 		},
 		[]string{"exchange"},
 	)
 
-	// Instrument metrics
-	InstrumentsLoaded = promauto.NewGaugeVec(
+	// Cold storage archive metrics
+	ArchiveUploads = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_archive_uploads_total",
+			Help: "Recording and export files uploaded to object storage by result (uploaded, failed)",
+		},
+		[]string{"result"},
+	)
+
+	ArchiveUploadedBytes = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_archive_uploaded_bytes_total",
+			Help: "Bytes of files uploaded to object storage",
+		},
+	)
+
+	ArchivePending = newGauge(
 		prometheus.GaugeOpts{
-			Name: "md_instruments_loaded",
-			Help: "Number of instruments loaded per exchange",
+			Name: "md_archive_pending_files",
+			Help: "Completed files waiting to be uploaded to object storage",
+		},
+	)
+
+	// Venue API health metrics, from every REST call a connector makes
+	VenueRESTDuration = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_venue_rest_duration_seconds",
+			Help:    "Time to the response headers of venue REST calls",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
 		},
 		[]string{"exchange"},
 	)
 
-	InstrumentsSubscribed = promauto.NewGaugeVec(
+	VenueRESTErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_venue_rest_errors_total",
+			Help: "Total number of venue REST calls that failed, were rate limited or returned a server error",
+		},
+		[]string{"exchange"},
+	)
+
+	VenueDegraded = newGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "md_instruments_subscribed",
-			Help: "Number of instruments subscribed per exchange",
+			Name: "md_venue_degraded",
+			Help: "1 while a venue's REST API is degraded and its orders are throttled",
 		},
 		[]string{"exchange"},
 	)
 
-	// Funding rate metrics
-	FundingRate = promauto.NewGaugeVec(
+	VenueDegradations = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_venue_degradations_total",
+			Help: "Times a venue's REST latency or error rate tripped the health breaker",
+		},
+		[]string{"exchange", "reason"},
+	)
+
+	RESTCacheRequests = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_venue_rest_cache_requests_total",
+			Help: "Venue metadata requests by cache result (hit, miss, stale, bypass)",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	// WebhookDeliveries tracks spread events sent to registered webhooks
+	WebhookDeliveries = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_webhook_deliveries_total",
+			Help: "Total number of webhook event deliveries by result (delivered, retried, failed, dropped)",
+		},
+		[]string{"webhook", "result"},
+	)
+
+	// StrategyHookDuration tracks time spent in compiled-in strategy hooks
+	StrategyHookDuration = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_strategy_hook_duration_seconds",
+			Help:    "Time spent in in-process strategy hooks by strategy and hook (orderbook, spread, funding)",
+			Buckets: []float64{0.000001, 0.000005, 0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.01},
+		},
+		[]string{"strategy", "hook"},
+	)
+
+	// FeedRate tracks each feed's message rate, per subscribed symbol when known
+	FeedRate = newGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "md_funding_rate",
-			Help: "Current funding rate",
+			Name: "md_feed_rate",
+			Help: "Messages per second of a feed over the last sample, per subscribed symbol when known",
 		},
-		[]string{"exchange", "symbol"},
+		[]string{"exchange", "channel"},
+	)
+
+	FeedBaseline = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_feed_baseline_rate",
+			Help: "Learned baseline message rate of a feed, in the same unit as md_feed_rate",
+		},
+		[]string{"exchange", "channel"},
+	)
+
+	FeedSilent = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_feed_silent",
+			Help: "1 while a connected feed runs far below its baseline rate",
+		},
+		[]string{"exchange", "channel"},
 	)
 
-	FundingRateUpdates = promauto.NewCounterVec(
+	FeedAlerts = newCounterVec(
 		prometheus.CounterOpts{
-			Name: "md_funding_rate_updates_total",
-			Help: "Total number of funding rate updates",
+			Name: "md_feed_alerts_total",
+			Help: "Total number of feed rate alerts by kind (silent, recovered)",
+		},
+		[]string{"exchange", "channel", "kind"},
+	)
+
+	// FalsePositiveTags tracks opportunities tagged as false positives
+	FalsePositiveTags = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_spread_false_positive_tags_total",
+			Help: "Total number of published opportunities tagged as false positives, per venue leg and reason",
+		},
+		[]string{"exchange", "reason"},
+	)
+
+	// EndpointLatency tracks the handshake time to each candidate endpoint
+	EndpointLatency = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_endpoint_latency_seconds",
+			Help: "Median TCP and TLS handshake time to a venue's candidate endpoint, measured at startup",
+		},
+		[]string{"exchange", "kind", "url"},
+	)
+
+	// RoutedOrders tracks child orders sent by the order router
+	RoutedOrders = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_execution_routed_orders_total",
+			Help: "Total number of child orders sent by the order router, by result",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	// PositionMigrations tracks legs rolled from one venue to another
+	PositionMigrations = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_execution_position_migrations_total",
+			Help: "Total number of hedged legs migrated between venues, by result",
+		},
+		[]string{"from", "to", "result"},
+	)
+
+	OpportunityClaims = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_opportunity_claims_total",
+			Help: "Total number of executor opportunity lease operations by result",
+		},
+		[]string{"result"},
+	)
+
+	OrderBudgetWait = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "md_order_budget_wait_seconds",
+			Help:    "Time spent waiting for an order rate token",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"exchange", "priority"},
+	)
+
+	// Bar metrics
+	BarsPublished = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_bars_published_total",
+			Help: "Total number of OHLCV bars closed and published",
+		},
+		[]string{"scope", "interval"},
+	)
+
+	BarLateTrades = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_bar_late_trades_total",
+			Help: "Total number of trades dropped because their bar was already closed",
+		},
+		[]string{"exchange", "interval"},
+	)
+
+	HistoryLateSpreads = newCounter(
+		prometheus.CounterOpts{
+			Name: "md_history_late_spreads_total",
+			Help: "Spread updates stamped before their episode's last update, kept out of episode timing",
+		},
+	)
+
+	BarWatermarkLag = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_bar_watermark_lag_seconds",
+			Help: "Wall time minus an exchange's event-time watermark; bars close this long after real time plus the grace",
+		},
+		[]string{"exchange"},
+	)
+
+	KlineBackfillBars = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_kline_backfill_bars_total",
+			Help: "Total number of bars backfilled from venue kline endpoints",
+		},
+		[]string{"exchange"},
+	)
+
+	KlineBackfillErrors = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_kline_backfill_errors_total",
+			Help: "Total number of failed kline backfill requests",
 		},
 		[]string{"exchange"},
 	)
+
+	// Open interest metrics
+	OpenInterestPolls = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_open_interest_polls_total",
+			Help: "Total number of REST open interest requests by result",
+		},
+		[]string{"exchange", "result"},
+	)
+
+	OpenInterestChange = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_open_interest_change_pct",
+			Help: "Change in open interest over the alerting window in percent",
+		},
+		[]string{"exchange", "symbol"},
+	)
+
+	OpenInterestEvents = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "md_open_interest_events_total",
+			Help: "Total number of abnormal open interest changes by kind",
+		},
+		[]string{"exchange", "kind"},
+	)
+
+	// Feature flag metrics
+	FeatureFlag = newGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "md_feature_flag",
+			Help: "Effective feature flag state (1=enabled, 0=disabled)",
+		},
+		[]string{"flag"},
+	)
 )
 
 // Timer is a helper for measuring operation duration
@@ -306,6 +1312,51 @@ func RecordFundingRate(exchange, symbol string, rate float64) {
 	FundingRateUpdates.WithLabelValues(exchange).Inc()
 }
 
+// RecordFundingPublished records a funding rate published to Redis
+func RecordFundingPublished(exchange, symbol string, markPrice float64) {
+	FundingRatesPublished.WithLabelValues(exchange).Inc()
+	if markPrice > 0 {
+		MarkPrice.WithLabelValues(exchange, symbol).Set(markPrice)
+	}
+}
+
+// RecordWSCompression records whether permessage-deflate was negotiated
+func RecordWSCompression(exchange string, negotiated bool) {
+	status := 0.0
+	if negotiated {
+		status = 1.0
+	}
+	WSCompressionNegotiated.WithLabelValues(exchange).Set(status)
+}
+
+// RecordPipelineLatency records stage-by-stage latency for a published message.
+// Stages with a missing stamp are skipped; negative values from clock skew are clamped to 0.
+func RecordPipelineLatency(exchange, messageType string, exchangeTs, received, normalized, published time.Time) {
+	observe := func(stage string, from, to time.Time) {
+		if from.IsZero() || to.IsZero() {
+			return
+		}
+		PipelineLatency.WithLabelValues(exchange, messageType, stage).Observe(math.Max(to.Sub(from).Seconds(), 0))
+	}
+
+	observe("exchange_to_receive", exchangeTs, received)
+	observe("receive_to_normalize", received, normalized)
+	observe("normalize_to_publish", normalized, published)
+	observe("total", exchangeTs, published)
+}
+
+// RecordIndexDeviation records a venue's deviation from the index price
+func RecordIndexDeviation(exchange string, deviationBps float64, stale, outlier bool) {
+	if stale {
+		IndexStaleQuotes.WithLabelValues(exchange).Inc()
+		return
+	}
+	IndexDeviation.WithLabelValues(exchange).Observe(math.Abs(deviationBps))
+	if outlier {
+		IndexOutliers.WithLabelValues(exchange).Inc()
+	}
+}
+
 // Server starts the Prometheus metrics HTTP server
 type Server struct {
 	addr   string
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metric types
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Metric describes a metric the service registers
+type Metric struct {
+	Name   string   `json:"name"`
+	Help   string   `json:"help"`
+	Type   string   `json:"type"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// registered lists every metric in declaration order. The constructors
+// below append to it, so dashboards built from it can't drift from the code.
+var registered []Metric
+
+// Registered returns every metric the service registers, in declaration order
+func Registered() []Metric {
+	return append([]Metric(nil), registered...)
+}
+
+func register(typ, name, help string, labels []string) {
+	registered = append(registered, Metric{Name: name, Help: help, Type: typ, Labels: labels})
+}
+
+func newCounter(opts prometheus.CounterOpts) prometheus.Counter {
+	register(TypeCounter, opts.Name, opts.Help, nil)
+	return promauto.NewCounter(opts)
+}
+
+func newCounterVec(opts prometheus.CounterOpts, labels []string) *prometheus.CounterVec {
+	register(TypeCounter, opts.Name, opts.Help, labels)
+	return promauto.NewCounterVec(opts, labels)
+}
+
+func newGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	register(TypeGauge, opts.Name, opts.Help, nil)
+	return promauto.NewGauge(opts)
+}
+
+func newGaugeVec(opts prometheus.GaugeOpts, labels []string) *prometheus.GaugeVec {
+	register(TypeGauge, opts.Name, opts.Help, labels)
+	return promauto.NewGaugeVec(opts, labels)
+}
+
+func newHistogram(opts prometheus.HistogramOpts) prometheus.Histogram {
+	register(TypeHistogram, opts.Name, opts.Help, nil)
+	return promauto.NewHistogram(opts)
+}
+
+func newHistogramVec(opts prometheus.HistogramOpts, labels []string) *prometheus.HistogramVec {
+	register(TypeHistogram, opts.Name, opts.Help, labels)
+	return promauto.NewHistogramVec(opts, labels)
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/symbolmap"
 )
 
 // InstrumentNormalizer maps exchange-specific symbols to canonical symbols
@@ -19,6 +20,14 @@ type InstrumentNormalizer struct {
 
 	// instruments: canonical -> exchange -> Instrument
 	instruments map[string]map[connector.ExchangeID]*connector.Instrument
+
+	// symbols maps venue symbols to canonicals and back with their unit
+	// multipliers, before renames; shared with the loader and discovery
+	symbols *symbolmap.Map
+
+	// renames: asset renames, oldest first
+	renameMu sync.RWMutex
+	renames  []Rename
 }
 
 // NewInstrumentNormalizer creates a new normalizer
@@ -27,11 +36,19 @@ func NewInstrumentNormalizer() *InstrumentNormalizer {
 		exchangeToCanonical: make(map[connector.ExchangeID]map[string]string),
 		canonicalToExchange: make(map[string]map[connector.ExchangeID]string),
 		instruments:         make(map[string]map[connector.ExchangeID]*connector.Instrument),
+		symbols:             symbolmap.New(),
 	}
 }
 
+// Symbols returns the venue symbol map instruments are registered in
+func (n *InstrumentNormalizer) Symbols() *symbolmap.Map {
+	return n.symbols
+}
+
 // RegisterInstruments registers instruments from an exchange
 func (n *InstrumentNormalizer) RegisterInstruments(instruments []connector.Instrument) {
+	n.symbols.Register(instruments)
+
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
@@ -39,7 +56,7 @@ func (n *InstrumentNormalizer) RegisterInstruments(instruments []connector.Instr
 		inst := &instruments[i]
 		exchangeID := inst.ExchangeID
 		symbol := inst.Symbol
-		canonical := n.normalizeToCanonical(inst.BaseAsset)
+		canonical := n.pairCanonical(exchangeID, n.instrumentPair(inst))
 
 		// Update instrument canonical field
 		inst.Canonical = canonical
@@ -75,8 +92,9 @@ func (n *InstrumentNormalizer) ToCanonical(exchangeID connector.ExchangeID, symb
 		}
 	}
 
-	// Fallback: extract base asset from common formats
-	return n.normalizeToCanonical(n.extractBaseAsset(symbol))
+	// Fallback: parse base/quote/settle from the symbol
+	pair, _ := symbolmap.UnitPair(connector.ParseSymbol(exchangeID, symbol))
+	return n.pairCanonical(exchangeID, pair)
 }
 
 // ToExchangeSymbol converts a canonical symbol to exchange-specific
@@ -145,77 +163,48 @@ func (n *InstrumentNormalizer) GetCommonSymbols(minExchanges int) []string {
 	return symbols
 }
 
+// instrumentPair returns the base/quote/settle triple of an instrument,
+// 1000PEPE counted as PEPE. Instruments without a quote asset are assumed
+// USDT-margined.
+func (n *InstrumentNormalizer) instrumentPair(inst *connector.Instrument) connector.Pair {
+	pair, _ := symbolmap.PairOf(inst)
+	return pair
+}
+
+// pairCanonical returns the canonical symbol for a pair after normalizing
+// its base asset and applying renames in effect
+func (n *InstrumentNormalizer) pairCanonical(exchangeID connector.ExchangeID, pair connector.Pair) string {
+	pair.Base = n.normalizeToCanonical(pair.Base)
+	return n.Rename(exchangeID, pair.Canonical())
+}
+
 // normalizeToCanonical normalizes a base asset to canonical form
 func (n *InstrumentNormalizer) normalizeToCanonical(baseAsset string) string {
 	canonical := strings.ToUpper(strings.TrimSpace(baseAsset))
 
 	// Handle common variations
 	synonyms := map[string]string{
-		"WBTC":      "BTC",
-		"WETH":      "ETH",
-		"WSOL":      "SOL",
-		"STETH":     "ETH",
-		"RETH":      "ETH",
-		"1000SHIB":  "SHIB",
-		"1000PEPE":  "PEPE",
-		"1000FLOKI": "FLOKI",
-		"1000LUNC":  "LUNC",
-		"1000XEC":   "XEC",
-		"USDC":      "USDC",
-		"USDT":      "USDT",
-		"BUSD":      "BUSD",
+		"WBTC":  "BTC",
+		"WETH":  "ETH",
+		"WSOL":  "SOL",
+		"STETH": "ETH",
+		"RETH":  "ETH",
+		"USDC":  "USDC",
+		"USDT":  "USDT",
+		"BUSD":  "BUSD",
 	}
 
+	// Unit prefixes (1000PEPE) are stripped by symbolmap.UnitPair
 	if normalized, ok := synonyms[canonical]; ok {
 		return normalized
 	}
 
-	// Remove common prefixes like "1000"
-	if strings.HasPrefix(canonical, "1000") {
-		return canonical[4:]
-	}
-
 	return canonical
 }
 
-// extractBaseAsset extracts base asset from exchange symbol formats
-func (n *InstrumentNormalizer) extractBaseAsset(symbol string) string {
-	// Handle BTCUSDT format
-	for _, quote := range []string{"USDT", "USDC", "BUSD", "USD"} {
-		if strings.HasSuffix(symbol, quote) {
-			return strings.TrimSuffix(symbol, quote)
-		}
-	}
-
-	// Handle BTC-USDT-SWAP format (OKX)
-	parts := strings.Split(symbol, "-")
-	if len(parts) >= 1 {
-		return parts[0]
-	}
-
-	// Handle BTC/USDT format
-	parts = strings.Split(symbol, "/")
-	if len(parts) >= 1 {
-		return parts[0]
-	}
-
-	return symbol
-}
-
 // constructExchangeSymbol constructs exchange-specific symbol from canonical
 func (n *InstrumentNormalizer) constructExchangeSymbol(canonical string, exchangeID connector.ExchangeID) string {
-	switch exchangeID {
-	case connector.Binance, connector.Bybit, connector.MEXC, connector.Bitget:
-		return canonical + "USDT"
-	case connector.OKX:
-		return canonical + "-USDT-SWAP"
-	case connector.KuCoin:
-		return canonical + "-USDT"
-	case connector.GateIO:
-		return canonical + "_USDT"
-	default:
-		return canonical + "USDT"
-	}
+	return connector.ParseCanonical(canonical).ExchangeSymbol(exchangeID)
 }
 
 // SymbolMapping represents a symbol mapping for serialization
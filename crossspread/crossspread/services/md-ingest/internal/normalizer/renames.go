@@ -0,0 +1,195 @@
+package normalizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// RenameDateLayout is the layout of a rename's effective date
+const RenameDateLayout = "2006-01-02"
+
+// Rename is an asset renamed one-for-one (MATIC to POL). From its effective
+// time symbols still quoted under the old name map to the new canonical, so
+// a venue that has not migrated yet keeps matching those that have.
+type Rename struct {
+	Exchange  connector.ExchangeID `json:"exchange,omitempty"` // Empty for every venue
+	From      string               `json:"from"`
+	To        string               `json:"to"`
+	Effective time.Time            `json:"effective"`
+}
+
+// DefaultRenames returns the renames known at build time
+func DefaultRenames() []Rename {
+	return []Rename{
+		{From: "MATIC", To: "POL", Effective: time.Date(2024, 9, 4, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+// ParseRenames parses "MATIC=POL@2024-09-04,bybit:FTM=S@2025-01-13". An
+// exchange prefix limits the rename to that venue; dates are UTC days.
+func ParseRenames(s string) ([]Rename, error) {
+	var renames []Rename
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var r Rename
+		rest := entry
+		if ex, after, ok := strings.Cut(rest, ":"); ok {
+			r.Exchange = connector.ExchangeID(strings.ToLower(strings.TrimSpace(ex)))
+			rest = after
+		}
+		names, date, ok := strings.Cut(rest, "@")
+		if !ok {
+			return nil, fmt.Errorf("rename %q: want FROM=TO@YYYY-MM-DD", entry)
+		}
+		from, to, ok := strings.Cut(names, "=")
+		if !ok {
+			return nil, fmt.Errorf("rename %q: want FROM=TO@YYYY-MM-DD", entry)
+		}
+		r.From = strings.ToUpper(strings.TrimSpace(from))
+		r.To = strings.ToUpper(strings.TrimSpace(to))
+		if r.From == "" || r.To == "" || r.From == r.To {
+			return nil, fmt.Errorf("rename %q: from and to must be different assets", entry)
+		}
+		effective, err := time.Parse(RenameDateLayout, strings.TrimSpace(date))
+		if err != nil {
+			return nil, fmt.Errorf("rename %q: date must be YYYY-MM-DD", entry)
+		}
+		r.Effective = effective
+		renames = append(renames, r)
+	}
+	return renames, nil
+}
+
+// SetRenames replaces the rename table
+func (n *InstrumentNormalizer) SetRenames(renames []Rename) {
+	sorted := append([]Rename(nil), renames...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Effective.Before(sorted[j].Effective) })
+
+	n.renameMu.Lock()
+	defer n.renameMu.Unlock()
+	n.renames = sorted
+}
+
+// Renames returns the rename table, oldest first
+func (n *InstrumentNormalizer) Renames() []Rename {
+	n.renameMu.RLock()
+	defer n.renameMu.RUnlock()
+	return append([]Rename(nil), n.renames...)
+}
+
+// Rename returns the canonical an exchange's data should carry now
+func (n *InstrumentNormalizer) Rename(exchangeID connector.ExchangeID, canonical string) string {
+	return n.RenameAt(exchangeID, canonical, time.Now())
+}
+
+// RenameAt returns the canonical an exchange's data carried at a time,
+// following chained renames
+func (n *InstrumentNormalizer) RenameAt(exchangeID connector.ExchangeID, canonical string, at time.Time) string {
+	if canonical == "" {
+		return canonical
+	}
+	pair := connector.ParseCanonical(canonical)
+	base := n.renameAsset(exchangeID, pair.Base, at)
+	if base == pair.Base {
+		return canonical
+	}
+	return withBase(pair, base).Canonical()
+}
+
+// withBase renames a pair's base asset, and its settle asset if the
+// contract is inverse
+func withBase(pair connector.Pair, base string) connector.Pair {
+	if pair.Settle == pair.Base {
+		pair.Settle = base
+	}
+	pair.Base = base
+	return pair
+}
+
+// renameAsset applies every rename of an asset effective at a time
+func (n *InstrumentNormalizer) renameAsset(exchangeID connector.ExchangeID, asset string, at time.Time) string {
+	n.renameMu.RLock()
+	defer n.renameMu.RUnlock()
+
+	for _, r := range n.renames {
+		if r.Effective.After(at) {
+			break
+		}
+		if r.From == asset && (r.Exchange == "" || r.Exchange == exchangeID) {
+			asset = r.To
+		}
+	}
+	return asset
+}
+
+// FormerNames returns the names an asset was known by at any point since a
+// time, so history recorded under an old name stays reachable under the new
+// one. The given name is not included. An empty exchange considers only
+// renames made on every venue.
+func (n *InstrumentNormalizer) FormerNames(exchangeID connector.ExchangeID, asset string, since time.Time) []string {
+	n.renameMu.RLock()
+	defer n.renameMu.RUnlock()
+
+	var names []string
+	seen := map[string]bool{asset: true}
+	current := []string{asset}
+	for len(current) > 0 {
+		var next []string
+		for _, name := range current {
+			for _, r := range n.renames {
+				if r.To != name || seen[r.From] || !r.Effective.After(since) {
+					continue
+				}
+				if r.Exchange != "" && r.Exchange != exchangeID {
+					continue
+				}
+				seen[r.From] = true
+				names = append(names, r.From)
+				next = append(next, r.From)
+			}
+		}
+		current = next
+	}
+	return names
+}
+
+// FormerCanonicals returns the canonicals a canonical was known by on
+// every venue at any point since a time
+func (n *InstrumentNormalizer) FormerCanonicals(canonical string, since time.Time) []string {
+	pair := connector.ParseCanonical(canonical)
+
+	var result []string
+	for _, name := range n.FormerNames("", pair.Base, since) {
+		result = append(result, withBase(pair, name).Canonical())
+	}
+	return result
+}
+
+// FormerSymbols returns the symbols an exchange quoted a contract under at
+// any point since a time, formatted like the given one (POLUSDT gives
+// MATICUSDT, pol_usdt gives matic_usdt)
+func (n *InstrumentNormalizer) FormerSymbols(exchangeID connector.ExchangeID, symbol string, since time.Time) []string {
+	base := connector.ParsePair(symbol).Base
+	upper := strings.ToUpper(symbol)
+	i := strings.Index(upper, base)
+	if base == "" || i < 0 {
+		return nil
+	}
+	lower := symbol[i:i+len(base)] == strings.ToLower(base)
+
+	var result []string
+	for _, name := range n.FormerNames(exchangeID, base, since) {
+		if lower {
+			name = strings.ToLower(name)
+		}
+		result = append(result, symbol[:i]+name+symbol[i+len(base):])
+	}
+	return result
+}
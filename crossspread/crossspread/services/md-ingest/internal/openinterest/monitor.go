@@ -0,0 +1,294 @@
+package openinterest
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event kinds
+const (
+	KindBuild = "build" // Open interest rose abnormally fast
+	KindDrop  = "drop"  // Open interest fell abnormally fast
+)
+
+// maxEvents is the number of events kept for the admin API
+const maxEvents = 500
+
+// Event is an open interest build or drop
+type Event = mdtypes.Event
+
+// Config controls open interest polling and alerting
+type Config struct {
+	PollInterval time.Duration // Time between polls of a venue's symbols
+	SymbolRate   float64       // Requests per second per venue
+	Timeout      time.Duration // Per-request timeout
+	Window       time.Duration // Changes are measured against the oldest sample in the window
+	BuildPct     float64       // Rise over the window that raises a build event
+	DropPct      float64       // Fall over the window that raises a drop event
+	Cooldown     time.Duration // Minimum time between events of the same kind for a symbol
+}
+
+// DefaultConfig returns a 15 minute window alerting on a 10% build or drop,
+// polled every minute at two requests per second per venue
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Minute,
+		SymbolRate:   2,
+		Timeout:      10 * time.Second,
+		Window:       15 * time.Minute,
+		BuildPct:     10,
+		DropPct:      10,
+		Cooldown:     15 * time.Minute,
+	}
+}
+
+type sample struct {
+	value float64
+	at    time.Time
+}
+
+type symbolKey struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+type symbolState struct {
+	samples   []sample
+	lastEvent map[string]time.Time // By kind
+}
+
+// Monitor polls open interest on venues with an OI endpoint, tracks its
+// change per symbol over a sliding window and publishes an event when a
+// build or drop crosses the threshold
+type Monitor struct {
+	config     Config
+	publisher  *publisher.RedisPublisher
+	connectors []connector.Connector
+	symbols    func() map[connector.ExchangeID][]string
+
+	mu     sync.Mutex
+	state  map[symbolKey]*symbolState
+	events []Event
+
+	done chan struct{}
+}
+
+// NewMonitor creates an open interest monitor. Connectors without an open
+// interest endpoint are skipped.
+func NewMonitor(connectors []connector.Connector, config Config, pub *publisher.RedisPublisher) *Monitor {
+	polled := make([]connector.Connector, 0, len(connectors))
+	for _, conn := range connectors {
+		if _, ok := conn.(connector.OpenInterestFetcher); ok {
+			polled = append(polled, conn)
+		}
+	}
+
+	return &Monitor{
+		config:     config,
+		publisher:  pub,
+		connectors: polled,
+		state:      make(map[symbolKey]*symbolState),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetSymbolSource sets the symbols to poll, keyed by exchange. Without a
+// source nothing is polled; venues have hundreds of contracts and no bulk
+// open interest endpoint.
+func (m *Monitor) SetSymbolSource(fn func() map[connector.ExchangeID][]string) {
+	m.symbols = fn
+}
+
+// Start polls every venue until the context is cancelled or Stop is called
+func (m *Monitor) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, conn := range m.connectors {
+		wg.Add(1)
+		go func(conn connector.Connector) {
+			defer wg.Done()
+			m.run(ctx, conn.ID(), conn.(connector.OpenInterestFetcher))
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// Stop stops the monitor
+func (m *Monitor) Stop() {
+	close(m.done)
+}
+
+// Events returns recent events, newest first
+func (m *Monitor) Events() []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Event, len(m.events))
+	for i, e := range m.events {
+		result[len(m.events)-1-i] = e
+	}
+	return result
+}
+
+// run polls one venue. The first poll is delayed at random so venues don't
+// fire together.
+func (m *Monitor) run(ctx context.Context, id connector.ExchangeID, fetcher connector.OpenInterestFetcher) {
+	if !m.wait(ctx, time.Duration(rand.Float64()*float64(m.config.PollInterval)/4)) {
+		return
+	}
+
+	gap := time.Duration(float64(time.Second) / m.config.SymbolRate)
+	for {
+		start := time.Now()
+		var symbols []string
+		if m.symbols != nil {
+			symbols = m.symbols()[id]
+		}
+		for i, symbol := range symbols {
+			if i > 0 && !m.wait(ctx, gap) {
+				return
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+			oi, err := fetcher.FetchOpenInterest(reqCtx, symbol)
+			cancel()
+			if err != nil {
+				metrics.OpenInterestPolls.WithLabelValues(string(id), "error").Inc()
+				log.Debug().Err(err).Str("exchange", string(id)).Str("symbol", symbol).Msg("Open interest poll failed")
+				continue
+			}
+			metrics.OpenInterestPolls.WithLabelValues(string(id), "ok").Inc()
+			m.Record(oi, time.Now())
+		}
+
+		if !m.wait(ctx, m.config.PollInterval-time.Since(start)) {
+			return
+		}
+	}
+}
+
+// Record adds an open interest sample and publishes any events it raises
+func (m *Monitor) Record(oi *connector.OpenInterest, now time.Time) {
+	if oi.OpenInterest <= 0 {
+		return
+	}
+	if oi.Canonical == "" {
+		oi.Canonical = connector.ParsePair(oi.Symbol).Canonical()
+	}
+
+	m.mu.Lock()
+	event := m.update(oi, now)
+	if event != nil {
+		m.events = append(m.events, *event)
+		if len(m.events) > maxEvents {
+			m.events = m.events[len(m.events)-maxEvents:]
+		}
+	}
+	m.mu.Unlock()
+
+	if event != nil {
+		m.publish(event)
+	}
+}
+
+// update appends a sample, drops samples older than the window and checks
+// the change against the oldest remaining one. Caller holds m.mu.
+func (m *Monitor) update(oi *connector.OpenInterest, now time.Time) *Event {
+	k := symbolKey{oi.ExchangeID, oi.Symbol}
+	st := m.state[k]
+	if st == nil {
+		st = &symbolState{lastEvent: make(map[string]time.Time)}
+		m.state[k] = st
+	}
+
+	cutoff := now.Add(-m.config.Window)
+	keep := 0
+	for keep < len(st.samples) && st.samples[keep].at.Before(cutoff) {
+		keep++
+	}
+	st.samples = append(st.samples[keep:], sample{value: oi.OpenInterest, at: now})
+
+	base := st.samples[0]
+	if len(st.samples) < 2 || base.value <= 0 {
+		return nil
+	}
+	change := (oi.OpenInterest - base.value) / base.value * 100
+	metrics.OpenInterestChange.WithLabelValues(string(oi.ExchangeID), oi.Symbol).Set(change)
+
+	var kind string
+	switch {
+	case m.config.BuildPct > 0 && change >= m.config.BuildPct:
+		kind = KindBuild
+	case m.config.DropPct > 0 && change <= -m.config.DropPct:
+		kind = KindDrop
+	default:
+		return nil
+	}
+	if last, ok := st.lastEvent[kind]; ok && now.Sub(last) < m.config.Cooldown {
+		return nil
+	}
+	st.lastEvent[kind] = now
+
+	return &Event{
+		Exchange:     oi.ExchangeID,
+		Symbol:       oi.Symbol,
+		Canonical:    oi.Canonical,
+		Kind:         kind,
+		OpenInterest: oi.OpenInterest,
+		Previous:     base.value,
+		ChangePct:    math.Round(change*100) / 100,
+		Window:       now.Sub(base.at).Round(time.Second).String(),
+		At:           now,
+	}
+}
+
+func (m *Monitor) publish(event *Event) {
+	metrics.OpenInterestEvents.WithLabelValues(string(event.Exchange), event.Kind).Inc()
+	log.Info().
+		Str("exchange", string(event.Exchange)).
+		Str("symbol", event.Symbol).
+		Str("kind", event.Kind).
+		Float64("change_pct", event.ChangePct).
+		Str("window", event.Window).
+		Msg("Abnormal open interest change")
+
+	if m.publisher == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal open interest event")
+		return
+	}
+	if err := m.publisher.PublishOpenInterestEvent(data); err != nil {
+		log.Error().Err(err).Msg("Failed to publish open interest event")
+		metrics.RedisPublishErrors.WithLabelValues("open_interest").Inc()
+	}
+}
+
+// wait sleeps for d, returning false if the monitor is stopping
+func (m *Monitor) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		d = time.Millisecond
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-m.done:
+		return false
+	case <-t.C:
+		return true
+	}
+}
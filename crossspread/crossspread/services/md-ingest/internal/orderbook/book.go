@@ -0,0 +1,178 @@
+// Package orderbook maintains local L2 books from a venue's snapshot and
+// delta streams. An Engine keeps one sorted book per venue symbol, applies
+// deltas in sequence, detects gaps and asks the connector to resync, and
+// answers top-of-book and depth queries for spread discovery.
+package orderbook
+
+import (
+	"sort"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Side is a side of a book
+type Side int
+
+// Book sides
+const (
+	Bid Side = iota
+	Ask
+)
+
+func (s Side) String() string {
+	if s == Bid {
+		return "bid"
+	}
+	return "ask"
+}
+
+// Book is one venue symbol's sorted L2 book: bids descending, asks
+// ascending, no empty levels
+type Book struct {
+	Exchange  connector.ExchangeID
+	Symbol    string
+	Canonical string
+	Bids      []connector.PriceLevel
+	Asks      []connector.PriceLevel
+	Seq       int64     // Sequence of the last update applied
+	Timestamp time.Time // Exchange time of the last update
+	UpdatedAt time.Time
+}
+
+// levels returns a side's levels
+func (b *Book) levels(side Side) []connector.PriceLevel {
+	if side == Bid {
+		return b.Bids
+	}
+	return b.Asks
+}
+
+// reset replaces both sides with a snapshot's levels
+func (b *Book) reset(bids, asks []connector.PriceLevel) {
+	b.Bids = b.Bids[:0]
+	b.Asks = b.Asks[:0]
+	for _, l := range bids {
+		b.set(Bid, l.Price, l.Quantity)
+	}
+	for _, l := range asks {
+		b.set(Ask, l.Price, l.Quantity)
+	}
+}
+
+// set upserts a level; a zero quantity removes it
+func (b *Book) set(side Side, price, qty float64) {
+	levels := &b.Asks
+	better := func(i int) bool { return (*levels)[i].Price >= price }
+	if side == Bid {
+		levels = &b.Bids
+		better = func(i int) bool { return (*levels)[i].Price <= price }
+	}
+
+	i := sort.Search(len(*levels), better)
+	found := i < len(*levels) && (*levels)[i].Price == price
+	switch {
+	case qty <= 0 && found:
+		*levels = append((*levels)[:i], (*levels)[i+1:]...)
+	case qty <= 0:
+	case found:
+		(*levels)[i].Quantity = qty
+	default:
+		*levels = append(*levels, connector.PriceLevel{})
+		copy((*levels)[i+1:], (*levels)[i:])
+		(*levels)[i] = connector.PriceLevel{Price: price, Quantity: qty}
+	}
+}
+
+// BestBid returns the highest bid, or false if there are none
+func (b *Book) BestBid() (connector.PriceLevel, bool) {
+	if len(b.Bids) == 0 {
+		return connector.PriceLevel{}, false
+	}
+	return b.Bids[0], true
+}
+
+// BestAsk returns the lowest ask, or false if there are none
+func (b *Book) BestAsk() (connector.PriceLevel, bool) {
+	if len(b.Asks) == 0 {
+		return connector.PriceLevel{}, false
+	}
+	return b.Asks[0], true
+}
+
+// Fill is the result of walking a book for a notional
+type Fill struct {
+	AvgPrice    float64 // Volume-weighted price of the levels taken
+	Quantity    float64 // Base quantity taken
+	NotionalUSD float64 // Quote notional taken; less than asked when the book is thin
+	Levels      int     // Levels touched
+	WorstPrice  float64 // Price of the last level touched
+}
+
+// Complete reports whether the fill reached the notional asked for
+func (f Fill) Complete(notional float64) bool {
+	return f.NotionalUSD >= notional*(1-1e-9)
+}
+
+// DepthAtNotional walks a side from the top, taking levels until notional
+// (price times quantity) is filled. Buying walks the asks, selling the
+// bids.
+func (b *Book) DepthAtNotional(side Side, notional float64) Fill {
+	var f Fill
+	if notional <= 0 {
+		return f
+	}
+	for _, l := range b.levels(side) {
+		left := notional - f.NotionalUSD
+		if left <= 0 {
+			break
+		}
+		qty := l.Quantity
+		if value := l.Price * qty; value > left {
+			qty = left / l.Price
+		}
+		f.Quantity += qty
+		f.NotionalUSD += l.Price * qty
+		f.Levels++
+		f.WorstPrice = l.Price
+	}
+	if f.Quantity > 0 {
+		f.AvgPrice = f.NotionalUSD / f.Quantity
+	}
+	return f
+}
+
+// Orderbook returns the book as a connector full book trimmed to depth
+// levels per side (0 for all)
+func (b *Book) Orderbook(depth int) *connector.Orderbook {
+	ob := &connector.Orderbook{
+		ExchangeID: b.Exchange,
+		Symbol:     b.Symbol,
+		Canonical:  b.Canonical,
+		Bids:       trim(b.Bids, depth),
+		Asks:       trim(b.Asks, depth),
+		Timestamp:  b.Timestamp,
+		SequenceID: b.Seq,
+		IsSnapshot: true, // Full book from the local copy, not the raw delta
+	}
+	if len(ob.Bids) > 0 {
+		ob.BestBid = ob.Bids[0].Price
+	}
+	if len(ob.Asks) > 0 {
+		ob.BestAsk = ob.Asks[0].Price
+	}
+	if ob.BestBid > 0 && ob.BestAsk > 0 {
+		ob.SpreadBps = (ob.BestAsk - ob.BestBid) / ob.BestBid * 10000
+	}
+	return ob
+}
+
+// trim copies up to depth levels (0 for all)
+func trim(levels []connector.PriceLevel, depth int) []connector.PriceLevel {
+	if depth > 0 && len(levels) > depth {
+		levels = levels[:depth]
+	}
+	out := make([]connector.PriceLevel, len(levels))
+	copy(out, levels)
+	return out
+}
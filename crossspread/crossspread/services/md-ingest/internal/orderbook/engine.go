@@ -0,0 +1,315 @@
+package orderbook
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// Apply errors. A delta that returns ErrNotSynced or ErrGap is buffered
+// and applied after the next snapshot if its sequence shows it came after
+// the snapshot; unsequenced deltas are dropped then.
+var (
+	ErrNotSynced = errors.New("orderbook: no snapshot yet")
+	ErrGap       = errors.New("orderbook: sequence gap")
+	ErrStale     = errors.New("orderbook: update older than book")
+)
+
+// Update is one snapshot or delta from a venue stream. Levels are
+// absolute: a delta level replaces the book's level at its price, and a
+// zero quantity removes it.
+//
+// Venues number updates one of two ways, and the engine checks whichever
+// is set:
+//   - PrevSeq: the sequence of the update before this one, which must be
+//     the book's (OKX prevSeqId/seqId). LastSeq is this update's.
+//   - FirstSeq..LastSeq: the range of sequences the update covers, which
+//     must contain the one after the book's (Gate U/u; Bybit's u as both).
+//
+// With neither set, deltas are applied in arrival order.
+type Update struct {
+	Exchange  connector.ExchangeID
+	Symbol    string
+	Canonical string
+	Snapshot  bool
+	FirstSeq  int64
+	LastSeq   int64
+	PrevSeq   int64
+	Bids      []connector.PriceLevel
+	Asks      []connector.PriceLevel
+	Timestamp time.Time
+}
+
+// Config controls gap recovery
+type Config struct {
+	MaxBuffered    int           // Deltas kept per symbol while awaiting a snapshot
+	ResyncInterval time.Duration // Least time between resync requests of a symbol
+}
+
+// DefaultConfig returns the default engine config
+func DefaultConfig() Config {
+	return Config{
+		MaxBuffered:    1000,
+		ResyncInterval: 5 * time.Second,
+	}
+}
+
+// ResyncFunc asks a connector for a fresh snapshot of a symbol, e.g. by
+// fetching one over REST or resubscribing. It runs on its own goroutine
+// and should feed the snapshot back through Apply.
+type ResyncFunc func(exchange connector.ExchangeID, symbol string)
+
+type entry struct {
+	book       Book
+	synced     bool
+	buffered   []Update
+	resyncedAt time.Time
+}
+
+// Engine maintains the books of many venue symbols. It is safe for
+// concurrent use.
+type Engine struct {
+	config Config
+
+	mu     sync.RWMutex
+	books  map[connector.ExchangeID]map[string]*entry
+	resync ResyncFunc
+}
+
+// NewEngine creates an engine with no books
+func NewEngine(config Config) *Engine {
+	return &Engine{
+		config: config,
+		books:  make(map[connector.ExchangeID]map[string]*entry),
+	}
+}
+
+// SetResync sets the function called when a symbol needs a snapshot. Without
+// one, books recover only when the venue sends a snapshot on its own.
+func (e *Engine) SetResync(fn ResyncFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resync = fn
+}
+
+// Apply applies an update. A snapshot replaces the book, then any buffered
+// deltas sequenced after it. A delta is applied if it follows the book;
+// otherwise the book is marked out of sync, a resync requested and the
+// delta buffered.
+func (e *Engine) Apply(u Update) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	en := e.entry(u.Exchange, u.Symbol, u.Canonical)
+	if u.Snapshot {
+		en.book.reset(u.Bids, u.Asks)
+		en.book.Seq = u.LastSeq
+		en.book.Timestamp = u.Timestamp
+		en.book.UpdatedAt = time.Now()
+		en.synced = true
+
+		// Deltas after a gap among them are buffered again
+		var err error
+		buffered := en.buffered
+		en.buffered = nil
+		for _, d := range buffered {
+			if !sequencedAfter(d, u.LastSeq) {
+				continue
+			}
+			if e.applyDelta(en, d) == ErrGap {
+				err = ErrGap
+			}
+		}
+		return err
+	}
+
+	if !en.synced {
+		e.buffer(en, u)
+		return ErrNotSynced
+	}
+	return e.applyDelta(en, u)
+}
+
+// applyDelta applies a delta to a synced book, or buffers it and requests
+// a resync on a gap
+func (e *Engine) applyDelta(en *entry, u Update) error {
+	if en.synced {
+		if err := follows(&en.book, u); err != nil {
+			if err == ErrStale {
+				return err
+			}
+			en.synced = false
+			metrics.OrderbookResyncs.WithLabelValues(string(u.Exchange)).Inc()
+		}
+	}
+	if !en.synced {
+		e.buffer(en, u)
+		return ErrGap
+	}
+
+	for _, l := range u.Bids {
+		en.book.set(Bid, l.Price, l.Quantity)
+	}
+	for _, l := range u.Asks {
+		en.book.set(Ask, l.Price, l.Quantity)
+	}
+	if u.LastSeq != 0 {
+		en.book.Seq = u.LastSeq
+	}
+	en.book.Timestamp = u.Timestamp
+	en.book.UpdatedAt = time.Now()
+	return nil
+}
+
+// sequencedAfter reports whether a buffered delta's sequence proves it came
+// after a snapshot's. Deltas without sequences, e.g. those HandleOrderbook
+// applies, may predate the snapshot, so they are never replayed over it.
+func sequencedAfter(u Update, snapshotSeq int64) bool {
+	switch {
+	case snapshotSeq == 0:
+		return false
+	case u.PrevSeq != 0:
+		return u.PrevSeq >= snapshotSeq
+	default:
+		return u.LastSeq > snapshotSeq
+	}
+}
+
+// follows checks a delta's sequence against the book's
+func follows(b *Book, u Update) error {
+	switch {
+	case u.PrevSeq != 0:
+		// The venue may reset sequences lower, so only the link is checked
+		if u.PrevSeq != b.Seq {
+			return ErrGap
+		}
+	case u.LastSeq != 0 && u.LastSeq <= b.Seq:
+		return ErrStale
+	case u.FirstSeq != 0 && u.FirstSeq > b.Seq+1:
+		return ErrGap
+	}
+	return nil
+}
+
+// buffer keeps a delta for the next snapshot, dropping the oldest beyond
+// MaxBuffered, and requests a resync
+func (e *Engine) buffer(en *entry, u Update) {
+	if e.config.MaxBuffered > 0 {
+		if len(en.buffered) >= e.config.MaxBuffered {
+			en.buffered = en.buffered[1:]
+		}
+		en.buffered = append(en.buffered, u)
+	}
+
+	if e.resync == nil || time.Since(en.resyncedAt) < e.config.ResyncInterval {
+		return
+	}
+	en.resyncedAt = time.Now()
+	go e.resync(u.Exchange, u.Symbol)
+}
+
+// entry returns a symbol's entry, creating it. Callers hold mu.
+func (e *Engine) entry(exchange connector.ExchangeID, symbol, canonical string) *entry {
+	symbols := e.books[exchange]
+	if symbols == nil {
+		symbols = make(map[string]*entry)
+		e.books[exchange] = symbols
+	}
+	en := symbols[symbol]
+	if en == nil {
+		en = &entry{book: Book{Exchange: exchange, Symbol: symbol, Canonical: canonical}}
+		symbols[symbol] = en
+	}
+	if canonical != "" {
+		en.book.Canonical = canonical
+	}
+	return en
+}
+
+// synced returns a symbol's book if it is in sync. Callers hold mu.
+func (e *Engine) synced(exchange connector.ExchangeID, symbol string) *Book {
+	en := e.books[exchange][symbol]
+	if en == nil || !en.synced {
+		return nil
+	}
+	return &en.book
+}
+
+// HandleOrderbook applies a book emitted by a connector, as a bus consumer.
+// Emitted books are full books or deltas already in order, so no sequence
+// checks are made.
+func (e *Engine) HandleOrderbook(ob *connector.Orderbook) {
+	if ob == nil || ob.Symbol == "" || ob.Approximate {
+		return
+	}
+	_ = e.Apply(Update{
+		Exchange:  ob.ExchangeID,
+		Symbol:    ob.Symbol,
+		Canonical: ob.Canonical,
+		Snapshot:  ob.IsSnapshot,
+		Bids:      ob.Bids,
+		Asks:      ob.Asks,
+		Timestamp: ob.Timestamp,
+	})
+}
+
+// Synced reports whether a symbol's book is in sync
+func (e *Engine) Synced(exchange connector.ExchangeID, symbol string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.synced(exchange, symbol) != nil
+}
+
+// BestBid returns a symbol's highest bid, or false if its book is out of
+// sync or has no bids
+func (e *Engine) BestBid(exchange connector.ExchangeID, symbol string) (connector.PriceLevel, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if b := e.synced(exchange, symbol); b != nil {
+		return b.BestBid()
+	}
+	return connector.PriceLevel{}, false
+}
+
+// BestAsk returns a symbol's lowest ask, or false if its book is out of
+// sync or has no asks
+func (e *Engine) BestAsk(exchange connector.ExchangeID, symbol string) (connector.PriceLevel, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if b := e.synced(exchange, symbol); b != nil {
+		return b.BestAsk()
+	}
+	return connector.PriceLevel{}, false
+}
+
+// DepthAtNotional walks a symbol's book side for a notional, or returns
+// false if the book is out of sync
+func (e *Engine) DepthAtNotional(exchange connector.ExchangeID, symbol string, side Side, notional float64) (Fill, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if b := e.synced(exchange, symbol); b != nil {
+		return b.DepthAtNotional(side, notional), true
+	}
+	return Fill{}, false
+}
+
+// Orderbook returns a symbol's book as a connector full book trimmed to
+// depth levels per side (0 for all), or nil if it is out of sync
+func (e *Engine) Orderbook(exchange connector.ExchangeID, symbol string, depth int) *connector.Orderbook {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if b := e.synced(exchange, symbol); b != nil {
+		return b.Orderbook(depth)
+	}
+	return nil
+}
+
+// Remove drops a symbol's book, e.g. on unsubscribe
+func (e *Engine) Remove(exchange connector.ExchangeID, symbol string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.books[exchange], symbol)
+}
@@ -0,0 +1,126 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+func levels(pq ...float64) []connector.PriceLevel {
+	var out []connector.PriceLevel
+	for i := 0; i+1 < len(pq); i += 2 {
+		out = append(out, connector.PriceLevel{Price: pq[i], Quantity: pq[i+1]})
+	}
+	return out
+}
+
+func update(snapshot bool, first, last int64, bids, asks []connector.PriceLevel) Update {
+	return Update{Exchange: connector.GateIO, Symbol: "BTC_USDT", Snapshot: snapshot, FirstSeq: first, LastSeq: last, Bids: bids, Asks: asks}
+}
+
+// A gap stops the book until a snapshot, after which buffered deltas
+// following it are replayed
+func TestEngineGapResync(t *testing.T) {
+	e := NewEngine(Config{MaxBuffered: 10, ResyncInterval: time.Hour})
+	resyncs := make(chan string, 4)
+	e.SetResync(func(_ connector.ExchangeID, symbol string) { resyncs <- symbol })
+
+	if err := e.Apply(update(false, 1, 1, levels(99, 1), nil)); err != ErrNotSynced {
+		t.Fatalf("delta before snapshot: got %v, want ErrNotSynced", err)
+	}
+	if err := e.Apply(update(true, 0, 10, levels(100, 1, 99, 2), levels(101, 1, 102, 3))); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	<-resyncs
+
+	if err := e.Apply(update(false, 9, 11, levels(100, 0, 98, 5), levels(100.5, 2))); err != nil {
+		t.Fatalf("overlapping delta: %v", err)
+	}
+	if bid, _ := e.BestBid(connector.GateIO, "BTC_USDT"); bid.Price != 99 {
+		t.Fatalf("best bid %v after removing 100, want 99", bid.Price)
+	}
+	if ask, _ := e.BestAsk(connector.GateIO, "BTC_USDT"); ask.Price != 100.5 {
+		t.Fatalf("best ask %v, want 100.5", ask.Price)
+	}
+	if err := e.Apply(update(false, 5, 11, nil, nil)); err != ErrStale {
+		t.Fatalf("old delta: got %v, want ErrStale", err)
+	}
+
+	if err := e.Apply(update(false, 14, 15, levels(97, 1), nil)); err != ErrGap {
+		t.Fatalf("gapped delta: got %v, want ErrGap", err)
+	}
+	if e.Synced(connector.GateIO, "BTC_USDT") {
+		t.Fatal("book still synced after a gap")
+	}
+	if _, ok := e.BestBid(connector.GateIO, "BTC_USDT"); ok {
+		t.Fatal("best bid served from an out of sync book")
+	}
+
+	// The snapshot at 13 picks up the buffered delta 14..15
+	if err := e.Apply(update(true, 0, 13, levels(96, 1), levels(103, 1))); err != nil {
+		t.Fatalf("resync snapshot: %v", err)
+	}
+	ob := e.Orderbook(connector.GateIO, "BTC_USDT", 0)
+	if ob == nil || ob.SequenceID != 15 || ob.BestBid != 97 || len(ob.Bids) != 2 {
+		t.Fatalf("book after resync = %+v, want seq 15 with bids 97, 96", ob)
+	}
+}
+
+// Deltas without sequences buffered before a snapshot may predate it, so
+// they are dropped rather than written over it
+func TestEngineDropsUnsequencedBuffered(t *testing.T) {
+	e := NewEngine(Config{MaxBuffered: 10})
+	if err := e.Apply(update(false, 0, 0, levels(99, 7), levels(101, 0))); err != ErrNotSynced {
+		t.Fatalf("delta before snapshot: got %v, want ErrNotSynced", err)
+	}
+	if err := e.Apply(update(true, 0, 0, levels(100, 1, 99, 2), levels(101, 1))); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	ob := e.Orderbook(connector.GateIO, "BTC_USDT", 0)
+	if ob == nil || len(ob.Bids) != 2 || ob.Bids[1].Quantity != 2 || len(ob.Asks) != 1 {
+		t.Fatalf("book after snapshot = %+v, want the snapshot's levels only", ob)
+	}
+
+	// Later unsequenced deltas apply in arrival order
+	if err := e.Apply(update(false, 0, 0, nil, levels(101, 0))); err != nil {
+		t.Fatalf("delta after snapshot: %v", err)
+	}
+	if _, ok := e.BestAsk(connector.GateIO, "BTC_USDT"); ok {
+		t.Fatal("ask removed after the snapshot still in the book")
+	}
+}
+
+// OKX-style deltas link to the previous sequence and may reset lower
+func TestEnginePrevSeq(t *testing.T) {
+	e := NewEngine(DefaultConfig())
+	u := Update{Exchange: connector.OKX, Symbol: "BTCUSDT", Snapshot: true, LastSeq: 100, Asks: levels(10, 1)}
+	if err := e.Apply(u); err != nil {
+		t.Fatal(err)
+	}
+	u = Update{Exchange: connector.OKX, Symbol: "BTCUSDT", PrevSeq: 100, LastSeq: 3, Asks: levels(9, 1)}
+	if err := e.Apply(u); err != nil {
+		t.Fatalf("linked delta after a sequence reset: %v", err)
+	}
+	u = Update{Exchange: connector.OKX, Symbol: "BTCUSDT", PrevSeq: 5, LastSeq: 6}
+	if err := e.Apply(u); err != ErrGap {
+		t.Fatalf("unlinked delta: got %v, want ErrGap", err)
+	}
+}
+
+func TestDepthAtNotional(t *testing.T) {
+	b := &Book{}
+	b.reset(nil, levels(100, 1, 102, 1, 104, 10))
+
+	f := b.DepthAtNotional(Ask, 304)
+	if f.Levels != 3 || f.WorstPrice != 104 || !f.Complete(304) {
+		t.Fatalf("fill = %+v, want 3 levels to 104", f)
+	}
+	if want := 304 / (2 + 102.0/104); math.Abs(f.AvgPrice-want) > 1e-9 {
+		t.Fatalf("avg price %v, want %v", f.AvgPrice, want)
+	}
+	if f := b.DepthAtNotional(Ask, 1e6); f.Complete(1e6) {
+		t.Fatalf("fill of a thin book reported complete: %+v", f)
+	}
+}
@@ -0,0 +1,65 @@
+package orderbook
+
+import (
+	"sync"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// BookKeeper is a connector keeping local books from its venue's snapshots
+// and deltas. Books returns nil when it streams whole books instead, e.g.
+// OKX on books5.
+type BookKeeper interface {
+	Books() *Engine
+}
+
+// FullBooks is the store of the books connectors emit; *books.Store
+// implements it. Levels returns a venue symbol's sorted levels, which the
+// caller must not modify, and whether a snapshot has been applied.
+type FullBooks interface {
+	Levels(exchange connector.ExchangeID, symbol string) (bids, asks []connector.PriceLevel, synced bool)
+}
+
+// Sources answers book queries from the engine each venue's connector
+// keeps, which holds every level and sequence checks every delta, and
+// otherwise from the store of emitted books. The store serves venues
+// without an engine of their own.
+type Sources struct {
+	fallback FullBooks
+
+	mu      sync.RWMutex
+	engines map[connector.ExchangeID]*Engine
+}
+
+// NewSources creates sources answering from fallback until connector
+// engines are added
+func NewSources(fallback FullBooks) *Sources {
+	return &Sources{fallback: fallback, engines: make(map[connector.ExchangeID]*Engine)}
+}
+
+// Add answers an exchange's queries from its connector's engine
+func (s *Sources) Add(exchange connector.ExchangeID, e *Engine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.engines[exchange] = e
+}
+
+// engine returns the engine holding an exchange's books, or nil
+func (s *Sources) engine(exchange connector.ExchangeID) *Engine {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.engines[exchange]
+}
+
+// DepthAtNotional walks a venue symbol's book; see Engine.DepthAtNotional
+func (s *Sources) DepthAtNotional(exchange connector.ExchangeID, symbol string, side Side, notional float64) (Fill, bool) {
+	if e := s.engine(exchange); e != nil {
+		return e.DepthAtNotional(exchange, symbol, side, notional)
+	}
+	bids, asks, synced := s.fallback.Levels(exchange, symbol)
+	if !synced {
+		return Fill{}, false
+	}
+	b := Book{Bids: bids, Asks: asks}
+	return b.DepthAtNotional(side, notional), true
+}
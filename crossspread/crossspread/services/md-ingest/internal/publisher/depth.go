@@ -0,0 +1,136 @@
+package publisher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// FullDepth publishes every level the connector emitted
+const FullDepth = 0
+
+// PublishDepths are the level counts a book can be published at
+var PublishDepths = []int{1, 5, 20, FullDepth}
+
+// ParseDepth parses a publication depth: "1", "5", "20" or "full"
+func ParseDepth(s string) (int, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "full" || s == "" {
+		return FullDepth, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || !validDepth(n) {
+		return 0, fmt.Errorf("invalid depth %q: want 1, 5, 20 or full", s)
+	}
+	return n, nil
+}
+
+// FormatDepth renders a publication depth the way ParseDepth reads it
+func FormatDepth(levels int) string {
+	if levels == FullDepth {
+		return "full"
+	}
+	return strconv.Itoa(levels)
+}
+
+func validDepth(levels int) bool {
+	for _, d := range PublishDepths {
+		if levels == d {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDefaultDepth sets the levels published for symbols without an override
+func (p *RedisPublisher) SetDefaultDepth(levels int) error {
+	if !validDepth(levels) {
+		return fmt.Errorf("invalid depth %d: want 1, 5, 20 or full", levels)
+	}
+	p.depthMu.Lock()
+	p.defaultDepth = levels
+	p.depthMu.Unlock()
+	return nil
+}
+
+// DefaultDepth returns the levels published for symbols without an override
+func (p *RedisPublisher) DefaultDepth() int {
+	p.depthMu.RLock()
+	defer p.depthMu.RUnlock()
+	return p.defaultDepth
+}
+
+// SetDepth overrides the levels published for one exchange-native symbol.
+// A negative value removes the override. The connector subscription is
+// unchanged; only what is written to Redis is trimmed.
+func (p *RedisPublisher) SetDepth(exchange connector.ExchangeID, symbol string, levels int) error {
+	if levels >= 0 && !validDepth(levels) {
+		return fmt.Errorf("invalid depth %d: want 1, 5, 20 or full", levels)
+	}
+
+	p.depthMu.Lock()
+	defer p.depthMu.Unlock()
+
+	if levels < 0 {
+		delete(p.depths[exchange], symbol)
+		if len(p.depths[exchange]) == 0 {
+			delete(p.depths, exchange)
+		}
+		return nil
+	}
+	if p.depths == nil {
+		p.depths = make(map[connector.ExchangeID]map[string]int)
+	}
+	if p.depths[exchange] == nil {
+		p.depths[exchange] = make(map[string]int)
+	}
+	p.depths[exchange][symbol] = levels
+	return nil
+}
+
+// Depths returns a copy of the per-symbol overrides by exchange
+func (p *RedisPublisher) Depths() map[connector.ExchangeID]map[string]int {
+	p.depthMu.RLock()
+	defer p.depthMu.RUnlock()
+
+	result := make(map[connector.ExchangeID]map[string]int, len(p.depths))
+	for ex, symbols := range p.depths {
+		result[ex] = make(map[string]int, len(symbols))
+		for symbol, levels := range symbols {
+			result[ex][symbol] = levels
+		}
+	}
+	return result
+}
+
+// depthFor returns the levels to publish for a symbol
+func (p *RedisPublisher) depthFor(exchange connector.ExchangeID, symbol string) int {
+	p.depthMu.RLock()
+	defer p.depthMu.RUnlock()
+
+	if levels, ok := p.depths[exchange][symbol]; ok {
+		return levels
+	}
+	return p.defaultDepth
+}
+
+// trimmed returns the book as it should be published. Only snapshots are
+// cut: dropping levels from a diff would corrupt books consumers maintain.
+// The original is left untouched for the in-process consumers.
+func (p *RedisPublisher) trimmed(ob *connector.Orderbook) *connector.Orderbook {
+	levels := p.depthFor(ob.ExchangeID, ob.Symbol)
+	if levels == FullDepth || !ob.IsSnapshot || (len(ob.Bids) <= levels && len(ob.Asks) <= levels) {
+		return ob
+	}
+
+	cp := *ob
+	if len(cp.Bids) > levels {
+		cp.Bids = cp.Bids[:levels]
+	}
+	if len(cp.Asks) > levels {
+		cp.Asks = cp.Asks[:levels]
+	}
+	return &cp
+}
@@ -0,0 +1,137 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HeartbeatConfig controls the heartbeats published for every channel
+type HeartbeatConfig struct {
+	Interval time.Duration // Between heartbeats of a channel
+	// Retain is how long a channel nothing was published on keeps its
+	// heartbeat; spread IDs come and go, so channels can't beat forever
+	Retain   time.Duration
+	Producer string // Identifies this instance in heartbeats
+}
+
+// DefaultHeartbeatConfig beats every second and drops channels quiet for
+// an hour
+func DefaultHeartbeatConfig() HeartbeatConfig {
+	return HeartbeatConfig{
+		Interval: time.Second,
+		Retain:   time.Hour,
+	}
+}
+
+type channelSeq struct {
+	seq  uint64
+	last time.Time
+}
+
+// heartbeats counts the messages published per channel
+type heartbeats struct {
+	config HeartbeatConfig
+
+	mu       sync.Mutex
+	channels map[string]*channelSeq
+	beat     uint64
+}
+
+// SetHeartbeat publishes a heartbeat for every channel published on. Call
+// it before publishing starts and run RunHeartbeats alongside.
+func (p *RedisPublisher) SetHeartbeat(config HeartbeatConfig) {
+	p.heartbeats = &heartbeats{
+		config:   config,
+		channels: make(map[string]*channelSeq),
+	}
+}
+
+// publish publishes a message and counts it for the channel's heartbeat
+func (p *RedisPublisher) publish(ctx context.Context, channel string, message interface{}) error {
+	if err := p.client.Publish(ctx, channel, message).Err(); err != nil {
+		return err
+	}
+	if hb := p.heartbeats; hb != nil {
+		now := time.Now()
+		hb.mu.Lock()
+		c, ok := hb.channels[channel]
+		if !ok {
+			c = &channelSeq{}
+			hb.channels[channel] = c
+		}
+		c.seq++
+		c.last = now
+		hb.mu.Unlock()
+	}
+	return nil
+}
+
+// RunHeartbeats publishes heartbeats every interval until ctx is done
+func (p *RedisPublisher) RunHeartbeats(ctx context.Context) {
+	hb := p.heartbeats
+	if hb == nil {
+		return
+	}
+	ticker := time.NewTicker(hb.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := p.beat(ctx, now); err != nil {
+				metrics.RedisPublishErrors.WithLabelValues("heartbeat").Inc()
+				log.Warn().Err(err).Msg("Failed to publish heartbeats")
+			}
+		}
+	}
+}
+
+// beat publishes one heartbeat per retained channel in a single round trip
+func (p *RedisPublisher) beat(ctx context.Context, now time.Time) error {
+	hb := p.heartbeats
+
+	hb.mu.Lock()
+	beats := make([]mdtypes.Heartbeat, 0, len(hb.channels))
+	for channel, c := range hb.channels {
+		if hb.config.Retain > 0 && now.Sub(c.last) > hb.config.Retain {
+			delete(hb.channels, channel)
+			continue
+		}
+		hb.beat++
+		beats = append(beats, mdtypes.Heartbeat{
+			Channel:         channel,
+			Producer:        hb.config.Producer,
+			Seq:             c.seq,
+			Beat:            hb.beat,
+			IntervalMs:      hb.config.Interval.Milliseconds(),
+			LastPublishedAt: c.last,
+			Timestamp:       now,
+		})
+	}
+	hb.mu.Unlock()
+
+	metrics.HeartbeatChannels.Set(float64(len(beats)))
+	if len(beats) == 0 {
+		return nil
+	}
+	pipe := p.client.Pipeline()
+	for _, b := range beats {
+		data, err := json.Marshal(b)
+		if err != nil {
+			continue
+		}
+		pipe.Publish(ctx, keyspace.HeartbeatKey(b.Channel), string(data))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,315 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nuid"
+	"github.com/rs/zerolog/log"
+)
+
+// JetStreamConfig controls the NATS JetStream publisher
+type JetStreamConfig struct {
+	URL      string
+	Name     string // Connection name shown by the server
+	User     string
+	Password string
+	Token    string
+
+	Replicas   int           // Of every stream, in a clustered server
+	MaxAge     time.Duration // Retention of every stream
+	Duplicates time.Duration // Window in which a retried message ID is dropped
+	// MaxPending is how many publishes may await their acknowledgement;
+	// publishing stalls, then fails, beyond it
+	MaxPending    int
+	RetryAttempts int // Republishes of a message whose acknowledgement failed
+	RetryWait     time.Duration
+	// RetryQueueWait is how long a failed message waits for room in the
+	// retry queue, holding back acknowledgements and so new publishes,
+	// before it is dropped
+	RetryQueueWait time.Duration
+	// SpreadsPerSubject is how many states of each spread MD_SPREADS keeps
+	SpreadsPerSubject int
+}
+
+// DefaultJetStreamConfig keeps a day of data on a single replica
+func DefaultJetStreamConfig() JetStreamConfig {
+	return JetStreamConfig{
+		URL:               nats.DefaultURL,
+		Name:              "md-ingest",
+		Replicas:          1,
+		MaxAge:            24 * time.Hour,
+		Duplicates:        2 * time.Minute,
+		MaxPending:        4096,
+		RetryAttempts:     3,
+		RetryWait:         250 * time.Millisecond,
+		RetryQueueWait:    time.Second,
+		SpreadsPerSubject: 100,
+	}
+}
+
+// jetStreamSpec is one persistent stream, holding one message type
+type jetStreamSpec struct {
+	name       string
+	msgType    string
+	perSubject int64 // Messages kept per subject, as the Redis streams cap them; zero takes it from the config
+}
+
+var jetStreams = []jetStreamSpec{
+	{name: "MD_ORDERBOOKS", msgType: TypeOrderbook, perSubject: keyspace.OrderbookStreamMaxLen},
+	{name: "MD_TRADES", msgType: TypeTrade, perSubject: keyspace.TradesStreamMaxLen},
+	{name: "MD_FUNDING", msgType: TypeFunding, perSubject: keyspace.FundingStreamMaxLen},
+	{name: "MD_SPREADS", msgType: TypeSpread}, // Subjects are single spreads; see JetStreamConfig.SpreadsPerSubject
+}
+
+// JetStreamPublisher publishes the market data stream to persistent NATS
+// JetStream streams on md.<exchange>.<symbol>.<type>. Every message is
+// published asynchronously and counted delivered only once the stream
+// acknowledges it; failed ones are republished under the same message ID,
+// which the stream deduplicates. Consumers reading with a durable consumer
+// and explicit acks get every message at least once across restarts.
+type JetStreamPublisher struct {
+	config JetStreamConfig
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	shaper Shaper
+
+	retries chan *nats.Msg
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Shaper trims, rounds and prices books and trades the way they are
+// published to Redis, so both backends carry the same payload.
+// RedisPublisher is one.
+type Shaper interface {
+	ShapeOrderbook(ob *connector.Orderbook) *connector.Orderbook
+	ShapeTrade(trade *connector.Trade) *connector.Trade
+}
+
+// NewJetStreamPublisher connects to NATS and creates or updates the
+// streams. shaper may be nil to publish books and trades as received.
+func NewJetStreamPublisher(config JetStreamConfig, shaper Shaper) (*JetStreamPublisher, error) {
+	opts := []nats.Option{nats.Name(config.Name), nats.MaxReconnects(-1)}
+	if config.User != "" {
+		opts = append(opts, nats.UserInfo(config.User, config.Password))
+	}
+	if config.Token != "" {
+		opts = append(opts, nats.Token(config.Token))
+	}
+	nc, err := nats.Connect(config.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("nats connect failed: %w", err)
+	}
+
+	p := &JetStreamPublisher{
+		config:  config,
+		nc:      nc,
+		shaper:  shaper,
+		retries: make(chan *nats.Msg, config.MaxPending),
+		done:    make(chan struct{}),
+	}
+	js, err := jetstream.New(nc,
+		jetstream.WithPublishAsyncMaxPending(config.MaxPending),
+		jetstream.WithPublishAsyncErrHandler(p.failed),
+	)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("jetstream init failed: %w", err)
+	}
+	p.js = js
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, s := range jetStreams {
+		perSubject := s.perSubject
+		if perSubject == 0 {
+			perSubject = int64(config.SpreadsPerSubject)
+		}
+		if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:              s.name,
+			Subjects:          []string{"md.*.*." + s.msgType},
+			Storage:           jetstream.FileStorage,
+			Replicas:          config.Replicas,
+			MaxAge:            config.MaxAge,
+			MaxMsgsPerSubject: perSubject,
+			Discard:           jetstream.DiscardOld,
+			Duplicates:        config.Duplicates,
+		}); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("create stream %s: %w", s.name, err)
+		}
+	}
+
+	p.wg.Add(1)
+	go p.retryLoop()
+	return p, nil
+}
+
+// PublishOrderbook publishes a book to md.<exchange>.<symbol>.orderbook
+func (p *JetStreamPublisher) PublishOrderbook(ob *connector.Orderbook) error {
+	// Bus events are shared by every subscriber, so only a copy is stamped
+	var out *connector.Orderbook
+	if p.shaper != nil {
+		out = p.shaper.ShapeOrderbook(ob)
+	} else {
+		cp := *ob
+		out = &cp
+	}
+	out.PublishedAt = time.Now()
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	if err := p.publish(Subject(string(ob.ExchangeID), ob.Symbol, TypeOrderbook), nuid.Next(), data); err != nil {
+		return err
+	}
+	metrics.RecordPipelineLatency(string(ob.ExchangeID), "orderbook", ob.Timestamp, ob.ReceivedAt, ob.NormalizedAt, out.PublishedAt)
+	return nil
+}
+
+// PublishTrade publishes a trade to md.<exchange>.<symbol>.trade. The
+// venue's trade ID is the message ID, so redundant instances publishing
+// the same trade store it once.
+func (p *JetStreamPublisher) PublishTrade(trade *connector.Trade) error {
+	var out *connector.Trade
+	if p.shaper != nil {
+		out = p.shaper.ShapeTrade(trade)
+	} else {
+		cp := *trade
+		out = &cp
+	}
+	out.PublishedAt = time.Now()
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	id := nuid.Next()
+	if trade.TradeID != "" {
+		id = string(trade.ExchangeID) + ":" + trade.Symbol + ":" + trade.TradeID
+	}
+	if err := p.publish(Subject(string(trade.ExchangeID), trade.Symbol, TypeTrade), id, data); err != nil {
+		return err
+	}
+	metrics.RecordPipelineLatency(string(trade.ExchangeID), "trade", trade.Timestamp, trade.ReceivedAt, trade.NormalizedAt, out.PublishedAt)
+	return nil
+}
+
+// PublishFundingRate publishes a funding rate to
+// md.<exchange>.<symbol>.funding
+func (p *JetStreamPublisher) PublishFundingRate(fr *connector.FundingRate) error {
+	data, err := json.Marshal(fr)
+	if err != nil {
+		return err
+	}
+	return p.publish(Subject(string(fr.ExchangeID), fr.Symbol, TypeFunding), nuid.Next(), data)
+}
+
+// SetSpread publishes a spread's latest state to
+// md.<long>-<short>.<canonical>.spread. The stream keeps every state, so
+// the quote age isn't used.
+func (p *JetStreamPublisher) SetSpread(spreadID string, data []byte, _ time.Duration) error {
+	return p.publish(SpreadSubject(spreadID), nuid.Next(), data)
+}
+
+// publish sends a message without waiting for its acknowledgement; failed
+// acknowledgements come back through failed
+func (p *JetStreamPublisher) publish(subject, id string, data []byte) error {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(jetstream.MsgIDHeader, id)
+	_, err := p.js.PublishMsgAsync(msg,
+		jetstream.WithRetryAttempts(p.config.RetryAttempts),
+		jetstream.WithRetryWait(p.config.RetryWait),
+	)
+	metrics.JetStreamPending.Set(float64(p.js.PublishAsyncPending()))
+	if err != nil {
+		metrics.JetStreamPublishErrors.WithLabelValues(msgType(subject)).Inc()
+	}
+	return err
+}
+
+// failed queues a message whose acknowledgement failed for republishing.
+// It runs on the acknowledgement handler, so while the retry queue is full
+// it holds back further acknowledgements and, once MaxPending publishes
+// await theirs, stalls new publishes. A message still without room after
+// RetryQueueWait is dropped.
+func (p *JetStreamPublisher) failed(_ jetstream.JetStream, msg *nats.Msg, err error) {
+	select {
+	case p.retries <- msg:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(p.config.RetryQueueWait)
+	defer timer.Stop()
+	select {
+	case p.retries <- msg:
+	case <-p.done:
+		metrics.JetStreamDropped.WithLabelValues(msgType(msg.Subject)).Inc()
+	case <-timer.C:
+		metrics.JetStreamDropped.WithLabelValues(msgType(msg.Subject)).Inc()
+		log.Warn().Err(err).Str("subject", msg.Subject).Dur("waited", p.config.RetryQueueWait).Msg("JetStream retry queue full, dropping message")
+	}
+}
+
+// retryLoop republishes failed messages, waiting for each acknowledgement
+func (p *JetStreamPublisher) retryLoop() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.done:
+			return
+		case msg := <-p.retries:
+			p.retry(msg)
+		}
+	}
+}
+
+func (p *JetStreamPublisher) retry(msg *nats.Msg) {
+	msgType := msgType(msg.Subject)
+	for attempt := 0; attempt < p.config.RetryAttempts; attempt++ {
+		metrics.JetStreamRetries.WithLabelValues(msgType).Inc()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := p.js.PublishMsg(ctx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+		select {
+		case <-p.done:
+			return
+		case <-time.After(p.config.RetryWait):
+		}
+	}
+	metrics.JetStreamPublishErrors.WithLabelValues(msgType).Inc()
+	log.Warn().Str("subject", msg.Subject).Msg("JetStream publish not acknowledged after retries")
+}
+
+// msgType returns the type token of a subject
+func msgType(subject string) string {
+	return subject[strings.LastIndexByte(subject, '.')+1:]
+}
+
+// Close waits briefly for outstanding acknowledgements, then drains the
+// connection
+func (p *JetStreamPublisher) Close() error {
+	select {
+	case <-p.js.PublishAsyncComplete():
+	case <-time.After(5 * time.Second):
+		log.Warn().Int("pending", p.js.PublishAsyncPending()).Msg("Closing JetStream with publishes unacknowledged")
+	}
+	close(p.done)
+	p.wg.Wait()
+	return p.nc.Drain()
+}
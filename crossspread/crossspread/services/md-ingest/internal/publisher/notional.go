@@ -0,0 +1,109 @@
+package publisher
+
+import (
+	"fmt"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Prices USD notional is computed at. Quotes in USDT and USDC count as USD.
+const (
+	NotionalOff   = "off"   // No notional published
+	NotionalLevel = "level" // Each level's or trade's own price
+	NotionalIndex = "index" // The canonical symbol's index price, falling back to the level price without one
+)
+
+// IndexSource returns the current index price of a canonical symbol
+// (index.Builder)
+type IndexSource interface {
+	IndexPrice(canonical string) (float64, bool)
+}
+
+// ParseNotionalMode parses a notional price source: off, level or index
+func ParseNotionalMode(s string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(s)); mode {
+	case NotionalOff, NotionalLevel, NotionalIndex:
+		return mode, nil
+	case "":
+		return NotionalOff, nil
+	default:
+		return "", fmt.Errorf("invalid notional mode %q: want off, level or index", s)
+	}
+}
+
+// SetNotional sets the price USD notional is published at on every book
+// level and trade. index may be nil unless mode is NotionalIndex.
+func (p *RedisPublisher) SetNotional(mode string, index IndexSource) {
+	p.notionalMu.Lock()
+	defer p.notionalMu.Unlock()
+	p.notionalMode = mode
+	p.indexSource = index
+}
+
+// setContractSizes records the contract size of each instrument so venue
+// quantities in contracts convert to base units. Caller holds precMu.
+func (p *RedisPublisher) setContractSizes(instruments []connector.Instrument) {
+	if p.contractSizes == nil {
+		p.contractSizes = make(map[connector.ExchangeID]map[string]float64)
+	}
+	for _, inst := range instruments {
+		if inst.ContractSize <= 0 {
+			continue
+		}
+		if p.contractSizes[inst.ExchangeID] == nil {
+			p.contractSizes[inst.ExchangeID] = make(map[string]float64)
+		}
+		p.contractSizes[inst.ExchangeID][inst.Symbol] = inst.ContractSize
+	}
+}
+
+// notionalPricer returns a function converting a venue quantity at a price
+// to USD notional, or nil when notional is off. Quantities are multiplied
+// by the contract size, the same conversion bars use for volume.
+func (p *RedisPublisher) notionalPricer(exchange connector.ExchangeID, symbol, canonical string) func(qty, price float64) float64 {
+	p.notionalMu.RLock()
+	mode, index := p.notionalMode, p.indexSource
+	p.notionalMu.RUnlock()
+	if mode == "" || mode == NotionalOff {
+		return nil
+	}
+
+	p.precMu.RLock()
+	size, ok := p.contractSizes[exchange][symbol]
+	p.precMu.RUnlock()
+	if !ok {
+		size = 1
+	}
+
+	if mode == NotionalIndex && index != nil {
+		if ref, ok := index.IndexPrice(canonical); ok && ref > 0 {
+			return func(qty, _ float64) float64 { return roundValue(qty*size*ref, 2, true) }
+		}
+	}
+	return func(qty, price float64) float64 { return roundValue(qty*size*price, 2, true) }
+}
+
+// withNotional sets the USD notional of every level of a book the publisher
+// owns, e.g. the copy made by roundedBook
+func (p *RedisPublisher) withNotional(ob *connector.Orderbook) *connector.Orderbook {
+	notional := p.notionalPricer(ob.ExchangeID, ob.Symbol, ob.Canonical)
+	if notional == nil {
+		return ob
+	}
+	for i := range ob.Bids {
+		ob.Bids[i].NotionalUSD = notional(ob.Bids[i].Quantity, ob.Bids[i].Price)
+	}
+	for i := range ob.Asks {
+		ob.Asks[i].NotionalUSD = notional(ob.Asks[i].Quantity, ob.Asks[i].Price)
+	}
+	return ob
+}
+
+// withNotionalTrade sets the USD notional of a trade the publisher owns
+func (p *RedisPublisher) withNotionalTrade(trade *connector.Trade) *connector.Trade {
+	if notional := p.notionalPricer(trade.ExchangeID, trade.Symbol, trade.Canonical); notional != nil {
+		trade.NotionalUSD = notional(trade.Quantity, trade.Price)
+	}
+	return trade
+}
@@ -0,0 +1,346 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Book publication modes
+const (
+	BookModeFull    = "full"    // Every update publishes the book
+	BookModePartial = "partial" // Touch changes publish the BBO at once; the book follows at DepthInterval
+)
+
+// ParseBookMode parses a book publication mode
+func ParseBookMode(s string) (string, error) {
+	switch s {
+	case BookModeFull, BookModePartial:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid book publish mode %q: want full or partial", s)
+}
+
+// PartialBookConfig controls partial-book publication and its latency SLO
+type PartialBookConfig struct {
+	DepthInterval time.Duration // Least time between book publishes of a symbol
+	SLO           time.Duration // p99 BBO publish latency target
+	Window        time.Duration // Trailing window the p99 is taken over
+	CheckInterval time.Duration // How often the SLO is judged
+	MinSamples    int           // BBO publishes in the window before the SLO is judged
+}
+
+// DefaultPartialBookConfig publishes books at most four times a second and
+// holds the BBO to a 5ms p99 from receipt over a minute
+func DefaultPartialBookConfig() PartialBookConfig {
+	return PartialBookConfig{
+		DepthInterval: 250 * time.Millisecond,
+		SLO:           5 * time.Millisecond,
+		Window:        time.Minute,
+		CheckInterval: 10 * time.Second,
+		MinSamples:    100,
+	}
+}
+
+// BBO is a symbol's best bid and offer, published on every touch change
+type BBO struct {
+	ExchangeID  connector.ExchangeID `json:"exchange"`
+	Symbol      string               `json:"symbol"`
+	Canonical   string               `json:"canonical"`
+	BidPrice    float64              `json:"bid_price"`
+	BidSize     float64              `json:"bid_size"`
+	AskPrice    float64              `json:"ask_price"`
+	AskSize     float64              `json:"ask_size"`
+	Timestamp   time.Time            `json:"timestamp"`   // Venue time of the book update
+	ReceivedAt  time.Time            `json:"received_at"` // When the update arrived
+	PublishedAt time.Time            `json:"published_at"`
+}
+
+// SLOStatus is the BBO latency SLO as last judged
+type SLOStatus struct {
+	TargetMs  float64   `json:"target_ms"`
+	P99Ms     float64   `json:"p99_ms"`
+	Samples   int       `json:"samples"`
+	Breached  bool      `json:"breached"`
+	Since     time.Time `json:"since,omitempty"` // Start of the current breach
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type touch struct {
+	bid, bidSize, ask, askSize float64
+}
+
+// bookState is a symbol's last published touch and the newest book not yet
+// published
+type bookState struct {
+	mu      sync.Mutex
+	touch   touch
+	pending *connector.Orderbook
+	depthAt time.Time
+
+	publishMu sync.Mutex // Keeps a symbol's book publishes in order
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// partialBooks publishes each touch change as a BBO immediately and
+// conflates deeper levels to one book per DepthInterval, so depth volume
+// never queues behind or ahead of the touch
+type partialBooks struct {
+	config PartialBookConfig
+
+	mu    sync.Mutex
+	books map[string]*bookState
+
+	samplesMu sync.Mutex
+	samples   []latencySample // Oldest first, within the window
+
+	statusMu sync.RWMutex
+	status   SLOStatus
+}
+
+// SetPartialBook switches books to partial publication. Call it before
+// publishing starts and run RunPartialBook alongside.
+func (p *RedisPublisher) SetPartialBook(config PartialBookConfig) {
+	p.partial = &partialBooks{
+		config: config,
+		books:  make(map[string]*bookState),
+		status: SLOStatus{TargetMs: ms(config.SLO)},
+	}
+}
+
+// RunPartialBook publishes conflated books that are due and judges the BBO
+// latency SLO until ctx is done
+func (p *RedisPublisher) RunPartialBook(ctx context.Context) {
+	if p.partial == nil {
+		return
+	}
+	flush := time.NewTicker(p.partial.config.DepthInterval)
+	defer flush.Stop()
+	check := time.NewTicker(p.partial.config.CheckInterval)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-flush.C:
+			p.flushDue()
+		case now := <-check.C:
+			p.partial.check(now)
+		}
+	}
+}
+
+// BBOStatus returns the BBO latency SLO, or nil when books are published in
+// full
+func (p *RedisPublisher) BBOStatus() *SLOStatus {
+	if p.partial == nil {
+		return nil
+	}
+	p.partial.statusMu.RLock()
+	defer p.partial.statusMu.RUnlock()
+	status := p.partial.status
+	return &status
+}
+
+// publishPartial publishes the BBO when the touch moved and the book when
+// its interval has passed; otherwise the book waits for the next flush
+func (p *RedisPublisher) publishPartial(ob *connector.Orderbook) error {
+	st := p.partial.book(ob.ExchangeID, ob.Symbol)
+	t := p.touchOf(ob)
+
+	st.mu.Lock()
+	moved := t != st.touch
+	st.touch = t
+	st.pending = ob
+	due := time.Since(st.depthAt) >= p.partial.config.DepthInterval
+	st.mu.Unlock()
+
+	if moved {
+		if err := p.publishBBO(ob, t); err != nil {
+			return err
+		}
+	}
+	if !due {
+		metrics.BookPublishesDeferred.WithLabelValues(string(ob.ExchangeID)).Inc()
+		return nil
+	}
+	return p.flushBook(st)
+}
+
+func (pb *partialBooks) book(exchange connector.ExchangeID, symbol string) *bookState {
+	key := string(exchange) + ":" + symbol
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	st := pb.books[key]
+	if st == nil {
+		st = &bookState{}
+		pb.books[key] = st
+	}
+	return st
+}
+
+// touchOf returns the book's top level at published precision, so a move
+// below it is not a touch change
+func (p *RedisPublisher) touchOf(ob *connector.Orderbook) touch {
+	prec, ok := p.precisionFor(ob.ExchangeID, ob.Symbol)
+	var t touch
+	if len(ob.Bids) > 0 {
+		t.bid = roundValue(ob.Bids[0].Price, prec.price, ok)
+		t.bidSize = roundValue(ob.Bids[0].Quantity, prec.size, ok)
+	}
+	if len(ob.Asks) > 0 {
+		t.ask = roundValue(ob.Asks[0].Price, prec.price, ok)
+		t.askSize = roundValue(ob.Asks[0].Quantity, prec.size, ok)
+	}
+	return t
+}
+
+// publishBBO publishes the touch on the symbol's BBO channel. It goes in
+// the trade class, ahead of books, and its latency from receipt counts
+// toward the SLO.
+func (p *RedisPublisher) publishBBO(ob *connector.Orderbook, t touch) error {
+	done, ok := p.admit(ClassTrade)
+	if !ok {
+		return nil
+	}
+	defer done()
+
+	bbo := BBO{
+		ExchangeID:  ob.ExchangeID,
+		Symbol:      ob.Symbol,
+		Canonical:   ob.Canonical,
+		BidPrice:    t.bid,
+		BidSize:     t.bidSize,
+		AskPrice:    t.ask,
+		AskSize:     t.askSize,
+		Timestamp:   ob.Timestamp,
+		ReceivedAt:  ob.ReceivedAt,
+		PublishedAt: time.Now(),
+	}
+	data, err := json.Marshal(bbo)
+	if err != nil {
+		return err
+	}
+	channel := keyspace.BBOKey(string(ob.ExchangeID), ob.Symbol)
+	if err := p.publish(context.Background(), channel, data); err != nil {
+		return err
+	}
+
+	if !ob.ReceivedAt.IsZero() {
+		latency := time.Since(ob.ReceivedAt)
+		metrics.BBOPublishLatency.WithLabelValues(string(ob.ExchangeID)).Observe(latency.Seconds())
+		p.partial.record(latency)
+	}
+	return nil
+}
+
+// flushBook publishes the symbol's pending book, if any
+func (p *RedisPublisher) flushBook(st *bookState) error {
+	st.publishMu.Lock()
+	defer st.publishMu.Unlock()
+
+	st.mu.Lock()
+	ob := st.pending
+	st.pending = nil
+	if ob != nil {
+		st.depthAt = time.Now()
+	}
+	st.mu.Unlock()
+	if ob == nil {
+		return nil
+	}
+	return p.publishBook(ob)
+}
+
+// flushDue publishes the pending books whose interval has passed
+func (p *RedisPublisher) flushDue() {
+	p.partial.mu.Lock()
+	var due []*bookState
+	for _, st := range p.partial.books {
+		st.mu.Lock()
+		if st.pending != nil && time.Since(st.depthAt) >= p.partial.config.DepthInterval {
+			due = append(due, st)
+		}
+		st.mu.Unlock()
+	}
+	p.partial.mu.Unlock()
+
+	for _, st := range due {
+		if err := p.flushBook(st); err != nil {
+			log.Error().Err(err).Msg("Failed to publish orderbook")
+			metrics.RedisPublishErrors.WithLabelValues("orderbook").Inc()
+		}
+	}
+}
+
+func (pb *partialBooks) record(latency time.Duration) {
+	pb.samplesMu.Lock()
+	pb.samples = append(pb.samples, latencySample{at: time.Now(), latency: latency})
+	pb.samplesMu.Unlock()
+}
+
+// check judges the p99 BBO latency over the window and alerts when it
+// crosses the SLO in either direction
+func (pb *partialBooks) check(now time.Time) {
+	cutoff := now.Add(-pb.config.Window)
+	pb.samplesMu.Lock()
+	i := sort.Search(len(pb.samples), func(i int) bool { return !pb.samples[i].at.Before(cutoff) })
+	pb.samples = append(pb.samples[:0], pb.samples[i:]...)
+	latencies := make([]time.Duration, len(pb.samples))
+	for i, s := range pb.samples {
+		latencies[i] = s.latency
+	}
+	pb.samplesMu.Unlock()
+
+	pb.statusMu.Lock()
+	defer pb.statusMu.Unlock()
+	status := &pb.status
+	status.Samples = len(latencies)
+	status.CheckedAt = now
+	if len(latencies) < pb.config.MinSamples {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p99 := latencies[(len(latencies)*99)/100]
+	status.P99Ms = ms(p99)
+	metrics.BBOLatencyP99.Set(p99.Seconds())
+
+	breached := p99 > pb.config.SLO
+	switch {
+	case breached && !status.Breached:
+		status.Breached, status.Since = true, now
+		metrics.BBOSLOBreached.Set(1)
+		metrics.BBOSLOBreaches.Inc()
+		log.Warn().
+			Float64("p99_ms", status.P99Ms).
+			Float64("target_ms", status.TargetMs).
+			Int("samples", status.Samples).
+			Msg("BBO publish latency SLO breached")
+	case !breached && status.Breached:
+		log.Info().
+			Float64("p99_ms", status.P99Ms).
+			Float64("target_ms", status.TargetMs).
+			Dur("breached_for", now.Sub(status.Since)).
+			Msg("BBO publish latency back within SLO")
+		status.Breached, status.Since = false, time.Time{}
+		metrics.BBOSLOBreached.Set(0)
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
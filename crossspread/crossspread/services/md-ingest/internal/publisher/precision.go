@@ -0,0 +1,121 @@
+package publisher
+
+import (
+	"math"
+	"strconv"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// cleanDigits is the number of significant digits kept for symbols without
+// tick/lot metadata; enough for any venue price, few enough to drop float
+// artifacts such as 0.30000000000000004
+const cleanDigits = 15
+
+// gridTolerance is the relative distance from the tick/lot grid treated as
+// float noise rather than real precision
+const gridTolerance = 1e-9
+
+// precision is the number of decimals published for a symbol
+type precision struct {
+	price int
+	size  int
+}
+
+// SetInstruments records the price and size decimals of each instrument
+// from its tick and lot size, and its contract size for USD notional. Sizes
+// keep enough decimals for both contract counts and base units, since
+// venues publish either.
+func (p *RedisPublisher) SetInstruments(instruments []connector.Instrument) {
+	p.precMu.Lock()
+	defer p.precMu.Unlock()
+
+	if p.precisions == nil {
+		p.precisions = make(map[connector.ExchangeID]map[string]precision)
+	}
+	p.setContractSizes(instruments)
+	for _, inst := range instruments {
+		if inst.TickSize <= 0 || inst.LotSize <= 0 {
+			continue
+		}
+		size := connector.StepDecimals(inst.LotSize)
+		if inst.ContractSize > 0 {
+			size = max(size, connector.StepDecimals(inst.LotSize*inst.ContractSize))
+		}
+		if p.precisions[inst.ExchangeID] == nil {
+			p.precisions[inst.ExchangeID] = make(map[string]precision)
+		}
+		p.precisions[inst.ExchangeID][inst.Symbol] = precision{
+			price: connector.StepDecimals(inst.TickSize),
+			size:  size,
+		}
+	}
+}
+
+// precisionFor returns the decimals of a symbol, if known
+func (p *RedisPublisher) precisionFor(exchange connector.ExchangeID, symbol string) (precision, bool) {
+	p.precMu.RLock()
+	defer p.precMu.RUnlock()
+	prec, ok := p.precisions[exchange][symbol]
+	return prec, ok
+}
+
+// roundedBook returns a copy of the book with prices and sizes at the
+// venue's precision. Levels are copied; the original is shared with other
+// consumers.
+func (p *RedisPublisher) roundedBook(ob *connector.Orderbook) *connector.Orderbook {
+	prec, ok := p.precisionFor(ob.ExchangeID, ob.Symbol)
+	price := func(v float64) float64 { return roundValue(v, prec.price, ok) }
+	size := func(v float64) float64 { return roundValue(v, prec.size, ok) }
+
+	levels := func(src []connector.PriceLevel) []connector.PriceLevel {
+		if src == nil {
+			return nil
+		}
+		out := make([]connector.PriceLevel, len(src))
+		for i, l := range src {
+			out[i] = connector.PriceLevel{Price: price(l.Price), Quantity: size(l.Quantity)}
+		}
+		return out
+	}
+
+	cp := *ob
+	cp.Bids = levels(ob.Bids)
+	cp.Asks = levels(ob.Asks)
+	cp.BestBid = price(ob.BestBid)
+	cp.BestAsk = price(ob.BestAsk)
+	return &cp
+}
+
+// roundedTrade returns a copy of the trade with price and size at the
+// venue's precision
+func (p *RedisPublisher) roundedTrade(trade *connector.Trade) *connector.Trade {
+	prec, ok := p.precisionFor(trade.ExchangeID, trade.Symbol)
+	cp := *trade
+	cp.Price = roundValue(trade.Price, prec.price, ok)
+	cp.Quantity = roundValue(trade.Quantity, prec.size, ok)
+	return &cp
+}
+
+// roundValue rounds to decimals when the precision is known, otherwise to
+// cleanDigits significant digits. The result is the float64 closest to the
+// decimal, which JSON encodes without artifacts. A value that is off the
+// grid by more than float noise means the metadata is wrong for it; it is
+// only cleaned, never moved.
+func roundValue(v float64, decimals int, known bool) float64 {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	if known {
+		scale := math.Pow10(decimals)
+		rounded := math.Round(v*scale) / scale
+		if math.Abs(rounded-v) <= math.Abs(v)*gridTolerance {
+			return rounded
+		}
+	}
+	cleaned, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', cleanDigits, 64), 64)
+	if err != nil {
+		return v
+	}
+	return cleaned
+}
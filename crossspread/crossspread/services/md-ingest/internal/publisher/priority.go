@@ -0,0 +1,214 @@
+package publisher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// Class orders outgoing messages competing for Redis connections when the
+// publisher is backlogged
+type Class int
+
+const (
+	ClassSpread    Class = iota // Spreads and the events strategies act on
+	ClassTrade                  // Trades and funding rates
+	ClassOrderbook              // Orderbook streams and channels
+	ClassAnalytics              // Bars, basis, index prices, open interest events
+	numClasses
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassSpread:
+		return "spread"
+	case ClassTrade:
+		return "trade"
+	case ClassOrderbook:
+		return "orderbook"
+	case ClassAnalytics:
+		return "analytics"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseClass parses a class name
+func ParseClass(s string) (Class, error) {
+	for c := Class(0); c < numClasses; c++ {
+		if strings.EqualFold(s, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown publish class %q", s)
+}
+
+// PriorityConfig controls the publish scheduler
+type PriorityConfig struct {
+	Slots int // Redis operations in flight at once; zero uses the client's pool size
+	// MaxQueue is how many messages of a class may wait for a slot before
+	// new ones are dropped; zero never drops
+	MaxQueue map[Class]int
+}
+
+// DefaultPriorityConfig never drops spreads and bounds the queues of the
+// high-volume classes, which are superseded by the next update anyway
+func DefaultPriorityConfig() PriorityConfig {
+	return PriorityConfig{
+		MaxQueue: map[Class]int{
+			ClassSpread:    0,
+			ClassTrade:     10000,
+			ClassOrderbook: 2000,
+			ClassAnalytics: 1000,
+		},
+	}
+}
+
+// ParseQueueLimits adds limits such as "orderbook=500,analytics=100" to
+// the default config
+func ParseQueueLimits(s string) (PriorityConfig, error) {
+	config := DefaultPriorityConfig()
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return config, fmt.Errorf("invalid publish queue limit %q: want class=messages", part)
+		}
+		class, err := ParseClass(strings.TrimSpace(name))
+		if err != nil {
+			return config, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return config, fmt.Errorf("invalid publish queue limit %q: messages must be a non-negative integer", part)
+		}
+		config.MaxQueue[class] = n
+	}
+	return config, nil
+}
+
+// ClassStats is a class's queue
+type ClassStats struct {
+	Class   string `json:"class"`
+	Queued  int    `json:"queued"`
+	Dropped int64  `json:"dropped"`
+	Limit   int    `json:"limit"` // Zero never drops
+}
+
+// scheduler hands a fixed number of slots to publishes, highest class first.
+// A free slot is taken at once; a released one goes to the oldest waiter of
+// the highest class waiting, so lower classes can delay but never starve
+// higher ones.
+type scheduler struct {
+	config PriorityConfig
+
+	mu      sync.Mutex
+	free    int
+	queues  [numClasses][]chan struct{}
+	dropped [numClasses]int64
+}
+
+func newScheduler(config PriorityConfig) *scheduler {
+	return &scheduler{config: config, free: config.Slots}
+}
+
+// acquire waits for a slot; false means the class's queue is full and the
+// message is dropped
+func (s *scheduler) acquire(class Class) bool {
+	s.mu.Lock()
+	if s.free > 0 {
+		s.free--
+		s.mu.Unlock()
+		return true
+	}
+	if limit := s.config.MaxQueue[class]; limit > 0 && len(s.queues[class]) >= limit {
+		s.dropped[class]++
+		s.mu.Unlock()
+		metrics.PublishDrops.WithLabelValues(class.String()).Inc()
+		return false
+	}
+	ready := make(chan struct{})
+	s.queues[class] = append(s.queues[class], ready)
+	metrics.PublishQueued.WithLabelValues(class.String()).Set(float64(len(s.queues[class])))
+	s.mu.Unlock()
+
+	start := time.Now()
+	<-ready
+	metrics.PublishQueueWait.WithLabelValues(class.String()).Observe(time.Since(start).Seconds())
+	return true
+}
+
+// release passes the slot to the highest class waiting
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := Class(0); c < numClasses; c++ {
+		if q := s.queues[c]; len(q) > 0 {
+			ready := q[0]
+			q[0] = nil
+			s.queues[c] = q[1:]
+			metrics.PublishQueued.WithLabelValues(c.String()).Set(float64(len(s.queues[c])))
+			close(ready)
+			return
+		}
+	}
+	s.free++
+}
+
+func (s *scheduler) stats() []ClassStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]ClassStats, 0, numClasses)
+	for c := Class(0); c < numClasses; c++ {
+		result = append(result, ClassStats{
+			Class:   c.String(),
+			Queued:  len(s.queues[c]),
+			Dropped: s.dropped[c],
+			Limit:   s.config.MaxQueue[c],
+		})
+	}
+	return result
+}
+
+// SetPriority makes publishes share a fixed number of Redis slots by class
+// instead of contending for pool connections in arrival order. Call it
+// before publishing starts.
+func (p *RedisPublisher) SetPriority(config PriorityConfig) {
+	if config.Slots <= 0 {
+		config.Slots = p.client.Options().PoolSize
+	}
+	p.scheduler = newScheduler(config)
+}
+
+// PriorityStats returns each class's queue, or nil when publishes are not
+// prioritized
+func (p *RedisPublisher) PriorityStats() []ClassStats {
+	if p.scheduler == nil {
+		return nil
+	}
+	return p.scheduler.stats()
+}
+
+// admit takes a slot for a publish of the class. A dropped publish returns
+// false; otherwise the caller must call the returned func when done, which
+// records the publish latency including any wait.
+func (p *RedisPublisher) admit(class Class) (func(), bool) {
+	if p.scheduler == nil {
+		return func() {}, true
+	}
+	start := time.Now()
+	if !p.scheduler.acquire(class) {
+		return nil, false
+	}
+	return func() {
+		p.scheduler.release()
+		metrics.PublishClassLatency.WithLabelValues(class.String()).Observe(time.Since(start).Seconds())
+	}, true
+}
@@ -0,0 +1,76 @@
+package publisher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Publisher carries the market data stream: books, trades, funding and
+// spreads. RedisPublisher writes them to Redis streams and pub/sub;
+// JetStreamPublisher to persistent NATS JetStream streams. Keys holding
+// state (spreads lists, indices, config) stay in Redis either way.
+type Publisher interface {
+	PublishOrderbook(ob *connector.Orderbook) error
+	PublishTrade(trade *connector.Trade) error
+	PublishFundingRate(fr *connector.FundingRate) error
+	SetSpread(spreadID string, data []byte, age time.Duration) error
+	Close() error
+}
+
+// Publisher backends, chosen by PUBLISHER_BACKEND
+const (
+	BackendRedis     = "redis"
+	BackendJetStream = "jetstream"
+)
+
+// ParseBackend parses a publisher backend name
+func ParseBackend(s string) (string, error) {
+	switch b := strings.ToLower(strings.TrimSpace(s)); b {
+	case "", BackendRedis:
+		return BackendRedis, nil
+	case BackendJetStream, "nats":
+		return BackendJetStream, nil
+	default:
+		return "", fmt.Errorf("unknown publisher backend %q: want redis or jetstream", s)
+	}
+}
+
+// Message types, the last token of a subject
+const (
+	TypeOrderbook = "orderbook"
+	TypeTrade     = "trade"
+	TypeFunding   = "funding"
+	TypeSpread    = "spread"
+)
+
+// Subject returns the subject of a message: md.<exchange>.<symbol>.<type>
+func Subject(exchange, symbol, msgType string) string {
+	return "md." + subjectToken(exchange) + "." + subjectToken(symbol) + "." + msgType
+}
+
+// SpreadSubject returns the subject of a spread. Its exchange token names
+// both legs, long first: md.<long>-<short>.<canonical>.spread
+func SpreadSubject(spreadID string) string {
+	parts := strings.Split(spreadID, ":")
+	if len(parts) != 3 {
+		return Subject("spread", spreadID, TypeSpread)
+	}
+	return Subject(parts[1]+"-"+parts[2], parts[0], TypeSpread)
+}
+
+// subjectToken replaces the characters NATS reserves in a subject token
+func subjectToken(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -14,12 +17,45 @@ import (
 // RedisPublisher publishes market data to Redis Streams
 type RedisPublisher struct {
 	client *redis.Client
+
+	// Published orderbook levels; see depth.go
+	depthMu      sync.RWMutex
+	defaultDepth int
+	depths       map[connector.ExchangeID]map[string]int
+
+	// Published price and size decimals; see precision.go
+	precMu        sync.RWMutex
+	precisions    map[connector.ExchangeID]map[string]precision
+	contractSizes map[connector.ExchangeID]map[string]float64
+
+	// USD notional on published levels and trades; see notional.go
+	notionalMu   sync.RWMutex
+	notionalMode string
+	indexSource  IndexSource
+
+	// Publish slots shared by class; nil publishes in arrival order. See
+	// priority.go
+	scheduler *scheduler
+
+	// BBO-first book publication; nil publishes every book. See partial.go
+	partial *partialBooks
+
+	// Messages published per channel; nil sends no heartbeats. See
+	// heartbeat.go
+	heartbeats *heartbeats
+
+	// Expiry of published spread keys; see ttl.go
+	ttlMu     sync.RWMutex
+	spreadTTL SpreadTTLConfig
 }
 
-// NewRedisPublisher creates a new Redis publisher
-func NewRedisPublisher(addr string) (*RedisPublisher, error) {
+// NewRedisPublisher creates a new Redis publisher. username and password
+// may be empty; set them when the instance runs under a Redis ACL user.
+func NewRedisPublisher(addr, username, password string) (*RedisPublisher, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr: addr,
+		Addr:     addr,
+		Username: username,
+		Password: password,
 	})
 
 	// Test connection
@@ -43,18 +79,35 @@ func (p *RedisPublisher) Close() error {
 
 // PublishOrderbook publishes orderbook to Redis Stream AND Pub/Sub for real-time streaming
 func (p *RedisPublisher) PublishOrderbook(ob *connector.Orderbook) error {
-	data, err := json.Marshal(ob)
+	if p.partial != nil {
+		return p.publishPartial(ob)
+	}
+	return p.publishBook(ob)
+}
+
+func (p *RedisPublisher) publishBook(ob *connector.Orderbook) error {
+	done, ok := p.admit(ClassOrderbook)
+	if !ok {
+		return nil
+	}
+	defer done()
+
+	// Bus events are shared by every subscriber, so only the shaped copy
+	// is stamped
+	out := p.ShapeOrderbook(ob)
+	out.PublishedAt = time.Now()
+	data, err := json.Marshal(out)
 	if err != nil {
 		return err
 	}
 
 	// Stream key: orderbook:{exchange}:{symbol}
-	streamKey := fmt.Sprintf("orderbook:%s:%s", ob.ExchangeID, ob.Symbol)
+	streamKey := keyspace.OrderbookKey(string(ob.ExchangeID), ob.Symbol)
 
 	// Publish to Redis Stream (for historical data/replay)
 	if err := p.client.XAdd(context.Background(), &redis.XAddArgs{
 		Stream: streamKey,
-		MaxLen: 1000, // Keep last 1000 entries
+		MaxLen: keyspace.OrderbookStreamMaxLen,
 		Approx: true,
 		Values: map[string]interface{}{
 			"data": string(data),
@@ -64,32 +117,125 @@ func (p *RedisPublisher) PublishOrderbook(ob *connector.Orderbook) error {
 	}
 
 	// Also publish to Pub/Sub for real-time WebSocket streaming
-	if err := p.client.Publish(context.Background(), streamKey, string(data)).Err(); err != nil {
+	if err := p.publish(context.Background(), streamKey, string(data)); err != nil {
 		return err
 	}
 
+	metrics.RecordPipelineLatency(string(ob.ExchangeID), "orderbook", ob.Timestamp, ob.ReceivedAt, ob.NormalizedAt, out.PublishedAt)
+
 	// Debug: log published channel for troubleshooting
-	fmt.Printf("[md-ingest] Published orderbook to channel/stream %s (bids=%d, asks=%d)\n", streamKey, len(ob.Bids), len(ob.Asks))
+	fmt.Printf("[md-ingest] Published orderbook to channel/stream %s (bids=%d, asks=%d)\n", streamKey, len(out.Bids), len(out.Asks))
 	return nil
 }
 
+// ShapeOrderbook returns a book as published: trimmed to its depth,
+// rounded to the venue's decimals and with notionals when enabled
+func (p *RedisPublisher) ShapeOrderbook(ob *connector.Orderbook) *connector.Orderbook {
+	return p.withNotional(p.roundedBook(p.trimmed(ob)))
+}
+
+// ShapeTrade returns a trade as published, rounded and with its notional
+func (p *RedisPublisher) ShapeTrade(trade *connector.Trade) *connector.Trade {
+	return p.withNotionalTrade(p.roundedTrade(trade))
+}
+
 // PublishTrade publishes trade to Redis Stream
 func (p *RedisPublisher) PublishTrade(trade *connector.Trade) error {
-	data, err := json.Marshal(trade)
+	done, ok := p.admit(ClassTrade)
+	if !ok {
+		return nil
+	}
+	defer done()
+	out := p.ShapeTrade(trade)
+	out.PublishedAt = time.Now()
+	data, err := json.Marshal(out)
 	if err != nil {
 		return err
 	}
 
-	streamKey := fmt.Sprintf("trades:%s:%s", trade.ExchangeID, trade.Symbol)
+	streamKey := keyspace.TradesKey(string(trade.ExchangeID), trade.Symbol)
 
-	return p.client.XAdd(context.Background(), &redis.XAddArgs{
+	if err := p.client.XAdd(context.Background(), &redis.XAddArgs{
 		Stream: streamKey,
-		MaxLen: 10000,
+		MaxLen: keyspace.TradesStreamMaxLen,
 		Approx: true,
 		Values: map[string]interface{}{
 			"data": string(data),
 		},
-	}).Err()
+	}).Err(); err != nil {
+		return err
+	}
+
+	metrics.RecordPipelineLatency(string(trade.ExchangeID), "trade", trade.Timestamp, trade.ReceivedAt, trade.NormalizedAt, out.PublishedAt)
+	return nil
+}
+
+// PublishFundingRate publishes a funding rate, with the mark price when the
+// venue returns it, to its stream and channel
+func (p *RedisPublisher) PublishFundingRate(fr *connector.FundingRate) error {
+	data, err := json.Marshal(fr)
+	if err != nil {
+		return err
+	}
+	return p.publishEvent(ClassTrade, keyspace.FundingKey(string(fr.ExchangeID), fr.Symbol), keyspace.FundingStreamMaxLen, data)
+}
+
+// PublishBar appends a closed OHLCV bar to its stream and publishes it
+func (p *RedisPublisher) PublishBar(key string, data []byte) error {
+	return p.publishEvent(ClassAnalytics, key, keyspace.BarsStreamMaxLen, data)
+}
+
+// PublishOpenInterestEvent publishes an open interest change event to the
+// events stream and channel
+func (p *RedisPublisher) PublishOpenInterestEvent(data []byte) error {
+	return p.publishEvent(ClassAnalytics, keyspace.Key(keyspace.OpenInterestEventsKey), keyspace.OpenInterestEventsMaxLen, data)
+}
+
+// PublishFundingSettlement publishes a funding settlement event to the
+// settlements stream and channel
+func (p *RedisPublisher) PublishFundingSettlement(data []byte) error {
+	return p.publishEvent(ClassSpread, keyspace.Key(keyspace.FundingSettlementsKey), keyspace.FundingEventsMaxLen, data)
+}
+
+// PublishFundingAction publishes a pre-settlement action to the actions
+// stream and channel
+func (p *RedisPublisher) PublishFundingAction(data []byte) error {
+	return p.publishEvent(ClassSpread, keyspace.Key(keyspace.FundingActionsKey), keyspace.FundingEventsMaxLen, data)
+}
+
+// PublishSymbolStatus publishes a symbol status transition to the status
+// stream and channel
+func (p *RedisPublisher) PublishSymbolStatus(data []byte) error {
+	return p.publishEvent(ClassSpread, keyspace.Key(keyspace.SymbolStatusEventsKey), keyspace.SymbolStatusMaxLen, data)
+}
+
+// PublishFeedAlert publishes a feed rate alert to the alerts stream and
+// channel
+func (p *RedisPublisher) PublishFeedAlert(data []byte) error {
+	return p.publishEvent(ClassSpread, keyspace.Key(keyspace.FeedAlertsKey), keyspace.FeedAlertsMaxLen, data)
+}
+
+// publishEvent appends an event to a capped stream and publishes it on the
+// channel of the same name
+func (p *RedisPublisher) publishEvent(class Class, key string, maxLen int64, data []byte) error {
+	done, ok := p.admit(class)
+	if !ok {
+		return nil
+	}
+	defer done()
+	ctx := context.Background()
+	if err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"data": string(data),
+		},
+	}).Err(); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, key, string(data))
 }
 
 // PublishSpread publishes computed spread to Redis Stream
@@ -98,10 +244,15 @@ func (p *RedisPublisher) PublishSpread(spread map[string]interface{}) error {
 	if err != nil {
 		return err
 	}
+	done, ok := p.admit(ClassSpread)
+	if !ok {
+		return nil
+	}
+	defer done()
 
 	return p.client.XAdd(context.Background(), &redis.XAddArgs{
-		Stream: "spreads",
-		MaxLen: 10000,
+		Stream: keyspace.Key(keyspace.SpreadsStreamKey),
+		MaxLen: keyspace.SpreadsStreamMaxLen,
 		Approx: true,
 		Values: map[string]interface{}{
 			"data": string(data),
@@ -109,51 +260,180 @@ func (p *RedisPublisher) PublishSpread(spread map[string]interface{}) error {
 	}).Err()
 }
 
-// Publish publishes a message to a Redis channel (Pub/Sub)
+// Publish publishes a message to a Redis channel (Pub/Sub). Discovery fans
+// spreads out with it, so it is in the spread class.
 func (p *RedisPublisher) Publish(channel, message string) error {
-	return p.client.Publish(context.Background(), channel, message).Err()
+	done, ok := p.admit(ClassSpread)
+	if !ok {
+		return nil
+	}
+	defer done()
+	return p.publish(context.Background(), channel, message)
 }
 
 // PublishOrderbookPubSub publishes orderbook update via Redis Pub/Sub for real-time streaming
 func (p *RedisPublisher) PublishOrderbookPubSub(ob *connector.Orderbook) error {
-	data, err := json.Marshal(ob)
+	done, ok := p.admit(ClassOrderbook)
+	if !ok {
+		return nil
+	}
+	defer done()
+	data, err := json.Marshal(p.withNotional(p.roundedBook(p.trimmed(ob))))
 	if err != nil {
 		return err
 	}
 
 	// Pub/Sub channel: orderbook:{exchange}:{symbol}
-	channel := fmt.Sprintf("orderbook:%s:%s", ob.ExchangeID, ob.Symbol)
-	return p.client.Publish(context.Background(), channel, string(data)).Err()
+	channel := keyspace.OrderbookKey(string(ob.ExchangeID), ob.Symbol)
+	return p.publish(context.Background(), channel, string(data))
 }
 
 // PublishSpreadPubSub publishes spread update via Redis Pub/Sub for real-time streaming
 func (p *RedisPublisher) PublishSpreadPubSub(spreadID string, data []byte) error {
-	channel := fmt.Sprintf("spread:%s", spreadID)
-	return p.client.Publish(context.Background(), channel, string(data)).Err()
+	done, ok := p.admit(ClassSpread)
+	if !ok {
+		return nil
+	}
+	defer done()
+	return p.publishSpreadPubSub(spreadID, data)
 }
 
-// SetSpread stores a spread in Redis as a key-value with expiration
-func (p *RedisPublisher) SetSpread(spreadID string, data []byte) error {
+func (p *RedisPublisher) publishSpreadPubSub(spreadID string, data []byte) error {
+	channel := keyspace.SpreadChannel(spreadID)
+	return p.publish(context.Background(), channel, string(data))
+}
+
+// SetSpread stores a spread in Redis as a key-value with expiration. The
+// data TTL counts from the older leg's quote, so the key lives the TTL less
+// age; a spread already past it is not written and leaves spreads:active.
+func (p *RedisPublisher) SetSpread(spreadID string, data []byte, age time.Duration) error {
+	done, ok := p.admit(ClassSpread)
+	if !ok {
+		return nil
+	}
+	defer done()
 	ctx := context.Background()
-	key := fmt.Sprintf("spread:data:%s", spreadID)
+	key := keyspace.SpreadDataKey(spreadID)
+	activeKey := keyspace.Key(keyspace.SpreadsActiveKey)
+	ttl := p.SpreadTTL().Data - age
+	if ttl <= 0 {
+		metrics.SpreadsExpiredUnwritten.Inc()
+		return p.client.SRem(ctx, activeKey, spreadID).Err()
+	}
 
-	// Set with 5 minute expiration (spreads auto-expire if not updated)
-	if err := p.client.Set(ctx, key, data, 5*time.Minute).Err(); err != nil {
+	// Set with expiration (spreads auto-expire if not updated)
+	if err := p.client.Set(ctx, key, data, ttl).Err(); err != nil {
 		return err
 	}
 
 	// Also publish to Pub/Sub for real-time streaming to frontend
-	if err := p.PublishSpreadPubSub(spreadID, data); err != nil {
+	if err := p.publishSpreadPubSub(spreadID, data); err != nil {
 		// Log but don't fail - Pub/Sub is best-effort
 		fmt.Printf("Warning: failed to publish spread to Pub/Sub: %v\n", err)
 	}
 
-	// Also add to spreads set for listing
-	return p.client.SAdd(ctx, "spreads:active", spreadID).Err()
+	// Also add to spreads set for listing. PruneActiveSpreads removes IDs
+	// whose data expired; the set itself expires a data TTL after the last
+	// write so a producer that stops leaves no IDs behind.
+	pipe := p.client.Pipeline()
+	pipe.SAdd(ctx, activeKey, spreadID)
+	pipe.Expire(ctx, activeKey, p.SpreadTTL().Data)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// PruneActiveSpreads removes the IDs from spreads:active whose data key is
+// gone, expired or deleted, and returns how many it removed
+func (p *RedisPublisher) PruneActiveSpreads() (int, error) {
+	ctx := context.Background()
+	activeKey := keyspace.Key(keyspace.SpreadsActiveKey)
+	ids, err := p.client.SMembers(ctx, activeKey).Result()
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	pipe := p.client.Pipeline()
+	exists := make([]*redis.IntCmd, len(ids))
+	for i, id := range ids {
+		exists[i] = pipe.Exists(ctx, keyspace.SpreadDataKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	var dead []interface{}
+	for i, cmd := range exists {
+		if cmd.Val() == 0 {
+			dead = append(dead, ids[i])
+		}
+	}
+	if len(dead) == 0 {
+		return 0, nil
+	}
+	if err := p.client.SRem(ctx, activeKey, dead...).Err(); err != nil {
+		return 0, err
+	}
+	metrics.SpreadsActivePruned.Add(float64(len(dead)))
+	return len(dead), nil
 }
 
 // SetSpreadsList stores the list of active spreads summary
 func (p *RedisPublisher) SetSpreadsList(data []byte) error {
+	done, ok := p.admit(ClassSpread)
+	if !ok {
+		return nil
+	}
+	defer done()
 	ctx := context.Background()
-	return p.client.Set(ctx, "spreads:list", data, 30*time.Second).Err()
+	return p.client.Set(ctx, keyspace.Key(keyspace.SpreadsListKey), data, p.SpreadTTL().List).Err()
+}
+
+// SetBasis stores the spot-vs-perp basis summary and publishes it
+func (p *RedisPublisher) SetBasis(data []byte) error {
+	done, ok := p.admit(ClassAnalytics)
+	if !ok {
+		return nil
+	}
+	defer done()
+	ctx := context.Background()
+	key := keyspace.Key(keyspace.BasisKey)
+
+	if err := p.client.Set(ctx, key, data, p.SpreadTTL().List).Err(); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, key, string(data))
+}
+
+// SetTenantSpreads stores a tenant's spreads summary and publishes it
+func (p *RedisPublisher) SetTenantSpreads(tenant string, data []byte) error {
+	done, ok := p.admit(ClassSpread)
+	if !ok {
+		return nil
+	}
+	defer done()
+	ctx := context.Background()
+	key := keyspace.TenantSpreadsKey(tenant)
+
+	if err := p.client.Set(ctx, key, data, p.SpreadTTL().List).Err(); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, key, string(data))
+}
+
+// SetIndex stores the index price for a canonical symbol and publishes it
+func (p *RedisPublisher) SetIndex(canonical string, data []byte) error {
+	done, ok := p.admit(ClassAnalytics)
+	if !ok {
+		return nil
+	}
+	defer done()
+	ctx := context.Background()
+	key := keyspace.IndexKey(canonical)
+
+	if err := p.client.Set(ctx, key, data, keyspace.IndexTTL).Err(); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, key, string(data))
 }
@@ -0,0 +1,47 @@
+package publisher
+
+import (
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+)
+
+// SpreadTTLConfig controls how long published spread keys outlive their
+// last write. A producer that crashes stops refreshing them, so they
+// expire instead of leaving spreads that look live to executors.
+type SpreadTTLConfig struct {
+	Data time.Duration // spread:data:{id} and spreads:active
+	List time.Duration // spreads:list, the basis and tenant summaries
+}
+
+// DefaultSpreadTTLConfig returns the keyspace's default TTLs
+func DefaultSpreadTTLConfig() SpreadTTLConfig {
+	return SpreadTTLConfig{
+		Data: keyspace.SpreadDataTTL,
+		List: keyspace.SpreadsListTTL,
+	}
+}
+
+// SetSpreadTTL sets the TTLs of published spread keys. Zero or negative
+// values keep the defaults.
+func (p *RedisPublisher) SetSpreadTTL(config SpreadTTLConfig) {
+	p.ttlMu.Lock()
+	defer p.ttlMu.Unlock()
+	p.spreadTTL = config
+}
+
+// SpreadTTL returns the TTLs in effect
+func (p *RedisPublisher) SpreadTTL() SpreadTTLConfig {
+	p.ttlMu.RLock()
+	config := p.spreadTTL
+	p.ttlMu.RUnlock()
+
+	defaults := DefaultSpreadTTLConfig()
+	if config.Data <= 0 {
+		config.Data = defaults.Data
+	}
+	if config.List <= 0 {
+		config.List = defaults.List
+	}
+	return config
+}
@@ -0,0 +1,269 @@
+package ratebudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrBudgetExhausted is returned when no token is available for a priority
+var ErrBudgetExhausted = errors.New("ratebudget: order rate budget exhausted")
+
+// Priority orders strategies competing for an exchange's order rate limit
+type Priority int
+
+const (
+	PriorityHedge     Priority = iota // Time-critical hedges and unwinds; may use the whole bucket
+	PriorityExecution                 // Spread executor entries and exits
+	PriorityRebalance                 // Funding-arb rebalancing and other background flow
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityHedge:
+		return "hedge"
+	case PriorityExecution:
+		return "execution"
+	case PriorityRebalance:
+		return "rebalance"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePriority parses a priority name
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(s) {
+	case "hedge":
+		return PriorityHedge, nil
+	case "execution":
+		return PriorityExecution, nil
+	case "rebalance":
+		return PriorityRebalance, nil
+	default:
+		return 0, fmt.Errorf("unknown priority %q", s)
+	}
+}
+
+// Limit is an exchange account's order rate limit
+type Limit struct {
+	Capacity int     // Burst size in orders
+	Rate     float64 // Sustained orders per second
+}
+
+// Config controls the shared budget
+type Config struct {
+	Limits       map[connector.ExchangeID]Limit
+	DefaultLimit Limit
+	// Reserve is the fraction of capacity a priority may not dip into, kept
+	// for higher priorities. Hedges reserve nothing.
+	Reserve map[Priority]float64
+	MaxWait time.Duration // Longest Acquire waits before giving up
+}
+
+// DefaultConfig returns conservative per-account order limits, below the
+// venues' published limits so manual and other tooling keeps some headroom
+func DefaultConfig() Config {
+	return Config{
+		Limits: map[connector.ExchangeID]Limit{
+			connector.Binance: {Capacity: 240, Rate: 20},
+			connector.Bybit:   {Capacity: 10, Rate: 8},
+			connector.OKX:     {Capacity: 50, Rate: 25},
+			connector.KuCoin:  {Capacity: 25, Rate: 8},
+			connector.GateIO:  {Capacity: 80, Rate: 80},
+			connector.Bitget:  {Capacity: 10, Rate: 8},
+			connector.MEXC:    {Capacity: 16, Rate: 8},
+			connector.BingX:   {Capacity: 10, Rate: 5},
+			connector.CoinEx:  {Capacity: 16, Rate: 16},
+		},
+		DefaultLimit: Limit{Capacity: 10, Rate: 5},
+		Reserve: map[Priority]float64{
+			PriorityHedge:     0,
+			PriorityExecution: 0.1,
+			PriorityRebalance: 0.4,
+		},
+		MaxWait: 2 * time.Second,
+	}
+}
+
+// takeScript refills the bucket from the Redis clock and takes a token if
+// at least floor tokens remain afterwards. A factor field, set while the
+// venue is degraded, scales capacity, rate and floor down. Returns
+// {1, tokens} on success or {0, wait_ms} with the time until a token above
+// floor is available.
+var takeScript = redis.NewScript(`
+local factor = tonumber(redis.call('HGET', KEYS[1], 'factor') or 1)
+local capacity = math.max(1, math.floor(tonumber(ARGV[1]) * factor))
+local rate = tonumber(ARGV[2]) * factor
+local floor = tonumber(ARGV[3]) * factor
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens') or capacity)
+local ts = tonumber(redis.call('HGET', KEYS[1], 'ts_ms') or now)
+tokens = math.min(capacity, tokens + (now - ts) * rate / 1000)
+
+local ok = 0
+local result = 0
+if tokens - 1 >= floor then
+	tokens = tokens - 1
+	ok = 1
+	result = math.floor(tokens)
+else
+	result = math.ceil((floor + 1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts_ms', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity * 1000 / rate) + 60000)
+return {ok, result}
+`)
+
+// Budget arbitrates order submissions of every strategy sharing exchange
+// accounts. State lives in Redis so strategies in separate processes draw
+// from the same bucket.
+type Budget struct {
+	client *redis.Client
+	config Config
+}
+
+// New creates a new shared order rate budget
+func New(client *redis.Client, config Config) *Budget {
+	return &Budget{client: client, config: config}
+}
+
+// limit returns the order limit for an exchange
+func (b *Budget) limit(exchange connector.ExchangeID) Limit {
+	if l, ok := b.config.Limits[exchange]; ok {
+		return l
+	}
+	return b.config.DefaultLimit
+}
+
+// take attempts to take one token, returning the wait until one is available on failure
+func (b *Budget) take(ctx context.Context, exchange connector.ExchangeID, priority Priority) (bool, time.Duration, error) {
+	l := b.limit(exchange)
+	floor := float64(l.Capacity) * b.config.Reserve[priority]
+
+	res, err := takeScript.Run(ctx, b.client, []string{keyspace.RateBudgetKey(string(exchange))},
+		l.Capacity, strconv.FormatFloat(l.Rate, 'f', -1, 64), strconv.FormatFloat(floor, 'f', -1, 64)).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if res[0] == 1 {
+		return true, 0, nil
+	}
+	return false, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// TryAcquire takes a token without waiting. Returns ErrBudgetExhausted if
+// none is available to this priority.
+func (b *Budget) TryAcquire(ctx context.Context, exchange connector.ExchangeID, strategy string, priority Priority) error {
+	labels := []string{string(exchange), strategy, priority.String()}
+	ok, _, err := b.take(ctx, exchange, priority)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		metrics.OrderBudgetDenied.WithLabelValues(labels...).Inc()
+		return ErrBudgetExhausted
+	}
+	metrics.OrderBudgetAcquired.WithLabelValues(labels...).Inc()
+	return nil
+}
+
+// Acquire takes a token, waiting up to MaxWait (or the context deadline)
+// for one to become available to this priority
+func (b *Budget) Acquire(ctx context.Context, exchange connector.ExchangeID, strategy string, priority Priority) error {
+	labels := []string{string(exchange), strategy, priority.String()}
+	start := time.Now()
+	deadline := start.Add(b.config.MaxWait)
+
+	for {
+		ok, wait, err := b.take(ctx, exchange, priority)
+		if err != nil {
+			return err
+		}
+		if ok {
+			metrics.OrderBudgetAcquired.WithLabelValues(labels...).Inc()
+			metrics.OrderBudgetWait.WithLabelValues(string(exchange), priority.String()).Observe(time.Since(start).Seconds())
+			return nil
+		}
+
+		if time.Now().Add(wait).After(deadline) {
+			metrics.OrderBudgetDenied.WithLabelValues(labels...).Inc()
+			return ErrBudgetExhausted
+		}
+		select {
+		case <-ctx.Done():
+			metrics.OrderBudgetDenied.WithLabelValues(labels...).Inc()
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// SetRateFactor scales an exchange's capacity and rate for every strategy
+// sharing its bucket, e.g. while its API is degraded. A factor of 1 or more
+// restores the configured limit.
+func (b *Budget) SetRateFactor(ctx context.Context, exchange connector.ExchangeID, factor float64) error {
+	key := keyspace.RateBudgetKey(string(exchange))
+	if factor >= 1 {
+		return b.client.HDel(ctx, key, "factor").Err()
+	}
+	return b.client.HSet(ctx, key, "factor", strconv.FormatFloat(math.Max(factor, 0.01), 'f', -1, 64)).Err()
+}
+
+// Status is the current state of an exchange's bucket. Capacity, rate and
+// floors are the configured ones, before Factor.
+type Status struct {
+	Exchange connector.ExchangeID `json:"exchange"`
+	Capacity int                  `json:"capacity"`
+	Rate     float64              `json:"rate"`
+	Factor   float64              `json:"factor"` // Below 1 while the venue is degraded
+	Tokens   float64              `json:"tokens"` // As of the last take, before refill
+	Floors   map[string]float64   `json:"floors"` // Tokens each priority must leave
+}
+
+// Status returns the bucket state of every configured exchange
+func (b *Budget) Status(ctx context.Context) ([]Status, error) {
+	result := make([]Status, 0, len(b.config.Limits))
+	for _, id := range connector.TradingExchanges() {
+		l := b.limit(id)
+		st := Status{
+			Exchange: id,
+			Capacity: l.Capacity,
+			Rate:     l.Rate,
+			Factor:   1,
+			Tokens:   float64(l.Capacity),
+			Floors:   make(map[string]float64),
+		}
+		for p, reserve := range b.config.Reserve {
+			st.Floors[p.String()] = float64(l.Capacity) * reserve
+		}
+
+		v, err := b.client.HMGet(ctx, keyspace.RateBudgetKey(string(id)), "tokens", "factor").Result()
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := v[0].(string); ok {
+			st.Tokens, _ = strconv.ParseFloat(s, 64)
+		}
+		if s, ok := v[1].(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil && f > 0 {
+				st.Factor = f
+			}
+		}
+		result = append(result, st)
+	}
+	return result, nil
+}
@@ -0,0 +1,285 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/spread"
+)
+
+// Timeline mark kinds
+const (
+	MarkBook        = "book"
+	MarkFunding     = "funding"
+	MarkTicker      = "ticker"
+	MarkSilent      = "silent"      // A leg's book went quiet for longer than the gap
+	MarkPublished   = "published"   // The opportunity entered the published spreads
+	MarkMoved       = "moved"       // Its spread or edge changed while published
+	MarkWithdrawn   = "withdrawn"   // It left the published spreads
+	MarkUnpublished = "unpublished" // Why it still isn't published, when that changes
+)
+
+// Mark is one annotated step of an opportunity's timeline
+type Mark struct {
+	At       time.Time                 `json:"at"`
+	Kind     string                    `json:"kind"`
+	Leg      string                    `json:"leg,omitempty"` // long or short, for frames of a spread's legs
+	Exchange connector.ExchangeID      `json:"exchange,omitempty"`
+	Note     string                    `json:"note"`
+	Spread   *spread.SpreadOpportunity `json:"spread,omitempty"` // As published, for discovery marks
+}
+
+// PostMortemConfig controls how an opportunity's timeline is annotated
+type PostMortemConfig struct {
+	Config
+	Gap     time.Duration // A leg without a book for this long is marked silent
+	MoveBps float64       // Spread or edge changes marked while published
+}
+
+// DefaultPostMortemConfig replays like live discovery, marking books
+// silent after a second and moves of a basis point
+func DefaultPostMortemConfig() PostMortemConfig {
+	return PostMortemConfig{
+		Config:  DefaultConfig(),
+		Gap:     time.Second,
+		MoveBps: 1,
+	}
+}
+
+// Legs splits an opportunity ID into its canonical symbol and venues: a
+// spread ID (canonical:long:short) gives both legs in order, a basis ID
+// (canonical:exchange:direction) its one venue
+func Legs(id string) (string, []connector.ExchangeID, error) {
+	parts := strings.Split(id, ":")
+	var venues []connector.ExchangeID
+	for _, part := range parts[1:] {
+		for _, e := range connector.Exchanges() {
+			if connector.ExchangeID(part) == e {
+				venues = append(venues, e)
+			}
+		}
+	}
+	if parts[0] == "" || len(venues) == 0 {
+		return "", nil, fmt.Errorf("%q is not an opportunity ID, want canonical:long:short", id)
+	}
+	return parts[0], venues, nil
+}
+
+// venue returns the exchange and canonical symbol a frame is about
+func (f Frame) venue() (connector.ExchangeID, string) {
+	switch {
+	case f.Orderbook != nil:
+		return f.Orderbook.ExchangeID, f.Orderbook.Canonical
+	case f.Funding != nil:
+		return f.Funding.ExchangeID, f.Funding.Canonical
+	case f.Ticker != nil:
+		return f.Ticker.ExchangeID, f.Ticker.Canonical
+	}
+	return "", ""
+}
+
+func (f Frame) kind() string {
+	switch {
+	case f.Orderbook != nil:
+		return MarkBook
+	case f.Funding != nil:
+		return MarkFunding
+	default:
+		return MarkTicker
+	}
+}
+
+// Extract keeps the frames of a canonical symbol on the given venues within
+// [from, to]. The last book, funding rate and ticker of each venue before
+// from come first, so discovery starts from the state it had live.
+func Extract(frames []Frame, canonical string, venues []connector.ExchangeID, from, to time.Time) []Frame {
+	wanted := make(map[connector.ExchangeID]bool, len(venues))
+	for _, v := range venues {
+		wanted[v] = true
+	}
+	type seedKey struct {
+		exchange connector.ExchangeID
+		kind     string
+	}
+	seeds := make(map[seedKey]Frame)
+	var seedOrder []seedKey
+	var window []Frame
+	for _, f := range frames {
+		exchange, c := f.venue()
+		if c != canonical || !wanted[exchange] || f.At.After(to) {
+			continue
+		}
+		if f.At.Before(from) {
+			k := seedKey{exchange, f.kind()}
+			if _, ok := seeds[k]; !ok {
+				seedOrder = append(seedOrder, k)
+			}
+			seeds[k] = f
+			continue
+		}
+		window = append(window, f)
+	}
+
+	extracted := make([]Frame, 0, len(seeds)+len(window))
+	for _, k := range seedOrder {
+		extracted = append(extracted, seeds[k])
+	}
+	// Seeds keep their recorded times, which must stay in order
+	sortFrames(extracted)
+	return append(extracted, window...)
+}
+
+// sortFrames orders frames by time, keeping recorded order among equals
+func sortFrames(frames []Frame) {
+	for i := 1; i < len(frames); i++ {
+		for j := i; j > 0 && frames[j].At.Before(frames[j-1].At); j-- {
+			frames[j], frames[j-1] = frames[j-1], frames[j]
+		}
+	}
+}
+
+// Write stores frames as JSON lines with whole books, so an extract can be
+// shared and read back with Read
+func Write(w io.Writer, frames []Frame) error {
+	enc := json.NewEncoder(w)
+	for _, f := range frames {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PostMortem replays frames, usually from Extract, and annotates what the
+// opportunity's legs did and when discovery published, moved and withdrew
+// it. Withdrawals and cycles where it was not published explain what the
+// books showed against the thresholds.
+func PostMortem(frames []Frame, id string, cfg PostMortemConfig) []Mark {
+	canonical, venues, err := Legs(id)
+	if err != nil {
+		return nil
+	}
+	leg := func(exchange connector.ExchangeID) string {
+		if len(venues) == 2 && venues[0] != venues[1] {
+			if exchange == venues[0] {
+				return "long"
+			}
+			return "short"
+		}
+		return ""
+	}
+
+	var marks []Mark
+	lastBook := make(map[connector.ExchangeID]time.Time)
+	var published *spread.SpreadOpportunity
+	var missing string // Kind of reason last noted while unpublished, so it isn't repeated each cycle
+
+	fed := func(sd *spread.SpreadDiscovery, f Frame) {
+		exchange, _ := f.venue()
+		m := Mark{At: f.At, Kind: f.kind(), Leg: leg(exchange), Exchange: exchange}
+		switch {
+		case f.Orderbook != nil:
+			if prev, ok := lastBook[exchange]; ok && cfg.Gap > 0 && f.At.Sub(prev) > cfg.Gap {
+				marks = append(marks, Mark{At: f.At, Kind: MarkSilent, Leg: m.Leg, Exchange: exchange,
+					Note: fmt.Sprintf("no book for %s", f.At.Sub(prev).Round(time.Millisecond))})
+			}
+			lastBook[exchange] = f.At
+			m.Note = describeBook(f.Orderbook)
+		case f.Funding != nil:
+			m.Note = fmt.Sprintf("funding %.4f%%, next %s", f.Funding.FundingRate*100, f.Funding.NextFundingTime.UTC().Format(time.TimeOnly))
+		case f.Ticker != nil:
+			m.Note = fmt.Sprintf("ticker %g, 24h volume %.0f", f.Ticker.Price, f.Ticker.Volume24h)
+		}
+		marks = append(marks, m)
+	}
+
+	publish := func(sd *spread.SpreadDiscovery, at time.Time) {
+		var current *spread.SpreadOpportunity
+		for _, o := range sd.PublishedSpreads() {
+			if o.ID == id {
+				current = o
+				break
+			}
+		}
+		switch {
+		case current != nil && published == nil:
+			marks = append(marks, Mark{At: at, Kind: MarkPublished, Note: describeSpread(current), Spread: current})
+			missing = ""
+		case current != nil && moved(published, current, cfg.MoveBps):
+			marks = append(marks, Mark{At: at, Kind: MarkMoved, Note: describeSpread(current), Spread: current})
+		case current == nil:
+			why, reason := explain(sd, canonical, venues)
+			if published != nil {
+				marks = append(marks, Mark{At: at, Kind: MarkWithdrawn, Note: reason})
+			} else if why != missing && len(venues) == 2 {
+				marks = append(marks, Mark{At: at, Kind: MarkUnpublished, Note: reason})
+			}
+			missing = why
+		}
+		if current == nil || published == nil || moved(published, current, cfg.MoveBps) {
+			published = current
+		}
+	}
+
+	run(frames, cfg.Config, fed, publish)
+	return marks
+}
+
+func moved(prev, cur *spread.SpreadOpportunity, bps float64) bool {
+	return math.Abs(cur.SpreadBps-prev.SpreadBps) >= bps ||
+		math.Abs(cur.NetEdgeBps-prev.NetEdgeBps) >= bps ||
+		cur.Profitable != prev.Profitable
+}
+
+func describeBook(ob *connector.Orderbook) string {
+	var bid, ask connector.PriceLevel
+	if len(ob.Bids) > 0 {
+		bid = ob.Bids[0]
+	}
+	if len(ob.Asks) > 0 {
+		ask = ob.Asks[0]
+	}
+	note := fmt.Sprintf("bid %g x %g / ask %g x %g", bid.Price, bid.Quantity, ask.Price, ask.Quantity)
+	if !ob.Timestamp.IsZero() && !ob.ReceivedAt.IsZero() {
+		note += fmt.Sprintf(", lag %s", ob.ReceivedAt.Sub(ob.Timestamp).Round(time.Millisecond))
+	}
+	if ob.IsSnapshot {
+		note += ", snapshot"
+	}
+	return note
+}
+
+func describeSpread(o *spread.SpreadOpportunity) string {
+	return fmt.Sprintf("%.2f bps, net edge %.2f bps (effective %.2f), depth $%.0f, quote ages %.0f/%.0fms, score %.2f",
+		o.SpreadBps, o.NetEdgeBps, o.EffectiveEdgeBps, o.MinDepthUSD, o.LongQuoteAgeMs, o.ShortQuoteAgeMs, o.Score)
+}
+
+// explain says what the legs' books showed while the opportunity was not
+// published: a short kind of reason, and the reason with its numbers
+func explain(sd *spread.SpreadDiscovery, canonical string, venues []connector.ExchangeID) (string, string) {
+	if len(venues) != 2 {
+		return "unpublished", "not among the published spreads"
+	}
+	long := sd.Orderbook(canonical, venues[0])
+	short := sd.Orderbook(canonical, venues[1])
+	switch {
+	case long == nil || len(long.Asks) == 0 || long.Asks[0].Price <= 0:
+		return "no_long_book", "no long book"
+	case short == nil || len(short.Bids) == 0:
+		return "no_short_book", "no short book"
+	}
+	t := sd.Thresholds()
+	raw := (short.Bids[0].Price - long.Asks[0].Price) / long.Asks[0].Price * 10000
+	if raw < t.MinSpreadBps {
+		return "below_minimum", fmt.Sprintf("raw spread %.2f bps below the %.2f bps minimum", raw, t.MinSpreadBps)
+	}
+	if o := sd.GetSpread(canonical + ":" + string(venues[0]) + ":" + string(venues[1])); o != nil {
+		return "outside_top", fmt.Sprintf("tracked at %.2f bps but outside the published top, score %.2f", o.SpreadBps, o.Score)
+	}
+	return "rejected", fmt.Sprintf("raw spread %.2f bps but rejected: depth, freshness, tier threshold, quality or filter", raw)
+}
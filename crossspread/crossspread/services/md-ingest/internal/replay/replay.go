@@ -0,0 +1,441 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/archive"
+	"crossspread-md-ingest/internal/books"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Frame is one input to spread discovery as it arrived: a normalized book,
+// funding rate or ticker, and when it was received. Recordings store most
+// books as a Delta from the symbol's previous one; Read turns them back
+// into whole books.
+type Frame struct {
+	At        time.Time              `json:"at"`
+	Orderbook *connector.Orderbook   `json:"orderbook,omitempty"`
+	Delta     *books.Delta           `json:"delta,omitempty"`
+	Funding   *connector.FundingRate `json:"funding,omitempty"`
+	Ticker    *connector.PriceTicker `json:"ticker,omitempty"`
+}
+
+// Event is what one publish cycle emitted
+type Event struct {
+	At      time.Time                   `json:"at"`
+	Spreads []*spread.SpreadOpportunity `json:"spreads"`
+}
+
+// Recorder writes the frames fed to spread discovery as JSON lines, for a
+// window of time, so a live session can be replayed later
+type Recorder struct {
+	mu          sync.Mutex
+	w           *bufio.Writer
+	enc         *json.Encoder
+	closer      io.Closer
+	now         func() time.Time
+	until       time.Time
+	deltaConfig books.DeltaConfig
+	deltas      *books.DeltaEncoder
+	frames      int
+	closed      bool
+
+	// Rotation, for recorders made by NewRotating
+	dir      string
+	every    time.Duration
+	rotateAt time.Time
+	partial  string // File being written; renamed without the suffix once complete
+}
+
+// RotatedSuffix marks a rotated recording that is still being written.
+// Archivers and loaders skip files carrying it.
+const RotatedSuffix = ".partial"
+
+const rotatedLayout = "20060102T150405Z"
+
+// NewRecorder records frames to w, stamping them with now. A zero window
+// records until Close. Books are stored as snapshots and deltas with
+// books.DefaultDeltaConfig.
+func NewRecorder(w io.Writer, now func() time.Time, window time.Duration) *Recorder {
+	bw := bufio.NewWriter(w)
+	r := &Recorder{
+		w:           bw,
+		enc:         json.NewEncoder(bw),
+		now:         now,
+		deltaConfig: books.DefaultDeltaConfig(),
+		deltas:      books.NewDeltaEncoder(books.DefaultDeltaConfig()),
+	}
+	if window > 0 {
+		r.until = now().Add(window)
+	}
+	return r
+}
+
+// NewRotating records frames to a new file in dir every period, named
+// after the time it starts, e.g. frames-20260101T150000Z.jsonl. Each file
+// begins with whole books so it replays on its own, and is written under
+// RotatedSuffix until the next one starts. A zero window records until
+// Close.
+func NewRotating(dir string, now func() time.Time, every, window time.Duration) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		now:         now,
+		deltaConfig: books.DefaultDeltaConfig(),
+		dir:         dir,
+		every:       every,
+	}
+	start := now()
+	if window > 0 {
+		r.until = start.Add(window)
+	}
+	if err := r.open(start); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open starts the rotated file covering at. Caller holds r.mu or owns r.
+func (r *Recorder) open(at time.Time) error {
+	name := "frames-" + at.UTC().Format(rotatedLayout) + ".jsonl"
+	r.partial = filepath.Join(r.dir, name+RotatedSuffix)
+	f, err := os.Create(r.partial)
+	if err != nil {
+		return err
+	}
+	r.w = bufio.NewWriter(f)
+	r.enc = json.NewEncoder(r.w)
+	r.closer = f
+	r.deltas = books.NewDeltaEncoder(r.deltaConfig)
+	r.rotateAt = at.Truncate(r.every).Add(r.every)
+	return nil
+}
+
+// rotate completes the current file and starts the next. Caller holds r.mu.
+func (r *Recorder) rotate(at time.Time) error {
+	path := strings.TrimSuffix(r.partial, RotatedSuffix)
+	if err := r.finish(); err != nil {
+		return err
+	}
+	log.Info().Str("path", path).Int("frames", r.frames).Msg("Frame recording rotated")
+	r.frames = 0
+	return r.open(at)
+}
+
+// Create records frames to a new file at path for the given window
+func Create(path string, window time.Duration) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f, time.Now, window)
+	r.closer = f
+	return r, nil
+}
+
+// SetDeltaConfig changes how often books are stored whole. Call it before
+// the first frame.
+func (r *Recorder) SetDeltaConfig(config books.DeltaConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deltaConfig = config
+	r.deltas = books.NewDeltaEncoder(config)
+}
+
+// HandleOrderbook records a book update
+func (r *Recorder) HandleOrderbook(ob *connector.Orderbook) {
+	r.record(Frame{Orderbook: ob})
+}
+
+// HandleFundingRate records a funding rate
+func (r *Recorder) HandleFundingRate(fr *connector.FundingRate) {
+	r.record(Frame{Funding: fr})
+}
+
+// HandleTicker records a REST ticker
+func (r *Recorder) HandleTicker(ticker connector.PriceTicker) {
+	r.record(Frame{Ticker: &ticker})
+}
+
+func (r *Recorder) record(f Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	f.At = r.now()
+	if !r.until.IsZero() && f.At.After(r.until) {
+		r.close()
+		return
+	}
+	if r.every > 0 && !f.At.Before(r.rotateAt) {
+		if err := r.rotate(f.At); err != nil {
+			log.Error().Err(err).Str("dir", r.dir).Msg("Failed to rotate frame recording")
+			r.closed = true
+			return
+		}
+	}
+	if f.Orderbook != nil {
+		if d := r.deltas.Encode(f.Orderbook, f.At); d != nil {
+			f.Orderbook, f.Delta = nil, d
+		}
+	}
+	if err := r.enc.Encode(f); err != nil {
+		log.Error().Err(err).Msg("Failed to record frame")
+		return
+	}
+	r.frames++
+}
+
+// Close flushes the recording. Frames arriving afterwards are dropped.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	return r.close()
+}
+
+// close flushes and closes the output. Caller holds r.mu.
+func (r *Recorder) close() error {
+	r.closed = true
+	err := r.finish()
+	log.Info().Int("frames", r.frames).Msg("Frame recording finished")
+	return err
+}
+
+// finish flushes and closes the current output, completing a rotated
+// file. Caller holds r.mu.
+func (r *Recorder) finish() error {
+	err := r.w.Flush()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if r.partial != "" {
+		if rerr := os.Rename(r.partial, strings.TrimSuffix(r.partial, RotatedSuffix)); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// Read parses frames recorded as JSON lines, rebuilding books stored as deltas
+func Read(rd io.Reader) ([]Frame, error) {
+	var frames []Frame
+	rc := books.NewReconstructor()
+	dec := json.NewDecoder(rd)
+	for {
+		var f Frame
+		if err := dec.Decode(&f); err == io.EOF {
+			return frames, nil
+		} else if err != nil {
+			return frames, fmt.Errorf("frame %d: %w", len(frames)+1, err)
+		}
+		switch {
+		case f.Orderbook != nil:
+			rc.Snapshot(f.Orderbook)
+		case f.Delta != nil:
+			ob, err := rc.Apply(f.Delta)
+			if err != nil {
+				return frames, fmt.Errorf("frame %d: %w", len(frames)+1, err)
+			}
+			f.Orderbook, f.Delta = ob, nil
+		}
+		frames = append(frames, f)
+	}
+}
+
+// Load reads a recording from a file
+func Load(path string) ([]Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Recordings returns the completed rotated recordings in dir that may hold
+// frames within [from, to], oldest first. A file runs from the time in its
+// name until the next file starts.
+func Recordings(dir string, from, to time.Time) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type recording struct {
+		path  string
+		start time.Time
+	}
+	var all []recording
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "frames-") || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		start, err := time.Parse(rotatedLayout, strings.TrimSuffix(strings.TrimPrefix(name, "frames-"), ".jsonl"))
+		if err != nil {
+			continue
+		}
+		all = append(all, recording{filepath.Join(dir, name), start})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].start.Before(all[j].start) })
+
+	var paths []string
+	for i, r := range all {
+		if r.start.After(to) || (i+1 < len(all) && !all[i+1].start.After(from)) {
+			continue
+		}
+		paths = append(paths, r.path)
+	}
+	return paths, nil
+}
+
+// LoadAll reads consecutive recordings, such as rotated files, into one
+// sequence of frames
+func LoadAll(paths []string) ([]Frame, error) {
+	var frames []Frame
+	for _, path := range paths {
+		fs, err := Load(path)
+		if err != nil {
+			return frames, fmt.Errorf("%s: %w", path, err)
+		}
+		frames = append(frames, fs...)
+	}
+	return frames, nil
+}
+
+// Fetch downloads the archived recordings overlapping [from, to] into dir,
+// reusing files already there, and returns their paths oldest first
+func Fetch(ctx context.Context, store archive.Store, prefix string, from, to time.Time, dir string) ([]string, error) {
+	entries, err := archive.Find(ctx, store, prefix, from, to, ".jsonl")
+	if err != nil {
+		return nil, err
+	}
+	var recordings []archive.Entry
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, "frames-") {
+			recordings = append(recordings, e)
+		}
+	}
+	if len(recordings) == 0 {
+		return nil, fmt.Errorf("no recordings archived between %s and %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	return archive.Download(ctx, store, recordings, dir)
+}
+
+// Config controls a replay
+type Config struct {
+	PublishInterval time.Duration                 // Recorded time between publish cycles
+	Setup           func(*spread.SpreadDiscovery) // Applies thresholds, economics etc. before the first frame
+}
+
+// DefaultConfig publishes every 500ms, like live discovery
+func DefaultConfig() Config {
+	return Config{PublishInterval: 500 * time.Millisecond}
+}
+
+// Replay feeds frames in order through a fresh spread discovery whose clock
+// follows the recorded times, and returns what each publish cycle emitted.
+// Cycles fall on PublishInterval boundaries of recorded time, plus a last
+// one after the final frame. Replaying the same frames must always give the
+// same events.
+func Replay(frames []Frame, cfg Config) []Event {
+	var events []Event
+	run(frames, cfg, nil, func(sd *spread.SpreadDiscovery, at time.Time) {
+		events = append(events, Event{At: at, Spreads: sd.PublishedSpreads()})
+	})
+	return events
+}
+
+// run drives a replay, calling fed after each frame and publish at each
+// publish cycle with the discovery clock set to the cycle
+func run(frames []Frame, cfg Config, fed func(*spread.SpreadDiscovery, Frame), publish func(*spread.SpreadDiscovery, time.Time)) {
+	if cfg.PublishInterval <= 0 {
+		cfg.PublishInterval = DefaultConfig().PublishInterval
+	}
+	sd := spread.NewSpreadDiscovery(nil, nil)
+	var now time.Time
+	sd.SetClock(func() time.Time { return now })
+	if cfg.Setup != nil {
+		cfg.Setup(sd)
+	}
+	cycle := func(at time.Time) {
+		now = at
+		publish(sd, at)
+	}
+
+	var next time.Time
+	for _, f := range frames {
+		if next.IsZero() {
+			next = f.At.Truncate(cfg.PublishInterval).Add(cfg.PublishInterval)
+		}
+		for !f.At.Before(next) {
+			cycle(next)
+			next = next.Add(cfg.PublishInterval)
+		}
+		now = f.At
+		Feed(sd, f)
+		if fed != nil {
+			fed(sd, f)
+		}
+	}
+	if !next.IsZero() {
+		cycle(next)
+	}
+}
+
+// Feed hands one frame to spread discovery
+func Feed(sd *spread.SpreadDiscovery, f Frame) {
+	switch {
+	case f.Orderbook != nil:
+		sd.HandleOrderbook(f.Orderbook)
+	case f.Funding != nil:
+		sd.HandleFundingRate(f.Funding)
+	case f.Ticker != nil:
+		sd.HandleTicker(*f.Ticker)
+	}
+}
+
+// Diff returns an error describing the first difference between two
+// sequences of events, or nil if they are identical
+func Diff(want, got []Event) error {
+	for i := 0; i < min(len(want), len(got)); i++ {
+		if !want[i].At.Equal(got[i].At) {
+			return fmt.Errorf("event %d: published at %s, want %s", i, got[i].At, want[i].At)
+		}
+		if len(want[i].Spreads) != len(got[i].Spreads) {
+			return fmt.Errorf("event %d at %s: %d spreads, want %d", i, want[i].At, len(got[i].Spreads), len(want[i].Spreads))
+		}
+		for j := range want[i].Spreads {
+			w, _ := json.Marshal(want[i].Spreads[j])
+			g, _ := json.Marshal(got[i].Spreads[j])
+			if !bytes.Equal(w, g) {
+				return fmt.Errorf("event %d at %s, spread %d: got %s, want %s", i, want[i].At, j, g, w)
+			}
+		}
+	}
+	if len(want) != len(got) {
+		return fmt.Errorf("%d events, want %d", len(got), len(want))
+	}
+	return nil
+}
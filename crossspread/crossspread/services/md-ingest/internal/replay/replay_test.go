@@ -0,0 +1,334 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"crossspread-md-ingest/internal/archive"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/spread"
+)
+
+var venues = []connector.ExchangeID{
+	connector.Binance, connector.Bybit, connector.OKX, connector.KuCoin, connector.MEXC, connector.Bitget,
+}
+
+func setup(sd *spread.SpreadDiscovery) {
+	sd.SetThresholds(spread.Thresholds{MinSpreadBps: 1, MinDepthUSD: 100})
+	sd.MarkNewListing("SOL", time.Date(2026, 1, 1, 0, 0, 3, 0, time.UTC))
+}
+
+// session generates a few seconds of market data. Prices move on a coarse
+// tick so many venue pairs tie on score, and several venues quote without
+// an exchange timestamp.
+func session() []Frame {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mids := map[string]float64{"BTC": 60000, "ETH": 3000, "SOL": 150}
+	canonicals := []string{"BTC", "ETH", "SOL"}
+
+	var frames []Frame
+	for _, venue := range venues {
+		for _, canonical := range canonicals {
+			frames = append(frames, Frame{At: start, Ticker: &connector.PriceTicker{
+				ExchangeID: venue, Symbol: canonical + "USDT", Canonical: canonical,
+				Price: mids[canonical], Volume24h: 1e6,
+			}})
+		}
+	}
+
+	at := start
+	for i := 0; i < 2000; i++ {
+		at = at.Add(time.Duration(rng.Intn(5)) * time.Millisecond)
+		venue := venues[rng.Intn(len(venues))]
+		canonical := canonicals[rng.Intn(len(canonicals))]
+
+		if i%97 == 0 {
+			frames = append(frames, Frame{At: at, Funding: &connector.FundingRate{
+				ExchangeID: venue, Symbol: canonical + "USDT", Canonical: canonical,
+				FundingRate: float64(rng.Intn(3)) / 10000, Timestamp: at,
+			}})
+			continue
+		}
+
+		mid := mids[canonical]
+		tick := mid / 10000
+		bid := mid + tick*float64(rng.Intn(7)-3)
+		ask := bid + tick
+		ob := &connector.Orderbook{
+			ExchangeID: venue,
+			Symbol:     canonical + "USDT",
+			Canonical:  canonical,
+			ReceivedAt: at,
+		}
+		if venue != connector.MEXC && venue != connector.KuCoin {
+			ob.Timestamp = at.Add(-time.Duration(rng.Intn(20)) * time.Millisecond)
+		}
+		for l := 0; l < 5; l++ {
+			ob.Bids = append(ob.Bids, connector.PriceLevel{Price: bid - tick*float64(l), Quantity: 10})
+			ob.Asks = append(ob.Asks, connector.PriceLevel{Price: ask + tick*float64(l), Quantity: 10})
+		}
+		frames = append(frames, Frame{At: at, Orderbook: ob})
+	}
+	return frames
+}
+
+// live runs frames through spread discovery the way the ingest process
+// does, recording them as they are handled, and returns the recording and
+// the events published
+func live(t *testing.T, frames []Frame, cfg Config) ([]byte, []Event) {
+	t.Helper()
+
+	var now time.Time
+	clock := func() time.Time { return now }
+	sd := spread.NewSpreadDiscovery(nil, nil)
+	sd.SetClock(clock)
+	setup(sd)
+
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, clock, 0)
+
+	var events []Event
+	next := frames[0].At.Truncate(cfg.PublishInterval).Add(cfg.PublishInterval)
+	for _, f := range frames {
+		for !f.At.Before(next) {
+			now = next
+			events = append(events, Event{At: next, Spreads: sd.PublishedSpreads()})
+			next = next.Add(cfg.PublishInterval)
+		}
+		now = f.At
+		switch {
+		case f.Orderbook != nil:
+			rec.HandleOrderbook(f.Orderbook)
+			sd.HandleOrderbook(f.Orderbook)
+		case f.Funding != nil:
+			rec.HandleFundingRate(f.Funding)
+			sd.HandleFundingRate(f.Funding)
+		case f.Ticker != nil:
+			rec.HandleTicker(*f.Ticker)
+			sd.HandleTicker(*f.Ticker)
+		}
+	}
+	now = next
+	events = append(events, Event{At: next, Spreads: sd.PublishedSpreads()})
+
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), events
+}
+
+func TestReplayMatchesLive(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Setup = setup
+
+	recording, want := live(t, session(), cfg)
+	frames, err := Read(bytes.NewReader(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := 0
+	for _, e := range want {
+		published += len(e.Spreads)
+	}
+	if published == 0 {
+		t.Fatal("live session published no spreads")
+	}
+
+	// Map iteration order differs between runs, so replay several times
+	for run := 0; run < 5; run++ {
+		if err := Diff(want, Replay(frames, cfg)); err != nil {
+			t.Fatalf("replay %d differs from live: %v", run, err)
+		}
+	}
+}
+
+func TestRecorderWindow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, func() time.Time { return now }, time.Second)
+
+	for i := 0; i < 4; i++ {
+		now = start.Add(time.Duration(i) * 400 * time.Millisecond)
+		rec.HandleFundingRate(&connector.FundingRate{ExchangeID: connector.Binance, Canonical: "BTC", Timestamp: now})
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("recorded %d frames in a 1s window, want 3", len(frames))
+	}
+}
+
+// TestReplayCapture replays a recording taken with the ingest --record flag
+// twice and checks both runs agree, e.g.
+//
+//	REPLAY_FRAMES=frames.jsonl go test ./internal/replay -run Capture
+//
+// Rotated recordings archived to object storage are fetched by time range
+// into REPLAY_DIR (default a temporary directory) and replayed in order,
+// with the ingest ARCHIVE_* settings locating the bucket:
+//
+//	ARCHIVE_BUCKET=md-history REPLAY_ARCHIVE_FROM=2026-01-01T15:00:00Z \
+//	    REPLAY_ARCHIVE_TO=2026-01-01T17:00:00Z go test ./internal/replay -run Capture
+func TestReplayCapture(t *testing.T) {
+	path := os.Getenv("REPLAY_FRAMES")
+	paths := []string{path}
+	if from := os.Getenv("REPLAY_ARCHIVE_FROM"); from != "" {
+		paths = fetchArchived(t, from, os.Getenv("REPLAY_ARCHIVE_TO"))
+		path = strings.Join(paths, ", ")
+	} else if path == "" {
+		t.Skip("REPLAY_FRAMES not set")
+	}
+	frames, err := LoadAll(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	if err := Diff(Replay(frames, cfg), Replay(frames, cfg)); err != nil {
+		t.Fatalf("replays of %s differ: %v", path, err)
+	}
+}
+
+// fetchArchived downloads the recordings archived in a time range
+func fetchArchived(t *testing.T, from, to string) []string {
+	t.Helper()
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		t.Fatal("REPLAY_ARCHIVE_FROM:", err)
+	}
+	end := start.Add(time.Hour)
+	if to != "" {
+		if end, err = time.Parse(time.RFC3339, to); err != nil {
+			t.Fatal("REPLAY_ARCHIVE_TO:", err)
+		}
+	}
+	getEnv := func(key, fallback string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fallback
+	}
+	bucket, err := archive.NewS3(archive.S3Config{
+		Endpoint:  os.Getenv("ARCHIVE_ENDPOINT"),
+		Region:    getEnv("ARCHIVE_REGION", "us-east-1"),
+		Bucket:    os.Getenv("ARCHIVE_BUCKET"),
+		AccessKey: getEnv("ARCHIVE_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		SecretKey: getEnv("ARCHIVE_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := os.Getenv("REPLAY_DIR")
+	if dir == "" {
+		dir = t.TempDir()
+	}
+	paths, err := Fetch(context.Background(), bucket, getEnv("ARCHIVE_PREFIX", archive.DefaultConfig().Prefix), start, end, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestRotatingRecorder(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	rec, err := NewRotating(dir, func() time.Time { return now }, time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := session()
+	for _, f := range frames {
+		// Stretch the few seconds of session over three minutes
+		now = start.Add(f.At.Sub(start) * 30)
+		switch {
+		case f.Orderbook != nil:
+			rec.HandleOrderbook(f.Orderbook)
+		case f.Funding != nil:
+			rec.HandleFundingRate(f.Funding)
+		case f.Ticker != nil:
+			rec.HandleTicker(*f.Ticker)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), RotatedSuffix) {
+			t.Errorf("%s left incomplete", e.Name())
+		}
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+	if len(paths) < 2 {
+		t.Fatalf("recorded %d files, want one per minute", len(paths))
+	}
+	loaded, err := LoadAll(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(frames) {
+		t.Fatalf("loaded %d frames from %d files, want %d", len(loaded), len(paths), len(frames))
+	}
+}
+
+func TestPostMortem(t *testing.T) {
+	frames := session()
+	cfg := DefaultPostMortemConfig()
+	cfg.Setup = setup
+
+	var id string
+	var at time.Time
+	for _, e := range Replay(frames, cfg.Config) {
+		if len(e.Spreads) > 0 {
+			id, at = e.Spreads[0].ID, e.At
+			break
+		}
+	}
+	if id == "" {
+		t.Fatal("session published no spreads")
+	}
+
+	canonical, venues, err := Legs(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extracted := Extract(frames, canonical, venues, at.Add(-time.Second), at.Add(time.Second))
+	for _, f := range extracted {
+		if exchange, c := f.venue(); c != canonical || (exchange != venues[0] && exchange != venues[1]) {
+			t.Fatalf("extracted a frame of %s on %s for %s", c, exchange, id)
+		}
+	}
+
+	published := false
+	for _, m := range PostMortem(extracted, id, cfg) {
+		if m.Kind == MarkPublished && m.Spread.ID == id {
+			published = true
+		}
+		if m.Kind == MarkBook && m.Leg == "" {
+			t.Errorf("book mark without a leg: %+v", m)
+		}
+	}
+	if !published {
+		t.Errorf("timeline of %s at %s never marks it published", id, at)
+	}
+}
@@ -0,0 +1,362 @@
+package restcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/apiversion"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Lookup results
+const (
+	ResultHit    = "hit"    // Served from disk within the TTL
+	ResultMiss   = "miss"   // Fetched and stored
+	ResultStale  = "stale"  // Fetch failed; an expired copy was served
+	ResultBypass = "bypass" // Caller asked for a fresh copy, which was stored
+)
+
+// Rule gives the responses of one endpoint a TTL
+type Rule struct {
+	Path string        // Matched against the end of the request path
+	TTL  time.Duration // How long a stored response is served without a fetch
+}
+
+// Config controls the cache
+type Config struct {
+	Dir   string
+	Rules []Rule
+	// MaxStale is how old an expired copy may be and still be served when
+	// the venue fails or rate limits the fetch; zero never serves stale
+	MaxStale time.Duration
+	MaxBytes int64 // Larger responses are passed through uncached
+}
+
+// DefaultConfig caches the venues' contract and instrument lists for ten
+// minutes and their asset lists, which carry deposit and withdrawal state,
+// for two. Signed endpoints are left out: their query changes every call.
+func DefaultConfig() Config {
+	contracts := 10 * time.Minute
+	assets := 2 * time.Minute
+	return Config{
+		Dir: filepath.Join(os.TempDir(), "md-ingest-restcache"),
+		Rules: []Rule{
+			{Path: "/fapi/v1/exchangeInfo", TTL: contracts},                  // Binance
+			{Path: "/v5/market/instruments-info", TTL: contracts},            // Bybit
+			{Path: "/api/v5/public/instruments", TTL: contracts},             // OKX
+			{Path: "/api/v1/contracts/active", TTL: contracts},               // KuCoin
+			{Path: "/api/v1/contract/detail", TTL: contracts},                // MEXC
+			{Path: "/api/v2/mix/market/contracts", TTL: contracts},           // Bitget
+			{Path: "/futures/usdt/contracts", TTL: contracts},                // Gate
+			{Path: "/openApi/swap/v2/quote/contracts", TTL: contracts},       // BingX
+			{Path: "/futures/market", TTL: contracts},                        // CoinEx
+			{Path: "/cfd/openApi/v1/pub/instrument", TTL: contracts},         // LBank
+			{Path: "/linear-swap-api/v1/swap_contract_info", TTL: contracts}, // HTX
+			{Path: "/api/v4/public/markets", TTL: contracts},                 // WhiteBIT
+			{Path: "/contract/public/details", TTL: contracts},               // BitMart
+			{Path: "/future/market/v1/public/symbol/list", TTL: contracts},   // XT
+			{Path: "/fapi/v1/contracts", TTL: contracts},                     // Bitrue
+			{Path: "/api/v2/public/get_instruments", TTL: contracts},         // Deribit
+			{Path: "/spot/currencies", TTL: assets},                          // Gate
+			{Path: "/v2/assetConfigs.do", TTL: assets},                       // LBank
+			{Path: "/api/v4/public/assets", TTL: assets},                     // WhiteBIT
+			{Path: "/account/v1/currencies", TTL: assets},                    // BitMart
+			{Path: "/api/v2/public/get_currencies", TTL: assets},             // Deribit
+		},
+		MaxStale: 24 * time.Hour,
+		MaxBytes: 32 << 20,
+	}
+}
+
+// ParseTTLs overrides rule TTLs or adds rules, e.g.
+// "/fapi/v1/exchangeInfo=30m,/api/v4/public/assets=1m"
+func ParseTTLs(config Config, s string) (Config, error) {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		path, value, ok := strings.Cut(part, "=")
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || !strings.HasPrefix(path, "/") || err != nil || ttl < 0 {
+			return config, fmt.Errorf("invalid cache TTL %q: want /path=duration", part)
+		}
+		found := false
+		for i := range config.Rules {
+			if config.Rules[i].Path == path {
+				config.Rules[i].TTL, found = ttl, true
+			}
+		}
+		if !found {
+			config.Rules = append(config.Rules, Rule{Path: path, TTL: ttl})
+		}
+	}
+	return config, nil
+}
+
+// entry is a stored response
+type entry struct {
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body"`
+	StoredAt    time.Time `json:"stored_at"`
+}
+
+// Entry describes a stored response
+type Entry struct {
+	URL        string        `json:"url"`
+	Exchange   string        `json:"exchange,omitempty"`
+	Bytes      int           `json:"bytes"`
+	StoredAt   time.Time     `json:"stored_at"`
+	TTL        time.Duration `json:"-"`
+	TTLSeconds int64         `json:"ttl_seconds"`
+	Fresh      bool          `json:"fresh"` // Still served without a fetch
+}
+
+// Cache keeps the venues' metadata responses on disk, so a restarted
+// instance reads them back instead of fetching every venue's instrument
+// and asset lists again. A crash loop otherwise repeats those fetches on
+// every start, which has got the host's IP rate limited.
+type Cache struct {
+	config Config
+}
+
+// New creates a cache storing responses in config.Dir
+func New(config Config) (*Cache, error) {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{config: config}, nil
+}
+
+type bypassKey struct{}
+
+// Fresh marks a request context so the response is fetched even when a
+// stored copy is within its TTL, for callers polling for changes such as
+// new listings. The fetched response is still stored.
+func Fresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Transport wraps base so GET requests to the configured endpoints are
+// served from disk within their TTL. Install it as http.DefaultTransport,
+// outermost, so cache hits skip the health and API version tracking of
+// calls that were never made.
+func (c *Cache) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{cache: c, base: base}
+}
+
+type transport struct {
+	cache *Cache
+	base  http.RoundTripper
+}
+
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ttl, ok := tr.cache.ttl(req)
+	if !ok {
+		return tr.base.RoundTrip(req)
+	}
+	exchange, _ := apiversion.ExchangeForHost(req.URL.Hostname())
+	key := req.URL.String()
+	stored, _ := tr.cache.load(key)
+	bypass, _ := req.Context().Value(bypassKey{}).(bool)
+
+	if stored != nil && !bypass && time.Since(stored.StoredAt) < ttl {
+		metrics.RESTCacheRequests.WithLabelValues(string(exchange), ResultHit).Inc()
+		return stored.response(req), nil
+	}
+
+	resp, err := tr.base.RoundTrip(req)
+	if failed(resp, err) {
+		if stored != nil && tr.cache.config.MaxStale > 0 && time.Since(stored.StoredAt) < tr.cache.config.MaxStale {
+			if err == nil {
+				resp.Body.Close()
+			}
+			metrics.RESTCacheRequests.WithLabelValues(string(exchange), ResultStale).Inc()
+			log.Warn().Err(err).Str("url", key).Dur("age", time.Since(stored.StoredAt)).
+				Msg("Venue metadata fetch failed, serving cached copy")
+			return stored.response(req), nil
+		}
+		return resp, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, tr.cache.config.MaxBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > tr.cache.config.MaxBytes {
+		// Passed on whole but not stored
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := tr.cache.store(&entry{
+		URL:         key,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		StoredAt:    time.Now(),
+	}); err != nil {
+		log.Warn().Err(err).Str("url", key).Msg("Failed to cache venue metadata")
+	}
+	result := ResultMiss
+	if bypass {
+		result = ResultBypass
+	}
+	metrics.RESTCacheRequests.WithLabelValues(string(exchange), result).Inc()
+	return resp, nil
+}
+
+// failed reports a fetch the venue did not answer usefully. Binance answers
+// 418 once an IP is banned for ignoring 429s.
+func failed(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot || resp.StatusCode >= 500
+}
+
+func (e *entry) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	header.Set("X-Cache", "HIT")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// ttl returns the TTL of the request's endpoint; false when it is not cached
+func (c *Cache) ttl(req *http.Request) (time.Duration, bool) {
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" {
+		return 0, false
+	}
+	for _, rule := range c.config.Rules {
+		if strings.HasSuffix(req.URL.Path, rule.Path) {
+			return rule.TTL, rule.TTL > 0
+		}
+	}
+	return 0, false
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.config.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) load(key string) (*entry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != key {
+		return nil, fmt.Errorf("unreadable cache entry for %s", key)
+	}
+	return &e, nil
+}
+
+// store writes through a temporary file so a crash never leaves a torn entry
+func (c *Cache) store(e *entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.config.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(e.URL))
+}
+
+// Entries lists the stored responses, newest first
+func (c *Cache) Entries() []Entry {
+	files, _ := filepath.Glob(filepath.Join(c.config.Dir, "*.json"))
+	result := make([]Entry, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+		req, err := http.NewRequest(http.MethodGet, e.URL, nil)
+		if err != nil {
+			continue
+		}
+		ttl, _ := c.ttl(req)
+		exchange, _ := apiversion.ExchangeForHost(req.URL.Hostname())
+		result = append(result, Entry{
+			URL:        e.URL,
+			Exchange:   string(exchange),
+			Bytes:      len(e.Body),
+			StoredAt:   e.StoredAt,
+			TTL:        ttl,
+			TTLSeconds: int64(ttl.Seconds()),
+			Fresh:      time.Since(e.StoredAt) < ttl,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StoredAt.After(result[j].StoredAt) })
+	return result
+}
+
+// Purge deletes every stored response whose URL contains match, or all of
+// them when match is empty, and returns how many were deleted
+func (c *Cache) Purge(match string) int {
+	files, _ := filepath.Glob(filepath.Join(c.config.Dir, "*.json"))
+	n := 0
+	for _, file := range files {
+		if match != "" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			var e entry
+			if json.Unmarshal(data, &e) == nil && !strings.Contains(e.URL, match) {
+				continue
+			}
+		}
+		if os.Remove(file) == nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,505 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/filterexpr"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Parameters tunable at runtime. Tier thresholds are named by TierParam.
+const (
+	MinSpreadBps = "spread.min_spread_bps"
+	MinDepthUSD  = "spread.min_depth_usd"
+)
+
+// Tier threshold names, the last part of a TierParam
+const (
+	TierMinSpreadBps = "min_spread_bps"
+	TierMinDepthUSD  = "min_depth_usd"
+)
+
+// Sections announced on the changed channel
+const (
+	SectionParams = "params"
+	SectionMutes  = "mutes"
+	SectionTiers  = "tiers"
+	SectionFilter = "filter"
+)
+
+// TierParam returns the parameter holding a tier's threshold, e.g.
+// tier.major.min_spread_bps
+func TierParam(tier, threshold string) string {
+	return "tier." + tier + "." + threshold
+}
+
+// Target receives the settings; *spread.SpreadDiscovery implements it
+type Target interface {
+	Thresholds() spread.Thresholds
+	SetThresholds(t spread.Thresholds)
+	SetSymbolTiers(tiers map[string]spread.Thresholds, symbols map[string]string)
+	SyncMutes(mutes []spread.PairMute, by string)
+	SetFilter(expr *filterexpr.Expr)
+}
+
+// Config controls the settings store
+type Config struct {
+	Env             string        // Environment whose settings are read, e.g. dev, staging, prod
+	RefreshInterval time.Duration // Poll interval, in case a write isn't announced
+}
+
+// DefaultConfig polls every 10s; announced writes apply at once
+func DefaultConfig() Config {
+	return Config{
+		Env:             "dev",
+		RefreshInterval: 10 * time.Second,
+	}
+}
+
+// Param is a parameter's effective value
+type Param struct {
+	Name       string  `json:"name"`
+	Value      float64 `json:"value"`
+	Default    float64 `json:"default"`
+	Overridden bool    `json:"overridden"`
+}
+
+// State is the settings last applied
+type State struct {
+	Env         string                       `json:"env"`
+	Params      []Param                      `json:"params"`
+	Tiers       map[string]spread.Thresholds `json:"tiers"`
+	Symbols     map[string]string            `json:"symbols"` // Canonical -> tier
+	Mutes       []spread.PairMute            `json:"mutes"`
+	Filter      string                       `json:"filter,omitempty"` // Opportunity filter expression
+	RefreshedAt time.Time                    `json:"refreshed_at"`
+}
+
+// Store applies runtime settings kept in the environment's Redis keys:
+// parameter overrides, symbol tiers, exchange pair mutes and the
+// opportunity filter expression. The backend,
+// the admin API or another instance writes them and announces the write on
+// the changed channel; every instance reloads within seconds, without a
+// restart or mounted files. Settings keep their last known values while
+// Redis is unreachable.
+type Store struct {
+	client   *redis.Client
+	config   Config
+	target   Target
+	defaults spread.Thresholds
+
+	mu          sync.RWMutex
+	params      map[string]float64
+	tiers       map[string]spread.Thresholds
+	symbols     map[string]string
+	mutes       []spread.PairMute
+	filter      string
+	refreshedAt time.Time
+	done        chan struct{}
+}
+
+// New creates a settings store. The target's current thresholds are the
+// defaults parameters revert to when their override is cleared.
+func New(client *redis.Client, config Config, target Target) *Store {
+	return &Store{
+		client:   client,
+		config:   config,
+		target:   target,
+		defaults: target.Thresholds(),
+		params:   make(map[string]float64),
+		tiers:    make(map[string]spread.Thresholds),
+		symbols:  make(map[string]string),
+		done:     make(chan struct{}),
+	}
+}
+
+// Env returns the environment the store reads
+func (s *Store) Env() string {
+	return s.config.Env
+}
+
+// Refresh re-reads every section and applies what changed. Invalid values
+// are skipped so a typo can't zero a threshold; expired mutes are deleted.
+func (s *Store) Refresh(ctx context.Context, trigger string) error {
+	pipe := s.client.Pipeline()
+	rawParams := pipe.HGetAll(ctx, keyspace.SettingsKey(s.config.Env))
+	rawTiers := pipe.HGetAll(ctx, keyspace.SettingsTiersKey(s.config.Env))
+	rawMutes := pipe.HGetAll(ctx, keyspace.SettingsMutesKey(s.config.Env))
+	rawFilter := pipe.Get(ctx, keyspace.SettingsFilterKey(s.config.Env))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		metrics.SettingsReloads.WithLabelValues(trigger, "error").Inc()
+		return err
+	}
+
+	params := make(map[string]float64)
+	for name, v := range rawParams.Val() {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value < 0 || !validParam(name) {
+			log.Warn().Str("param", name).Str("value", v).Msg("Ignoring invalid runtime setting")
+			continue
+		}
+		params[name] = value
+	}
+
+	tiers := make(map[string]spread.Thresholds)
+	for name, value := range params {
+		tier, threshold, ok := splitTierParam(name)
+		if !ok {
+			continue
+		}
+		t := tiers[tier]
+		switch threshold {
+		case TierMinSpreadBps:
+			t.MinSpreadBps = value
+		case TierMinDepthUSD:
+			t.MinDepthUSD = value
+		}
+		tiers[tier] = t
+	}
+
+	symbols := make(map[string]string)
+	for canonical, tier := range rawTiers.Val() {
+		if tier == "" {
+			continue
+		}
+		symbols[strings.ToUpper(canonical)] = tier
+	}
+
+	now := time.Now()
+	var mutes []spread.PairMute
+	var expired []string
+	for id, v := range rawMutes.Val() {
+		var m spread.PairMute
+		if err := json.Unmarshal([]byte(v), &m); err != nil {
+			log.Warn().Err(err).Str("mute", id).Msg("Ignoring invalid shared mute")
+			continue
+		}
+		if !m.Until.IsZero() && !now.Before(m.Until) {
+			expired = append(expired, id)
+			continue
+		}
+		mutes = append(mutes, m)
+	}
+	sort.Slice(mutes, func(i, j int) bool {
+		return spread.MuteID(mutes[i].Long, mutes[i].Short) < spread.MuteID(mutes[j].Long, mutes[j].Short)
+	})
+	if len(expired) > 0 {
+		if err := s.client.HDel(ctx, keyspace.SettingsMutesKey(s.config.Env), expired...).Err(); err != nil {
+			log.Warn().Err(err).Msg("Failed to delete expired shared mutes")
+		}
+	}
+
+	// An invalid filter keeps the one in force rather than publishing everything
+	filter := rawFilter.Val()
+	var filterExpr *filterexpr.Expr
+	if filter != "" {
+		expr, err := spread.CompileFilter(filter)
+		if err != nil {
+			log.Warn().Err(err).Str("filter", filter).Msg("Ignoring invalid filter expression")
+			s.mu.RLock()
+			filter = s.filter
+			s.mu.RUnlock()
+		} else {
+			filterExpr = expr
+		}
+	}
+
+	s.mu.Lock()
+	paramsChanged := !reflect.DeepEqual(params, s.params) || s.refreshedAt.IsZero()
+	tiersChanged := !reflect.DeepEqual(tiers, s.tiers) || !reflect.DeepEqual(symbols, s.symbols)
+	mutesChanged := !sameMutes(mutes, s.mutes)
+	filterChanged := filter != s.filter
+	s.params = params
+	s.tiers = tiers
+	s.symbols = symbols
+	s.mutes = mutes
+	s.filter = filter
+	s.refreshedAt = now
+	defaults := s.defaults
+	s.mu.Unlock()
+
+	if paramsChanged {
+		t := withParams(defaults, params)
+		s.target.SetThresholds(t)
+		metrics.SettingsChanges.WithLabelValues(SectionParams).Inc()
+		log.Info().
+			Str("env", s.config.Env).
+			Float64("min_spread_bps", t.MinSpreadBps).
+			Float64("min_depth_usd", t.MinDepthUSD).
+			Int("overrides", len(params)).
+			Msg("Runtime parameters applied")
+	}
+	if paramsChanged || tiersChanged {
+		s.target.SetSymbolTiers(tiers, symbols)
+		if tiersChanged {
+			metrics.SettingsChanges.WithLabelValues(SectionTiers).Inc()
+			log.Info().Str("env", s.config.Env).Int("tiers", len(tiers)).Int("symbols", len(symbols)).Msg("Symbol tiers applied")
+		}
+	}
+	if mutesChanged {
+		s.target.SyncMutes(mutes, "settings")
+		metrics.SettingsChanges.WithLabelValues(SectionMutes).Inc()
+	}
+	if filterChanged {
+		s.target.SetFilter(filterExpr)
+		metrics.SettingsChanges.WithLabelValues(SectionFilter).Inc()
+		log.Info().Str("env", s.config.Env).Str("filter", filter).Msg("Opportunity filter applied")
+	}
+
+	metrics.SettingsReloads.WithLabelValues(trigger, "ok").Inc()
+	return nil
+}
+
+// SetDefaults replaces the thresholds parameters revert to, e.g. when the
+// config file is reloaded, and applies them under the current overrides
+func (s *Store) SetDefaults(t spread.Thresholds) {
+	s.mu.Lock()
+	s.defaults = t
+	params := s.params
+	s.mu.Unlock()
+	s.target.SetThresholds(withParams(t, params))
+}
+
+// withParams returns the default thresholds with parameter overrides applied
+func withParams(t spread.Thresholds, params map[string]float64) spread.Thresholds {
+	if v, ok := params[MinSpreadBps]; ok {
+		t.MinSpreadBps = v
+	}
+	if v, ok := params[MinDepthUSD]; ok {
+		t.MinDepthUSD = v
+	}
+	return t
+}
+
+// Start watches the changed channel and polls until the context is
+// cancelled or Stop is called
+func (s *Store) Start(ctx context.Context) {
+	sub := s.client.Subscribe(ctx, keyspace.SettingsChangedKey(s.config.Env))
+	defer sub.Close()
+	changed := sub.Channel()
+
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case msg := <-changed:
+			if err := s.Refresh(ctx, "watch"); err != nil {
+				log.Warn().Err(err).Str("section", msg.Payload).Msg("Failed to reload runtime settings")
+			}
+		case <-ticker.C:
+			if err := s.Refresh(ctx, "poll"); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh runtime settings")
+			}
+		}
+	}
+}
+
+// Stop stops watching
+func (s *Store) Stop() {
+	close(s.done)
+}
+
+// SetParam overrides a parameter for the environment
+func (s *Store) SetParam(ctx context.Context, name string, value float64) error {
+	if !validParam(name) {
+		return fmt.Errorf("settings: unknown parameter %q", name)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+		return fmt.Errorf("settings: %s must be a non-negative number", name)
+	}
+	if err := s.client.HSet(ctx, keyspace.SettingsKey(s.config.Env), name, strconv.FormatFloat(value, 'f', -1, 64)).Err(); err != nil {
+		return err
+	}
+	return s.written(ctx, SectionParams)
+}
+
+// ClearParam removes a parameter's override so it reverts to its default
+func (s *Store) ClearParam(ctx context.Context, name string) error {
+	if err := s.client.HDel(ctx, keyspace.SettingsKey(s.config.Env), name).Err(); err != nil {
+		return err
+	}
+	return s.written(ctx, SectionParams)
+}
+
+// SetTier assigns a canonical symbol to a tier
+func (s *Store) SetTier(ctx context.Context, canonical, tier string) error {
+	if canonical == "" || tier == "" || strings.Contains(tier, ".") {
+		return fmt.Errorf("settings: canonical and a tier name without dots are required")
+	}
+	if err := s.client.HSet(ctx, keyspace.SettingsTiersKey(s.config.Env), strings.ToUpper(canonical), tier).Err(); err != nil {
+		return err
+	}
+	return s.written(ctx, SectionTiers)
+}
+
+// ClearTier returns a canonical symbol to the default thresholds
+func (s *Store) ClearTier(ctx context.Context, canonical string) error {
+	if err := s.client.HDel(ctx, keyspace.SettingsTiersKey(s.config.Env), strings.ToUpper(canonical)).Err(); err != nil {
+		return err
+	}
+	return s.written(ctx, SectionTiers)
+}
+
+// SetFilter publishes only opportunities matching expr in every instance
+// of the environment. The expression is compiled first so a typo is
+// rejected instead of stored.
+func (s *Store) SetFilter(ctx context.Context, expr string) error {
+	if _, err := spread.CompileFilter(expr); err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, keyspace.SettingsFilterKey(s.config.Env), expr, 0).Err(); err != nil {
+		return err
+	}
+	return s.written(ctx, SectionFilter)
+}
+
+// ClearFilter removes the filter so every opportunity is published
+func (s *Store) ClearFilter(ctx context.Context) error {
+	if err := s.client.Del(ctx, keyspace.SettingsFilterKey(s.config.Env)).Err(); err != nil {
+		return err
+	}
+	return s.written(ctx, SectionFilter)
+}
+
+// SaveMute shares a mute with every instance of the environment
+func (s *Store) SaveMute(ctx context.Context, m spread.PairMute) error {
+	m.ID = spread.MuteID(m.Long, m.Short)
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := s.client.HSet(ctx, keyspace.SettingsMutesKey(s.config.Env), m.ID, data).Err(); err != nil {
+		return err
+	}
+	s.notify(ctx, SectionMutes)
+	return nil
+}
+
+// DeleteMute removes a shared mute. Returns false if it wasn't stored.
+func (s *Store) DeleteMute(ctx context.Context, id string) (bool, error) {
+	n, err := s.client.HDel(ctx, keyspace.SettingsMutesKey(s.config.Env), id).Result()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		s.notify(ctx, SectionMutes)
+	}
+	return n > 0, nil
+}
+
+// State returns the settings last applied
+func (s *Store) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	defaults := map[string]float64{
+		MinSpreadBps: s.defaults.MinSpreadBps,
+		MinDepthUSD:  s.defaults.MinDepthUSD,
+	}
+	names := make(map[string]bool, len(defaults)+len(s.params))
+	for name := range defaults {
+		names[name] = true
+	}
+	for name := range s.params {
+		names[name] = true
+	}
+
+	params := make([]Param, 0, len(names))
+	for name := range names {
+		p := Param{Name: name, Default: defaults[name]}
+		p.Value, p.Overridden = s.params[name]
+		if !p.Overridden {
+			p.Value = p.Default
+		}
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+
+	tiers := make(map[string]spread.Thresholds, len(s.tiers))
+	for name, t := range s.tiers {
+		tiers[name] = t
+	}
+	symbols := make(map[string]string, len(s.symbols))
+	for canonical, tier := range s.symbols {
+		symbols[canonical] = tier
+	}
+	return State{
+		Env:         s.config.Env,
+		Params:      params,
+		Tiers:       tiers,
+		Symbols:     symbols,
+		Mutes:       append([]spread.PairMute{}, s.mutes...),
+		Filter:      s.filter,
+		RefreshedAt: s.refreshedAt,
+	}
+}
+
+// written announces a write and applies it locally without waiting for
+// the announcement
+func (s *Store) written(ctx context.Context, section string) error {
+	s.notify(ctx, section)
+	return s.Refresh(ctx, "write")
+}
+
+// notify announces a write on the changed channel. Watchers that miss it
+// still pick the write up on their next poll.
+func (s *Store) notify(ctx context.Context, section string) {
+	if err := s.client.Publish(ctx, keyspace.SettingsChangedKey(s.config.Env), section).Err(); err != nil {
+		log.Warn().Err(err).Str("section", section).Msg("Failed to announce settings change")
+	}
+}
+
+// validParam returns true for the global thresholds and tier thresholds
+func validParam(name string) bool {
+	if name == MinSpreadBps || name == MinDepthUSD {
+		return true
+	}
+	_, _, ok := splitTierParam(name)
+	return ok
+}
+
+// splitTierParam parses tier.{tier}.{threshold}
+func splitTierParam(name string) (tier, threshold string, ok bool) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 || parts[0] != "tier" || parts[1] == "" {
+		return "", "", false
+	}
+	if parts[2] != TierMinSpreadBps && parts[2] != TierMinDepthUSD {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// sameMutes compares mutes as SyncMutes would: by pair, reason and times
+func sameMutes(a, b []spread.PairMute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Long != b[i].Long || a[i].Short != b[i].Short || a[i].Reason != b[i].Reason ||
+			!a[i].At.Equal(b[i].At) || !a[i].Until.Equal(b[i].Until) {
+			return false
+		}
+	}
+	return true
+}
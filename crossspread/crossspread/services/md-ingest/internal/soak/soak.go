@@ -0,0 +1,340 @@
+package soak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Invariants checked during a soak run
+const (
+	KindSequenceRegression = "sequence_regression" // Book update with a lower sequence ID than the last one
+	KindCrossedBook        = "crossed_book"        // Best bid at or above best ask
+	KindUnsortedLevels     = "unsorted_levels"     // Bids not descending or asks not ascending
+	KindBookSpread         = "book_spread"         // Venue's own bid/ask spread above MaxBookSpreadBps
+	KindInvalidTrade       = "invalid_trade"       // Trade without a positive price and quantity
+	KindSpreadBounds       = "spread_bounds"       // Published opportunity outside sane bounds
+	KindGoroutineLeak      = "goroutine_leak"      // Goroutines grew past the warmup baseline
+)
+
+// Config controls a soak run
+type Config struct {
+	Duration         time.Duration // How long to run before reporting
+	SampleInterval   time.Duration // How often goroutines, heap and spreads are sampled
+	Warmup           time.Duration // Goroutine baseline is taken once this has passed
+	MaxBookSpreadBps float64       // A venue's own spread above this is reported
+	MaxSpreadBps     float64       // A cross-venue opportunity above this is reported
+	GoroutineGrowth  float64       // Goroutines above baseline*growth+slack are a leak
+	GoroutineSlack   int
+	MaxExamples      int // Violations of each kind kept in the report
+}
+
+// DefaultConfig runs for 4h, sampling every 30s after a 5m warmup
+func DefaultConfig() Config {
+	return Config{
+		Duration:         4 * time.Hour,
+		SampleInterval:   30 * time.Second,
+		Warmup:           5 * time.Minute,
+		MaxBookSpreadBps: 500,
+		MaxSpreadBps:     2000,
+		GoroutineGrowth:  1.5,
+		GoroutineSlack:   50,
+		MaxExamples:      20,
+	}
+}
+
+// SpreadSource returns the current top spreads (spread.SpreadDiscovery)
+type SpreadSource interface {
+	GetTopSpreads(n int) []*spread.SpreadOpportunity
+}
+
+// Violation is one broken invariant
+type Violation struct {
+	Kind     string               `json:"kind"`
+	Exchange connector.ExchangeID `json:"exchange,omitempty"`
+	Symbol   string               `json:"symbol,omitempty"`
+	Detail   string               `json:"detail"`
+	At       time.Time            `json:"at"`
+}
+
+// ExchangeStats counts what one venue delivered during the run
+type ExchangeStats struct {
+	Books      int64            `json:"books"`
+	Trades     int64            `json:"trades"`
+	Symbols    int              `json:"symbols"`
+	Violations map[string]int64 `json:"violations,omitempty"`
+}
+
+// Samples summarises a sampled quantity
+type Samples struct {
+	Baseline int `json:"baseline,omitempty"`
+	Min      int `json:"min"`
+	Max      int `json:"max"`
+	Final    int `json:"final"`
+}
+
+func (s *Samples) add(v int) {
+	if s.Max == 0 || v < s.Min {
+		s.Min = v
+	}
+	s.Max = max(s.Max, v)
+	s.Final = v
+}
+
+// Report is the outcome of a soak run
+type Report struct {
+	StartedAt      time.Time                               `json:"started_at"`
+	EndedAt        time.Time                               `json:"ended_at"`
+	Duration       string                                  `json:"duration"`
+	Passed         bool                                    `json:"passed"`
+	Violations     map[string]int64                        `json:"violations"`
+	Examples       []Violation                             `json:"examples"`
+	Exchanges      map[connector.ExchangeID]*ExchangeStats `json:"exchanges"`
+	Goroutines     Samples                                 `json:"goroutines"`
+	HeapMB         Samples                                 `json:"heap_mb"`
+	SpreadsChecked int64                                   `json:"spreads_checked"`
+}
+
+type bookKey struct {
+	exchange connector.ExchangeID
+	symbol   string
+}
+
+// Runner checks invariants on live market data for a fixed duration and
+// reports what broke, to qualify a release before production. It consumes
+// books and trades off the event bus like any other subscriber.
+type Runner struct {
+	config  Config
+	spreads SpreadSource
+
+	mu        sync.Mutex
+	startedAt time.Time
+	sequences map[bookKey]int64
+	exchanges map[connector.ExchangeID]*ExchangeStats
+	counts    map[string]int64
+	examples  map[string][]Violation
+	checked   int64
+	routines  Samples
+	heap      Samples
+
+	done chan struct{}
+}
+
+// New creates a soak runner
+func New(config Config, spreads SpreadSource) *Runner {
+	return &Runner{
+		config:    config,
+		spreads:   spreads,
+		startedAt: time.Now(),
+		sequences: make(map[bookKey]int64),
+		exchanges: make(map[connector.ExchangeID]*ExchangeStats),
+		counts:    make(map[string]int64),
+		examples:  make(map[string][]Violation),
+		done:      make(chan struct{}),
+	}
+}
+
+// Done is closed when the run's duration has elapsed
+func (r *Runner) Done() <-chan struct{} {
+	return r.done
+}
+
+// HandleOrderbook checks a book update
+func (r *Runner) HandleOrderbook(ob *connector.Orderbook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats(ob.ExchangeID)
+	stats.Books++
+	key := bookKey{ob.ExchangeID, ob.Symbol}
+	last, seen := r.sequences[key]
+	if !seen {
+		stats.Symbols++
+	}
+
+	if ob.SequenceID > 0 {
+		if seen && !ob.IsSnapshot && ob.SequenceID < last {
+			r.violate(KindSequenceRegression, ob.ExchangeID, ob.Symbol, fmt.Sprintf("sequence %d after %d", ob.SequenceID, last))
+		}
+		r.sequences[key] = ob.SequenceID
+	} else if !seen {
+		r.sequences[key] = 0
+	}
+
+	if len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+		return
+	}
+	bid, ask := ob.Bids[0].Price, ob.Asks[0].Price
+	if bid >= ask {
+		r.violate(KindCrossedBook, ob.ExchangeID, ob.Symbol, fmt.Sprintf("bid %g >= ask %g", bid, ask))
+	} else if bps := (ask - bid) / bid * 10000; bps > r.config.MaxBookSpreadBps {
+		r.violate(KindBookSpread, ob.ExchangeID, ob.Symbol, fmt.Sprintf("%.1f bps between bid %g and ask %g", bps, bid, ask))
+	}
+	if side, i := unsorted(ob); i > 0 {
+		r.violate(KindUnsortedLevels, ob.ExchangeID, ob.Symbol, fmt.Sprintf("%s level %d out of order", side, i))
+	}
+}
+
+// unsorted returns the side and index of the first level out of order, or
+// 0 if both sides are sorted
+func unsorted(ob *connector.Orderbook) (string, int) {
+	for i := 1; i < len(ob.Bids); i++ {
+		if ob.Bids[i].Price >= ob.Bids[i-1].Price {
+			return "bid", i
+		}
+	}
+	for i := 1; i < len(ob.Asks); i++ {
+		if ob.Asks[i].Price <= ob.Asks[i-1].Price {
+			return "ask", i
+		}
+	}
+	return "", 0
+}
+
+// HandleTrade checks a trade
+func (r *Runner) HandleTrade(trade *connector.Trade) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats(trade.ExchangeID).Trades++
+	if trade.Price <= 0 || trade.Quantity <= 0 {
+		r.violate(KindInvalidTrade, trade.ExchangeID, trade.Symbol, fmt.Sprintf("price %g quantity %g", trade.Price, trade.Quantity))
+	}
+}
+
+// stats returns a venue's counters. Caller holds r.mu.
+func (r *Runner) stats(exchange connector.ExchangeID) *ExchangeStats {
+	stats := r.exchanges[exchange]
+	if stats == nil {
+		stats = &ExchangeStats{Violations: make(map[string]int64)}
+		r.exchanges[exchange] = stats
+	}
+	return stats
+}
+
+// violate records a broken invariant. Caller holds r.mu.
+func (r *Runner) violate(kind string, exchange connector.ExchangeID, symbol, detail string) {
+	r.counts[kind]++
+	if exchange != "" {
+		r.stats(exchange).Violations[kind]++
+	}
+	if len(r.examples[kind]) < r.config.MaxExamples {
+		v := Violation{Kind: kind, Exchange: exchange, Symbol: symbol, Detail: detail, At: time.Now()}
+		r.examples[kind] = append(r.examples[kind], v)
+		log.Warn().Str("kind", kind).Str("exchange", string(exchange)).Str("symbol", symbol).Str("detail", detail).Msg("Soak invariant violated")
+	}
+}
+
+// Start samples goroutines, heap and spreads until the duration has
+// elapsed, then closes Done. Returns early if ctx is done.
+func (r *Runner) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.SampleInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(r.config.Duration)
+	defer deadline.Stop()
+
+	log.Info().Dur("duration", r.config.Duration).Msg("Soak run started")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			r.sample(time.Now())
+			close(r.done)
+			return
+		case now := <-ticker.C:
+			r.sample(now)
+		}
+	}
+}
+
+// sample records goroutines and heap, and checks the published spreads
+func (r *Runner) sample(now time.Time) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	goroutines := runtime.NumGoroutine()
+	spreads := r.spreads.GetTopSpreads(math.MaxInt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.heap.add(int(mem.HeapAlloc >> 20))
+	r.routines.add(goroutines)
+	if now.Sub(r.startedAt) >= r.config.Warmup {
+		if r.routines.Baseline == 0 {
+			r.routines.Baseline = goroutines
+		} else if limit := int(float64(r.routines.Baseline)*r.config.GoroutineGrowth) + r.config.GoroutineSlack; goroutines > limit {
+			r.violate(KindGoroutineLeak, "", "", fmt.Sprintf("%d goroutines, baseline %d, limit %d", goroutines, r.routines.Baseline, limit))
+		}
+	}
+
+	for _, sp := range spreads {
+		r.checked++
+		switch {
+		case sp.LongExchange == sp.ShortExchange:
+			r.violate(KindSpreadBounds, sp.LongExchange, sp.Canonical, "both legs on one venue: "+sp.ID)
+		case sp.LongPrice <= 0 || sp.ShortPrice <= 0:
+			r.violate(KindSpreadBounds, sp.LongExchange, sp.Canonical, fmt.Sprintf("%s: non-positive leg price", sp.ID))
+		case sp.SpreadBps > r.config.MaxSpreadBps:
+			r.violate(KindSpreadBounds, sp.LongExchange, sp.Canonical, fmt.Sprintf("%s: %.1f bps above %.0f", sp.ID, sp.SpreadBps, r.config.MaxSpreadBps))
+		}
+	}
+}
+
+// Report returns the outcome so far
+func (r *Runner) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	report := Report{
+		StartedAt:      r.startedAt,
+		EndedAt:        now,
+		Duration:       now.Sub(r.startedAt).Round(time.Second).String(),
+		Passed:         len(r.counts) == 0,
+		Violations:     make(map[string]int64, len(r.counts)),
+		Exchanges:      make(map[connector.ExchangeID]*ExchangeStats, len(r.exchanges)),
+		Goroutines:     r.routines,
+		HeapMB:         r.heap,
+		SpreadsChecked: r.checked,
+	}
+	for kind, n := range r.counts {
+		report.Violations[kind] = n
+	}
+	for id, stats := range r.exchanges {
+		cp := *stats
+		cp.Violations = make(map[string]int64, len(stats.Violations))
+		for kind, n := range stats.Violations {
+			cp.Violations[kind] = n
+		}
+		report.Exchanges[id] = &cp
+	}
+	for _, examples := range r.examples {
+		report.Examples = append(report.Examples, examples...)
+	}
+	sort.Slice(report.Examples, func(i, j int) bool {
+		return report.Examples[i].At.Before(report.Examples[j].At)
+	})
+	return report
+}
+
+// WriteReport writes the report as indented JSON to path
+func (r *Runner) WriteReport(path string) (Report, error) {
+	report := r.Report()
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return report, err
+	}
+	return report, os.WriteFile(path, data, 0o644)
+}
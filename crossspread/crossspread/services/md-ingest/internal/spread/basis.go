@@ -0,0 +1,194 @@
+package spread
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Basis directions
+const (
+	// BasisCashAndCarry buys spot and sells the perp, when the perp bid is
+	// above the spot ask; the short perp collects positive funding
+	BasisCashAndCarry = "cash_and_carry"
+	// BasisReverse sells spot and buys the perp, when the spot bid is above
+	// the perp ask; the spot leg needs inventory or a margin borrow
+	BasisReverse = "reverse"
+)
+
+// BasisOpportunity is a spread between a venue's spot and perp books. Both
+// legs sit on one venue, so closing it needs no transfer between exchanges.
+type BasisOpportunity struct {
+	ID           string               `json:"id"` // canonical:exchange:direction
+	Canonical    string               `json:"canonical"`
+	Exchange     connector.ExchangeID `json:"exchange"`
+	Direction    string               `json:"direction"` // cash_and_carry or reverse
+	Kind         string               `json:"kind"`      // basis
+	Legs         []Leg                `json:"legs"`      // Buy leg then sell leg
+	SpotSymbol   string               `json:"spot_symbol"`
+	PerpSymbol   string               `json:"perp_symbol"`
+	SpotPrice    float64              `json:"spot_price"`     // Ask when buying spot, bid when selling
+	PerpPrice    float64              `json:"perp_price"`     // Bid when selling the perp, ask when buying
+	BasisBps     float64              `json:"basis_bps"`      // Sell leg over buy leg
+	Funding      float64              `json:"funding"`        // Perp funding rate per interval
+	SpotDepthUSD float64              `json:"spot_depth_usd"` // Top 5 levels on the side taken
+	PerpDepthUSD float64              `json:"perp_depth_usd"`
+	MinDepthUSD  float64              `json:"min_depth_usd"`
+	BreakevenBps float64              `json:"breakeven_bps"` // Basis needed to cover spot and perp fees less funding
+	NetEdgeBps   float64              `json:"net_edge_bps"`  // basis_bps - breakeven_bps
+	Profitable   bool                 `json:"profitable"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	EventTime    time.Time            `json:"event_time"` // Exchange time of the newer leg's quote
+}
+
+// BasisSummary is the periodic summary of current basis opportunities
+type BasisSummary struct {
+	Timestamp time.Time           `json:"timestamp"`
+	Count     int                 `json:"count"`
+	Basis     []*BasisOpportunity `json:"basis"`
+}
+
+// basisID returns the ID of a venue's basis in one direction
+func basisID(canonical string, exchange connector.ExchangeID, direction string) string {
+	return fmt.Sprintf("%s:%s:%s", canonical, exchange, direction)
+}
+
+// handleSpotBook stores a spot book and re-evaluates the venue's basis.
+// Spot books never enter cross-venue pairs. Caller holds s.mu.
+func (s *SpreadDiscovery) handleSpotBook(ob *connector.Orderbook) {
+	books := s.spotBooks[ob.Canonical]
+	if books == nil {
+		books = make(map[connector.ExchangeID]*connector.Orderbook)
+		s.spotBooks[ob.Canonical] = books
+	}
+	books[ob.ExchangeID] = ob
+
+	if perp := s.orderbooks[ob.Canonical][ob.ExchangeID]; perp != nil {
+		s.checkBasis(ob.Canonical, ob, perp)
+	}
+}
+
+// checkBasis re-evaluates both directions of a venue's spot-vs-perp basis.
+// Caller holds s.mu.
+func (s *SpreadDiscovery) checkBasis(canonical string, spot, perp *connector.Orderbook) {
+	now := s.now()
+	thresholds := s.thresholdsFor(canonical)
+	funding := s.fundingRates[canonical][perp.ExchangeID]
+
+	for _, direction := range []string{BasisCashAndCarry, BasisReverse} {
+		id := basisID(canonical, perp.ExchangeID, direction)
+
+		var spotPrice, perpPrice, spotDepth, perpDepth, perpFunding float64
+		var buyPrice, sellPrice float64
+		switch direction {
+		case BasisCashAndCarry:
+			if len(spot.Asks) == 0 || len(perp.Bids) == 0 {
+				delete(s.basis, id)
+				continue
+			}
+			spotPrice, perpPrice = spot.Asks[0].Price, perp.Bids[0].Price
+			spotDepth, perpDepth = s.calculateDepthUSD(spot.Asks), s.calculateDepthUSD(perp.Bids)
+			buyPrice, sellPrice = spotPrice, perpPrice
+			perpFunding = funding
+		case BasisReverse:
+			if len(spot.Bids) == 0 || len(perp.Asks) == 0 {
+				delete(s.basis, id)
+				continue
+			}
+			spotPrice, perpPrice = spot.Bids[0].Price, perp.Asks[0].Price
+			spotDepth, perpDepth = s.calculateDepthUSD(spot.Bids), s.calculateDepthUSD(perp.Asks)
+			buyPrice, sellPrice = perpPrice, spotPrice
+			perpFunding = -funding
+		}
+
+		if buyPrice <= 0 || sellPrice <= 0 || s.gated(spot, perp) {
+			delete(s.basis, id)
+			continue
+		}
+		basisBps := (sellPrice - buyPrice) / buyPrice * 10000
+		minDepth := math.Min(spotDepth, perpDepth)
+		if basisBps < thresholds.MinSpreadBps || minDepth < thresholds.MinDepthUSD {
+			delete(s.basis, id)
+			continue
+		}
+
+		breakevenBps := s.economics.BasisBreakevenBps(perp.ExchangeID, perpFunding)
+		legs := []Leg{bookLeg(spot, SideBuy, spotDepth, 0), bookLeg(perp, SideSell, perpDepth, funding)}
+		if direction == BasisReverse {
+			legs = []Leg{bookLeg(perp, SideBuy, perpDepth, funding), bookLeg(spot, SideSell, spotDepth, 0)}
+		}
+		s.basis[id] = &BasisOpportunity{
+			ID:           id,
+			Canonical:    canonical,
+			Exchange:     perp.ExchangeID,
+			Direction:    direction,
+			Kind:         KindBasis,
+			Legs:         legs,
+			SpotSymbol:   spot.Symbol,
+			PerpSymbol:   perp.Symbol,
+			SpotPrice:    spotPrice,
+			PerpPrice:    perpPrice,
+			BasisBps:     basisBps,
+			Funding:      funding,
+			SpotDepthUSD: spotDepth,
+			PerpDepthUSD: perpDepth,
+			MinDepthUSD:  minDepth,
+			BreakevenBps: breakevenBps,
+			NetEdgeBps:   basisBps - breakevenBps,
+			Profitable:   basisBps > breakevenBps,
+			UpdatedAt:    now,
+			EventTime:    eventTime(spot, perp, now),
+		}
+	}
+}
+
+// GetBasis returns up to n basis opportunities by net edge, highest first
+func (s *SpreadDiscovery) GetBasis(n int) []*BasisOpportunity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*BasisOpportunity, 0, len(s.basis))
+	for _, b := range s.basis {
+		result = append(result, b)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NetEdgeBps != result[j].NetEdgeBps {
+			return result[i].NetEdgeBps > result[j].NetEdgeBps
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result[:min(n, len(result))]
+}
+
+// publishBasis publishes the basis summary. Nothing is published until a
+// spot book has been seen.
+func (s *SpreadDiscovery) publishBasis() {
+	s.mu.RLock()
+	hasSpot := len(s.spotBooks) > 0
+	s.mu.RUnlock()
+	if !hasSpot {
+		return
+	}
+
+	basis := s.GetBasis(100)
+	summary := BasisSummary{
+		Timestamp: s.clock(),
+		Count:     len(basis),
+		Basis:     basis,
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal basis summary")
+		return
+	}
+	if err := s.publisher.SetBasis(data); err != nil {
+		log.Error().Err(err).Str("key", keyspace.Key(keyspace.BasisKey)).Msg("Failed to publish basis")
+	}
+}
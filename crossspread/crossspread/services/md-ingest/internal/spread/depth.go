@@ -0,0 +1,64 @@
+package spread
+
+import (
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/orderbook"
+)
+
+// BookSource walks a venue symbol's full book for a notional;
+// *orderbook.Engine and *orderbook.Sources implement it
+type BookSource interface {
+	DepthAtNotional(exchange connector.ExchangeID, symbol string, side orderbook.Side, notional float64) (orderbook.Fill, bool)
+}
+
+// SetBooks prices spreads at the economics notional from full books as
+// well as at the top of book
+func (s *SpreadDiscovery) SetBooks(src BookSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books = src
+}
+
+// sizedSpreadBps returns the spread of buying then selling the economics
+// notional through both books, in bps, or false if a book is out of sync
+// or too thin to fill it. Caller holds s.mu.
+func (s *SpreadDiscovery) sizedSpreadBps(longOb, shortOb *connector.Orderbook) (float64, bool) {
+	notional := s.economics.NotionalUSD
+	if s.books == nil || notional <= 0 {
+		return 0, false
+	}
+	buy, ok := s.fill(longOb, orderbook.Ask, notional)
+	if !ok {
+		return 0, false
+	}
+	sell, ok := s.fill(shortOb, orderbook.Bid, notional)
+	if !ok {
+		return 0, false
+	}
+
+	// The engine keeps venue prices; pair them in base units
+	buyPrice, sellPrice := buy.AvgPrice, sell.AvgPrice
+	if e, ok := s.symbols.Unit(longOb.ExchangeID, longOb.Symbol); ok {
+		buyPrice = e.UnitPrice(buyPrice)
+	}
+	if e, ok := s.symbols.Unit(shortOb.ExchangeID, shortOb.Symbol); ok {
+		sellPrice = e.UnitPrice(sellPrice)
+	}
+	if buyPrice <= 0 {
+		return 0, false
+	}
+	return (sellPrice - buyPrice) / buyPrice * 10000, true
+}
+
+// fill walks a venue symbol's book for a USD notional, reporting false
+// unless it fills completely. The engine keeps venue sizes: on venues
+// quoting contracts (Gate, OKX) a level's price times its size is the
+// notional of that many contracts, so the walk is for the notional over the
+// contract size. Caller holds s.mu.
+func (s *SpreadDiscovery) fill(ob *connector.Orderbook, side orderbook.Side, notional float64) (orderbook.Fill, bool) {
+	if e, ok := s.symbols.Lookup(ob.ExchangeID, ob.Symbol); ok && e.ContractSize > 0 {
+		notional /= e.ContractSize
+	}
+	f, ok := s.books.DepthAtNotional(ob.ExchangeID, ob.Symbol, side, notional)
+	return f, ok && f.Complete(notional)
+}
@@ -3,41 +3,36 @@ package spread
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"math"
 	"sort"
 	"sync"
 	"time"
 
 	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/filterexpr"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
 	"crossspread-md-ingest/internal/normalizer"
 	"crossspread-md-ingest/internal/publisher"
+	"crossspread-md-ingest/internal/symbolmap"
+	"crossspread-md-ingest/pkg/mdtypes"
 
 	"github.com/rs/zerolog/log"
 )
 
-// SpreadOpportunity represents an arbitrage spread opportunity
-type SpreadOpportunity struct {
-	ID            string               `json:"id"`
-	Canonical     string               `json:"canonical"`      // e.g., "BTC"
-	LongExchange  connector.ExchangeID `json:"long_exchange"`  // Exchange to buy
-	ShortExchange connector.ExchangeID `json:"short_exchange"` // Exchange to sell
-	LongSymbol    string               `json:"long_symbol"`
-	ShortSymbol   string               `json:"short_symbol"`
-	LongPrice     float64              `json:"long_price"`      // Best ask on long exchange
-	ShortPrice    float64              `json:"short_price"`     // Best bid on short exchange
-	SpreadPercent float64              `json:"spread_percent"`  // (short - long) / long * 100
-	SpreadBps     float64              `json:"spread_bps"`      // Spread in basis points
-	LongFunding   float64              `json:"long_funding"`    // Funding rate on long
-	ShortFunding  float64              `json:"short_funding"`   // Funding rate on short
-	NetFunding    float64              `json:"net_funding"`     // short_funding - long_funding
-	LongDepthUSD  float64              `json:"long_depth_usd"`  // Top 5 levels depth
-	ShortDepthUSD float64              `json:"short_depth_usd"` // Top 5 levels depth
-	MinDepthUSD   float64              `json:"min_depth_usd"`   // Min of both sides
-	Volume24h     float64              `json:"volume_24h"`      // Combined volume
-	Score         float64              `json:"score"`           // Opportunity score
-	UpdatedAt     time.Time            `json:"updated_at"`
-}
+// Published spread payloads are defined in pkg/mdtypes so pkg/mdclient
+// can decode them without importing this package
+type (
+	SpreadOpportunity = mdtypes.SpreadOpportunity
+	SpreadSummary     = mdtypes.SpreadSummary
+)
+
+// TagNewListing marks spreads on a contract recently listed on a second venue
+const TagNewListing = "new_listing"
+
+// TagApproximate marks spreads with a leg priced from a REST ticker's best
+// bid/ask during cold start; depth is the top level only
+const TagApproximate = "approximate"
 
 // SpreadDiscovery discovers and tracks arbitrage opportunities
 type SpreadDiscovery struct {
@@ -46,20 +41,88 @@ type SpreadDiscovery struct {
 	normalizer *normalizer.InstrumentNormalizer
 	publisher  *publisher.RedisPublisher
 
+	// Venue symbols quoting a multiple of the unit; see units.go
+	symbols *symbolmap.Map
+
 	// Current orderbooks per exchange per canonical symbol
 	orderbooks map[string]map[connector.ExchangeID]*connector.Orderbook
 
-	// Current funding rates per exchange per canonical symbol
+	// Venue pairs per canonical symbol; an update re-evaluates only its venue's pairs
+	pairs map[string]*pairIndex
+
+	// Spot books per exchange per canonical symbol, and each venue's
+	// spot-vs-perp basis. Spot books never enter cross-venue pairs.
+	spotBooks map[string]map[connector.ExchangeID]*connector.Orderbook
+	basis     map[string]*BasisOpportunity // key: "canonical:exchange:direction"
+
+	// Current funding rates per exchange per canonical symbol, as 8h
+	// equivalents once normalized so venues on other intervals compare
 	fundingRates map[string]map[connector.ExchangeID]float64
 
-	// Current spread opportunities
+	// Mark prices per exchange per canonical symbol, from venues returning
+	// them with funding rates
+	markPrices map[string]map[connector.ExchangeID]float64
+
+	// 24h volume per exchange per canonical symbol (from REST tickers)
+	volumes map[string]map[connector.ExchangeID]float64
+
+	// Current spread opportunities, only those ranking in their symbol's top N
 	spreads map[string]*SpreadOpportunity // key: "canonical:longExchange:shortExchange"
+	top     map[string]*topSpreads        // Rankings per canonical symbol
+	topN    int
 
 	// Configuration
-	minSpreadBps    float64 // Minimum spread in bps to consider
-	minDepthUSD     float64 // Minimum depth in USD
+	minSpreadBps    float64               // Minimum spread in bps to consider
+	minDepthUSD     float64               // Minimum depth in USD
+	tiers           map[string]Thresholds // Tier name -> thresholds overriding the two above
+	symbolTiers     map[string]string     // Canonical symbol -> tier name
 	updateInterval  time.Duration
 	publishInterval time.Duration
+	economics       EconomicsConfig
+	quoteAge        QuoteAgeConfig
+
+	// Canonicals recently listed on a second venue, tagged until the given time
+	newListings map[string]time.Time
+
+	// Current positions; scores are skewed away from adding to them
+	inventory       InventorySource
+	inventoryConfig InventoryConfig
+
+	// Full books for pricing the economics notional; see depth.go
+	books BookSource
+
+	// Observed transfer latencies for rebalance times; see transfers.go
+	transfers TransferTimes
+
+	// Exchange pairs muted through the admin API, and who muted them
+	mutes     map[string]PairMute
+	muteAudit []MuteAudit
+
+	// Venue symbol statuses; legs that cannot open positions are skipped
+	statusGate StatusGate
+
+	// Data quality per venue symbol; low-quality legs need a wider spread
+	qualitySource QualitySource
+
+	// Operator filter expression; opportunities it rejects are not published
+	filter *filterexpr.Expr
+
+	// Tenants receiving their own stream of spreads they can trade
+	tenants TenantSource
+
+	// Called with the spreads published each cycle (e.g. history recording)
+	spreadsHandler func([]*SpreadOpportunity)
+
+	// Called with every spread as it is computed, under s.mu (e.g. in-process strategies)
+	spreadHook func(*SpreadOpportunity)
+
+	// Under memory pressure only the top shedDepth levels of each book are kept
+	shedding  bool
+	shedDepth int
+
+	// Clock read when spreads are computed and published; replay drives it
+	// from recorded frame times
+	now func() time.Time
 
 	done chan struct{}
 }
@@ -73,12 +136,25 @@ func NewSpreadDiscovery(
 		normalizer:      normalizer,
 		publisher:       publisher,
 		orderbooks:      make(map[string]map[connector.ExchangeID]*connector.Orderbook),
+		pairs:           make(map[string]*pairIndex),
+		spotBooks:       make(map[string]map[connector.ExchangeID]*connector.Orderbook),
+		basis:           make(map[string]*BasisOpportunity),
 		fundingRates:    make(map[string]map[connector.ExchangeID]float64),
+		markPrices:      make(map[string]map[connector.ExchangeID]float64),
+		volumes:         make(map[string]map[connector.ExchangeID]float64),
 		spreads:         make(map[string]*SpreadOpportunity),
-		minSpreadBps:    1.0,   // Minimum 0.01% spread (lowered from 5.0 to show more opportunities)
-		minDepthUSD:     1000,  // Minimum $1k depth (lowered from 5000 to show more pairs)
+		top:             make(map[string]*topSpreads),
+		topN:            DefaultTopN,
+		newListings:     make(map[string]time.Time),
+		mutes:           make(map[string]PairMute),
+		minSpreadBps:    1.0,  // Minimum 0.01% spread (lowered from 5.0 to show more opportunities)
+		minDepthUSD:     1000, // Minimum $1k depth (lowered from 5000 to show more pairs)
 		updateInterval:  100 * time.Millisecond,
 		publishInterval: 500 * time.Millisecond,
+		economics:       DefaultEconomicsConfig(),
+		quoteAge:        DefaultQuoteAgeConfig(),
+		shedDepth:       5, // Levels used by calculateDepthUSD
+		now:             time.Now,
 		done:            make(chan struct{}),
 	}
 }
@@ -105,22 +181,211 @@ func (s *SpreadDiscovery) Stop() {
 	close(s.done)
 }
 
+// SeedOrderbook stores a REST snapshot unless a fresher book for the same
+// venue has already arrived, e.g. when re-seeding a venue after a reconnect.
+// An approximate book never replaces a real one.
+func (s *SpreadDiscovery) SeedOrderbook(ob *connector.Orderbook) {
+	s.mu.RLock()
+	canonical := ob.Canonical
+	if _, unit, ok := s.unitCanonical(ob.ExchangeID, ob.Symbol); ok {
+		canonical = unit
+	}
+	existing := s.orderbooks[canonical][ob.ExchangeID]
+	s.mu.RUnlock()
+
+	if existing != nil && existing.ReceivedAt.After(ob.ReceivedAt) {
+		return
+	}
+	if existing != nil && ob.Approximate && !existing.Approximate {
+		return
+	}
+	s.HandleOrderbook(ob)
+}
+
 // HandleOrderbook processes an orderbook update
 func (s *SpreadDiscovery) HandleOrderbook(ob *connector.Orderbook) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	ob = s.unitBook(ob)
 	canonical := ob.Canonical
 	exchangeID := ob.ExchangeID
 
-	// Store orderbook
-	if s.orderbooks[canonical] == nil {
-		s.orderbooks[canonical] = make(map[connector.ExchangeID]*connector.Orderbook)
+	// Store orderbook, trimmed to the levels we use when shedding memory
+	if s.shedding {
+		ob = trimOrderbook(ob, s.shedDepth)
+	}
+	if ob.Market == connector.MarketSpot {
+		s.handleSpotBook(ob)
+		return
+	}
+	books := s.orderbooks[canonical]
+	if books == nil {
+		books = make(map[connector.ExchangeID]*connector.Orderbook)
+		s.orderbooks[canonical] = books
+	}
+	books[exchangeID] = ob
+
+	pairs := s.pairs[canonical]
+	if pairs == nil {
+		pairs = newPairIndex()
+		s.pairs[canonical] = pairs
+	}
+	slot, ok := pairs.slots[exchangeID]
+	if !ok {
+		slot = pairs.add(canonical, exchangeID)
+	}
+	pairs.books[slot] = ob
+
+	// Re-evaluate the pairs this venue is a leg of, and its own basis
+	s.recalculatePairs(canonical, pairs, slot)
+	if spot := s.spotBooks[canonical][exchangeID]; spot != nil {
+		s.checkBasis(canonical, spot, ob)
+	}
+}
+
+// DropApproximate removes the approximate books no real book has replaced,
+// and their spreads, once the cold-start window is over. Returns the number
+// of books dropped.
+func (s *SpreadDiscovery) DropApproximate() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := 0
+	for canonical, books := range s.orderbooks {
+		pairs := s.pairs[canonical]
+		for id, ob := range books {
+			if !ob.Approximate {
+				continue
+			}
+			delete(books, id)
+			slot := pairs.slots[id]
+			pairs.books[slot] = nil
+			for peer := range pairs.venues {
+				if peer != slot {
+					s.removeSpread(pairs.ids[slot][peer])
+					s.removeSpread(pairs.ids[peer][slot])
+				}
+			}
+			dropped++
+		}
+		if len(books) == 0 {
+			delete(s.orderbooks, canonical)
+		}
+	}
+	return dropped
+}
+
+// SetSpreadsHandler sets a callback receiving the spreads published each cycle
+func (s *SpreadDiscovery) SetSpreadsHandler(handler func([]*SpreadOpportunity)) {
+	s.spreadsHandler = handler
+}
+
+// SetSpreadHook sets a callback receiving every spread as it is computed,
+// before top-N ranking. It runs under the discovery lock, so it must not
+// block or call back into discovery.
+func (s *SpreadDiscovery) SetSpreadHook(hook func(*SpreadOpportunity)) {
+	s.spreadHook = hook
+}
+
+// SetClock replaces the clock read when spreads are computed and published.
+// Replays set it to the recorded time of the frame being fed, so nothing in
+// the discovery path depends on when it runs.
+func (s *SpreadDiscovery) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetEconomics sets the fee, transfer and holding assumptions used for the
+// breakeven and profitability verdict
+func (s *SpreadDiscovery) SetEconomics(cfg EconomicsConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.economics = cfg
+}
+
+// SetQuoteAge sets how stale legs decay the published effective edge
+func (s *SpreadDiscovery) SetQuoteAge(cfg QuoteAgeConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quoteAge = cfg
+}
+
+// MarkNewListing tags a canonical's spreads as new_listing until the given time
+func (s *SpreadDiscovery) MarkNewListing(canonical string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.newListings[canonical] = until
+}
+
+// tags returns the tags for a canonical's spreads. Caller holds s.mu.
+func (s *SpreadDiscovery) tags(canonical string, now time.Time) []string {
+	if until, ok := s.newListings[canonical]; ok {
+		if now.Before(until) {
+			return []string{TagNewListing}
+		}
+		delete(s.newListings, canonical)
+	}
+	return nil
+}
+
+// SetShedding enables or disables book trimming under memory pressure
+func (s *SpreadDiscovery) SetShedding(shedding bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shedding = shedding
+	if !shedding {
+		return
+	}
+	for canonical, books := range s.orderbooks {
+		pairs := s.pairs[canonical]
+		for id, ob := range books {
+			books[id] = trimOrderbook(ob, s.shedDepth)
+			pairs.books[pairs.slots[id]] = books[id]
+		}
+	}
+	for _, books := range s.spotBooks {
+		for id, ob := range books {
+			books[id] = trimOrderbook(ob, s.shedDepth)
+		}
+	}
+}
+
+// MemoryUsage estimates the bytes held by cached orderbooks and spreads
+func (s *SpreadDiscovery) MemoryUsage() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	const (
+		levelSize     = 24  // PriceLevel: three float64
+		orderbookSize = 200 // Orderbook struct and strings
+		spreadSize    = 400 // SpreadOpportunity struct and strings
+	)
+
+	var total int64
+	for _, byCanonical := range []map[string]map[connector.ExchangeID]*connector.Orderbook{s.orderbooks, s.spotBooks} {
+		for _, books := range byCanonical {
+			for _, ob := range books {
+				total += orderbookSize + int64(cap(ob.Bids)+cap(ob.Asks))*levelSize
+			}
+		}
 	}
-	s.orderbooks[canonical][exchangeID] = ob
+	total += int64(len(s.spreads)+len(s.basis)) * spreadSize
+	return total
+}
 
-	// Recalculate spreads for this canonical symbol
-	s.recalculateSpreads(canonical)
+// trimOrderbook returns a copy of ob holding at most depth levels per side.
+// Levels are copied so the original backing arrays can be collected.
+func trimOrderbook(ob *connector.Orderbook, depth int) *connector.Orderbook {
+	if len(ob.Bids) <= depth && len(ob.Asks) <= depth {
+		return ob
+	}
+	trimmed := *ob
+	trimmed.Bids = append([]connector.PriceLevel(nil), ob.Bids[:min(depth, len(ob.Bids))]...)
+	trimmed.Asks = append([]connector.PriceLevel(nil), ob.Asks[:min(depth, len(ob.Asks))]...)
+	return &trimmed
 }
 
 // HandleFundingRate processes a funding rate update
@@ -128,47 +393,93 @@ func (s *SpreadDiscovery) HandleFundingRate(fr *connector.FundingRate) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	fr = s.unitFunding(fr)
 	canonical := fr.Canonical
 	exchangeID := fr.ExchangeID
 
 	if s.fundingRates[canonical] == nil {
 		s.fundingRates[canonical] = make(map[connector.ExchangeID]float64)
 	}
-	s.fundingRates[canonical][exchangeID] = fr.FundingRate
+	rate := fr.FundingRate
+	if fr.IntervalSource != "" {
+		rate = fr.FundingRate8h
+	}
+	s.fundingRates[canonical][exchangeID] = rate
+
+	if fr.MarkPrice > 0 {
+		if s.markPrices[canonical] == nil {
+			s.markPrices[canonical] = make(map[connector.ExchangeID]float64)
+		}
+		s.markPrices[canonical][exchangeID] = fr.MarkPrice
+	}
 }
 
-// recalculateSpreads recalculates all spreads for a canonical symbol
-func (s *SpreadDiscovery) recalculateSpreads(canonical string) {
-	exchanges, ok := s.orderbooks[canonical]
-	if !ok || len(exchanges) < 2 {
+// FundingRate returns the latest funding rate of a venue for a canonical symbol
+func (s *SpreadDiscovery) FundingRate(canonical string, exchange connector.ExchangeID) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rate, ok := s.fundingRates[canonical][exchange]
+	return rate, ok
+}
+
+// MarkPrice returns the latest mark price of a venue for a canonical symbol,
+// if the venue reports one with its funding rate
+func (s *SpreadDiscovery) MarkPrice(canonical string, exchange connector.ExchangeID) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	mark, ok := s.markPrices[canonical][exchange]
+	return mark, ok
+}
+
+// HandleTicker processes a REST price ticker, keeping 24h volume for scoring
+func (s *SpreadDiscovery) HandleTicker(ticker connector.PriceTicker) {
+	if ticker.Canonical == "" {
 		return
 	}
 
-	// Get all exchange orderbooks
-	var exchangeIDs []connector.ExchangeID
-	for id := range exchanges {
-		exchangeIDs = append(exchangeIDs, id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.volumes[ticker.Canonical] == nil {
+		s.volumes[ticker.Canonical] = make(map[connector.ExchangeID]float64)
 	}
+	s.volumes[ticker.Canonical][ticker.ExchangeID] = ticker.Volume24h
+}
 
-	// Check all pairs of exchanges
-	for i := 0; i < len(exchangeIDs); i++ {
-		for j := i + 1; j < len(exchangeIDs); j++ {
-			ob1 := exchanges[exchangeIDs[i]]
-			ob2 := exchanges[exchangeIDs[j]]
+// recalculatePairs re-evaluates, in both directions, every pair of the
+// venue in slot with the other venues quoting the canonical symbol. Pairs
+// not involving the venue can't have changed. Caller holds s.mu.
+func (s *SpreadDiscovery) recalculatePairs(canonical string, pairs *pairIndex, slot int) {
+	if len(pairs.books) < 2 {
+		return
+	}
+	ob := pairs.books[slot]
+	pc := &pairContext{
+		canonical:  canonical,
+		thresholds: s.thresholdsFor(canonical),
+	}
 
-			// Check both directions
-			s.checkSpread(canonical, ob1, ob2)
-			s.checkSpread(canonical, ob2, ob1)
+	for peer, other := range pairs.books {
+		if peer == slot || other == nil {
+			continue
 		}
+		s.checkSpread(pc, pairs.ids[slot][peer], ob, other)
+		s.checkSpread(pc, pairs.ids[peer][slot], other, ob)
 	}
 }
 
 // checkSpread checks if there's a profitable spread between two orderbooks
 // longOb is where we buy (use ask price), shortOb is where we sell (use bid price)
-func (s *SpreadDiscovery) checkSpread(canonical string, longOb, shortOb *connector.Orderbook) {
+func (s *SpreadDiscovery) checkSpread(pc *pairContext, spreadID string, longOb, shortOb *connector.Orderbook) {
+	canonical := pc.canonical
+
 	if len(longOb.Asks) == 0 || len(shortOb.Bids) == 0 {
 		return
 	}
+	if s.gated(longOb, shortOb) {
+		s.removeSpread(spreadID)
+		return
+	}
 
 	longPrice := longOb.Asks[0].Price   // Buy at ask
 	shortPrice := shortOb.Bids[0].Price // Sell at bid
@@ -181,7 +492,17 @@ func (s *SpreadDiscovery) checkSpread(canonical string, longOb, shortOb *connect
 	spreadBps := spreadPercent * 100
 
 	// Skip if spread is too small
-	if spreadBps < s.minSpreadBps {
+	if spreadBps < pc.thresholds.MinSpreadBps {
+		return
+	}
+	pc.load(s)
+	now := pc.now
+	if s.muted(longOb.ExchangeID, shortOb.ExchangeID, now) {
+		return
+	}
+	// Venue symbols tagged as false positives need a wider spread
+	quality := s.legQuality(canonical, longOb.ExchangeID, shortOb.ExchangeID)
+	if spreadBps < pc.thresholds.MinSpreadBps/quality {
 		return
 	}
 
@@ -191,45 +512,109 @@ func (s *SpreadDiscovery) checkSpread(canonical string, longOb, shortOb *connect
 	minDepth := math.Min(longDepth, shortDepth)
 
 	// Skip if depth is too small
-	if minDepth < s.minDepthUSD {
+	if minDepth < pc.thresholds.MinDepthUSD {
 		return
 	}
 
 	// Get funding rates
-	var longFunding, shortFunding float64
-	if rates, ok := s.fundingRates[canonical]; ok {
-		longFunding = rates[longOb.ExchangeID]
-		shortFunding = rates[shortOb.ExchangeID]
-	}
+	longFunding := pc.funding[longOb.ExchangeID]
+	shortFunding := pc.funding[shortOb.ExchangeID]
+
+	// Get 24h volumes
+	volume24h := pc.volumes[longOb.ExchangeID] + pc.volumes[shortOb.ExchangeID]
 
 	// Calculate opportunity score
 	// Higher spread, better funding, more depth = higher score
 	score := spreadBps * math.Log10(minDepth+1) * (1 + (shortFunding-longFunding)*100)
 
-	spreadID := fmt.Sprintf("%s:%s:%s", canonical, longOb.ExchangeID, shortOb.ExchangeID)
+	// Down-rank spreads that add to existing inventory, up-rank ones that unwind it
+	skewUSD := s.inventorySkewUSD(canonical, longOb.ExchangeID, shortOb.ExchangeID, longPrice)
+	score *= s.inventoryFactor(skewUSD)
+	score *= quality
+	tags := s.tags(canonical, now)
+	if skewUSD < 0 {
+		tags = append(tags, TagReducesInventory)
+	}
+	if longOb.Approximate || shortOb.Approximate {
+		tags = append(tags, TagApproximate)
+	}
+
+	sizedBps, _ := s.sizedSpreadBps(longOb, shortOb)
+
+	// Breakeven after fees, transfers and expected funding
+	breakevenBps := s.economics.BreakevenBps(longOb.ExchangeID, shortOb.ExchangeID, shortFunding-longFunding)
+
+	// The opportunity and its legs share one allocation
+	built := &builtSpread{legs: [2]Leg{bookLeg(longOb, SideBuy, longDepth, longFunding), bookLeg(shortOb, SideSell, shortDepth, shortFunding)}}
+	legs := built.legs[:]
+	s.stampMultipliers(legs)
+
+	opportunity := &built.SpreadOpportunity
+	*opportunity = SpreadOpportunity{
+		ID:             spreadID,
+		Canonical:      canonical,
+		Kind:           KindCrossVenue,
+		Legs:           legs,
+		LongExchange:   longOb.ExchangeID,
+		ShortExchange:  shortOb.ExchangeID,
+		LongSymbol:     longOb.Symbol,
+		ShortSymbol:    shortOb.Symbol,
+		LongPrice:      longPrice,
+		ShortPrice:     shortPrice,
+		SpreadPercent:  spreadPercent,
+		SpreadBps:      spreadBps,
+		SizedSpreadBps: sizedBps,
+		LongFunding:    longFunding,
+		ShortFunding:   shortFunding,
+		NetFunding:     shortFunding - longFunding,
+		LongDepthUSD:   longDepth,
+		ShortDepthUSD:  shortDepth,
+		MinDepthUSD:    minDepth,
+		Volume24h:      volume24h,
+		Score:          score,
+		Quality:        quality,
+		QuoteOnly:      connector.GetCapabilities(longOb.ExchangeID).QuoteOnly() || connector.GetCapabilities(shortOb.ExchangeID).QuoteOnly(),
+		LatencyMs:      math.Max(pipelineLatencyMs(longOb, now), pipelineLatencyMs(shortOb, now)),
+		BreakevenBps:   breakevenBps,
+		NetEdgeBps:     spreadBps - breakevenBps,
+		Profitable:     spreadBps > breakevenBps,
+		Tags:           tags,
+		SkewUSD:        skewUSD,
+		UpdatedAt:      now,
+		EventTime:      eventTime(longOb, shortOb, now),
+		LongQuoteAt:    quoteTime(longOb),
+		ShortQuoteAt:   quoteTime(shortOb),
+	}
+
+	if s.filtered(opportunity) {
+		metrics.SpreadsFiltered.Inc()
+		s.removeSpread(spreadID)
+		return
+	}
 
-	opportunity := &SpreadOpportunity{
-		ID:            spreadID,
-		Canonical:     canonical,
-		LongExchange:  longOb.ExchangeID,
-		ShortExchange: shortOb.ExchangeID,
-		LongSymbol:    longOb.Symbol,
-		ShortSymbol:   shortOb.Symbol,
-		LongPrice:     longPrice,
-		ShortPrice:    shortPrice,
-		SpreadPercent: spreadPercent,
-		SpreadBps:     spreadBps,
-		LongFunding:   longFunding,
-		ShortFunding:  shortFunding,
-		NetFunding:    shortFunding - longFunding,
-		LongDepthUSD:  longDepth,
-		ShortDepthUSD: shortDepth,
-		MinDepthUSD:   minDepth,
-		Score:         score,
-		UpdatedAt:     time.Now(),
+	s.storeSpread(opportunity)
+	if s.spreadHook != nil {
+		s.spreadHook(opportunity)
 	}
+}
+
+// builtSpread holds a cross-venue opportunity together with its legs
+type builtSpread struct {
+	SpreadOpportunity
+	legs [2]Leg
+}
 
-	s.spreads[spreadID] = opportunity
+// pipelineLatencyMs returns the time from the exchange event to now, in ms.
+// Books without an exchange timestamp fall back to the receive stamp.
+func pipelineLatencyMs(ob *connector.Orderbook, now time.Time) float64 {
+	from := ob.Timestamp
+	if from.IsZero() || from.Unix() <= 0 {
+		from = ob.ReceivedAt
+	}
+	if from.IsZero() {
+		return 0
+	}
+	return math.Max(float64(now.Sub(from))/float64(time.Millisecond), 0)
 }
 
 // calculateDepthUSD calculates depth in USD for top N levels
@@ -254,10 +639,7 @@ func (s *SpreadDiscovery) GetTopSpreads(n int) []*SpreadOpportunity {
 		spreads = append(spreads, spread)
 	}
 
-	// Sort by score descending
-	sort.Slice(spreads, func(i, j int) bool {
-		return spreads[i].Score > spreads[j].Score
-	})
+	sortByScore(spreads)
 
 	if n > len(spreads) {
 		n = len(spreads)
@@ -265,6 +647,33 @@ func (s *SpreadDiscovery) GetTopSpreads(n int) []*SpreadOpportunity {
 	return spreads[:n]
 }
 
+// GetSpread returns a current spread by ID, or nil if it is not tracked
+func (s *SpreadDiscovery) GetSpread(id string) *SpreadOpportunity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.spreads[id]
+}
+
+// Orderbook returns the latest streamed book of a venue for a canonical
+// symbol, or nil. Books are replaced, never mutated; callers must not modify it.
+func (s *SpreadDiscovery) Orderbook(canonical string, exchange connector.ExchangeID) *connector.Orderbook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.orderbooks[canonical][exchange]
+}
+
+// Orderbooks returns the latest streamed book of every venue quoting a
+// canonical symbol. Callers must not modify the books.
+func (s *SpreadDiscovery) Orderbooks(canonical string) map[connector.ExchangeID]*connector.Orderbook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[connector.ExchangeID]*connector.Orderbook, len(s.orderbooks[canonical]))
+	for id, ob := range s.orderbooks[canonical] {
+		result[id] = ob
+	}
+	return result
+}
+
 // GetSpreadsByCanonical returns all spreads for a canonical symbol
 func (s *SpreadDiscovery) GetSpreadsByCanonical(canonical string) []*SpreadOpportunity {
 	s.mu.RLock()
@@ -277,16 +686,40 @@ func (s *SpreadDiscovery) GetSpreadsByCanonical(canonical string) []*SpreadOppor
 		}
 	}
 
+	sortByScore(spreads)
+
+	return spreads
+}
+
+// sortByScore sorts spreads by score descending. Ties are broken by ID so
+// the order never depends on map iteration.
+func sortByScore(spreads []*SpreadOpportunity) {
 	sort.Slice(spreads, func(i, j int) bool {
-		return spreads[i].Score > spreads[j].Score
+		if spreads[i].Score != spreads[j].Score {
+			return spreads[i].Score > spreads[j].Score
+		}
+		return spreads[i].ID < spreads[j].ID
 	})
+}
 
+// PublishedSpreads returns what a publish cycle emits now: the top 100
+// spreads, stamped with their quote ages and rebalance times
+func (s *SpreadDiscovery) PublishedSpreads() []*SpreadOpportunity {
+	spreads := s.withQuoteAges(s.GetTopSpreads(100), s.clock())
+	s.stampRebalanceTimes(spreads)
 	return spreads
 }
 
+// clock reads the discovery clock
+func (s *SpreadDiscovery) clock() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.now()
+}
+
 // publishSpreads publishes current spreads to Redis
 func (s *SpreadDiscovery) publishSpreads() {
-	topSpreads := s.GetTopSpreads(100)
+	topSpreads := s.PublishedSpreads()
 
 	for _, spread := range topSpreads {
 		data, err := json.Marshal(spread)
@@ -296,39 +729,43 @@ func (s *SpreadDiscovery) publishSpreads() {
 		}
 
 		// Store spread as a Redis key (for backend API to read)
-		if err := s.publisher.SetSpread(spread.ID, data); err != nil {
+		if err := s.publisher.SetSpread(spread.ID, data, QuoteAge(spread)); err != nil {
 			log.Error().Err(err).Str("spread", spread.ID).Msg("Failed to store spread")
 		}
 
 		// Publish to Redis channel (for real-time WebSocket updates)
-		channel := fmt.Sprintf("spread:%s", spread.Canonical)
+		channel := keyspace.SpreadChannel(spread.Canonical)
 		if err := s.publisher.Publish(channel, string(data)); err != nil {
 			log.Error().Err(err).Str("channel", channel).Msg("Failed to publish spread")
 		}
 
 		// Also publish to spread-specific channel
-		spreadChannel := fmt.Sprintf("spread:%s", spread.ID)
+		spreadChannel := keyspace.SpreadChannel(spread.ID)
 		if err := s.publisher.Publish(spreadChannel, string(data)); err != nil {
 			log.Error().Err(err).Str("channel", spreadChannel).Msg("Failed to publish spread detail")
 		}
 	}
 
 	// Publish summary of top spreads and store as a list
-	summary := struct {
-		Timestamp time.Time            `json:"timestamp"`
-		Count     int                  `json:"count"`
-		Top10     []*SpreadOpportunity `json:"top_10"`
-		Spreads   []*SpreadOpportunity `json:"spreads"`
-	}{
-		Timestamp: time.Now(),
+	summary := SpreadSummary{
+		Timestamp: s.clock(),
 		Count:     len(topSpreads),
 		Top10:     topSpreads[:min(10, len(topSpreads))],
 		Spreads:   topSpreads,
 	}
 
 	data, _ := json.Marshal(summary)
-	s.publisher.Publish("spreads:summary", string(data))
+	s.publisher.Publish(keyspace.Key(keyspace.SpreadsSummaryChan), string(data))
 	s.publisher.SetSpreadsList(data)
+	if _, err := s.publisher.PruneActiveSpreads(); err != nil {
+		log.Error().Err(err).Msg("Failed to prune active spreads")
+	}
+	s.publishTenantSpreads(topSpreads)
+	s.publishBasis()
+
+	if s.spreadsHandler != nil {
+		s.spreadsHandler(topSpreads)
+	}
 }
 
 func min(a, b int) int {
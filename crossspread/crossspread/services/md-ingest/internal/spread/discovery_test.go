@@ -0,0 +1,233 @@
+package spread
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/orderbook"
+	"crossspread-md-ingest/internal/symbolmap"
+)
+
+var benchVenues = []connector.ExchangeID{
+	connector.Binance, connector.Bybit, connector.OKX, connector.KuCoin, connector.MEXC, connector.Bitget,
+	connector.GateIO, connector.BingX, connector.CoinEx, connector.LBank, connector.HTX,
+}
+
+func testBook(canonical string, venue connector.ExchangeID, bid, ask float64) *connector.Orderbook {
+	levels := func(price, step float64) []connector.PriceLevel {
+		out := make([]connector.PriceLevel, 5)
+		for i := range out {
+			out[i] = connector.PriceLevel{Price: price + step*float64(i), Quantity: 50}
+		}
+		return out
+	}
+	now := time.Now()
+	return &connector.Orderbook{
+		ExchangeID: venue,
+		Symbol:     canonical + "USDT",
+		Canonical:  canonical,
+		Bids:       levels(bid, -0.01),
+		Asks:       levels(ask, 0.01),
+		Timestamp:  now,
+		ReceivedAt: now,
+	}
+}
+
+func TestHandleOrderbookEvaluatesUpdatedVenuePairs(t *testing.T) {
+	s := NewSpreadDiscovery(nil, nil)
+	s.SetThresholds(Thresholds{MinSpreadBps: 1, MinDepthUSD: 100})
+
+	s.HandleOrderbook(testBook("BTC", connector.Binance, 100, 100.01))
+	s.HandleOrderbook(testBook("BTC", connector.Bybit, 100, 100.01))
+	if n := len(s.GetSpreadsByCanonical("BTC")); n != 0 {
+		t.Fatalf("got %d spreads between equal books, want 0", n)
+	}
+
+	// OKX bids above both asks: both venues buy there, OKX sells
+	s.HandleOrderbook(testBook("BTC", connector.OKX, 100.5, 100.51))
+	for _, id := range []string{"BTC:binance:okx", "BTC:bybit:okx"} {
+		if s.GetSpread(id) == nil {
+			t.Errorf("missing spread %s", id)
+		}
+	}
+	if s.GetSpread("BTC:okx:binance") != nil {
+		t.Error("unexpected spread BTC:okx:binance")
+	}
+
+	// A later venue pairs with all earlier ones in both directions
+	s.HandleOrderbook(testBook("BTC", connector.KuCoin, 99, 99.01))
+	for _, id := range []string{"BTC:kucoin:binance", "BTC:kucoin:bybit", "BTC:kucoin:okx"} {
+		if s.GetSpread(id) == nil {
+			t.Errorf("missing spread %s", id)
+		}
+	}
+}
+
+// Deribit lists USDC perpetuals only; they pair with USDT ones elsewhere
+func TestDeribitUSDCPairsWithUSDT(t *testing.T) {
+	s := NewSpreadDiscovery(nil, nil)
+	s.SetThresholds(Thresholds{MinSpreadBps: 1, MinDepthUSD: 100})
+
+	deribit := testBook("", connector.Deribit, 100.5, 100.51)
+	deribit.Symbol = "BTC_USDC-PERPETUAL"
+	deribit.Canonical = connector.ParseSymbol(connector.Deribit, deribit.Symbol).Canonical()
+	binance := testBook("", connector.Binance, 100, 100.01)
+	binance.Symbol = "BTCUSDT"
+	binance.Canonical = connector.ParseSymbol(connector.Binance, binance.Symbol).Canonical()
+
+	s.HandleOrderbook(deribit)
+	s.HandleOrderbook(binance)
+
+	sp := s.GetSpread("BTC:binance:deribit")
+	if sp == nil {
+		t.Fatalf("no spread between %s and %s", deribit.Canonical, binance.Canonical)
+	}
+	if sp.Legs[1].Quote != "USDC" || sp.Legs[0].Quote != "" {
+		t.Errorf("leg quotes %q, %q, want USDC on the Deribit leg only", sp.Legs[0].Quote, sp.Legs[1].Quote)
+	}
+	if sym := connector.ParseCanonical(sp.Canonical).ExchangeSymbol(connector.Deribit); sym != deribit.Symbol {
+		t.Errorf("canonical %s maps back to %s on Deribit, want %s", sp.Canonical, sym, deribit.Symbol)
+	}
+}
+
+func TestSpotBookFindsBasisOnSameVenue(t *testing.T) {
+	s := NewSpreadDiscovery(nil, nil)
+	s.SetThresholds(Thresholds{MinSpreadBps: 1, MinDepthUSD: 100})
+
+	s.HandleOrderbook(testBook("BTC", connector.Binance, 100.5, 100.51))
+	spot := testBook("BTC", connector.Binance, 100, 100.01)
+	spot.Market = connector.MarketSpot
+	s.HandleOrderbook(spot)
+
+	// The spot book is not a venue of its own for cross-venue pairs
+	if n := len(s.GetSpreadsByCanonical("BTC")); n != 0 {
+		t.Fatalf("got %d cross-venue spreads, want 0", n)
+	}
+	basis := s.GetBasis(10)
+	if len(basis) != 1 || basis[0].ID != "BTC:binance:cash_and_carry" {
+		t.Fatalf("got basis %+v, want BTC:binance:cash_and_carry", basis)
+	}
+
+	// A perp update that closes the basis removes it
+	s.HandleOrderbook(testBook("BTC", connector.Binance, 100, 100.01))
+	if basis := s.GetBasis(10); len(basis) != 0 {
+		t.Errorf("got %d basis after it closed, want 0", len(basis))
+	}
+}
+
+// benchmarkUpdates feeds pre-built books for every venue of canonicals
+// symbols round-robin, so each iteration is one streamed update
+func benchmarkUpdates(b *testing.B, canonicals int, bid func(venue int) float64) {
+	s := NewSpreadDiscovery(nil, nil)
+	s.SetThresholds(Thresholds{MinSpreadBps: 1, MinDepthUSD: 100})
+
+	var updates []*connector.Orderbook
+	for c := 0; c < canonicals; c++ {
+		canonical := fmt.Sprintf("C%d", c)
+		for v, venue := range benchVenues {
+			ob := testBook(canonical, venue, bid(v), bid(v)+0.01)
+			s.HandleOrderbook(ob)
+			updates = append(updates, ob)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.HandleOrderbook(updates[i%len(updates)])
+	}
+}
+
+// BenchmarkHandleOrderbook measures one update against 10 other venues of
+// the same symbol. Updates moving no pair past the spread threshold read
+// no clock, depth or funding and take about 0.4µs without allocating.
+// Updates that build opportunities miss the 1µs target: each opportunity
+// costs about 1.2µs and one allocation, so spreads/1_symbol, averaging two
+// per update, runs near 2.7µs. Opportunities are not reused because the
+// spread hook, the admin API and the publish cycle keep pointers to them
+// outside s.mu.
+func BenchmarkHandleOrderbook(b *testing.B) {
+	flat := func(int) float64 { return 100 }
+	b.Run("no_spread/1_symbol", func(b *testing.B) { benchmarkUpdates(b, 1, flat) })
+	b.Run("no_spread/500_symbols", func(b *testing.B) { benchmarkUpdates(b, 500, flat) })
+
+	// One venue quotes 50 bps rich, so its 20 pairs hold 10 spreads
+	rich := func(v int) float64 {
+		if v == 0 {
+			return 100.5
+		}
+		return 100
+	}
+	b.Run("spreads/1_symbol", func(b *testing.B) { benchmarkUpdates(b, 1, rich) })
+}
+
+func TestTopNBoundsSpreadsPerSymbol(t *testing.T) {
+	s := NewSpreadDiscovery(nil, nil)
+	s.SetThresholds(Thresholds{MinSpreadBps: 1, MinDepthUSD: 100})
+	s.SetTopN(2)
+
+	// Bids rise 10 bps per venue, so later venues are the best short legs
+	for v, venue := range benchVenues {
+		price := 100 * (1 + float64(v)*0.001)
+		s.HandleOrderbook(testBook("BTC", venue, price, price+0.001))
+	}
+
+	spreads := s.GetSpreadsByCanonical("BTC")
+	if len(spreads) == 0 || len(spreads) > 4 {
+		t.Fatalf("got %d spreads, want 1 to 4 (top 2 by bps and by net edge)", len(spreads))
+	}
+	// Buying on the cheapest venue and selling on the richest is the widest spread
+	best := spreadID("BTC", benchVenues[0], benchVenues[len(benchVenues)-1])
+	if s.GetSpread(best) == nil {
+		t.Errorf("widest spread %s was evicted", best)
+	}
+	for _, sp := range spreads {
+		if sp.SpreadBps < 80 {
+			t.Errorf("kept %s at %.1f bps over wider spreads", sp.ID, sp.SpreadBps)
+		}
+	}
+
+	// Gating or muting removes the spread from the rankings too
+	s.MutePair(PairMute{Long: benchVenues[0]})
+	if s.GetSpread(best) != nil {
+		t.Errorf("muted spread %s still tracked", best)
+	}
+}
+
+// OKX books are in contracts of 0.01 BTC; the sized spread walks them for
+// the notional in contracts, not as if each were a coin
+func TestSizedSpreadAppliesContractSize(t *testing.T) {
+	s := NewSpreadDiscovery(nil, nil)
+	symbols := symbolmap.New()
+	symbols.Register([]connector.Instrument{
+		{ExchangeID: connector.OKX, Symbol: "BTC-USDT-SWAP", BaseAsset: "BTC", QuoteAsset: "USDT", InstrumentType: "perpetual", ContractSize: 0.01},
+		{ExchangeID: connector.Binance, Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT", InstrumentType: "perpetual"},
+	})
+	s.SetSymbolMap(symbols)
+	books := orderbook.NewEngine(orderbook.DefaultConfig())
+	s.SetBooks(books)
+	s.economics.NotionalUSD = 500
+
+	// 300 contracts at 100 are $300; the other $200 fills at 101
+	okx := orderbook.Update{Exchange: connector.OKX, Symbol: "BTC-USDT-SWAP", Snapshot: true, LastSeq: 1,
+		Asks: []connector.PriceLevel{{Price: 100, Quantity: 300}, {Price: 101, Quantity: 10000}}}
+	binance := orderbook.Update{Exchange: connector.Binance, Symbol: "BTCUSDT", Snapshot: true, LastSeq: 1,
+		Bids: []connector.PriceLevel{{Price: 110, Quantity: 100}}}
+	for _, u := range []orderbook.Update{okx, binance} {
+		if err := books.Apply(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bps, ok := s.sizedSpreadBps(
+		&connector.Orderbook{ExchangeID: connector.OKX, Symbol: "BTC-USDT-SWAP"},
+		&connector.Orderbook{ExchangeID: connector.Binance, Symbol: "BTCUSDT"},
+	)
+	buyPrice := 500 / (3 + 200/101.0)
+	if want := (110 - buyPrice) / buyPrice * 10000; !ok || math.Abs(bps-want) > 1e-6 {
+		t.Fatalf("sized spread = %.4f bps, %v, want %.4f", bps, ok, want)
+	}
+}
@@ -0,0 +1,113 @@
+package spread
+
+import (
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// EconomicsConfig holds the account-level costs used to turn a raw spread
+// into a profitable/unprofitable verdict
+type EconomicsConfig struct {
+	TakerFeeBps            map[connector.ExchangeID]float64 // Account taker fee per exchange
+	DefaultTakerFeeBps     float64                          // Used for exchanges missing from TakerFeeBps
+	SpotTakerFeeBps        map[connector.ExchangeID]float64 // Account spot taker fee per exchange, for basis trades
+	DefaultSpotTakerFeeBps float64                          // Used for exchanges missing from SpotTakerFeeBps
+	TransferCostUSD        float64                          // Typical cost of moving margin between venues per round trip
+	NotionalUSD            float64                          // Trade size the transfer cost is amortized over
+	TransferTime           time.Duration                    // Time to move margin between venues on routes without observed transfers
+	HoldingPeriod          time.Duration                    // Expected time until the spread converges
+	FundingInterval        time.Duration                    // Interval funding rates are quoted for
+}
+
+// DefaultEconomicsConfig returns base-tier (VIP 0) taker fees and a
+// conservative transfer cost, transfer time and holding time
+func DefaultEconomicsConfig() EconomicsConfig {
+	return EconomicsConfig{
+		TakerFeeBps: map[connector.ExchangeID]float64{
+			connector.Binance:  5.0,
+			connector.Bybit:    5.5,
+			connector.OKX:      5.0,
+			connector.KuCoin:   6.0,
+			connector.MEXC:     2.0,
+			connector.Bitget:   6.0,
+			connector.GateIO:   5.0,
+			connector.BingX:    5.0,
+			connector.CoinEx:   5.0,
+			connector.HTX:      5.0,
+			connector.LBank:    6.0,
+			connector.WhiteBIT: 5.5,
+			connector.BitMart:  6.0,
+			connector.XT:       6.0,
+			connector.Bitrue:   6.0,
+			connector.Deribit:  5.0,
+		},
+		DefaultTakerFeeBps:     6.0,
+		SpotTakerFeeBps:        map[connector.ExchangeID]float64{},
+		DefaultSpotTakerFeeBps: 10.0,
+		TransferCostUSD:        2.0,
+		NotionalUSD:            10000,
+		TransferTime:           time.Hour,
+		HoldingPeriod:          8 * time.Hour,
+		FundingInterval:        8 * time.Hour,
+	}
+}
+
+// takerFeeBps returns the taker fee for an exchange
+func (c EconomicsConfig) takerFeeBps(id connector.ExchangeID) float64 {
+	if fee, ok := c.TakerFeeBps[id]; ok {
+		return fee
+	}
+	return c.DefaultTakerFeeBps
+}
+
+// TakerFee returns the account taker fee for an exchange in bps
+func (c EconomicsConfig) TakerFee(id connector.ExchangeID) float64 {
+	return c.takerFeeBps(id)
+}
+
+// BreakevenBps returns the spread needed to cover costs of a round trip:
+// taker fees to open and close both legs plus the amortized transfer cost,
+// less the funding collected over the expected holding period.
+// netFunding is short funding minus long funding, per funding interval.
+func (c EconomicsConfig) BreakevenBps(long, short connector.ExchangeID, netFunding float64) float64 {
+	feesBps := 2 * (c.takerFeeBps(long) + c.takerFeeBps(short))
+
+	var transferBps float64
+	if c.NotionalUSD > 0 {
+		transferBps = c.TransferCostUSD / c.NotionalUSD * 10000
+	}
+
+	var fundingBps float64
+	if c.FundingInterval > 0 {
+		intervals := float64(c.HoldingPeriod) / float64(c.FundingInterval)
+		fundingBps = netFunding * intervals * 10000
+	}
+
+	return feesBps + transferBps - fundingBps
+}
+
+// spotTakerFeeBps returns the spot taker fee for an exchange
+func (c EconomicsConfig) spotTakerFeeBps(id connector.ExchangeID) float64 {
+	if fee, ok := c.SpotTakerFeeBps[id]; ok {
+		return fee
+	}
+	return c.DefaultSpotTakerFeeBps
+}
+
+// BasisBreakevenBps returns the basis needed to cover a spot-vs-perp round
+// trip on one venue: spot and perp taker fees to open and close, less the
+// funding the perp leg collects over the holding period. Both legs share
+// the venue, so there is no transfer cost. perpFunding is the funding the
+// perp leg receives per interval: the rate when short, its negation when long.
+func (c EconomicsConfig) BasisBreakevenBps(exchange connector.ExchangeID, perpFunding float64) float64 {
+	feesBps := 2 * (c.spotTakerFeeBps(exchange) + c.takerFeeBps(exchange))
+
+	var fundingBps float64
+	if c.FundingInterval > 0 {
+		intervals := float64(c.HoldingPeriod) / float64(c.FundingInterval)
+		fundingBps = perpFunding * intervals * 10000
+	}
+
+	return feesBps - fundingBps
+}
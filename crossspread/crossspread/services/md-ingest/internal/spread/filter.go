@@ -0,0 +1,105 @@
+package spread
+
+import (
+	"crossspread-md-ingest/internal/filterexpr"
+)
+
+// filterFields are the opportunity fields a filter expression may use
+var filterFields = map[string]struct {
+	typ filterexpr.Type
+	get func(o *SpreadOpportunity) filterexpr.Value
+}{
+	"canonical":         {filterexpr.String, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Str: o.Canonical} }},
+	"long_exchange":     {filterexpr.String, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Str: string(o.LongExchange)} }},
+	"short_exchange":    {filterexpr.String, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Str: string(o.ShortExchange)} }},
+	"long_symbol":       {filterexpr.String, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Str: o.LongSymbol} }},
+	"short_symbol":      {filterexpr.String, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Str: o.ShortSymbol} }},
+	"spread_bps":        {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.SpreadBps} }},
+	"sized_spread_bps":  {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.SizedSpreadBps} }},
+	"net_edge_bps":      {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.NetEdgeBps} }},
+	"breakeven_bps":     {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.BreakevenBps} }},
+	"long_funding":      {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.LongFunding} }},
+	"short_funding":     {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.ShortFunding} }},
+	"net_funding":       {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.NetFunding} }},
+	"long_depth_usd":    {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.LongDepthUSD} }},
+	"short_depth_usd":   {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.ShortDepthUSD} }},
+	"min_liquidity_usd": {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.MinDepthUSD} }},
+	"volume_24h":        {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.Volume24h} }},
+	"score":             {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.Score} }},
+	"quality":           {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.Quality} }},
+	"latency_ms":        {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.LatencyMs} }},
+	"skew_usd":          {filterexpr.Number, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Num: o.SkewUSD} }},
+	"profitable":        {filterexpr.Bool, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Bool: o.Profitable} }},
+	"quote_only":        {filterexpr.Bool, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{Bool: o.QuoteOnly} }},
+	"tags":              {filterexpr.List, func(o *SpreadOpportunity) filterexpr.Value { return filterexpr.Value{List: o.Tags} }},
+}
+
+// FilterFields returns the fields a filter expression may use, by name
+func FilterFields() map[string]string {
+	fields := make(map[string]string, len(filterFields))
+	for name, f := range filterFields {
+		fields[name] = f.typ.String()
+	}
+	return fields
+}
+
+// CompileFilter compiles a filter over opportunity fields, e.g.
+// spread_bps > 8 && min_liquidity_usd > 50000 && long_exchange != "lbank"
+func CompileFilter(src string) (*filterexpr.Expr, error) {
+	schema := make(filterexpr.Schema, len(filterFields))
+	for name, f := range filterFields {
+		schema[name] = f.typ
+	}
+	return filterexpr.Compile(src, schema)
+}
+
+type filterEnv struct {
+	o *SpreadOpportunity
+}
+
+func (e filterEnv) Lookup(name string) filterexpr.Value {
+	return filterFields[name].get(e.o)
+}
+
+// MatchFilter reports whether an opportunity passes a filter
+func MatchFilter(expr *filterexpr.Expr, o *SpreadOpportunity) bool {
+	return expr.Match(filterEnv{o})
+}
+
+// SetFilter publishes only opportunities the expression matches; nil
+// publishes all of them
+func (s *SpreadDiscovery) SetFilter(expr *filterexpr.Expr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = expr
+}
+
+// Filter returns the filter's source, empty when there is none
+func (s *SpreadDiscovery) Filter() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.filter == nil {
+		return ""
+	}
+	return s.filter.String()
+}
+
+// filtered returns true if the filter rejects an opportunity. Caller holds s.mu.
+func (s *SpreadDiscovery) filtered(o *SpreadOpportunity) bool {
+	return s.filter != nil && !MatchFilter(s.filter, o)
+}
+
+// MatchingSpreads returns the current spreads an expression matches, by
+// score, to try a filter before applying it
+func (s *SpreadDiscovery) MatchingSpreads(expr *filterexpr.Expr) []*SpreadOpportunity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matched := make([]*SpreadOpportunity, 0)
+	for _, o := range s.spreads {
+		if MatchFilter(expr, o) {
+			matched = append(matched, o)
+		}
+	}
+	sortByScore(matched)
+	return matched
+}
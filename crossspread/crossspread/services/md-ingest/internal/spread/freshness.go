@@ -0,0 +1,113 @@
+package spread
+
+import (
+	"math"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// QuoteAgeConfig controls how stale legs shrink the reported effective edge
+type QuoteAgeConfig struct {
+	Grace    time.Duration // Ages up to Grace don't decay (normal feed latency)
+	HalfLife time.Duration // Age beyond Grace at which the edge is halved; 0 disables decay
+}
+
+// DefaultQuoteAgeConfig returns a decay that leaves healthy feeds untouched
+// and halves the edge of a leg that has been silent for two seconds
+func DefaultQuoteAgeConfig() QuoteAgeConfig {
+	return QuoteAgeConfig{
+		Grace:    250 * time.Millisecond,
+		HalfLife: 2 * time.Second,
+	}
+}
+
+// Decay returns the factor in (0, 1] applied to an edge quoted at the given age
+func (c QuoteAgeConfig) Decay(age time.Duration) float64 {
+	if c.HalfLife <= 0 || age <= c.Grace {
+		return 1
+	}
+	return math.Pow(0.5, float64(age-c.Grace)/float64(c.HalfLife))
+}
+
+// ExpiryDecay is the decay below which a spread's edge no longer counts
+const ExpiryDecay = 0.01
+
+// Expiry returns the quote age at which a spread has decayed to
+// ExpiryDecay, rounded up to a second: how long a published spread stays
+// worth acting on, counted from its older leg's quote rather than from the
+// publish. The publisher shortens the key's TTL by QuoteAge. It is zero
+// when decay is disabled.
+func (c QuoteAgeConfig) Expiry() time.Duration {
+	if c.HalfLife <= 0 {
+		return 0
+	}
+	halvings := -math.Log2(ExpiryDecay)
+	age := c.Grace + time.Duration(halvings*float64(c.HalfLife))
+	return age.Truncate(time.Second) + time.Second
+}
+
+// EffectiveEdgeBps decays a positive edge by the older leg's age. Negative
+// edges are left alone so staleness never makes a loss look smaller.
+func (c QuoteAgeConfig) EffectiveEdgeBps(netEdgeBps float64, age time.Duration) float64 {
+	if netEdgeBps <= 0 {
+		return netEdgeBps
+	}
+	return netEdgeBps * c.Decay(age)
+}
+
+// QuoteAge returns the age of a published spread's older leg quote, as
+// stamped by PublishedSpreads
+func QuoteAge(sp *SpreadOpportunity) time.Duration {
+	return time.Duration(max(sp.LongQuoteAgeMs, sp.ShortQuoteAgeMs) * float64(time.Millisecond))
+}
+
+// quoteTime returns when a book's quote was current: the exchange event
+// time, or the receive time for venues without one
+func quoteTime(ob *connector.Orderbook) time.Time {
+	if ob.Timestamp.IsZero() || ob.Timestamp.Unix() <= 0 {
+		return ob.ReceivedAt
+	}
+	return ob.Timestamp
+}
+
+// eventTime returns the exchange time a spread's state became current: the
+// newer of its legs' quote times, or now if neither has one
+func eventTime(longOb, shortOb *connector.Orderbook, now time.Time) time.Time {
+	at := quoteTime(longOb)
+	if short := quoteTime(shortOb); short.After(at) {
+		at = short
+	}
+	if at.IsZero() {
+		return now
+	}
+	return at
+}
+
+// quoteAge returns the age of a quote at now
+func quoteAge(at, now time.Time) time.Duration {
+	if at.IsZero() || now.Before(at) {
+		return 0
+	}
+	return now.Sub(at)
+}
+
+// withQuoteAges returns copies of spreads stamped with both legs' quote ages
+// at now and the edge decayed by the older of them
+func (s *SpreadDiscovery) withQuoteAges(spreads []*SpreadOpportunity, now time.Time) []*SpreadOpportunity {
+	s.mu.RLock()
+	cfg := s.quoteAge
+	s.mu.RUnlock()
+
+	result := make([]*SpreadOpportunity, len(spreads))
+	for i, sp := range spreads {
+		cp := *sp
+		longAge := quoteAge(sp.LongQuoteAt, now)
+		shortAge := quoteAge(sp.ShortQuoteAt, now)
+		cp.LongQuoteAgeMs = float64(longAge) / float64(time.Millisecond)
+		cp.ShortQuoteAgeMs = float64(shortAge) / float64(time.Millisecond)
+		cp.EffectiveEdgeBps = cfg.EffectiveEdgeBps(sp.NetEdgeBps, max(longAge, shortAge))
+		result[i] = &cp
+	}
+	return result
+}
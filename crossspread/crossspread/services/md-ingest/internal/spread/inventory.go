@@ -0,0 +1,63 @@
+package spread
+
+import (
+	"math"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// TagReducesInventory marks spreads whose trade would unwind existing
+// inventory on either leg
+const TagReducesInventory = "reduces_inventory"
+
+// InventorySource returns the net position on a venue in base units;
+// *inventory.Store implements it
+type InventorySource interface {
+	Position(exchange connector.ExchangeID, canonical string) float64
+}
+
+// InventoryConfig controls skew-aware scoring
+type InventoryConfig struct {
+	// ScaleUSD is the skew at which the full weight applies
+	ScaleUSD float64
+	// Weight scales the score by 1 - Weight for a spread adding ScaleUSD of
+	// skew and 1 + Weight for one removing as much. Above 1, spreads that
+	// add to a large skew score negative and rank below everything else.
+	Weight float64
+}
+
+// DefaultInventoryConfig halves the score of spreads adding $50k of skew
+func DefaultInventoryConfig() InventoryConfig {
+	return InventoryConfig{
+		ScaleUSD: 50000,
+		Weight:   0.5,
+	}
+}
+
+// SetInventory enables skew-aware scoring against current positions
+func (s *SpreadDiscovery) SetInventory(src InventorySource, cfg InventoryConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inventory = src
+	s.inventoryConfig = cfg
+}
+
+// inventorySkewUSD returns how much a spread's trade (buy on long, sell on
+// short) adds to existing inventory: positive if we are already long the
+// long venue or short the short venue, negative if it unwinds.
+// Caller holds s.mu.
+func (s *SpreadDiscovery) inventorySkewUSD(canonical string, long, short connector.ExchangeID, price float64) float64 {
+	if s.inventory == nil {
+		return 0
+	}
+	return (s.inventory.Position(long, canonical) - s.inventory.Position(short, canonical)) * price
+}
+
+// inventoryFactor returns the score multiplier for a skew. Caller holds s.mu.
+func (s *SpreadDiscovery) inventoryFactor(skewUSD float64) float64 {
+	if s.inventoryConfig.ScaleUSD <= 0 || skewUSD == 0 {
+		return 1
+	}
+	x := math.Max(-1, math.Min(1, skewUSD/s.inventoryConfig.ScaleUSD))
+	return 1 - x*s.inventoryConfig.Weight
+}
@@ -0,0 +1,55 @@
+package spread
+
+import (
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/pkg/mdtypes"
+)
+
+// Opportunity kinds. Every kind lists its orders as legs, so consumers
+// handle any of them through one format; the kind says how the legs relate.
+const (
+	KindCrossVenue = "cross_venue" // Same perpetual bought on one venue and sold on another
+	KindBasis      = "basis"       // A venue's spot against its perpetual
+	KindCalendar   = "calendar"    // Contracts of one asset with different expiries
+	KindTriangular = "triangular"  // Three or more pairs forming a cycle
+)
+
+// Leg sides
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// Leg markets, as in connector.Instrument.InstrumentType
+const (
+	MarketPerpetual = "perpetual"
+	MarketSpot      = "spot"
+	MarketFuture    = "future"
+)
+
+// Leg is one order of an opportunity
+type Leg = mdtypes.Leg
+
+// bookLeg returns the leg taking the top of a book's side: its asks when
+// buying, its bids when selling. The book must have a level on that side.
+func bookLeg(ob *connector.Orderbook, side string, depthUSD, funding float64) Leg {
+	level := ob.Bids[0]
+	if side == SideBuy {
+		level = ob.Asks[0]
+	}
+	market := MarketPerpetual
+	if ob.Market == connector.MarketSpot {
+		market, funding = MarketSpot, 0
+	}
+	return Leg{
+		Exchange: ob.ExchangeID,
+		Symbol:   ob.Symbol,
+		Market:   market,
+		Side:     side,
+		Price:    level.Price,
+		Size:     level.Quantity,
+		DepthUSD: depthUSD,
+		Funding:  funding,
+		Quote:    connector.NativeQuote(ob.ExchangeID, ob.Canonical),
+	}
+}
@@ -0,0 +1,209 @@
+package spread
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxMuteAudit is the number of audit records kept for the admin API
+const maxMuteAudit = 500
+
+// Mute audit actions
+const (
+	MuteActionMute   = "mute"
+	MuteActionResume = "resume"
+	MuteActionExpire = "expire"
+)
+
+// MaintenanceBy marks mutes the maintenance scheduler makes for a venue's
+// known maintenance windows. They are local to each instance, so SyncMutes
+// leaves them alone.
+const MaintenanceBy = "maintenance"
+
+// PairMute suspends discovery of spreads between two exchanges. An empty
+// leg matches any exchange, so {Long: lbank} mutes every spread that buys
+// on LBank whatever the short venue.
+type PairMute struct {
+	ID     string               `json:"id"`
+	Long   connector.ExchangeID `json:"long,omitempty"`
+	Short  connector.ExchangeID `json:"short,omitempty"`
+	Reason string               `json:"reason,omitempty"`
+	By     string               `json:"by"`
+	At     time.Time            `json:"at"`
+	Until  time.Time            `json:"until,omitempty"` // Zero mutes until resumed
+}
+
+// MuteID returns the ID of the mute covering a long/short pair; "*" stands
+// for any exchange
+func MuteID(long, short connector.ExchangeID) string {
+	leg := func(id connector.ExchangeID) string {
+		if id == "" {
+			return "*"
+		}
+		return string(id)
+	}
+	return leg(long) + ":" + leg(short)
+}
+
+// Matches returns true if the mute covers a spread buying on long and
+// selling on short
+func (m PairMute) Matches(long, short connector.ExchangeID) bool {
+	return (m.Long == "" || m.Long == long) && (m.Short == "" || m.Short == short)
+}
+
+func (m PairMute) expired(now time.Time) bool {
+	return !m.Until.IsZero() && !now.Before(m.Until)
+}
+
+// MuteAudit records who muted or resumed a pair and when
+type MuteAudit struct {
+	Action string    `json:"action"`
+	Mute   PairMute  `json:"mute"`
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+}
+
+// MutePair suspends discovery for an exchange pair and drops its current
+// spreads. Muting a pair that is already muted replaces the mute.
+func (s *SpreadDiscovery) MutePair(m PairMute) PairMute {
+	m.Long = connector.ExchangeID(strings.ToLower(string(m.Long)))
+	m.Short = connector.ExchangeID(strings.ToLower(string(m.Short)))
+	m.ID = MuteID(m.Long, m.Short)
+	if m.At.IsZero() {
+		m.At = time.Now()
+	}
+
+	s.mu.Lock()
+	s.mutes[m.ID] = m
+	for id, spread := range s.spreads {
+		if m.Matches(spread.LongExchange, spread.ShortExchange) {
+			s.removeSpread(id)
+		}
+	}
+	s.audit(MuteActionMute, m, m.By, m.At)
+	s.mu.Unlock()
+
+	log.Info().
+		Str("mute", m.ID).
+		Str("by", m.By).
+		Str("reason", m.Reason).
+		Time("until", m.Until).
+		Msg("Exchange pair muted")
+	return m
+}
+
+// ResumePair lifts a mute. Returns false if no such mute exists.
+func (s *SpreadDiscovery) ResumePair(id, by string) (PairMute, bool) {
+	s.mu.Lock()
+	m, ok := s.mutes[id]
+	if ok {
+		delete(s.mutes, id)
+		s.audit(MuteActionResume, m, by, time.Now())
+	}
+	s.mu.Unlock()
+
+	if ok {
+		log.Info().Str("mute", id).Str("by", by).Msg("Exchange pair resumed")
+	}
+	return m, ok
+}
+
+// SyncMutes makes the active mutes match a shared set, e.g. mutes stored in
+// Redis by another instance or the backend. Mutes that are already active
+// unchanged are left alone so the audit trail only records real changes;
+// mutes missing from the set are resumed by by, except maintenance mutes.
+func (s *SpreadDiscovery) SyncMutes(mutes []PairMute, by string) {
+	wanted := make(map[string]PairMute, len(mutes))
+	for _, m := range mutes {
+		m.Long = connector.ExchangeID(strings.ToLower(string(m.Long)))
+		m.Short = connector.ExchangeID(strings.ToLower(string(m.Short)))
+		m.ID = MuteID(m.Long, m.Short)
+		wanted[m.ID] = m
+	}
+
+	s.mu.RLock()
+	var stale []string
+	for id, m := range s.mutes {
+		if _, ok := wanted[id]; !ok && m.By != MaintenanceBy {
+			stale = append(stale, id)
+		}
+	}
+	var changed []PairMute
+	for id, m := range wanted {
+		current, ok := s.mutes[id]
+		if !ok || current.Reason != m.Reason || !current.Until.Equal(m.Until) || !current.At.Equal(m.At) {
+			changed = append(changed, m)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, id := range stale {
+		s.ResumePair(id, by)
+	}
+	for _, m := range changed {
+		s.MutePair(m)
+	}
+}
+
+// Mutes returns the active mutes sorted by ID
+func (s *SpreadDiscovery) Mutes() []PairMute {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]PairMute, 0, len(s.mutes))
+	for _, m := range s.mutes {
+		if !s.expireMute(m, now) {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// MuteAudit returns the mute audit trail, newest first
+func (s *SpreadDiscovery) MuteAudit() []MuteAudit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]MuteAudit, len(s.muteAudit))
+	for i, a := range s.muteAudit {
+		result[len(s.muteAudit)-1-i] = a
+	}
+	return result
+}
+
+// muted returns true if a spread buying on long and selling on short is
+// muted. Caller holds s.mu for writing.
+func (s *SpreadDiscovery) muted(long, short connector.ExchangeID, now time.Time) bool {
+	for _, m := range s.mutes {
+		if m.Matches(long, short) && !s.expireMute(m, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// expireMute removes a mute whose time is up. Caller holds s.mu for writing.
+func (s *SpreadDiscovery) expireMute(m PairMute, now time.Time) bool {
+	if !m.expired(now) {
+		return false
+	}
+	delete(s.mutes, m.ID)
+	s.audit(MuteActionExpire, m, "system", now)
+	log.Info().Str("mute", m.ID).Msg("Exchange pair mute expired")
+	return true
+}
+
+// audit appends to the audit trail. Caller holds s.mu for writing.
+func (s *SpreadDiscovery) audit(action string, m PairMute, by string, at time.Time) {
+	s.muteAudit = append(s.muteAudit, MuteAudit{Action: action, Mute: m, By: by, At: at})
+	if len(s.muteAudit) > maxMuteAudit {
+		s.muteAudit = s.muteAudit[len(s.muteAudit)-maxMuteAudit:]
+	}
+}
@@ -0,0 +1,70 @@
+package spread
+
+import (
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// pairIndex lists the venues quoting a canonical symbol in the order they
+// were first seen, with their latest books and the spread ID of every
+// ordered venue pair. It mirrors SpreadDiscovery.orderbooks for the hot
+// path: slices instead of map lookups per peer. Venues are never removed;
+// books only get replaced.
+type pairIndex struct {
+	venues []connector.ExchangeID
+	books  []*connector.Orderbook // Same slots as venues
+	slots  map[connector.ExchangeID]int
+	ids    [][]string // ids[long slot][short slot]
+}
+
+func newPairIndex() *pairIndex {
+	return &pairIndex{slots: make(map[connector.ExchangeID]int)}
+}
+
+// add appends a venue and the IDs of its pairs with every known venue, and
+// returns its slot
+func (p *pairIndex) add(canonical string, venue connector.ExchangeID) int {
+	slot := len(p.venues)
+	p.venues = append(p.venues, venue)
+	p.books = append(p.books, nil)
+	p.slots[venue] = slot
+
+	row := make([]string, slot+1)
+	for i, other := range p.venues[:slot] {
+		p.ids[i] = append(p.ids[i], spreadID(canonical, other, venue))
+		row[i] = spreadID(canonical, venue, other)
+	}
+	p.ids = append(p.ids, row)
+	return slot
+}
+
+// pairContext holds what every pair evaluated for one update shares, looked
+// up once per update instead of once per pair. The clock and the per-venue
+// maps are only read by load, once a pair passes the spread threshold,
+// which most updates never do.
+type pairContext struct {
+	canonical  string
+	thresholds Thresholds
+	loaded     bool
+	funding    map[connector.ExchangeID]float64
+	volumes    map[connector.ExchangeID]float64
+	now        time.Time
+}
+
+// load reads the clock, funding rates and volumes for the first pair that
+// needs them. Caller holds s.mu.
+func (pc *pairContext) load(s *SpreadDiscovery) {
+	if pc.loaded {
+		return
+	}
+	pc.loaded = true
+	pc.funding = s.fundingRates[pc.canonical]
+	pc.volumes = s.volumes[pc.canonical]
+	pc.now = s.now()
+}
+
+// spreadID returns the ID of the spread buying on long and selling on short
+func spreadID(canonical string, long, short connector.ExchangeID) string {
+	return canonical + ":" + string(long) + ":" + string(short)
+}
@@ -0,0 +1,29 @@
+package spread
+
+import (
+	"math"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// QualitySource scores each venue symbol's data quality in (0, 1], 1 being
+// fully trusted; *feedback.Store implements it from false positive tags
+type QualitySource interface {
+	Quality(exchange connector.ExchangeID, canonical string) float64
+}
+
+// SetQualitySource makes spreads on low-quality venue symbols need a wider
+// spread (MinSpreadBps divided by the quality) and ranks them lower
+func (s *SpreadDiscovery) SetQualitySource(src QualitySource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.qualitySource = src
+}
+
+// legQuality returns the lower quality of the two legs. Caller holds s.mu.
+func (s *SpreadDiscovery) legQuality(canonical string, longExchange, shortExchange connector.ExchangeID) float64 {
+	if s.qualitySource == nil {
+		return 1
+	}
+	return math.Min(s.qualitySource.Quality(longExchange, canonical), s.qualitySource.Quality(shortExchange, canonical))
+}
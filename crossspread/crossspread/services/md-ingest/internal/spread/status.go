@@ -0,0 +1,27 @@
+package spread
+
+import (
+	"crossspread-md-ingest/internal/connector"
+)
+
+// StatusGate reports whether positions may be opened on a venue symbol;
+// *symbolstatus.Tracker implements it
+type StatusGate interface {
+	CanOpen(exchange connector.ExchangeID, symbol string) bool
+}
+
+// SetStatusGate skips spreads with a leg on a symbol the venue has put in
+// maintenance, reduce-only or settlement
+func (s *SpreadDiscovery) SetStatusGate(gate StatusGate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusGate = gate
+}
+
+// gated returns true if either leg cannot open a position. Caller holds s.mu.
+func (s *SpreadDiscovery) gated(longOb, shortOb *connector.Orderbook) bool {
+	if s.statusGate == nil {
+		return false
+	}
+	return !s.statusGate.CanOpen(longOb.ExchangeID, longOb.Symbol) || !s.statusGate.CanOpen(shortOb.ExchangeID, shortOb.Symbol)
+}
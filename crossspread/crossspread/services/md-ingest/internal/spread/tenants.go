@@ -0,0 +1,67 @@
+package spread
+
+import (
+	"encoding/json"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/credentials"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TenantSource lists tenants and the exchanges they hold credentials on;
+// *credentials.TenantStore implements it
+type TenantSource interface {
+	Tenants() []credentials.Tenant
+}
+
+// TenantSpreadSummary is the summary of one tenant's tradable spreads
+type TenantSpreadSummary = mdtypes.TenantSpreadSummary
+
+// SetTenants publishes, alongside the shared summary, a summary per tenant
+// holding only the spreads whose venues it has credentials on
+func (s *SpreadDiscovery) SetTenants(source TenantSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants = source
+}
+
+// publishTenantSpreads publishes each tenant's tradable subset of the top
+// spreads. Tenants with nothing tradable still get an empty summary so
+// their consumers see the spreads go away.
+func (s *SpreadDiscovery) publishTenantSpreads(spreads []*SpreadOpportunity) {
+	s.mu.RLock()
+	source := s.tenants
+	s.mu.RUnlock()
+	if source == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, tenant := range source.Tenants() {
+		venues := make(map[connector.ExchangeID]bool, len(tenant.Exchanges))
+		for _, exchange := range tenant.Exchanges {
+			venues[connector.ExchangeID(exchange)] = true
+		}
+
+		tradable := make([]*SpreadOpportunity, 0)
+		for _, spread := range spreads {
+			if venues[spread.LongExchange] && venues[spread.ShortExchange] {
+				tradable = append(tradable, spread)
+			}
+		}
+
+		data, _ := json.Marshal(TenantSpreadSummary{
+			Tenant:    tenant.ID,
+			Timestamp: now,
+			Count:     len(tradable),
+			Top10:     tradable[:min(10, len(tradable))],
+			Spreads:   tradable,
+		})
+		if err := s.publisher.SetTenantSpreads(tenant.ID, data); err != nil {
+			log.Error().Err(err).Str("tenant", tenant.ID).Msg("Failed to publish tenant spreads")
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package spread
+
+import (
+	"strings"
+)
+
+// Thresholds are the minimum spread and depth a spread must clear to be
+// published
+type Thresholds struct {
+	MinSpreadBps float64 `json:"min_spread_bps"`
+	MinDepthUSD  float64 `json:"min_depth_usd"`
+}
+
+// Thresholds returns the thresholds of symbols without a tier
+func (s *SpreadDiscovery) Thresholds() Thresholds {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Thresholds{MinSpreadBps: s.minSpreadBps, MinDepthUSD: s.minDepthUSD}
+}
+
+// SetThresholds sets the thresholds of symbols without a tier
+func (s *SpreadDiscovery) SetThresholds(t Thresholds) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minSpreadBps = t.MinSpreadBps
+	s.minDepthUSD = t.MinDepthUSD
+}
+
+// SetSymbolTiers replaces the tier thresholds and the tier of each
+// canonical symbol. A zero field in a tier falls back to the default
+// threshold; symbols of an unknown tier use the defaults.
+func (s *SpreadDiscovery) SetSymbolTiers(tiers map[string]Thresholds, symbols map[string]string) {
+	normalized := make(map[string]string, len(symbols))
+	for canonical, tier := range symbols {
+		normalized[strings.ToUpper(canonical)] = tier
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tiers = tiers
+	s.symbolTiers = normalized
+}
+
+// thresholdsFor returns the thresholds of a canonical symbol. Caller holds s.mu.
+func (s *SpreadDiscovery) thresholdsFor(canonical string) Thresholds {
+	t := Thresholds{MinSpreadBps: s.minSpreadBps, MinDepthUSD: s.minDepthUSD}
+	tier, ok := s.tiers[s.symbolTiers[canonical]]
+	if !ok {
+		return t
+	}
+	if tier.MinSpreadBps != 0 {
+		t.MinSpreadBps = tier.MinSpreadBps
+	}
+	if tier.MinDepthUSD != 0 {
+		t.MinDepthUSD = tier.MinDepthUSD
+	}
+	return t
+}
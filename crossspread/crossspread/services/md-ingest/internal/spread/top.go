@@ -0,0 +1,186 @@
+package spread
+
+import (
+	"container/heap"
+
+	"crossspread-md-ingest/internal/metrics"
+)
+
+// DefaultTopN is the number of spreads kept per canonical symbol in each
+// ranking
+const DefaultTopN = 10
+
+// Rankings a spread is kept by
+const (
+	RankingBps     = "bps"
+	RankingNetEdge = "net_edge"
+)
+
+// topEntry is a tracked spread and its position in each ranking heap, -1
+// when it is not in that heap
+type topEntry struct {
+	spread *SpreadOpportunity
+	index  [2]int
+}
+
+// rankHeap is a min-heap of one ranking, so the weakest spread is evicted
+// first
+type rankHeap struct {
+	rank    int // 0 by bps, 1 by net edge
+	entries []*topEntry
+}
+
+func (h *rankHeap) key(e *topEntry) float64 {
+	if h.rank == 0 {
+		return e.spread.SpreadBps
+	}
+	return e.spread.NetEdgeBps
+}
+
+func (h *rankHeap) Len() int           { return len(h.entries) }
+func (h *rankHeap) Less(i, j int) bool { return h.key(h.entries[i]) < h.key(h.entries[j]) }
+func (h *rankHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].index[h.rank] = i
+	h.entries[j].index[h.rank] = j
+}
+func (h *rankHeap) Push(x any) {
+	e := x.(*topEntry)
+	e.index[h.rank] = len(h.entries)
+	h.entries = append(h.entries, e)
+}
+func (h *rankHeap) Pop() any {
+	n := len(h.entries) - 1
+	e := h.entries[n]
+	h.entries[n] = nil
+	h.entries = h.entries[:n]
+	e.index[h.rank] = -1
+	return e
+}
+
+// topSpreads keeps the best n spreads of one canonical symbol by bps and
+// the best n by net edge; a spread in either ranking is kept. Memory is
+// bounded by 2n per symbol whatever the number of venue pairs.
+type topSpreads struct {
+	n       int
+	heaps   [2]*rankHeap
+	entries map[string]*topEntry
+}
+
+func newTopSpreads(n int) *topSpreads {
+	return &topSpreads{
+		n:       n,
+		heaps:   [2]*rankHeap{{rank: 0}, {rank: 1}},
+		entries: make(map[string]*topEntry),
+	}
+}
+
+// offer records a spread's latest state. It returns the IDs of spreads
+// that dropped out of both rankings, including the offered one if it
+// didn't make either.
+func (t *topSpreads) offer(sp *SpreadOpportunity) (evicted []string) {
+	e, ok := t.entries[sp.ID]
+	if !ok {
+		e = &topEntry{index: [2]int{-1, -1}}
+	}
+	e.spread = sp
+
+	for rank, h := range t.heaps {
+		switch {
+		case e.index[rank] >= 0:
+			heap.Fix(h, e.index[rank])
+		case h.Len() < t.n:
+			heap.Push(h, e)
+		case h.key(e) > h.key(h.entries[0]):
+			weakest := heap.Pop(h).(*topEntry)
+			heap.Push(h, e)
+			if weakest.index[1-rank] < 0 {
+				delete(t.entries, weakest.spread.ID)
+				evicted = append(evicted, weakest.spread.ID)
+				metrics.SpreadTrackerEvictions.WithLabelValues(rankingName(rank)).Inc()
+			}
+		}
+	}
+
+	if e.index[0] < 0 && e.index[1] < 0 {
+		if ok {
+			delete(t.entries, sp.ID)
+		}
+		metrics.SpreadTrackerEvictions.WithLabelValues("rejected").Inc()
+		return append(evicted, sp.ID)
+	}
+	t.entries[sp.ID] = e
+	return evicted
+}
+
+// remove drops a spread from both rankings
+func (t *topSpreads) remove(id string) bool {
+	e, ok := t.entries[id]
+	if !ok {
+		return false
+	}
+	for rank, h := range t.heaps {
+		if e.index[rank] >= 0 {
+			heap.Remove(h, e.index[rank])
+		}
+	}
+	delete(t.entries, id)
+	return true
+}
+
+func rankingName(rank int) string {
+	if rank == 0 {
+		return RankingBps
+	}
+	return RankingNetEdge
+}
+
+// SetTopN sets how many spreads are kept per canonical symbol in each
+// ranking. Current spreads are re-ranked under the new bound.
+func (s *SpreadDiscovery) SetTopN(n int) {
+	if n <= 0 {
+		n = DefaultTopN
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.topN = n
+	current := s.spreads
+	s.spreads = make(map[string]*SpreadOpportunity, len(current))
+	s.top = make(map[string]*topSpreads)
+	for _, sp := range current {
+		s.storeSpread(sp)
+	}
+}
+
+// storeSpread records a spread if it ranks in its symbol's top N. Caller
+// holds s.mu for writing.
+func (s *SpreadDiscovery) storeSpread(sp *SpreadOpportunity) {
+	top := s.top[sp.Canonical]
+	if top == nil {
+		top = newTopSpreads(s.topN)
+		s.top[sp.Canonical] = top
+	}
+	s.spreads[sp.ID] = sp
+	for _, id := range top.offer(sp) {
+		delete(s.spreads, id)
+	}
+	metrics.SpreadTrackerSpreads.Set(float64(len(s.spreads)))
+}
+
+// removeSpread drops a tracked spread. Caller holds s.mu for writing.
+func (s *SpreadDiscovery) removeSpread(id string) {
+	sp, ok := s.spreads[id]
+	if !ok {
+		return
+	}
+	delete(s.spreads, id)
+	if top := s.top[sp.Canonical]; top != nil {
+		top.remove(id)
+		if len(top.entries) == 0 {
+			delete(s.top, sp.Canonical)
+		}
+	}
+	metrics.SpreadTrackerSpreads.Set(float64(len(s.spreads)))
+}
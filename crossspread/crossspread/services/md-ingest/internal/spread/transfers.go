@@ -0,0 +1,56 @@
+package spread
+
+import (
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// TransferTimes expects how long moving an asset between venues takes;
+// *execution.TransferLatencies implements it from observed deposits and
+// withdrawals
+type TransferTimes interface {
+	TransferP90(asset string, from, to connector.ExchangeID) (time.Duration, bool)
+}
+
+// SetTransferTimes sets where rebalance times come from. Routes it has no
+// estimate for, or all of them without it, use EconomicsConfig.TransferTime.
+func (s *SpreadDiscovery) SetTransferTimes(t TransferTimes) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transfers = t
+}
+
+// stampRebalanceTimes sets each spread's rebalance time: the slower
+// direction of moving its settle asset between the legs' venues. Spreads
+// are the copies withQuoteAges returns.
+func (s *SpreadDiscovery) stampRebalanceTimes(spreads []*SpreadOpportunity) {
+	s.mu.RLock()
+	times, fallback := s.transfers, s.economics.TransferTime
+	s.mu.RUnlock()
+
+	expected := make(map[string]time.Duration)
+	p90 := func(asset string, from, to connector.ExchangeID) time.Duration {
+		key := asset + ":" + string(from) + ":" + string(to)
+		if d, ok := expected[key]; ok {
+			return d
+		}
+		d := fallback
+		if times != nil {
+			if observed, ok := times.TransferP90(asset, from, to); ok {
+				d = observed
+			}
+		}
+		expected[key] = d
+		return d
+	}
+
+	for _, sp := range spreads {
+		if sp.LongExchange == sp.ShortExchange {
+			continue
+		}
+		asset := connector.ParseCanonical(sp.Canonical).Settle
+		d := max(p90(asset, sp.LongExchange, sp.ShortExchange), p90(asset, sp.ShortExchange, sp.LongExchange))
+		sp.RebalanceSeconds = d.Seconds()
+	}
+}
@@ -0,0 +1,79 @@
+package spread
+
+import (
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/symbolmap"
+)
+
+// SetSymbolMap moves books and funding of venues quoting an asset per 1000
+// or per million units (1000PEPEUSDT) to the unit canonical (PEPE), priced
+// in base units, so they pair with venues quoting the unit
+func (s *SpreadDiscovery) SetSymbolMap(m *symbolmap.Map) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols = m
+}
+
+// unitCanonical returns a venue symbol's unit entry and its canonical with
+// renames applied. Caller holds s.mu.
+func (s *SpreadDiscovery) unitCanonical(exchange connector.ExchangeID, symbol string) (symbolmap.Entry, string, bool) {
+	e, ok := s.symbols.Unit(exchange, symbol)
+	if !ok {
+		return e, "", false
+	}
+	canonical := e.Canonical
+	if s.normalizer != nil {
+		canonical = s.normalizer.Rename(exchange, canonical)
+	}
+	return e, canonical, true
+}
+
+// unitBook returns ob under its unit canonical with prices and sizes in
+// base units. Books already there are returned as they are; others are
+// copied, as subscribers share the bus's book. Caller holds s.mu.
+func (s *SpreadDiscovery) unitBook(ob *connector.Orderbook) *connector.Orderbook {
+	e, canonical, ok := s.unitCanonical(ob.ExchangeID, ob.Symbol)
+	if !ok || (canonical == ob.Canonical && e.Multiplier == 1) {
+		return ob
+	}
+	unit := *ob
+	unit.Canonical = canonical
+	if e.Multiplier != 1 {
+		unit.Bids = unitLevels(ob.Bids, e)
+		unit.Asks = unitLevels(ob.Asks, e)
+		unit.BestBid = e.UnitPrice(ob.BestBid)
+		unit.BestAsk = e.UnitPrice(ob.BestAsk)
+	}
+	return &unit
+}
+
+func unitLevels(levels []connector.PriceLevel, e symbolmap.Entry) []connector.PriceLevel {
+	out := make([]connector.PriceLevel, len(levels))
+	for i, l := range levels {
+		out[i] = connector.PriceLevel{Price: e.UnitPrice(l.Price), Quantity: e.UnitSize(l.Quantity), NotionalUSD: l.NotionalUSD}
+	}
+	return out
+}
+
+// unitFunding returns fr under its unit canonical with the mark price in
+// base units. Caller holds s.mu.
+func (s *SpreadDiscovery) unitFunding(fr *connector.FundingRate) *connector.FundingRate {
+	e, canonical, ok := s.unitCanonical(fr.ExchangeID, fr.Symbol)
+	if !ok || (canonical == fr.Canonical && e.Multiplier == 1) {
+		return fr
+	}
+	unit := *fr
+	unit.Canonical = canonical
+	unit.MarkPrice = e.UnitPrice(fr.MarkPrice)
+	return &unit
+}
+
+// stampMultipliers sets the multiplier of legs on venues quoting a multiple
+// of the unit, whose prices and sizes are in base units. Caller holds s.mu.
+func (s *SpreadDiscovery) stampMultipliers(legs []Leg) {
+	for i := range legs {
+		if e, ok := s.symbols.Unit(legs[i].Exchange, legs[i].Symbol); ok && e.Multiplier != 1 {
+			legs[i].Multiplier = e.Multiplier
+		}
+	}
+}
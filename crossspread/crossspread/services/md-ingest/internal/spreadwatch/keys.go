@@ -0,0 +1,65 @@
+package spreadwatch
+
+import "unicode/utf8"
+
+// Keys ParseKeys returns for control input; a printable character is
+// returned as itself
+const (
+	KeyEnter     = "enter"
+	KeyEsc       = "esc"
+	KeyBackspace = "backspace"
+	KeyCtrlC     = "ctrl-c"
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyHome      = "home"
+)
+
+// ParseKeys splits a read from a raw-mode terminal into keys. Escape
+// sequences other than the arrows and Home are dropped; a lone escape is
+// the Esc key.
+func ParseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b && len(b) >= 3 && (b[1] == '[' || b[1] == 'O'):
+			// CSI or SS3: parameters, then a final byte
+			i := 2
+			for i < len(b) && (b[i] < '@' || b[i] > '~') {
+				i++
+			}
+			if i < len(b) {
+				switch string(b[2 : i+1]) {
+				case "A":
+					keys = append(keys, KeyUp)
+				case "B":
+					keys = append(keys, KeyDown)
+				case "H", "1~":
+					keys = append(keys, KeyHome)
+				}
+				i++
+			}
+			b = b[i:]
+		case c == 0x1b:
+			keys = append(keys, KeyEsc)
+			b = b[1:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, KeyEnter)
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, KeyBackspace)
+			b = b[1:]
+		case c == 0x03:
+			keys = append(keys, KeyCtrlC)
+			b = b[1:]
+		case c < 0x20:
+			b = b[1:]
+		default:
+			r, n := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				keys = append(keys, string(r))
+			}
+			b = b[n:]
+		}
+	}
+	return keys
+}
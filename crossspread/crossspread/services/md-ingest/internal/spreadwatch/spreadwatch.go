@@ -0,0 +1,375 @@
+// Package spreadwatch is the model behind the spreadwatch terminal UI: the
+// latest published spreads as a table that can be sorted and filtered from
+// the keyboard and rendered to a fixed-size screen.
+package spreadwatch
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"crossspread-md-ingest/internal/filterexpr"
+	"crossspread-md-ingest/internal/spread"
+)
+
+// Column is a table column
+type Column int
+
+// Columns, in display order
+const (
+	ColPair Column = iota
+	ColBps
+	ColNetEdge
+	ColAge
+	ColSize
+)
+
+var columns = []struct {
+	title string
+	width int
+}{
+	ColPair:    {"PAIR", 32},
+	ColBps:     {"BPS", 9},
+	ColNetEdge: {"NET EDGE", 10},
+	ColAge:     {"AGE", 9},
+	ColSize:    {"SIZE", 10},
+}
+
+func (c Column) String() string {
+	return strings.ToLower(columns[c].title)
+}
+
+// Row is one spread as shown
+type Row struct {
+	ID         string
+	Pair       string // CANONICAL long->short
+	SpreadBps  float64
+	NetEdgeBps float64
+	Age        time.Duration // Of the older leg's quote
+	SizeUSD    float64       // Depth of the thinner leg
+	Profitable bool
+}
+
+// View holds the latest summary and the operator's sort and filter
+type View struct {
+	spreads  []*spread.SpreadOpportunity
+	received time.Time
+
+	sortBy Column
+	asc    bool
+	filter string
+	expr   *filterexpr.Expr // Set when the filter is an expression
+
+	editing bool
+	input   string
+	err     string
+	offset  int
+	status  string
+}
+
+// New creates a view sorted by net edge, best first
+func New() *View {
+	return &View{sortBy: ColNetEdge}
+}
+
+// Update replaces the spreads shown with a summary received at the given
+// time
+func (v *View) Update(summary *spread.SpreadSummary, received time.Time) {
+	v.spreads = summary.Spreads
+	v.received = received
+}
+
+// SetStatus sets the text shown on the right of the header, e.g. the
+// producer's heartbeat state
+func (v *View) SetStatus(status string) {
+	v.status = status
+}
+
+// SetFilter filters the rows. A filter using operators is a spread filter
+// expression (net_edge_bps > 2 && long_exchange != "lbank"); anything else
+// matches pairs and spread IDs containing it, ignoring case. Empty clears.
+func (v *View) SetFilter(src string) error {
+	src = strings.TrimSpace(src)
+	if src == "" || !strings.ContainsAny(src, "<>=!&|") {
+		v.filter, v.expr = src, nil
+		return nil
+	}
+	expr, err := spread.CompileFilter(src)
+	if err != nil {
+		return err
+	}
+	v.filter, v.expr = src, expr
+	return nil
+}
+
+// SortBy sorts by a column. Sorting by the current column reverses it.
+func (v *View) SortBy(c Column) {
+	if c == v.sortBy {
+		v.asc = !v.asc
+		return
+	}
+	v.sortBy = c
+	// Pairs read alphabetically; numbers best or freshest first
+	v.asc = c == ColPair || c == ColAge
+}
+
+// Key handles a key from ParseKeys and reports whether to quit
+func (v *View) Key(k string) bool {
+	if v.editing {
+		switch k {
+		case KeyEnter:
+			if err := v.SetFilter(v.input); err != nil {
+				v.err = err.Error()
+				return false
+			}
+			v.editing, v.err, v.offset = false, "", 0
+		case KeyEsc:
+			v.editing, v.err = false, ""
+		case KeyBackspace:
+			if r := []rune(v.input); len(r) > 0 {
+				v.input = string(r[:len(r)-1])
+			}
+		case KeyCtrlC:
+			return true
+		default:
+			if len([]rune(k)) == 1 {
+				v.input += k
+			}
+		}
+		return false
+	}
+
+	switch k {
+	case "q", KeyCtrlC:
+		return true
+	case "/":
+		v.editing, v.input, v.err = true, v.filter, ""
+	case KeyEsc:
+		v.filter, v.expr, v.offset = "", nil, 0
+	case "s":
+		v.SortBy((v.sortBy + 1) % Column(len(columns)))
+	case "r":
+		v.asc = !v.asc
+	case "1", "2", "3", "4", "5":
+		v.SortBy(Column(k[0] - '1'))
+	case "j", KeyDown:
+		v.offset++
+	case "k", KeyUp:
+		if v.offset > 0 {
+			v.offset--
+		}
+	case "g", KeyHome:
+		v.offset = 0
+	}
+	return false
+}
+
+// Rows returns the spreads passing the filter, sorted, with ages as of now
+func (v *View) Rows(now time.Time) []Row {
+	// Quote ages were stamped at publication; the summary has aged since
+	elapsed := now.Sub(v.received)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	needle := strings.ToLower(v.filter)
+
+	rows := make([]Row, 0, len(v.spreads))
+	for _, o := range v.spreads {
+		if v.expr != nil && !spread.MatchFilter(v.expr, o) {
+			continue
+		}
+		row := Row{
+			ID:         o.ID,
+			Pair:       fmt.Sprintf("%s %s->%s", o.Canonical, o.LongExchange, o.ShortExchange),
+			SpreadBps:  o.SpreadBps,
+			NetEdgeBps: o.NetEdgeBps,
+			Age:        time.Duration(math.Max(o.LongQuoteAgeMs, o.ShortQuoteAgeMs)*float64(time.Millisecond)) + elapsed,
+			SizeUSD:    o.MinDepthUSD,
+			Profitable: o.Profitable,
+		}
+		if v.expr == nil && needle != "" &&
+			!strings.Contains(strings.ToLower(row.Pair), needle) && !strings.Contains(strings.ToLower(row.ID), needle) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	less := func(a, b Row) bool {
+		switch v.sortBy {
+		case ColPair:
+			return a.Pair < b.Pair
+		case ColBps:
+			return a.SpreadBps < b.SpreadBps
+		case ColAge:
+			return a.Age < b.Age
+		case ColSize:
+			return a.SizeUSD < b.SizeUSD
+		default:
+			return a.NetEdgeBps < b.NetEdgeBps
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if less(a, b) == less(b, a) {
+			return a.ID < b.ID // Equal: keep rows from jumping between updates
+		}
+		if v.asc {
+			return less(a, b)
+		}
+		return less(b, a)
+	})
+	return rows
+}
+
+// ANSI sequences
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	bold        = "\x1b[1m"
+	reverse     = "\x1b[7m"
+	green       = "\x1b[32m"
+	red         = "\x1b[31m"
+	dim         = "\x1b[2m"
+	reset       = "\x1b[0m"
+)
+
+// Render draws the view on a width by height screen. Lines end in \r\n,
+// as the terminal is in raw mode.
+func (v *View) Render(now time.Time, width, height int) string {
+	if width < 40 {
+		width = 40
+	}
+	if height < 6 {
+		height = 6
+	}
+	rows := v.Rows(now)
+
+	var b strings.Builder
+	b.WriteString(clearScreen)
+
+	// Header: counts, sort, filter and producer status
+	dir := "v"
+	if v.asc {
+		dir = "^"
+	}
+	head := fmt.Sprintf("spreadwatch  %d/%d spreads  sort: %s %s", len(rows), len(v.spreads), v.sortBy, dir)
+	if v.filter != "" {
+		head += "  filter: " + v.filter
+	}
+	line(&b, bold+pad(head, width-len(v.status)-1)+" "+v.status+reset, width)
+
+	var cols strings.Builder
+	for i, c := range columns {
+		if i == int(ColPair) {
+			cols.WriteString(pad(c.title, c.width))
+		} else {
+			cols.WriteString(padLeft(c.title, c.width))
+		}
+	}
+	line(&b, reverse+pad(cols.String(), width)+reset, width)
+
+	// Body, scrolled so the last page stays full
+	body := height - 4
+	if last := len(rows) - body; v.offset > last {
+		v.offset = last
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+	for i := 0; i < body; i++ {
+		if v.offset+i >= len(rows) {
+			b.WriteString("\r\n")
+			continue
+		}
+		r := rows[v.offset+i]
+		text := pad(r.Pair, columns[ColPair].width) +
+			padLeft(fmt.Sprintf("%.2f", r.SpreadBps), columns[ColBps].width) +
+			padLeft(fmt.Sprintf("%.2f", r.NetEdgeBps), columns[ColNetEdge].width) +
+			padLeft(formatAge(r.Age), columns[ColAge].width) +
+			padLeft(formatUSD(r.SizeUSD), columns[ColSize].width)
+		switch {
+		case r.Profitable:
+			text = green + text + reset
+		case r.NetEdgeBps < 0:
+			text = dim + text + reset
+		}
+		line(&b, text, width)
+	}
+
+	// Footer: the filter being typed, its error, or the key help
+	switch {
+	case v.editing && v.err != "":
+		line(&b, "filter: "+v.input+"  "+red+v.err+reset, width)
+	case v.editing:
+		line(&b, "filter: "+v.input+"_", width)
+	default:
+		line(&b, dim+"[/] filter  [esc] clear  [s] sort  [1-5] sort by column  [r] reverse  [j/k] scroll  [q] quit"+reset, width)
+	}
+	return b.String()
+}
+
+// line writes s, cut to the screen width, and a line break
+func line(b *strings.Builder, s string, width int) {
+	b.WriteString(cut(s, width))
+	b.WriteString("\x1b[K\r\n")
+}
+
+// cut truncates s to width visible runes, skipping escape sequences
+func cut(s string, width int) string {
+	var b strings.Builder
+	visible := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape:
+			if r >= '@' && r <= '~' && r != '[' {
+				inEscape = false
+			}
+		case visible >= width:
+			continue
+		default:
+			visible++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func pad(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func padLeft(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		return d.Truncate(time.Second).String()
+	}
+}
+
+func formatUSD(v float64) string {
+	switch {
+	case v >= 1e6:
+		return fmt.Sprintf("$%.1fM", v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("$%.1fk", v/1e3)
+	default:
+		return fmt.Sprintf("$%.0f", v)
+	}
+}
@@ -0,0 +1,138 @@
+package spreadwatch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/spread"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"printable", "q/é", []string{"q", "/", "é"}},
+		{"arrows", "\x1b[A\x1b[B\x1bOA", []string{KeyUp, KeyDown, KeyUp}},
+		{"home", "\x1b[H\x1b[1~\x1bOH", []string{KeyHome, KeyHome, KeyHome}},
+		{"other sequences dropped", "\x1b[C\x1b[15~x", []string{"x"}},
+		{"lone escape", "\x1b", []string{KeyEsc}},
+		{"escape then key", "\x1bq", []string{KeyEsc, "q"}},
+		{"truncated sequence", "\x1b[1", nil},
+		{"controls", "\r\n\x7f\x08\x03\x01", []string{KeyEnter, KeyEnter, KeyBackspace, KeyBackspace, KeyCtrlC}},
+		{"invalid utf8", "\xffa", []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKeys([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeys(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func opportunity(canonical string, long, short connector.ExchangeID, bps, edge, ageMs, depth float64) *spread.SpreadOpportunity {
+	return &spread.SpreadOpportunity{
+		ID:             canonical + ":" + string(long) + ":" + string(short),
+		Canonical:      canonical,
+		LongExchange:   long,
+		ShortExchange:  short,
+		SpreadBps:      bps,
+		NetEdgeBps:     edge,
+		LongQuoteAgeMs: ageMs,
+		MinDepthUSD:    depth,
+		Profitable:     edge > 0,
+	}
+}
+
+func newTestView() (*View, time.Time) {
+	received := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	v := New()
+	v.Update(&spread.SpreadSummary{Spreads: []*spread.SpreadOpportunity{
+		opportunity("BTC", connector.Binance, connector.Bybit, 8, 3, 100, 50000),
+		opportunity("ETH", connector.OKX, connector.GateIO, 12, -1, 900, 2000),
+		opportunity("SOL", connector.Bybit, connector.Binance, 10, 5, 300, 9000),
+	}}, received)
+	return v, received
+}
+
+func pairs(rows []Row) []string {
+	out := make([]string, len(rows))
+	for i, r := range rows {
+		out[i] = r.Pair
+	}
+	return out
+}
+
+func TestViewSort(t *testing.T) {
+	v, now := newTestView()
+	want := []string{"SOL bybit->binance", "BTC binance->bybit", "ETH okx->gateio"}
+	if got := pairs(v.Rows(now)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("default order %v, want net edge best first %v", got, want)
+	}
+
+	v.Key("1")
+	want = []string{"BTC binance->bybit", "ETH okx->gateio", "SOL bybit->binance"}
+	if got := pairs(v.Rows(now)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("by pair %v, want %v", got, want)
+	}
+	v.Key("1") // Same column again reverses
+	if got := pairs(v.Rows(now)); got[0] != "SOL bybit->binance" {
+		t.Fatalf("reversed pair order %v", got)
+	}
+
+	v.Key("4")
+	if got := pairs(v.Rows(now)); got[0] != "BTC binance->bybit" {
+		t.Fatalf("by age %v, want freshest first", got)
+	}
+	v.Key("r")
+	if got := pairs(v.Rows(now)); got[0] != "ETH okx->gateio" {
+		t.Fatalf("reversed age order %v, want stalest first", got)
+	}
+}
+
+// Ages keep growing after the summary was received
+func TestViewRowAge(t *testing.T) {
+	v, received := newTestView()
+	v.Key("1")
+	rows := v.Rows(received.Add(2 * time.Second))
+	if rows[0].Age != 2100*time.Millisecond {
+		t.Errorf("age %v, want the stamped 100ms plus 2s since", rows[0].Age)
+	}
+}
+
+func TestViewFilter(t *testing.T) {
+	v, now := newTestView()
+	if err := v.SetFilter("binance"); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Rows(now); len(got) != 2 {
+		t.Fatalf("substring filter kept %v, want the 2 binance pairs", pairs(got))
+	}
+	if err := v.SetFilter("net_edge_bps > 0 && long_exchange != \"binance\""); err != nil {
+		t.Fatal(err)
+	}
+	if got := pairs(v.Rows(now)); !reflect.DeepEqual(got, []string{"SOL bybit->binance"}) {
+		t.Fatalf("expression filter kept %v", got)
+	}
+	if err := v.SetFilter("net_edge_bps >"); err == nil {
+		t.Fatal("invalid expression accepted")
+	}
+
+	// Typed filters apply on enter; a bad one keeps the editor open
+	v.SetFilter("")
+	for _, k := range ParseKeys([]byte("/bps >\r")) {
+		v.Key(k)
+	}
+	if !v.editing || v.err == "" {
+		t.Fatal("invalid typed filter applied")
+	}
+	v.Key(KeyEsc)
+	v.Key(KeyEsc)
+	if got := v.Rows(now); len(got) != 3 {
+		t.Errorf("esc left %d rows, want the filter cleared", len(got))
+	}
+}
@@ -0,0 +1,236 @@
+// Package strategy hosts custom strategies compiled into md-ingest. They
+// receive orderbooks, spreads and funding rates in-process, as they are
+// produced, instead of reading them back from Redis.
+//
+// A strategy registers itself from an init function and is linked in with
+// a blank import in cmd/ingest:
+//
+//	func init() {
+//		strategy.Register("basis", func() strategy.Strategy { return &Basis{} })
+//	}
+//
+// and is enabled with STRATEGIES=basis.
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/bus"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/execution"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Strategy reacts to market data. Each hook runs on the strategy's own
+// goroutine, in publish order, and must treat its argument as read-only:
+// the same value is shared with every other consumer. Embed Base to
+// implement only the hooks needed.
+type Strategy interface {
+	OnOrderbook(ob *connector.Orderbook)
+	OnSpread(opp *spread.SpreadOpportunity) // Every spread as computed, before top-N ranking
+	OnFunding(fr *connector.FundingRate)
+}
+
+// Starter is implemented by strategies needing setup before the first event
+type Starter interface {
+	Start(ctx context.Context, env Env) error
+}
+
+// Stopper is implemented by strategies releasing resources on shutdown
+type Stopper interface {
+	Stop()
+}
+
+// Base implements every hook as a no-op
+type Base struct{}
+
+func (Base) OnOrderbook(*connector.Orderbook)   {}
+func (Base) OnSpread(*spread.SpreadOpportunity) {}
+func (Base) OnFunding(*connector.FundingRate)   {}
+
+// Env is what a strategy is given when it starts
+type Env struct {
+	Books  execution.BookCache // Latest streamed books; read-only
+	Config map[string]string   // The strategy's entries from STRATEGY_CONFIG
+}
+
+// Factory creates a strategy instance
+type Factory func() Strategy
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a strategy available under name. It panics if the name is
+// taken, like database/sql.Register, since that is a build mistake.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("strategy: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("strategy: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Registered returns the names of every compiled-in strategy, sorted
+func Registered() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registeredLocked()
+}
+
+func registeredLocked() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseConfig parses STRATEGY_CONFIG, e.g. "basis.min_bps=5,basis.size=0.1",
+// into settings per strategy
+func ParseConfig(s string) (map[string]map[string]string, error) {
+	config := make(map[string]map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		name, setting, dotted := strings.Cut(key, ".")
+		if !ok || !dotted || name == "" || setting == "" {
+			return nil, fmt.Errorf("invalid strategy setting %q: want name.key=value", entry)
+		}
+		if config[name] == nil {
+			config[name] = make(map[string]string)
+		}
+		config[name][setting] = value
+	}
+	return config, nil
+}
+
+// Status describes a running strategy
+type Status struct {
+	Name   string                `json:"name"`
+	Queues []bus.SubscriberStats `json:"queues"`
+}
+
+type running struct {
+	name     string
+	strategy Strategy
+}
+
+// Host runs the enabled strategies. Orderbooks and funding rates reach them
+// as bus subscribers named "strategy:<name>"; spreads come from discovery
+// through HandleSpread. A panicking hook loses only its event.
+type Host struct {
+	config     bus.SubscribeConfig
+	strategies []running
+	spreads    *bus.Topic[*spread.SpreadOpportunity]
+}
+
+// NewHost creates the strategies named. Unknown names are an error so a
+// typo in STRATEGIES does not silently run nothing.
+func NewHost(names []string, config bus.SubscribeConfig) (*Host, error) {
+	h := &Host{
+		config:  config,
+		spreads: bus.NewTopic[*spread.SpreadOpportunity]("spreads"),
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, name := range names {
+		factory, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown strategy %q; compiled in: %s", name, strings.Join(registeredLocked(), ", "))
+		}
+		h.strategies = append(h.strategies, running{name: name, strategy: factory()})
+	}
+	return h, nil
+}
+
+// Start starts every strategy and subscribes it to b. A strategy whose
+// Start fails is dropped. Call it before connectors start.
+func (h *Host) Start(ctx context.Context, b *bus.Bus, books execution.BookCache, config map[string]map[string]string) {
+	spreadConfig := h.config
+	spreadConfig.Policy = bus.DropOldest
+	started := h.strategies[:0]
+	for _, r := range h.strategies {
+		if s, ok := r.strategy.(Starter); ok {
+			if err := s.Start(ctx, Env{Books: books, Config: config[r.name]}); err != nil {
+				log.Error().Err(err).Str("strategy", r.name).Msg("Failed to start strategy")
+				continue
+			}
+		}
+		sub := "strategy:" + r.name
+		s := r.strategy
+		b.Orderbooks.Subscribe(sub, h.config, func(ob *connector.Orderbook) {
+			start := time.Now()
+			s.OnOrderbook(ob)
+			observe(r.name, "orderbook", start)
+		})
+		b.Funding.Subscribe(sub, h.config, func(fr *connector.FundingRate) {
+			start := time.Now()
+			s.OnFunding(fr)
+			observe(r.name, "funding", start)
+		})
+		h.spreads.Subscribe(sub, spreadConfig, func(opp *spread.SpreadOpportunity) {
+			start := time.Now()
+			s.OnSpread(opp)
+			observe(r.name, "spread", start)
+		})
+		started = append(started, r)
+		log.Info().Str("strategy", r.name).Msg("Strategy started")
+	}
+	h.strategies = started
+}
+
+func observe(name, hook string, start time.Time) {
+	metrics.StrategyHookDuration.WithLabelValues(name, hook).Observe(time.Since(start).Seconds())
+}
+
+// HandleSpread hands a computed spread to every strategy. Discovery calls
+// it under its lock, so it never blocks: a full queue drops its oldest
+// spread, whatever the configured policy.
+func (h *Host) HandleSpread(opp *spread.SpreadOpportunity) {
+	h.spreads.Publish(opp)
+}
+
+// Strategies returns the running strategies and their spread queues;
+// orderbook and funding queues are listed with the bus
+func (h *Host) Strategies() []Status {
+	stats := h.spreads.Stats()
+	result := make([]Status, 0, len(h.strategies))
+	for _, r := range h.strategies {
+		st := Status{Name: r.name}
+		for _, q := range stats {
+			if q.Subscriber == "strategy:"+r.name {
+				st.Queues = append(st.Queues, q)
+			}
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// Stop drains the spread queues and stops every strategy
+func (h *Host) Stop() {
+	h.spreads.Close()
+	for _, r := range h.strategies {
+		if s, ok := r.strategy.(Stopper); ok {
+			s.Stop()
+		}
+	}
+}
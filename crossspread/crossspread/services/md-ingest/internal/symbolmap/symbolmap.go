@@ -0,0 +1,273 @@
+// Package symbolmap maps exchange symbols to canonical symbols and back,
+// built from each venue's instrument list. Venues quote some small-priced
+// assets per 1000 or per million units (1000PEPEUSDT, 1MBABYDOGEUSDT)
+// where others quote the unit (PEPE_USDT); both map to the same canonical
+// and the entry carries the multiplier to bring prices and sizes to units.
+package symbolmap
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+// Entry is one venue's contract for a canonical symbol
+type Entry struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Symbol    string               `json:"symbol"`
+	Canonical string               `json:"canonical"`
+	Pair      connector.Pair       `json:"pair"` // Base without the multiplier prefix
+	// Multiplier is how many base units one quoted unit is: 1000 for
+	// 1000PEPEUSDT. Divide prices and multiply sizes by it to get units.
+	Multiplier   float64 `json:"multiplier"`
+	ContractSize float64 `json:"contract_size,omitempty"` // Quoted units per contract; zero if not listed
+}
+
+// UnitPrice converts a venue price to the price of one base unit
+func (e Entry) UnitPrice(price float64) float64 {
+	if e.Multiplier <= 0 {
+		return price
+	}
+	return price / e.Multiplier
+}
+
+// UnitSize converts a venue size, in quoted units, to base units
+func (e Entry) UnitSize(size float64) float64 {
+	if e.Multiplier <= 0 {
+		return size
+	}
+	return size * e.Multiplier
+}
+
+// SplitMultiplier splits a base asset into the unit asset and how many
+// units it stands for: 1000PEPE gives PEPE and 1000, 1MBABYDOGE gives
+// BABYDOGE and 1000000, BTC gives BTC and 1. Only powers of ten from 100
+// up count, so 1INCH stays whole.
+func SplitMultiplier(base string) (string, float64) {
+	upper := strings.ToUpper(strings.TrimSpace(base))
+	if rest, ok := strings.CutPrefix(upper, "1M"); ok && isAsset(rest) {
+		return rest, 1e6
+	}
+	digits := 0
+	for digits < len(upper) && upper[digits] >= '0' && upper[digits] <= '9' {
+		digits++
+	}
+	if digits < 3 || upper[0] != '1' || strings.Trim(upper[1:digits], "0") != "" || !isAsset(upper[digits:]) {
+		return upper, 1
+	}
+	return upper[digits:], math.Pow10(digits - 1)
+}
+
+// isAsset reports whether s can be an asset name after a prefix
+func isAsset(s string) bool {
+	return len(s) >= 2 && s[0] >= 'A' && s[0] <= 'Z'
+}
+
+// PairOf returns the unit pair of an instrument and its multiplier. The
+// symbol is parsed when the venue doesn't list the base asset.
+func PairOf(inst *connector.Instrument) (connector.Pair, float64) {
+	var pair connector.Pair
+	if inst.BaseAsset != "" {
+		quote := inst.QuoteAsset
+		if quote == "" {
+			quote = "USDT"
+		}
+		pair = connector.VenuePair(inst.ExchangeID, connector.NewPair(inst.BaseAsset, quote, inst.SettleAsset))
+	} else {
+		pair = connector.ParseSymbol(inst.ExchangeID, inst.Symbol)
+	}
+	return UnitPair(pair)
+}
+
+// UnitPair strips the multiplier prefix from a pair's base, and its settle
+// asset for inverse contracts, returning the multiplier
+func UnitPair(pair connector.Pair) (connector.Pair, float64) {
+	base, multiplier := SplitMultiplier(pair.Base)
+	if multiplier == 1 {
+		return pair, 1
+	}
+	if pair.Settle == pair.Base {
+		pair.Settle = base
+	}
+	pair.Base = base
+	return pair, multiplier
+}
+
+// Map is the bidirectional symbol map across venues. The zero value is not
+// usable; create one with New. A nil *Map looks nothing up.
+type Map struct {
+	mu          sync.RWMutex
+	bySymbol    map[connector.ExchangeID]map[string]Entry
+	byCanonical map[string]map[connector.ExchangeID]Entry
+}
+
+// New creates an empty map
+func New() *Map {
+	return &Map{
+		bySymbol:    make(map[connector.ExchangeID]map[string]Entry),
+		byCanonical: make(map[string]map[connector.ExchangeID]Entry),
+	}
+}
+
+// Register adds or replaces a venue's instruments. Spot instruments are
+// skipped: a canonical names one perpetual per venue.
+func (m *Map) Register(instruments []connector.Instrument) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range instruments {
+		inst := &instruments[i]
+		if inst.InstrumentType == "spot" || inst.Symbol == "" {
+			continue
+		}
+		pair, multiplier := PairOf(inst)
+		e := Entry{
+			Exchange:     inst.ExchangeID,
+			Symbol:       inst.Symbol,
+			Canonical:    pair.Canonical(),
+			Pair:         pair,
+			Multiplier:   multiplier,
+			ContractSize: inst.ContractSize,
+		}
+		if m.bySymbol[e.Exchange] == nil {
+			m.bySymbol[e.Exchange] = make(map[string]Entry)
+		}
+		old, renamed := m.bySymbol[e.Exchange][e.Symbol]
+		renamed = renamed && old.Canonical != e.Canonical
+		m.bySymbol[e.Exchange][e.Symbol] = e
+		// The symbol moving off its old canonical only frees the venue's
+		// slot there if it held it; another contract then takes it
+		if renamed && m.byCanonical[old.Canonical][e.Exchange].Symbol == e.Symbol {
+			m.resolve(old.Canonical, e.Exchange)
+		}
+		if m.byCanonical[e.Canonical] == nil {
+			m.byCanonical[e.Canonical] = make(map[connector.ExchangeID]Entry)
+		}
+		// A venue listing both PEPE and 1000PEPE keeps the unit contract
+		if have, ok := m.byCanonical[e.Canonical][e.Exchange]; !ok || have.Symbol == e.Symbol || e.Multiplier <= have.Multiplier {
+			m.byCanonical[e.Canonical][e.Exchange] = e
+		}
+	}
+}
+
+// resolve points a canonical at the venue's contract with the smallest
+// multiplier still registered under it. Caller holds m.mu.
+func (m *Map) resolve(canonical string, exchange connector.ExchangeID) {
+	var best Entry
+	found := false
+	for _, e := range m.bySymbol[exchange] {
+		if e.Canonical == canonical && (!found || e.Multiplier < best.Multiplier ||
+			e.Multiplier == best.Multiplier && e.Symbol < best.Symbol) {
+			best, found = e, true
+		}
+	}
+	if found {
+		m.byCanonical[canonical][exchange] = best
+		return
+	}
+	delete(m.byCanonical[canonical], exchange)
+	if len(m.byCanonical[canonical]) == 0 {
+		delete(m.byCanonical, canonical)
+	}
+}
+
+// Lookup returns the entry of a venue symbol, if its instrument was
+// registered
+func (m *Map) Lookup(exchange connector.ExchangeID, symbol string) (Entry, bool) {
+	if m == nil {
+		return Entry{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.bySymbol[exchange][symbol]
+	return e, ok
+}
+
+// Unit returns the entry of a venue symbol when it is the contract its
+// canonical resolves to on that venue. A venue listing both PEPE and
+// 1000PEPE resolves PEPE to the unit contract; 1000PEPE is then not a
+// unit and keeps its own canonical.
+func (m *Map) Unit(exchange connector.ExchangeID, symbol string) (Entry, bool) {
+	if m == nil {
+		return Entry{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.bySymbol[exchange][symbol]
+	if !ok || m.byCanonical[e.Canonical][exchange].Symbol != symbol {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Reverse returns a venue's contract for a canonical symbol, if it lists
+// one
+func (m *Map) Reverse(canonical string, exchange connector.ExchangeID) (Entry, bool) {
+	if m == nil {
+		return Entry{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.byCanonical[canonical][exchange]
+	return e, ok
+}
+
+// Canonical returns a venue symbol's canonical: the registered one, or
+// else parsed from the symbol with any multiplier prefix stripped
+func (m *Map) Canonical(exchange connector.ExchangeID, symbol string) string {
+	if e, ok := m.Lookup(exchange, symbol); ok {
+		return e.Canonical
+	}
+	pair, _ := UnitPair(connector.ParseSymbol(exchange, symbol))
+	return pair.Canonical()
+}
+
+// Exchanges returns the venues listing a canonical symbol, sorted
+func (m *Map) Exchanges(canonical string) []connector.ExchangeID {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	exchanges := make([]connector.ExchangeID, 0, len(m.byCanonical[canonical]))
+	for id := range m.byCanonical[canonical] {
+		exchanges = append(exchanges, id)
+	}
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i] < exchanges[j] })
+	return exchanges
+}
+
+// Canonicals returns every registered canonical symbol listed on at least
+// minExchanges venues, sorted
+func (m *Map) Canonicals(minExchanges int) []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var canonicals []string
+	for canonical, venues := range m.byCanonical {
+		if len(venues) >= minExchanges {
+			canonicals = append(canonicals, canonical)
+		}
+	}
+	sort.Strings(canonicals)
+	return canonicals
+}
+
+// Entries returns a canonical's contract on every venue listing it
+func (m *Map) Entries(canonical string) []Entry {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make([]Entry, 0, len(m.byCanonical[canonical]))
+	for _, e := range m.byCanonical[canonical] {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Exchange < entries[j].Exchange })
+	return entries
+}
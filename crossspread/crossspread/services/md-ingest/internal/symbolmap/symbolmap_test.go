@@ -0,0 +1,90 @@
+package symbolmap
+
+import (
+	"testing"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+func TestSplitMultiplier(t *testing.T) {
+	tests := []struct {
+		base       string
+		want       string
+		multiplier float64
+	}{
+		{"1000PEPE", "PEPE", 1000},
+		{"1000000MOG", "MOG", 1e6},
+		{"1MBABYDOGE", "BABYDOGE", 1e6},
+		{"10000SATS", "SATS", 1e4},
+		{"100SHIB", "SHIB", 100},
+		{"1INCH", "1INCH", 1},
+		{"10SET", "10SET", 1},
+		{"1200X", "1200X", 1},
+		{"1000", "1000", 1},
+		{" btc ", "BTC", 1},
+	}
+	for _, tt := range tests {
+		got, multiplier := SplitMultiplier(tt.base)
+		if got != tt.want || multiplier != tt.multiplier {
+			t.Errorf("SplitMultiplier(%q) = %q, %v, want %q, %v", tt.base, got, multiplier, tt.want, tt.multiplier)
+		}
+	}
+}
+
+func perp(exchange connector.ExchangeID, symbol, base string) connector.Instrument {
+	return connector.Instrument{ExchangeID: exchange, Symbol: symbol, BaseAsset: base, QuoteAsset: "USDT", InstrumentType: "perpetual"}
+}
+
+// A venue listing both the unit and the multiple contract resolves the
+// canonical to the unit, whatever order they are registered in
+func TestRegisterPrefersUnitContract(t *testing.T) {
+	for _, order := range [][]string{{"PEPE", "1000PEPE"}, {"1000PEPE", "PEPE"}} {
+		m := New()
+		for _, base := range order {
+			m.Register([]connector.Instrument{perp(connector.Binance, base+"USDT", base)})
+		}
+		e, ok := m.Reverse("PEPE", connector.Binance)
+		if !ok || e.Symbol != "PEPEUSDT" || e.Multiplier != 1 {
+			t.Errorf("registered %v: PEPE resolves to %+v, want PEPEUSDT", order, e)
+		}
+		if _, ok := m.Unit(connector.Binance, "1000PEPEUSDT"); ok {
+			t.Errorf("registered %v: 1000PEPEUSDT reported as the unit contract", order)
+		}
+		if e, _ := m.Lookup(connector.Binance, "1000PEPEUSDT"); e.Canonical != "PEPE" || e.UnitPrice(12) != 0.012 {
+			t.Errorf("registered %v: 1000PEPEUSDT = %+v", order, e)
+		}
+	}
+}
+
+func TestRegisterRenamedSymbol(t *testing.T) {
+	m := New()
+	m.Register([]connector.Instrument{
+		perp(connector.Binance, "PEPEUSDT", "PEPE"),
+		perp(connector.Binance, "1000PEPEUSDT", "1000PEPE"),
+		perp(connector.Bybit, "1000PEPEUSDT", "1000PEPE"),
+	})
+
+	// The multiple contract moving to another asset leaves the unit one
+	m.Register([]connector.Instrument{perp(connector.Binance, "1000PEPEUSDT", "1000NEIRO")})
+	if e, ok := m.Reverse("PEPE", connector.Binance); !ok || e.Symbol != "PEPEUSDT" {
+		t.Fatalf("PEPE on binance = %+v, %v after 1000PEPEUSDT was renamed, want PEPEUSDT", e, ok)
+	}
+	if e, ok := m.Reverse("NEIRO", connector.Binance); !ok || e.Symbol != "1000PEPEUSDT" {
+		t.Fatalf("NEIRO on binance = %+v, %v, want the renamed contract", e, ok)
+	}
+
+	// The unit contract moving away hands the slot to the venue's next one
+	m.Register([]connector.Instrument{
+		perp(connector.Binance, "1000PEPEUSDT", "1000PEPE"),
+		perp(connector.Binance, "PEPEUSDT", "PEPE2"),
+	})
+	if e, ok := m.Reverse("PEPE", connector.Binance); !ok || e.Symbol != "1000PEPEUSDT" {
+		t.Fatalf("PEPE on binance = %+v, %v after PEPEUSDT was renamed, want 1000PEPEUSDT", e, ok)
+	}
+	if got := m.Exchanges("NEIRO"); len(got) != 0 {
+		t.Errorf("NEIRO still listed on %v after its only contract moved back", got)
+	}
+	if got := m.Canonicals(2); len(got) != 1 || got[0] != "PEPE" {
+		t.Errorf("canonicals on 2 venues = %v, want [PEPE]", got)
+	}
+}
@@ -0,0 +1,502 @@
+package symbolstatus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/restcache"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Transition sources
+const (
+	SourceVenue  = "venue"  // Reported by the venue's instrument list
+	SourceWindow = "window" // A scheduled window opened or closed
+)
+
+// CanOpen returns true if a status accepts orders that open positions
+func CanOpen(status string) bool {
+	return status == "" || status == connector.StatusTrading
+}
+
+// CanClose returns true if a status accepts orders that reduce positions
+func CanClose(status string) bool {
+	switch status {
+	case "", connector.StatusTrading, connector.StatusLimitOpen, connector.StatusDelisting:
+		return true
+	}
+	return false
+}
+
+// ValidStatus returns true for the statuses a window may impose
+func ValidStatus(status string) bool {
+	switch status {
+	case connector.StatusLimitOpen, connector.StatusDelisting, connector.StatusSettling, connector.StatusMaintenance:
+		return true
+	}
+	return false
+}
+
+// Transition is a venue symbol changing status
+type Transition = mdtypes.Transition
+
+// Window imposes a status for a period, e.g. announced venue maintenance or
+// a contract's trading hours. An empty Symbol covers the whole exchange.
+type Window struct {
+	ID       string               `json:"id"`
+	Exchange connector.ExchangeID `json:"exchange"`
+	Symbol   string               `json:"symbol,omitempty"`
+	Status   string               `json:"status"`
+	From     time.Time            `json:"from"`
+	Until    time.Time            `json:"until"`
+	Reason   string               `json:"reason,omitempty"`
+}
+
+// WindowID returns the ID of the window covering an exchange symbol; "*"
+// stands for every symbol
+func WindowID(exchange connector.ExchangeID, symbol string) string {
+	if symbol == "" {
+		symbol = "*"
+	}
+	return string(exchange) + ":" + symbol
+}
+
+func (w Window) active(now time.Time) bool {
+	return !now.Before(w.From) && now.Before(w.Until)
+}
+
+// SymbolStatus is a symbol's effective status
+type SymbolStatus struct {
+	Exchange  connector.ExchangeID `json:"exchange"`
+	Symbol    string               `json:"symbol"`
+	Canonical string               `json:"canonical,omitempty"`
+	Status    string               `json:"status"`
+	Venue     string               `json:"venue"`            // As reported by the venue
+	Window    string               `json:"window,omitempty"` // ID of the window overriding it
+	Since     time.Time            `json:"since"`
+}
+
+// Handler is called for every transition
+type Handler func(t Transition)
+
+// Config controls the status tracker
+type Config struct {
+	PollInterval   time.Duration // Time between instrument polls of each venue
+	WindowInterval time.Duration // How often windows are checked for opening or closing
+	Timeout        time.Duration // Per-request timeout
+	KeepRecent     int           // Transitions kept for the admin API
+}
+
+// DefaultConfig polls instruments every minute
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:   time.Minute,
+		WindowInterval: 5 * time.Second,
+		Timeout:        15 * time.Second,
+		KeepRecent:     500,
+	}
+}
+
+type venueStatus struct {
+	canonical string
+	status    string
+	since     time.Time
+}
+
+// Tracker follows the trading status of every venue symbol from the venues'
+// instrument lists and scheduled windows, so discovery and execution can
+// stop opening positions on contracts in maintenance, reduce-only or
+// settlement. Symbols never seen are treated as trading.
+type Tracker struct {
+	config     Config
+	connectors []connector.Connector
+	handler    Handler
+	onList     func(connector.ExchangeID, []connector.Instrument) // Optional; sees every instrument list polled
+
+	mu       sync.RWMutex
+	venues   map[connector.ExchangeID]map[string]venueStatus
+	windows  map[string]Window
+	open     map[string]bool // Windows active at the last check
+	baseline map[connector.ExchangeID]bool
+	recent   []Transition
+	done     chan struct{}
+}
+
+// NewTracker creates a status tracker polling the given connectors
+func NewTracker(connectors []connector.Connector, config Config) *Tracker {
+	return &Tracker{
+		config:     config,
+		connectors: connectors,
+		venues:     make(map[connector.ExchangeID]map[string]venueStatus),
+		windows:    make(map[string]Window),
+		open:       make(map[string]bool),
+		baseline:   make(map[connector.ExchangeID]bool),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetHandler sets the callback for status transitions
+func (t *Tracker) SetHandler(handler Handler) {
+	t.handler = handler
+}
+
+// SetInstrumentsHandler sets a callback receiving every instrument list
+// polled, e.g. to learn funding intervals from contract metadata
+func (t *Tracker) SetInstrumentsHandler(handler func(connector.ExchangeID, []connector.Instrument)) {
+	t.onList = handler
+}
+
+// Start polls the venues and checks windows until the context is cancelled
+// or Stop is called
+func (t *Tracker) Start(ctx context.Context) {
+	t.pollAll(ctx)
+
+	poll := time.NewTicker(t.config.PollInterval)
+	defer poll.Stop()
+	windows := time.NewTicker(t.config.WindowInterval)
+	defer windows.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.done:
+			return
+		case <-poll.C:
+			t.pollAll(ctx)
+		case now := <-windows.C:
+			t.checkWindows(now)
+		}
+	}
+}
+
+// Stop stops the tracker
+func (t *Tracker) Stop() {
+	close(t.done)
+}
+
+// Status returns the effective status of a venue symbol
+func (t *Tracker) Status(exchange connector.ExchangeID, symbol string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	status, _ := t.effective(exchange, symbol, time.Now())
+	return status
+}
+
+// CanOpen returns true if positions may be opened on a venue symbol
+func (t *Tracker) CanOpen(exchange connector.ExchangeID, symbol string) bool {
+	return CanOpen(t.Status(exchange, symbol))
+}
+
+// CanClose returns true if positions may be reduced on a venue symbol
+func (t *Tracker) CanClose(exchange connector.ExchangeID, symbol string) bool {
+	return CanClose(t.Status(exchange, symbol))
+}
+
+// Gated returns every symbol whose effective status is not trading, by
+// exchange then symbol
+func (t *Tracker) Gated() []SymbolStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := time.Now()
+	var result []SymbolStatus
+	for exchange, symbols := range t.venues {
+		for symbol, vs := range symbols {
+			status, window := t.effective(exchange, symbol, now)
+			if CanOpen(status) {
+				continue
+			}
+			result = append(result, SymbolStatus{
+				Exchange:  exchange,
+				Symbol:    symbol,
+				Canonical: vs.canonical,
+				Status:    status,
+				Venue:     vs.status,
+				Window:    window,
+				Since:     vs.since,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// Recent returns the most recent transitions, newest first
+func (t *Tracker) Recent() []Transition {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]Transition, len(t.recent))
+	for i, tr := range t.recent {
+		result[len(t.recent)-1-i] = tr
+	}
+	return result
+}
+
+// AddWindow schedules a window, replacing any window with the same ID.
+// A window already in effect takes effect immediately.
+func (t *Tracker) AddWindow(w Window) (Window, error) {
+	w.Exchange = connector.ExchangeID(strings.ToLower(string(w.Exchange)))
+	if w.Exchange == "" {
+		return w, fmt.Errorf("exchange is required")
+	}
+	if !ValidStatus(w.Status) {
+		return w, fmt.Errorf("invalid status %q: want limit_open, delisting, settling or maintenance", w.Status)
+	}
+	if w.From.IsZero() {
+		w.From = time.Now()
+	}
+	if !w.Until.After(w.From) {
+		return w, fmt.Errorf("until must be after from")
+	}
+	w.ID = WindowID(w.Exchange, w.Symbol)
+
+	t.mu.Lock()
+	t.windows[w.ID] = w
+	delete(t.open, w.ID) // A replaced window is reported again with its new status
+	t.mu.Unlock()
+
+	log.Info().
+		Str("window", w.ID).
+		Str("status", w.Status).
+		Time("from", w.From).
+		Time("until", w.Until).
+		Str("reason", w.Reason).
+		Msg("Symbol status window scheduled")
+	t.checkWindows(time.Now())
+	return w, nil
+}
+
+// RemoveWindow cancels a window. Returns false if no such window exists.
+func (t *Tracker) RemoveWindow(id string) bool {
+	t.mu.Lock()
+	w, ok := t.windows[id]
+	var transitions []Transition
+	if ok && t.open[id] {
+		transitions = append(transitions, t.windowTransition(w, false, time.Now()))
+	}
+	delete(t.windows, id)
+	delete(t.open, id)
+	t.remember(transitions)
+	t.mu.Unlock()
+
+	if ok {
+		log.Info().Str("window", id).Msg("Symbol status window removed")
+	}
+	t.emit(transitions)
+	return ok
+}
+
+// Windows returns every scheduled window, soonest first
+func (t *Tracker) Windows() []Window {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]Window, 0, len(t.windows))
+	for _, w := range t.windows {
+		result = append(result, w)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].From.Before(result[j].From)
+	})
+	return result
+}
+
+// effective returns the status of a symbol and the window imposing it, if
+// any. A symbol window takes precedence over an exchange-wide one.
+// Caller holds t.mu.
+func (t *Tracker) effective(exchange connector.ExchangeID, symbol string, now time.Time) (string, string) {
+	for _, id := range []string{WindowID(exchange, symbol), WindowID(exchange, "")} {
+		if w, ok := t.windows[id]; ok && w.active(now) {
+			return w.Status, w.ID
+		}
+	}
+	if vs, ok := t.venues[exchange][symbol]; ok {
+		return vs.status, ""
+	}
+	return connector.StatusTrading, ""
+}
+
+// pollAll fetches every venue's instruments concurrently
+func (t *Tracker) pollAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, conn := range t.connectors {
+		wg.Add(1)
+		go func(c connector.Connector) {
+			defer wg.Done()
+
+			// Status transitions must show up within a poll, not a cache TTL
+			reqCtx, cancel := context.WithTimeout(restcache.Fresh(ctx), t.config.Timeout)
+			defer cancel()
+			instruments, err := c.FetchInstruments(reqCtx)
+			if err != nil {
+				log.Debug().Err(err).Str("exchange", string(c.ID())).Msg("Symbol status poll failed")
+				return
+			}
+			t.Record(c.ID(), instruments)
+			if t.onList != nil {
+				t.onList(c.ID(), instruments)
+			}
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// Record updates a venue's statuses from its instrument list and reports
+// the changes. On the first list of a venue only symbols that are not
+// trading are reported. Symbols missing from a non-empty list are forgotten.
+func (t *Tracker) Record(exchange connector.ExchangeID, instruments []connector.Instrument) {
+	now := time.Now()
+
+	t.mu.Lock()
+	first := !t.baseline[exchange]
+	t.baseline[exchange] = true
+
+	symbols := t.venues[exchange]
+	if symbols == nil {
+		symbols = make(map[string]venueStatus)
+		t.venues[exchange] = symbols
+	}
+
+	var transitions []Transition
+	current := make(map[string]bool, len(instruments))
+	for _, inst := range instruments {
+		status := inst.Status
+		if status == "" {
+			status = connector.StatusTrading
+		}
+		current[inst.Symbol] = true
+
+		prev, seen := symbols[inst.Symbol]
+		from := connector.StatusTrading
+		if seen {
+			from = prev.status
+			if from == status {
+				continue
+			}
+		}
+		symbols[inst.Symbol] = venueStatus{canonical: inst.Canonical, status: status, since: now}
+		if (first || !seen) && status == connector.StatusTrading {
+			continue
+		}
+		transitions = append(transitions, Transition{
+			Exchange:  exchange,
+			Symbol:    inst.Symbol,
+			Canonical: inst.Canonical,
+			From:      from,
+			To:        status,
+			Source:    SourceVenue,
+			At:        now,
+		})
+	}
+
+	// An empty response is more likely a venue hiccup than a full delisting
+	if len(instruments) > 0 {
+		for symbol := range symbols {
+			if !current[symbol] {
+				delete(symbols, symbol)
+			}
+		}
+	}
+
+	gated := make(map[string]int)
+	for _, vs := range symbols {
+		if vs.status != connector.StatusTrading {
+			gated[vs.status]++
+		}
+	}
+	t.remember(transitions)
+	t.mu.Unlock()
+
+	for _, status := range []string{connector.StatusLimitOpen, connector.StatusDelisting, connector.StatusSettling, connector.StatusMaintenance} {
+		metrics.SymbolsGated.WithLabelValues(string(exchange), status).Set(float64(gated[status]))
+	}
+	t.emit(transitions)
+}
+
+// checkWindows reports windows that opened or closed since the last check
+// and drops those that ended
+func (t *Tracker) checkWindows(now time.Time) {
+	t.mu.Lock()
+	var transitions []Transition
+	for id, w := range t.windows {
+		active := w.active(now)
+		if active != t.open[id] {
+			transitions = append(transitions, t.windowTransition(w, active, now))
+		}
+		if active {
+			t.open[id] = true
+			continue
+		}
+		delete(t.open, id)
+		if !now.Before(w.Until) {
+			delete(t.windows, id)
+		}
+	}
+	t.remember(transitions)
+	t.mu.Unlock()
+
+	t.emit(transitions)
+}
+
+// windowTransition describes a window opening or closing over the venue's
+// own status. Caller holds t.mu.
+func (t *Tracker) windowTransition(w Window, opening bool, now time.Time) Transition {
+	venue := connector.StatusTrading
+	var canonical string
+	if vs, ok := t.venues[w.Exchange][w.Symbol]; ok && w.Symbol != "" {
+		venue, canonical = vs.status, vs.canonical
+	}
+	tr := Transition{
+		Exchange:  w.Exchange,
+		Symbol:    w.Symbol,
+		Canonical: canonical,
+		From:      venue,
+		To:        w.Status,
+		Source:    SourceWindow,
+		Reason:    w.Reason,
+		At:        now,
+	}
+	if !opening {
+		tr.From, tr.To = w.Status, venue
+	}
+	return tr
+}
+
+// remember keeps transitions for the admin API. Caller holds t.mu.
+func (t *Tracker) remember(transitions []Transition) {
+	t.recent = append(t.recent, transitions...)
+	if len(t.recent) > t.config.KeepRecent {
+		t.recent = t.recent[len(t.recent)-t.config.KeepRecent:]
+	}
+}
+
+func (t *Tracker) emit(transitions []Transition) {
+	for _, tr := range transitions {
+		metrics.SymbolStatusTransitions.WithLabelValues(string(tr.Exchange), tr.To, tr.Source).Inc()
+		log.Info().
+			Str("exchange", string(tr.Exchange)).
+			Str("symbol", tr.Symbol).
+			Str("from", tr.From).
+			Str("to", tr.To).
+			Str("source", tr.Source).
+			Msg("Symbol status changed")
+		if t.handler != nil {
+			t.handler(tr)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Package universe loads the symbol universe md-ingest subscribes to: the
+// symbols, book depth and default spread thresholds, with per-exchange
+// overrides, from a YAML or JSON file named by CONFIG_PATH. Without a file
+// the built-in defaults apply.
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"crossspread-md-ingest/internal/connector"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSymbols are the perpetuals subscribed without a config file
+var DefaultSymbols = []string{
+	"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT", "XRPUSDT",
+	"DOGEUSDT", "ADAUSDT", "MATICUSDT", "AVAXUSDT", "DOTUSDT",
+	"LTCUSDT", "LINKUSDT", "UNIUSDT", "ATOMUSDT", "ETCUSDT",
+}
+
+// DefaultDepth is the book depth of exchanges without an override
+const DefaultDepth = 20
+
+// Config is the symbol universe. Symbols are written BASEQUOTE (BTCUSDT)
+// and converted to each exchange's format when subscribing.
+//
+//	symbols: [BTCUSDT, ETHUSDT, SOLUSDT]
+//	depth: 20
+//	min_spread_bps: 5
+//	min_depth_usd: 1000
+//	exchanges:
+//	  bybit: {depth: 50}
+//	  binance: {add: [PEPEUSDT]}
+//	  deribit: {symbols: [BTCUSDT, ETHUSDT]}
+type Config struct {
+	Symbols []string `json:"symbols" yaml:"symbols"`
+	Depth   int      `json:"depth,omitempty" yaml:"depth,omitempty"`
+
+	// Default spread thresholds; zero keeps the built-in one. Runtime
+	// settings in Redis override them.
+	MinSpreadBps float64 `json:"min_spread_bps,omitempty" yaml:"min_spread_bps,omitempty"`
+	MinDepthUSD  float64 `json:"min_depth_usd,omitempty" yaml:"min_depth_usd,omitempty"`
+
+	Exchanges map[connector.ExchangeID]Exchange `json:"exchanges,omitempty" yaml:"exchanges,omitempty"`
+}
+
+// Exchange overrides the universe on one exchange
+type Exchange struct {
+	Symbols []string `json:"symbols,omitempty" yaml:"symbols,omitempty"` // Replaces the universe's symbols
+	Add     []string `json:"add,omitempty" yaml:"add,omitempty"`
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	Depth   int      `json:"depth,omitempty" yaml:"depth,omitempty"`
+}
+
+// Default returns the built-in universe: the default symbols everywhere,
+// 50 levels on Bybit and the top 5 on OKX
+func Default() *Config {
+	return &Config{
+		Symbols: append([]string(nil), DefaultSymbols...),
+		Depth:   DefaultDepth,
+		Exchanges: map[connector.ExchangeID]Exchange{
+			connector.Bybit: {Depth: 50},
+			connector.OKX:   {Depth: 5},
+		},
+	}
+}
+
+// Load reads a config file, as JSON if it ends in .json and YAML
+// otherwise. Exchanges the file doesn't mention keep the built-in depth.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, strings.EqualFold(filepath.Ext(path), ".json"))
+}
+
+// Parse parses and validates a config
+func Parse(data []byte, isJSON bool) (*Config, error) {
+	c := &Config{}
+	var err error
+	if isJSON {
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	} else {
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("universe: %w", err)
+	}
+
+	defaults := Default()
+	if c.Depth == 0 {
+		c.Depth = defaults.Depth
+	}
+	normalized := make(map[connector.ExchangeID]Exchange, len(c.Exchanges))
+	for id, ex := range defaults.Exchanges {
+		normalized[id] = ex
+	}
+	for id, ex := range c.Exchanges {
+		id = connector.ExchangeID(strings.ToLower(string(id)))
+		if ex.Depth == 0 {
+			ex.Depth = normalized[id].Depth
+		}
+		ex.Symbols = normalize(ex.Symbols)
+		ex.Add = normalize(ex.Add)
+		ex.Exclude = normalize(ex.Exclude)
+		normalized[id] = ex
+	}
+	c.Exchanges = normalized
+	c.Symbols = normalize(c.Symbols)
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks a config is usable
+func (c *Config) Validate() error {
+	if len(c.Symbols) == 0 {
+		return fmt.Errorf("universe: no symbols")
+	}
+	if c.Depth < 0 {
+		return fmt.Errorf("universe: negative depth %d", c.Depth)
+	}
+	if c.MinSpreadBps < 0 || c.MinDepthUSD < 0 {
+		return fmt.Errorf("universe: negative threshold")
+	}
+	for id, ex := range c.Exchanges {
+		if ex.Depth < 0 {
+			return fmt.Errorf("universe: %s: negative depth %d", id, ex.Depth)
+		}
+	}
+	for _, s := range c.Symbols {
+		if err := validSymbol(s); err != nil {
+			return err
+		}
+	}
+	for id, ex := range c.Exchanges {
+		for _, list := range [][]string{ex.Symbols, ex.Add, ex.Exclude} {
+			for _, s := range list {
+				if err := validSymbol(s); err != nil {
+					return fmt.Errorf("%w on %s", err, id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validSymbol checks a symbol is written BASEQUOTE
+func validSymbol(s string) error {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("universe: symbol %q is not written BASEQUOTE, e.g. BTCUSDT", s)
+		}
+	}
+	return nil
+}
+
+// SymbolsFor returns the symbols subscribed on an exchange, sorted
+func (c *Config) SymbolsFor(exchange connector.ExchangeID) []string {
+	ex := c.Exchanges[exchange]
+	base := c.Symbols
+	if len(ex.Symbols) > 0 {
+		base = ex.Symbols
+	}
+
+	excluded := make(map[string]bool, len(ex.Exclude))
+	for _, s := range ex.Exclude {
+		excluded[s] = true
+	}
+	seen := make(map[string]bool, len(base)+len(ex.Add))
+	out := make([]string, 0, len(base)+len(ex.Add))
+	for _, list := range [][]string{base, ex.Add} {
+		for _, s := range list {
+			if !seen[s] && !excluded[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DepthFor returns the book depth subscribed on an exchange
+func (c *Config) DepthFor(exchange connector.ExchangeID) int {
+	if d := c.Exchanges[exchange].Depth; d > 0 {
+		return d
+	}
+	if c.Depth > 0 {
+		return c.Depth
+	}
+	return DefaultDepth
+}
+
+// Diff returns the symbols added and removed on an exchange going from c
+// to next
+func (c *Config) Diff(next *Config, exchange connector.ExchangeID) (added, removed []string) {
+	before := make(map[string]bool)
+	for _, s := range c.SymbolsFor(exchange) {
+		before[s] = true
+	}
+	for _, s := range next.SymbolsFor(exchange) {
+		if before[s] {
+			delete(before, s)
+			continue
+		}
+		added = append(added, s)
+	}
+	for s := range before {
+		removed = append(removed, s)
+	}
+	sort.Strings(removed)
+	return added, removed
+}
+
+// normalize upper-cases and trims symbols, dropping empty ones
+func normalize(symbols []string) []string {
+	out := symbols[:0]
+	for _, s := range symbols {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
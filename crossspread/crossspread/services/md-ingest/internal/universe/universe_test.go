@@ -0,0 +1,127 @@
+package universe
+
+import (
+	"reflect"
+	"testing"
+
+	"crossspread-md-ingest/internal/connector"
+)
+
+const yamlConfig = `
+symbols: [btcusdt, " ETHUSDT ", SOLUSDT]
+min_spread_bps: 5
+exchanges:
+  Bybit: {add: [PEPEUSDT]}
+  OKX: {depth: 400}
+  binance: {exclude: [SOLUSDT], depth: 10}
+  deribit: {symbols: [BTCUSDT]}
+`
+
+const jsonConfig = `{
+	"symbols": ["btcusdt", " ETHUSDT ", "SOLUSDT"],
+	"min_spread_bps": 5,
+	"exchanges": {
+		"Bybit": {"add": ["PEPEUSDT"]},
+		"OKX": {"depth": 400},
+		"binance": {"exclude": ["SOLUSDT"], "depth": 10},
+		"deribit": {"symbols": ["BTCUSDT"]}
+	}
+}`
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		data   string
+		isJSON bool
+	}{
+		{"yaml", yamlConfig, false},
+		{"json", jsonConfig, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.data), tt.isJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}; !reflect.DeepEqual(c.Symbols, want) {
+				t.Errorf("symbols %q, want them trimmed and upper-cased %q", c.Symbols, want)
+			}
+			if c.MinSpreadBps != 5 {
+				t.Errorf("min_spread_bps %v, want 5", c.MinSpreadBps)
+			}
+			if _, ok := c.Exchanges["Bybit"]; ok {
+				t.Error("exchange keys not lowercased")
+			}
+
+			// Unset depths fall back to the built-in ones
+			for id, want := range map[connector.ExchangeID]int{
+				connector.Bybit:   50,
+				connector.OKX:     400,
+				connector.Binance: 10,
+				connector.Deribit: DefaultDepth,
+				connector.GateIO:  DefaultDepth,
+			} {
+				if got := c.DepthFor(id); got != want {
+					t.Errorf("depth on %s = %d, want %d", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		data   string
+		isJSON bool
+	}{
+		{"unknown yaml field", "symbols: [BTCUSDT]\nmin_spread: 5\n", false},
+		{"unknown yaml exchange field", "symbols: [BTCUSDT]\nexchanges:\n  okx: {remove: [BTCUSDT]}\n", false},
+		{"unknown json field", `{"symbols": ["BTCUSDT"], "min_spread": 5}`, true},
+		{"no symbols", "depth: 20\n", false},
+		{"venue format", "symbols: [BTC-USDT]\n", false},
+		{"negative depth", `{"symbols": ["BTCUSDT"], "exchanges": {"okx": {"depth": -1}}}`, true},
+	} {
+		if _, err := Parse([]byte(tt.data), tt.isJSON); err == nil {
+			t.Errorf("%s: parsed, want an error", tt.name)
+		}
+	}
+}
+
+func TestSymbolsFor(t *testing.T) {
+	c, err := Parse([]byte(yamlConfig), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[connector.ExchangeID][]string{
+		connector.Bybit:   {"BTCUSDT", "ETHUSDT", "PEPEUSDT", "SOLUSDT"},
+		connector.Binance: {"BTCUSDT", "ETHUSDT"},
+		connector.Deribit: {"BTCUSDT"},
+		connector.GateIO:  {"BTCUSDT", "ETHUSDT", "SOLUSDT"},
+	} {
+		if got := c.SymbolsFor(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("symbols on %s = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current, err := Parse([]byte(yamlConfig), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := Parse([]byte("symbols: [BTCUSDT, XRPUSDT, DOGEUSDT]\nexchanges:\n  deribit: {symbols: [BTCUSDT]}\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, removed := current.Diff(next, connector.Bybit)
+	if want := []string{"DOGEUSDT", "XRPUSDT"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added on bybit %q, want %q", added, want)
+	}
+	if want := []string{"ETHUSDT", "PEPEUSDT", "SOLUSDT"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed on bybit %q, want %q", removed, want)
+	}
+	if added, removed := current.Diff(next, connector.Deribit); len(added)+len(removed) != 0 {
+		t.Errorf("deribit changed: added %q, removed %q", added, removed)
+	}
+}
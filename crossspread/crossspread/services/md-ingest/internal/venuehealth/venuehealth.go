@@ -0,0 +1,358 @@
+package venuehealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/apiversion"
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Venue states
+const (
+	StateHealthy  = "healthy"
+	StateDegraded = "degraded" // Orders throttled, passive quoting disabled
+)
+
+// Reasons a venue was degraded
+const (
+	ReasonLatency = "latency"
+	ReasonErrors  = "errors"
+)
+
+// Config controls when a venue's REST API counts as degraded
+type Config struct {
+	Window        time.Duration // Trailing window of REST calls judged
+	MinSamples    int           // Calls in the window before a venue is judged at all
+	MaxLatency    time.Duration // p90 latency above this degrades the venue
+	MaxErrorRate  float64       // Share of failed calls above this degrades the venue
+	RecoverAfter  time.Duration // Time without a breach before a degraded venue recovers
+	RateFactor    float64       // Scale of the venue's order rate while degraded
+	CheckInterval time.Duration // How often venues are judged and the state published
+}
+
+// DefaultConfig degrades a venue whose p90 REST latency passes 2s or whose
+// error rate passes 20% over a minute, quartering its order rate until two
+// minutes pass without a breach
+func DefaultConfig() Config {
+	return Config{
+		Window:        time.Minute,
+		MinSamples:    10,
+		MaxLatency:    2 * time.Second,
+		MaxErrorRate:  0.2,
+		RecoverAfter:  2 * time.Minute,
+		RateFactor:    0.25,
+		CheckInterval: 5 * time.Second,
+	}
+}
+
+// Health is a venue's REST health as last judged
+type Health struct {
+	Exchange       connector.ExchangeID `json:"exchange"`
+	State          string               `json:"state"`            // healthy or degraded
+	Reason         string               `json:"reason,omitempty"` // latency or errors, while degraded
+	Samples        int                  `json:"samples"`          // REST calls in the window
+	ErrorRate      float64              `json:"error_rate"`
+	P90LatencyMs   float64              `json:"p90_latency_ms"`
+	RateFactor     float64              `json:"rate_factor"`     // Scale of the venue's order rate budget
+	QuotingAllowed bool                 `json:"quoting_allowed"` // Passive quotes may rest on the venue
+	Since          time.Time            `json:"since"`           // When the state was entered
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// Throttle scales a venue's order rate; *ratebudget.Budget implements it
+type Throttle interface {
+	SetRateFactor(ctx context.Context, exchange connector.ExchangeID, factor float64) error
+}
+
+type sample struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+type venue struct {
+	samples []sample // Oldest first, within the window
+	health  Health
+	clearAt time.Time // First check without a breach while degraded
+}
+
+// Breaker watches the latency and error rate of every venue's REST calls.
+// A venue past either limit is degraded: its shared order rate is scaled
+// down and passive quoting on it is disabled until it stays within both
+// for RecoverAfter. A nil *Breaker reports every venue healthy.
+type Breaker struct {
+	config   Config
+	client   *redis.Client
+	throttle Throttle // Optional; order rates are scaled while degraded
+
+	mu     sync.RWMutex
+	venues map[connector.ExchangeID]*venue
+	done   chan struct{}
+}
+
+// NewBreaker creates a breaker publishing venue health to Redis
+func NewBreaker(client *redis.Client, config Config) *Breaker {
+	return &Breaker{
+		config: config,
+		client: client,
+		venues: make(map[connector.ExchangeID]*venue),
+		done:   make(chan struct{}),
+	}
+}
+
+// SetThrottle scales the order rate of degraded venues
+func (b *Breaker) SetThrottle(throttle Throttle) {
+	b.throttle = throttle
+}
+
+// Transport wraps base so every venue REST call is timed and its outcome
+// recorded. Install it as http.DefaultTransport to cover the connectors'
+// http.DefaultClient.
+func (b *Breaker) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{breaker: b, base: base}
+}
+
+type transport struct {
+	breaker *Breaker
+	base    http.RoundTripper
+}
+
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := tr.base.RoundTrip(req)
+	exchange, ok := apiversion.ExchangeForHost(req.URL.Hostname())
+	if !ok || req.Context().Err() != nil {
+		// Calls the caller gave up on say nothing about the venue
+		return resp, err
+	}
+	failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	tr.breaker.Record(exchange, time.Since(start), failed)
+	return resp, err
+}
+
+// Record adds the outcome of a REST call to a venue
+func (b *Breaker) Record(exchange connector.ExchangeID, latency time.Duration, failed bool) {
+	metrics.VenueRESTDuration.WithLabelValues(string(exchange)).Observe(latency.Seconds())
+	if failed {
+		metrics.VenueRESTErrors.WithLabelValues(string(exchange)).Inc()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v := b.venues[exchange]
+	if v == nil {
+		v = &venue{health: Health{Exchange: exchange, State: StateHealthy, RateFactor: 1, QuotingAllowed: true}}
+		b.venues[exchange] = v
+	}
+	v.samples = append(v.samples, sample{at: time.Now(), latency: latency, failed: failed})
+}
+
+// check judges every venue over the window. Returns the venues whose state
+// changed and all current health.
+func (b *Breaker) check(now time.Time) ([]Health, []Health) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var changed []Health
+	all := make([]Health, 0, len(b.venues))
+	cutoff := now.Add(-b.config.Window)
+	for id, v := range b.venues {
+		drop := 0
+		for drop < len(v.samples) && v.samples[drop].at.Before(cutoff) {
+			drop++
+		}
+		v.samples = v.samples[drop:]
+
+		h := &v.health
+		h.Samples, h.ErrorRate, h.P90LatencyMs = stats(v.samples)
+		h.UpdatedAt = now
+		reason := b.breach(*h)
+
+		switch {
+		case h.State == StateHealthy && reason != "":
+			h.State, h.Reason, h.Since = StateDegraded, reason, now
+			h.RateFactor, h.QuotingAllowed = b.config.RateFactor, false
+			v.clearAt = time.Time{}
+			metrics.VenueDegradations.WithLabelValues(string(id), reason).Inc()
+			log.Warn().
+				Str("exchange", string(id)).
+				Str("reason", reason).
+				Float64("error_rate", h.ErrorRate).
+				Float64("p90_latency_ms", h.P90LatencyMs).
+				Int("samples", h.Samples).
+				Msg("Venue REST API degraded, throttling orders and disabling passive quoting")
+			changed = append(changed, *h)
+		case h.State == StateDegraded && reason != "":
+			v.clearAt = time.Time{}
+		case h.State == StateDegraded && v.clearAt.IsZero():
+			v.clearAt = now
+		case h.State == StateDegraded && now.Sub(v.clearAt) >= b.config.RecoverAfter:
+			log.Info().
+				Str("exchange", string(id)).
+				Dur("degraded_for", now.Sub(h.Since)).
+				Msg("Venue REST API recovered, restoring order rate and passive quoting")
+			h.State, h.Reason, h.Since = StateHealthy, "", now
+			h.RateFactor, h.QuotingAllowed = 1, true
+			changed = append(changed, *h)
+		}
+
+		degraded := 0.0
+		if h.State == StateDegraded {
+			degraded = 1
+		}
+		metrics.VenueDegraded.WithLabelValues(string(id)).Set(degraded)
+		all = append(all, *h)
+	}
+	return changed, all
+}
+
+// breach returns why a venue's window is past a limit, or "" if it is not.
+// Venues with too few calls to judge never breach.
+func (b *Breaker) breach(h Health) string {
+	switch {
+	case h.Samples < b.config.MinSamples:
+		return ""
+	case h.ErrorRate > b.config.MaxErrorRate:
+		return ReasonErrors
+	case h.P90LatencyMs > float64(b.config.MaxLatency.Milliseconds()):
+		return ReasonLatency
+	default:
+		return ""
+	}
+}
+
+// stats returns the count, error rate and p90 latency in ms of samples
+func stats(samples []sample) (int, float64, float64) {
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+	failed := 0
+	latencies := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		if s.failed {
+			failed++
+		}
+		latencies[i] = s.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p90 := latencies[len(latencies)*9/10]
+	return len(samples), float64(failed) / float64(len(samples)), float64(p90) / float64(time.Millisecond)
+}
+
+// Refresh judges every venue, applies rate changes and publishes health
+func (b *Breaker) Refresh(ctx context.Context) error {
+	changed, all := b.check(time.Now())
+	if b.throttle != nil {
+		// Degraded venues are re-applied every check, so the factor
+		// survives the bucket expiring while no strategy trades
+		for _, h := range all {
+			if h.State != StateDegraded {
+				continue
+			}
+			if err := b.throttle.SetRateFactor(ctx, h.Exchange, h.RateFactor); err != nil {
+				return fmt.Errorf("throttle %s: %w", h.Exchange, err)
+			}
+		}
+		for _, h := range changed {
+			if h.State != StateHealthy {
+				continue
+			}
+			if err := b.throttle.SetRateFactor(ctx, h.Exchange, 1); err != nil {
+				return fmt.Errorf("restore %s: %w", h.Exchange, err)
+			}
+		}
+	}
+	return b.publish(ctx, all)
+}
+
+// publish stores every venue's health for executors
+func (b *Breaker) publish(ctx context.Context, all []Health) error {
+	if len(all) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, len(all))
+	for _, h := range all {
+		data, err := json.Marshal(h)
+		if err != nil {
+			return err
+		}
+		fields[string(h.Exchange)] = data
+	}
+	key := keyspace.Key(keyspace.VenueHealthKey)
+	pipe := b.client.TxPipeline()
+	pipe.HSet(ctx, key, fields)
+	pipe.Expire(ctx, key, keyspace.VenueHealthTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Health returns a venue's health; venues without REST calls are healthy
+func (b *Breaker) Health(exchange connector.ExchangeID) Health {
+	healthy := Health{Exchange: exchange, State: StateHealthy, RateFactor: 1, QuotingAllowed: true}
+	if b == nil {
+		return healthy
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if v := b.venues[exchange]; v != nil {
+		return v.health
+	}
+	return healthy
+}
+
+// Degraded reports whether a venue is degraded
+func (b *Breaker) Degraded(exchange connector.ExchangeID) bool {
+	return b.Health(exchange).State == StateDegraded
+}
+
+// QuotingAllowed reports whether passive quotes may rest on a venue
+func (b *Breaker) QuotingAllowed(exchange connector.ExchangeID) bool {
+	return b.Health(exchange).QuotingAllowed
+}
+
+// Venues returns the health of every venue called, sorted by ID
+func (b *Breaker) Venues() []Health {
+	b.mu.RLock()
+	result := make([]Health, 0, len(b.venues))
+	for _, v := range b.venues {
+		result = append(result, v.health)
+	}
+	b.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Exchange < result[j].Exchange })
+	return result
+}
+
+// Start judges venues until the context is cancelled or Stop is called
+func (b *Breaker) Start(ctx context.Context) {
+	ticker := time.NewTicker(b.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if err := b.Refresh(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to apply venue health")
+			}
+		}
+	}
+}
+
+// Stop stops the check loop
+func (b *Breaker) Stop() {
+	close(b.done)
+}
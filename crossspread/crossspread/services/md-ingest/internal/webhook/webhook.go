@@ -0,0 +1,528 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/connector"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/internal/metrics"
+	"crossspread-md-ingest/internal/spread"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Event types
+const (
+	EventOpen  = "spread.open"  // A spread matching the filter was published
+	EventClose = "spread.close" // A spread sent as open is no longer published
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery" // Event ID, the same on every retry
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex>, see Sign
+)
+
+// ErrNotFound is returned for an unknown endpoint ID
+var ErrNotFound = errors.New("webhook not found")
+
+// Config controls delivery
+type Config struct {
+	QueueSize      int           // Events buffered per endpoint; more are dropped
+	Timeout        time.Duration // Per attempt
+	MaxAttempts    int           // Attempts per event before it is given up
+	InitialBackoff time.Duration // Wait after the first failed attempt, doubled after each
+	MaxBackoff     time.Duration
+}
+
+// DefaultConfig makes up to six attempts per event, backing off from 1s
+// to at most a minute
+func DefaultConfig() Config {
+	return Config{
+		QueueSize:      1000,
+		Timeout:        5 * time.Second,
+		MaxAttempts:    6,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+}
+
+// Filter selects the spreads an endpoint receives. Empty fields match
+// everything. A spread is opened to an endpoint the first time it matches
+// and closed once it is no longer published, whether or not it still
+// matches by then.
+type Filter struct {
+	Events         []string               `json:"events,omitempty"`     // spread.open, spread.close
+	Canonicals     []string               `json:"canonicals,omitempty"` // e.g. BTC, ETH-USDC
+	Exchanges      []connector.ExchangeID `json:"exchanges,omitempty"`  // Either leg on one of these
+	MinSpreadBps   float64                `json:"min_spread_bps,omitempty"`
+	MinNetEdgeBps  float64                `json:"min_net_edge_bps,omitempty"`
+	ProfitableOnly bool                   `json:"profitable_only,omitempty"`
+}
+
+// wants reports whether the endpoint takes events of a type
+func (f Filter) wants(eventType string) bool {
+	if len(f.Events) == 0 {
+		return true
+	}
+	for _, e := range f.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a published spread opens to the endpoint
+func (f Filter) matches(opp *spread.SpreadOpportunity) bool {
+	if opp.SpreadBps < f.MinSpreadBps || opp.NetEdgeBps < f.MinNetEdgeBps || (f.ProfitableOnly && !opp.Profitable) {
+		return false
+	}
+	if len(f.Canonicals) > 0 {
+		found := false
+		for _, c := range f.Canonicals {
+			found = found || strings.EqualFold(c, opp.Canonical)
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Exchanges) > 0 {
+		found := false
+		for _, ex := range f.Exchanges {
+			found = found || ex == opp.LongExchange || ex == opp.ShortExchange
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks the filter's event types
+func (f Filter) validate() error {
+	for _, e := range f.Events {
+		if e != EventOpen && e != EventClose {
+			return fmt.Errorf("unknown event %q, want %s or %s", e, EventOpen, EventClose)
+		}
+	}
+	return nil
+}
+
+// Endpoint is a registered webhook. Its secret signs every delivery and
+// is only returned when the endpoint is registered.
+type Endpoint struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Filter    Filter    `json:"filter"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Event is the JSON body POSTed to an endpoint
+type Event struct {
+	ID         string                    `json:"id"`
+	Type       string                    `json:"type"`
+	At         time.Time                 `json:"at"`
+	Spread     *spread.SpreadOpportunity `json:"spread"`    // As first published, or last published on close
+	OpenedAt   time.Time                 `json:"opened_at"` // When the spread was first published
+	PeakBps    float64                   `json:"peak_bps"`
+	DurationMs int64                     `json:"duration_ms,omitempty"` // On close
+}
+
+// Status is an endpoint's delivery counters, without its secret
+type Status struct {
+	Endpoint
+	Queued          int       `json:"queued"`
+	Delivered       int64     `json:"delivered"`
+	Failed          int64     `json:"failed"`  // Given up after every attempt or refused
+	Dropped         int64     `json:"dropped"` // Not queued, the queue being full
+	LastError       string    `json:"last_error,omitempty"`
+	LastDeliveredAt time.Time `json:"last_delivered_at,omitempty"`
+}
+
+type endpoint struct {
+	Endpoint
+	queue  chan Event
+	cancel context.CancelFunc
+	opened map[string]bool // Spread IDs sent as open and not closed yet
+
+	delivered, failed, dropped int64
+	lastError                  string
+	lastDeliveredAt            time.Time
+}
+
+// tracked is a spread being published
+type tracked struct {
+	openedAt time.Time
+	peakBps  float64
+	last     *spread.SpreadOpportunity
+}
+
+// Dispatcher turns published spreads into open and close events and
+// delivers them to registered endpoints, HMAC-signed, so systems without
+// Redis access can consume opportunities. Each endpoint has its own queue
+// and worker; events reach it in order, a failing one retrying with
+// backoff before the next is sent.
+type Dispatcher struct {
+	config Config
+	client *redis.Client
+	http   *http.Client
+
+	mu        sync.Mutex
+	endpoints map[string]*endpoint
+	spreads   map[string]*tracked
+}
+
+// NewDispatcher creates a dispatcher saving endpoints to Redis
+func NewDispatcher(client *redis.Client, config Config) *Dispatcher {
+	return &Dispatcher{
+		config:    config,
+		client:    client,
+		http:      &http.Client{Timeout: config.Timeout},
+		endpoints: make(map[string]*endpoint),
+		spreads:   make(map[string]*tracked),
+	}
+}
+
+// Load starts delivering to the endpoints saved before a restart
+func (d *Dispatcher) Load(ctx context.Context) error {
+	raw, err := d.client.HGetAll(ctx, keyspace.Key(keyspace.WebhooksKey)).Result()
+	if err != nil {
+		return err
+	}
+	for id, v := range raw {
+		var e Endpoint
+		if err := json.Unmarshal([]byte(v), &e); err != nil {
+			log.Warn().Err(err).Str("webhook", id).Msg("Ignoring invalid saved webhook")
+			continue
+		}
+		d.add(e)
+	}
+	return nil
+}
+
+// Register saves an endpoint and starts delivering to it. A secret is
+// generated if none is given.
+func (d *Dispatcher) Register(ctx context.Context, rawURL, secret string, filter Filter, by string) (Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, errors.New("url must be an absolute http or https URL")
+	}
+	if err := filter.validate(); err != nil {
+		return Endpoint{}, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if secret == "" {
+		if secret, err = randomHex(32); err != nil {
+			return Endpoint{}, err
+		}
+	}
+
+	e := Endpoint{
+		ID:        id,
+		URL:       u.String(),
+		Secret:    secret,
+		Filter:    filter,
+		CreatedBy: by,
+		CreatedAt: time.Now(),
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if err := d.client.HSet(ctx, keyspace.Key(keyspace.WebhooksKey), id, data).Err(); err != nil {
+		return Endpoint{}, err
+	}
+	d.add(e)
+	log.Info().Str("webhook", id).Str("url", e.URL).Str("by", by).Msg("Webhook registered")
+	return e, nil
+}
+
+// Delete removes an endpoint; events still queued for it are dropped
+func (d *Dispatcher) Delete(ctx context.Context, id string) error {
+	n, err := d.client.HDel(ctx, keyspace.Key(keyspace.WebhooksKey), id).Result()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	ep := d.endpoints[id]
+	delete(d.endpoints, id)
+	d.mu.Unlock()
+	if ep == nil && n == 0 {
+		return ErrNotFound
+	}
+	if ep != nil {
+		ep.cancel()
+	}
+	log.Info().Str("webhook", id).Msg("Webhook deleted")
+	return nil
+}
+
+// add starts an endpoint's worker
+func (d *Dispatcher) add(e Endpoint) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ep := &endpoint{
+		Endpoint: e,
+		queue:    make(chan Event, d.config.QueueSize),
+		cancel:   cancel,
+		opened:   make(map[string]bool),
+	}
+
+	d.mu.Lock()
+	if prev := d.endpoints[e.ID]; prev != nil {
+		prev.cancel()
+	}
+	d.endpoints[e.ID] = ep
+	d.mu.Unlock()
+
+	go d.run(ctx, ep)
+}
+
+// Endpoints returns every endpoint's delivery status, oldest first
+func (d *Dispatcher) Endpoints() []Status {
+	d.mu.Lock()
+	result := make([]Status, 0, len(d.endpoints))
+	for _, ep := range d.endpoints {
+		st := Status{
+			Endpoint:        ep.Endpoint,
+			Queued:          len(ep.queue),
+			Delivered:       ep.delivered,
+			Failed:          ep.failed,
+			Dropped:         ep.dropped,
+			LastError:       ep.lastError,
+			LastDeliveredAt: ep.lastDeliveredAt,
+		}
+		st.Secret = ""
+		result = append(result, st)
+	}
+	d.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Record takes the spreads published in one cycle, for
+// SpreadDiscovery.SetSpreadsHandler, and queues the open and close events
+// each endpoint is due
+func (d *Dispatcher) Record(spreads []*spread.SpreadOpportunity) {
+	now := time.Now()
+	seen := make(map[string]bool, len(spreads))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, opp := range spreads {
+		seen[opp.ID] = true
+		snapshot := *opp
+		t := d.spreads[opp.ID]
+		if t == nil {
+			t = &tracked{openedAt: now, peakBps: opp.SpreadBps}
+			d.spreads[opp.ID] = t
+		}
+		t.last = &snapshot
+		if opp.SpreadBps > t.peakBps {
+			t.peakBps = opp.SpreadBps
+		}
+
+		for _, ep := range d.endpoints {
+			if ep.opened[opp.ID] || !ep.Filter.matches(opp) {
+				continue
+			}
+			ep.opened[opp.ID] = true
+			if ep.Filter.wants(EventOpen) {
+				d.enqueue(ep, Event{Type: EventOpen, At: now, Spread: &snapshot, OpenedAt: t.openedAt, PeakBps: t.peakBps})
+			}
+		}
+	}
+
+	for id, t := range d.spreads {
+		if seen[id] {
+			continue
+		}
+		delete(d.spreads, id)
+		for _, ep := range d.endpoints {
+			if !ep.opened[id] {
+				continue
+			}
+			delete(ep.opened, id)
+			if ep.Filter.wants(EventClose) {
+				d.enqueue(ep, Event{
+					Type:       EventClose,
+					At:         now,
+					Spread:     t.last,
+					OpenedAt:   t.openedAt,
+					PeakBps:    t.peakBps,
+					DurationMs: now.Sub(t.openedAt).Milliseconds(),
+				})
+			}
+		}
+	}
+}
+
+// enqueue queues an event without blocking spread publication. Caller
+// holds d.mu.
+func (d *Dispatcher) enqueue(ep *endpoint, ev Event) {
+	id, err := randomHex(8)
+	if err != nil {
+		return
+	}
+	ev.ID = id
+	select {
+	case ep.queue <- ev:
+	default:
+		ep.dropped++
+		metrics.WebhookDeliveries.WithLabelValues(ep.ID, "dropped").Inc()
+	}
+}
+
+// run delivers an endpoint's events in order until it is deleted
+func (d *Dispatcher) run(ctx context.Context, ep *endpoint) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-ep.queue:
+			err := d.deliver(ctx, ep.Endpoint, ev)
+			if ctx.Err() != nil {
+				return
+			}
+
+			d.mu.Lock()
+			if err != nil {
+				ep.failed++
+				ep.lastError = err.Error()
+			} else {
+				ep.delivered++
+				ep.lastDeliveredAt = time.Now()
+			}
+			d.mu.Unlock()
+
+			if err != nil {
+				metrics.WebhookDeliveries.WithLabelValues(ep.ID, "failed").Inc()
+				log.Warn().Err(err).Str("webhook", ep.ID).Str("event", ev.Type).Str("spread", ev.Spread.ID).Msg("Webhook delivery given up")
+				continue
+			}
+			metrics.WebhookDeliveries.WithLabelValues(ep.ID, "delivered").Inc()
+		}
+	}
+}
+
+// permanentError is a response that retrying won't change
+type permanentError struct{ status int }
+
+func (e permanentError) Error() string {
+	return fmt.Sprintf("endpoint refused the event: HTTP %d", e.status)
+}
+
+// deliver POSTs an event, retrying with exponential backoff on network
+// errors, timeouts, 408, 429 and 5xx responses
+func (d *Dispatcher) deliver(ctx context.Context, e Endpoint, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	backoff := d.config.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := d.post(ctx, e, ev, body)
+		var permanent permanentError
+		if err == nil || errors.As(err, &permanent) || attempt >= d.config.MaxAttempts {
+			return err
+		}
+		metrics.WebhookDeliveries.WithLabelValues(e.ID, "retried").Inc()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, d.config.MaxBackoff)
+	}
+}
+
+// post makes one delivery attempt
+func (d *Dispatcher) post(ctx context.Context, e Endpoint, ev Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, ev.Type)
+	req.Header.Set(HeaderDelivery, ev.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(e.Secret, timestamp, body))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	default:
+		return permanentError{status: resp.StatusCode}
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "{timestamp}.{body}" under secret.
+// Receivers recompute it over the raw body and the X-Webhook-Timestamp
+// header, and should reject stale timestamps to stop replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Stop stops every endpoint's worker; queued events are dropped
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, ep := range d.endpoints {
+		ep.cancel()
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,333 @@
+package mdclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"crossspread-md-ingest/internal/claim"
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/pkg/mdtypes"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound is returned when a key does not exist (or has expired)
+var ErrNotFound = errors.New("mdclient: key not found")
+
+// Client is a typed reader for the keys and channels md-ingest writes
+type Client struct {
+	rdb *redis.Client
+}
+
+// NewClient creates a typed client on top of an existing Redis client
+func NewClient(rdb *redis.Client) *Client {
+	return &Client{rdb: rdb}
+}
+
+// SetKeyPrefix sets the namespace of the md-ingest instance to read, i.e.
+// its REDIS_KEY_PREFIX. It applies to every client in the process.
+func SetKeyPrefix(prefix string) {
+	keyspace.SetPrefix(prefix)
+}
+
+// Claims returns the opportunity lease registry executors use so only one
+// of them acts on a spread ID at a time
+func (c *Client) Claims(config claim.Config) *claim.Claims {
+	return claim.New(c.rdb, config)
+}
+
+// GetSpread returns the latest state of a spread by ID
+func (c *Client) GetSpread(ctx context.Context, spreadID string) (*mdtypes.SpreadOpportunity, error) {
+	var opp mdtypes.SpreadOpportunity
+	if err := c.getJSON(ctx, keyspace.SpreadDataKey(spreadID), &opp); err != nil {
+		return nil, err
+	}
+	return &opp, nil
+}
+
+// GetSpreadsList returns the current top spreads summary
+func (c *Client) GetSpreadsList(ctx context.Context) (*mdtypes.SpreadSummary, error) {
+	var summary mdtypes.SpreadSummary
+	if err := c.getJSON(ctx, keyspace.Key(keyspace.SpreadsListKey), &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetTenantSpreads returns the current top spreads a tenant can trade on
+// both legs
+func (c *Client) GetTenantSpreads(ctx context.Context, tenant string) (*mdtypes.TenantSpreadSummary, error) {
+	var summary mdtypes.TenantSpreadSummary
+	if err := c.getJSON(ctx, keyspace.TenantSpreadsKey(tenant), &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetIndex returns the latest index price for a canonical symbol
+func (c *Client) GetIndex(ctx context.Context, canonical string) (*mdtypes.IndexPrice, error) {
+	var idx mdtypes.IndexPrice
+	if err := c.getJSON(ctx, keyspace.IndexKey(canonical), &idx); err != nil {
+		return nil, err
+	}
+	return &idx, nil
+}
+
+// GetSpreadClusters returns the latest correlation of exchange pairs'
+// spreads and the clusters of pairs that move together
+func (c *Client) GetSpreadClusters(ctx context.Context) (*mdtypes.SpreadCorrelation, error) {
+	var corr mdtypes.SpreadCorrelation
+	if err := c.getJSON(ctx, keyspace.Key(keyspace.HistoryClustersKey), &corr); err != nil {
+		return nil, err
+	}
+	return &corr, nil
+}
+
+// ActiveSpreadIDs returns all spread IDs that have been published
+func (c *Client) ActiveSpreadIDs(ctx context.Context) ([]string, error) {
+	return c.rdb.SMembers(ctx, keyspace.Key(keyspace.SpreadsActiveKey)).Result()
+}
+
+// RecentOrderbooks returns up to count most recent orderbooks from the stream, newest first
+func (c *Client) RecentOrderbooks(ctx context.Context, exchange mdtypes.ExchangeID, symbol string, count int64) ([]*mdtypes.Orderbook, error) {
+	msgs, err := c.rdb.XRevRangeN(ctx, keyspace.OrderbookKey(string(exchange), symbol), "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*mdtypes.Orderbook, 0, len(msgs))
+	for _, msg := range msgs {
+		var ob mdtypes.Orderbook
+		if err := decodeStreamData(msg, &ob); err != nil {
+			return nil, err
+		}
+		result = append(result, &ob)
+	}
+	return result, nil
+}
+
+// RecentTrades returns up to count most recent trades from the stream, newest first
+func (c *Client) RecentTrades(ctx context.Context, exchange mdtypes.ExchangeID, symbol string, count int64) ([]*mdtypes.Trade, error) {
+	msgs, err := c.rdb.XRevRangeN(ctx, keyspace.TradesKey(string(exchange), symbol), "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*mdtypes.Trade, 0, len(msgs))
+	for _, msg := range msgs {
+		var trade mdtypes.Trade
+		if err := decodeStreamData(msg, &trade); err != nil {
+			return nil, err
+		}
+		result = append(result, &trade)
+	}
+	return result, nil
+}
+
+// RecentFundingRates returns up to count most recent funding rates of a
+// venue symbol from the stream, newest first
+func (c *Client) RecentFundingRates(ctx context.Context, exchange mdtypes.ExchangeID, symbol string, count int64) ([]*mdtypes.FundingRate, error) {
+	msgs, err := c.rdb.XRevRangeN(ctx, keyspace.FundingKey(string(exchange), symbol), "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*mdtypes.FundingRate, 0, len(msgs))
+	for _, msg := range msgs {
+		var fr mdtypes.FundingRate
+		if err := decodeStreamData(msg, &fr); err != nil {
+			return nil, err
+		}
+		result = append(result, &fr)
+	}
+	return result, nil
+}
+
+// RecentBars returns up to count most recent closed bars of a venue symbol
+// for an interval (1s, 1m), newest first
+func (c *Client) RecentBars(ctx context.Context, exchange mdtypes.ExchangeID, symbol, interval string, count int64) ([]*mdtypes.Bar, error) {
+	return c.recentBars(ctx, keyspace.BarsKey(string(exchange), symbol, interval), count)
+}
+
+// RecentConsolidatedBars returns up to count most recent closed bars of a
+// canonical symbol across venues, newest first
+func (c *Client) RecentConsolidatedBars(ctx context.Context, canonical, interval string, count int64) ([]*mdtypes.Bar, error) {
+	return c.recentBars(ctx, keyspace.ConsolidatedBarsKey(canonical, interval), count)
+}
+
+func (c *Client) recentBars(ctx context.Context, key string, count int64) ([]*mdtypes.Bar, error) {
+	msgs, err := c.rdb.XRevRangeN(ctx, key, "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*mdtypes.Bar, 0, len(msgs))
+	for _, msg := range msgs {
+		var bar mdtypes.Bar
+		if err := decodeStreamData(msg, &bar); err != nil {
+			return nil, err
+		}
+		result = append(result, &bar)
+	}
+	return result, nil
+}
+
+// RecentOpenInterestEvents returns up to count most recent open interest
+// events across venues, newest first
+func (c *Client) RecentOpenInterestEvents(ctx context.Context, count int64) ([]*mdtypes.Event, error) {
+	msgs, err := c.rdb.XRevRangeN(ctx, keyspace.Key(keyspace.OpenInterestEventsKey), "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*mdtypes.Event, 0, len(msgs))
+	for _, msg := range msgs {
+		var event mdtypes.Event
+		if err := decodeStreamData(msg, &event); err != nil {
+			return nil, err
+		}
+		result = append(result, &event)
+	}
+	return result, nil
+}
+
+// SubscribeOrderbooks streams real-time orderbooks until ctx is cancelled
+func (c *Client) SubscribeOrderbooks(ctx context.Context, exchange mdtypes.ExchangeID, symbol string) <-chan *mdtypes.Orderbook {
+	out := make(chan *mdtypes.Orderbook, 64)
+	go subscribe(ctx, c.rdb, keyspace.OrderbookKey(string(exchange), symbol), out)
+	return out
+}
+
+// SubscribeFundingRates streams real-time funding rates of a venue symbol
+// until ctx is cancelled
+func (c *Client) SubscribeFundingRates(ctx context.Context, exchange mdtypes.ExchangeID, symbol string) <-chan *mdtypes.FundingRate {
+	out := make(chan *mdtypes.FundingRate, 64)
+	go subscribe(ctx, c.rdb, keyspace.FundingKey(string(exchange), symbol), out)
+	return out
+}
+
+// SubscribeSpreads streams real-time spreads for a spread ID or canonical symbol
+func (c *Client) SubscribeSpreads(ctx context.Context, idOrCanonical string) <-chan *mdtypes.SpreadOpportunity {
+	out := make(chan *mdtypes.SpreadOpportunity, 64)
+	go subscribe(ctx, c.rdb, keyspace.SpreadChannel(idOrCanonical), out)
+	return out
+}
+
+// SubscribeSummary streams the periodic top spreads summary
+func (c *Client) SubscribeSummary(ctx context.Context) <-chan *mdtypes.SpreadSummary {
+	out := make(chan *mdtypes.SpreadSummary, 8)
+	go subscribe(ctx, c.rdb, keyspace.Key(keyspace.SpreadsSummaryChan), out)
+	return out
+}
+
+// SubscribeSpreadClusters streams spread clusters as they are recomputed
+func (c *Client) SubscribeSpreadClusters(ctx context.Context) <-chan *mdtypes.SpreadCorrelation {
+	out := make(chan *mdtypes.SpreadCorrelation, 1)
+	go subscribe(ctx, c.rdb, keyspace.Key(keyspace.HistoryClustersKey), out)
+	return out
+}
+
+// SubscribeTenantSpreads streams a tenant's periodic spreads summary
+func (c *Client) SubscribeTenantSpreads(ctx context.Context, tenant string) <-chan *mdtypes.TenantSpreadSummary {
+	out := make(chan *mdtypes.TenantSpreadSummary, 8)
+	go subscribe(ctx, c.rdb, keyspace.TenantSpreadsKey(tenant), out)
+	return out
+}
+
+// SubscribeIndex streams index price updates for a canonical symbol
+func (c *Client) SubscribeIndex(ctx context.Context, canonical string) <-chan *mdtypes.IndexPrice {
+	out := make(chan *mdtypes.IndexPrice, 16)
+	go subscribe(ctx, c.rdb, keyspace.IndexKey(canonical), out)
+	return out
+}
+
+// SubscribeBars streams a venue symbol's bars as they close
+func (c *Client) SubscribeBars(ctx context.Context, exchange mdtypes.ExchangeID, symbol, interval string) <-chan *mdtypes.Bar {
+	out := make(chan *mdtypes.Bar, 64)
+	go subscribe(ctx, c.rdb, keyspace.BarsKey(string(exchange), symbol, interval), out)
+	return out
+}
+
+// SubscribeConsolidatedBars streams a canonical symbol's consolidated bars as they close
+func (c *Client) SubscribeConsolidatedBars(ctx context.Context, canonical, interval string) <-chan *mdtypes.Bar {
+	out := make(chan *mdtypes.Bar, 64)
+	go subscribe(ctx, c.rdb, keyspace.ConsolidatedBarsKey(canonical, interval), out)
+	return out
+}
+
+// SubscribeOpenInterestEvents streams open interest builds and drops as they are detected
+func (c *Client) SubscribeOpenInterestEvents(ctx context.Context) <-chan *mdtypes.Event {
+	out := make(chan *mdtypes.Event, 64)
+	go subscribe(ctx, c.rdb, keyspace.Key(keyspace.OpenInterestEventsKey), out)
+	return out
+}
+
+// SubscribeFundingSettlements streams funding settlements as they become
+// upcoming, imminent and settled
+func (c *Client) SubscribeFundingSettlements(ctx context.Context) <-chan *mdtypes.Settlement {
+	out := make(chan *mdtypes.Settlement, 64)
+	go subscribe(ctx, c.rdb, keyspace.Key(keyspace.FundingSettlementsKey), out)
+	return out
+}
+
+// SubscribeFundingActions streams pre-settlement actions for executors to
+// apply to the paying leg
+func (c *Client) SubscribeFundingActions(ctx context.Context) <-chan *mdtypes.Action {
+	out := make(chan *mdtypes.Action, 64)
+	go subscribe(ctx, c.rdb, keyspace.Key(keyspace.FundingActionsKey), out)
+	return out
+}
+
+// SubscribeSymbolStatus streams symbol status transitions so executors can
+// stop opening positions on contracts in maintenance, reduce-only or settlement
+func (c *Client) SubscribeSymbolStatus(ctx context.Context) <-chan *mdtypes.Transition {
+	out := make(chan *mdtypes.Transition, 64)
+	go subscribe(ctx, c.rdb, keyspace.Key(keyspace.SymbolStatusEventsKey), out)
+	return out
+}
+
+func (c *Client) getJSON(ctx context.Context, key string, v interface{}) error {
+	data, err := c.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeStreamData decodes the JSON payload stored in a stream entry's data field
+func decodeStreamData(msg redis.XMessage, v interface{}) error {
+	raw, ok := msg.Values["data"].(string)
+	if !ok {
+		return fmt.Errorf("stream entry %s has no data field", msg.ID)
+	}
+	return json.Unmarshal([]byte(raw), v)
+}
+
+// subscribe decodes Pub/Sub messages into out, dropping undecodable payloads
+func subscribe[T any](ctx context.Context, rdb *redis.Client, channel string, out chan<- *T) {
+	defer close(out)
+
+	sub := rdb.Subscribe(ctx, channel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var v T
+			if err := json.Unmarshal([]byte(msg.Payload), &v); err != nil {
+				continue
+			}
+			select {
+			case out <- &v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package mdclient is a typed client for the Redis keys and channels written by
+// md-ingest. Key names come from internal/keyspace and payloads are the
+// pkg/mdtypes types the service marshals, so consumers never hand-roll
+// channel names. The package links none of the service itself.
+package mdclient
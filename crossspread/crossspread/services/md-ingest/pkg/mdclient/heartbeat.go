@@ -0,0 +1,129 @@
+package mdclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"crossspread-md-ingest/internal/keyspace"
+	"crossspread-md-ingest/pkg/mdtypes"
+)
+
+// SubscribeHeartbeats streams the producer's heartbeats for a channel,
+// given by its full name (keyspace.OrderbookKey and friends). Feed them,
+// and the channel's messages, to a ChannelMonitor.
+func (c *Client) SubscribeHeartbeats(ctx context.Context, channel string) <-chan *mdtypes.Heartbeat {
+	out := make(chan *mdtypes.Heartbeat, 16)
+	go subscribe(ctx, c.rdb, keyspace.HeartbeatKey(channel), out)
+	return out
+}
+
+// Lag returns how long a heartbeat took to arrive. It includes any skew
+// between the producer's clock and the consumer's.
+func Lag(hb *mdtypes.Heartbeat, received time.Time) time.Duration {
+	return received.Sub(hb.Timestamp)
+}
+
+// Channel states
+const (
+	ChannelWaiting = "waiting" // No heartbeat yet
+	ChannelLive    = "live"    // Messages arriving
+	ChannelQuiet   = "quiet"   // Heartbeats but no recent messages: no market activity
+	ChannelDead    = "dead"    // Heartbeats stopped: producer down or partitioned
+)
+
+// ChannelStatus is a consumer's view of one channel
+type ChannelStatus struct {
+	Channel       string        `json:"channel"`
+	State         string        `json:"state"`
+	Lag           time.Duration `json:"lag"` // Of the last heartbeat
+	Received      uint64        `json:"received"`
+	Dropped       uint64        `json:"dropped"` // Published but not received, since the first heartbeat
+	LastMessage   time.Time     `json:"last_message,omitempty"`
+	LastHeartbeat time.Time     `json:"last_heartbeat,omitempty"`
+}
+
+// ChannelMonitor tells a quiet channel from a dead producer and counts
+// messages lost between the producer and this consumer. Call Message for
+// every message received on the channel and Heartbeat for every
+// heartbeat.
+type ChannelMonitor struct {
+	channel    string
+	quietAfter time.Duration
+
+	mu          sync.Mutex
+	last        *mdtypes.Heartbeat
+	lastAt      time.Time
+	baseSeq     uint64 // Seq of the heartbeat counting started at
+	counted     uint64 // Messages received since then
+	received    uint64
+	lastMessage time.Time
+	started     bool
+}
+
+// NewChannelMonitor creates a monitor calling a channel quiet once no
+// message arrived for quietAfter
+func NewChannelMonitor(channel string, quietAfter time.Duration) *ChannelMonitor {
+	return &ChannelMonitor{channel: channel, quietAfter: quietAfter}
+}
+
+// Message records a message received on the channel
+func (m *ChannelMonitor) Message(at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received++
+	m.lastMessage = at
+	if m.started {
+		m.counted++
+	}
+}
+
+// Heartbeat records a heartbeat received for the channel. A producer that
+// restarted, or a different producer, restarts the drop count.
+func (m *ChannelMonitor) Heartbeat(hb *mdtypes.Heartbeat, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.started || hb.Producer != m.last.Producer || hb.Seq < m.last.Seq {
+		m.started = true
+		m.baseSeq = hb.Seq
+		m.counted = 0
+	}
+	m.last = hb
+	m.lastAt = at
+}
+
+// Status returns the channel's state as of now
+func (m *ChannelMonitor) Status(now time.Time) ChannelStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := ChannelStatus{
+		Channel:       m.channel,
+		State:         ChannelWaiting,
+		Received:      m.received,
+		LastMessage:   m.lastMessage,
+		LastHeartbeat: m.lastAt,
+	}
+	if m.last == nil {
+		return s
+	}
+	s.Lag = Lag(m.last, m.lastAt)
+	// Messages and heartbeats travel on separate connections, so a few may
+	// be in flight either side of a heartbeat
+	if published := m.last.Seq - m.baseSeq; published > m.counted {
+		s.Dropped = published - m.counted
+	}
+
+	interval := time.Duration(m.last.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	switch {
+	case now.Sub(m.lastAt) > 3*interval:
+		s.State = ChannelDead
+	case now.Sub(m.lastMessage) > m.quietAfter:
+		s.State = ChannelQuiet
+	default:
+		s.State = ChannelLive
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package mdtypes
+
+import "time"
+
+// Settlement is a funding settlement of one contract on one venue
+type Settlement struct {
+	Exchange      ExchangeID `json:"exchange"`
+	Symbol        string     `json:"symbol"`
+	Canonical     string     `json:"canonical"`
+	Stage         string     `json:"stage"`          // upcoming, imminent, settled
+	PredictedRate float64    `json:"predicted_rate"` // Latest rate before settlement
+	SettlesAt     time.Time  `json:"settles_at"`
+	IntervalHours int        `json:"interval_hours,omitempty"`
+	At            time.Time  `json:"at"`
+}
+
+// Action asks executors to act on the leg paying an imminent settlement.
+// Longs pay positive rates and shorts pay negative ones.
+type Action struct {
+	Exchange      ExchangeID `json:"exchange"`
+	Symbol        string     `json:"symbol"`
+	Canonical     string     `json:"canonical"`
+	Kind          string     `json:"kind"` // reduce, flip
+	Fraction      float64    `json:"fraction,omitempty"`
+	PayingSide    string     `json:"paying_side"`        // long, short
+	Position      float64    `json:"position,omitempty"` // Signed base units held on the venue, when known
+	PredictedRate float64    `json:"predicted_rate"`
+	SettlesAt     time.Time  `json:"settles_at"`
+	At            time.Time  `json:"at"`
+}
+
+// IndexConstituent is one venue's contribution to an index price
+type IndexConstituent struct {
+	Exchange     ExchangeID `json:"exchange"`
+	Price        float64    `json:"price"`         // Mid price on the venue
+	Volume24h    float64    `json:"volume_24h"`    // 24h volume used as weight
+	Weight       float64    `json:"weight"`        // Normalized weight, 0 if excluded
+	DeviationBps float64    `json:"deviation_bps"` // (price - index) / index * 10000
+	Included     bool       `json:"included"`      // Contributed to the index
+	Stale        bool       `json:"stale"`         // Quote older than the stale threshold
+	Outlier      bool       `json:"outlier"`       // |deviation| above the outlier threshold
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IndexPrice is the reference price for a canonical symbol
+type IndexPrice struct {
+	Canonical    string             `json:"canonical"`
+	Price        float64            `json:"price"`        // Volume-weighted median of included mids
+	Included     int                `json:"included"`     // Number of venues in the index
+	Constituents []IndexConstituent `json:"constituents"` // Every venue quoting the symbol
+	Timestamp    time.Time          `json:"timestamp"`
+}
+
+// Event is an abnormal change in a contract's open interest over the window
+type Event struct {
+	Exchange     ExchangeID `json:"exchange"`
+	Symbol       string     `json:"symbol"`
+	Canonical    string     `json:"canonical"`
+	Kind         string     `json:"kind"` // build, drop
+	OpenInterest float64    `json:"open_interest"`
+	Previous     float64    `json:"previous"`   // Open interest at the start of the window
+	ChangePct    float64    `json:"change_pct"` // Signed
+	Window       string     `json:"window"`     // Time between the two samples, e.g. 14m30s
+	At           time.Time  `json:"at"`
+}
+
+// Transition is a change of a symbol's effective status. An empty Symbol
+// covers every symbol of the exchange (exchange-wide windows).
+type Transition struct {
+	Exchange  ExchangeID `json:"exchange"`
+	Symbol    string     `json:"symbol"`
+	Canonical string     `json:"canonical,omitempty"`
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Source    string     `json:"source"` // venue or window
+	Reason    string     `json:"reason,omitempty"`
+	At        time.Time  `json:"at"`
+}
+
+// Bar is an OHLCV bar built from the trade stream. Consolidated bars cover
+// every venue's trades of a canonical symbol and have no exchange or symbol.
+type Bar struct {
+	Exchange    ExchangeID `json:"exchange,omitempty"`
+	Symbol      string     `json:"symbol,omitempty"`
+	Canonical   string     `json:"canonical"`
+	Interval    string     `json:"interval"` // 1s, 1m
+	Open        float64    `json:"open"`
+	High        float64    `json:"high"`
+	Low         float64    `json:"low"`
+	Close       float64    `json:"close"`
+	Volume      float64    `json:"volume"`       // Base units, contracts converted with the contract size
+	QuoteVolume float64    `json:"quote_volume"` // Sum of price * volume
+	BuyVolume   float64    `json:"buy_volume"`   // Volume of taker buys
+	VWAP        float64    `json:"vwap"`
+	Trades      int        `json:"trades"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+}
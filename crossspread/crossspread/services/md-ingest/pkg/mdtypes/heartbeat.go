@@ -0,0 +1,17 @@
+package mdtypes
+
+import "time"
+
+// Heartbeat is published on heartbeat:{channel} every interval. A consumer
+// receiving heartbeats but no messages is watching a quiet market; one
+// receiving neither has lost the producer. Seq moving by more than the
+// messages received between two heartbeats means messages were dropped.
+type Heartbeat struct {
+	Channel         string    `json:"channel"`
+	Producer        string    `json:"producer,omitempty"`
+	Seq             uint64    `json:"seq"`  // Messages published on the channel since the producer started
+	Beat            uint64    `json:"beat"` // Heartbeats sent by the producer, across channels
+	IntervalMs      int64     `json:"interval_ms"`
+	LastPublishedAt time.Time `json:"last_published_at,omitempty"`
+	Timestamp       time.Time `json:"timestamp"` // Producer clock when sent
+}
@@ -0,0 +1,34 @@
+package mdtypes
+
+import "time"
+
+// SpreadCluster is a group of exchange pairs whose spreads move together,
+// so opportunities on them are not independent
+type SpreadCluster struct {
+	Pairs          []string `json:"pairs"`             // long:short, strongest linked first
+	Common         []string `json:"common,omitempty"`  // Venues on a leg of every pair, e.g. one that lags everywhere
+	AvgCorrelation float64  `json:"avg_correlation"`   // Mean correlation between member pairs
+	MinCorrelation float64  `json:"min_correlation"`   // Weakest link between member pairs
+	Episodes       int64    `json:"episodes"`          // Opportunities on member pairs
+	AvgPeakBps     float64  `json:"avg_peak_bps"`      // Mean peak of those opportunities
+	Symbols        []string `json:"symbols,omitempty"` // Canonical symbols most often involved
+}
+
+// PairCorrelation is one entry of the correlation matrix
+type PairCorrelation struct {
+	A           string  `json:"a"` // long:short
+	B           string  `json:"b"`
+	Correlation float64 `json:"correlation"` // Pearson, -1 to 1
+}
+
+// SpreadCorrelation is the correlation matrix of exchange pairs' spread
+// activity over a period and the clusters found in it
+type SpreadCorrelation struct {
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Bucket       string            `json:"bucket"`
+	Pairs        []string          `json:"pairs"`        // long:short pairs analysed
+	Correlations []PairCorrelation `json:"correlations"` // Upper triangle of the matrix, strongest first
+	Clusters     []SpreadCluster   `json:"clusters"`
+	GeneratedAt  time.Time         `json:"generated_at"`
+}
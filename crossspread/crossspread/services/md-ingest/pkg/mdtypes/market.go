@@ -0,0 +1,94 @@
+// Package mdtypes defines the payloads md-ingest publishes. It has no
+// dependencies outside the standard library, so clients can decode the
+// payloads without linking the service.
+package mdtypes
+
+import "time"
+
+// ExchangeID represents supported exchange identifiers
+type ExchangeID string
+
+// PriceLevel represents a single level in the orderbook
+type PriceLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+
+	// Set by the publisher: quantity in base units times the notional price
+	NotionalUSD float64 `json:"notional_usd,omitempty"`
+}
+
+// Orderbook represents an L2 orderbook snapshot or update
+type Orderbook struct {
+	ExchangeID ExchangeID   `json:"exchange_id"`
+	Symbol     string       `json:"symbol"`           // Exchange-native symbol
+	Canonical  string       `json:"canonical"`        // Normalized symbol
+	Market     string       `json:"market,omitempty"` // MarketSpot, or empty for perpetuals
+	Bids       []PriceLevel `json:"bids"`             // Sorted desc by price
+	Asks       []PriceLevel `json:"asks"`             // Sorted asc by price
+	BestBid    float64      `json:"best_bid"`
+	BestAsk    float64      `json:"best_ask"`
+	SpreadBps  float64      `json:"spread_bps"`
+	Timestamp  time.Time    `json:"timestamp"`
+	SequenceID int64        `json:"sequence_id,omitempty"`
+	IsSnapshot bool         `json:"is_snapshot"`
+
+	// Built from a REST ticker's best bid/ask during cold start, not a depth
+	// snapshot; replaced by the first real book
+	Approximate bool `json:"approximate,omitempty"`
+
+	// Price bucket the book was merged into; zero is full precision
+	Aggregation float64 `json:"aggregation,omitempty"`
+
+	// Pipeline stamps for end-to-end latency tracking
+	ReceivedAt   time.Time `json:"received_at"`   // Frame read from the socket
+	NormalizedAt time.Time `json:"normalized_at"` // Parsed into this struct and emitted
+	PublishedAt  time.Time `json:"published_at"`  // Handed to Redis
+}
+
+// Clone returns a copy of the book that shares no level slices with ob
+func (ob *Orderbook) Clone() *Orderbook {
+	cp := *ob
+	cp.Bids = append([]PriceLevel(nil), ob.Bids...)
+	cp.Asks = append([]PriceLevel(nil), ob.Asks...)
+	return &cp
+}
+
+// Trade represents a single trade event
+type Trade struct {
+	ExchangeID ExchangeID `json:"exchange_id"`
+	Symbol     string     `json:"symbol"`
+	Canonical  string     `json:"canonical"`
+	TradeID    string     `json:"trade_id"`
+	Price      float64    `json:"price"`
+	Quantity   float64    `json:"quantity"`
+	Side       string     `json:"side"` // Taker side: "buy", "sell" or "" if unknown
+	Timestamp  time.Time  `json:"timestamp"`
+
+	// Set by the publisher: quantity in base units times the notional price
+	NotionalUSD float64 `json:"notional_usd,omitempty"`
+
+	// Side was inferred from the book or the previous trade, not sent by the venue
+	SideInferred bool `json:"side_inferred,omitempty"`
+
+	// Pipeline stamps for end-to-end latency tracking
+	ReceivedAt   time.Time `json:"received_at"`
+	NormalizedAt time.Time `json:"normalized_at"`
+	PublishedAt  time.Time `json:"published_at"`
+}
+
+// FundingRate represents funding rate info for perpetuals
+type FundingRate struct {
+	ExchangeID           ExchangeID `json:"exchange_id"`
+	Symbol               string     `json:"symbol"`
+	Canonical            string     `json:"canonical"`
+	FundingRate          float64    `json:"funding_rate"`
+	MarkPrice            float64    `json:"mark_price,omitempty"` // Set by venues returning it with the rate
+	NextFundingTime      time.Time  `json:"next_funding_time"`
+	FundingIntervalHours int        `json:"funding_interval_hours"`
+	Timestamp            time.Time  `json:"timestamp"`
+
+	// Stamped before publishing from the resolved interval
+	IntervalSource string  `json:"interval_source,omitempty"` // rule, metadata, observed, venue, reported or default
+	FundingRate8h  float64 `json:"funding_rate_8h"`           // Rate scaled to an 8h interval
+	FundingAPR     float64 `json:"funding_apr"`               // Rate annualized, as a fraction
+}
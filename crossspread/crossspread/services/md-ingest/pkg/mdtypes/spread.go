@@ -0,0 +1,86 @@
+package mdtypes
+
+import "time"
+
+// Leg is one order of an opportunity
+type Leg struct {
+	Exchange ExchangeID `json:"exchange"`
+	Symbol   string     `json:"symbol"`            // Exchange-native instrument
+	Market   string     `json:"market"`            // perpetual, spot, future
+	Side     string     `json:"side"`              // buy, sell
+	Price    float64    `json:"price"`             // Best ask when buying, best bid when selling
+	Size     float64    `json:"size"`              // Quantity at that price, in the book's units
+	DepthUSD float64    `json:"depth_usd"`         // Top 5 levels on the side taken
+	Funding  float64    `json:"funding,omitempty"` // Funding rate per interval; perpetuals only
+	// Base units per quoted unit on venues quoting a multiple (1000 for
+	// 1000PEPEUSDT); Price and Size are then in base units. Zero is 1.
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Quote currency of the venue's contract when it differs from the
+	// canonical's: USDC on Deribit's linear perpetuals matched as USDT
+	Quote string `json:"quote,omitempty"`
+}
+
+// SpreadOpportunity represents an arbitrage spread opportunity
+type SpreadOpportunity struct {
+	ID             string     `json:"id"`
+	Canonical      string     `json:"canonical"`      // e.g., "BTC"
+	Kind           string     `json:"kind"`           // cross_venue
+	Legs           []Leg      `json:"legs"`           // Buy leg then sell leg; the long/short fields repeat them
+	LongExchange   ExchangeID `json:"long_exchange"`  // Exchange to buy
+	ShortExchange  ExchangeID `json:"short_exchange"` // Exchange to sell
+	LongSymbol     string     `json:"long_symbol"`
+	ShortSymbol    string     `json:"short_symbol"`
+	LongPrice      float64    `json:"long_price"`                 // Best ask on long exchange
+	ShortPrice     float64    `json:"short_price"`                // Best bid on short exchange
+	SpreadPercent  float64    `json:"spread_percent"`             // (short - long) / long * 100
+	SpreadBps      float64    `json:"spread_bps"`                 // Spread in basis points
+	SizedSpreadBps float64    `json:"sized_spread_bps,omitempty"` // Spread filling the economics notional through both books
+	LongFunding    float64    `json:"long_funding"`               // Funding rate on long
+	ShortFunding   float64    `json:"short_funding"`              // Funding rate on short
+	NetFunding     float64    `json:"net_funding"`                // short_funding - long_funding
+	LongDepthUSD   float64    `json:"long_depth_usd"`             // Top 5 levels depth
+	ShortDepthUSD  float64    `json:"short_depth_usd"`            // Top 5 levels depth
+	MinDepthUSD    float64    `json:"min_depth_usd"`              // Min of both sides
+	Volume24h      float64    `json:"volume_24h"`                 // Combined volume
+	Score          float64    `json:"score"`                      // Opportunity score
+	Quality        float64    `json:"quality"`                    // Lower leg's data quality, 1 when untagged
+	QuoteOnly      bool       `json:"quote_only"`                 // A leg is on a venue without a trading client
+	LatencyMs      float64    `json:"latency_ms"`                 // Worst leg's exchange-event-to-computation latency
+	BreakevenBps   float64    `json:"breakeven_bps"`              // Spread needed to cover fees, transfers and funding
+	NetEdgeBps     float64    `json:"net_edge_bps"`               // spread_bps - breakeven_bps
+	Profitable     bool       `json:"profitable"`                 // Spread exceeds breakeven after costs
+	Tags           []string   `json:"tags,omitempty"`             // e.g. new_listing; executors may size tagged spreads differently
+	SkewUSD        float64    `json:"skew_usd,omitempty"`         // Existing inventory the trade adds to; negative if it unwinds
+	UpdatedAt      time.Time  `json:"updated_at"`
+	EventTime      time.Time  `json:"event_time"` // Exchange time of the newer leg's quote; history aggregates by it
+
+	// Stamped when published: each leg's quote age and net_edge_bps decayed by the older one
+	LongQuoteAgeMs   float64 `json:"long_quote_age_ms"`
+	ShortQuoteAgeMs  float64 `json:"short_quote_age_ms"`
+	EffectiveEdgeBps float64 `json:"effective_edge_bps"`
+	// Stamped when published: p90 time to move the settle asset between the
+	// legs' venues, the slower way, from observed transfers where seen
+	RebalanceSeconds float64 `json:"rebalance_seconds,omitempty"`
+
+	// Exchange times of each leg's quote, for the publisher to age them
+	LongQuoteAt  time.Time `json:"-"`
+	ShortQuoteAt time.Time `json:"-"`
+}
+
+// SpreadSummary is the periodic summary of the current top spreads
+type SpreadSummary struct {
+	Timestamp time.Time            `json:"timestamp"`
+	Count     int                  `json:"count"`
+	Top10     []*SpreadOpportunity `json:"top_10"`
+	Spreads   []*SpreadOpportunity `json:"spreads"`
+}
+
+// TenantSpreadSummary is the spreads summary of one tenant, limited to
+// spreads it can trade on both legs
+type TenantSpreadSummary struct {
+	Tenant    string               `json:"tenant"`
+	Timestamp time.Time            `json:"timestamp"`
+	Count     int                  `json:"count"`
+	Top10     []*SpreadOpportunity `json:"top_10"`
+	Spreads   []*SpreadOpportunity `json:"spreads"`
+}
@@ -0,0 +1,767 @@
+# Code generated by md-ingest cmd/mdschema (schema v1). DO NOT EDIT.
+from __future__ import annotations
+
+from datetime import datetime
+from typing import Any, Dict, List, Optional
+
+from pydantic import BaseModel
+
+SCHEMA_VERSION = 1
+
+
+class Action(BaseModel):
+    exchange: str
+    symbol: str
+    canonical: str
+    kind: str
+    fraction: Optional[float] = None
+    paying_side: str
+    position: Optional[float] = None
+    predicted_rate: float
+    settles_at: datetime
+    at: datetime
+
+
+class Alert(BaseModel):
+    exchange: str
+    channel: str
+    kind: str
+    rate: float
+    baseline: float
+    symbols: Optional[int] = None
+    since: datetime
+    at: datetime
+
+
+class BBO(BaseModel):
+    exchange: str
+    symbol: str
+    canonical: str
+    bid_price: float
+    bid_size: float
+    ask_price: float
+    ask_size: float
+    timestamp: datetime
+    received_at: datetime
+    published_at: datetime
+
+
+class Bar(BaseModel):
+    exchange: Optional[str] = None
+    symbol: Optional[str] = None
+    canonical: str
+    interval: str
+    open: float
+    high: float
+    low: float
+    close: float
+    volume: float
+    quote_volume: float
+    buy_volume: float
+    vwap: float
+    trades: int
+    start: datetime
+    end: datetime
+
+
+class Leg(BaseModel):
+    exchange: str
+    symbol: str
+    market: str
+    side: str
+    price: float
+    size: float
+    depth_usd: float
+    funding: Optional[float] = None
+    multiplier: Optional[float] = None
+    quote: Optional[str] = None
+
+
+class BasisOpportunity(BaseModel):
+    id: str
+    canonical: str
+    exchange: str
+    direction: str
+    kind: str
+    legs: List[Leg]
+    spot_symbol: str
+    perp_symbol: str
+    spot_price: float
+    perp_price: float
+    basis_bps: float
+    funding: float
+    spot_depth_usd: float
+    perp_depth_usd: float
+    min_depth_usd: float
+    breakeven_bps: float
+    net_edge_bps: float
+    profitable: bool
+    updated_at: datetime
+    event_time: datetime
+
+
+class BasisSummary(BaseModel):
+    timestamp: datetime
+    count: int
+    basis: List[BasisOpportunity]
+
+
+class DeRiskState(BaseModel):
+    active: bool
+    action: Optional[str] = None
+    size_factor: float
+    drawdown_usd: Optional[float] = None
+    peak_usd: Optional[float] = None
+    pnl_usd: Optional[float] = None
+    strategies: Optional[Dict[str, Any]] = None
+    tripped_at: Optional[datetime] = None
+    acknowledged_by: Optional[str] = None
+    acknowledged_at: Optional[datetime] = None
+
+
+class Filter(BaseModel):
+    events: Optional[List[str]] = None
+    canonicals: Optional[List[str]] = None
+    exchanges: Optional[List[str]] = None
+    min_spread_bps: Optional[float] = None
+    min_net_edge_bps: Optional[float] = None
+    profitable_only: Optional[bool] = None
+
+
+class Endpoint(BaseModel):
+    id: str
+    url: str
+    secret: Optional[str] = None
+    filter: Filter
+    created_by: Optional[str] = None
+    created_at: datetime
+
+
+class ThresholdTime(BaseModel):
+    bps: float
+    ms: int
+
+
+class Episode(BaseModel):
+    id: str
+    canonical: str
+    long_exchange: str
+    short_exchange: str
+    start: datetime
+    duration_ms: int
+    peak_bps: float
+    min_after_peak_bps: float
+    above_ms: List[ThresholdTime]
+
+
+class Event(BaseModel):
+    exchange: str
+    symbol: str
+    canonical: str
+    kind: str
+    open_interest: float
+    previous: float
+    change_pct: float
+    window: str
+    at: datetime
+
+
+class FundingRate(BaseModel):
+    exchange_id: str
+    symbol: str
+    canonical: str
+    funding_rate: float
+    mark_price: Optional[float] = None
+    next_funding_time: datetime
+    funding_interval_hours: int
+    timestamp: datetime
+    interval_source: Optional[str] = None
+    funding_rate_8h: float
+    funding_apr: float
+
+
+class Health(BaseModel):
+    exchange: str
+    state: str
+    reason: Optional[str] = None
+    samples: int
+    error_rate: float
+    p90_latency_ms: float
+    rate_factor: float
+    quoting_allowed: bool
+    since: datetime
+    updated_at: datetime
+
+
+class Heartbeat(BaseModel):
+    channel: str
+    producer: Optional[str] = None
+    seq: int
+    beat: int
+    interval_ms: int
+    last_published_at: Optional[datetime] = None
+    timestamp: datetime
+
+
+class IndexConstituent(BaseModel):
+    exchange: str
+    price: float
+    volume_24h: float
+    weight: float
+    deviation_bps: float
+    included: bool
+    stale: bool
+    outlier: bool
+    updated_at: datetime
+
+
+class IndexPrice(BaseModel):
+    canonical: str
+    price: float
+    included: int
+    constituents: List[IndexConstituent]
+    timestamp: datetime
+
+
+class MigrationFlag(BaseModel):
+    id: str
+    canonical: str
+    side: str
+    intended: str
+    actual: str
+    symbol: str
+    quantity: float
+    avg_price: float
+    at: datetime
+
+
+class PriceLevel(BaseModel):
+    price: float
+    quantity: float
+    notional_usd: Optional[float] = None
+
+
+class Orderbook(BaseModel):
+    exchange_id: str
+    symbol: str
+    canonical: str
+    market: Optional[str] = None
+    bids: List[PriceLevel]
+    asks: List[PriceLevel]
+    best_bid: float
+    best_ask: float
+    spread_bps: float
+    timestamp: datetime
+    sequence_id: Optional[int] = None
+    is_snapshot: bool
+    approximate: Optional[bool] = None
+    aggregation: Optional[float] = None
+    received_at: datetime
+    normalized_at: datetime
+    published_at: datetime
+
+
+class PairCorrelation(BaseModel):
+    a: str
+    b: str
+    correlation: float
+
+
+class PairMute(BaseModel):
+    id: str
+    long: Optional[str] = None
+    short: Optional[str] = None
+    reason: Optional[str] = None
+    by: str
+    at: datetime
+    until: Optional[datetime] = None
+
+
+class Settlement(BaseModel):
+    exchange: str
+    symbol: str
+    canonical: str
+    stage: str
+    predicted_rate: float
+    settles_at: datetime
+    interval_hours: Optional[int] = None
+    at: datetime
+
+
+class SpreadCluster(BaseModel):
+    pairs: List[str]
+    common: Optional[List[str]] = None
+    avg_correlation: float
+    min_correlation: float
+    episodes: int
+    avg_peak_bps: float
+    symbols: Optional[List[str]] = None
+
+
+class SpreadCorrelation(BaseModel):
+    start: datetime
+    end: datetime
+    bucket: str
+    pairs: List[str]
+    correlations: List[PairCorrelation]
+    clusters: List[SpreadCluster]
+    generated_at: datetime
+
+
+class SpreadOpportunity(BaseModel):
+    id: str
+    canonical: str
+    kind: str
+    legs: List[Leg]
+    long_exchange: str
+    short_exchange: str
+    long_symbol: str
+    short_symbol: str
+    long_price: float
+    short_price: float
+    spread_percent: float
+    spread_bps: float
+    sized_spread_bps: Optional[float] = None
+    long_funding: float
+    short_funding: float
+    net_funding: float
+    long_depth_usd: float
+    short_depth_usd: float
+    min_depth_usd: float
+    volume_24h: float
+    score: float
+    quality: float
+    quote_only: bool
+    latency_ms: float
+    breakeven_bps: float
+    net_edge_bps: float
+    profitable: bool
+    tags: Optional[List[str]] = None
+    skew_usd: Optional[float] = None
+    updated_at: datetime
+    event_time: datetime
+    long_quote_age_ms: float
+    short_quote_age_ms: float
+    effective_edge_bps: float
+    rebalance_seconds: Optional[float] = None
+
+
+class SpreadSummary(BaseModel):
+    timestamp: datetime
+    count: int
+    top_10: List[SpreadOpportunity]
+    spreads: List[SpreadOpportunity]
+
+
+class Tag(BaseModel):
+    opportunity_id: str
+    canonical: str
+    exchanges: List[str]
+    reason: str
+    source: str
+    note: Optional[str] = None
+    at: datetime
+
+
+class TenantSpreadSummary(BaseModel):
+    tenant: str
+    timestamp: datetime
+    count: int
+    top_10: List[SpreadOpportunity]
+    spreads: List[SpreadOpportunity]
+
+
+class Trade(BaseModel):
+    exchange_id: str
+    symbol: str
+    canonical: str
+    trade_id: str
+    price: float
+    quantity: float
+    side: str
+    timestamp: datetime
+    notional_usd: Optional[float] = None
+    side_inferred: Optional[bool] = None
+    received_at: datetime
+    normalized_at: datetime
+    published_at: datetime
+
+
+class TransferSample(BaseModel):
+    id: str
+    seconds: float
+    at: datetime
+
+
+class TransferLatency(BaseModel):
+    asset: str
+    chain: str
+    from: Optional[str] = None
+    to: Optional[str] = None
+    samples: List[TransferSample]
+    p50_seconds: float
+    p90_seconds: float
+    max_seconds: float
+    updated_at: datetime
+
+
+class Transition(BaseModel):
+    exchange: str
+    symbol: str
+    canonical: Optional[str] = None
+    from: str
+    to: str
+    source: str
+    reason: Optional[str] = None
+    at: datetime
+
+
+class VenueEquity(BaseModel):
+    balance_usd: float
+    unrealized_pnl_usd: float
+    updated_at: datetime
+
+
+class VenueSubscriptions(BaseModel):
+    exchange: str
+    symbols: List[str]
+    channels: List[str]
+    updated_at: datetime
+
+
+# Key and channel names, within the namespace set by set_prefix
+_prefix = ""
+
+
+def set_prefix(prefix: str, instance: str = "default") -> None:
+    """Namespace every key and channel like md-ingest's REDIS_KEY_PREFIX: {instance} expands to the instance ID (INSTANCE_ID) and a missing trailing colon is added"""
+    global _prefix
+    prefix = prefix.replace("{instance}", instance)
+    if prefix and not prefix.endswith(":"):
+        prefix += ":"
+    _prefix = prefix
+
+
+def key(name: str) -> str:
+    """Return a key or channel name within the namespace"""
+    return _prefix + name
+
+
+def orderbook_stream(exchange: str, symbol: str) -> str:
+    """Orderbook snapshots/updates per exchange-native symbol (approximate trim); snapshots carry at most the configured publish depth (1/5/20/full) (stream, payload Orderbook)"""
+    return key(f"orderbook:{exchange}:{symbol}")
+
+
+def orderbook_channel(exchange: str, symbol: str) -> str:
+    """Real-time orderbook updates, same payload as the stream (pubsub, payload Orderbook)"""
+    return key(f"orderbook:{exchange}:{symbol}")
+
+
+def bbo_channel(exchange: str, symbol: str) -> str:
+    """Best bid and offer on every touch change, published only in partial book mode; books then follow at most every depth interval (pubsub, payload BBO)"""
+    return key(f"bbo:{exchange}:{symbol}")
+
+
+def heartbeat_channel(channel: str) -> str:
+    """Every second per published channel ({channel} unprefixed, e.g. heartbeat:orderbook:binance:BTCUSDT): messages published on it so far and the producer's clock, so a quiet market is told apart from a dead or partitioned producer (pubsub, payload Heartbeat)"""
+    return key(f"heartbeat:{channel}")
+
+
+def trades_stream(exchange: str, symbol: str) -> str:
+    """Public trades per exchange-native symbol (stream, payload Trade)"""
+    return key(f"trades:{exchange}:{symbol}")
+
+
+def funding_stream(exchange: str, symbol: str) -> str:
+    """Funding rate, next funding time and mark price (where the venue returns it) per exchange-native symbol, from WebSocket and REST polling (stream, payload FundingRate)"""
+    return key(f"funding:{exchange}:{symbol}")
+
+
+def funding_channel(exchange: str, symbol: str) -> str:
+    """Real-time funding rates, same payload as the stream (pubsub, payload FundingRate)"""
+    return key(f"funding:{exchange}:{symbol}")
+
+
+def spreads_stream() -> str:
+    """Historical spread opportunities (stream, payload SpreadOpportunity)"""
+    return key("spreads")
+
+
+def spread_data(spread_id: str) -> str:
+    """Latest state of a spread, keyed by canonical:long:short; the TTL follows the quote-age decay (SPREAD_TTL) less the older leg's quote age, this is the fallback (string, payload SpreadOpportunity)"""
+    return key(f"spread:data:{spread_id}")
+
+
+def spread_channel(spread_id: str) -> str:
+    """Real-time updates for a single spread ID (pubsub, payload SpreadOpportunity)"""
+    return key(f"spread:{spread_id}")
+
+
+def spread_canonical_channel(canonical: str) -> str:
+    """Real-time updates for every spread of a canonical symbol (pubsub, payload SpreadOpportunity)"""
+    return key(f"spread:{canonical}")
+
+
+def spreads_active() -> str:
+    """Set of spread IDs whose data key is live; IDs are removed as their data expires and the set expires once nothing is published (set, payload SpreadID)"""
+    return key("spreads:active")
+
+
+def spreads_list() -> str:
+    """Summary of the current top spreads (string, payload SpreadSummary)"""
+    return key("spreads:list")
+
+
+def spreads_summary_channel() -> str:
+    """Real-time summary of the current top spreads (pubsub, payload SpreadSummary)"""
+    return key("spreads:summary")
+
+
+def tenant_spreads(tenant: str) -> str:
+    """Summary of the current top spreads whose legs are both on venues the tenant has credentials for (string, payload TenantSpreadSummary)"""
+    return key(f"tenant:{tenant}:spreads")
+
+
+def tenant_spreads_channel(tenant: str) -> str:
+    """Real-time per-tenant spread summary, same payload as the key (pubsub, payload TenantSpreadSummary)"""
+    return key(f"tenant:{tenant}:spreads")
+
+
+def basis() -> str:
+    """Current spot-vs-perp basis opportunities within single venues, by net edge; written once spot books are ingested (string, payload BasisSummary)"""
+    return key("spreads:basis")
+
+
+def basis_channel() -> str:
+    """Real-time basis summary, same payload as the key (pubsub, payload BasisSummary)"""
+    return key("spreads:basis")
+
+
+def index_price(canonical: str) -> str:
+    """Volume-weighted median reference price with per-venue deviation (string, payload IndexPrice)"""
+    return key(f"index:{canonical}")
+
+
+def index_channel(canonical: str) -> str:
+    """Real-time index price updates, same payload as the key (pubsub, payload IndexPrice)"""
+    return key(f"index:{canonical}")
+
+
+def bars_stream(exchange: str, symbol: str, interval: str) -> str:
+    """OHLCV bars per exchange-native symbol built from the trade stream, one entry per closed bar (stream, payload Bar)"""
+    return key(f"bars:{exchange}:{symbol}:{interval}")
+
+
+def bars_channel(exchange: str, symbol: str, interval: str) -> str:
+    """Real-time closed bars, same payload as the stream (pubsub, payload Bar)"""
+    return key(f"bars:{exchange}:{symbol}:{interval}")
+
+
+def consolidated_bars_stream(canonical: str, interval: str) -> str:
+    """OHLCV bars per canonical symbol across every venue's trades; volume in base units (stream, payload Bar)"""
+    return key(f"bars:consolidated:{canonical}:{interval}")
+
+
+def consolidated_bars_channel(canonical: str, interval: str) -> str:
+    """Real-time closed consolidated bars, same payload as the stream (pubsub, payload Bar)"""
+    return key(f"bars:consolidated:{canonical}:{interval}")
+
+
+def oi_events_stream() -> str:
+    """Abnormal open interest builds and drops per exchange-native symbol (stream, payload OpenInterestEvent)"""
+    return key("oi:events")
+
+
+def oi_events_channel() -> str:
+    """Real-time open interest events, same payload as the stream (pubsub, payload OpenInterestEvent)"""
+    return key("oi:events")
+
+
+def funding_settlements_stream() -> str:
+    """Funding settlements per exchange-native symbol as they become upcoming, imminent and settled (stream, payload FundingSettlement)"""
+    return key("funding:settlements")
+
+
+def funding_settlements_channel() -> str:
+    """Real-time funding settlement events, same payload as the stream (pubsub, payload FundingSettlement)"""
+    return key("funding:settlements")
+
+
+def funding_actions_stream() -> str:
+    """Pre-settlement reduce or flip actions for legs paying an imminent funding settlement (stream, payload FundingAction)"""
+    return key("funding:actions")
+
+
+def funding_actions_channel() -> str:
+    """Real-time pre-settlement actions, same payload as the stream (pubsub, payload FundingAction)"""
+    return key("funding:actions")
+
+
+def symbol_status_stream() -> str:
+    """Symbol status transitions (trading, limit_open, delisting, settling, maintenance) from venue instrument lists and scheduled windows; an empty symbol covers the whole exchange (stream, payload SymbolStatusTransition)"""
+    return key("symbols:status")
+
+
+def symbol_status_channel() -> str:
+    """Real-time symbol status transitions, same payload as the stream (pubsub, payload SymbolStatusTransition)"""
+    return key("symbols:status")
+
+
+def feed_alerts_stream() -> str:
+    """Feeds (exchange and channel) whose message rate collapsed against their learned baseline while still connected, and their recoveries (stream, payload FeedAlert)"""
+    return key("feeds:alerts")
+
+
+def feed_alerts_channel() -> str:
+    """Real-time feed alerts, same payload as the stream (pubsub, payload FeedAlert)"""
+    return key("feeds:alerts")
+
+
+def false_positives_stream() -> str:
+    """Published opportunities tagged as false positives by executors or operators; the tags within a week lower the data-quality score of the venue symbols involved (stream, payload FalsePositiveTag)"""
+    return key("feedback:false_positives")
+
+
+def history_top(date: str) -> str:
+    """Spread IDs scored by peak spread bps for a UTC date (zset, payload SpreadID)"""
+    return key(f"history:top:{date}")
+
+
+def history_peak(date: str) -> str:
+    """Spread snapshot at its daily peak, field per spread ID (hash, payload SpreadOpportunity)"""
+    return key(f"history:peak:{date}")
+
+
+def history_distribution(date: str, long: str, short: str) -> str:
+    """Sampled spread bps histogram per exchange pair, field per bucket (hash, payload Counter)"""
+    return key(f"history:dist:{date}:{long}:{short}")
+
+
+def history_pairs(date: str) -> str:
+    """Exchange pairs (long:short) with distribution data for a date (set, payload ExchangePair)"""
+    return key(f"history:pairs:{date}")
+
+
+def history_persistence(date: str, canonical: str) -> str:
+    """Opportunity lifetime counters (episodes, total_ms, max_ms) per symbol (hash, payload Counter)"""
+    return key(f"history:persist:{date}:{canonical}")
+
+
+def history_symbols(date: str) -> str:
+    """Canonical symbols with persistence data for a date (set, payload Canonical)"""
+    return key(f"history:symbols:{date}")
+
+
+def history_episodes(date: str) -> str:
+    """Opportunity episodes (lifetime, peak, time above levels) that ended on a date (stream, payload Episode)"""
+    return key(f"history:episodes:{date}")
+
+
+def history_bars(exchange: str, symbol: str, interval: str) -> str:
+    """OHLCV bars scored by start time in ms, backfilled from venue klines at startup and extended by live bars (zset, payload Bar)"""
+    return key(f"history:bars:{exchange}:{symbol}:{interval}")
+
+
+def history_capture(date: str) -> str:
+    """Signal vs captured spread counters of executed opportunities, fields {pair:long:short|symbol:canonical}|{count,signal_bps,captured_bps} (hash, payload Counter)"""
+    return key(f"history:capture:{date}")
+
+
+def history_clusters() -> str:
+    """Correlation matrix of exchange pairs' spread activity and the clusters of pairs that move together, recomputed periodically (string, payload SpreadCorrelation)"""
+    return key("history:clusters")
+
+
+def history_clusters_channel() -> str:
+    """Spread clusters as they are recomputed, same payload as the key (pubsub, payload SpreadCorrelation)"""
+    return key("history:clusters")
+
+
+def rate_budget(exchange: str) -> str:
+    """Order rate token bucket (tokens, ts_ms) shared by every strategy trading the account; factor, set while the venue is degraded, scales its capacity and rate (hash, payload RateBudget)"""
+    return key(f"ratebudget:{exchange}")
+
+
+def venue_health() -> str:
+    """REST health per venue ({exchange} -> JSON); executors stop resting passive quotes on venues with quoting_allowed false (hash, payload VenueHealth)"""
+    return key("venues:health")
+
+
+def subscription_state() -> str:
+    """WebSocket subscription set per venue ({exchange} -> JSON), saved periodically; a restarted or failed-over instance resubscribes from it before the REST load (hash, payload VenueSubscriptions)"""
+    return key("subscriptions:state")
+
+
+def webhooks() -> str:
+    """Registered spread event webhooks ({id} -> JSON), including the secret each delivery is signed with (hash, payload WebhookEndpoint)"""
+    return key("webhooks")
+
+
+def flags(env: str) -> str:
+    """Feature flag overrides (flag name -> true/false) for an environment; unset flags use each service's default (hash, payload Flags)"""
+    return key(f"flags:{env}")
+
+
+def settings(env: str) -> str:
+    """Runtime parameter overrides (name -> number), e.g. spread.min_spread_bps or tier.major.min_depth_usd; unset parameters use the service default (hash, payload Settings)"""
+    return key(f"settings:{env}")
+
+
+def settings_mutes(env: str) -> str:
+    """Exchange pair mutes applied by every instance (mute ID -> JSON); expired mutes are removed by the watchers (hash, payload PairMute)"""
+    return key(f"settings:{env}:mutes")
+
+
+def settings_tiers(env: str) -> str:
+    """Tier of each canonical symbol (canonical -> tier name); a tier's thresholds are the tier.{name}.* settings (hash, payload SymbolTiers)"""
+    return key(f"settings:{env}:tiers")
+
+
+def settings_filter(env: str) -> str:
+    """Filter expression every opportunity must match to be published, e.g. spread_bps > 8 && long_exchange != "lbank"; unset publishes all (string, payload FilterExpression)"""
+    return key(f"settings:{env}:filter")
+
+
+def settings_changed_channel(env: str) -> str:
+    """Name of the settings section just written (params, mutes, tiers, filter); watchers reload within seconds without it (pubsub, payload SettingsSection)"""
+    return key(f"settings:{env}:changed")
+
+
+def claim(opportunity_id: str) -> str:
+    """Executor lease on a spread opportunity (token, owner, state, claimed_ms); expires unless renewed, held briefly once done (hash, payload Claim)"""
+    return key(f"claim:{opportunity_id}")
+
+
+def tenant_claim(tenant: str, opportunity_id: str) -> str:
+    """Executor lease on a spread opportunity for one tenant's account; same fields as claim (hash, payload Claim)"""
+    return key(f"claim:tenant:{tenant}:{opportunity_id}")
+
+
+def migrations() -> str:
+    """Positions hedged on a fallback venue (flag ID -> JSON), pending migration to the intended venue pair (hash, payload MigrationFlag)"""
+    return key("execution:migrations")
+
+
+def positions() -> str:
+    """Net perp position per venue ({exchange}:{canonical} -> signed base quantity), adjusted by executors on fills (hash, payload Positions)"""
+    return key("positions")
+
+
+def strategy_pnl() -> str:
+    """Cumulative realized plus unrealized PnL per strategy ({strategy} -> USD), written by executors; drives drawdown de-risking (hash, payload StrategyPnL)"""
+    return key("risk:pnl")
+
+
+def derisk() -> str:
+    """De-risking in force after a drawdown (halve sizes or halt entries); executors scale entries by size_factor until it is acknowledged (string, payload DeRiskState)"""
+    return key("risk:derisk")
+
+
+def venue_equity() -> str:
+    """Balance and unrealized PnL per venue ({exchange} -> JSON), written by executors; drives per-venue exposure caps (hash, payload VenueEquity)"""
+    return key("risk:equity")
+
+
+def transfer_latency() -> str:
+    """Observed deposit and withdrawal completion times per route ({asset}:{chain}:{from}:{to} -> JSON, empty from/to for untracked venues); the expected latency for rebalancing transfers (hash, payload TransferLatency)"""
+    return key("transfers:latency")